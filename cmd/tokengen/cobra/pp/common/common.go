@@ -14,7 +14,10 @@ import (
 	"strings"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
 	msp3 "github.com/hyperledger/fabric/msp"
 	"github.com/pkg/errors"
 )
@@ -63,6 +66,32 @@ func GetMSPIdentity(entry string, mspID string) (driver.Identity, error) {
 	return id, nil
 }
 
+// GetIssuerIdentity returns the issuer identity from the passed entry formatted as <MSPConfigPath>:<MSPID>.
+// If the folder contains a post-quantum key, then the post-quantum identity is returned,
+// or the hybrid identity if the folder contains also an X509 signing certificate.
+func GetIssuerIdentity(entry string) (driver.Identity, error) {
+	dir := strings.Split(entry, ":")[0]
+	if !pq.IsKeyDir(dir) {
+		return GetMSPIdentity(entry, msp.IssuerMSPID)
+	}
+	pqID, err := pq.LoadIdentity(dir)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load post-quantum identity for [%s]", entry)
+	}
+	if _, err := os.Stat(filepath.Join(dir, signcerts)); err != nil {
+		return pqID.Typed()
+	}
+	classical, err := GetMSPIdentity(entry, msp.IssuerMSPID)
+	if err != nil {
+		return nil, err
+	}
+	pqRaw, err := pqID.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to marshal post-quantum identity for [%s]", entry)
+	}
+	return (&hybrid.Identity{Classical: classical, PostQuantum: pqRaw}).Typed()
+}
+
 // SetupIssuersAndAuditors sets up the issuers and auditors for the given public parameters
 func SetupIssuersAndAuditors(pp PP, Auditors, Issuers []string) error {
 	// Auditors
@@ -75,7 +104,7 @@ func SetupIssuersAndAuditors(pp PP, Auditors, Issuers []string) error {
 	}
 	// Issuers
 	for _, issuer := range Issuers {
-		id, err := GetMSPIdentity(issuer, msp.IssuerMSPID)
+		id, err := GetIssuerIdentity(issuer)
 		if err != nil {
 			return errors.WithMessagef(err, "failed to get issuer identity [%s]", issuer)
 		}
@@ -84,6 +113,72 @@ func SetupIssuersAndAuditors(pp PP, Auditors, Issuers []string) error {
 	return nil
 }
 
+// PostQuantumCAs defines the public parameters that can list post-quantum CAs
+type PostQuantumCAs interface {
+	// AddPostQuantumCA adds a post-quantum CA to the public parameters
+	AddPostQuantumCA(raw driver.Identity)
+}
+
+// SetupPostQuantumCAs adds to the passed public parameters the post-quantum CAs whose public keys are stored in the passed folders
+func SetupPostQuantumCAs(pp PostQuantumCAs, dirs []string) error {
+	for _, dir := range dirs {
+		id, err := pq.LoadIdentity(dir)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to load post-quantum CA [%s]", dir)
+		}
+		raw, err := id.Bytes()
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal post-quantum CA [%s]", dir)
+		}
+		pp.AddPostQuantumCA(raw)
+	}
+	return nil
+}
+
+// FeeArgs describes the fee policy to set in the public parameters
+type FeeArgs struct {
+	// Kind is the kind of fee, either flat or proportional. If empty, transfers are free.
+	Kind string
+	// Amount is the flat fee, or the minimum fee if the fee is proportional
+	Amount uint64
+	// Rate is the fraction of the transferred value, in basis points, charged by a proportional fee
+	Rate uint64
+	// TokenType is the token type the fee must be paid with
+	TokenType string
+	// Collector is the MSP directory, formatted as <MSPConfigPath>:<MSPID>, containing the certificate of the fee collector
+	Collector string
+}
+
+// FeePolicy returns the fee policy described by the passed arguments, nil if no fee kind has been passed.
+// The fee collector is an X509 owner identity.
+func FeePolicy(args *FeeArgs) (*driver.FeePolicy, error) {
+	if len(args.Kind) == 0 {
+		return nil, nil
+	}
+	if len(args.Collector) == 0 {
+		return nil, errors.New("missing fee collector")
+	}
+	id, err := GetMSPIdentity(args.Collector, "")
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get fee collector identity [%s]", args.Collector)
+	}
+	collector, err := identity.WrapWithType(msp.X509Identity, id)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to wrap fee collector identity [%s]", args.Collector)
+	}
+	policy := &driver.FeePolicy{
+		Kind:      driver.FeeKind(args.Kind),
+		Amount:    args.Amount,
+		Rate:      args.Rate,
+		TokenType: args.TokenType,
+		Collector: collector,
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
 // ReadSingleCertificateFromFile reads the passed file and checks that it contains only one
 // certificate in the PEM format.
 // It returns an error if the file contains more than one certificate.
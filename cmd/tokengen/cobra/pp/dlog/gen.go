@@ -17,6 +17,7 @@ import (
 
 	math3 "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -38,6 +39,12 @@ type GeneratorArgs struct {
 	Exponent uint
 	// Aries is a flag to indicate that aries should be used as backend for idemix
 	Aries bool
+	// AuditorEncryption is a flag to indicate that an auditor encryption key should be generated
+	AuditorEncryption bool
+	// AsyncAuditing is a flag to indicate that token requests can be committed without the auditor's signature
+	AsyncAuditing bool
+	// Fee describes the fee to be paid on transfers
+	Fee common.FeeArgs
 }
 
 var (
@@ -59,6 +66,12 @@ var (
 	Exponent uint
 	// Aries is a flag to indicate that aries should be used as backend for idemix
 	Aries bool
+	// AuditorEncryption is a flag to indicate that an auditor encryption key should be generated
+	AuditorEncryption bool
+	// AsyncAuditing is a flag to indicate that token requests can be committed without the auditor's signature
+	AsyncAuditing bool
+	// Fee describes the fee to be paid on transfers
+	Fee common.FeeArgs
 )
 
 // Cmd returns the Cobra Command for Version
@@ -73,6 +86,13 @@ func Cmd() *cobra.Command {
 	flags.UintVarP(&Base, "base", "b", 100, "base is used to define the maximum quantity a token can contain as Base^Exponent")
 	flags.UintVarP(&Exponent, "exponent", "e", 2, "exponent is used to define the maximum quantity a token can contain as Base^Exponent")
 	flags.BoolVarP(&Aries, "aries", "r", false, "flag to indicate that aries should be used as backend for idemix")
+	flags.BoolVarP(&AuditorEncryption, "auditor-encryption", "", false, "generate an auditor encryption key, the secret key is stored in the output folder")
+	flags.BoolVarP(&AsyncAuditing, "async-auditing", "", false, "allow token requests to be committed without the auditor's signature")
+	flags.StringVarP(&Fee.Kind, "fee-kind", "", "", "kind of fee charged on transfers, either flat or proportional. If empty, transfers are free")
+	flags.Uint64VarP(&Fee.Amount, "fee-amount", "", 0, "flat fee, or minimum fee if the fee is proportional")
+	flags.Uint64VarP(&Fee.Rate, "fee-rate", "", 0, "fraction of the transferred value, in basis points, charged by a proportional fee")
+	flags.StringVarP(&Fee.TokenType, "fee-type", "", "", "token type the fee must be paid with")
+	flags.StringVarP(&Fee.Collector, "fee-collector", "", "", "MSP directory containing the certificate of the fee collector, formatted as <MSPConfigPath>:<MSPID>")
 
 	return cobraCommand
 }
@@ -96,6 +116,9 @@ var cobraCommand = &cobra.Command{
 			Base:              Base,
 			Exponent:          Exponent,
 			Aries:             Aries,
+			AuditorEncryption: AuditorEncryption,
+			AsyncAuditing:     AsyncAuditing,
+			Fee:               Fee,
 		})
 		if err != nil {
 			return errors.Wrap(err, "failed to generate public parameters")
@@ -136,6 +159,33 @@ func Gen(args *GeneratorArgs) ([]byte, error) {
 	if err := common.SetupIssuersAndAuditors(pp, args.Auditors, args.Issuers); err != nil {
 		return nil, err
 	}
+	pp.SetAsyncAuditing(args.AsyncAuditing)
+	fee, err := common.FeePolicy(&args.Fee)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid fee policy")
+	}
+	pp.SetFeePolicy(fee)
+	if args.AuditorEncryption {
+		sk, err := encryption.KeyGen(pp.PedersenGenerators, math3.Curves[pp.Curve])
+		if err != nil {
+			return nil, errors.Wrap(err, "failed generating auditor encryption key")
+		}
+		pp.SetAuditorEncryptionKey(sk.PK)
+		raw, err := sk.Serialize()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed serializing auditor encryption key")
+		}
+		path := filepath.Join(args.OutputDir, "auditor_encryption.key")
+		if err := os.WriteFile(path, raw, 0600); err != nil {
+			return nil, errors.Wrap(err, "failed writing auditor encryption key to file")
+		}
+	}
+	if args.AsyncAuditing && !args.AuditorEncryption {
+		return nil, errors.New("asynchronous auditing requires the auditor encryption key")
+	}
+	if err := pp.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "failed to validate public parameters")
+	}
 
 	// Store Public Params
 	raw, err := pp.Serialize()
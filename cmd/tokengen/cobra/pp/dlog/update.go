@@ -10,10 +10,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/cmd/tokengen/cobra/pp/common"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 )
@@ -21,6 +23,9 @@ import (
 // InputFile is the file that contains the public parameters
 var InputFile string
 
+// RotateAuditor is set to keep the replaced auditor in the history of the auditor keys
+var RotateAuditor bool
+
 type UpdateArgs struct {
 	// InputFile is the file that contains the public parameters
 	InputFile string
@@ -30,6 +35,9 @@ type UpdateArgs struct {
 	Issuers []string
 	// Auditors is the list of auditor MSP directories containing the corresponding auditor certificate
 	Auditors []string
+	// RotateAuditor is set to keep the replaced auditor in the history of the auditor keys,
+	// so that the token requests it signed can still be verified
+	RotateAuditor bool
 }
 
 // Cmd returns the Cobra Command for Version
@@ -40,6 +48,7 @@ func UpdateCmd() *cobra.Command {
 	flags.StringVarP(&OutputDir, "output", "o", ".", "output folder")
 	flags.StringSliceVarP(&Auditors, "auditors", "a", nil, "list of auditor MSP directories containing the corresponding auditor certificate")
 	flags.StringSliceVarP(&Issuers, "issuers", "s", nil, "list of issuer MSP directories containing the corresponding issuer certificate")
+	flags.BoolVarP(&RotateAuditor, "rotate-auditor", "r", false, "keep the replaced auditor in the history of the auditor keys")
 
 	return cmd
 }
@@ -55,10 +64,11 @@ var cmd = &cobra.Command{
 		// Parsing of the command line is done so silence cmd usage
 		cmd.SilenceUsage = true
 		err := Update(&UpdateArgs{
-			InputFile: InputFile,
-			OutputDir: OutputDir,
-			Issuers:   Issuers,
-			Auditors:  Auditors,
+			InputFile:     InputFile,
+			OutputDir:     OutputDir,
+			Issuers:       Issuers,
+			Auditors:      Auditors,
+			RotateAuditor: RotateAuditor,
 		})
 		if err != nil {
 			return errors.Wrap(err, "failed to generate public parameters")
@@ -84,13 +94,27 @@ func Update(args *UpdateArgs) error {
 
 	// Clear auditor and issuers if provided, and add them again.
 	// If not provided, do not change them.
-	if len(args.Auditors) > 0 {
+	auditors := args.Auditors
+	if len(auditors) > 0 && args.RotateAuditor {
+		if len(auditors) != 1 {
+			return errors.Errorf("expected a single auditor to rotate to, got [%d]", len(auditors))
+		}
+		id, err := common.GetMSPIdentity(auditors[0], msp.AuditorMSPID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to get auditor identity [%s]", auditors[0])
+		}
+		if err := pp.RotateAuditor(id, time.Now()); err != nil {
+			return err
+		}
+		auditors = nil
+	}
+	if len(auditors) > 0 {
 		pp.Auditor = []byte{}
 	}
 	if len(args.Issuers) > 0 {
 		pp.Issuers = [][]byte{}
 	}
-	if err := common.SetupIssuersAndAuditors(pp, args.Auditors, args.Issuers); err != nil {
+	if err := common.SetupIssuersAndAuditors(pp, auditors, args.Issuers); err != nil {
 		return err
 	}
 
@@ -30,6 +30,14 @@ var (
 	Issuers []string
 	// Auditors is the list of auditor MSP directories containing the corresponding auditor certificate
 	Auditors []string
+	// AsyncAuditing is a flag to indicate that token requests can be committed without the auditor's signature
+	AsyncAuditing bool
+	// AsyncAuditingTypes restricts asynchronous auditing to the listed token types
+	AsyncAuditingTypes []string
+	// Fee describes the fee to be paid on transfers
+	Fee common.FeeArgs
+	// PostQuantumCAs is the list of folders containing the public keys of the post-quantum CAs
+	PostQuantumCAs []string
 )
 
 // Cmd returns the Cobra Command for Version
@@ -40,6 +48,14 @@ func Cmd() *cobra.Command {
 	flags.BoolVarP(&GenerateCCPackage, "cc", "", false, "generate chaincode package")
 	flags.StringSliceVarP(&Auditors, "auditors", "a", nil, "list of auditor MSP directories containing the corresponding auditor certificate")
 	flags.StringSliceVarP(&Issuers, "issuers", "s", nil, "list of issuer MSP directories containing the corresponding issuer certificate")
+	flags.BoolVarP(&AsyncAuditing, "async-auditing", "", false, "allow token requests to be committed without the auditor's signature")
+	flags.StringSliceVarP(&AsyncAuditingTypes, "async-auditing-types", "", nil, "restrict asynchronous auditing to the listed token types. If empty, any token type")
+	flags.StringVarP(&Fee.Kind, "fee-kind", "", "", "kind of fee charged on transfers, either flat or proportional. If empty, transfers are free")
+	flags.Uint64VarP(&Fee.Amount, "fee-amount", "", 0, "flat fee, or minimum fee if the fee is proportional")
+	flags.Uint64VarP(&Fee.Rate, "fee-rate", "", 0, "fraction of the transferred value, in basis points, charged by a proportional fee")
+	flags.StringVarP(&Fee.TokenType, "fee-type", "", "", "token type the fee must be paid with")
+	flags.StringVarP(&Fee.Collector, "fee-collector", "", "", "MSP directory containing the certificate of the fee collector, formatted as <MSPConfigPath>:<MSPID>")
+	flags.StringSliceVarP(&PostQuantumCAs, "pq-cas", "", nil, "list of folders containing the public keys of the post-quantum CAs that certify the enrollment IDs of post-quantum owners")
 	return cobraCommand
 }
 
@@ -54,10 +70,14 @@ var cobraCommand = &cobra.Command{
 		// Parsing of the command line is done so silence cmd usage
 		cmd.SilenceUsage = true
 		raw, err := Gen(&GeneratorArgs{
-			OutputDir:         OutputDir,
-			GenerateCCPackage: GenerateCCPackage,
-			Issuers:           Issuers,
-			Auditors:          Auditors,
+			OutputDir:          OutputDir,
+			GenerateCCPackage:  GenerateCCPackage,
+			Issuers:            Issuers,
+			Auditors:           Auditors,
+			AsyncAuditing:      AsyncAuditing,
+			AsyncAuditingTypes: AsyncAuditingTypes,
+			Fee:                Fee,
+			PostQuantumCAs:     PostQuantumCAs,
 		})
 		if err != nil {
 			return errors.Wrap(err, "failed to generate public parameters")
@@ -82,6 +102,14 @@ type GeneratorArgs struct {
 	Issuers []string
 	// Auditors is the list of auditor MSP directories containing the corresponding auditor certificate
 	Auditors []string
+	// AsyncAuditing is a flag to indicate that token requests can be committed without the auditor's signature
+	AsyncAuditing bool
+	// AsyncAuditingTypes restricts asynchronous auditing to the listed token types
+	AsyncAuditingTypes []string
+	// Fee describes the fee to be paid on transfers
+	Fee common.FeeArgs
+	// PostQuantumCAs is the list of folders containing the public keys of the post-quantum CAs
+	PostQuantumCAs []string
 }
 
 // Gen generates the public parameters for the FabToken driver
@@ -94,6 +122,19 @@ func Gen(args *GeneratorArgs) ([]byte, error) {
 	if err := common.SetupIssuersAndAuditors(pp, args.Auditors, args.Issuers); err != nil {
 		return nil, err
 	}
+	if err := common.SetupPostQuantumCAs(pp, args.PostQuantumCAs); err != nil {
+		return nil, err
+	}
+	pp.SetAsyncAuditing(args.AsyncAuditing)
+	pp.SetAsyncAuditingTypes(args.AsyncAuditingTypes...)
+	fee, err := common.FeePolicy(&args.Fee)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid fee policy")
+	}
+	pp.SetFeePolicy(fee)
+	if err := pp.Validate(); err != nil {
+		return nil, errors.WithMessage(err, "invalid public parameters")
+	}
 	// Store Public Params
 	raw, err := pp.Serialize()
 	if err != nil {
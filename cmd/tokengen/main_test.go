@@ -142,6 +142,58 @@ func TestPartialUpdate(t *testing.T) {
 	)
 }
 
+func TestRotateAuditorUpdate(t *testing.T) {
+	gt := NewWithT(t)
+	tokengen, err := gexec.Build("github.com/hyperledger-labs/fabric-token-sdk/cmd/tokengen")
+	gt.Expect(err).NotTo(HaveOccurred())
+	defer gexec.CleanupBuildArtifacts()
+
+	tempOutput, err := os.MkdirTemp("", "tokengen-update-test")
+	gt.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempOutput)
+
+	testGenRun(
+		gt,
+		tokengen,
+		[]string{
+			"update",
+			"dlog",
+			"--auditors",
+			"./testdata/issuers/msp",
+			"--rotate-auditor",
+			"--input",
+			"./testdata/zkatdlog_pp.json",
+			"--output",
+			tempOutput,
+		},
+	)
+
+	validateOutputEquivalent(
+		gt,
+		tempOutput,
+		"./testdata/issuers/msp",
+		"./testdata/issuers/msp",
+		"./testdata/idemix/msp/IssuerPublicKey",
+	)
+
+	// the old auditor is kept in the history
+	ppRaw, err := os.ReadFile(filepath.Join(tempOutput, "zkatdlog_pp.json"))
+	gt.Expect(err).NotTo(HaveOccurred())
+	pp, err := crypto.NewPublicParamsFromBytes(ppRaw, crypto.DLogPublicParameters)
+	gt.Expect(err).NotTo(HaveOccurred())
+	oldAuditor, err := common.GetMSPIdentity("./testdata/auditors/msp", msp.AuditorMSPID)
+	gt.Expect(err).NotTo(HaveOccurred())
+	newAuditor, err := common.GetMSPIdentity("./testdata/issuers/msp", msp.AuditorMSPID)
+	gt.Expect(err).NotTo(HaveOccurred())
+	history := pp.AuditorHistory()
+	gt.Expect(history).To(HaveLen(2))
+	gt.Expect(history[0].Identity).To(Equal(oldAuditor))
+	gt.Expect(history[0].IsCurrent()).To(BeFalse())
+	gt.Expect(history[1].Identity).To(Equal(newAuditor))
+	gt.Expect(history.Current()).To(HaveLen(1))
+	gt.Expect(history.Current()[0]).To(Equal(newAuditor))
+}
+
 func TestGenFailure(t *testing.T) {
 	gt := NewGomegaWithT(t)
 	tokengen, err := gexec.Build("github.com/hyperledger-labs/fabric-token-sdk/cmd/tokengen")
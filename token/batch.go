@@ -0,0 +1,121 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultBatchFanOut is the default maximum number of outputs of each transfer action generated by BatchTransfer
+const DefaultBatchFanOut = 64
+
+// BatchTransfer appends to the request the transfer actions paying the passed values to the passed owners.
+// The payments are split across transfer actions having at most fanOut outputs each, the rest included.
+// If fanOut is not positive, DefaultBatchFanOut is used.
+// The tokens to spend are selected sequentially, one action at a time, while the actions, and their proofs,
+// are generated in parallel. The actions are appended to the request in the order of the passed payments.
+// The fee, if any, is paid once for the whole batch.
+// Explicit token IDs are not supported, because they cannot be split across actions.
+func (r *Request) BatchTransfer(ctx context.Context, wallet *OwnerWallet, typ string, values []uint64, owners []Identity, fanOut int, opts ...TransferOption) ([]*TransferAction, error) {
+	if len(values) == 0 {
+		return nil, errors.New("no payments")
+	}
+	if len(values) != len(owners) {
+		return nil, errors.Errorf("number of values [%d] does not match number of owners [%d]", len(values), len(owners))
+	}
+	for _, v := range values {
+		if v == 0 {
+			return nil, errors.Errorf("value is zero")
+		}
+	}
+	if fanOut <= 0 {
+		fanOut = DefaultBatchFanOut
+	}
+	if fanOut < 2 {
+		return nil, errors.Errorf("fan-out must be at least 2, got [%d]", fanOut)
+	}
+	opt, err := compileTransferOptions(opts...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed compiling options [%v]", opts)
+	}
+	if len(opt.TokenIDs) != 0 {
+		return nil, errors.New("explicit token IDs are not supported by batch transfers")
+	}
+	if err := r.checkTransferPolicy(ctx, wallet, typ, values, owners); err != nil {
+		return nil, err
+	}
+
+	// add the fee output to the batch, if possible, otherwise pay the fee with an additional action.
+	// The spent tokens are not known yet, the fee is checked again once the actions are prepared.
+	policy, _, fee := r.fee(typ, values, owners)
+	var paid uint64
+	if fee != 0 && typ == policy.TokenType {
+		values = append(append([]uint64{}, values...), fee)
+		owners = append(append([]Identity{}, owners...), policy.Collector)
+		paid = fee
+	}
+
+	// select the inputs of each action, leaving room for the rest
+	size := fanOut - 1
+	var chunks []*preparedTransfer
+	for start := 0; start < len(values); start += size {
+		end := start + size
+		if end > len(values) {
+			end = len(values)
+		}
+		tokenIDs, outputTokens, err := r.prepareTransfer(false, wallet, typ, values[start:end], owners[start:end], opt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed preparing transfer for payments [%d:%d]", start, end)
+		}
+		chunks = append(chunks, &preparedTransfer{tokenIDs: tokenIDs, outputTokens: outputTokens})
+	}
+	r.TokenService.logger.Debugf("Prepare Batch Transfer [id:%s,payments:%d,actions:%d]", r.Anchor, len(values), len(chunks))
+
+	// generate the actions in parallel
+	generated := make([]*generatedTransfer, len(chunks))
+	errs := make([]error, len(chunks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				generated[i], errs[i] = r.generateTransfer(ctx, wallet, chunks[i].tokenIDs, chunks[i].outputTokens, opt.Attributes)
+			}
+		}()
+	}
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed generating transfer action [%d]", i)
+		}
+	}
+
+	// append in order
+	actions := make([]*TransferAction, len(generated))
+	for i, g := range generated {
+		r.appendGeneratedTransfer(g)
+		actions[i] = &TransferAction{a: g.action}
+	}
+	if err := r.payFee(ctx, wallet, typ, chunks, paid, opt.Selector); err != nil {
+		return nil, err
+	}
+	return actions, nil
+}
@@ -0,0 +1,364 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	math2 "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	zktoken "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver/mock"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type batchTMS struct {
+	driver.TokenManagerService
+	ts  *mock.TransferService
+	ppm *mock.PublicParamsManager
+}
+
+func (t *batchTMS) TransferService() driver.TransferService { return t.ts }
+
+func (t *batchTMS) PublicParamsManager() driver.PublicParamsManager { return t.ppm }
+
+type batchWallet struct {
+	driver.OwnerWallet
+}
+
+func (w *batchWallet) GetRecipientIdentity() (driver.Identity, error) {
+	return driver.Identity("alice"), nil
+}
+
+// batchSelector selects, for each request, a single token worth one more than the requested quantity
+type batchSelector struct {
+	lock  sync.Mutex
+	count int
+}
+
+func (s *batchSelector) Select(_ OwnerFilter, q, _ string) ([]*token2.ID, token2.Quantity, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.count++
+	quantity, err := token2.ToQuantity(q, 64)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []*token2.ID{{TxId: fmt.Sprintf("tx%d", s.count)}}, quantity.Add(token2.NewOneQuantity(64)), nil
+}
+
+func (s *batchSelector) Close() error {
+	return nil
+}
+
+func newBatchRequest(pp *mock.PublicParameters) (*Request, *mock.TransferService) {
+	pp.PrecisionReturns(64)
+	pp.MaxTokenValueReturns(math.MaxUint64)
+	ppm := &mock.PublicParamsManager{}
+	ppm.PublicParametersReturns(pp)
+
+	ts := &mock.TransferService{}
+	ts.TransferCalls(func(_ context.Context, _ string, _ driver.OwnerWallet, ids []*token2.ID, outputs []*token2.Token, _ *driver.TransferOptions) (driver.TransferAction, *driver.TransferMetadata, error) {
+		action := &mock.TransferAction{}
+		action.NumOutputsReturns(len(outputs))
+		action.SerializeReturns([]byte(fmt.Sprintf("transfer of %s", ids[0].TxId)), nil)
+		metadata := &driver.TransferMetadata{TokenIDs: ids}
+		for _, output := range outputs {
+			metadata.Receivers = append(metadata.Receivers, output.Owner)
+		}
+		return action, metadata, nil
+	})
+
+	tms := &ManagementService{
+		tms:    &batchTMS{ts: ts, ppm: ppm},
+		logger: logging.MustGetLogger("test"),
+	}
+	return NewRequest(tms, "anchor"), ts
+}
+
+func TestRequest_BatchTransfer(t *testing.T) {
+	request, ts := newBatchRequest(&mock.PublicParameters{})
+	wallet := &OwnerWallet{w: &batchWallet{}}
+	selector := &batchSelector{}
+
+	// pay 1000 recipients
+	const recipients = 1000
+	const fanOut = 64
+	values := make([]uint64, recipients)
+	owners := make([]Identity, recipients)
+	for i := 0; i < recipients; i++ {
+		values[i] = uint64(i + 1)
+		owners[i] = Identity(fmt.Sprintf("recipient%d", i))
+	}
+	actions, err := request.BatchTransfer(context.Background(), wallet, "USD", values, owners, fanOut, WithTokenSelector(selector))
+	assert.NoError(t, err)
+
+	// each action pays fanOut-1 recipients and the rest
+	expectedActions := (recipients + fanOut - 2) / (fanOut - 1)
+	assert.Len(t, actions, expectedActions)
+	assert.Len(t, request.Actions.Transfers, expectedActions)
+	assert.Len(t, request.Metadata.Transfers, expectedActions)
+	assert.Equal(t, expectedActions, ts.TransferCallCount())
+	assert.Equal(t, expectedActions, selector.count)
+
+	// the actions are appended in the order of the payments
+	var paid []Identity
+	for i, transfer := range request.Metadata.Transfers {
+		assert.Equal(t, fmt.Sprintf("transfer of %s", transfer.TokenIDs[0].TxId), string(request.Actions.Transfers[i]))
+		assert.LessOrEqual(t, len(transfer.Receivers), fanOut)
+		last := len(transfer.Receivers) - 1
+		assert.Equal(t, Identity("alice"), Identity(transfer.Receivers[last]))
+		for _, receiver := range transfer.Receivers[:last] {
+			paid = append(paid, Identity(receiver))
+		}
+	}
+	assert.Equal(t, owners, paid)
+}
+
+func TestRequest_BatchTransferWithFee(t *testing.T) {
+	pp := &mock.PublicParameters{}
+	pp.FeePolicyReturns(&driver.FeePolicy{Kind: driver.FlatFee, Amount: 5, TokenType: "USD", Collector: Identity("collector")})
+	request, _ := newBatchRequest(pp)
+	wallet := &OwnerWallet{w: &batchWallet{}}
+
+	values := []uint64{10, 20, 30}
+	owners := []Identity{Identity("bob"), Identity("charlie"), Identity("dave")}
+	actions, err := request.BatchTransfer(context.Background(), wallet, "USD", values, owners, 3, WithTokenSelector(&batchSelector{}))
+	assert.NoError(t, err)
+
+	// the fee is paid once, with the last action
+	assert.Len(t, actions, 2)
+	assert.Equal(t, []Identity{Identity("bob"), Identity("charlie"), Identity("alice")}, request.Metadata.Transfers[0].Receivers)
+	assert.Equal(t, []Identity{Identity("dave"), Identity("collector"), Identity("alice")}, request.Metadata.Transfers[1].Receivers)
+	assert.Equal(t, uint64(5), request.feePaid)
+}
+
+type feeVault struct {
+	driver.Vault
+	qe *mock.QueryEngine
+}
+
+func (v *feeVault) QueryEngine() driver.QueryEngine { return v.qe }
+
+type feeWallet struct {
+	batchWallet
+}
+
+func (w *feeWallet) Contains(identity driver.Identity) bool {
+	return Identity("alice").Equal(identity)
+}
+
+func (w *feeWallet) RegisterRecipient(*driver.RecipientData) error {
+	return nil
+}
+
+func TestRequest_RedeemWithProportionalFee(t *testing.T) {
+	pp := &mock.PublicParameters{}
+	pp.FeePolicyReturns(&driver.FeePolicy{Kind: driver.ProportionalFee, Amount: 1, Rate: 1000, TokenType: "USD", Collector: Identity("collector")})
+	request, _ := newBatchRequest(pp)
+	qe := &mock.QueryEngine{}
+	qe.GetTokensCalls(func(ids ...*token2.ID) ([]*token2.Token, error) {
+		tokens := make([]*token2.Token, len(ids))
+		for i := range ids {
+			tokens[i] = &token2.Token{Owner: Identity("alice"), Type: "USD"}
+		}
+		return tokens, nil
+	})
+	request.TokenService.vault = &Vault{v: &feeVault{qe: qe}, logger: request.TokenService.logger}
+	w := &feeWallet{}
+	wallet := &OwnerWallet{Wallet: &Wallet{w: w}, w: w}
+
+	// the redeemed value is not charged, the rest goes back to the owner of the input,
+	// therefore only the minimum fee is paid, with an additional action
+	assert.NoError(t, request.Redeem(context.Background(), wallet, "USD", 100, WithTokenSelector(&batchSelector{})))
+	assert.Len(t, request.Metadata.Transfers, 2)
+	assert.Equal(t, []Identity{nil, Identity("alice")}, request.Metadata.Transfers[0].Receivers)
+	assert.Equal(t, []Identity{Identity("collector"), Identity("alice")}, request.Metadata.Transfers[1].Receivers)
+	assert.Equal(t, uint64(1), request.feePaid)
+	assert.Equal(t, uint64(0), request.feeBase)
+
+	// the rest sent to another identity is charged
+	request, _ = newBatchRequest(pp)
+	request.TokenService.vault = &Vault{v: &feeVault{qe: qe}, logger: request.TokenService.logger}
+	assert.NoError(t, request.Redeem(context.Background(), wallet, "USD", 100, WithTokenSelector(&batchSelector{}), WithRestRecipientIdentity(&RecipientData{Identity: Identity("bob")})))
+	assert.Equal(t, uint64(1), request.feeBase)
+	assert.Equal(t, uint64(1), request.feePaid)
+}
+
+func TestRequest_BatchTransferInvalidArguments(t *testing.T) {
+	request, _ := newBatchRequest(&mock.PublicParameters{})
+	wallet := &OwnerWallet{w: &batchWallet{}}
+
+	_, err := request.BatchTransfer(context.Background(), wallet, "USD", nil, nil, 0)
+	assert.EqualError(t, err, "no payments")
+	_, err = request.BatchTransfer(context.Background(), wallet, "USD", []uint64{1}, nil, 0)
+	assert.EqualError(t, err, "number of values [1] does not match number of owners [0]")
+	_, err = request.BatchTransfer(context.Background(), wallet, "USD", []uint64{0}, []Identity{Identity("bob")}, 0)
+	assert.EqualError(t, err, "value is zero")
+	_, err = request.BatchTransfer(context.Background(), wallet, "USD", []uint64{1}, []Identity{Identity("bob")}, 1)
+	assert.EqualError(t, err, "fan-out must be at least 2, got [1]")
+	_, err = request.BatchTransfer(context.Background(), wallet, "USD", []uint64{1}, []Identity{Identity("bob")}, 0, WithTokenIDs(&token2.ID{TxId: "tx"}))
+	assert.EqualError(t, err, "explicit token IDs are not supported by batch transfers")
+}
+
+// zkTransferService generates zkatdlog transfer actions, with their proofs, spending a fresh input worth the sum of the outputs
+type zkTransferService struct {
+	driver.TransferService
+	pp *crypto.PublicParams
+
+	lock        sync.Mutex
+	inputs      map[string]*math2.G1
+	inFlight    int
+	maxInFlight int
+}
+
+func (s *zkTransferService) Transfer(ctx context.Context, _ string, _ driver.OwnerWallet, ids []*token2.ID, outputs []*token2.Token, _ *driver.TransferOptions) (driver.TransferAction, *driver.TransferMetadata, error) {
+	s.lock.Lock()
+	s.inFlight++
+	if s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.lock.Unlock()
+	defer func() {
+		s.lock.Lock()
+		s.inFlight--
+		s.lock.Unlock()
+	}()
+
+	var values []uint64
+	var owners [][]byte
+	var sum uint64
+	metadata := &driver.TransferMetadata{TokenIDs: ids}
+	for _, output := range outputs {
+		q, err := token2.ToQuantity(output.Quantity, 64)
+		if err != nil {
+			return nil, nil, err
+		}
+		values = append(values, q.ToBigInt().Uint64())
+		sum += q.ToBigInt().Uint64()
+		owners = append(owners, output.Owner)
+		metadata.Receivers = append(metadata.Receivers, output.Owner)
+	}
+	c := math2.Curves[s.pp.Curve]
+	in, witness, err := zktoken.GetTokensWithWitness([]uint64{sum}, "USD", s.pp.PedersenGenerators, c)
+	if err != nil {
+		return nil, nil, err
+	}
+	sender, err := transfer.NewSender(
+		nil,
+		[]*zktoken.Token{{Owner: []byte("alice"), Data: in[0]}},
+		ids,
+		[]*zktoken.Metadata{{Type: "USD", Value: c.NewZrFromUint64(sum), BlindingFactor: witness[0].BlindingFactor}},
+		s.pp,
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	action, _, err := sender.GenerateZKTransfer(ctx, values, owners)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.lock.Lock()
+	s.inputs[ids[0].TxId] = in[0]
+	s.lock.Unlock()
+	return action, metadata, nil
+}
+
+func TestRequest_BatchTransferProofs(t *testing.T) {
+	// the actions are generated by as many workers as GOMAXPROCS
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	pp, err := crypto.Setup(16, nil, math2.FP256BN_AMCL)
+	assert.NoError(t, err)
+	ts := &zkTransferService{pp: pp, inputs: map[string]*math2.G1{}}
+	request, _ := newBatchRequest(&mock.PublicParameters{})
+	request.TokenService.tms = &zkTMS{batchTMS: request.TokenService.tms.(*batchTMS), ts: ts}
+	wallet := &OwnerWallet{w: &batchWallet{}}
+
+	const recipients = 20
+	const fanOut = 4
+	values := make([]uint64, recipients)
+	owners := make([]Identity, recipients)
+	for i := 0; i < recipients; i++ {
+		values[i] = uint64(i + 1)
+		owners[i] = Identity(fmt.Sprintf("recipient%d", i))
+	}
+	start := time.Now()
+	actions, err := request.BatchTransfer(context.Background(), wallet, "USD", values, owners, fanOut, WithTokenSelector(&batchSelector{}))
+	assert.NoError(t, err)
+	t.Logf("generated [%d] actions in [%v]", len(actions), time.Since(start))
+
+	expectedActions := (recipients + fanOut - 2) / (fanOut - 1)
+	assert.Len(t, request.Actions.Transfers, expectedActions)
+	assert.Greater(t, ts.maxInFlight, 1, "actions must be generated in parallel")
+
+	// each action carries a valid proof, for its own input, and pays the recipients in order
+	var paid []uint64
+	for i, raw := range request.Actions.Transfers {
+		action := &transfer.Action{}
+		assert.NoError(t, action.Deserialize(raw))
+		input, ok := ts.inputs[action.Inputs[0].TxId]
+		assert.True(t, ok)
+		assert.NoError(t, transfer.NewVerifier([]*math2.G1{input}, action.GetOutputCommitments(), pp).Verify(action.Proof), "invalid proof for action [%d]", i)
+		assert.LessOrEqual(t, len(action.OutputTokens), fanOut)
+		receivers := request.Metadata.Transfers[i].Receivers
+		assert.Equal(t, Identity("alice"), receivers[len(receivers)-1])
+		for _, receiver := range receivers[:len(receivers)-1] {
+			var index uint64
+			_, err := fmt.Sscanf(string(receiver), "recipient%d", &index)
+			assert.NoError(t, err)
+			paid = append(paid, index+1)
+		}
+	}
+	assert.Equal(t, values, paid)
+
+	// a proof does not verify against the input of another action
+	action := &transfer.Action{}
+	assert.NoError(t, action.Deserialize(request.Actions.Transfers[0]))
+	other := &transfer.Action{}
+	assert.NoError(t, other.Deserialize(request.Actions.Transfers[1]))
+	assert.Error(t, transfer.NewVerifier([]*math2.G1{ts.inputs[other.Inputs[0].TxId]}, action.GetOutputCommitments(), pp).Verify(action.Proof))
+}
+
+func TestRequest_BatchTransferGenerationFailure(t *testing.T) {
+	request, ts := newBatchRequest(&mock.PublicParameters{})
+	transfer := ts.TransferStub
+	ts.TransferCalls(func(ctx context.Context, txID string, wallet driver.OwnerWallet, ids []*token2.ID, outputs []*token2.Token, opts *driver.TransferOptions) (driver.TransferAction, *driver.TransferMetadata, error) {
+		if ids[0].TxId == "tx2" {
+			return nil, nil, errors.New("proof generation failed")
+		}
+		return transfer(ctx, txID, wallet, ids, outputs, opts)
+	})
+	wallet := &OwnerWallet{w: &batchWallet{}}
+
+	values := []uint64{1, 2, 3, 4, 5}
+	owners := []Identity{Identity("a"), Identity("b"), Identity("c"), Identity("d"), Identity("e")}
+	_, err := request.BatchTransfer(context.Background(), wallet, "USD", values, owners, 2, WithTokenSelector(&batchSelector{}))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "proof generation failed")
+	// nothing is appended
+	assert.Empty(t, request.Actions.Transfers)
+	assert.Empty(t, request.Metadata.Transfers)
+}
+
+type zkTMS struct {
+	*batchTMS
+	ts *zkTransferService
+}
+
+func (t *zkTMS) TransferService() driver.TransferService { return t.ts }
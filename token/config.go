@@ -24,3 +24,8 @@ func (m *Configuration) IsSet(key string) bool {
 func (m *Configuration) UnmarshalKey(key string, rawVal interface{}) error {
 	return m.cm.UnmarshalKey(key, rawVal)
 }
+
+// TranslatePath translates the passed path relative to the config path
+func (m *Configuration) TranslatePath(path string) string {
+	return m.cm.TranslatePath(path)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/metrics"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -51,3 +52,12 @@ func (o *ObservableAuditorService) AuditorCheck(ctx context.Context, request *dr
 	span.SetAttributes(attribute.Bool(SuccessfulLabel, err == nil))
 	return err
 }
+
+func (o *ObservableAuditorService) LedgerOutputs(ctx context.Context, request *driver.TokenRequest, types []string) ([][]*token.Token, [][]*token.Token, error) {
+	newCtx, span := o.Metrics.auditTracer.Start(ctx, "ledger_outputs")
+	defer span.End()
+
+	issues, transfers, err := o.AuditService.LedgerOutputs(newCtx, request, types)
+	span.SetAttributes(attribute.Bool(SuccessfulLabel, err == nil))
+	return issues, transfers, err
+}
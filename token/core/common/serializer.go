@@ -17,8 +17,9 @@ func NewSerializer() *Serializer {
 
 func (s Serializer) MarshalTokenRequestToSign(request *driver.TokenRequest, meta *driver.TokenRequestMetadata) ([]byte, error) {
 	newReq := &driver.TokenRequest{
-		Issues:    request.Issues,
-		Transfers: request.Transfers,
+		Issues:      request.Issues,
+		Transfers:   request.Transfers,
+		SummaryHash: request.SummaryHash,
 	}
 	return newReq.Bytes()
 }
@@ -8,6 +8,7 @@ package common
 
 import (
 	"context"
+	"slices"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/logging"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -32,12 +33,43 @@ type Context[P driver.PublicParameters, T any, TA driver.TransferAction, IA driv
 	Ledger            driver.Ledger
 	MetadataCounter   map[MetadataCounterID]int
 	Attributes        driver.ValidationAttributes
+	// Fees accumulates the fees paid by the transfer actions of the token request under validation
+	Fees *FeeTally
 }
 
 func (c *Context[P, T, TA, IA, DS]) CountMetadataKey(key string) {
 	c.MetadataCounter[key] = c.MetadataCounter[key] + 1
 }
 
+// FeeTally accumulates the fees paid by the transfer actions of a token request
+type FeeTally struct {
+	// Paid is the sum of the values of the outputs paying the fee collector
+	Paid uint64
+	// Charged is true if at least one transfer action has an output that is neither a redeem nor a fee output
+	Charged bool
+	// Transferred is the value subject to the fee, as far as the driver can see it.
+	// Drivers that hide the values leave it to zero, therefore only the minimum fee is enforced.
+	Transferred uint64
+}
+
+// Pay adds the passed value to the fees paid
+func (f *FeeTally) Pay(value uint64) error {
+	if f.Paid+value < f.Paid {
+		return errors.New("fee overflow")
+	}
+	f.Paid += value
+	return nil
+}
+
+// Transfer adds the passed value to the value subject to the fee
+func (f *FeeTally) Transfer(value uint64) error {
+	if f.Transferred+value < f.Transferred {
+		return errors.New("transferred value overflow")
+	}
+	f.Transferred += value
+	return nil
+}
+
 type ValidateTransferFunc[P driver.PublicParameters, T any, TA driver.TransferAction, IA driver.IssueAction, DS driver.Deserializer] func(ctx *Context[P, T, TA, IA, DS]) error
 
 type ValidateIssueFunc[P driver.PublicParameters, T any, TA driver.TransferAction, IA driver.IssueAction, DS driver.Deserializer] func(ctx *Context[P, T, TA, IA, DS]) error
@@ -46,6 +78,10 @@ type ActionDeserializer[TA driver.TransferAction, IA driver.IssueAction] interfa
 	DeserializeActions(tr *driver.TokenRequest) ([]IA, []TA, error)
 }
 
+// TokenTypesFunc returns the token types of the outputs of the passed actions.
+// It is nil for the drivers that hide the token types.
+type TokenTypesFunc[TA driver.TransferAction, IA driver.IssueAction] func(issues []IA, transfers []TA) []string
+
 type Validator[P driver.PublicParameters, T any, TA driver.TransferAction, IA driver.IssueAction, DS driver.Deserializer] struct {
 	Logger             logging.Logger
 	PublicParams       P
@@ -54,6 +90,9 @@ type Validator[P driver.PublicParameters, T any, TA driver.TransferAction, IA dr
 	TransferValidators []ValidateTransferFunc[P, T, TA, IA, DS]
 	IssueValidators    []ValidateIssueFunc[P, T, TA, IA, DS]
 	Serializer         driver.Serializer
+	// TokenTypes is used to check that a request committed without the auditor's signature
+	// only moves the token types the public parameters allow to audit asynchronously
+	TokenTypes TokenTypesFunc[TA, IA]
 }
 
 func NewValidator[P driver.PublicParameters, T any, TA driver.TransferAction, IA driver.IssueAction, DS driver.Deserializer](
@@ -90,6 +129,7 @@ func (v *Validator[P, T, TA, IA, DS]) VerifyTokenRequestFromRaw(ctx context.Cont
 	req := &driver.TokenRequest{}
 	req.Transfers = tr.Transfers
 	req.Issues = tr.Issues
+	req.SummaryHash = tr.SummaryHash
 	raqRaw, err := req.Bytes()
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to marshal signed token request")
@@ -116,13 +156,13 @@ func (v *Validator[P, T, TA, IA, DS]) VerifyTokenRequestFromRaw(ctx context.Cont
 }
 
 func (v *Validator[P, T, TA, IA, DS]) VerifyTokenRequest(ledger driver.Ledger, signatureProvider driver.SignatureProvider, anchor string, tr *driver.TokenRequest, attributes driver.ValidationAttributes) ([]interface{}, driver.ValidationAttributes, error) {
-	if err := v.verifyAuditorSignature(signatureProvider, attributes); err != nil {
-		return nil, nil, errors.Wrapf(err, "failed to verifier auditor's signature [%s]", anchor)
-	}
 	ia, ta, err := v.ActionDeserializer.DeserializeActions(tr)
 	if err != nil {
 		return nil, nil, errors.Wrapf(err, "failed to unmarshal actions [%s]", anchor)
 	}
+	if err := v.verifyAuditorSignature(signatureProvider, tr, ia, ta, attributes); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to verifier auditor's signature [%s]", anchor)
+	}
 	err = v.verifyIssues(ledger, ia, signatureProvider, attributes)
 	if err != nil {
 		return nil, nil, errors.Wrapf(err, "failed to verify issuers' signatures [%s]", anchor)
@@ -163,8 +203,16 @@ func (v *Validator[P, T, TA, IA, DS]) UnmarshalActions(raw []byte) ([]interface{
 	return res, nil
 }
 
-func (v *Validator[P, T, TA, IA, DS]) verifyAuditorSignature(signatureProvider driver.SignatureProvider, attributes driver.ValidationAttributes) error {
+func (v *Validator[P, T, TA, IA, DS]) verifyAuditorSignature(signatureProvider driver.SignatureProvider, tr *driver.TokenRequest, ia []IA, ta []TA, attributes driver.ValidationAttributes) error {
 	if len(v.PublicParams.Auditors()) != 0 {
+		if v.PublicParams.AsyncAuditing() && len(tr.AuditorSignatures) == 0 {
+			if err := v.verifyAsyncAuditing(ia, ta); err != nil {
+				return err
+			}
+			// the auditor will inspect the request once committed
+			v.Logger.Debugf("asynchronous auditing enabled, no auditor signature to verify")
+			return nil
+		}
 		auditor := v.PublicParams.Auditors()[0]
 		verifier, err := v.Deserializer.GetAuditorVerifier(auditor)
 		if err != nil {
@@ -176,6 +224,23 @@ func (v *Validator[P, T, TA, IA, DS]) verifyAuditorSignature(signatureProvider d
 	return nil
 }
 
+// verifyAsyncAuditing checks that the passed actions only move token types that can be audited asynchronously
+func (v *Validator[P, T, TA, IA, DS]) verifyAsyncAuditing(ia []IA, ta []TA) error {
+	allowed := v.PublicParams.AsyncAuditingTypes()
+	if len(allowed) == 0 {
+		return nil
+	}
+	if v.TokenTypes == nil {
+		return errors.New("asynchronous auditing is restricted by token type, but the token types are hidden")
+	}
+	for _, tokenType := range v.TokenTypes(ia, ta) {
+		if !slices.Contains(allowed, tokenType) {
+			return errors.Errorf("token type [%s] requires the auditor's signature", tokenType)
+		}
+	}
+	return nil
+}
+
 func (v *Validator[P, T, TA, IA, DS]) verifyIssues(ledger driver.Ledger, issues []IA, signatureProvider driver.SignatureProvider, attributes driver.ValidationAttributes) error {
 	for _, issue := range issues {
 		if err := v.verifyIssue(issue, ledger, signatureProvider, attributes); err != nil {
@@ -220,15 +285,30 @@ func (v *Validator[P, T, TA, IA, DS]) verifyIssue(tr IA, ledger driver.Ledger, s
 func (v *Validator[P, T, TA, IA, DS]) verifyTransfers(ledger driver.Ledger, transferActions []TA, signatureProvider driver.SignatureProvider, attributes driver.ValidationAttributes) error {
 	v.Logger.Debugf("check sender start...")
 	defer v.Logger.Debugf("check sender finished.")
+	fees := &FeeTally{}
 	for _, action := range transferActions {
-		if err := v.verifyTransfer(action, ledger, signatureProvider, attributes); err != nil {
+		if err := v.verifyTransfer(action, ledger, signatureProvider, attributes, fees); err != nil {
 			return errors.Wrapf(err, "failed to verify transfer action")
 		}
 	}
+	return v.verifyFees(fees)
+}
+
+// verifyFees checks that the transfer actions paid the fee due, according to the public parameters, on the transferred value.
+// If the driver hides the values, the transferred value is zero and the minimum fee is due.
+// A token request whose transfers only redeem tokens or pay the fee collector is free.
+func (v *Validator[P, T, TA, IA, DS]) verifyFees(fees *FeeTally) error {
+	policy := v.PublicParams.FeePolicy()
+	if policy == nil || !fees.Charged {
+		return nil
+	}
+	if due := policy.Fee(fees.Transferred); fees.Paid < due {
+		return errors.Errorf("insufficient fee, paid [%d], expected at least [%d] on transferred value [%d]", fees.Paid, due, fees.Transferred)
+	}
 	return nil
 }
 
-func (v *Validator[P, T, TA, IA, DS]) verifyTransfer(tr TA, ledger driver.Ledger, signatureProvider driver.SignatureProvider, attributes driver.ValidationAttributes) error {
+func (v *Validator[P, T, TA, IA, DS]) verifyTransfer(tr TA, ledger driver.Ledger, signatureProvider driver.SignatureProvider, attributes driver.ValidationAttributes, fees *FeeTally) error {
 	context := &Context[P, T, TA, IA, DS]{
 		Logger:            v.Logger,
 		PP:                v.PublicParams,
@@ -238,6 +318,7 @@ func (v *Validator[P, T, TA, IA, DS]) verifyTransfer(tr TA, ledger driver.Ledger
 		SignatureProvider: signatureProvider,
 		MetadataCounter:   map[MetadataCounterID]int{},
 		Attributes:        attributes,
+		Fees:              fees,
 	}
 	for _, v := range v.TransferValidators {
 		if err := v(context); err != nil {
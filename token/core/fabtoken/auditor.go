@@ -10,6 +10,8 @@ import (
 	"context"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/pkg/errors"
 )
 
 type AuditorService struct{}
@@ -24,3 +26,40 @@ func NewAuditorService() *AuditorService {
 func (s *AuditorService) AuditorCheck(ctx context.Context, request *driver.TokenRequest, metadata *driver.TokenRequestMetadata, anchor string) error {
 	return nil
 }
+
+// LedgerOutputs returns the outputs of the passed request.
+// fabtoken outputs are in the clear, therefore the passed types are not needed.
+func (s *AuditorService) LedgerOutputs(ctx context.Context, request *driver.TokenRequest, types []string) ([][]*token.Token, [][]*token.Token, error) {
+	if request == nil {
+		return nil, nil, errors.New("token request is nil")
+	}
+	issueActions, transferActions, err := UnmarshalIssueTransferActions(request)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal actions")
+	}
+	issues := make([][]*token.Token, len(issueActions))
+	for i, action := range issueActions {
+		if issues[i], err = outputTokens(action.Outputs); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid issue action [%d]", i)
+		}
+	}
+	transfers := make([][]*token.Token, len(transferActions))
+	for i, action := range transferActions {
+		if transfers[i], err = outputTokens(action.Outputs); err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid transfer action [%d]", i)
+		}
+	}
+	return issues, transfers, nil
+}
+
+func outputTokens(outputs []*Output) ([]*token.Token, error) {
+	res := make([]*token.Token, len(outputs))
+	for i, output := range outputs {
+		if output == nil {
+			return nil, errors.Errorf("output at index [%d] is nil", i)
+		}
+		tok := output.Output
+		res[i] = &tok
+	}
+	return res, nil
+}
@@ -14,6 +14,8 @@ import (
 	config2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/config"
 	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/sig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
 	"github.com/pkg/errors"
@@ -32,7 +34,10 @@ func (d *base) PublicParametersFromBytes(params []byte) (driver.PublicParameters
 
 func (d *base) DefaultValidator(pp driver.PublicParameters) (driver.Validator, error) {
 	logger := logging.DriverLoggerFromPP("token-sdk.driver.fabtoken", pp.Identifier())
-	deserializer := NewDeserializer()
+	deserializer, err := NewDeserializer(pp.(*fabtoken.PublicParams))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create token service deserializer")
+	}
 	return fabtoken.NewValidator(logger, pp.(*fabtoken.PublicParams), deserializer), nil
 }
 
@@ -44,7 +49,7 @@ func (d *base) newWalletService(
 	logger logging.Logger,
 	fscIdentity driver.Identity,
 	networkDefaultIdentity driver.Identity,
-	_ driver.PublicParameters,
+	pp *fabtoken.PublicParams,
 	ignoreRemote bool,
 ) (*common.WalletService, error) {
 	tmsID := tmsConfig.ID()
@@ -52,6 +57,8 @@ func (d *base) newWalletService(
 	// Prepare roles
 	roles := identity.NewRoles()
 	deserializerManager := sig.NewMultiplexDeserializer()
+	deserializerManager.AddDeserializer(&pq.Deserializer{})
+	deserializerManager.AddDeserializer(&hybrid.Deserializer{})
 	identityDB, err := storageProvider.OpenIdentityDB(tmsID)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to open identity db for tms [%s]", tmsID)
@@ -103,10 +110,14 @@ func (d *base) newWalletService(
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get identity storage provider")
 	}
+	deserializer, err := NewDeserializer(pp)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to instantiate the deserializer")
+	}
 	ws := common.NewWalletService(
 		logger,
 		ip,
-		NewDeserializer(),
+		deserializer,
 		fabtoken.NewWalletFactory(logger, ip, qe),
 		identity.NewWalletRegistry(roles[driver.OwnerRole], walletDB),
 		identity.NewWalletRegistry(roles[driver.IssuerRole], walletDB),
@@ -12,8 +12,11 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/deserializer"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/interop/htlc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
 	htlc2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/interop/htlc"
+	"github.com/pkg/errors"
 )
 
 // Deserializer deserializes verifiers associated with issuers, owners, and auditors
@@ -21,10 +24,20 @@ type Deserializer struct {
 	*common.Deserializer
 }
 
-// NewDeserializer returns a deserializer
-func NewDeserializer() *Deserializer {
+// NewDeserializer returns a deserializer.
+// The enrollment IDs of post-quantum owners must be certified by the post-quantum CAs in the passed public parameters.
+func NewDeserializer(pp *fabtoken.PublicParams) (*Deserializer, error) {
+	if pp == nil {
+		return nil, errors.New("failed to get deserializer: nil public parameters")
+	}
+	pqDes, err := pq.NewIdentityDeserializer(pp.PostQuantumCAs)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting post-quantum deserializer")
+	}
 	m := deserializer.NewTypedVerifierDeserializerMultiplex(&x509.AuditMatcherDeserializer{})
 	m.AddTypedVerifierDeserializer(msp.X509Identity, deserializer.NewTypedIdentityVerifierDeserializer(&x509.MSPIdentityDeserializer{}))
+	m.AddTypedVerifierDeserializer(msp.DilithiumIdentity, deserializer.NewTypedIdentityVerifierDeserializer(pqDes))
+	m.AddTypedVerifierDeserializer(msp.HybridIdentity, deserializer.NewTypedIdentityVerifierDeserializer(&hybrid.IdentityDeserializer{}))
 	m.AddTypedVerifierDeserializer(htlc2.ScriptType, htlc.NewTypedIdentityDeserializer(m))
 
 	return &Deserializer{
@@ -32,11 +45,11 @@ func NewDeserializer() *Deserializer {
 			msp.X509Identity,
 			&x509.MSPIdentityDeserializer{}, // audit
 			m,                               // owner
-			&x509.MSPIdentityDeserializer{}, // issuer
+			hybrid.NewTypedIdentityDeserializer(pq.NewTypedIdentityDeserializer(&x509.MSPIdentityDeserializer{})), // issuer
 			m,
 			m,
 		),
-	}
+	}, nil
 }
 
 type PublicParamsDeserializer struct{}
@@ -52,6 +65,8 @@ type EIDRHDeserializer = deserializer.EIDRHDeserializer
 func NewEIDRHDeserializer() *EIDRHDeserializer {
 	d := deserializer.NewEIDRHDeserializer()
 	d.AddDeserializer(msp.X509Identity, &x509.AuditInfoDeserializer{})
+	d.AddDeserializer(msp.DilithiumIdentity, &pq.AuditInfoDeserializer{})
+	d.AddDeserializer(msp.HybridIdentity, &hybrid.AuditInfoDeserializer{})
 	d.AddDeserializer(htlc2.ScriptType, htlc.NewAuditDeserializer(&x509.AuditInfoDeserializer{}))
 	return d
 }
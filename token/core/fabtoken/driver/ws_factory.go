@@ -11,6 +11,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/pkg/errors"
 )
 
 type WalletServiceFactory struct {
@@ -26,8 +27,14 @@ func NewWalletServiceFactory(storageProvider identity.StorageProvider) driver.Na
 	}
 }
 
-func (d *WalletServiceFactory) NewWalletService(tmsConfig driver.Config, _ driver.PublicParameters) (driver.WalletService, error) {
+func (d *WalletServiceFactory) NewWalletService(tmsConfig driver.Config, params driver.PublicParameters) (driver.WalletService, error) {
 	tmsID := tmsConfig.ID()
 	logger := logging.DriverLogger("token-sdk.driver.fabtoken", tmsID.Network, tmsID.Channel, tmsID.Namespace)
-	return d.base.newWalletService(tmsConfig, nil, d.storageProvider, nil, logger, nil, nil, nil, true)
+
+	pp, ok := params.(*fabtoken.PublicParams)
+	if !ok {
+		return nil, errors.Errorf("invalid public parameters type [%T]", params)
+	}
+
+	return d.base.newWalletService(tmsConfig, nil, d.storageProvider, nil, logger, nil, nil, pp, true)
 }
@@ -9,6 +9,7 @@ package fabtoken
 import (
 	"encoding/json"
 	"math"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/pkg/errors"
@@ -29,8 +30,19 @@ type PublicParams struct {
 	QuantityPrecision uint64
 	// This is set when audit is enabled
 	Auditor []byte
+	// AuditorKeys is the history of the auditor keys. The last key is the current Auditor.
+	AuditorKeys driver.AuditorKeys `json:",omitempty"`
+	// AsynchronousAuditing is set when token requests can be committed without the auditor's signature
+	AsynchronousAuditing bool `json:",omitempty"`
+	// AsynchronousAuditingTypes restricts asynchronous auditing to the listed token types.
+	// If empty, the requests of any token type can be committed without the auditor's signature.
+	AsynchronousAuditingTypes []string `json:",omitempty"`
+	// Fee is the fee that must be paid on transfers, if any
+	Fee *driver.FeePolicy `json:",omitempty"`
 	// This encodes the list of authorized issuers
 	Issuers [][]byte
+	// PostQuantumCAs are the post-quantum identities of the CAs that bind enrollment IDs to post-quantum owner keys
+	PostQuantumCAs [][]byte `json:",omitempty"`
 	// MaxToken is the maximum quantity a token can hold
 	MaxToken uint64
 }
@@ -114,11 +126,31 @@ func (pp *PublicParams) AddAuditor(auditor driver.Identity) {
 	pp.Auditor = auditor
 }
 
+// SetAsyncAuditing sets the AsynchronousAuditing field in PublicParams to the passed value
+func (pp *PublicParams) SetAsyncAuditing(async bool) {
+	pp.AsynchronousAuditing = async
+}
+
+// SetAsyncAuditingTypes restricts asynchronous auditing to the passed token types
+func (pp *PublicParams) SetAsyncAuditingTypes(tokenTypes ...string) {
+	pp.AsynchronousAuditingTypes = tokenTypes
+}
+
+// SetFeePolicy sets the fee that must be paid on transfers. A nil policy makes transfers free.
+func (pp *PublicParams) SetFeePolicy(policy *driver.FeePolicy) {
+	pp.Fee = policy
+}
+
 // AddIssuer adds the passed issuer to the array of Issuers in PublicParams
 func (pp *PublicParams) AddIssuer(issuer driver.Identity) {
 	pp.Issuers = append(pp.Issuers, issuer)
 }
 
+// AddPostQuantumCA adds the passed CA to the array of PostQuantumCAs in PublicParams
+func (pp *PublicParams) AddPostQuantumCA(ca driver.Identity) {
+	pp.PostQuantumCAs = append(pp.PostQuantumCAs, ca)
+}
+
 // Auditors returns the list of authorized auditors
 // fabtoken only supports a single auditor
 func (pp *PublicParams) Auditors() []driver.Identity {
@@ -128,6 +160,42 @@ func (pp *PublicParams) Auditors() []driver.Identity {
 	return []driver.Identity{pp.Auditor}
 }
 
+// AsyncAuditing returns true if token requests can be committed without the auditor's signature
+func (pp *PublicParams) AsyncAuditing() bool {
+	return pp.AsynchronousAuditing
+}
+
+// AsyncAuditingTypes returns the token types that can be audited asynchronously, empty for any token type
+func (pp *PublicParams) AsyncAuditingTypes() []string {
+	return pp.AsynchronousAuditingTypes
+}
+
+// FeePolicy returns the fee that must be paid on transfers, nil if transfers are free
+func (pp *PublicParams) FeePolicy() *driver.FeePolicy {
+	return pp.Fee
+}
+
+// AuditorHistory returns the history of the auditor keys.
+// If the auditor has never been rotated, the current auditor, if any, is returned as valid since the beginning.
+func (pp *PublicParams) AuditorHistory() driver.AuditorKeys {
+	if len(pp.AuditorKeys) == 0 && len(pp.Auditor) != 0 {
+		return driver.AuditorKeys{{Identity: pp.Auditor}}
+	}
+	return pp.AuditorKeys
+}
+
+// RotateAuditor replaces the current auditor with the passed one, recording in the history
+// that the current auditor's key stops being valid at the passed time
+func (pp *PublicParams) RotateAuditor(auditor driver.Identity, at time.Time) error {
+	keys, err := pp.AuditorKeys.Rotate(pp.Auditor, auditor, at)
+	if err != nil {
+		return errors.WithMessage(err, "failed rotating auditor")
+	}
+	pp.AuditorKeys = keys
+	pp.Auditor = auditor
+	return nil
+}
+
 // Precision returns the quantity precision encoded in PublicParams
 func (pp *PublicParams) Precision() uint64 {
 	return pp.QuantityPrecision
@@ -138,6 +206,20 @@ func (pp *PublicParams) Validate() error {
 	if pp.MaxToken > pp.ComputeMaxTokenValue() {
 		return errors.Errorf("max token value is invalid [%d]>[%d]", pp.MaxToken, pp.ComputeMaxTokenValue())
 	}
+	if err := pp.AuditorKeys.Validate(pp.Auditor); err != nil {
+		return errors.WithMessage(err, "invalid auditor history")
+	}
+	if len(pp.AsynchronousAuditingTypes) != 0 && !pp.AsynchronousAuditing {
+		return errors.New("asynchronous auditing token types set without asynchronous auditing")
+	}
+	if pp.Fee != nil {
+		if err := pp.Fee.Validate(); err != nil {
+			return err
+		}
+		if pp.Fee.Amount > pp.MaxToken {
+			return errors.Errorf("invalid fee policy: fee exceeds max token value [%d]>[%d]", pp.Fee.Amount, pp.MaxToken)
+		}
+	}
 	return nil
 }
 
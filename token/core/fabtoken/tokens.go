@@ -23,13 +23,18 @@ func NewTokensService() *TokensService {
 	return &TokensService{TokensService: common.NewTokensService()}
 }
 
-// DeserializeToken returns a deserialized token and the identity of its issuer
+// DeserializeToken returns a deserialized token and the identity of its issuer.
+// Without the token metadata, the issuer is not returned.
 func (s *TokensService) DeserializeToken(outputRaw []byte, tokenInfoRaw []byte) (*token2.Token, driver.Identity, error) {
 	tok := &token2.Token{}
 	if err := json.Unmarshal(outputRaw, tok); err != nil {
 		return nil, nil, errors.Wrap(err, "failed unmarshalling token")
 	}
 
+	if len(tokenInfoRaw) == 0 {
+		// the metadata is not on the ledger, the issuer is unknown
+		return tok, nil, nil
+	}
 	tokInfo := &OutputMetadata{}
 	if err := tokInfo.Deserialize(tokenInfoRaw); err != nil {
 		return nil, nil, errors.Wrap(err, "failed unmarshalling token information")
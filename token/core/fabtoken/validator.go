@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package fabtoken
 
 import (
+	"slices"
+
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/logging"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -50,6 +52,7 @@ func NewValidator(logger logging.Logger, pp *PublicParams, deserializer driver.D
 		TransferSignatureValidate,
 		TransferBalanceValidate,
 		TransferHTLCValidate,
+		TransferFeeValidate,
 	}
 	transferValidators = append(transferValidators, extraValidators...)
 
@@ -57,7 +60,7 @@ func NewValidator(logger logging.Logger, pp *PublicParams, deserializer driver.D
 		IssueValidate,
 	}
 
-	return common.NewValidator[*PublicParams, *token.Token, *TransferAction, *IssueAction, driver.Deserializer](
+	v := common.NewValidator[*PublicParams, *token.Token, *TransferAction, *IssueAction, driver.Deserializer](
 		logger,
 		pp,
 		deserializer,
@@ -66,4 +69,26 @@ func NewValidator(logger logging.Logger, pp *PublicParams, deserializer driver.D
 		issueValidators,
 		&common.Serializer{},
 	)
+	v.TokenTypes = TokenTypes
+	return v
+}
+
+// TokenTypes returns the token types of the outputs of the passed actions.
+// A transfer action moves a single token type, therefore its outputs are enough.
+func TokenTypes(issues []*IssueAction, transfers []*TransferAction) []string {
+	var res []string
+	add := func(outputs []*Output) {
+		for _, output := range outputs {
+			if output != nil && !slices.Contains(res, output.Output.Type) {
+				res = append(res, output.Output.Type)
+			}
+		}
+	}
+	for _, issue := range issues {
+		add(issue.Outputs)
+	}
+	for _, transfer := range transfers {
+		add(transfer.Outputs)
+	}
+	return res
 }
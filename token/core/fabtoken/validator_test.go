@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package fabtoken_test
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/fabtoken"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenTypes(t *testing.T) {
+	issues := []*fabtoken.IssueAction{
+		{Outputs: []*fabtoken.Output{{Output: token.Token{Type: "USD"}}, {Output: token.Token{Type: "EUR"}}}},
+	}
+	transfers := []*fabtoken.TransferAction{
+		{Outputs: []*fabtoken.Output{{Output: token.Token{Type: "USD"}}, nil}},
+	}
+	assert.Equal(t, []string{"USD", "EUR"}, fabtoken.TokenTypes(issues, transfers))
+	assert.Empty(t, fabtoken.TokenTypes(nil, nil))
+}
+
+func TestValidator_AsyncAuditingTypes(t *testing.T) {
+	pp, err := fabtoken.Setup()
+	assert.NoError(t, err)
+	pp.AddAuditor([]byte("auditor"))
+	// the issuer check fails right after the auditor's one, this avoids setting up a deserializer
+	pp.AddIssuer([]byte("issuer"))
+	pp.SetAsyncAuditing(true)
+	pp.SetAsyncAuditingTypes("USD")
+	assert.NoError(t, pp.Validate())
+	validator := fabtoken.NewValidator(logging.MustGetLogger("test"), pp, nil)
+
+	request := func(tokenType string) *driver.TokenRequest {
+		action := &fabtoken.IssueAction{
+			Issuer:  []byte("another issuer"),
+			Outputs: []*fabtoken.Output{{Output: token.Token{Owner: []byte("alice"), Type: tokenType, Quantity: "0x0a"}}},
+		}
+		raw, err := action.Serialize()
+		assert.NoError(t, err)
+		return &driver.TokenRequest{Issues: [][]byte{raw}}
+	}
+
+	// USD can be committed without the auditor's signature
+	_, _, err = validator.VerifyTokenRequest(nil, nil, "tx1", request("USD"), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "is not in issuers")
+
+	// EUR requires the auditor's signature
+	_, _, err = validator.VerifyTokenRequest(nil, nil, "tx2", request("EUR"), nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "token type [EUR] requires the auditor's signature")
+
+	// the token types cannot be restricted without asynchronous auditing
+	pp.SetAsyncAuditing(false)
+	assert.Error(t, pp.Validate())
+}
+
+func TestTransferFeeValidate(t *testing.T) {
+	pp, err := fabtoken.Setup()
+	assert.NoError(t, err)
+	collector := []byte("collector")
+	alice, bob := []byte("alice"), []byte("bob")
+	output := func(owner []byte, typ string, value string) *fabtoken.Output {
+		return &fabtoken.Output{Output: token.Token{Owner: owner, Type: typ, Quantity: value}}
+	}
+	validate := func(outputs ...*fabtoken.Output) (*common.FeeTally, error) {
+		ctx := &fabtoken.Context{
+			PP: pp,
+			TransferAction: &fabtoken.TransferAction{
+				InputTokens: []*token.Token{{Owner: alice, Type: "USD", Quantity: "0x640"}},
+				Outputs:     outputs,
+			},
+			Fees: &common.FeeTally{},
+		}
+		return ctx.Fees, fabtoken.TransferFeeValidate(ctx)
+	}
+
+	// no fee policy, nothing is accounted
+	fees, err := validate(output(bob, "USD", "0x3e8"))
+	assert.NoError(t, err)
+	assert.Equal(t, common.FeeTally{}, *fees)
+
+	// 1% with a minimum of 5
+	policy := &driver.FeePolicy{Kind: driver.ProportionalFee, Amount: 5, Rate: 100, TokenType: "USD", Collector: collector}
+	pp.SetFeePolicy(policy)
+	assert.NoError(t, pp.Validate())
+
+	// the value sent to bob is charged, the rest going back to alice is not
+	fees, err = validate(output(bob, "USD", "0x3e8"), output(alice, "USD", "0x1ea"), output(collector, "USD", "0xa"))
+	assert.NoError(t, err)
+	assert.True(t, fees.Charged)
+	assert.Equal(t, uint64(1000), fees.Transferred)
+	assert.Equal(t, uint64(10), fees.Paid)
+	assert.Equal(t, uint64(10), policy.Fee(fees.Transferred))
+
+	// paying the minimum only is not enough
+	fees, err = validate(output(bob, "USD", "0x3e8"), output(alice, "USD", "0x1ef"), output(collector, "USD", "0x5"))
+	assert.NoError(t, err)
+	assert.Less(t, fees.Paid, policy.Fee(fees.Transferred))
+
+	// outputs of another type paying the collector are charged too
+	fees, err = validate(output(collector, "EUR", "0x3e8"))
+	assert.NoError(t, err)
+	assert.True(t, fees.Charged)
+	assert.Equal(t, uint64(1000), fees.Transferred)
+	assert.Equal(t, uint64(0), fees.Paid)
+
+	// redeems and fee outputs are not charged
+	fees, err = validate(output(nil, "USD", "0x3e8"), output(collector, "USD", "0x5"))
+	assert.NoError(t, err)
+	assert.False(t, fees.Charged)
+	assert.Equal(t, uint64(0), fees.Transferred)
+	assert.Equal(t, uint64(5), fees.Paid)
+
+	// invalid quantities are rejected
+	_, err = validate(output(bob, "USD", "not a quantity"))
+	assert.Error(t, err)
+}
+
+func TestFeeTally(t *testing.T) {
+	fees := &common.FeeTally{}
+	assert.NoError(t, fees.Pay(10))
+	assert.NoError(t, fees.Transfer(1000))
+	assert.Error(t, fees.Pay(^uint64(0)))
+	assert.Error(t, fees.Transfer(^uint64(0)))
+	assert.Equal(t, uint64(10), fees.Paid)
+	assert.Equal(t, uint64(1000), fees.Transferred)
+}
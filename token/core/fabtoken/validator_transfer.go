@@ -7,6 +7,7 @@ SPDX-License-Identifier: Apache-2.0
 package fabtoken
 
 import (
+	"bytes"
 	"encoding/json"
 	"time"
 
@@ -165,3 +166,53 @@ func TransferHTLCValidate(ctx *Context) error {
 	}
 	return nil
 }
+
+// TransferFeeValidate accounts the fee paid by the transfer action, if the public parameters define a fee policy.
+// Outputs owned by the fee collector and having the fee token type pay the fee.
+// The values of the other outputs are subject to the fee, unless they are redeemed or go back to the owner of an input,
+// like the rest of the transfer.
+// Whether the token request paid enough is checked once all transfer actions have been validated.
+func TransferFeeValidate(ctx *Context) error {
+	policy := ctx.PP.FeePolicy()
+	if policy == nil {
+		return nil
+	}
+	for _, o := range ctx.TransferAction.GetOutputs() {
+		out, ok := o.(*Output)
+		if !ok {
+			return errors.New("invalid output")
+		}
+		if out.IsRedeem() {
+			continue
+		}
+		q, err := token.ToQuantity(out.Output.Quantity, ctx.PP.QuantityPrecision)
+		if err != nil {
+			return errors.Wrapf(err, "failed parsing quantity [%s]", out.Output.Quantity)
+		}
+		value := q.ToBigInt().Uint64()
+		if policy.IsFeeOutput(out.Output.Owner, out.Output.Type) {
+			if err := ctx.Fees.Pay(value); err != nil {
+				return errors.Wrap(err, "invalid fee output")
+			}
+			continue
+		}
+		ctx.Fees.Charged = true
+		if ownsInput(ctx.TransferAction.InputTokens, out.Output.Owner) {
+			continue
+		}
+		if err := ctx.Fees.Transfer(value); err != nil {
+			return errors.Wrap(err, "invalid output")
+		}
+	}
+	return nil
+}
+
+// ownsInput returns true if the passed owner owns one of the passed input tokens
+func ownsInput(inputs []*token.Token, owner []byte) bool {
+	for _, in := range inputs {
+		if in != nil && bytes.Equal(in.Owner, owner) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package audit
+
+import (
+	"encoding/json"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/pkg/errors"
+)
+
+// DecryptedOutput contains an output token together with the type and value
+// the auditor recovered from its verifiable encryption.
+type DecryptedOutput struct {
+	Token *token.Token
+	Type  string
+	Value uint64
+}
+
+// DecryptOutputs recovers type and value of the outputs of the passed token request from the
+// verifiable encryptions the actions carry. Unlike Check, it does not rely on the token request metadata,
+// therefore it can be used to audit token requests read from the ledger.
+// The validity of the encryptions is assumed to be already checked by the validator.
+// The passed types are the candidate token types the auditor expects.
+func DecryptOutputs(decryptor *encryption.Decryptor, tokenRequest *driver.TokenRequest, types []string) ([][]*DecryptedOutput, [][]*DecryptedOutput, error) {
+	if tokenRequest == nil {
+		return nil, nil, errors.New("token request is nil")
+	}
+	issues := make([][]*DecryptedOutput, len(tokenRequest.Issues))
+	for k, raw := range tokenRequest.Issues {
+		ia := &issue.IssueAction{}
+		if err := json.Unmarshal(raw, ia); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed unmarshalling issue action [%d]", k)
+		}
+		var err error
+		issues[k], err = decryptOutputs(decryptor, ia.OutputTokens, ia.OutputEncryptions, types)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed decrypting issue action [%d]", k)
+		}
+	}
+	transfers := make([][]*DecryptedOutput, len(tokenRequest.Transfers))
+	for k, raw := range tokenRequest.Transfers {
+		ta := &transfer.Action{}
+		if err := json.Unmarshal(raw, ta); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed unmarshalling transfer action [%d]", k)
+		}
+		var err error
+		transfers[k], err = decryptOutputs(decryptor, ta.OutputTokens, ta.OutputEncryptions, types)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed decrypting transfer action [%d]", k)
+		}
+	}
+	return issues, transfers, nil
+}
+
+func decryptOutputs(decryptor *encryption.Decryptor, outputs []*token.Token, encryptions []*encryption.VerifiableEncryption, types []string) ([]*DecryptedOutput, error) {
+	if len(outputs) != len(encryptions) {
+		return nil, errors.Errorf("number of outputs [%d] does not match number of auditor encryptions [%d]", len(outputs), len(encryptions))
+	}
+	res := make([]*DecryptedOutput, len(outputs))
+	for i, output := range outputs {
+		if output == nil {
+			return nil, errors.Errorf("output token at index [%d] is nil", i)
+		}
+		tokenType, value, err := decryptor.Decrypt(encryptions[i], types)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed decrypting output at index [%d]", i)
+		}
+		res[i] = &DecryptedOutput{Token: output, Type: tokenType, Value: value}
+	}
+	return res, nil
+}
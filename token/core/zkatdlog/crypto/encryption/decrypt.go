@@ -0,0 +1,104 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"sync"
+
+	math "github.com/IBM/mathlib"
+	"github.com/pkg/errors"
+)
+
+// Decryptor recovers type and value from a VerifiableEncryption using the auditor secret key
+type Decryptor struct {
+	// PedersenParams are the generators (G_0, G_1, H) used to compute token commitments
+	PedersenParams []*math.G1
+	// SK is the auditor secret key
+	SK *SecretKey
+	// Curve is the elliptic curve in which Pedersen commitments are computed
+	Curve *math.Curve
+
+	once  sync.Once
+	limbs map[string]uint64
+}
+
+// NewDecryptor returns a Decryptor as a function of the passed arguments
+func NewDecryptor(pedersenParams []*math.G1, sk *SecretKey, c *math.Curve) *Decryptor {
+	return &Decryptor{PedersenParams: pedersenParams, SK: sk, Curve: c}
+}
+
+// Decrypt returns the type and the value encrypted in the passed VerifiableEncryption.
+// The type is recovered by matching the decrypted G_0^type against the passed candidate types.
+// Decrypt does not verify the consistency proof, this is the job of the validator.
+func (d *Decryptor) Decrypt(ve *VerifiableEncryption, types []string) (string, uint64, error) {
+	if len(d.PedersenParams) != 3 {
+		return "", 0, errors.Errorf("length of Pedersen basis != 3")
+	}
+	if d.SK == nil || d.SK.X == nil {
+		return "", 0, errors.New("auditor secret key is nil")
+	}
+	if ve == nil || ve.Type == nil {
+		return "", 0, errors.New("auditor encryption is nil")
+	}
+
+	// recover the type
+	m, err := d.decrypt(ve.Type)
+	if err != nil {
+		return "", 0, errors.Wrap(err, "failed to decrypt type")
+	}
+	tokenType := ""
+	found := false
+	for _, t := range types {
+		if d.PedersenParams[0].Mul(d.Curve.HashToZr([]byte(t))).Equals(m) {
+			tokenType = t
+			found = true
+			break
+		}
+	}
+	if !found {
+		return "", 0, errors.New("encrypted type does not match any of the candidate types")
+	}
+
+	// recover the value
+	if len(ve.Value) > 64/LimbBitLength {
+		return "", 0, errors.Errorf("too many value limbs [%d]", len(ve.Value))
+	}
+	d.once.Do(d.precompute)
+	value := uint64(0)
+	for i, ct := range ve.Value {
+		m, err := d.decrypt(ct)
+		if err != nil {
+			return "", 0, errors.Wrapf(err, "failed to decrypt value limb [%d]", i)
+		}
+		l, ok := d.limbs[string(m.Bytes())]
+		if !ok {
+			return "", 0, errors.Errorf("value limb [%d] is out of range", i)
+		}
+		value |= l << (uint(i) * LimbBitLength)
+	}
+	return tokenType, value, nil
+}
+
+// decrypt returns C2 / C1^x
+func (d *Decryptor) decrypt(ct *Ciphertext) (*math.G1, error) {
+	if ct == nil || ct.C1 == nil || ct.C2 == nil {
+		return nil, errors.New("invalid ciphertext")
+	}
+	m := ct.C2.Copy()
+	m.Sub(ct.C1.Mul(d.SK.X))
+	return m, nil
+}
+
+// precompute fills the lookup table G_1^l -> l, for l in [0, 2^LimbBitLength)
+func (d *Decryptor) precompute() {
+	d.limbs = make(map[string]uint64, 1<<LimbBitLength)
+	acc := d.Curve.NewG1()
+	for l := uint64(0); l < 1<<LimbBitLength; l++ {
+		d.limbs[string(acc.Bytes())] = l
+		acc.Add(d.PedersenParams[1])
+	}
+}
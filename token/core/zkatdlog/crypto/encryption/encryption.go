@@ -0,0 +1,332 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"encoding/json"
+	"math/bits"
+
+	math "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/rp"
+	"github.com/pkg/errors"
+)
+
+const (
+	// LimbBitLength is the bit length of each chunk of a token value encrypted for the auditor.
+	// The auditor recovers each chunk by table lookup, therefore the table has 2^LimbBitLength entries.
+	LimbBitLength = 16
+)
+
+// Ciphertext is an ElGamal encryption of a group element M under the auditor encryption key Y = H^x:
+// C1 = H^r, C2 = M * Y^r
+type Ciphertext struct {
+	C1 *math.G1
+	C2 *math.G1
+}
+
+// VerifiableEncryption carries the encryption, under the auditor encryption key, of the type
+// and the value committed in a token, together with a proof that the ciphertexts are consistent
+// with the token's Pedersen commitment.
+// The value is split in limbs of LimbBitLength bits, each encrypted separately.
+// Each limb comes with a range proof, so that the auditor can always recover it by table lookup.
+type VerifiableEncryption struct {
+	// Type encrypts G_0^type
+	Type *Ciphertext
+	// Value encrypts G_1^limb for each limb of the value, the least significant limb first
+	Value []*Ciphertext
+	// Proof shows that Type and Value encrypt the type and the value in the token commitment
+	Proof *ConsistencyProof
+	// LimbProofs contains, for each limb, a range proof that shows that the limb is smaller than 2^LimbBitLength.
+	// The proof is computed for C2 = G_1^limb Y^limbR, seen as a Pedersen commitment with generators (G_1, Y).
+	LimbProofs []*rp.RangeProof
+}
+
+// Serialize marshals VerifiableEncryption
+func (e *VerifiableEncryption) Serialize() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Deserialize unmarshals VerifiableEncryption
+func (e *VerifiableEncryption) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, e)
+}
+
+// SecretKey is the auditor decryption key
+type SecretKey struct {
+	// X is the discrete logarithm of PK in base H
+	X *math.Zr
+	// PK is the auditor encryption key
+	PK *math.G1
+}
+
+// Serialize marshals SecretKey
+func (k *SecretKey) Serialize() ([]byte, error) {
+	return json.Marshal(k)
+}
+
+// Deserialize unmarshals SecretKey
+func (k *SecretKey) Deserialize(raw []byte) error {
+	return json.Unmarshal(raw, k)
+}
+
+// KeyGen generates a new auditor key pair with respect to the passed Pedersen generators (G_0, G_1, H).
+func KeyGen(pedersenParams []*math.G1, c *math.Curve) (*SecretKey, error) {
+	if len(pedersenParams) != 3 {
+		return nil, errors.Errorf("length of Pedersen basis != 3")
+	}
+	rand, err := c.Rand()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get RNG")
+	}
+	x := c.NewRandomZr(rand)
+	return &SecretKey{X: x, PK: pedersenParams[2].Mul(x)}, nil
+}
+
+// NumberOfLimbs returns the number of limbs needed to encrypt a value of the passed bit length
+func NumberOfLimbs(bitLength uint64) int {
+	return int((bitLength + LimbBitLength - 1) / LimbBitLength)
+}
+
+// Witness contains the opening of a token commitment
+type Witness struct {
+	Type           string
+	Value          uint64
+	BlindingFactor *math.Zr
+}
+
+// Encrypter produces verifiable encryptions of token openings
+type Encrypter struct {
+	// PedersenParams are the generators (G_0, G_1, H) used to compute token commitments
+	PedersenParams []*math.G1
+	// PK is the auditor encryption key
+	PK *math.G1
+	// RangeProofParams are the parameters of the token range proofs.
+	// The maximum bit length of a token value is RangeProofParams.BitLength,
+	// the limb range proofs use the first LimbBitLength generators.
+	RangeProofParams *crypto.RangeProofParams
+	// Curve is the elliptic curve in which Pedersen commitments are computed
+	Curve *math.Curve
+}
+
+// NewEncrypter returns an Encrypter as a function of the passed arguments
+func NewEncrypter(pedersenParams []*math.G1, pk *math.G1, rpp *crypto.RangeProofParams, c *math.Curve) *Encrypter {
+	return &Encrypter{PedersenParams: pedersenParams, PK: pk, RangeProofParams: rpp, Curve: c}
+}
+
+// Encrypt returns a VerifiableEncryption of the passed opening of the passed token commitment
+func (e *Encrypter) Encrypt(commitment *math.G1, witness *Witness) (*VerifiableEncryption, error) {
+	if len(e.PedersenParams) != 3 {
+		return nil, errors.Errorf("length of Pedersen basis != 3")
+	}
+	if e.PK == nil {
+		return nil, errors.New("auditor encryption key is nil")
+	}
+	if witness == nil || witness.BlindingFactor == nil {
+		return nil, errors.New("invalid token witness")
+	}
+	if commitment == nil {
+		return nil, errors.New("token commitment is nil")
+	}
+	if err := e.checkRangeProofParams(); err != nil {
+		return nil, err
+	}
+	n := NumberOfLimbs(e.RangeProofParams.BitLength)
+	if n < 4 && witness.Value>>(uint(n)*LimbBitLength) != 0 {
+		return nil, errors.Errorf("value [%d] exceeds [%d] bits", witness.Value, e.RangeProofParams.BitLength)
+	}
+	rand, err := e.Curve.Rand()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get RNG")
+	}
+
+	w := &consistencyWitness{
+		tokenType: e.Curve.HashToZr([]byte(witness.Type)),
+		bf:        witness.BlindingFactor,
+		typeR:     e.Curve.NewRandomZr(rand),
+		limbs:     make([]*math.Zr, n),
+		limbsR:    make([]*math.Zr, n),
+	}
+	ve := &VerifiableEncryption{
+		Type:  e.encrypt(e.PedersenParams[0].Mul(w.tokenType), w.typeR),
+		Value: make([]*Ciphertext, n),
+	}
+	for i := 0; i < n; i++ {
+		w.limbs[i] = e.Curve.NewZrFromUint64(limb(witness.Value, i))
+		w.limbsR[i] = e.Curve.NewRandomZr(rand)
+		ve.Value[i] = e.encrypt(e.PedersenParams[1].Mul(w.limbs[i]), w.limbsR[i])
+	}
+
+	prover := &ConsistencyProver{
+		PedersenParams: e.PedersenParams,
+		PK:             e.PK,
+		Commitment:     commitment,
+		Encryption:     ve,
+		witness:        w,
+		Curve:          e.Curve,
+	}
+	ve.Proof, err = prover.Prove()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to prove consistency of the auditor encryption")
+	}
+	ve.LimbProofs = make([]*rp.RangeProof, n)
+	for i := 0; i < n; i++ {
+		ve.LimbProofs[i], err = e.limbRangeProver(ve.Value[i].C2, limb(witness.Value, i), w.limbsR[i]).Prove()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to prove range of limb [%d]", i)
+		}
+	}
+	return ve, nil
+}
+
+// Verify returns an error if the passed VerifiableEncryption is not consistent with the passed commitment
+func (e *Encrypter) Verify(commitment *math.G1, ve *VerifiableEncryption) error {
+	if ve == nil {
+		return errors.New("auditor encryption is nil")
+	}
+	if err := e.checkRangeProofParams(); err != nil {
+		return err
+	}
+	n := NumberOfLimbs(e.RangeProofParams.BitLength)
+	if len(ve.Value) != n {
+		return errors.Errorf("invalid auditor encryption: expected [%d] value limbs, got [%d]", n, len(ve.Value))
+	}
+	if len(ve.LimbProofs) != n {
+		return errors.Errorf("invalid auditor encryption: expected [%d] limb range proofs, got [%d]", n, len(ve.LimbProofs))
+	}
+	verifier := &ConsistencyVerifier{
+		PedersenParams: e.PedersenParams,
+		PK:             e.PK,
+		Commitment:     commitment,
+		Encryption:     ve,
+		Curve:          e.Curve,
+	}
+	if err := verifier.Verify(ve.Proof); err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		if ve.LimbProofs[i] == nil {
+			return errors.Errorf("invalid auditor encryption: nil range proof for limb [%d]", i)
+		}
+		if err := e.limbRangeVerifier(ve.Value[i].C2).Verify(ve.LimbProofs[i]); err != nil {
+			return errors.Wrapf(err, "invalid range proof for limb [%d]", i)
+		}
+	}
+	return nil
+}
+
+// EncryptAll returns a VerifiableEncryption for each of the passed commitments
+func (e *Encrypter) EncryptAll(commitments []*math.G1, witnesses []*Witness) ([]*VerifiableEncryption, error) {
+	if len(commitments) != len(witnesses) {
+		return nil, errors.Errorf("number of commitments [%d] does not match number of witnesses [%d]", len(commitments), len(witnesses))
+	}
+	res := make([]*VerifiableEncryption, len(commitments))
+	for i := 0; i < len(commitments); i++ {
+		var err error
+		res[i], err = e.Encrypt(commitments[i], witnesses[i])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encrypt output [%d]", i)
+		}
+	}
+	return res, nil
+}
+
+// VerifyAll returns an error if any of the passed VerifiableEncryption is not consistent with the corresponding commitment
+func (e *Encrypter) VerifyAll(commitments []*math.G1, ves []*VerifiableEncryption) error {
+	if len(commitments) != len(ves) {
+		return errors.Errorf("number of outputs [%d] does not match number of auditor encryptions [%d]", len(commitments), len(ves))
+	}
+	for i := 0; i < len(commitments); i++ {
+		if err := e.Verify(commitments[i], ves[i]); err != nil {
+			return errors.Wrapf(err, "invalid auditor encryption for output [%d]", i)
+		}
+	}
+	return nil
+}
+
+func (e *Encrypter) encrypt(m *math.G1, r *math.Zr) *Ciphertext {
+	c2 := m.Copy()
+	c2.Add(e.PK.Mul(r))
+	return &Ciphertext{
+		C1: e.PedersenParams[2].Mul(r),
+		C2: c2,
+	}
+}
+
+func (e *Encrypter) checkRangeProofParams() error {
+	if e.RangeProofParams == nil {
+		return errors.New("range proof parameters are nil")
+	}
+	if uint64(len(e.RangeProofParams.LeftGenerators)) < e.limbBitLength() || uint64(len(e.RangeProofParams.RightGenerators)) < e.limbBitLength() {
+		return errors.New("invalid range proof parameters: not enough generators")
+	}
+	if bitLength := e.RangeProofParams.BitLength; bitLength&(bitLength-1) != 0 {
+		return errors.Errorf("invalid range proof parameters: bit length [%d] is not a power of two", bitLength)
+	}
+	return nil
+}
+
+// limbBitLength returns the bit length proven for each limb.
+// It is smaller than LimbBitLength when the token values are shorter than a limb.
+func (e *Encrypter) limbBitLength() uint64 {
+	if e.RangeProofParams.BitLength < LimbBitLength {
+		return e.RangeProofParams.BitLength
+	}
+	return LimbBitLength
+}
+
+// limbRounds returns the number of rounds of the limb range proofs, that is log2 of limbBitLength, rounded up.
+func (e *Encrypter) limbRounds() uint64 {
+	return uint64(bits.Len64(e.limbBitLength() - 1))
+}
+
+func (e *Encrypter) limbRangeProver(com *math.G1, value uint64, r *math.Zr) rangeProver {
+	bitLength := e.limbBitLength()
+	return rp.NewRangeProver(
+		com,
+		value,
+		[]*math.G1{e.PedersenParams[1], e.PK},
+		r,
+		e.RangeProofParams.LeftGenerators[:bitLength],
+		e.RangeProofParams.RightGenerators[:bitLength],
+		e.RangeProofParams.P,
+		e.RangeProofParams.Q,
+		e.limbRounds(),
+		bitLength,
+		e.Curve,
+	)
+}
+
+func (e *Encrypter) limbRangeVerifier(com *math.G1) rangeVerifier {
+	bitLength := e.limbBitLength()
+	return rp.NewRangeVerifier(
+		com,
+		[]*math.G1{e.PedersenParams[1], e.PK},
+		e.RangeProofParams.LeftGenerators[:bitLength],
+		e.RangeProofParams.RightGenerators[:bitLength],
+		e.RangeProofParams.P,
+		e.RangeProofParams.Q,
+		e.limbRounds(),
+		bitLength,
+		e.Curve,
+	)
+}
+
+type rangeProver interface {
+	Prove() (*rp.RangeProof, error)
+}
+
+type rangeVerifier interface {
+	Verify(*rp.RangeProof) error
+}
+
+func limb(value uint64, i int) uint64 {
+	if uint(i)*LimbBitLength >= 64 {
+		return 0
+	}
+	return (value >> (uint(i) * LimbBitLength)) & (1<<LimbBitLength - 1)
+}
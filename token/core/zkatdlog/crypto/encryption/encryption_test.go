@@ -0,0 +1,155 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	"testing"
+
+	math "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/rp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	pp, err := crypto.Setup(64, []byte("issuerPK"), math.BN254)
+	assert.NoError(t, err)
+	c := math.Curves[pp.Curve]
+	sk, err := KeyGen(pp.PedersenGenerators, c)
+	assert.NoError(t, err)
+
+	rand, err := c.Rand()
+	assert.NoError(t, err)
+	witness := &Witness{Type: "ABC", Value: 0xdeadbeef0042, BlindingFactor: c.NewRandomZr(rand)}
+	com := pp.PedersenGenerators[0].Mul(c.HashToZr([]byte(witness.Type)))
+	com.Add(pp.PedersenGenerators[1].Mul(c.NewZrFromUint64(witness.Value)))
+	com.Add(pp.PedersenGenerators[2].Mul(witness.BlindingFactor))
+
+	e := NewEncrypter(pp.PedersenGenerators, sk.PK, pp.RangeProofParams, c)
+	ve, err := e.Encrypt(com, witness)
+	assert.NoError(t, err)
+	assert.Len(t, ve.Value, 4)
+
+	// serialization round trip and verification
+	raw, err := ve.Serialize()
+	assert.NoError(t, err)
+	ve2 := &VerifiableEncryption{}
+	assert.NoError(t, ve2.Deserialize(raw))
+	assert.NoError(t, e.Verify(com, ve2))
+
+	// decryption
+	d := NewDecryptor(pp.PedersenGenerators, sk, c)
+	tokenType, value, err := d.Decrypt(ve2, []string{"XYZ", "ABC"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ABC", tokenType)
+	assert.Equal(t, witness.Value, value)
+
+	_, _, err = d.Decrypt(ve2, []string{"XYZ"})
+	assert.Error(t, err)
+
+	// the encryption does not match another commitment
+	other := com.Copy()
+	other.Add(pp.PedersenGenerators[1])
+	assert.Error(t, e.Verify(other, ve2))
+
+	// tampered ciphertexts are rejected
+	ve2.Value[1].C2.Add(pp.PedersenGenerators[1])
+	assert.Error(t, e.Verify(com, ve2))
+
+	// the encryption is bound to the auditor key
+	sk2, err := KeyGen(pp.PedersenGenerators, c)
+	assert.NoError(t, err)
+	assert.Error(t, NewEncrypter(pp.PedersenGenerators, sk2.PK, pp.RangeProofParams, c).Verify(com, ve))
+}
+
+func TestEncryptValueOutOfRange(t *testing.T) {
+	pp, err := crypto.Setup(32, []byte("issuerPK"), math.BN254)
+	assert.NoError(t, err)
+	c := math.Curves[pp.Curve]
+	sk, err := KeyGen(pp.PedersenGenerators, c)
+	assert.NoError(t, err)
+	rand, err := c.Rand()
+	assert.NoError(t, err)
+
+	e := NewEncrypter(pp.PedersenGenerators, sk.PK, pp.RangeProofParams, c)
+	_, err = e.Encrypt(c.NewG1(), &Witness{Type: "ABC", Value: 1 << 32, BlindingFactor: c.NewRandomZr(rand)})
+	assert.Error(t, err)
+}
+
+func TestEncryptLimbOutOfRange(t *testing.T) {
+	pp, err := crypto.Setup(32, []byte("issuerPK"), math.BN254)
+	assert.NoError(t, err)
+	c := math.Curves[pp.Curve]
+	sk, err := KeyGen(pp.PedersenGenerators, c)
+	assert.NoError(t, err)
+	rand, err := c.Rand()
+	assert.NoError(t, err)
+
+	// the value 2^16 is encrypted as the limbs (2^16, 0) instead of (0, 1):
+	// the ciphertexts are consistent with the commitment, but the auditor cannot recover the first limb
+	bf := c.NewRandomZr(rand)
+	com := pp.PedersenGenerators[0].Mul(c.HashToZr([]byte("ABC")))
+	com.Add(pp.PedersenGenerators[1].Mul(c.NewZrFromUint64(1 << LimbBitLength)))
+	com.Add(pp.PedersenGenerators[2].Mul(bf))
+
+	e := NewEncrypter(pp.PedersenGenerators, sk.PK, pp.RangeProofParams, c)
+	w := &consistencyWitness{
+		tokenType: c.HashToZr([]byte("ABC")),
+		bf:        bf,
+		typeR:     c.NewRandomZr(rand),
+		limbs:     []*math.Zr{c.NewZrFromUint64(1 << LimbBitLength), c.NewZrFromInt(0)},
+		limbsR:    []*math.Zr{c.NewRandomZr(rand), c.NewRandomZr(rand)},
+	}
+	ve := &VerifiableEncryption{
+		Type:  e.encrypt(pp.PedersenGenerators[0].Mul(w.tokenType), w.typeR),
+		Value: []*Ciphertext{e.encrypt(pp.PedersenGenerators[1].Mul(w.limbs[0]), w.limbsR[0]), e.encrypt(pp.PedersenGenerators[1].Mul(w.limbs[1]), w.limbsR[1])},
+	}
+	ve.Proof, err = (&ConsistencyProver{PedersenParams: pp.PedersenGenerators, PK: sk.PK, Commitment: com, Encryption: ve, witness: w, Curve: c}).Prove()
+	assert.NoError(t, err)
+
+	// without range proofs the encryption is rejected
+	assert.Error(t, e.Verify(com, ve))
+
+	// the range proof of the first limb does not verify
+	ve.LimbProofs = make([]*rp.RangeProof, 2)
+	ve.LimbProofs[0], err = e.limbRangeProver(ve.Value[0].C2, 1<<LimbBitLength, w.limbsR[0]).Prove()
+	assert.NoError(t, err)
+	ve.LimbProofs[1], err = e.limbRangeProver(ve.Value[1].C2, 0, w.limbsR[1]).Prove()
+	assert.NoError(t, err)
+	assert.Error(t, e.Verify(com, ve))
+
+	// the honest encryption of the same value verifies
+	ve, err = e.Encrypt(com, &Witness{Type: "ABC", Value: 1 << LimbBitLength, BlindingFactor: bf})
+	assert.NoError(t, err)
+	assert.NoError(t, e.Verify(com, ve))
+}
+
+func TestEncryptShortBitLength(t *testing.T) {
+	pp, err := crypto.Setup(8, []byte("issuerPK"), math.BN254)
+	assert.NoError(t, err)
+	c := math.Curves[pp.Curve]
+	sk, err := KeyGen(pp.PedersenGenerators, c)
+	assert.NoError(t, err)
+	rand, err := c.Rand()
+	assert.NoError(t, err)
+
+	// the limb range proofs use log2(8) rounds
+	witness := &Witness{Type: "ABC", Value: 200, BlindingFactor: c.NewRandomZr(rand)}
+	com := pp.PedersenGenerators[0].Mul(c.HashToZr([]byte(witness.Type)))
+	com.Add(pp.PedersenGenerators[1].Mul(c.NewZrFromUint64(witness.Value)))
+	com.Add(pp.PedersenGenerators[2].Mul(witness.BlindingFactor))
+	e := NewEncrypter(pp.PedersenGenerators, sk.PK, pp.RangeProofParams, c)
+	ve, err := e.Encrypt(com, witness)
+	assert.NoError(t, err)
+	assert.NoError(t, e.Verify(com, ve))
+
+	// bit lengths that are not a power of two are rejected
+	rpp := *pp.RangeProofParams
+	rpp.BitLength = 6
+	_, err = NewEncrypter(pp.PedersenGenerators, sk.PK, &rpp, c).Encrypt(com, witness)
+	assert.EqualError(t, err, "invalid range proof parameters: bit length [6] is not a power of two")
+}
@@ -0,0 +1,217 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package encryption
+
+import (
+	math "github.com/IBM/mathlib"
+	crypto "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/pkg/errors"
+)
+
+// ConsistencyProof is a zero-knowledge proof that shows that a VerifiableEncryption
+// encrypts the type and the value committed in a token.
+// That is, for commitment C = G_0^type G_1^value H^bf, it shows knowledge of
+// (type, limbs, bf, typeR, limbsR) such that:
+// C = G_0^type G_1^(\sum 2^(16i) limb_i) H^bf,
+// Type = (H^typeR, G_0^type Y^typeR), and
+// Value_i = (H^limbR_i, G_1^limb_i Y^limbR_i)
+type ConsistencyProof struct {
+	// proof of knowledge of the token type
+	Type *math.Zr
+	// proof of knowledge of the blinding factor of the token commitment
+	BlindingFactor *math.Zr
+	// proof of knowledge of the randomness used to encrypt the type
+	TypeRandomness *math.Zr
+	// proof of knowledge of the value limbs
+	Limbs []*math.Zr
+	// proof of knowledge of the randomness used to encrypt the value limbs
+	LimbsRandomness []*math.Zr
+	// challenge used in proof
+	Challenge *math.Zr
+}
+
+// consistencyWitness contains the secret information used to produce ConsistencyProof
+type consistencyWitness struct {
+	tokenType *math.Zr
+	bf        *math.Zr
+	typeR     *math.Zr
+	limbs     []*math.Zr
+	limbsR    []*math.Zr
+}
+
+// ConsistencyProver produces a ConsistencyProof
+type ConsistencyProver struct {
+	// PedersenParams are the generators (G_0, G_1, H) used to compute token commitments
+	PedersenParams []*math.G1
+	// PK is the auditor encryption key
+	PK *math.G1
+	// Commitment is the token commitment
+	Commitment *math.G1
+	// Encryption contains the ciphertexts
+	Encryption *VerifiableEncryption
+	// witness is the secret information used to produce the proof
+	witness *consistencyWitness
+	// Curve is the elliptic curve in which Pedersen commitments are computed
+	Curve *math.Curve
+}
+
+// ConsistencyVerifier checks the validity of ConsistencyProof
+type ConsistencyVerifier struct {
+	// PedersenParams are the generators (G_0, G_1, H) used to compute token commitments
+	PedersenParams []*math.G1
+	// PK is the auditor encryption key
+	PK *math.G1
+	// Commitment is the token commitment
+	Commitment *math.G1
+	// Encryption contains the ciphertexts
+	Encryption *VerifiableEncryption
+	// Curve is the elliptic curve in which Pedersen commitments are computed
+	Curve *math.Curve
+}
+
+// Prove returns a ConsistencyProof
+func (p *ConsistencyProver) Prove() (*ConsistencyProof, error) {
+	rand, err := p.Curve.Rand()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get RNG")
+	}
+	n := len(p.witness.limbs)
+	// generate randomness for the proof
+	randomness := &consistencyWitness{
+		tokenType: p.Curve.NewRandomZr(rand),
+		bf:        p.Curve.NewRandomZr(rand),
+		typeR:     p.Curve.NewRandomZr(rand),
+		limbs:     make([]*math.Zr, n),
+		limbsR:    make([]*math.Zr, n),
+	}
+	for i := 0; i < n; i++ {
+		randomness.limbs[i] = p.Curve.NewRandomZr(rand)
+		randomness.limbsR[i] = p.Curve.NewRandomZr(rand)
+	}
+	// compute commitments to the randomness
+	commitments := computeCommitments(p.PedersenParams, p.PK, randomness, p.Curve)
+
+	// compute challenge
+	chal, err := challenge(p.PK, p.Commitment, p.Encryption, commitments, p.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	// compute proof
+	proof := &ConsistencyProof{
+		Type:            response(p.witness.tokenType, randomness.tokenType, chal, p.Curve),
+		BlindingFactor:  response(p.witness.bf, randomness.bf, chal, p.Curve),
+		TypeRandomness:  response(p.witness.typeR, randomness.typeR, chal, p.Curve),
+		Limbs:           make([]*math.Zr, n),
+		LimbsRandomness: make([]*math.Zr, n),
+		Challenge:       chal,
+	}
+	for i := 0; i < n; i++ {
+		proof.Limbs[i] = response(p.witness.limbs[i], randomness.limbs[i], chal, p.Curve)
+		proof.LimbsRandomness[i] = response(p.witness.limbsR[i], randomness.limbsR[i], chal, p.Curve)
+	}
+	return proof, nil
+}
+
+// Verify returns an error when ConsistencyProof is not valid
+func (v *ConsistencyVerifier) Verify(proof *ConsistencyProof) error {
+	if len(v.PedersenParams) != 3 || v.PK == nil || v.Commitment == nil {
+		return errors.New("invalid auditor encryption verifier")
+	}
+	if proof == nil || proof.Type == nil || proof.BlindingFactor == nil || proof.TypeRandomness == nil || proof.Challenge == nil {
+		return errors.New("invalid auditor encryption proof")
+	}
+	if v.Encryption == nil || v.Encryption.Type == nil || v.Encryption.Type.C1 == nil || v.Encryption.Type.C2 == nil {
+		return errors.New("invalid auditor encryption")
+	}
+	n := len(v.Encryption.Value)
+	if len(proof.Limbs) != n || len(proof.LimbsRandomness) != n {
+		return errors.New("invalid auditor encryption proof")
+	}
+	for i := 0; i < n; i++ {
+		if v.Encryption.Value[i] == nil || v.Encryption.Value[i].C1 == nil || v.Encryption.Value[i].C2 == nil {
+			return errors.New("invalid auditor encryption")
+		}
+		if proof.Limbs[i] == nil || proof.LimbsRandomness[i] == nil {
+			return errors.New("invalid auditor encryption proof")
+		}
+	}
+
+	// recompute commitments from the responses
+	commitments := computeCommitments(v.PedersenParams, v.PK, &consistencyWitness{
+		tokenType: proof.Type,
+		bf:        proof.BlindingFactor,
+		typeR:     proof.TypeRandomness,
+		limbs:     proof.Limbs,
+		limbsR:    proof.LimbsRandomness,
+	}, v.Curve)
+	commitments[0].Sub(v.Commitment.Mul(proof.Challenge))
+	commitments[1].Sub(v.Encryption.Type.C1.Mul(proof.Challenge))
+	commitments[2].Sub(v.Encryption.Type.C2.Mul(proof.Challenge))
+	for i := 0; i < n; i++ {
+		commitments[3+2*i].Sub(v.Encryption.Value[i].C1.Mul(proof.Challenge))
+		commitments[4+2*i].Sub(v.Encryption.Value[i].C2.Mul(proof.Challenge))
+	}
+
+	chal, err := challenge(v.PK, v.Commitment, v.Encryption, commitments, v.Curve)
+	if err != nil {
+		return err
+	}
+	if !chal.Equals(proof.Challenge) {
+		return errors.New("invalid auditor encryption proof")
+	}
+	return nil
+}
+
+// computeCommitments evaluates the relations proven by ConsistencyProof on the passed values.
+// The result is ordered as follows: token commitment, type ciphertext (C1, C2), limb ciphertexts (C1, C2).
+func computeCommitments(pp []*math.G1, pk *math.G1, w *consistencyWitness, c *math.Curve) []*math.G1 {
+	n := len(w.limbs)
+	res := make([]*math.G1, 3+2*n)
+
+	// G_0^type G_1^(\sum 2^(16i) limb_i) H^bf
+	value := c.NewZrFromInt(0)
+	base := c.NewZrFromUint64(1 << LimbBitLength)
+	shift := c.NewZrFromInt(1)
+	for i := 0; i < n; i++ {
+		value = c.ModAdd(value, c.ModMul(w.limbs[i], shift, c.GroupOrder), c.GroupOrder)
+		shift = c.ModMul(shift, base, c.GroupOrder)
+	}
+	res[0] = pp[0].Mul(w.tokenType)
+	res[0].Add(pp[1].Mul(value))
+	res[0].Add(pp[2].Mul(w.bf))
+
+	// H^typeR, G_0^type Y^typeR
+	res[1] = pp[2].Mul(w.typeR)
+	res[2] = pp[0].Mul(w.tokenType)
+	res[2].Add(pk.Mul(w.typeR))
+
+	// H^limbR_i, G_1^limb_i Y^limbR_i
+	for i := 0; i < n; i++ {
+		res[3+2*i] = pp[2].Mul(w.limbsR[i])
+		res[4+2*i] = pp[1].Mul(w.limbs[i])
+		res[4+2*i].Add(pk.Mul(w.limbsR[i]))
+	}
+	return res
+}
+
+func challenge(pk *math.G1, commitment *math.G1, ve *VerifiableEncryption, commitments []*math.G1, c *math.Curve) (*math.Zr, error) {
+	statement := []*math.G1{pk, commitment, ve.Type.C1, ve.Type.C2}
+	for _, ct := range ve.Value {
+		statement = append(statement, ct.C1, ct.C2)
+	}
+	raw, err := crypto.GetG1Array(statement, commitments).Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot compute auditor encryption challenge")
+	}
+	return c.HashToZr(raw), nil
+}
+
+func response(secret, randomness, chal *math.Zr, c *math.Curve) *math.Zr {
+	r := c.ModMul(chal, secret, c.GroupOrder)
+	return c.ModAdd(r, randomness, c.GroupOrder)
+}
@@ -11,6 +11,7 @@ import (
 
 	math "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/rp"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -27,6 +28,9 @@ type IssueAction struct {
 	Proof []byte
 	// Metadata of the issue action
 	Metadata map[string][]byte
+	// OutputEncryptions contains, for each output, the verifiable encryption of its type and value
+	// under the auditor encryption key. It is set only when the public parameters define such a key.
+	OutputEncryptions []*encryption.VerifiableEncryption `json:",omitempty"`
 }
 
 // GetProof returns IssueAction ZKP
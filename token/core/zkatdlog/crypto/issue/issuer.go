@@ -10,6 +10,7 @@ import (
 	math "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/pkg/errors"
@@ -68,6 +69,21 @@ func (i *Issuer) GenerateZKIssue(values []uint64, owners [][]byte) (*IssueAction
 	if err != nil {
 		return nil, nil, err
 	}
+	if i.PublicParams.AuditorEncryptionKey != nil {
+		w := make([]*encryption.Witness, len(tw))
+		for j := 0; j < len(tw); j++ {
+			w[j] = &encryption.Witness{Type: tw[j].Type, Value: tw[j].Value, BlindingFactor: tw[j].BlindingFactor}
+		}
+		issue.OutputEncryptions, err = encryption.NewEncrypter(
+			i.PublicParams.PedersenGenerators,
+			i.PublicParams.AuditorEncryptionKey,
+			i.PublicParams.RangeProofParams,
+			math.Curves[i.PublicParams.Curve],
+		).EncryptAll(tokens, w)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to encrypt outputs for the auditor")
+		}
+	}
 
 	inf := make([]*token.Metadata, len(values))
 	for j := 0; j < len(inf); j++ {
@@ -12,6 +12,7 @@ import (
 	"math"
 	"math/big"
 	"strconv"
+	"time"
 
 	mathlib "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -95,6 +96,19 @@ type PublicParams struct {
 	IdemixIssuerPK []byte
 	// Auditor is the public key of the auditor.
 	Auditor []byte
+	// AuditorKeys is the history of the auditor keys with their validity periods.
+	// When set, the last key is the current Auditor.
+	AuditorKeys driver.AuditorKeys `json:",omitempty"`
+	// AuditorEncryptionKey is the key under which the type and the value of each output are encrypted for the auditor.
+	// When set, each output must carry a verifiable encryption of its type and value,
+	// and the auditor can inspect the token requests from the ledger alone.
+	AuditorEncryptionKey *mathlib.G1 `json:",omitempty"`
+	// AsynchronousAuditing is set when token requests can be committed without the auditor's signature.
+	// The auditor then inspects the committed token requests afterward.
+	AsynchronousAuditing bool `json:",omitempty"`
+	// Fee is the fee that must be paid on transfers, if any.
+	// Fee outputs are opened to the validators that can then check the minimum fee has been paid.
+	Fee *driver.FeePolicy `json:",omitempty"`
 	// Issuers is a list of public keys of the entities that can issue tokens.
 	Issuers [][]byte
 	// MaxToken is the maximum quantity a token can hold
@@ -160,6 +174,45 @@ func (pp *PublicParams) Auditors() []driver.Identity {
 	return []driver.Identity{pp.Auditor}
 }
 
+// AsyncAuditing returns true if token requests can be committed without the auditor's signature.
+// This requires the auditor encryption key, because the auditor inspects the committed outputs from the ledger alone.
+func (pp *PublicParams) AsyncAuditing() bool {
+	return pp.AsynchronousAuditing && pp.AuditorEncryptionKey != nil
+}
+
+// AsyncAuditingTypes returns nil, that is any token type, when asynchronous auditing is enabled.
+// The token types are hidden from the validators, therefore asynchronous auditing cannot be restricted by token type.
+// Instead, the type and the value of each output are encrypted for the auditor.
+func (pp *PublicParams) AsyncAuditingTypes() []string {
+	return nil
+}
+
+// FeePolicy returns the fee that must be paid on transfers, nil if transfers are free
+func (pp *PublicParams) FeePolicy() *driver.FeePolicy {
+	return pp.Fee
+}
+
+// AuditorHistory returns the history of the auditor keys.
+// If the auditor has never been rotated, the current auditor, if any, is returned as valid since the beginning.
+func (pp *PublicParams) AuditorHistory() driver.AuditorKeys {
+	if len(pp.AuditorKeys) == 0 && len(pp.Auditor) != 0 {
+		return driver.AuditorKeys{{Identity: pp.Auditor}}
+	}
+	return pp.AuditorKeys
+}
+
+// RotateAuditor replaces the current auditor with the passed one, recording in the history
+// that the current auditor's key stops being valid at the passed time
+func (pp *PublicParams) RotateAuditor(auditor driver.Identity, at time.Time) error {
+	keys, err := pp.AuditorKeys.Rotate(pp.Auditor, auditor, at)
+	if err != nil {
+		return errors.WithMessage(err, "failed rotating auditor")
+	}
+	pp.AuditorKeys = keys
+	pp.Auditor = auditor
+	return nil
+}
+
 func (pp *PublicParams) Serialize() ([]byte, error) {
 	raw, err := json.Marshal(pp)
 	if err != nil {
@@ -227,6 +280,21 @@ func (pp *PublicParams) AddAuditor(auditor driver.Identity) {
 	pp.Auditor = auditor
 }
 
+// SetAuditorEncryptionKey sets the key under which the outputs are encrypted for the auditor
+func (pp *PublicParams) SetAuditorEncryptionKey(pk *mathlib.G1) {
+	pp.AuditorEncryptionKey = pk
+}
+
+// SetAsyncAuditing sets whether token requests can be committed without the auditor's signature
+func (pp *PublicParams) SetAsyncAuditing(async bool) {
+	pp.AsynchronousAuditing = async
+}
+
+// SetFeePolicy sets the fee that must be paid on transfers. A nil policy makes transfers free.
+func (pp *PublicParams) SetFeePolicy(policy *driver.FeePolicy) {
+	pp.Fee = policy
+}
+
 func (pp *PublicParams) AddIssuer(id driver.Identity) {
 	pp.Issuers = append(pp.Issuers, id)
 }
@@ -292,10 +360,31 @@ func (pp *PublicParams) Validate() error {
 	if len(pp.IdemixIssuerPK) == 0 {
 		return errors.New("invalid public parameters: empty idemix issuer")
 	}
+	if pp.AuditorEncryptionKey != nil && pp.AuditorEncryptionKey.IsInfinity() {
+		return errors.New("invalid public parameters: auditor encryption key is the identity element")
+	}
+	if pp.AsynchronousAuditing && pp.AuditorEncryptionKey == nil {
+		return errors.New("invalid public parameters: asynchronous auditing requires the auditor encryption key")
+	}
+	if err := pp.AuditorKeys.Validate(pp.Auditor); err != nil {
+		return errors.WithMessage(err, "invalid public parameters: invalid auditor history")
+	}
 	maxToken := pp.ComputeMaxTokenValue()
 	if maxToken != pp.MaxToken {
 		return errors.Errorf("invalid maxt token, [%d]!=[%d]", maxToken, pp.MaxToken)
 	}
+	if pp.Fee != nil {
+		if err := pp.Fee.Validate(); err != nil {
+			return errors.WithMessage(err, "invalid public parameters")
+		}
+		if pp.Fee.Amount > pp.MaxToken {
+			return errors.Errorf("invalid public parameters: fee exceeds max token value [%d]>[%d]", pp.Fee.Amount, pp.MaxToken)
+		}
+		// the validators cannot see the transferred value, therefore they cannot enforce a proportional fee
+		if pp.Fee.Kind == driver.ProportionalFee {
+			return errors.New("invalid public parameters: proportional fees are not supported, the transferred value is hidden")
+		}
+	}
 	// if len(pp.Issuers) == 0 {
 	//	return errors.New("invalid public parameters: empty list of issuers")
 	// }
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	math3 "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,3 +78,29 @@ func TestNewG1(t *testing.T) {
 		assert.Equal(t, c.NewG1().IsInfinity(), true)
 	}
 }
+
+func TestValidateAsyncAuditing(t *testing.T) {
+	pp, err := Setup(32, []byte("issuerPK"), math3.BN254)
+	assert.NoError(t, err)
+
+	// asynchronous auditing requires the auditor encryption key
+	pp.SetAsyncAuditing(true)
+	assert.False(t, pp.AsyncAuditing())
+	assert.EqualError(t, pp.Validate(), "invalid public parameters: asynchronous auditing requires the auditor encryption key")
+
+	pp.SetAuditorEncryptionKey(pp.PedersenGenerators[1].Mul(math3.Curves[pp.Curve].NewZrFromInt(7)))
+	assert.True(t, pp.AsyncAuditing())
+	assert.Nil(t, pp.AsyncAuditingTypes())
+	assert.NoError(t, pp.Validate())
+}
+
+func TestValidateFeePolicy(t *testing.T) {
+	pp, err := Setup(32, []byte("issuerPK"), math3.BN254)
+	assert.NoError(t, err)
+
+	pp.SetFeePolicy(&driver.FeePolicy{Kind: driver.FlatFee, Amount: 5, TokenType: "USD", Collector: driver.Identity("collector")})
+	assert.NoError(t, pp.Validate())
+
+	pp.SetFeePolicy(&driver.FeePolicy{Kind: driver.ProportionalFee, Amount: 5, Rate: 100, TokenType: "USD", Collector: driver.Identity("collector")})
+	assert.EqualError(t, pp.Validate(), "invalid public parameters: proportional fees are not supported, the transferred value is hidden")
+}
@@ -12,6 +12,7 @@ import (
 
 	math "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
@@ -96,6 +97,13 @@ func (s *Sender) GenerateZKTransfer(ctx context.Context, values []uint64, owners
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "failed to produce transfer action")
 	}
+	if s.PublicParams.AuditorEncryptionKey != nil {
+		span.AddEvent("encrypt_outputs_for_auditor")
+		transfer.OutputEncryptions, err = EncryptOutputs(out, outtw, s.PublicParams)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to encrypt outputs for the auditor")
+		}
+	}
 	inf := make([]*token.Metadata, len(owners))
 	for i := 0; i < len(inf); i++ {
 		inf[i] = &token.Metadata{
@@ -133,6 +141,22 @@ type Action struct {
 	Proof []byte
 	// Metadata contains the transfer action's metadata
 	Metadata map[string][]byte
+	// OutputEncryptions contains, for each output, the verifiable encryption of its type and value
+	// under the auditor encryption key. It is set only when the public parameters define such a key.
+	OutputEncryptions []*encryption.VerifiableEncryption `json:",omitempty"`
+	// FeeOpenings contains the openings of the outputs paying the fee collector.
+	// It is set only when the public parameters define a fee policy.
+	FeeOpenings []*FeeOpening `json:",omitempty"`
+}
+
+// FeeOpening reveals the value of an output paying the fee collector, so that validators can check the fee
+type FeeOpening struct {
+	// Index is the index of the output in the transfer action
+	Index int
+	// Value is the value of the output
+	Value uint64
+	// BlindingFactor is the randomness used to commit to the output
+	BlindingFactor *math.Zr
 }
 
 // NewTransfer returns the Action that matches the passed arguments
@@ -249,6 +273,21 @@ func (t *Action) GetMetadata() map[string][]byte {
 	return t.Metadata
 }
 
+// EncryptOutputs returns the verifiable encryptions, under the auditor encryption key, of the passed outputs
+func EncryptOutputs(outputs []*math.G1, witness []*token.TokenDataWitness, pp *crypto.PublicParams) ([]*encryption.VerifiableEncryption, error) {
+	if len(outputs) != len(witness) {
+		return nil, errors.Errorf("number of outputs [%d] does not match number of witnesses [%d]", len(outputs), len(witness))
+	}
+	w := make([]*encryption.Witness, len(witness))
+	for i, tw := range witness {
+		if tw == nil {
+			return nil, errors.New("invalid token witness")
+		}
+		w[i] = &encryption.Witness{Type: tw.Type, Value: tw.Value, BlindingFactor: tw.BlindingFactor}
+	}
+	return encryption.NewEncrypter(pp.PedersenGenerators, pp.AuditorEncryptionKey, pp.RangeProofParams, math.Curves[pp.Curve]).EncryptAll(outputs, w)
+}
+
 func getTokenData(tokens []*token.Token) []*math.G1 {
 	tokenData := make([]*math.G1, len(tokens))
 	for i := 0; i < len(tokens); i++ {
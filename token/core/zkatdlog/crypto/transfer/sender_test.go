@@ -12,6 +12,7 @@ import (
 
 	math "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	transfer2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer/mock"
@@ -108,6 +109,28 @@ var _ = Describe("Sender", func() {
 				Expect(err).NotTo(HaveOccurred())
 			})
 		})
+		When("the public parameters define an auditor encryption key", func() {
+			It("each output carries a verifiable encryption", func() {
+				c := math.Curves[pp.Curve]
+				sk, err := encryption.KeyGen(pp.PedersenGenerators, c)
+				Expect(err).NotTo(HaveOccurred())
+				pp.SetAuditorEncryptionKey(sk.PK)
+
+				transfer, _, err = sender.GenerateZKTransfer(context.TODO(), outvalues, owners)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(transfer.OutputEncryptions).To(HaveLen(2))
+				e := encryption.NewEncrypter(pp.PedersenGenerators, pp.AuditorEncryptionKey, pp.RangeProofParams, c)
+				Expect(e.VerifyAll(transfer.GetOutputCommitments(), transfer.OutputEncryptions)).To(Succeed())
+
+				d := encryption.NewDecryptor(pp.PedersenGenerators, sk, c)
+				for i, ve := range transfer.OutputEncryptions {
+					tokenType, value, err := d.Decrypt(ve, []string{"ABC"})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(tokenType).To(Equal("ABC"))
+					Expect(value).To(Equal(outvalues[i]))
+				}
+			})
+		})
 		When("when signature fails", func() {
 			BeforeEach(func() {
 				fakeSigningIdentity.SignReturnsOnCall(2, nil, errors.New("banana republic"))
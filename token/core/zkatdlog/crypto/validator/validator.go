@@ -53,11 +53,14 @@ func New(logger logging.Logger, pp *crypto.PublicParams, deserializer driver.Des
 		TransferSignatureValidate,
 		TransferZKProofValidate,
 		TransferHTLCValidate,
+		TransferAuditorEncryptionValidate,
+		TransferFeeValidate,
 	}
 	transferValidators = append(transferValidators, extraValidators...)
 
 	issueValidators := []ValidateIssueFunc{
 		IssueValidate,
+		IssueAuditorEncryptionValidate,
 	}
 
 	return common.NewValidator[*crypto.PublicParams, *token.Token, *transfer.Action, *issue.IssueAction, driver.Deserializer](
@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package validator
+
+import (
+	math "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
+	"github.com/pkg/errors"
+)
+
+// TransferAuditorEncryptionValidate checks that, when the public parameters define an auditor encryption key,
+// each output of the transfer action carries a valid verifiable encryption of its type and value.
+func TransferAuditorEncryptionValidate(ctx *Context) error {
+	if ctx.PP.AuditorEncryptionKey == nil {
+		return nil
+	}
+	if err := newEncrypter(ctx.PP).VerifyAll(ctx.TransferAction.GetOutputCommitments(), ctx.TransferAction.OutputEncryptions); err != nil {
+		return errors.Wrap(err, "invalid transfer action")
+	}
+	return nil
+}
+
+// IssueAuditorEncryptionValidate checks that, when the public parameters define an auditor encryption key,
+// each output of the issue action carries a valid verifiable encryption of its type and value.
+func IssueAuditorEncryptionValidate(ctx *Context) error {
+	if ctx.PP.AuditorEncryptionKey == nil {
+		return nil
+	}
+	commitments, err := ctx.IssueAction.GetCommitments()
+	if err != nil {
+		return errors.New("failed to verify issue")
+	}
+	if err := newEncrypter(ctx.PP).VerifyAll(commitments, ctx.IssueAction.OutputEncryptions); err != nil {
+		return errors.Wrap(err, "invalid issue action")
+	}
+	return nil
+}
+
+func newEncrypter(pp *crypto.PublicParams) *encryption.Encrypter {
+	return encryption.NewEncrypter(pp.PedersenGenerators, pp.AuditorEncryptionKey, pp.RangeProofParams, math.Curves[pp.Curve])
+}
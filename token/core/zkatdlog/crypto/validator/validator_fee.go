@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package validator
+
+import (
+	math "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/pkg/errors"
+)
+
+// TransferFeeValidate accounts the fee paid by the transfer action, if the public parameters define a fee policy.
+// The outputs paying the fee collector are opened by the action: each opening must match the commitment of
+// an output owned by the fee collector and having the fee token type.
+// Whether the token request paid enough is checked once all transfer actions have been validated.
+func TransferFeeValidate(ctx *Context) error {
+	policy := ctx.PP.FeePolicy()
+	if policy == nil {
+		if len(ctx.TransferAction.FeeOpenings) != 0 {
+			return errors.New("invalid transfer action: fee openings not expected")
+		}
+		return nil
+	}
+	outputs := ctx.TransferAction.OutputTokens
+	opened := make(map[int]bool, len(ctx.TransferAction.FeeOpenings))
+	for _, opening := range ctx.TransferAction.FeeOpenings {
+		if opening == nil || opening.BlindingFactor == nil {
+			return errors.New("invalid transfer action: invalid fee opening")
+		}
+		if opening.Index < 0 || opening.Index >= len(outputs) {
+			return errors.Errorf("invalid transfer action: fee opening index [%d] out of range", opening.Index)
+		}
+		if opened[opening.Index] {
+			return errors.Errorf("invalid transfer action: output [%d] opened more than once", opening.Index)
+		}
+		opened[opening.Index] = true
+
+		output := outputs[opening.Index]
+		if output == nil || output.Data == nil || !policy.IsFeeOutput(output.Owner, policy.TokenType) {
+			return errors.Errorf("invalid transfer action: output [%d] is not owned by the fee collector", opening.Index)
+		}
+		if _, err := output.GetTokenInTheClear(&token.Metadata{
+			Type:           policy.TokenType,
+			Value:          math.Curves[ctx.PP.Curve].NewZrFromUint64(opening.Value),
+			BlindingFactor: opening.BlindingFactor,
+		}, ctx.PP); err != nil {
+			return errors.Wrapf(err, "invalid transfer action: fee opening does not match output [%d]", opening.Index)
+		}
+		if err := ctx.Fees.Pay(opening.Value); err != nil {
+			return errors.Wrap(err, "invalid transfer action")
+		}
+	}
+	for i, output := range outputs {
+		if !opened[i] && output != nil && !output.IsRedeem() {
+			ctx.Fees.Charged = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package validator_test
+
+import (
+	"context"
+	"os"
+
+	math "github.com/IBM/mathlib"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	tokn "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
+	enginedlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/validator"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("fee validation", func() {
+	var (
+		pp        *crypto.PublicParams
+		action    *transfer.Action
+		outputs   []*tokn.Metadata
+		collector = driver.Identity("collector")
+	)
+
+	validate := func() (*common.FeeTally, error) {
+		fees := &common.FeeTally{}
+		err := enginedlog.TransferFeeValidate(&enginedlog.Context{
+			Logger:         logging.MustGetLogger("validator"),
+			PP:             pp,
+			TransferAction: action,
+			Fees:           fees,
+		})
+		return fees, err
+	}
+
+	BeforeEach(func() {
+		ipk, err := os.ReadFile("./testdata/idemix/msp/IssuerPublicKey")
+		Expect(err).NotTo(HaveOccurred())
+		pp, err = crypto.Setup(32, ipk, math.FP256BN_AMCL)
+		Expect(err).NotTo(HaveOccurred())
+		pp.SetFeePolicy(&driver.FeePolicy{Kind: driver.FlatFee, Amount: 5, TokenType: "ABC", Collector: collector})
+		Expect(pp.Validate()).To(Succeed())
+
+		// a transfer of 100 ABC paying 5 to the fee collector
+		c := math.Curves[pp.Curve]
+		rand, err := c.Rand()
+		Expect(err).NotTo(HaveOccurred())
+		value := c.NewZrFromInt(100)
+		bf := c.NewRandomZr(rand)
+		inputs := prepareTokens([]*math.Zr{value}, []*math.Zr{bf}, "ABC", pp.PedersenGenerators, c)
+		sender, err := transfer.NewSender(
+			nil,
+			[]*tokn.Token{{Data: inputs[0], Owner: []byte("alice")}},
+			[]*token2.ID{{TxId: "0"}},
+			[]*tokn.Metadata{{Type: "ABC", Value: value, BlindingFactor: bf}},
+			pp,
+		)
+		Expect(err).NotTo(HaveOccurred())
+		action, outputs, err = sender.GenerateZKTransfer(context.TODO(), []uint64{95, 5}, [][]byte{[]byte("bob"), collector})
+		Expect(err).NotTo(HaveOccurred())
+		action.FeeOpenings = []*transfer.FeeOpening{{Index: 1, Value: 5, BlindingFactor: outputs[1].BlindingFactor}}
+	})
+
+	It("accounts the opened fee output", func() {
+		fees, err := validate()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fees.Paid).To(Equal(uint64(5)))
+		Expect(fees.Charged).To(BeTrue())
+	})
+
+	It("rejects an opening that does not match the output", func() {
+		action.FeeOpenings[0].Value = 50
+		_, err := validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fee opening does not match output [1]"))
+	})
+
+	It("rejects an opening of an output not owned by the fee collector", func() {
+		action.FeeOpenings[0] = &transfer.FeeOpening{Index: 0, Value: 95, BlindingFactor: outputs[0].BlindingFactor}
+		_, err := validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("output [0] is not owned by the fee collector"))
+	})
+
+	It("rejects an output opened twice", func() {
+		action.FeeOpenings = append(action.FeeOpenings, action.FeeOpenings[0])
+		_, err := validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("output [1] opened more than once"))
+	})
+
+	It("does not account unopened fee outputs", func() {
+		action.FeeOpenings = nil
+		fees, err := validate()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(fees.Paid).To(BeZero())
+		Expect(fees.Charged).To(BeTrue())
+	})
+
+	It("rejects fee openings when there is no fee policy", func() {
+		pp.SetFeePolicy(nil)
+		_, err := validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fee openings not expected"))
+	})
+})
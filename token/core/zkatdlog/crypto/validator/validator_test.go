@@ -24,6 +24,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/audit"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/ecdsa"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	issue2 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/issue"
 	tokn "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/transfer"
@@ -143,6 +144,41 @@ var _ = Describe("validator", func() {
 			})
 		})
 
+		Context("Validator is called with an issue action without the auditor's signature", func() {
+			var (
+				err error
+				raw []byte
+			)
+			BeforeEach(func() {
+				ir.AuditorSignatures = nil
+				raw, err = asn1.Marshal(*ir)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("fails", func() {
+				_, _, err := engine.VerifyTokenRequestFromRaw(context.TODO(), fakeLedger.GetStateStub, "1", raw)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("failed to verifier auditor's signature"))
+			})
+			When("the public parameters allow asynchronous auditing", func() {
+				BeforeEach(func() {
+					// the outputs are encrypted for the auditor that inspects them once committed
+					sk, err := encryption.KeyGen(pp.PedersenGenerators, math.Curves[pp.Curve])
+					Expect(err).NotTo(HaveOccurred())
+					pp.SetAuditorEncryptionKey(sk.PK)
+					pp.SetAsyncAuditing(true)
+					_, ir, _ = prepareNonAnonymousIssueRequest(pp, auditor)
+					ir.AuditorSignatures = nil
+					raw, err = asn1.Marshal(*ir)
+					Expect(err).NotTo(HaveOccurred())
+				})
+				It("succeeds", func() {
+					actions, _, err := engine.VerifyTokenRequestFromRaw(context.TODO(), fakeLedger.GetStateStub, "1", raw)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(len(actions)).To(Equal(1))
+				})
+			})
+		})
+
 		Context("validator is called correctly with a transfer action", func() {
 			var (
 				err error
@@ -176,6 +212,16 @@ var _ = Describe("validator", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(len(actions)).To(Equal(1))
 			})
+			When("the public parameters require a fee", func() {
+				BeforeEach(func() {
+					pp.SetFeePolicy(&driver.FeePolicy{Kind: driver.FlatFee, Amount: 5, TokenType: "ABC", Collector: driver.Identity("collector")})
+				})
+				It("fails because no fee is paid", func() {
+					_, _, err := engine.VerifyTokenRequestFromRaw(context.TODO(), getState, "1", raw)
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("insufficient fee, paid [0], expected at least [5]"))
+				})
+			})
 		})
 		Context("validator is called correctly with a redeem action", func() {
 			var (
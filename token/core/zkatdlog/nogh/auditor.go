@@ -8,6 +8,7 @@ package nogh
 
 import (
 	"context"
+	"sync"
 
 	math "github.com/IBM/mathlib"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
@@ -15,6 +16,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/logging"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/audit"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
@@ -22,13 +24,23 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
+// AuditorEncryptionKeyPath is the configuration key, relative to the TMS, of the path of the auditor encryption
+// secret key, as generated by tokengen with the auditor-encryption flag
+const AuditorEncryptionKeyPath = "services.auditor.encryption.key"
+
 type AuditorService struct {
 	Logger                  logging.Logger
 	PublicParametersManager common.PublicParametersManager[*crypto.PublicParams]
 	TokenCommitmentLoader   TokenCommitmentLoader
 	Deserializer            driver.Deserializer
 	Metrics                 *Metrics
-	tracer                  trace.Tracer
+	// EncryptionKey is the auditor secret key used to decrypt the outputs of the token requests read from the ledger.
+	// It is nil when the node is not the holder of the auditor encryption key.
+	EncryptionKey *encryption.SecretKey
+	tracer        trace.Tracer
+
+	decryptorLock sync.Mutex
+	decryptor     *encryption.Decryptor
 }
 
 func NewAuditorService(
@@ -37,6 +49,7 @@ func NewAuditorService(
 	tokenCommitmentLoader TokenCommitmentLoader,
 	deserializer driver.Deserializer,
 	metrics *Metrics,
+	encryptionKey *encryption.SecretKey,
 	tracerProvider trace.TracerProvider,
 ) *AuditorService {
 	return &AuditorService{
@@ -45,6 +58,7 @@ func NewAuditorService(
 		TokenCommitmentLoader:   tokenCommitmentLoader,
 		Deserializer:            deserializer,
 		Metrics:                 metrics,
+		EncryptionKey:           encryptionKey,
 		tracer:                  tracerProvider.Tracer("auditor_service", tracing.WithMetricsOpts(tracing.MetricsOpts{Namespace: "nogh"})),
 	}
 }
@@ -104,3 +118,57 @@ func (s *AuditorService) AuditorCheck(ctx context.Context, request *driver.Token
 
 	return nil
 }
+
+// LedgerOutputs decrypts, with the auditor encryption key, type and value of the outputs of the passed request.
+// The public parameters must define an auditor encryption key, and this node must hold the corresponding secret key.
+func (s *AuditorService) LedgerOutputs(ctx context.Context, request *driver.TokenRequest, types []string) ([][]*token2.Token, [][]*token2.Token, error) {
+	_, span := s.tracer.Start(ctx, "ledger_outputs")
+	defer span.End()
+
+	pp := s.PublicParametersManager.PublicParams()
+	decryptor, err := s.getDecryptor(pp)
+	if err != nil {
+		return nil, nil, err
+	}
+	span.AddEvent("decrypt_outputs")
+	issues, transfers, err := audit.DecryptOutputs(decryptor, request, types)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed to decrypt outputs")
+	}
+	return ledgerTokens(issues), ledgerTokens(transfers), nil
+}
+
+// getDecryptor returns a decryptor for the passed public parameters.
+// The decryptor is cached, because it builds a lookup table on first use.
+func (s *AuditorService) getDecryptor(pp *crypto.PublicParams) (*encryption.Decryptor, error) {
+	if pp.AuditorEncryptionKey == nil {
+		return nil, errors.New("public parameters do not define an auditor encryption key")
+	}
+	if s.EncryptionKey == nil || s.EncryptionKey.PK == nil {
+		return nil, errors.New("auditor encryption key not configured")
+	}
+	if !s.EncryptionKey.PK.Equals(pp.AuditorEncryptionKey) {
+		return nil, errors.New("the configured auditor encryption key does not match the public parameters")
+	}
+	s.decryptorLock.Lock()
+	defer s.decryptorLock.Unlock()
+	if s.decryptor == nil || !s.decryptor.PedersenParams[1].Equals(pp.PedersenGenerators[1]) {
+		s.decryptor = encryption.NewDecryptor(pp.PedersenGenerators, s.EncryptionKey, math.Curves[pp.Curve])
+	}
+	return s.decryptor, nil
+}
+
+func ledgerTokens(actions [][]*audit.DecryptedOutput) [][]*token2.Token {
+	res := make([][]*token2.Token, len(actions))
+	for i, outputs := range actions {
+		res[i] = make([]*token2.Token, len(outputs))
+		for j, output := range outputs {
+			res[i][j] = &token2.Token{
+				Owner:    output.Token.Owner,
+				Type:     output.Type,
+				Quantity: token2.NewQuantityFromUInt64(output.Value).Hex(),
+			}
+		}
+	}
+	return res
+}
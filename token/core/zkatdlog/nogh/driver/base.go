@@ -17,6 +17,8 @@ import (
 	config2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/config"
 	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/sig"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
 	"github.com/pkg/errors"
@@ -56,6 +58,8 @@ func (d *base) newWalletService(
 	// Prepare roles
 	roles := identity.NewRoles()
 	deserializerManager := sig.NewMultiplexDeserializer()
+	deserializerManager.AddDeserializer(&pq.Deserializer{})
+	deserializerManager.AddDeserializer(&hybrid.Deserializer{})
 	tmsID := tmsConfig.ID()
 	identityDB, err := storageProvider.OpenIdentityDB(tmsID)
 	if err != nil {
@@ -13,7 +13,9 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/deserializer"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/interop/htlc"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/idemix"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
 	htlc2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/interop/htlc"
 	"github.com/pkg/errors"
@@ -35,6 +37,8 @@ func NewDeserializer(pp *crypto.PublicParams) (*Deserializer, error) {
 	}
 	m := deserializer.NewTypedVerifierDeserializerMultiplex(idemixDes)
 	m.AddTypedVerifierDeserializer(msp.IdemixIdentity, deserializer.NewTypedIdentityVerifierDeserializer(idemixDes))
+	m.AddTypedVerifierDeserializer(msp.DilithiumIdentity, deserializer.NewTypedIdentityVerifierDeserializer(&pq.IdentityDeserializer{}))
+	m.AddTypedVerifierDeserializer(msp.HybridIdentity, deserializer.NewTypedIdentityVerifierDeserializer(&hybrid.IdentityDeserializer{}))
 	m.AddTypedVerifierDeserializer(htlc2.ScriptType, htlc.NewTypedIdentityDeserializer(m))
 
 	return &Deserializer{
@@ -42,7 +46,7 @@ func NewDeserializer(pp *crypto.PublicParams) (*Deserializer, error) {
 			msp.IdemixIdentity,
 			&x509.MSPIdentityDeserializer{},
 			m,
-			&x509.MSPIdentityDeserializer{},
+			hybrid.NewTypedIdentityDeserializer(pq.NewTypedIdentityDeserializer(&x509.MSPIdentityDeserializer{})),
 			m,
 			m,
 		),
@@ -80,6 +84,8 @@ type EIDRHDeserializer = deserializer.EIDRHDeserializer
 func NewEIDRHDeserializer() *EIDRHDeserializer {
 	d := deserializer.NewEIDRHDeserializer()
 	d.AddDeserializer(msp.IdemixIdentity, &idemix.AuditInfoDeserializer{})
+	d.AddDeserializer(msp.DilithiumIdentity, &pq.AuditInfoDeserializer{})
+	d.AddDeserializer(msp.HybridIdentity, &hybrid.AuditInfoDeserializer{})
 	d.AddDeserializer(htlc2.ScriptType, htlc.NewAuditDeserializer(&idemix.AuditInfoDeserializer{}))
 	return d
 }
@@ -7,6 +7,8 @@ SPDX-License-Identifier: Apache-2.0
 package driver
 
 import (
+	"os"
+
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/server/view"
@@ -16,6 +18,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/metrics"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/observables"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/encryption"
 	token3 "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/crypto/token"
 	zkatdlog "github.com/hyperledger-labs/fabric-token-sdk/token/core/zkatdlog/nogh"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -97,6 +100,11 @@ func (d *Driver) NewTokenService(_ driver.ServiceProvider, networkID string, cha
 		return nil, errors.Wrapf(err, "failed to initiliaze public params manager")
 	}
 
+	encryptionKey, err := loadAuditorEncryptionKey(tmsConfig)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to load auditor encryption key for [%s:%s:%s]", networkID, channel, namespace)
+	}
+
 	qe := v.QueryEngine()
 	ws, err := d.newWalletService(tmsConfig, d.endpointService, d.storageProvider, qe, logger, d.identityProvider.DefaultIdentity(), networkLocalMembership.DefaultIdentity(), ppm.PublicParams(), false)
 	if err != nil {
@@ -145,6 +153,7 @@ func (d *Driver) NewTokenService(_ driver.ServiceProvider, networkID string, cha
 				common.NewLedgerTokenLoader[*token3.Token](logger, d.tracerProvider, qe, tokDeserializer),
 				deserializer,
 				driverMetrics,
+				encryptionKey,
 				d.tracerProvider,
 			),
 			observables.NewAudit(tracerProvider),
@@ -173,3 +182,20 @@ func (d *Driver) NewValidator(_ driver.ServiceProvider, tmsID driver.TMSID, para
 	tracerProvider := tracing2.NewTracerProviderWithBackingProvider(d.tracerProvider, metricsProvider)
 	return observables.NewObservableValidator(defaultValidator, observables.NewValidator(tracerProvider)), nil
 }
+
+// loadAuditorEncryptionKey loads the auditor encryption secret key from the path configured for the TMS, if any
+func loadAuditorEncryptionKey(tmsConfig driver.Configuration) (*encryption.SecretKey, error) {
+	if !tmsConfig.IsSet(zkatdlog.AuditorEncryptionKeyPath) {
+		return nil, nil
+	}
+	path := tmsConfig.TranslatePath(tmsConfig.GetString(zkatdlog.AuditorEncryptionKeyPath))
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading auditor encryption key from [%s]", path)
+	}
+	sk := &encryption.SecretKey{}
+	if err := sk.Deserialize(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling auditor encryption key from [%s]", path)
+	}
+	return sk, nil
+}
@@ -121,6 +121,20 @@ func (s *TransferService) Transfer(ctx context.Context, txID string, wallet driv
 	// add transfer action's metadata
 	zkTransfer.Metadata = meta.TransferActionMetadata(opts.Attributes)
 
+	// open the outputs paying the fee collector
+	if policy := pp.FeePolicy(); policy != nil {
+		for i, information := range outputMetadata {
+			if !policy.IsFeeOutput(owners[i], information.Type) {
+				continue
+			}
+			zkTransfer.FeeOpenings = append(zkTransfer.FeeOpenings, &transfer.FeeOpening{
+				Index:          i,
+				Value:          values[i],
+				BlindingFactor: information.BlindingFactor,
+			})
+		}
+	}
+
 	ws := s.WalletService
 
 	// prepare metadata
@@ -6,10 +6,19 @@ SPDX-License-Identifier: Apache-2.0
 
 package driver
 
-import "context"
+import (
+	"context"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
 
 // AuditorService models the auditor service
 type AuditorService interface {
 	// AuditorCheck verifies the well-formedness of the passed request with the respect to the passed metadata and anchor
 	AuditorCheck(ctx context.Context, request *TokenRequest, metadata *TokenRequestMetadata, anchor string) error
+	// LedgerOutputs returns, for each issue and transfer action of the passed request, the outputs in the clear.
+	// Unlike AuditorCheck, it does not need the token request metadata, therefore it can be used on
+	// token requests read from the ledger. The passed types are the candidate token types the auditor expects,
+	// drivers that hide the token type use them to recover it.
+	LedgerOutputs(ctx context.Context, request *TokenRequest, types []string) ([][]*token.Token, [][]*token.Token, error)
 }
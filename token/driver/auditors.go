@@ -0,0 +1,136 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package driver
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AuditorKey is an auditor identity together with the period during which it was authorized to audit
+type AuditorKey struct {
+	// Identity is the auditor identity
+	Identity Identity
+	// NotBefore is the time from which the key is valid. The zero value means no lower bound.
+	NotBefore time.Time
+	// NotAfter is the time after which the key is not valid anymore. The zero value means the key is still valid.
+	NotAfter time.Time
+}
+
+// IsCurrent returns true if the key has not been rotated yet
+func (k *AuditorKey) IsCurrent() bool {
+	return k.NotAfter.IsZero()
+}
+
+// IsValidAt returns true if the key was valid at the passed time
+func (k *AuditorKey) IsValidAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+	return k.IsCurrent() || t.Before(k.NotAfter)
+}
+
+// AuditorKeys is the history of the auditor keys, sorted by validity period
+type AuditorKeys []*AuditorKey
+
+// Current returns the auditor identities that are still valid
+func (k AuditorKeys) Current() []Identity {
+	var res []Identity
+	for _, key := range k {
+		if key.IsCurrent() {
+			res = append(res, key.Identity)
+		}
+	}
+	return res
+}
+
+// At returns the auditor identities that were valid at the passed time
+func (k AuditorKeys) At(t time.Time) []Identity {
+	var res []Identity
+	for _, key := range k {
+		if key.IsValidAt(t) {
+			res = append(res, key.Identity)
+		}
+	}
+	return res
+}
+
+// Identities returns all the auditor identities in the history
+func (k AuditorKeys) Identities() []Identity {
+	res := make([]Identity, len(k))
+	for i, key := range k {
+		res[i] = key.Identity
+	}
+	return res
+}
+
+// Rotate closes, at the passed time, the validity period of the current auditor key, and opens one for the next key.
+// If the history is empty, the current key, if any, is recorded as valid since the beginning.
+// If next is empty, no new key is added.
+func (k AuditorKeys) Rotate(current, next Identity, at time.Time) (AuditorKeys, error) {
+	res := make(AuditorKeys, 0, len(k)+2)
+	for _, key := range k {
+		c := *key
+		res = append(res, &c)
+	}
+	if len(res) == 0 && len(current) != 0 {
+		res = append(res, &AuditorKey{Identity: current})
+	}
+	if len(res) != 0 {
+		last := res[len(res)-1]
+		if last.IsCurrent() {
+			if !bytes.Equal(last.Identity, current) {
+				return nil, errors.Errorf("the last key in the history does not match the current auditor")
+			}
+			if !last.NotBefore.IsZero() && !at.After(last.NotBefore) {
+				return nil, errors.Errorf("rotation time [%s] must follow the validity start of the current key [%s]", at, last.NotBefore)
+			}
+			last.NotAfter = at
+		} else if at.Before(last.NotAfter) {
+			return nil, errors.Errorf("rotation time [%s] precedes the end of the last key's validity [%s]", at, last.NotAfter)
+		}
+	}
+	if len(next) != 0 {
+		res = append(res, &AuditorKey{Identity: next, NotBefore: at})
+	}
+	return res, nil
+}
+
+// Validate checks that the validity periods are well-formed and do not overlap, and that
+// the history ends with the passed current auditor, if any.
+func (k AuditorKeys) Validate(current Identity) error {
+	if len(k) == 0 {
+		return nil
+	}
+	for i, key := range k {
+		if len(key.Identity) == 0 {
+			return errors.Errorf("empty auditor identity at index [%d]", i)
+		}
+		if !key.IsCurrent() && !key.NotAfter.After(key.NotBefore) {
+			return errors.Errorf("invalid validity period at index [%d]", i)
+		}
+		if key.IsCurrent() && i != len(k)-1 {
+			return errors.Errorf("auditor key at index [%d] has been rotated but has no end of validity", i)
+		}
+		if i > 0 && key.NotBefore.Before(k[i-1].NotAfter) {
+			return errors.Errorf("auditor key at index [%d] overlaps with the previous one", i)
+		}
+	}
+	last := k[len(k)-1]
+	if len(current) == 0 {
+		if last.IsCurrent() {
+			return errors.New("the history has a current auditor key but no auditor is set")
+		}
+		return nil
+	}
+	if !last.IsCurrent() || !bytes.Equal(last.Identity, current) {
+		return errors.New("the last key in the history does not match the current auditor")
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditorKeysRotation(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(24 * time.Hour)
+
+	var history AuditorKeys
+	assert.NoError(t, history.Validate(Identity("alice")))
+
+	// first rotation records the existing auditor as valid since the beginning
+	history, err := history.Rotate(Identity("alice"), Identity("bob"), t0)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.NoError(t, history.Validate(Identity("bob")))
+	assert.Error(t, history.Validate(Identity("alice")))
+	assert.Equal(t, []Identity{Identity("bob")}, history.Current())
+	assert.Equal(t, []Identity{Identity("alice")}, history.At(t0.Add(-time.Second)))
+	assert.Equal(t, []Identity{Identity("bob")}, history.At(t0))
+
+	// second rotation
+	history2, err := history.Rotate(Identity("bob"), Identity("charlie"), t1)
+	assert.NoError(t, err)
+	assert.Len(t, history2, 3)
+	assert.NoError(t, history2.Validate(Identity("charlie")))
+	assert.Equal(t, []Identity{Identity("bob")}, history2.At(t1.Add(-time.Second)))
+	assert.Equal(t, []Identity{Identity("alice"), Identity("bob"), Identity("charlie")}, history2.Identities())
+	// the original history is untouched
+	assert.True(t, history[1].IsCurrent())
+
+	// invalid rotations
+	_, err = history.Rotate(Identity("alice"), Identity("charlie"), t1)
+	assert.Error(t, err)
+	_, err = history.Rotate(Identity("bob"), Identity("charlie"), t0)
+	assert.Error(t, err)
+
+	// removing the auditor
+	history3, err := history2.Rotate(Identity("charlie"), nil, t1.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, history3.Validate(nil))
+	assert.Empty(t, history3.Current())
+}
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package driver
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// FeeKind is the kind of fee charged on transfers
+type FeeKind string
+
+const (
+	// FlatFee charges the same amount on every transfer
+	FlatFee FeeKind = "flat"
+	// ProportionalFee charges a fraction, expressed in basis points, of the transferred value, with a minimum amount
+	ProportionalFee FeeKind = "proportional"
+)
+
+// MaxFeeRate is the maximum rate, in basis points, of a proportional fee
+const MaxFeeRate = 10000
+
+// FeePolicy describes the fee that must be paid to the fee collector on each token request containing transfers
+type FeePolicy struct {
+	// Kind is the kind of fee
+	Kind FeeKind
+	// Amount is the flat fee, or the minimum fee if the fee is proportional
+	Amount uint64
+	// Rate is the fraction of the transferred value, in basis points, charged by a proportional fee
+	Rate uint64 `json:",omitempty"`
+	// TokenType is the token type the fee must be paid with
+	TokenType string
+	// Collector is the owner of the fee outputs
+	Collector Identity
+}
+
+// Validate returns an error if the fee policy is not well-formed
+func (p *FeePolicy) Validate() error {
+	switch p.Kind {
+	case FlatFee:
+		if p.Amount == 0 {
+			return errors.New("invalid fee policy: flat fee must be greater than zero")
+		}
+		if p.Rate != 0 {
+			return errors.New("invalid fee policy: flat fee cannot have a rate")
+		}
+	case ProportionalFee:
+		if p.Rate == 0 || p.Rate > MaxFeeRate {
+			return errors.Errorf("invalid fee policy: rate must be in (0, %d] basis points", MaxFeeRate)
+		}
+	default:
+		return errors.Errorf("invalid fee policy: unknown kind [%s]", p.Kind)
+	}
+	if len(p.TokenType) == 0 {
+		return errors.New("invalid fee policy: missing token type")
+	}
+	if p.Collector.IsNone() {
+		return errors.New("invalid fee policy: missing collector")
+	}
+	return nil
+}
+
+// Minimum returns the smallest fee a token request containing transfers must pay
+func (p *FeePolicy) Minimum() uint64 {
+	return p.Amount
+}
+
+// Fee returns the fee due for transferring the passed value.
+// A flat fee does not depend on the value, a proportional fee is rounded up and never less than the minimum.
+func (p *FeePolicy) Fee(value uint64) uint64 {
+	if p.Kind != ProportionalFee {
+		return p.Amount
+	}
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(value), new(big.Int).SetUint64(p.Rate))
+	fee.Add(fee, big.NewInt(MaxFeeRate-1))
+	fee.Div(fee, big.NewInt(MaxFeeRate))
+	if !fee.IsUint64() {
+		return math.MaxUint64
+	}
+	if fee.Uint64() < p.Amount {
+		return p.Amount
+	}
+	return fee.Uint64()
+}
+
+// IsFeeOutput returns true if an output with the passed owner and type pays the fee
+func (p *FeePolicy) IsFeeOutput(owner Identity, tokenType string) bool {
+	return tokenType == p.TokenType && p.Collector.Equal(owner)
+}
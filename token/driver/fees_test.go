@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package driver
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeePolicyValidate(t *testing.T) {
+	assert.NoError(t, (&FeePolicy{Kind: FlatFee, Amount: 5, TokenType: "USD", Collector: Identity("collector")}).Validate())
+	assert.NoError(t, (&FeePolicy{Kind: ProportionalFee, Rate: 25, TokenType: "USD", Collector: Identity("collector")}).Validate())
+
+	assert.Error(t, (&FeePolicy{Kind: FlatFee, TokenType: "USD", Collector: Identity("collector")}).Validate())
+	assert.Error(t, (&FeePolicy{Kind: FlatFee, Amount: 5, Rate: 1, TokenType: "USD", Collector: Identity("collector")}).Validate())
+	assert.Error(t, (&FeePolicy{Kind: ProportionalFee, Rate: MaxFeeRate + 1, TokenType: "USD", Collector: Identity("collector")}).Validate())
+	assert.Error(t, (&FeePolicy{Kind: "percentage", Amount: 5, TokenType: "USD", Collector: Identity("collector")}).Validate())
+	assert.Error(t, (&FeePolicy{Kind: FlatFee, Amount: 5, Collector: Identity("collector")}).Validate())
+	assert.Error(t, (&FeePolicy{Kind: FlatFee, Amount: 5, TokenType: "USD"}).Validate())
+}
+
+func TestFeePolicyFee(t *testing.T) {
+	flat := &FeePolicy{Kind: FlatFee, Amount: 5, TokenType: "USD", Collector: Identity("collector")}
+	assert.Equal(t, uint64(5), flat.Fee(0))
+	assert.Equal(t, uint64(5), flat.Fee(1000000))
+	assert.Equal(t, uint64(5), flat.Minimum())
+
+	// 1% with a minimum of 2, rounded up
+	proportional := &FeePolicy{Kind: ProportionalFee, Amount: 2, Rate: 100, TokenType: "USD", Collector: Identity("collector")}
+	assert.Equal(t, uint64(2), proportional.Fee(10))
+	assert.Equal(t, uint64(2), proportional.Fee(200))
+	assert.Equal(t, uint64(3), proportional.Fee(201))
+	assert.Equal(t, uint64(10), proportional.Fee(1000))
+	assert.Equal(t, uint64(2), proportional.Minimum())
+	assert.Equal(t, uint64(math.MaxUint64/100+1), proportional.Fee(math.MaxUint64))
+
+	assert.True(t, flat.IsFeeOutput(Identity("collector"), "USD"))
+	assert.False(t, flat.IsFeeOutput(Identity("collector"), "EUR"))
+	assert.False(t, flat.IsFeeOutput(Identity("alice"), "USD"))
+}
@@ -9,6 +9,36 @@ import (
 )
 
 type PublicParameters struct {
+	AsyncAuditingStub        func() bool
+	asyncAuditingMutex       sync.RWMutex
+	asyncAuditingArgsForCall []struct {
+	}
+	asyncAuditingReturns struct {
+		result1 bool
+	}
+	asyncAuditingReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	AsyncAuditingTypesStub        func() []string
+	asyncAuditingTypesMutex       sync.RWMutex
+	asyncAuditingTypesArgsForCall []struct {
+	}
+	asyncAuditingTypesReturns struct {
+		result1 []string
+	}
+	asyncAuditingTypesReturnsOnCall map[int]struct {
+		result1 []string
+	}
+	AuditorHistoryStub        func() driver.AuditorKeys
+	auditorHistoryMutex       sync.RWMutex
+	auditorHistoryArgsForCall []struct {
+	}
+	auditorHistoryReturns struct {
+		result1 driver.AuditorKeys
+	}
+	auditorHistoryReturnsOnCall map[int]struct {
+		result1 driver.AuditorKeys
+	}
 	AuditorsStub        func() []view.Identity
 	auditorsMutex       sync.RWMutex
 	auditorsArgsForCall []struct {
@@ -41,6 +71,16 @@ type PublicParameters struct {
 	certificationDriverReturnsOnCall map[int]struct {
 		result1 string
 	}
+	FeePolicyStub        func() *driver.FeePolicy
+	feePolicyMutex       sync.RWMutex
+	feePolicyArgsForCall []struct {
+	}
+	feePolicyReturns struct {
+		result1 *driver.FeePolicy
+	}
+	feePolicyReturnsOnCall map[int]struct {
+		result1 *driver.FeePolicy
+	}
 	GraphHidingStub        func() bool
 	graphHidingMutex       sync.RWMutex
 	graphHidingArgsForCall []struct {
@@ -127,6 +167,167 @@ type PublicParameters struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *PublicParameters) AsyncAuditing() bool {
+	fake.asyncAuditingMutex.Lock()
+	ret, specificReturn := fake.asyncAuditingReturnsOnCall[len(fake.asyncAuditingArgsForCall)]
+	fake.asyncAuditingArgsForCall = append(fake.asyncAuditingArgsForCall, struct {
+	}{})
+	stub := fake.AsyncAuditingStub
+	fakeReturns := fake.asyncAuditingReturns
+	fake.recordInvocation("AsyncAuditing", []interface{}{})
+	fake.asyncAuditingMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PublicParameters) AsyncAuditingCallCount() int {
+	fake.asyncAuditingMutex.RLock()
+	defer fake.asyncAuditingMutex.RUnlock()
+	fake.asyncAuditingTypesMutex.RLock()
+	defer fake.asyncAuditingTypesMutex.RUnlock()
+	return len(fake.asyncAuditingArgsForCall)
+}
+
+func (fake *PublicParameters) AsyncAuditingCalls(stub func() bool) {
+	fake.asyncAuditingMutex.Lock()
+	defer fake.asyncAuditingMutex.Unlock()
+	fake.AsyncAuditingStub = stub
+}
+
+func (fake *PublicParameters) AsyncAuditingReturns(result1 bool) {
+	fake.asyncAuditingMutex.Lock()
+	defer fake.asyncAuditingMutex.Unlock()
+	fake.AsyncAuditingStub = nil
+	fake.asyncAuditingReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *PublicParameters) AsyncAuditingReturnsOnCall(i int, result1 bool) {
+	fake.asyncAuditingMutex.Lock()
+	defer fake.asyncAuditingMutex.Unlock()
+	fake.AsyncAuditingStub = nil
+	if fake.asyncAuditingReturnsOnCall == nil {
+		fake.asyncAuditingReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.asyncAuditingReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *PublicParameters) AsyncAuditingTypes() []string {
+	fake.asyncAuditingTypesMutex.Lock()
+	ret, specificReturn := fake.asyncAuditingTypesReturnsOnCall[len(fake.asyncAuditingTypesArgsForCall)]
+	fake.asyncAuditingTypesArgsForCall = append(fake.asyncAuditingTypesArgsForCall, struct {
+	}{})
+	stub := fake.AsyncAuditingTypesStub
+	fakeReturns := fake.asyncAuditingTypesReturns
+	fake.recordInvocation("AsyncAuditingTypes", []interface{}{})
+	fake.asyncAuditingTypesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PublicParameters) AsyncAuditingTypesCallCount() int {
+	fake.asyncAuditingTypesMutex.RLock()
+	defer fake.asyncAuditingTypesMutex.RUnlock()
+	return len(fake.asyncAuditingTypesArgsForCall)
+}
+
+func (fake *PublicParameters) AsyncAuditingTypesCalls(stub func() []string) {
+	fake.asyncAuditingTypesMutex.Lock()
+	defer fake.asyncAuditingTypesMutex.Unlock()
+	fake.AsyncAuditingTypesStub = stub
+}
+
+func (fake *PublicParameters) AsyncAuditingTypesReturns(result1 []string) {
+	fake.asyncAuditingTypesMutex.Lock()
+	defer fake.asyncAuditingTypesMutex.Unlock()
+	fake.AsyncAuditingTypesStub = nil
+	fake.asyncAuditingTypesReturns = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *PublicParameters) AsyncAuditingTypesReturnsOnCall(i int, result1 []string) {
+	fake.asyncAuditingTypesMutex.Lock()
+	defer fake.asyncAuditingTypesMutex.Unlock()
+	fake.AsyncAuditingTypesStub = nil
+	if fake.asyncAuditingTypesReturnsOnCall == nil {
+		fake.asyncAuditingTypesReturnsOnCall = make(map[int]struct {
+			result1 []string
+		})
+	}
+	fake.asyncAuditingTypesReturnsOnCall[i] = struct {
+		result1 []string
+	}{result1}
+}
+
+func (fake *PublicParameters) AuditorHistory() driver.AuditorKeys {
+	fake.auditorHistoryMutex.Lock()
+	ret, specificReturn := fake.auditorHistoryReturnsOnCall[len(fake.auditorHistoryArgsForCall)]
+	fake.auditorHistoryArgsForCall = append(fake.auditorHistoryArgsForCall, struct {
+	}{})
+	stub := fake.AuditorHistoryStub
+	fakeReturns := fake.auditorHistoryReturns
+	fake.recordInvocation("AuditorHistory", []interface{}{})
+	fake.auditorHistoryMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PublicParameters) AuditorHistoryCallCount() int {
+	fake.auditorHistoryMutex.RLock()
+	defer fake.auditorHistoryMutex.RUnlock()
+	return len(fake.auditorHistoryArgsForCall)
+}
+
+func (fake *PublicParameters) AuditorHistoryCalls(stub func() driver.AuditorKeys) {
+	fake.auditorHistoryMutex.Lock()
+	defer fake.auditorHistoryMutex.Unlock()
+	fake.AuditorHistoryStub = stub
+}
+
+func (fake *PublicParameters) AuditorHistoryReturns(result1 driver.AuditorKeys) {
+	fake.auditorHistoryMutex.Lock()
+	defer fake.auditorHistoryMutex.Unlock()
+	fake.AuditorHistoryStub = nil
+	fake.auditorHistoryReturns = struct {
+		result1 driver.AuditorKeys
+	}{result1}
+}
+
+func (fake *PublicParameters) AuditorHistoryReturnsOnCall(i int, result1 driver.AuditorKeys) {
+	fake.auditorHistoryMutex.Lock()
+	defer fake.auditorHistoryMutex.Unlock()
+	fake.AuditorHistoryStub = nil
+	if fake.auditorHistoryReturnsOnCall == nil {
+		fake.auditorHistoryReturnsOnCall = make(map[int]struct {
+			result1 driver.AuditorKeys
+		})
+	}
+	fake.auditorHistoryReturnsOnCall[i] = struct {
+		result1 driver.AuditorKeys
+	}{result1}
+}
+
 func (fake *PublicParameters) Auditors() []view.Identity {
 	fake.auditorsMutex.Lock()
 	ret, specificReturn := fake.auditorsReturnsOnCall[len(fake.auditorsArgsForCall)]
@@ -289,6 +490,59 @@ func (fake *PublicParameters) CertificationDriverReturnsOnCall(i int, result1 st
 	}{result1}
 }
 
+func (fake *PublicParameters) FeePolicy() *driver.FeePolicy {
+	fake.feePolicyMutex.Lock()
+	ret, specificReturn := fake.feePolicyReturnsOnCall[len(fake.feePolicyArgsForCall)]
+	fake.feePolicyArgsForCall = append(fake.feePolicyArgsForCall, struct {
+	}{})
+	stub := fake.FeePolicyStub
+	fakeReturns := fake.feePolicyReturns
+	fake.recordInvocation("FeePolicy", []interface{}{})
+	fake.feePolicyMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *PublicParameters) FeePolicyCallCount() int {
+	fake.feePolicyMutex.RLock()
+	defer fake.feePolicyMutex.RUnlock()
+	return len(fake.feePolicyArgsForCall)
+}
+
+func (fake *PublicParameters) FeePolicyCalls(stub func() *driver.FeePolicy) {
+	fake.feePolicyMutex.Lock()
+	defer fake.feePolicyMutex.Unlock()
+	fake.FeePolicyStub = stub
+}
+
+func (fake *PublicParameters) FeePolicyReturns(result1 *driver.FeePolicy) {
+	fake.feePolicyMutex.Lock()
+	defer fake.feePolicyMutex.Unlock()
+	fake.FeePolicyStub = nil
+	fake.feePolicyReturns = struct {
+		result1 *driver.FeePolicy
+	}{result1}
+}
+
+func (fake *PublicParameters) FeePolicyReturnsOnCall(i int, result1 *driver.FeePolicy) {
+	fake.feePolicyMutex.Lock()
+	defer fake.feePolicyMutex.Unlock()
+	fake.FeePolicyStub = nil
+	if fake.feePolicyReturnsOnCall == nil {
+		fake.feePolicyReturnsOnCall = make(map[int]struct {
+			result1 *driver.FeePolicy
+		})
+	}
+	fake.feePolicyReturnsOnCall[i] = struct {
+		result1 *driver.FeePolicy
+	}{result1}
+}
+
 func (fake *PublicParameters) GraphHiding() bool {
 	fake.graphHidingMutex.Lock()
 	ret, specificReturn := fake.graphHidingReturnsOnCall[len(fake.graphHidingArgsForCall)]
@@ -719,12 +973,20 @@ func (fake *PublicParameters) ValidateReturnsOnCall(i int, result1 error) {
 func (fake *PublicParameters) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.asyncAuditingMutex.RLock()
+	defer fake.asyncAuditingMutex.RUnlock()
+	fake.asyncAuditingTypesMutex.RLock()
+	defer fake.asyncAuditingTypesMutex.RUnlock()
+	fake.auditorHistoryMutex.RLock()
+	defer fake.auditorHistoryMutex.RUnlock()
 	fake.auditorsMutex.RLock()
 	defer fake.auditorsMutex.RUnlock()
 	fake.bytesMutex.RLock()
 	defer fake.bytesMutex.RUnlock()
 	fake.certificationDriverMutex.RLock()
 	defer fake.certificationDriverMutex.RUnlock()
+	fake.feePolicyMutex.RLock()
+	defer fake.feePolicyMutex.RUnlock()
 	fake.graphHidingMutex.RLock()
 	defer fake.graphHidingMutex.RUnlock()
 	fake.identifierMutex.RLock()
@@ -60,6 +60,17 @@ type PublicParameters interface {
 	Bytes() ([]byte, error)
 	// Auditors returns the list of auditors.
 	Auditors() []Identity
+	// AsyncAuditing returns true if a token request can be committed without the auditor's signature.
+	// In this case, the auditor inspects the committed token requests afterward.
+	AsyncAuditing() bool
+	// AsyncAuditingTypes returns the token types whose requests can be committed without the auditor's signature,
+	// when AsyncAuditing is true. An empty list means any token type.
+	AsyncAuditingTypes() []string
+	// AuditorHistory returns the history of the auditor keys with their validity periods.
+	// The current auditors are the keys that have not been rotated yet.
+	AuditorHistory() AuditorKeys
+	// FeePolicy returns the fee that must be paid on transfers, nil if transfers are free.
+	FeePolicy() *FeePolicy
 	// Precision returns the precision used to represent the token value.
 	Precision() uint64
 	// String returns a readable version of the public parameters
@@ -25,6 +25,10 @@ type TokenRequest struct {
 	Transfers         [][]byte
 	Signatures        [][]byte
 	AuditorSignatures [][]byte
+	// SummaryHash, if set, is the hash of the summary of the request shown to the external signers.
+	// It is part of the signed message, therefore the signatures commit to the summary too.
+	// It is omitted from the encoding when not set.
+	SummaryHash []byte `asn1:"optional"`
 }
 
 func (r *TokenRequest) Bytes() ([]byte, error) {
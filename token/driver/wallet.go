@@ -122,6 +122,8 @@ type IdentityConfiguration struct {
 	URL    string
 	Config []byte
 	Raw    []byte
+	// Type is the identity type of the wallet, empty for the default one
+	Type string
 }
 
 // WalletLookupID defines the type of identifiers that can be used to retrieve a given wallet.
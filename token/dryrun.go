@@ -0,0 +1,403 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/pkg/errors"
+)
+
+// InfeasibilityCode identifies why a transfer evaluated by Request#DryRun cannot be performed
+type InfeasibilityCode string
+
+const (
+	// InvalidTransfer is returned when the arguments of the transfer are not valid, for instance a zero value
+	InvalidTransfer InfeasibilityCode = "invalid_transfer"
+	// TransferPolicyViolation is returned when the transfer policy of the TMS rejects the transfer
+	TransferPolicyViolation InfeasibilityCode = "transfer_policy_violation"
+	// InsufficientFunds is returned when the wallet does not own enough tokens
+	InsufficientFunds InfeasibilityCode = "insufficient_funds"
+	// NotCertifiedFunds is returned when the wallet owns enough tokens, but not enough of them are certified
+	NotCertifiedFunds InfeasibilityCode = "not_certified_funds"
+	// UnreachableRecipient is returned when a recipient cannot be contacted
+	UnreachableRecipient InfeasibilityCode = "unreachable_recipient"
+	// GenerationFailure is returned when the token driver fails to generate the action
+	GenerationFailure InfeasibilityCode = "generation_failure"
+)
+
+// InfeasibilityReason describes why a transfer evaluated by Request#DryRun cannot be performed
+type InfeasibilityReason struct {
+	// Code identifies the reason
+	Code InfeasibilityCode
+	// Template is the index of the template the reason refers to, -1 if it refers to the whole request
+	Template int
+	// TokenType is the token type involved, if any
+	TokenType string
+	// Required is the quantity required, in decimal format, for funds related reasons
+	Required string
+	// Available is the quantity available, in decimal format, for funds related reasons
+	Available string
+	// Recipient is the recipient involved, if any
+	Recipient Identity
+	// Message is a human readable description of the reason
+	Message string
+}
+
+// TransferTemplate describes a transfer to evaluate with Request#DryRun.
+// The fields are the arguments of Request#Transfer.
+type TransferTemplate struct {
+	Wallet    *OwnerWallet
+	TokenType string
+	Values    []uint64
+	Owners    []Identity
+	Options   []TransferOption
+}
+
+// DryRunReport is the outcome of Request#DryRun
+type DryRunReport struct {
+	// Feasible is true if all the transfers can be performed
+	Feasible bool
+	// Reasons lists why the transfers cannot be performed, if not feasible
+	Reasons []InfeasibilityReason
+	// Actions is the number of transfer actions the transfers would append to the request, fee actions included
+	Actions int
+	// Inputs is the number of tokens the transfers would spend
+	Inputs int
+	// Outputs is the number of tokens the transfers would create, rest and fee outputs included
+	Outputs int
+	// Fee is the fee the transfers would pay
+	Fee uint64
+	// Size is the size in bytes of the serialized actions of the request once the transfers are appended,
+	// signatures excluded
+	Size int
+	// MetadataSize is the size in bytes of the serialized metadata of the request once the transfers are appended
+	MetadataSize int
+	// ProvingTime is the time the token driver took to generate the actions of the feasible transfers
+	ProvingTime time.Duration
+	// VerificationTime is the time the token driver took to verify the actions of the feasible transfers
+	VerificationTime time.Duration
+}
+
+// DryRun evaluates the passed transfers as if they were appended, in order, to the request, without modifying it.
+// The tokens to spend are selected from the vault without locking them: tokens locked by other transactions
+// are considered available, and the selector passed as option, if any, is not used.
+// The actions of the feasible transfers are generated and verified by the token driver,
+// to measure their size, and the proving and verification times.
+// An error is returned only if the evaluation itself fails; infeasible transfers are reported in the DryRunReport.
+func (r *Request) DryRun(ctx context.Context, templates ...*TransferTemplate) (*DryRunReport, error) {
+	request := r.clone()
+	request.dryRun = true
+	d := &dryRun{
+		request:        request,
+		report:         &DryRunReport{},
+		reserved:       map[string]struct{}{},
+		owners:         map[string]Identity{},
+		restIdentities: map[string]Identity{},
+	}
+	for i, template := range templates {
+		if err := d.transfer(ctx, i, template); err != nil {
+			return nil, errors.WithMessagef(err, "failed evaluating transfer template [%d]", i)
+		}
+	}
+	actions, err := d.request.RequestToBytes()
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := d.request.MetadataToBytes()
+	if err != nil {
+		return nil, err
+	}
+	d.report.Size = len(actions)
+	d.report.MetadataSize = len(metadata)
+	d.report.Feasible = len(d.report.Reasons) == 0
+	return d.report, nil
+}
+
+// clone returns a copy of the request that can be modified without affecting the original
+func (r *Request) clone() *Request {
+	actions := *r.Actions
+	actions.Issues = append([][]byte{}, r.Actions.Issues...)
+	actions.Transfers = append([][]byte{}, r.Actions.Transfers...)
+	actions.Signatures = append([][]byte{}, r.Actions.Signatures...)
+	actions.AuditorSignatures = append([][]byte{}, r.Actions.AuditorSignatures...)
+	metadata := *r.Metadata
+	metadata.Issues = append([]driver.IssueMetadata{}, r.Metadata.Issues...)
+	metadata.Transfers = append([]driver.TransferMetadata{}, r.Metadata.Transfers...)
+	return &Request{
+		Anchor:       r.Anchor,
+		Actions:      &actions,
+		Metadata:     &metadata,
+		TokenService: r.TokenService,
+		feeBase:      r.feeBase,
+		feePaid:      r.feePaid,
+	}
+}
+
+type dryRun struct {
+	request *Request
+	report  *DryRunReport
+	// reserved are the tokens already spent by the previous templates
+	reserved map[string]struct{}
+	// owners are the owners of the unspent tokens listed from the wallets, by token id
+	owners map[string]Identity
+	// restIdentities are the rest identities already obtained from the wallets, by wallet id
+	restIdentities map[string]Identity
+}
+
+func (d *dryRun) transfer(ctx context.Context, index int, template *TransferTemplate) error {
+	if template == nil || template.Wallet == nil {
+		d.infeasible(InfeasibilityReason{Code: InvalidTransfer, Template: index, Message: "wallet not set"})
+		return nil
+	}
+	if len(template.Values) == 0 || len(template.Values) != len(template.Owners) {
+		d.infeasible(InfeasibilityReason{
+			Code:      InvalidTransfer,
+			Template:  index,
+			TokenType: template.TokenType,
+			Message:   fmt.Sprintf("number of values [%d] does not match number of owners [%d]", len(template.Values), len(template.Owners)),
+		})
+		return nil
+	}
+	for _, v := range template.Values {
+		if v == 0 {
+			d.infeasible(InfeasibilityReason{Code: InvalidTransfer, Template: index, TokenType: template.TokenType, Message: "value is zero"})
+			return nil
+		}
+	}
+	opt, err := compileTransferOptions(template.Options...)
+	if err != nil {
+		return errors.WithMessagef(err, "failed compiling options")
+	}
+	if err := d.request.checkTransferPolicy(ctx, template.Wallet, template.TokenType, template.Values, template.Owners); err != nil {
+		d.infeasible(InfeasibilityReason{Code: TransferPolicyViolation, Template: index, TokenType: template.TokenType, Message: err.Error()})
+		return nil
+	}
+
+	// add the fee output to this action, if possible, otherwise pay the fee with an additional action
+	values, owners := template.Values, template.Owners
+	policy, base, fee := d.request.fee(template.TokenType, values, owners)
+	feeInAction := fee != 0 && template.TokenType == policy.TokenType && len(opt.TokenIDs) == 0
+	if feeInAction {
+		values = append(append([]uint64{}, values...), fee)
+		owners = append(append([]Identity{}, owners...), policy.Collector)
+	}
+	ok, err := d.action(ctx, index, template.Wallet, template.TokenType, values, owners, opt)
+	if err != nil || !ok {
+		return err
+	}
+	if policy == nil {
+		return nil
+	}
+	if fee != 0 && !feeInAction {
+		ok, err = d.action(ctx, index, template.Wallet, policy.TokenType, []uint64{fee}, []Identity{policy.Collector}, &TransferOptions{})
+		if err != nil || !ok {
+			return err
+		}
+	}
+	d.request.feeBase = base
+	d.request.feePaid += fee
+	d.report.Fee += fee
+	return nil
+}
+
+// action evaluates a single transfer action. It returns false if the action is not feasible.
+func (d *dryRun) action(ctx context.Context, index int, wallet *OwnerWallet, tokenType string, values []uint64, owners []Identity, opt *TransferOptions) (bool, error) {
+	for _, owner := range owners {
+		if owner.IsNone() {
+			d.infeasible(InfeasibilityReason{Code: InvalidTransfer, Template: index, TokenType: tokenType, Message: "all recipients should be defined"})
+			return false, nil
+		}
+	}
+	outputTokens, outputSum, err := d.request.genOutputs(values, owners, tokenType)
+	if err != nil {
+		d.infeasible(InfeasibilityReason{Code: InvalidTransfer, Template: index, TokenType: tokenType, Message: err.Error()})
+		return false, nil
+	}
+
+	// select the inputs without locking them
+	var tokenIDs []*token.ID
+	var inputSum token.Quantity
+	if ids := d.request.cleanupInputIDs(opt.TokenIDs); len(ids) != 0 {
+		tokenIDs, inputSum, _, err = d.request.parseInputIDs(ids)
+		if err != nil {
+			d.infeasible(InfeasibilityReason{Code: InvalidTransfer, Template: index, TokenType: tokenType, Message: err.Error()})
+			return false, nil
+		}
+		if inputSum.Cmp(outputSum) < 0 {
+			d.infeasible(InfeasibilityReason{
+				Code:      InsufficientFunds,
+				Template:  index,
+				TokenType: tokenType,
+				Required:  outputSum.Decimal(),
+				Available: inputSum.Decimal(),
+				Message:   "the passed tokens do not cover the outputs",
+			})
+			return false, nil
+		}
+	} else {
+		var ok bool
+		tokenIDs, inputSum, ok, err = d.selectTokens(ctx, index, wallet, tokenType, outputSum)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	for _, id := range tokenIDs {
+		d.reserved[id.String()] = struct{}{}
+	}
+
+	// add the rest, if any
+	if inputSum.Cmp(outputSum) > 0 {
+		restIdentity, err := d.restIdentity(wallet, tokenIDs, opt)
+		if err != nil {
+			return false, err
+		}
+		outputTokens = append(outputTokens, &token.Token{
+			Owner:    restIdentity,
+			Type:     tokenType,
+			Quantity: inputSum.Sub(outputSum).Hex(),
+		})
+	}
+
+	// generate and verify the action
+	ts := d.request.TokenService.tms.TransferService()
+	start := time.Now()
+	transfer, metadata, err := ts.Transfer(ctx, d.request.Anchor, wallet.w, tokenIDs, outputTokens, &driver.TransferOptions{Attributes: opt.Attributes})
+	if err != nil {
+		d.infeasible(InfeasibilityReason{Code: GenerationFailure, Template: index, TokenType: tokenType, Message: err.Error()})
+		return false, nil
+	}
+	d.report.ProvingTime += time.Since(start)
+	start = time.Now()
+	if err := ts.VerifyTransfer(transfer, metadata.OutputsMetadata); err != nil {
+		d.infeasible(InfeasibilityReason{Code: GenerationFailure, Template: index, TokenType: tokenType, Message: err.Error()})
+		return false, nil
+	}
+	d.report.VerificationTime += time.Since(start)
+	raw, err := transfer.Serialize()
+	if err != nil {
+		return false, errors.Wrap(err, "failed serializing transfer action")
+	}
+	d.request.appendGeneratedTransfer(&generatedTransfer{action: transfer, raw: raw, metadata: metadata})
+
+	d.report.Actions++
+	d.report.Inputs += len(tokenIDs)
+	d.report.Outputs += len(outputTokens)
+	return true, nil
+}
+
+// selectTokens selects, in the order of the vault, the unspent tokens of the passed wallet and type
+// needed to cover the passed quantity, skipping the tokens already spent by the previous templates
+// and, if graph hiding is enabled, the tokens not certified yet.
+// It returns false if the tokens are not enough.
+func (d *dryRun) selectTokens(ctx context.Context, index int, wallet *OwnerWallet, tokenType string, required token.Quantity) ([]*token.ID, token.Quantity, bool, error) {
+	pp := d.request.TokenService.PublicParametersManager().PublicParameters()
+	precision := pp.Precision()
+	var certifications *CertificationStorage
+	if pp.GraphHiding() {
+		certifications = d.request.TokenService.Vault().CertificationStorage()
+	}
+	it, err := wallet.ListUnspentTokensIterator(WithType(tokenType), WithContext(ctx))
+	if err != nil {
+		return nil, nil, false, errors.WithMessagef(err, "failed listing unspent tokens of [%s]", wallet.ID())
+	}
+	defer it.Close()
+
+	selected := token.NewZeroQuantity(precision)
+	total := token.NewZeroQuantity(precision)
+	var ids []*token.ID
+	for selected.Cmp(required) < 0 {
+		tok, err := it.Next()
+		if err != nil {
+			return nil, nil, false, errors.WithMessagef(err, "failed iterating unspent tokens of [%s]", wallet.ID())
+		}
+		if tok == nil {
+			break
+		}
+		if _, ok := d.reserved[tok.Id.String()]; ok {
+			continue
+		}
+		q, err := token.ToQuantity(tok.Quantity, precision)
+		if err != nil {
+			return nil, nil, false, errors.WithMessagef(err, "failed parsing quantity of [%s]", tok.Id)
+		}
+		total = total.Add(q)
+		if certifications != nil && !certifications.Exists(tok.Id) {
+			continue
+		}
+		selected = selected.Add(q)
+		ids = append(ids, tok.Id)
+		d.owners[tok.Id.String()] = tok.Owner
+	}
+	if selected.Cmp(required) >= 0 {
+		return ids, selected, true, nil
+	}
+
+	reason := InfeasibilityReason{
+		Code:      InsufficientFunds,
+		Template:  index,
+		TokenType: tokenType,
+		Required:  required.Decimal(),
+		Available: total.Decimal(),
+		Message:   fmt.Sprintf("wallet [%s] owns [%s] of [%s], [%s] required", wallet.ID(), total.Decimal(), tokenType, required.Decimal()),
+	}
+	if total.Cmp(required) >= 0 {
+		reason.Code = NotCertifiedFunds
+		reason.Available = selected.Decimal()
+		reason.Message = fmt.Sprintf("wallet [%s] owns [%s] of [%s], but only [%s] certified, [%s] required", wallet.ID(), total.Decimal(), tokenType, selected.Decimal(), required.Decimal())
+	}
+	d.infeasible(reason)
+	return nil, nil, false, nil
+}
+
+// restIdentity returns the owner of the rest without deriving a fresh identity for each evaluation.
+// The owner of one of the spent tokens is used as placeholder, if known. Otherwise, a recipient identity
+// is obtained from the wallet once and reused for all the templates of the same wallet.
+func (d *dryRun) restIdentity(wallet *OwnerWallet, tokenIDs []*token.ID, opt *TransferOptions) (Identity, error) {
+	if opt.RestRecipientIdentity != nil {
+		return opt.RestRecipientIdentity.Identity, nil
+	}
+	var unknown []*token.ID
+	for _, id := range tokenIDs {
+		owner, ok := d.owners[id.String()]
+		if !ok {
+			unknown = append(unknown, id)
+			continue
+		}
+		if !owner.IsNone() {
+			return owner, nil
+		}
+	}
+	if len(unknown) != 0 {
+		inputs, err := d.request.TokenService.Vault().NewQueryEngine().GetTokens(unknown...)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting the tokens to spend")
+		}
+		for _, input := range inputs {
+			if input != nil && wallet.Contains(input.Owner) {
+				return input.Owner, nil
+			}
+		}
+	}
+	if id, ok := d.restIdentities[wallet.ID()]; ok {
+		return id, nil
+	}
+	id, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity for the rest, wallet [%s]", wallet.ID())
+	}
+	d.restIdentities[wallet.ID()] = id
+	return id, nil
+}
+
+func (d *dryRun) infeasible(reason InfeasibilityReason) {
+	d.report.Reasons = append(d.report.Reasons, reason)
+}
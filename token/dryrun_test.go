@@ -0,0 +1,167 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver/mock"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/stretchr/testify/assert"
+)
+
+type dryRunWallet struct {
+	batchWallet
+	tokens     []*token2.UnspentToken
+	recipients int
+}
+
+func (w *dryRunWallet) ID() string { return "alice" }
+
+func (w *dryRunWallet) GetRecipientIdentity() (driver.Identity, error) {
+	w.recipients++
+	return w.batchWallet.GetRecipientIdentity()
+}
+
+func (w *dryRunWallet) ListTokensIterator(opts *driver.ListTokensOptions) (driver.UnspentTokensIterator, error) {
+	var tokens []*token2.UnspentToken
+	for _, tok := range w.tokens {
+		if tok.Type == opts.TokenType {
+			tokens = append(tokens, tok)
+		}
+	}
+	return &dryRunIterator{tokens: tokens}, nil
+}
+
+type dryRunIterator struct {
+	tokens []*token2.UnspentToken
+}
+
+func (it *dryRunIterator) Close() {}
+
+func (it *dryRunIterator) Next() (*token2.UnspentToken, error) {
+	if len(it.tokens) == 0 {
+		return nil, nil
+	}
+	tok := it.tokens[0]
+	it.tokens = it.tokens[1:]
+	return tok, nil
+}
+
+func newDryRunWallet(tokenType string, quantities ...uint64) *OwnerWallet {
+	w := &dryRunWallet{}
+	for i, q := range quantities {
+		w.tokens = append(w.tokens, &token2.UnspentToken{
+			Id:       &token2.ID{TxId: fmt.Sprintf("tx%d", i)},
+			Type:     tokenType,
+			Quantity: token2.NewQuantityFromUInt64(q).Hex(),
+		})
+	}
+	return &OwnerWallet{Wallet: &Wallet{w: w}, w: w}
+}
+
+func TestRequest_DryRun(t *testing.T) {
+	request, ts := newBatchRequest(&mock.PublicParameters{})
+	wallet := newDryRunWallet("USD", 10, 20, 30)
+
+	report, err := request.DryRun(context.Background(),
+		&TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{25}, Owners: []Identity{Identity("bob")}},
+		// the tokens selected by the first template are not selected again
+		&TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{30}, Owners: []Identity{Identity("charlie")}},
+	)
+	assert.NoError(t, err)
+	assert.True(t, report.Feasible)
+	assert.Empty(t, report.Reasons)
+	assert.Equal(t, 2, report.Actions)
+	assert.Equal(t, 3, report.Inputs)
+	assert.Equal(t, 3, report.Outputs)
+	_, _, _, ids, _, _ := ts.TransferArgsForCall(0)
+	assert.Equal(t, []*token2.ID{{TxId: "tx0"}, {TxId: "tx1"}}, ids)
+	_, _, _, ids, _, _ = ts.TransferArgsForCall(1)
+	assert.Equal(t, []*token2.ID{{TxId: "tx2"}}, ids)
+	assert.Equal(t, 2, ts.VerifyTransferCallCount())
+	assert.Greater(t, report.Size, 0)
+	assert.Greater(t, report.MetadataSize, 0)
+
+	// the request is not modified
+	assert.Empty(t, request.Actions.Transfers)
+	assert.Empty(t, request.Metadata.Transfers)
+}
+
+func TestRequest_DryRunInfeasible(t *testing.T) {
+	request, ts := newBatchRequest(&mock.PublicParameters{})
+	wallet := newDryRunWallet("USD", 10, 20)
+
+	report, err := request.DryRun(context.Background(),
+		&TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{25}, Owners: []Identity{Identity("bob")}},
+		&TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{10}, Owners: []Identity{Identity("charlie")}},
+		&TransferTemplate{Wallet: wallet, TokenType: "EUR", Values: []uint64{0}, Owners: []Identity{Identity("bob")}},
+		&TransferTemplate{Wallet: wallet, TokenType: "EUR", Values: []uint64{1}, Owners: []Identity{nil}},
+	)
+	assert.NoError(t, err)
+	assert.False(t, report.Feasible)
+	assert.Equal(t, 1, report.Actions)
+	assert.Equal(t, 1, ts.TransferCallCount())
+	assert.Len(t, report.Reasons, 3)
+	assert.Equal(t, InsufficientFunds, report.Reasons[0].Code)
+	assert.Equal(t, 1, report.Reasons[0].Template)
+	assert.Equal(t, "10", report.Reasons[0].Required)
+	assert.Equal(t, "0", report.Reasons[0].Available)
+	assert.Equal(t, InvalidTransfer, report.Reasons[1].Code)
+	assert.Equal(t, 2, report.Reasons[1].Template)
+	assert.Equal(t, InvalidTransfer, report.Reasons[2].Code)
+	assert.Equal(t, 3, report.Reasons[2].Template)
+}
+
+func TestRequest_DryRunWithFee(t *testing.T) {
+	pp := &mock.PublicParameters{}
+	pp.FeePolicyReturns(&driver.FeePolicy{Kind: driver.FlatFee, Amount: 5, TokenType: "USD", Collector: Identity("collector")})
+	request, _ := newBatchRequest(pp)
+	wallet := newDryRunWallet("USD", 10)
+
+	// the fee is added to the action, and it is not covered
+	report, err := request.DryRun(context.Background(), &TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{8}, Owners: []Identity{Identity("bob")}})
+	assert.NoError(t, err)
+	assert.False(t, report.Feasible)
+	assert.Equal(t, "13", report.Reasons[0].Required)
+	assert.Equal(t, "10", report.Reasons[0].Available)
+
+	report, err = request.DryRun(context.Background(), &TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{5}, Owners: []Identity{Identity("bob")}})
+	assert.NoError(t, err)
+	assert.True(t, report.Feasible)
+	assert.Equal(t, uint64(5), report.Fee)
+	assert.Equal(t, 2, report.Outputs)
+	assert.Equal(t, uint64(0), request.feePaid)
+}
+
+func TestRequest_DryRunRestIdentity(t *testing.T) {
+	request, ts := newBatchRequest(&mock.PublicParameters{})
+
+	// the owner of a spent token is used for the rest
+	wallet := newDryRunWallet("USD", 10, 20)
+	wallet.w.(*dryRunWallet).tokens[0].Owner = Identity("alice.0")
+	report, err := request.DryRun(context.Background(), &TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{5}, Owners: []Identity{Identity("bob")}})
+	assert.NoError(t, err)
+	assert.True(t, report.Feasible)
+	_, _, _, _, outputs, _ := ts.TransferArgsForCall(0)
+	assert.Equal(t, []byte("alice.0"), outputs[1].Owner)
+	assert.Equal(t, 0, wallet.w.(*dryRunWallet).recipients)
+
+	// otherwise, a single recipient identity is obtained from the wallet
+	wallet = newDryRunWallet("USD", 10, 20)
+	report, err = request.DryRun(context.Background(),
+		&TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{5}, Owners: []Identity{Identity("bob")}},
+		&TransferTemplate{Wallet: wallet, TokenType: "USD", Values: []uint64{5}, Owners: []Identity{Identity("charlie")}},
+	)
+	assert.NoError(t, err)
+	assert.True(t, report.Feasible)
+	assert.Equal(t, 4, report.Outputs)
+	assert.Equal(t, 1, wallet.w.(*dryRunWallet).recipients)
+}
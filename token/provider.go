@@ -47,6 +47,12 @@ type SelectorManagerProvider interface {
 	SelectorManager(tms *ManagementService) (SelectorManager, error)
 }
 
+// TransferPolicyProvider provides instances of TransferPolicy
+type TransferPolicyProvider interface {
+	// TransferPolicy returns the TransferPolicy the transfers of the passed TMS must satisfy.
+	TransferPolicy(tms *ManagementService) (TransferPolicy, error)
+}
+
 // CertificationClientProvider provides instances of CertificationClient
 type CertificationClientProvider interface {
 	// New returns a new CertificationClient instance for the passed inputs
@@ -60,6 +66,7 @@ type ManagementServiceProvider struct {
 	normalizer                  TMSNormalizer
 	certificationClientProvider CertificationClientProvider
 	selectorManagerProvider     SelectorManagerProvider
+	transferPolicyProvider      TransferPolicyProvider
 	vaultProvider               VaultProvider
 }
 
@@ -71,6 +78,7 @@ func NewManagementServiceProvider(
 	vaultProvider VaultProvider,
 	certificationClientProvider CertificationClientProvider,
 	selectorManagerProvider SelectorManagerProvider,
+	transferPolicyProvider TransferPolicyProvider,
 ) *ManagementServiceProvider {
 	return &ManagementServiceProvider{
 		logger:                      logger,
@@ -79,6 +87,7 @@ func NewManagementServiceProvider(
 		vaultProvider:               vaultProvider,
 		certificationClientProvider: certificationClientProvider,
 		selectorManagerProvider:     selectorManagerProvider,
+		transferPolicyProvider:      transferPolicyProvider,
 	}
 }
 
@@ -134,6 +143,7 @@ func (p *ManagementServiceProvider) managementService(aNew bool, opts ...Service
 		vaultProvider:               p.vaultProvider,
 		certificationClientProvider: p.certificationClientProvider,
 		selectorManagerProvider:     p.selectorManagerProvider,
+		transferPolicyProvider:      p.transferPolicyProvider,
 		signatureService: &SignatureService{
 			deserializer: tokenService.Deserializer(),
 			ip:           tokenService.IdentityProvider(),
@@ -57,6 +57,27 @@ func (c *PublicParameters) Auditors() []Identity {
 	return c.PublicParameters.Auditors()
 }
 
+// AsyncAuditing returns true if a token request can be committed without the auditor's signature
+func (c *PublicParameters) AsyncAuditing() bool {
+	return c.PublicParameters.AsyncAuditing()
+}
+
+// AsyncAuditingTypes returns the token types whose requests can be committed without the auditor's signature.
+// An empty list means any token type.
+func (c *PublicParameters) AsyncAuditingTypes() []string {
+	return c.PublicParameters.AsyncAuditingTypes()
+}
+
+// AuditorHistory returns the history of the auditor keys with their validity periods
+func (c *PublicParameters) AuditorHistory() driver.AuditorKeys {
+	return c.PublicParameters.AuditorHistory()
+}
+
+// FeePolicy returns the fee that must be paid on transfers, nil if transfers are free
+func (c *PublicParameters) FeePolicy() *driver.FeePolicy {
+	return c.PublicParameters.FeePolicy()
+}
+
 // PublicParamsFetcher models the public parameters fetcher
 type PublicParamsFetcher interface {
 	// Fetch fetches the public parameters from the backend
@@ -9,6 +9,7 @@ package token
 import (
 	"context"
 	"encoding/asn1"
+	"math"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common/meta"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -169,6 +170,13 @@ type Request struct {
 	Metadata *driver.TokenRequestMetadata
 	// TokenService this request refers to
 	TokenService *ManagementService `json:"-"`
+
+	// feeBase is the value transferred by the actions added so far that are subject to the fee
+	feeBase uint64
+	// feePaid is the sum of the fee outputs added so far
+	feePaid uint64
+	// dryRun is true if the request is a copy used to evaluate transfers, see DryRun
+	dryRun bool
 }
 
 // NewRequest creates a new empty request for the given token service and anchor
@@ -288,44 +296,33 @@ func (r *Request) Transfer(ctx context.Context, wallet *OwnerWallet, typ string,
 	if err != nil {
 		return nil, errors.WithMessagef(err, "failed compiling options [%v]", opts)
 	}
+	if err := r.checkTransferPolicy(ctx, wallet, typ, values, owners); err != nil {
+		return nil, err
+	}
+
+	// add the fee output to this action, if possible, otherwise pay the fee with an additional action.
+	// The spent tokens are not known yet, the fee is checked again once the action is prepared.
+	policy, _, fee := r.fee(typ, values, owners)
+	var paid uint64
+	if fee != 0 && typ == policy.TokenType && len(opt.TokenIDs) == 0 {
+		values = append(append([]uint64{}, values...), fee)
+		owners = append(append([]Identity{}, owners...), policy.Collector)
+		paid = fee
+	}
+
 	tokenIDs, outputTokens, err := r.prepareTransfer(false, wallet, typ, values, owners, opt)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed preparing transfer")
 	}
 
 	r.TokenService.logger.Debugf("Prepare Transfer Action [id:%s,ins:%d,outs:%d]", r.Anchor, len(tokenIDs), len(outputTokens))
-
-	ts := r.TokenService.tms.TransferService()
-
-	// Compute transfer
-	transfer, transferMetadata, err := ts.Transfer(
-		ctx,
-		r.Anchor,
-		wallet.w,
-		tokenIDs,
-		outputTokens,
-		&driver.TransferOptions{
-			Attributes: opt.Attributes,
-		},
-	)
+	transfer, err := r.appendTransfer(ctx, wallet, tokenIDs, outputTokens, opt.Attributes)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed creating transfer action")
-	}
-	if r.TokenService.logger.IsEnabledFor(zapcore.DebugLevel) {
-		// double check
-		if err := ts.VerifyTransfer(transfer, transferMetadata.OutputsMetadata); err != nil {
-			return nil, errors.Wrap(err, "failed checking generated proof")
-		}
+		return nil, err
 	}
-
-	// Append
-	raw, err := transfer.Serialize()
-	if err != nil {
-		return nil, errors.Wrap(err, "failed serializing transfer action")
+	if err := r.payFee(ctx, wallet, typ, []*preparedTransfer{{tokenIDs: tokenIDs, outputTokens: outputTokens}}, paid, opt.Selector); err != nil {
+		return nil, err
 	}
-	r.Actions.Transfers = append(r.Actions.Transfers, raw)
-	r.Metadata.Transfers = append(r.Metadata.Transfers, *transferMetadata)
-
 	return &TransferAction{a: transfer}, nil
 }
 
@@ -344,40 +341,13 @@ func (r *Request) Redeem(ctx context.Context, wallet *OwnerWallet, typ string, v
 
 	r.TokenService.logger.Debugf("Prepare Redeem Action [ins:%d,outs:%d]", len(tokenIDs), len(outputTokens))
 
-	ts := r.TokenService.tms.TransferService()
-
 	// Compute redeem, it is a transfer with owner set to nil
-	transfer, transferMetadata, err := ts.Transfer(
-		ctx,
-		r.Anchor,
-		wallet.w,
-		tokenIDs,
-		outputTokens,
-		&driver.TransferOptions{
-			Attributes: opt.Attributes,
-		},
-	)
-	if err != nil {
-		return errors.Wrap(err, "failed creating transfer action")
-	}
-
-	if r.TokenService.logger.IsEnabledFor(zapcore.DebugLevel) {
-		// double check
-		if err := ts.VerifyTransfer(transfer, transferMetadata.OutputsMetadata); err != nil {
-			return errors.Wrap(err, "failed checking generated proof")
-		}
-	}
-
-	// Append
-	raw, err := transfer.Serialize()
-	if err != nil {
-		return errors.Wrap(err, "failed serializing transfer action")
+	if _, err := r.appendTransfer(ctx, wallet, tokenIDs, outputTokens, opt.Attributes); err != nil {
+		return err
 	}
 
-	r.Actions.Transfers = append(r.Actions.Transfers, raw)
-	r.Metadata.Transfers = append(r.Metadata.Transfers, *transferMetadata)
-
-	return nil
+	// the rest of a redeem might be subject to the fee, which is paid with an additional action
+	return r.payFee(ctx, wallet, typ, []*preparedTransfer{{tokenIDs: tokenIDs, outputTokens: outputTokens}}, 0, opt.Selector)
 }
 
 // Outputs returns the sequence of outputs of the request supporting sequential and parallel aggregate operations.
@@ -777,7 +747,7 @@ func (r *Request) MarshalToAudit() ([]byte, error) {
 	if r.Actions == nil {
 		return nil, errors.Errorf("failed to marshal request in tx [%s] for audit", r.Anchor)
 	}
-	bytes, err := asn1.Marshal(driver.TokenRequest{Issues: r.Actions.Issues, Transfers: r.Actions.Transfers})
+	bytes, err := asn1.Marshal(driver.TokenRequest{Issues: r.Actions.Issues, Transfers: r.Actions.Transfers, SummaryHash: r.Actions.SummaryHash})
 	if err != nil {
 		return nil, errors.Wrapf(err, "audit of tx [%s] failed: error marshal token request for signature", r.Anchor)
 	}
@@ -967,6 +937,9 @@ func (r *Request) AuditCheck(ctx context.Context) error {
 	if err := r.IsValid(); err != nil {
 		return err
 	}
+	if err := r.VerifySummaryHash(); err != nil {
+		return err
+	}
 	return r.TokenService.tms.AuditorService().AuditorCheck(
 		ctx,
 		r.Actions,
@@ -975,6 +948,13 @@ func (r *Request) AuditCheck(ctx context.Context) error {
 	)
 }
 
+// LedgerOutputs returns, for each issue and transfer action, the outputs of the request in the clear,
+// as the auditor can recover them without the request metadata.
+// It can be used on requests read from the ledger. The passed types are the candidate token types.
+func (r *Request) LedgerOutputs(ctx context.Context, types []string) ([][]*token.Token, [][]*token.Token, error) {
+	return r.TokenService.tms.AuditorService().LedgerOutputs(ctx, r.Actions, types)
+}
+
 // AuditRecord return the audit record of the request.
 // The audit record contains: The anchor, the audit inputs and outputs
 func (r *Request) AuditRecord() (*AuditRecord, error) {
@@ -1105,6 +1085,198 @@ func (r *Request) parseInputIDs(inputs []*token.ID) ([]*token.ID, token.Quantity
 	return inputs, sum, typ, nil
 }
 
+// appendTransfer generates the transfer action spending the passed tokens and creating the passed outputs,
+// and appends it to the request
+func (r *Request) appendTransfer(ctx context.Context, wallet *OwnerWallet, tokenIDs []*token.ID, outputTokens []*token.Token, attributes map[interface{}]interface{}) (driver.TransferAction, error) {
+	generated, err := r.generateTransfer(ctx, wallet, tokenIDs, outputTokens, attributes)
+	if err != nil {
+		return nil, err
+	}
+	r.appendGeneratedTransfer(generated)
+	return generated.action, nil
+}
+
+// generatedTransfer is a transfer action ready to be appended to a request
+type generatedTransfer struct {
+	action   driver.TransferAction
+	raw      []byte
+	metadata *driver.TransferMetadata
+}
+
+// generateTransfer generates the transfer action spending the passed tokens and creating the passed outputs.
+// It does not modify the request, therefore it can be called concurrently.
+func (r *Request) generateTransfer(ctx context.Context, wallet *OwnerWallet, tokenIDs []*token.ID, outputTokens []*token.Token, attributes map[interface{}]interface{}) (*generatedTransfer, error) {
+	ts := r.TokenService.tms.TransferService()
+
+	// Compute transfer
+	transfer, transferMetadata, err := ts.Transfer(
+		ctx,
+		r.Anchor,
+		wallet.w,
+		tokenIDs,
+		outputTokens,
+		&driver.TransferOptions{
+			Attributes: attributes,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed creating transfer action")
+	}
+	if r.TokenService.logger.IsEnabledFor(zapcore.DebugLevel) {
+		// double check
+		if err := ts.VerifyTransfer(transfer, transferMetadata.OutputsMetadata); err != nil {
+			return nil, errors.Wrap(err, "failed checking generated proof")
+		}
+	}
+	raw, err := transfer.Serialize()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed serializing transfer action")
+	}
+	return &generatedTransfer{action: transfer, raw: raw, metadata: transferMetadata}, nil
+}
+
+// appendGeneratedTransfer appends the passed transfer action to the request
+func (r *Request) appendGeneratedTransfer(generated *generatedTransfer) {
+	r.Actions.Transfers = append(r.Actions.Transfers, generated.raw)
+	r.Metadata.Transfers = append(r.Metadata.Transfers, *generated.metadata)
+}
+
+// fee returns the fee policy of the public parameters, if any, together with the value subject to the fee
+// after transferring the passed values to the passed owners, and the additional fee this transfer must pay.
+// Outputs redeeming tokens or paying the fee collector are not subject to the fee.
+func (r *Request) fee(tokenType string, values []uint64, owners []Identity) (*driver.FeePolicy, uint64, uint64) {
+	pp := r.TokenService.PublicParametersManager().PublicParameters()
+	if pp == nil || pp.FeePolicy() == nil {
+		return nil, 0, 0
+	}
+	policy := pp.FeePolicy()
+	var transferred uint64
+	charged := false
+	for i, owner := range owners {
+		if owner.IsNone() || policy.IsFeeOutput(owner, tokenType) {
+			continue
+		}
+		charged = true
+		if transferred+values[i] < transferred {
+			transferred = math.MaxUint64
+			continue
+		}
+		transferred += values[i]
+	}
+	if !charged {
+		return policy, r.feeBase, 0
+	}
+	base := r.feeBase + transferred
+	if base < r.feeBase {
+		base = math.MaxUint64
+	}
+	due := policy.Fee(base)
+	if due <= r.feePaid {
+		return policy, base, 0
+	}
+	return policy, base, due - r.feePaid
+}
+
+// preparedTransfer is a transfer action, not generated yet, spending the passed tokens to create the passed outputs
+type preparedTransfer struct {
+	tokenIDs     []*token.ID
+	outputTokens []*token.Token
+}
+
+// payFee accounts the fee of the passed transfer actions, once appended to the request.
+// The actions paid the passed amount to the fee collector. If they did not pay enough,
+// payFee appends an action transferring the rest of the fee to the collector.
+// The tokens to spend are selected from the passed wallet with the passed selector, or the default one.
+func (r *Request) payFee(ctx context.Context, wallet *OwnerWallet, tokenType string, transfers []*preparedTransfer, paid uint64, selector Selector) error {
+	pp := r.TokenService.PublicParametersManager().PublicParameters()
+	if pp == nil || pp.FeePolicy() == nil {
+		return nil
+	}
+	values, owners, err := r.chargedOutputs(transfers)
+	if err != nil {
+		return err
+	}
+	policy, base, fee := r.fee(tokenType, values, owners)
+	if fee > paid {
+		r.TokenService.logger.Debugf("pay fee [%d:%s] to [%s] with an additional action", fee-paid, policy.TokenType, policy.Collector)
+		opt := &TransferOptions{Selector: selector}
+		tokenIDs, outputTokens, err := r.prepareTransfer(false, wallet, policy.TokenType, []uint64{fee - paid}, []Identity{policy.Collector}, opt)
+		if err != nil {
+			return errors.Wrap(err, "failed preparing fee transfer")
+		}
+		if _, err := r.appendTransfer(ctx, wallet, tokenIDs, outputTokens, nil); err != nil {
+			return errors.WithMessage(err, "failed appending fee transfer")
+		}
+		paid = fee
+	}
+	r.feeBase = base
+	r.feePaid += paid
+	return nil
+}
+
+// chargedOutputs returns the values and the owners of the outputs of the passed transfer actions, as charged by the validators.
+// If the validators charge a proportional fee on the values in the clear, the value of an output going back
+// to the owner of one of the tokens spent by its action is not subject to the fee, therefore it is returned as zero.
+func (r *Request) chargedOutputs(transfers []*preparedTransfer) ([]uint64, []Identity, error) {
+	pp := r.TokenService.PublicParametersManager().PublicParameters()
+	proportional := !pp.TokenDataHiding() && pp.FeePolicy().Kind == driver.ProportionalFee
+	var values []uint64
+	var owners []Identity
+	for _, transfer := range transfers {
+		var inputOwners []Identity
+		if proportional && len(transfer.tokenIDs) != 0 {
+			inputs, err := r.TokenService.Vault().NewQueryEngine().GetTokens(transfer.tokenIDs...)
+			if err != nil {
+				return nil, nil, errors.WithMessagef(err, "failed getting the spent tokens")
+			}
+			for _, input := range inputs {
+				if input != nil {
+					inputOwners = append(inputOwners, input.Owner)
+				}
+			}
+		}
+		for _, output := range transfer.outputTokens {
+			q, err := token.ToQuantity(output.Quantity, pp.Precision())
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed parsing quantity [%s]", output.Quantity)
+			}
+			value := q.ToBigInt().Uint64()
+			for _, owner := range inputOwners {
+				if owner.Equal(output.Owner) {
+					value = 0
+					break
+				}
+			}
+			values = append(values, value)
+			owners = append(owners, output.Owner)
+		}
+	}
+	return values, owners, nil
+}
+
+// restIdentity returns the owner of the rest of a transfer spending the passed tokens.
+// If the validators charge a proportional fee on the values in the clear, the rest goes back to the owner
+// of one of the spent tokens, so that the validators do not charge it. Otherwise, a fresh recipient identity is used.
+func (r *Request) restIdentity(wallet *OwnerWallet, tokenIDs []*token.ID) (Identity, error) {
+	pp := r.TokenService.PublicParametersManager().PublicParameters()
+	if pp != nil && !pp.TokenDataHiding() && pp.FeePolicy() != nil && pp.FeePolicy().Kind == driver.ProportionalFee {
+		inputs, err := r.TokenService.Vault().NewQueryEngine().GetTokens(tokenIDs...)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting the tokens to spend")
+		}
+		for _, input := range inputs {
+			if input != nil && wallet.Contains(input.Owner) {
+				return input.Owner, nil
+			}
+		}
+	}
+	restIdentity, err := wallet.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity for the rest, wallet [%s]", wallet.ID())
+	}
+	return restIdentity, nil
+}
+
 func (r *Request) prepareTransfer(redeem bool, wallet *OwnerWallet, tokenType string, values []uint64, owners []Identity, transferOpts *TransferOptions) ([]*token.ID, []*token.Token, error) {
 	for _, owner := range owners {
 		if redeem {
@@ -1177,9 +1349,9 @@ func (r *Request) prepareTransfer(redeem bool, wallet *OwnerWallet, tokenType st
 			}
 			restIdentity = transferOpts.RestRecipientIdentity.Identity
 		} else {
-			restIdentity, err = wallet.GetRecipientIdentity()
+			restIdentity, err = r.restIdentity(wallet, tokenIDs)
 			if err != nil {
-				return nil, nil, errors.WithMessagef(err, "failed getting recipient identity for the rest, wallet [%s]", wallet.ID())
+				return nil, nil, err
 			}
 		}
 
@@ -17,6 +17,7 @@ import (
 	dbdriver "github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/driver/unity"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identitydb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/schedulerdb"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokendb"
 	tokensql "github.com/hyperledger-labs/fabric-token-sdk/token/services/tokendb/db/sql"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokenlockdb"
@@ -35,6 +36,17 @@ func NewTokenLockDBManager(in struct {
 	return tokenlockdb.NewHolder(in.Drivers).NewManager(in.ConfigService, dbconfig.NewConfig(in.ConfigProvider, "tokenlockdb.persistence.type", "db.persistence.type"))
 }
 
+// Scheduler DB
+
+func NewSchedulerDBManager(in struct {
+	dig.In
+	ConfigService  driver2.ConfigService
+	ConfigProvider *config2.Service
+	Drivers        []db.NamedDriver[dbdriver.SchedulerDBDriver] `group:"schedulerdb-drivers"`
+}) *schedulerdb.Manager {
+	return schedulerdb.NewHolder(in.Drivers).NewManager(in.ConfigService, dbconfig.NewConfig(in.ConfigProvider, "schedulerdb.persistence.type", "db.persistence.type"))
+}
+
 // Audit DB
 
 func NewAuditDBManager(in struct {
@@ -105,10 +117,11 @@ type DBDriverResult struct {
 	TokenLockDBDriver   db.NamedDriver[dbdriver.TokenLockDBDriver]   `group:"tokenlockdb-drivers"`
 	AuditDBDriver       db.NamedDriver[dbdriver.AuditDBDriver]       `group:"auditdb-drivers"`
 	IdentityDBDriver    db.NamedDriver[dbdriver.IdentityDBDriver]    `group:"identitydb-drivers"`
+	SchedulerDBDriver   db.NamedDriver[dbdriver.SchedulerDBDriver]   `group:"schedulerdb-drivers"`
 }
 
 func NewDBDrivers() DBDriverResult {
-	ttxDBDriver, tokenDBDriver, tokenNotifierDriver, tokenLockDBDriver, auditDBDriver, identityDBDriver := unity.NewDBDrivers()
+	ttxDBDriver, tokenDBDriver, tokenNotifierDriver, tokenLockDBDriver, auditDBDriver, identityDBDriver, schedulerDBDriver := unity.NewDBDrivers()
 	return DBDriverResult{
 		TTXDBDriver:         ttxDBDriver,
 		TokenDBDriver:       tokenDBDriver,
@@ -116,6 +129,7 @@ func NewDBDrivers() DBDriverResult {
 		TokenLockDBDriver:   tokenLockDBDriver,
 		AuditDBDriver:       auditDBDriver,
 		IdentityDBDriver:    identityDBDriver,
+		SchedulerDBDriver:   schedulerDBDriver,
 	}
 }
 
@@ -17,6 +17,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/committer"
 	fabricsdk "github.com/hyperledger-labs/fabric-smart-client/platform/fabric/sdk/dig"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/driver"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
@@ -35,6 +36,7 @@ import (
 	_ "github.com/hyperledger-labs/fabric-token-sdk/token/services/certifier/dummy"
 	config2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/config"
 	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/db/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/extsigner"
 	identity2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
 	kvs2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/kvs"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identitydb"
@@ -43,6 +45,11 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common"
 	driver3 "github.com/hyperledger-labs/fabric-token-sdk/token/services/network/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/policy"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/rescan"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/scheduler"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/schedulerdb"
+	schedulerdriver "github.com/hyperledger-labs/fabric-token-sdk/token/services/schedulerdb/db/sql"
 	sdriver "github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/sherdlock"
 	selector "github.com/hyperledger-labs/fabric-token-sdk/token/services/selector/simple"
@@ -93,6 +100,7 @@ func (p *SDK) Install() error {
 		p.Container().Provide(common.NewAcceptTxInDBFilterProvider),
 		p.Container().Provide(network.NewProvider),
 		p.Container().Provide(newTokenDriverService),
+		p.Container().Provide(digutils.Identity[*driver2.TokenDriverService](), dig.As(new(auditor.PublicParamsDeserializer))),
 		p.Container().Provide(
 			digutils.Identity[*network.Provider](),
 			dig.As(
@@ -100,6 +108,7 @@ func (p *SDK) Install() error {
 				new(token.Normalizer),
 				new(auditor.NetworkProvider),
 				new(common2.NetworkProvider),
+				new(rescan.NetworkProvider),
 			),
 		),
 		p.Container().Provide(func(networkProvider *network.Provider) *vault.PublicParamsProvider {
@@ -116,6 +125,9 @@ func (p *SDK) Install() error {
 		}, dig.As(new(selector.LockerProvider))),
 		p.Container().Provide(selectorProviders[sdriver.Driver(p.ConfigService().GetString("token.selector.driver"))], dig.As(new(token.SelectorManagerProvider))),
 		p.Container().Provide(network2.NewCertificationClientProvider, dig.As(new(token.CertificationClientProvider))),
+		p.Container().Provide(func(ttxdbManager *ttxdb.Manager) *policy.Provider {
+			return policy.NewProvider(ttxdbManager)
+		}, dig.As(new(token.TransferPolicyProvider))),
 		p.Container().Provide(func(networkProvider *network.Provider) *vault.ProviderAdaptor {
 			return &vault.ProviderAdaptor{Provider: networkProvider}
 		}, dig.As(new(token.VaultProvider))),
@@ -128,17 +140,27 @@ func (p *SDK) Install() error {
 				new(tokens.TMSProvider),
 				new(auditor.TokenManagementServiceProvider),
 				new(common2.TokenManagementServiceProvider),
+				new(rescan.TMSProvider),
 			),
 		),
 		p.Container().Provide(NewTTXDBManager),
-		p.Container().Provide(digutils.Identity[*ttxdb.Manager](), dig.As(new(ttx.DBProvider), new(network2.TTXDBProvider))),
+		p.Container().Provide(digutils.Identity[*ttxdb.Manager](), dig.As(new(ttx.DBProvider), new(network2.TTXDBProvider), new(rescan.TTXDBProvider), new(scheduler.TTXDBProvider))),
 		p.Container().Provide(NewTokenManagers),
 		p.Container().Provide(digutils.Identity[*tokendb.Manager](), dig.As(new(tokens.DBProvider))),
+		p.Container().Provide(digutils.Identity[*tokendb.NotifierManager](), dig.As(new(ttx.TokenNotifierProvider))),
 		p.Container().Provide(NewAuditDBManager),
-		p.Container().Provide(digutils.Identity[*auditdb.Manager](), dig.As(new(auditor.AuditDBProvider))),
+		p.Container().Provide(digutils.Identity[*auditdb.Manager](), dig.As(new(auditor.AuditDBProvider), new(rescan.AuditDBProvider))),
 		p.Container().Provide(NewIdentityDBManager),
 		p.Container().Provide(NewTokenLockDBManager),
-		p.Container().Provide(digutils.Identity[*kvs.KVS](), dig.As(new(kvs2.KVS))),
+		p.Container().Provide(NewSchedulerDBManager),
+		p.Container().Provide(digutils.Identity[*schedulerdb.Manager](), dig.As(new(scheduler.DBProvider))),
+		p.Container().Provide(digutils.Identity[*view2.Manager](), dig.As(new(scheduler.ViewManager), new(ttx.ViewManager))),
+		p.Container().Provide(scheduler.NewManager),
+		p.Container().Provide(func(configService driver.ConfigService) (*extsigner.Server, error) {
+			return extsigner.NewServer(configService)
+		}),
+		p.Container().Provide(func(server *extsigner.Server) *extsigner.Service { return server.Service() }),
+		p.Container().Provide(digutils.Identity[*kvs.KVS](), dig.As(new(kvs2.KVS), new(rescan.KVS))),
 		p.Container().Provide(identity.NewDBStorageProvider),
 		p.Container().Provide(digutils.Identity[*identity.DBStorageProvider](), dig.As(new(identity2.StorageProvider))),
 		p.Container().Provide(NewAuditorCheckServiceProvider),
@@ -148,7 +170,8 @@ func (p *SDK) Install() error {
 		p.Container().Provide(digutils.Identity[*db.OwnerCheckServiceProvider](), dig.As(new(ttx.CheckServiceProvider))),
 		p.Container().Provide(ttx.NewManager),
 		p.Container().Provide(tokens.NewManager),
-		p.Container().Provide(digutils.Identity[*tokens.Manager](), dig.As(new(ttx.TokensProvider), new(auditor.TokenDBProvider))),
+		p.Container().Provide(rescan.NewManager),
+		p.Container().Provide(digutils.Identity[*tokens.Manager](), dig.As(new(ttx.TokensProvider), new(auditor.TokenDBProvider), new(rescan.TokensProvider))),
 		p.Container().Provide(vault.NewVaultProvider),
 		p.Container().Provide(tms.NewPostInitializer),
 		p.Container().Provide(ttx.NewMetrics),
@@ -156,6 +179,7 @@ func (p *SDK) Install() error {
 			return tracing.NewTracerProvider(tracerProvider)
 		}),
 		p.Container().Provide(tokenlockdriver.NewDriver, dig.Group("tokenlockdb-drivers")),
+		p.Container().Provide(schedulerdriver.NewDriver, dig.Group("schedulerdb-drivers")),
 		p.Container().Provide(auditdriver.NewDriver, dig.Group("auditdb-drivers")),
 		p.Container().Provide(NewTokenDrivers),
 		p.Container().Provide(ttxdriver.NewDriver, dig.Group("ttxdb-drivers")),
@@ -199,6 +223,9 @@ func (p *SDK) Install() error {
 		digutils.Register[*config2.Service](p.Container()),
 		digutils.Register[*ttx.Manager](p.Container()),
 		digutils.Register[*tokens.Manager](p.Container()),
+		digutils.Register[*rescan.Manager](p.Container()),
+		digutils.Register[*scheduler.Manager](p.Container()),
+		digutils.Register[*extsigner.Service](p.Container()),
 		digutils.Register[trace.TracerProvider](p.Container()),
 		digutils.Register[metrics.Provider](p.Container()),
 	)
@@ -230,6 +257,8 @@ func (p *SDK) Start(ctx context.Context) error {
 	return errors2.Join(
 		p.Container().Invoke(registerNetworkDrivers),
 		p.Container().Invoke(connectNetworks),
+		p.Container().Invoke(func(schedulerManager *scheduler.Manager) error { return schedulerManager.Start(ctx) }),
+		p.Container().Invoke(func(server *extsigner.Server) error { return server.Start(ctx) }),
 	)
 }
 
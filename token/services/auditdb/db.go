@@ -121,6 +121,33 @@ type QueryTransactionsParams = driver.QueryTransactionsParams
 // QueryTokenRequestsParams defines the parameters for querying token requests
 type QueryTokenRequestsParams = driver.QueryTokenRequestsParams
 
+// DiscrepancyRecord describes an inconsistency found on a transaction committed without being audited synchronously
+type DiscrepancyRecord = driver.DiscrepancyRecord
+
+// QueryDiscrepanciesParams defines the parameters for querying discrepancies
+type QueryDiscrepanciesParams = driver.QueryDiscrepanciesParams
+
+// ComplianceDecision is the outcome of the evaluation of the compliance rules on a transaction
+type ComplianceDecision = driver.ComplianceDecision
+
+const (
+	// ComplianceApproved is the decision on a transaction that violates no rule
+	ComplianceApproved = driver.ComplianceApproved
+	// ComplianceFlagged is the decision on a transaction the auditor approves but that needs a review
+	ComplianceFlagged = driver.ComplianceFlagged
+	// ComplianceDenied is the decision on a transaction the auditor refuses to sign
+	ComplianceDenied = driver.ComplianceDenied
+)
+
+// ComplianceDecisionMessage maps ComplianceDecision to string
+var ComplianceDecisionMessage = driver.ComplianceDecisionMessage
+
+// ComplianceDecisionRecord records the decision of the compliance rules on an audited transaction
+type ComplianceDecisionRecord = driver.ComplianceDecisionRecord
+
+// QueryComplianceDecisionsParams defines the parameters for querying compliance decisions
+type QueryComplianceDecisionsParams = driver.QueryComplianceDecisionsParams
+
 // Wallet models a wallet
 type Wallet interface {
 	// ID returns the wallet ID
@@ -264,6 +291,62 @@ func (d *DB) GetTokenRequest(txID string) ([]byte, error) {
 	return d.db.GetTokenRequest(txID)
 }
 
+// AddDiscrepancy records a discrepancy for the passed transaction id
+func (d *DB) AddDiscrepancy(txID string, reason string) error {
+	logger.Warnf("discrepancy found for [%s]: %s", txID, reason)
+	if err := d.db.AddDiscrepancy(&DiscrepancyRecord{TxID: txID, Reason: reason, Timestamp: time.Now()}); err != nil {
+		return errors.Wrapf(err, "failed adding discrepancy for [%s]", txID)
+	}
+	return nil
+}
+
+// Discrepancies returns the discrepancies matching the passed params
+func (d *DB) Discrepancies(params QueryDiscrepanciesParams) ([]*DiscrepancyRecord, error) {
+	return d.db.QueryDiscrepancies(params)
+}
+
+// AddComplianceDecision records the decision of the compliance rules on the passed transaction id
+func (d *DB) AddComplianceDecision(txID string, decision ComplianceDecision, reason string) error {
+	if decision != ComplianceApproved {
+		logger.Warnf("transaction [%s] %s by the compliance rules: %s", txID, ComplianceDecisionMessage[decision], reason)
+	}
+	if err := d.db.AddComplianceDecision(&ComplianceDecisionRecord{TxID: txID, Decision: decision, Reason: reason, Timestamp: time.Now()}); err != nil {
+		return errors.Wrapf(err, "failed adding compliance decision for [%s]", txID)
+	}
+	return nil
+}
+
+// ComplianceDecisions returns the compliance decisions matching the passed params
+func (d *DB) ComplianceDecisions(params QueryComplianceDecisionsParams) ([]*ComplianceDecisionRecord, error) {
+	return d.db.QueryComplianceDecisions(params)
+}
+
+// AddAuditorSignature stores the signature the passed auditor identity produced on the token request of the passed transaction
+func (d *DB) AddAuditorSignature(txID string, auditor token.Identity, sigma []byte) error {
+	if err := d.db.AddTransactionEndorsementAck(txID, auditor, sigma); err != nil {
+		return errors.Wrapf(err, "failed storing auditor signature for [%s]", txID)
+	}
+	return nil
+}
+
+// AuditorSignatures returns the auditor signatures stored for the passed transaction, indexed by auditor identity
+func (d *DB) AuditorSignatures(txID string) (map[string][]byte, error) {
+	return d.db.GetTransactionEndorsementAcks(txID)
+}
+
+// AddTransactionAttachment stores the passed payload as the attachment of the given audited transaction with the given name
+func (d *DB) AddTransactionAttachment(txID string, name string, payload []byte) error {
+	if err := d.db.AddTransactionAttachment(txID, name, payload); err != nil {
+		return errors.Wrapf(err, "failed storing attachment [%s] of [%s]", name, txID)
+	}
+	return nil
+}
+
+// TransactionAttachments returns the attachments of the given audited transaction, indexed by name
+func (d *DB) TransactionAttachments(txID string) (map[string][]byte, error) {
+	return d.db.GetTransactionAttachments(txID)
+}
+
 // AcquireLocks acquires locks for the passed anchor and enrollment ids.
 // This can be used to prevent concurrent read/write access to the audit records of the passed enrollment ids.
 func (d *DB) AcquireLocks(anchor string, eIDs ...string) error {
@@ -0,0 +1,231 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/driver"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultIngestionGracePeriod is the time the auditor waits, after a transaction has been committed,
+	// for the corresponding token request to be submitted before recording a discrepancy.
+	DefaultIngestionGracePeriod = time.Minute
+
+	// MissingTokenRequest is the reason recorded for a committed transaction that has never been submitted to the auditor
+	MissingTokenRequest = "token request committed without being submitted to the auditor"
+)
+
+// Ingest checks and appends to the auditor database a transaction that has been submitted for ordering
+// without the auditor's signature.
+// The compliance rules are evaluated as well, a denied transaction is recorded as a discrepancy.
+// Once the transaction is committed, the auditor verifies that the committed token request matches the ingested one
+// and runs the auditor's checks. Any inconsistency is recorded as a discrepancy.
+func (a *Auditor) Ingest(ctx context.Context, tx Transaction) error {
+	logger.Debugf("ingest transaction [%s]...", tx.ID())
+	if err := tx.Request().AuditCheck(ctx); err != nil {
+		if err2 := a.auditDB.AddDiscrepancy(tx.ID(), fmt.Sprintf("audit check failed: %s", err)); err2 != nil {
+			return errors.WithMessagef(err2, "failed recording discrepancy for [%s]", tx.ID())
+		}
+		return errors.WithMessagef(err, "failed audit check for [%s]", tx.ID())
+	}
+	// the transaction has already been submitted, a denial can only be recorded
+	result, err := a.CheckCompliance(ctx, tx)
+	if err != nil {
+		return errors.WithMessagef(err, "failed checking compliance of [%s]", tx.ID())
+	}
+	if result.Decision == Denied {
+		if err := a.auditDB.AddDiscrepancy(tx.ID(), fmt.Sprintf("denied by the compliance rules: %s", result.Reason())); err != nil {
+			return errors.WithMessagef(err, "failed recording discrepancy for [%s]", tx.ID())
+		}
+	}
+	if err := a.auditDB.Append(tx.Request()); err != nil {
+		return errors.WithMessagef(err, "failed appending request %s", tx.ID())
+	}
+
+	net, err := a.networkProvider.GetNetwork(tx.Network(), tx.Channel())
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting network instance for [%s:%s]", tx.Network(), tx.Channel())
+	}
+	logger.Debugf("register async tx status listener for tx [%s] at network [%s]", tx.ID(), tx.Network())
+	if err := net.AddFinalityListener(tx.Namespace(), tx.ID(), a.newIngestionListener()); err != nil {
+		return errors.WithMessagef(err, "failed listening to network [%s:%s]", tx.Network(), tx.Channel())
+	}
+	logger.Debugf("ingest done for request [%s]", tx.ID())
+	return nil
+}
+
+// ListenToCommits registers a listener for all the transactions committed in the auditor's namespace.
+// For each valid transaction whose token request has not been submitted to the auditor within the passed
+// grace period, a discrepancy is recorded.
+func (a *Auditor) ListenToCommits(gracePeriod time.Duration) error {
+	net, err := a.networkProvider.GetNetwork(a.tmsID.Network, a.tmsID.Channel)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting network instance for [%s]", a.tmsID)
+	}
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultIngestionGracePeriod
+	}
+	logger.Debugf("listen to all commits in [%s] with grace period [%s]", a.tmsID, gracePeriod)
+	return net.AddFinalityListener(a.tmsID.Namespace, "", &commitListener{auditor: a, gracePeriod: gracePeriod})
+}
+
+// AddDiscrepancy records a discrepancy for the passed transaction
+func (a *Auditor) AddDiscrepancy(txID string, reason string) error {
+	if err := a.auditDB.AddDiscrepancy(txID, reason); err != nil {
+		return errors.WithMessagef(err, "failed recording discrepancy for [%s]", txID)
+	}
+	return nil
+}
+
+// Discrepancies returns the discrepancies recorded for the passed transaction ids, all if none is passed
+func (a *Auditor) Discrepancies(txIDs ...string) ([]*DiscrepancyRecord, error) {
+	return a.auditDB.Discrepancies(QueryDiscrepanciesParams{IDs: txIDs})
+}
+
+func (a *Auditor) newIngestionListener() *ingestionListener {
+	return &ingestionListener{
+		auditor:  a,
+		delegate: common.NewFinalityListener(logger, a.tmsProvider, a.tmsID, a.auditDB, a.tokenDB, a.finalityTracer),
+	}
+}
+
+// ingestionListener updates the status of an ingested transaction and records the discrepancies found once committed
+type ingestionListener struct {
+	auditor  *Auditor
+	delegate driver.FinalityListener
+}
+
+func (l *ingestionListener) OnStatus(ctx context.Context, txID string, status int, message string, tokenRequestHash []byte) {
+	l.delegate.OnStatus(ctx, txID, status, message, tokenRequestHash)
+	if status != network.Valid {
+		return
+	}
+
+	// the delegate marks as deleted a transaction whose committed token request does not match the ingested one
+	s, m, err := l.auditor.auditDB.GetStatus(txID)
+	if err != nil {
+		logger.Errorf("failed getting status for [%s]: [%s]", txID, err)
+		return
+	}
+	if s == Deleted {
+		l.record(txID, fmt.Sprintf("committed token request does not match the ingested one: %s", m))
+	}
+
+	issues, err := l.auditor.checkTransaction(ctx, txID)
+	if err != nil {
+		logger.Errorf("failed checking [%s]: [%s]", txID, err)
+		return
+	}
+	for _, issue := range issues {
+		l.record(txID, issue)
+	}
+}
+
+func (l *ingestionListener) record(txID, reason string) {
+	if err := l.auditor.auditDB.AddDiscrepancy(txID, reason); err != nil {
+		logger.Errorf("failed recording discrepancy for [%s]: [%s]", txID, err)
+	}
+}
+
+// checkTransaction compares the ingested transaction with the ledger.
+// It checks the ledger status of the transaction and that the outputs on the ledger are the audited ones.
+func (a *Auditor) checkTransaction(ctx context.Context, txID string) ([]string, error) {
+	net, err := a.networkProvider.GetNetwork(a.tmsID.Network, a.tmsID.Channel)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting network instance for [%s]", a.tmsID)
+	}
+	l, err := net.Ledger()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting ledger for [%s]", a.tmsID)
+	}
+	var issues []string
+	vc, _, err := l.Status(txID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting ledger status")
+	}
+	if vc != network.Valid {
+		issues = append(issues, fmt.Sprintf("transaction is valid for the auditor but not for the ledger [%d]", vc))
+	}
+
+	raw, err := a.auditDB.GetTokenRequest(txID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting token request")
+	}
+	tms, err := a.tmsProvider.GetManagementService(token.WithTMSID(a.tmsID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting tms [%s]", a.tmsID)
+	}
+	request, err := tms.NewFullRequestFromBytes(raw)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling token request")
+	}
+	outputs, err := request.Outputs()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting outputs")
+	}
+	var ids []*token2.ID
+	var expected [][]byte
+	for _, output := range outputs.Outputs() {
+		if len(output.LedgerOutput) == 0 {
+			// redeemed, not stored on the ledger
+			continue
+		}
+		ids = append(ids, output.ID(txID))
+		expected = append(expected, output.LedgerOutput)
+	}
+	if len(ids) == 0 {
+		return issues, nil
+	}
+	contents, err := net.QueryTokens(ctx, a.tmsID.Namespace, ids)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying tokens")
+	}
+	if len(contents) != len(ids) {
+		return append(issues, fmt.Sprintf("expected [%d] outputs on the ledger, got [%d]", len(ids), len(contents))), nil
+	}
+	for i, id := range ids {
+		if !bytes.Equal(contents[i], expected[i]) {
+			issues = append(issues, fmt.Sprintf("output [%s] on the ledger does not match the audited one", id))
+		}
+	}
+	return issues, nil
+}
+
+// commitListener records a discrepancy for each valid transaction that the auditor has not seen
+type commitListener struct {
+	auditor     *Auditor
+	gracePeriod time.Duration
+}
+
+func (l *commitListener) OnStatus(_ context.Context, txID string, status int, _ string, _ []byte) {
+	if status != network.Valid {
+		return
+	}
+	time.AfterFunc(l.gracePeriod, func() {
+		tr, err := l.auditor.auditDB.GetTokenRequest(txID)
+		if err != nil {
+			logger.Errorf("failed getting token request for [%s]: [%s]", txID, err)
+			return
+		}
+		if tr != nil {
+			return
+		}
+		if err := l.auditor.auditDB.AddDiscrepancy(txID, MissingTokenRequest); err != nil {
+			logger.Errorf("failed recording discrepancy for [%s]: [%s]", txID, err)
+		}
+	})
+}
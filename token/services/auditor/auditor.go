@@ -8,6 +8,7 @@ package auditor
 
 import (
 	"context"
+	"sync"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
@@ -18,6 +19,7 @@ import (
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokens"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/trace"
 )
@@ -40,6 +42,12 @@ const txIdLabel tracing.LabelName = "tx_id"
 
 var TxStatusMessage = auditdb.TxStatusMessage
 
+// DiscrepancyRecord describes an inconsistency found on a transaction committed without being audited synchronously
+type DiscrepancyRecord = auditdb.DiscrepancyRecord
+
+// QueryDiscrepanciesParams defines the parameters for querying discrepancies
+type QueryDiscrepanciesParams = auditdb.QueryDiscrepanciesParams
+
 // Transaction models a generic token transaction
 type Transaction interface {
 	ID() string
@@ -66,6 +74,11 @@ type Auditor struct {
 	tmsProvider     TokenManagementServiceProvider
 	finalityTracer  trace.Tracer
 	checkService    CheckService
+	ppDeserializer  PublicParamsDeserializer
+
+	// complianceLock guards the lazy loading of the compliance engine, the engine guards its own rules
+	complianceLock sync.Mutex
+	compliance     *ComplianceEngine
 }
 
 // Validate validates the passed token request
@@ -120,6 +133,88 @@ func (a *Auditor) Append(tx Transaction) error {
 	return nil
 }
 
+// AddTransactionAttachment stores an off-ledger attachment of the passed audited transaction
+func (a *Auditor) AddTransactionAttachment(txID string, name string, payload []byte) error {
+	return a.auditDB.AddTransactionAttachment(txID, name, payload)
+}
+
+// TransactionAttachments returns the off-ledger attachments of the passed audited transaction, indexed by name
+func (a *Auditor) TransactionAttachments(txID string) (map[string][]byte, error) {
+	return a.auditDB.TransactionAttachments(txID)
+}
+
+// AddComplianceRule adds the passed rule to those configured for this auditor
+func (a *Auditor) AddComplianceRule(rule ComplianceRule) error {
+	a.complianceLock.Lock()
+	defer a.complianceLock.Unlock()
+	engine, err := a.complianceEngine()
+	if err != nil {
+		return err
+	}
+	engine.AddRule(rule)
+	return nil
+}
+
+// CheckCompliance evaluates the compliance rules on the passed transaction and records the decision in the audit db.
+// If no rule is set, the transaction is approved and nothing is recorded.
+func (a *Auditor) CheckCompliance(ctx context.Context, tx Transaction) (*ComplianceResult, error) {
+	a.complianceLock.Lock()
+	engine, err := a.complianceEngine()
+	a.complianceLock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if engine.Empty() {
+		return &ComplianceResult{Decision: Approved}, nil
+	}
+
+	request := tx.Request()
+	record, err := request.AuditRecord()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting transaction audit record")
+	}
+	var metadata map[string][]byte
+	if request.Metadata != nil {
+		metadata = request.Metadata.Application
+	}
+	result, err := engine.Evaluate(ctx, &AuditedRequest{
+		TxID:                tx.ID(),
+		Inputs:              record.Inputs,
+		Outputs:             record.Outputs,
+		ApplicationMetadata: metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := a.auditDB.AddComplianceDecision(tx.ID(), result.Decision, result.Reason()); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ComplianceDecisions returns the compliance decisions recorded for the passed transaction ids, all if none is passed
+func (a *Auditor) ComplianceDecisions(txIDs ...string) ([]*ComplianceDecisionRecord, error) {
+	return a.auditDB.ComplianceDecisions(QueryComplianceDecisionsParams{IDs: txIDs})
+}
+
+// complianceEngine returns the compliance engine, loading the configured rules at the first invocation.
+// It must be called holding complianceLock.
+func (a *Auditor) complianceEngine() (*ComplianceEngine, error) {
+	if a.compliance != nil {
+		return a.compliance, nil
+	}
+	tms, err := a.tmsProvider.GetManagementService(token.WithTMSID(a.tmsID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting tms [%s]", a.tmsID)
+	}
+	engine, err := NewComplianceEngineFromConfig(tms.Configuration(), &auditDBHistory{db: a.auditDB})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed loading compliance rules for [%s]", a.tmsID)
+	}
+	a.compliance = engine
+	return engine, nil
+}
+
 // Release releases the lock acquired of the passed transaction.
 func (a *Auditor) Release(tx Transaction) {
 	a.auditDB.ReleaseLocks(tx.Request().Anchor)
@@ -143,6 +238,21 @@ func (a *Auditor) GetTokenRequest(txID string) ([]byte, error) {
 	return a.auditDB.GetTokenRequest(txID)
 }
 
+// LedgerOutputs returns the outputs, in the clear, of the passed token request as committed on the ledger.
+// It does not need the token request metadata, therefore it can be used on transactions that have not been
+// submitted to the auditor. The passed types are the candidate token types the auditor expects.
+func (a *Auditor) LedgerOutputs(ctx context.Context, txID string, raw []byte, types []string) ([][]*token2.Token, [][]*token2.Token, error) {
+	tms, err := a.tmsProvider.GetManagementService(token.WithTMSID(a.tmsID))
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed getting tms [%s]", a.tmsID)
+	}
+	request, err := tms.NewRequestFromBytes(txID, raw, nil)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed unmarshalling token request [%s]", txID)
+	}
+	return request.LedgerOutputs(ctx, types)
+}
+
 func (a *Auditor) Check(context context.Context) ([]string, error) {
 	return a.checkService.Check(context)
 }
@@ -0,0 +1,327 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditdb"
+	"github.com/pkg/errors"
+)
+
+// ComplianceDecision is the outcome of the evaluation of the compliance rules on a transaction
+type ComplianceDecision = auditdb.ComplianceDecision
+
+const (
+	// Approved is the decision on a transaction that violates no rule
+	Approved = auditdb.ComplianceApproved
+	// Flagged is the decision on a transaction the auditor signs but that needs a review
+	Flagged = auditdb.ComplianceFlagged
+	// Denied is the decision on a transaction the auditor refuses to sign
+	Denied = auditdb.ComplianceDenied
+)
+
+// ComplianceDecisionRecord records the decision of the compliance rules on an audited transaction
+type ComplianceDecisionRecord = auditdb.ComplianceDecisionRecord
+
+// QueryComplianceDecisionsParams defines the parameters for querying compliance decisions
+type QueryComplianceDecisionsParams = auditdb.QueryComplianceDecisionsParams
+
+// AuditedRequest is what the compliance rules evaluate: the inputs and outputs of an audited token request,
+// with the enrollment IDs of their owners, and its application metadata
+type AuditedRequest struct {
+	TxID                string
+	Inputs              *token.InputStream
+	Outputs             *token.OutputStream
+	ApplicationMetadata map[string][]byte
+}
+
+// Parties returns the sorted enrollment IDs owning the inputs or the outputs of the request
+func (r *AuditedRequest) Parties() []string {
+	var eIDs []string
+	eIDs = append(eIDs, r.Inputs.EnrollmentIDs()...)
+	eIDs = append(eIDs, r.Outputs.EnrollmentIDs()...)
+	sort.Strings(eIDs)
+	return slices.Compact(slices.DeleteFunc(eIDs, func(eID string) bool { return len(eID) == 0 }))
+}
+
+// Sent returns, for each enrollment ID and token type, the amount the enrollment ID pays to others with the request,
+// that is the amount of its inputs minus the amount of the outputs it owns. Issued tokens are not sent by anyone.
+func (r *AuditedRequest) Sent() map[string]map[string]*big.Int {
+	net := map[string]map[string]*big.Int{}
+	add := func(eID, tokenType string, amount *big.Int) {
+		if len(eID) == 0 {
+			return
+		}
+		if _, ok := net[eID]; !ok {
+			net[eID] = map[string]*big.Int{}
+		}
+		if _, ok := net[eID][tokenType]; !ok {
+			net[eID][tokenType] = big.NewInt(0)
+		}
+		net[eID][tokenType].Add(net[eID][tokenType], amount)
+	}
+	for _, input := range r.Inputs.Inputs() {
+		if input.Quantity != nil {
+			add(input.EnrollmentID, input.Type, input.Quantity.ToBigInt())
+		}
+	}
+	senders := map[string]bool{}
+	for _, input := range r.Inputs.Inputs() {
+		senders[input.EnrollmentID] = true
+	}
+	for _, output := range r.Outputs.Outputs() {
+		if output.Quantity != nil && senders[output.EnrollmentID] {
+			add(output.EnrollmentID, output.Type, new(big.Int).Neg(output.Quantity.ToBigInt()))
+		}
+	}
+	sent := map[string]map[string]*big.Int{}
+	for eID, amounts := range net {
+		for tokenType, amount := range amounts {
+			if amount.Sign() <= 0 {
+				continue
+			}
+			if _, ok := sent[eID]; !ok {
+				sent[eID] = map[string]*big.Int{}
+			}
+			sent[eID][tokenType] = amount
+		}
+	}
+	return sent
+}
+
+// ComplianceRule evaluates an audited request.
+// It returns the decision on the request and, unless the request is approved, the reason of the decision.
+type ComplianceRule interface {
+	Name() string
+	Evaluate(ctx context.Context, request *AuditedRequest) (ComplianceDecision, string, error)
+}
+
+// ComplianceFinding is the decision of a single rule that did not approve a request
+type ComplianceFinding struct {
+	Rule     string
+	Decision ComplianceDecision
+	Reason   string
+}
+
+// ComplianceResult is the outcome of the evaluation of all the compliance rules on a request.
+// The decision is the most severe among those of the rules: a request is denied if any rule denies it,
+// flagged if any rule flags it, approved otherwise.
+type ComplianceResult struct {
+	Decision ComplianceDecision
+	Findings []*ComplianceFinding
+}
+
+// Reason describes the findings, empty if the request has been approved
+func (r *ComplianceResult) Reason() string {
+	reasons := make([]string, len(r.Findings))
+	for i, finding := range r.Findings {
+		reasons[i] = fmt.Sprintf("rule [%s] %s: %s", finding.Rule, strings.ToLower(auditdb.ComplianceDecisionMessage[finding.Decision]), finding.Reason)
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// ComplianceEngine evaluates the audited requests against a list of rules.
+// Rules can be added while requests are evaluated, an evaluation uses the rules in place when it starts.
+type ComplianceEngine struct {
+	lock  sync.RWMutex
+	rules []ComplianceRule
+}
+
+// NewComplianceEngine returns a new ComplianceEngine for the passed rules
+func NewComplianceEngine(rules ...ComplianceRule) *ComplianceEngine {
+	return &ComplianceEngine{rules: rules}
+}
+
+// AddRule appends the passed rule to those of the engine
+func (e *ComplianceEngine) AddRule(rule ComplianceRule) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.rules = append(e.rules, rule)
+}
+
+// Empty returns true if the engine has no rules
+func (e *ComplianceEngine) Empty() bool {
+	return len(e.Rules()) == 0
+}
+
+// Rules returns a copy of the rules of the engine
+func (e *ComplianceEngine) Rules() []ComplianceRule {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return slices.Clone(e.rules)
+}
+
+// Evaluate evaluates all the rules on the passed request.
+// If a rule fails, an error is returned and no decision is taken.
+func (e *ComplianceEngine) Evaluate(ctx context.Context, request *AuditedRequest) (*ComplianceResult, error) {
+	result := &ComplianceResult{Decision: Approved}
+	for _, rule := range e.Rules() {
+		decision, reason, err := rule.Evaluate(ctx, request)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed evaluating rule [%s] on [%s]", rule.Name(), request.TxID)
+		}
+		if decision == Approved {
+			continue
+		}
+		result.Findings = append(result.Findings, &ComplianceFinding{Rule: rule.Name(), Decision: decision, Reason: reason})
+		if decision > result.Decision {
+			result.Decision = decision
+		}
+	}
+	return result, nil
+}
+
+// SanctionsRule takes its decision on the requests involving, as sender or recipient, a sanctioned enrollment ID
+type SanctionsRule struct {
+	RuleName      string
+	Decision      ComplianceDecision
+	EnrollmentIDs []string
+}
+
+func (r *SanctionsRule) Name() string {
+	return r.RuleName
+}
+
+func (r *SanctionsRule) Evaluate(_ context.Context, request *AuditedRequest) (ComplianceDecision, string, error) {
+	for _, eID := range request.Parties() {
+		if slices.Contains(r.EnrollmentIDs, eID) {
+			return r.Decision, fmt.Sprintf("[%s] is on the sanctions list", eID), nil
+		}
+	}
+	return Approved, "", nil
+}
+
+// LargeTransferRule takes its decision on the requests in which an enrollment ID sends more than a threshold
+// of a token type, all types if none is listed
+type LargeTransferRule struct {
+	RuleName  string
+	Decision  ComplianceDecision
+	Types     []string
+	Threshold uint64
+}
+
+func (r *LargeTransferRule) Name() string {
+	return r.RuleName
+}
+
+func (r *LargeTransferRule) Evaluate(_ context.Context, request *AuditedRequest) (ComplianceDecision, string, error) {
+	threshold := new(big.Int).SetUint64(r.Threshold)
+	for _, s := range sortedSent(request.Sent(), r.Types) {
+		if s.amount.Cmp(threshold) > 0 {
+			return r.Decision, fmt.Sprintf("[%s] sends [%s] [%s] tokens, above the threshold [%d]", s.eID, s.amount, s.tokenType, r.Threshold), nil
+		}
+	}
+	return Approved, "", nil
+}
+
+// PaymentHistory returns the amount of a token type sent by an enrollment ID since a given time
+type PaymentHistory interface {
+	Sent(eID string, tokenType string, from time.Time) (*big.Int, error)
+}
+
+// VelocityRule takes its decision on the requests in which an enrollment ID sends, together with what it has sent
+// within the window preceding the request, more than a limit of a token type, all types if none is listed
+type VelocityRule struct {
+	RuleName string
+	Decision ComplianceDecision
+	Types    []string
+	Window   time.Duration
+	Limit    uint64
+	History  PaymentHistory
+	Now      func() time.Time
+}
+
+func (r *VelocityRule) Name() string {
+	return r.RuleName
+}
+
+func (r *VelocityRule) Evaluate(_ context.Context, request *AuditedRequest) (ComplianceDecision, string, error) {
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+	from := now().Add(-r.Window)
+	limit := new(big.Int).SetUint64(r.Limit)
+	for _, s := range sortedSent(request.Sent(), r.Types) {
+		sent, err := r.History.Sent(s.eID, s.tokenType, from)
+		if err != nil {
+			return Approved, "", errors.WithMessagef(err, "failed getting the payments of [%s]", s.eID)
+		}
+		if new(big.Int).Add(sent, s.amount).Cmp(limit) > 0 {
+			return r.Decision, fmt.Sprintf("[%s] sends [%s] [%s] tokens after sending [%s] in the last [%s], above the limit [%d]", s.eID, s.amount, s.tokenType, sent, r.Window, r.Limit), nil
+		}
+	}
+	return Approved, "", nil
+}
+
+// RequiredMetadataRule takes its decision on the requests missing some application metadata
+type RequiredMetadataRule struct {
+	RuleName string
+	Decision ComplianceDecision
+	Keys     []string
+}
+
+func (r *RequiredMetadataRule) Name() string {
+	return r.RuleName
+}
+
+func (r *RequiredMetadataRule) Evaluate(_ context.Context, request *AuditedRequest) (ComplianceDecision, string, error) {
+	for _, key := range r.Keys {
+		if len(request.ApplicationMetadata[key]) == 0 {
+			return r.Decision, fmt.Sprintf("application metadata [%s] missing", key), nil
+		}
+	}
+	return Approved, "", nil
+}
+
+type sentAmount struct {
+	eID       string
+	tokenType string
+	amount    *big.Int
+}
+
+// sortedSent flattens the passed amounts, keeping only the passed types if any, so that rules are evaluated deterministically
+func sortedSent(sent map[string]map[string]*big.Int, types []string) []sentAmount {
+	var res []sentAmount
+	for eID, amounts := range sent {
+		for tokenType, amount := range amounts {
+			if len(types) != 0 && !slices.Contains(types, tokenType) {
+				continue
+			}
+			res = append(res, sentAmount{eID: eID, tokenType: tokenType, amount: amount})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].eID != res[j].eID {
+			return res[i].eID < res[j].eID
+		}
+		return res[i].tokenType < res[j].tokenType
+	})
+	return res
+}
+
+// auditDBHistory is a PaymentHistory over the movements recorded in the auditdb, pending and confirmed transactions included
+type auditDBHistory struct {
+	db *auditdb.DB
+}
+
+func (h *auditDBHistory) Sent(eID string, tokenType string, from time.Time) (*big.Int, error) {
+	filter, err := h.db.NewPaymentsFilter().ByEnrollmentId(eID).ByType(tokenType).Since(from).Execute()
+	if err != nil {
+		return nil, err
+	}
+	return filter.Sum(), nil
+}
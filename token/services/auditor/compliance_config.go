@@ -0,0 +1,122 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditor
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+)
+
+// ComplianceConfigKey is the configuration key, relative to the TMS, of the compliance rules of the auditor
+const ComplianceConfigKey = "services.auditor.compliance"
+
+const (
+	// SanctionsRuleKind selects a SanctionsRule
+	SanctionsRuleKind = "sanctions"
+	// LargeTransferRuleKind selects a LargeTransferRule
+	LargeTransferRuleKind = "largeTransfer"
+	// VelocityRuleKind selects a VelocityRule
+	VelocityRuleKind = "velocity"
+	// RequiredMetadataRuleKind selects a RequiredMetadataRule
+	RequiredMetadataRuleKind = "requiredMetadata"
+)
+
+// ComplianceConfig is the configuration of the compliance rules of the auditor of a TMS
+type ComplianceConfig struct {
+	Rules []*ComplianceRuleConfig `yaml:"rules,omitempty"`
+}
+
+// ComplianceRuleConfig configures a built-in compliance rule
+type ComplianceRuleConfig struct {
+	// Name identifies the rule in the decisions, defaults to the kind and position of the rule
+	Name string `yaml:"name,omitempty"`
+	// Kind is one of sanctions, largeTransfer, velocity and requiredMetadata
+	Kind string `yaml:"kind,omitempty"`
+	// Decision is the decision taken by the rule when violated: flag or deny.
+	// Defaults to flag for largeTransfer, to deny for the other kinds.
+	Decision string `yaml:"decision,omitempty"`
+	// EnrollmentIDs is the sanctions list
+	EnrollmentIDs []string `yaml:"enrollmentIDs,omitempty"`
+	// Types are the token types the largeTransfer and velocity rules apply to, all if empty
+	Types []string `yaml:"types,omitempty"`
+	// Threshold is the amount an enrollment ID can send with a single request without violating a largeTransfer rule
+	Threshold uint64 `yaml:"threshold,omitempty"`
+	// Window is the period over which a velocity rule sums the amounts sent
+	Window time.Duration `yaml:"window,omitempty"`
+	// Limit is the amount an enrollment ID can send within the window without violating a velocity rule
+	Limit uint64 `yaml:"limit,omitempty"`
+	// Keys are the application metadata keys required by a requiredMetadata rule
+	Keys []string `yaml:"keys,omitempty"`
+}
+
+// NewComplianceEngineFromConfig returns the engine evaluating the rules configured for the passed TMS,
+// an empty engine if none is configured
+func NewComplianceEngineFromConfig(tmsConfig *token.Configuration, history PaymentHistory) (*ComplianceEngine, error) {
+	engine := NewComplianceEngine()
+	if !tmsConfig.IsSet(ComplianceConfigKey) {
+		return engine, nil
+	}
+	c := &ComplianceConfig{}
+	if err := tmsConfig.UnmarshalKey(ComplianceConfigKey, c); err != nil {
+		return nil, errors.Wrapf(err, "invalid config for key [%s]", ComplianceConfigKey)
+	}
+	for i, rc := range c.Rules {
+		rule, err := rc.rule(i, history)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid compliance rule [%d]", i)
+		}
+		engine.AddRule(rule)
+	}
+	return engine, nil
+}
+
+func (c *ComplianceRuleConfig) rule(index int, history PaymentHistory) (ComplianceRule, error) {
+	name := c.Name
+	if len(name) == 0 {
+		name = fmt.Sprintf("%s-%d", c.Kind, index)
+	}
+	defaultDecision := Denied
+	if c.Kind == LargeTransferRuleKind {
+		defaultDecision = Flagged
+	}
+	decision, err := parseDecision(c.Decision, defaultDecision)
+	if err != nil {
+		return nil, err
+	}
+	switch c.Kind {
+	case SanctionsRuleKind:
+		return &SanctionsRule{RuleName: name, Decision: decision, EnrollmentIDs: c.EnrollmentIDs}, nil
+	case LargeTransferRuleKind:
+		return &LargeTransferRule{RuleName: name, Decision: decision, Types: c.Types, Threshold: c.Threshold}, nil
+	case VelocityRuleKind:
+		if c.Window <= 0 {
+			return nil, errors.Errorf("velocity rule [%s] requires a positive window", name)
+		}
+		return &VelocityRule{RuleName: name, Decision: decision, Types: c.Types, Window: c.Window, Limit: c.Limit, History: history}, nil
+	case RequiredMetadataRuleKind:
+		return &RequiredMetadataRule{RuleName: name, Decision: decision, Keys: c.Keys}, nil
+	default:
+		return nil, errors.Errorf("unknown rule kind [%s]", c.Kind)
+	}
+}
+
+func parseDecision(s string, defaultDecision ComplianceDecision) (ComplianceDecision, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return defaultDecision, nil
+	case "flag":
+		return Flagged, nil
+	case "deny":
+		return Denied, nil
+	default:
+		return Approved, errors.Errorf("invalid decision [%s], expected flag or deny", s)
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditor
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func quantity(v uint64) token2.Quantity {
+	return token2.NewQuantityFromUInt64(v)
+}
+
+// request returns a request in which alice pays 70 USD to bob and 30 USD to charlie, getting back 20 USD as change,
+// and the issuer issues 500 EUR to dave
+func request() *AuditedRequest {
+	inputs := token.NewInputStream(nil, []*token.Input{
+		{EnrollmentID: "alice", Type: "USD", Quantity: quantity(60)},
+		{EnrollmentID: "alice", Type: "USD", Quantity: quantity(60)},
+	}, 64)
+	outputs := token.NewOutputStream([]*token.Output{
+		{EnrollmentID: "dave", Type: "EUR", Quantity: quantity(500)},
+		{EnrollmentID: "bob", Type: "USD", Quantity: quantity(70)},
+		{EnrollmentID: "charlie", Type: "USD", Quantity: quantity(30)},
+		{EnrollmentID: "alice", Type: "USD", Quantity: quantity(20)},
+	}, 64)
+	return &AuditedRequest{
+		TxID:                "tx1",
+		Inputs:              inputs,
+		Outputs:             outputs,
+		ApplicationMetadata: map[string][]byte{"travel.rule": []byte("payload")},
+	}
+}
+
+type history map[string]int64
+
+func (h history) Sent(eID string, tokenType string, from time.Time) (*big.Int, error) {
+	if eID == "broken" {
+		return nil, errors.New("db unavailable")
+	}
+	return big.NewInt(h[eID+tokenType]), nil
+}
+
+func TestAuditedRequest(t *testing.T) {
+	r := request()
+	assert.Equal(t, []string{"alice", "bob", "charlie", "dave"}, r.Parties())
+	assert.Equal(t, map[string]map[string]*big.Int{"alice": {"USD": big.NewInt(100)}}, r.Sent())
+}
+
+func TestComplianceEngine(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+	engine := NewComplianceEngine()
+	assert.True(t, engine.Empty())
+	result, err := engine.Evaluate(ctx, request())
+	assert.NoError(t, err)
+	assert.Equal(t, Approved, result.Decision)
+	assert.Empty(t, result.Reason())
+
+	engine.AddRule(&SanctionsRule{RuleName: "ofac", Decision: Denied, EnrollmentIDs: []string{"mallory"}})
+	engine.AddRule(&LargeTransferRule{RuleName: "large", Decision: Flagged, Types: []string{"USD"}, Threshold: 100})
+	engine.AddRule(&VelocityRule{RuleName: "velocity", Decision: Denied, Window: 24 * time.Hour, Limit: 1000, History: history{"aliceUSD": 900}, Now: func() time.Time { return now }})
+	engine.AddRule(&RequiredMetadataRule{RuleName: "travel", Decision: Denied, Keys: []string{"travel.rule"}})
+	result, err = engine.Evaluate(ctx, request())
+	assert.NoError(t, err)
+	assert.Equal(t, Approved, result.Decision)
+
+	// the most severe decision wins
+	engine = NewComplianceEngine(
+		&LargeTransferRule{RuleName: "large", Decision: Flagged, Threshold: 99},
+		&SanctionsRule{RuleName: "ofac", Decision: Denied, EnrollmentIDs: []string{"charlie"}},
+	)
+	result, err = engine.Evaluate(ctx, request())
+	assert.NoError(t, err)
+	assert.Equal(t, Denied, result.Decision)
+	assert.Equal(t, "rule [large] flagged: [alice] sends [100] [USD] tokens, above the threshold [99]; rule [ofac] denied: [charlie] is on the sanctions list", result.Reason())
+
+	engine = NewComplianceEngine(&VelocityRule{RuleName: "velocity", Decision: Denied, Window: time.Hour, Limit: 1000, History: history{"aliceUSD": 901}})
+	result, err = engine.Evaluate(ctx, request())
+	assert.NoError(t, err)
+	assert.Equal(t, Denied, result.Decision)
+	assert.Equal(t, "rule [velocity] denied: [alice] sends [100] [USD] tokens after sending [901] in the last [1h0m0s], above the limit [1000]", result.Reason())
+
+	r := request()
+	r.ApplicationMetadata = nil
+	engine = NewComplianceEngine(&RequiredMetadataRule{RuleName: "travel", Decision: Flagged, Keys: []string{"travel.rule"}})
+	result, err = engine.Evaluate(ctx, r)
+	assert.NoError(t, err)
+	assert.Equal(t, Flagged, result.Decision)
+	assert.Equal(t, "rule [travel] flagged: application metadata [travel.rule] missing", result.Reason())
+
+	// a failing rule gives no decision
+	r = request()
+	r.Inputs = token.NewInputStream(nil, []*token.Input{{EnrollmentID: "broken", Type: "USD", Quantity: quantity(10)}}, 64)
+	engine = NewComplianceEngine(&VelocityRule{RuleName: "velocity", Decision: Denied, Window: time.Hour, Limit: 1000, History: history{}})
+	_, err = engine.Evaluate(ctx, r)
+	assert.EqualError(t, err, "failed evaluating rule [velocity] on [tx1]: failed getting the payments of [broken]: db unavailable")
+}
+
+func TestComplianceEngineAddRuleWhileEvaluating(t *testing.T) {
+	ctx := context.Background()
+	engine := NewComplianceEngine(&LargeTransferRule{RuleName: "large", Decision: Flagged, Threshold: 1000})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			engine.AddRule(&LargeTransferRule{RuleName: fmt.Sprintf("large%d", i), Decision: Flagged, Threshold: 1000})
+		}()
+		go func() {
+			defer wg.Done()
+			result, err := engine.Evaluate(ctx, request())
+			assert.NoError(t, err)
+			assert.Equal(t, Approved, result.Decision)
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, engine.Rules(), 11)
+}
+
+func TestComplianceRuleConfig(t *testing.T) {
+	rule, err := (&ComplianceRuleConfig{Kind: LargeTransferRuleKind, Threshold: 10}).rule(1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &LargeTransferRule{RuleName: "largeTransfer-1", Decision: Flagged, Threshold: 10}, rule)
+
+	rule, err = (&ComplianceRuleConfig{Name: "ofac", Kind: SanctionsRuleKind, Decision: "flag", EnrollmentIDs: []string{"mallory"}}).rule(0, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &SanctionsRule{RuleName: "ofac", Decision: Flagged, EnrollmentIDs: []string{"mallory"}}, rule)
+
+	_, err = (&ComplianceRuleConfig{Kind: VelocityRuleKind, Limit: 10}).rule(0, nil)
+	assert.EqualError(t, err, "velocity rule [velocity-0] requires a positive window")
+	_, err = (&ComplianceRuleConfig{Kind: SanctionsRuleKind, Decision: "approve"}).rule(0, nil)
+	assert.EqualError(t, err, "invalid decision [approve], expected flag or deny")
+	_, err = (&ComplianceRuleConfig{Kind: "weekly"}).rule(0, nil)
+	assert.EqualError(t, err, "unknown rule kind [weekly]")
+}
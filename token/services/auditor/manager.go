@@ -12,6 +12,7 @@ import (
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	tdriver "github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditdb"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/driver"
@@ -32,6 +33,11 @@ type TokenDBProvider interface {
 	Tokens(id token.TMSID) (*tokens.Tokens, error)
 }
 
+// PublicParamsDeserializer deserializes public parameters
+type PublicParamsDeserializer interface {
+	PublicParametersFromBytes(params []byte) (tdriver.PublicParameters, error)
+}
+
 type CheckServiceProvider interface {
 	CheckService(id token.TMSID, adb *auditdb.DB, tdb *tokens.Tokens) (CheckService, error)
 }
@@ -44,6 +50,7 @@ type Manager struct {
 	tmsProvider          TokenManagementServiceProvider
 	tracerProvider       trace.TracerProvider
 	checkServiceProvider CheckServiceProvider
+	ppDeserializer       PublicParamsDeserializer
 
 	mutex    sync.Mutex
 	auditors map[string]*Auditor
@@ -57,6 +64,7 @@ func NewManager(
 	tmsProvider TokenManagementServiceProvider,
 	tracerProvider trace.TracerProvider,
 	checkServiceProvider CheckServiceProvider,
+	ppDeserializer PublicParamsDeserializer,
 ) *Manager {
 	return &Manager{
 		networkProvider:      networkProvider,
@@ -66,6 +74,7 @@ func NewManager(
 		tracerProvider:       tracerProvider,
 		auditors:             map[string]*Auditor{},
 		checkServiceProvider: checkServiceProvider,
+		ppDeserializer:       ppDeserializer,
 	}
 }
 
@@ -130,7 +139,8 @@ func (cm *Manager) newAuditor(tmsID token.TMSID) (*Auditor, error) {
 			Namespace:  "tokensdk",
 			LabelNames: []tracing.LabelName{txIdLabel},
 		})),
-		checkService: checkService,
+		checkService:   checkService,
+		ppDeserializer: cm.ppDeserializer,
 	}
 	return auditor, nil
 }
@@ -0,0 +1,178 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package auditor
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditdb"
+	"github.com/pkg/errors"
+)
+
+// VerificationResult is the outcome of the re-verification of an audited token request
+type VerificationResult struct {
+	// TxID is the transaction the token request belongs to
+	TxID string
+	// Status is the status of the transaction
+	Status TxStatus
+	// PPHash is the hash of the public parameters the token request was assembled with
+	PPHash []byte
+	// Auditor is the auditor identity whose signature has been verified. It is nil if no signature has been verified.
+	Auditor token.Identity
+	// Err is the reason the verification failed, nil on success
+	Err error
+}
+
+// AddSignature stores the signature the passed auditor identity produced on the token request of the passed transaction.
+// Stored signatures can be verified again later with Reverify, also after the auditor has been rotated.
+func (a *Auditor) AddSignature(txID string, auditor token.Identity, sigma []byte) error {
+	return a.auditDB.AddAuditorSignature(txID, auditor, sigma)
+}
+
+// Reverify verifies again the auditor signatures of the token requests, stored in the auditor database,
+// whose transaction has one of the passed statuses. If no status is passed, all token requests are verified.
+// The auditor keys are taken from the public parameters each token request was assembled with,
+// looked up by the public parameters hash recorded with the request.
+// If these public parameters are not available, the keys in the auditor history of the current
+// public parameters that were valid when the transaction was recorded are tried.
+// Token requests committed without the auditor's signature, because auditing was asynchronous, are reported as valid.
+func (a *Auditor) Reverify(ctx context.Context, statuses ...TxStatus) ([]*VerificationResult, error) {
+	tms, err := a.tmsProvider.GetManagementService(token.WithTMSID(a.tmsID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting token management service [%s]", a.tmsID)
+	}
+	it, err := a.auditDB.TokenRequests(auditdb.QueryTokenRequestsParams{Statuses: statuses})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting token requests for [%s]", a.tmsID)
+	}
+	defer it.Close()
+
+	pps := map[string]driver.PublicParameters{}
+	var results []*VerificationResult
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		record, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting next token request for [%s]", a.tmsID)
+		}
+		if record == nil {
+			break
+		}
+		result := &VerificationResult{
+			TxID:   record.TxID,
+			Status: record.Status,
+			PPHash: record.PPHash,
+		}
+		result.Auditor, result.Err = a.reverify(tms, record.TxID, record.TokenRequest, record.PPHash, pps)
+		if result.Err != nil {
+			logger.Warnf("failed verifying auditor signature for [%s]: [%s]", record.TxID, result.Err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func (a *Auditor) reverify(tms *token.ManagementService, txID string, raw []byte, ppHash []byte, pps map[string]driver.PublicParameters) (token.Identity, error) {
+	request, err := tms.NewFullRequestFromBytes(raw)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed unmarshalling token request")
+	}
+	msg, err := request.MarshalToAudit()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed marshalling token request to audit")
+	}
+	signatures, err := a.auditDB.AuditorSignatures(txID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting auditor signatures")
+	}
+
+	pp, err := a.publicParamsByHash(ppHash, pps)
+	var auditors []token.Identity
+	if err != nil {
+		logger.Debugf("public parameters [%s] not available for [%s], use the auditor history: [%s]", token.Identity(ppHash), txID, err)
+		current := tms.PublicParametersManager().PublicParameters()
+		if current == nil {
+			return nil, errors.Errorf("public parameters not set for [%s]", tms.ID())
+		}
+		pp = current.PublicParameters
+		txTime, err := a.transactionTime(txID)
+		if err != nil {
+			return nil, err
+		}
+		auditors = pp.AuditorHistory().At(txTime)
+	} else {
+		auditors = pp.Auditors()
+	}
+	if len(signatures) == 0 {
+		if len(auditors) == 0 || pp.AsyncAuditing() {
+			return nil, nil
+		}
+		return nil, errors.New("no auditor signature found")
+	}
+
+	sigService := tms.SigService()
+	for _, auditor := range auditors {
+		sigma, ok := signatures[auditor.String()]
+		if !ok {
+			continue
+		}
+		verifier, err := sigService.AuditorVerifier(auditor)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting verifier for auditor [%s]", auditor)
+		}
+		if err := verifier.Verify(msg, sigma); err != nil {
+			return nil, errors.WithMessagef(err, "invalid signature from auditor [%s]", auditor)
+		}
+		return auditor, nil
+	}
+	return nil, errors.New("no signature from the auditors authorized by the public parameters")
+}
+
+// transactionTime returns the time the passed transaction has been recorded in the audit db
+func (a *Auditor) transactionTime(txID string) (time.Time, error) {
+	it, err := a.auditDB.Transactions(auditdb.QueryTransactionsParams{IDs: []string{txID}})
+	if err != nil {
+		return time.Time{}, errors.WithMessagef(err, "failed getting transaction records for [%s]", txID)
+	}
+	defer it.Close()
+	record, err := it.Next()
+	if err != nil {
+		return time.Time{}, errors.WithMessagef(err, "failed getting transaction record for [%s]", txID)
+	}
+	if record == nil {
+		return time.Time{}, errors.Errorf("no transaction record found for [%s], cannot select the auditor keys", txID)
+	}
+	return record.Timestamp, nil
+}
+
+// publicParamsByHash returns the public parameters stored in the token db with the passed hash
+func (a *Auditor) publicParamsByHash(ppHash []byte, cache map[string]driver.PublicParameters) (driver.PublicParameters, error) {
+	if len(ppHash) == 0 {
+		return nil, errors.New("no public parameters hash")
+	}
+	if pp, ok := cache[string(ppHash)]; ok {
+		return pp, nil
+	}
+	if a.ppDeserializer == nil {
+		return nil, errors.New("no public parameters deserializer available")
+	}
+	raw, err := a.tokenDB.PublicParamsByHash(ppHash)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed loading public parameters")
+	}
+	pp, err := a.ppDeserializer.PublicParametersFromBytes(raw)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed deserializing public parameters")
+	}
+	cache[string(ppHash)] = pp
+	return pp, nil
+}
@@ -12,9 +12,12 @@ import (
 	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
 	"go.opentelemetry.io/otel/trace"
 )
 
+var logger = logging.MustGetLogger("token-sdk.db.common")
+
 type StatusEvent struct {
 	Ctx               context.Context
 	TxID              string
@@ -24,6 +27,7 @@ type StatusEvent struct {
 
 type StatusSupport struct {
 	listeners      map[string][]chan StatusEvent
+	allListeners   []chan StatusEvent
 	mutex          sync.RWMutex
 	pollingTimeout time.Duration
 }
@@ -64,22 +68,55 @@ func (c *StatusSupport) DeleteStatusListener(txID string, ch chan StatusEvent) {
 	}
 }
 
+// AddAllStatusListener registers the passed channel to receive the status events of all the transactions.
+// The events are sent without blocking: if the channel is full, the event is dropped.
+// The channel must therefore be drained promptly.
+func (c *StatusSupport) AddAllStatusListener(ch chan StatusEvent) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.allListeners = append(c.allListeners, ch)
+}
+
+// DeleteAllStatusListener removes the passed channel registered with AddAllStatusListener
+func (c *StatusSupport) DeleteAllStatusListener(ch chan StatusEvent) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for i, l := range c.allListeners {
+		if l == ch {
+			c.allListeners = append(c.allListeners[:i], c.allListeners[i+1:]...)
+			return
+		}
+	}
+}
+
 func (c *StatusSupport) Notify(event StatusEvent) {
 	span := trace.SpanFromContext(event.Ctx)
 	span.AddEvent("start_notify")
 	defer span.AddEvent("end_notify")
 	c.mutex.RLock()
 	listeners := c.listeners[event.TxID]
-	if len(listeners) == 0 {
+	if len(listeners) == 0 && len(c.allListeners) == 0 {
 		c.mutex.RUnlock()
 		return
 	}
 	// clone listeners and release lock
-	clone := make([]chan StatusEvent, len(listeners))
-	copy(clone, listeners)
+	clone := make([]chan StatusEvent, 0, len(listeners))
+	clone = append(clone, listeners...)
+	allClone := make([]chan StatusEvent, 0, len(c.allListeners))
+	allClone = append(allClone, c.allListeners...)
 	c.mutex.RUnlock()
 
 	for _, listener := range clone {
 		listener <- event
 	}
+	// a slow listener of all the transactions must not block the update of the status
+	for _, listener := range allClone {
+		select {
+		case listener <- event:
+		default:
+			logger.Warnf("status listener full, dropping status [%d] of [%s]", event.ValidationCode, event.TxID)
+		}
+	}
 }
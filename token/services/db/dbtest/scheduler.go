@@ -0,0 +1,193 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package dbtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/test-go/testify/assert"
+)
+
+// SchedulerDBCases collects test functions that db driver implementations can use for integration tests
+var SchedulerDBCases = []struct {
+	Name string
+	Fn   func(*testing.T, driver.SchedulerDB)
+}{
+	{"TestScheduledTransfers", TestScheduledTransfers},
+	{"TestScheduledTransferClaims", TestScheduledTransferClaims},
+	{"TestScheduledTransferRuns", TestScheduledTransferRuns},
+}
+
+func newScheduledTransfer(id, wallet string, nextRun time.Time) *driver.ScheduledTransfer {
+	return &driver.ScheduledTransfer{
+		ID:         id,
+		Wallet:     wallet,
+		TokenType:  "USD",
+		Amount:     100,
+		Recipient:  []byte("bob"),
+		Start:      nextRun,
+		Unit:       driver.Month,
+		Every:      1,
+		Status:     driver.ScheduleActive,
+		Occurrence: nextRun,
+		NextRun:    nextRun,
+	}
+}
+
+func TestScheduledTransfers(t *testing.T, db driver.SchedulerDB) {
+	now := time.Now().UTC().Truncate(time.Second)
+	end := now.AddDate(1, 0, 0)
+
+	rent := newScheduledTransfer("rent", "alice", now.Add(-time.Minute))
+	rent.End = end
+	assert.NoError(t, db.AddScheduledTransfer(rent))
+	assert.Error(t, db.AddScheduledTransfer(rent), "ids must be unique")
+	assert.NoError(t, db.AddScheduledTransfer(newScheduledTransfer("salary", "charlie", now.Add(time.Hour))))
+	assert.NoError(t, db.AddScheduledTransfer(newScheduledTransfer("gift", "alice", now.Add(-time.Hour))))
+
+	// get
+	st, err := db.GetScheduledTransfer("rent")
+	assert.NoError(t, err)
+	assert.NotNil(t, st)
+	assert.Equal(t, "alice", st.Wallet)
+	assert.Equal(t, "USD", st.TokenType)
+	assert.Equal(t, uint64(100), st.Amount)
+	assert.Equal(t, []byte("bob"), []byte(st.Recipient))
+	assert.Equal(t, driver.Month, st.Unit)
+	assert.Equal(t, 1, st.Every)
+	assert.True(t, end.Equal(st.End))
+	assert.True(t, now.Add(-time.Minute).Equal(st.NextRun))
+	assert.False(t, st.CreatedAt.IsZero())
+	st, err = db.GetScheduledTransfer("gift")
+	assert.NoError(t, err)
+	assert.True(t, st.End.IsZero())
+	st, err = db.GetScheduledTransfer("unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+
+	// due, ordered by next run
+	due, err := db.DueScheduledTransfers(now, 0)
+	assert.NoError(t, err)
+	assert.Len(t, due, 2)
+	assert.Equal(t, "gift", due[0].ID)
+	assert.Equal(t, "rent", due[1].ID)
+	due, err = db.DueScheduledTransfers(now, 1)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	// cancel
+	ok, err := db.CancelScheduledTransfer("gift")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.CancelScheduledTransfer("gift")
+	assert.NoError(t, err)
+	assert.False(t, ok, "only active transfers can be cancelled")
+	ok, err = db.CancelScheduledTransfer("unknown")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	due, err = db.DueScheduledTransfers(now, 0)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	assert.Equal(t, "rent", due[0].ID)
+
+	// query
+	all, err := db.QueryScheduledTransfers(driver.QueryScheduledTransfersParams{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	alice, err := db.QueryScheduledTransfers(driver.QueryScheduledTransfersParams{Wallet: "alice"})
+	assert.NoError(t, err)
+	assert.Len(t, alice, 2)
+	active, err := db.QueryScheduledTransfers(driver.QueryScheduledTransfersParams{Wallet: "alice", Statuses: []driver.ScheduledTransferStatus{driver.ScheduleActive}})
+	assert.NoError(t, err)
+	assert.Len(t, active, 1)
+	assert.Equal(t, "rent", active[0].ID)
+	closed, err := db.QueryScheduledTransfers(driver.QueryScheduledTransfersParams{Statuses: []driver.ScheduledTransferStatus{driver.ScheduleCancelled, driver.ScheduleCompleted}})
+	assert.NoError(t, err)
+	assert.Len(t, closed, 1)
+	assert.Equal(t, "gift", closed[0].ID)
+}
+
+func TestScheduledTransferClaims(t *testing.T, db driver.SchedulerDB) {
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, db.AddScheduledTransfer(newScheduledTransfer("rent", "alice", now)))
+
+	// only one claim of the same run succeeds
+	st, err := db.GetScheduledTransfer("rent")
+	assert.NoError(t, err)
+	ok, err := db.ClaimScheduledTransfer(st.ID, st.NextRun, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.ClaimScheduledTransfer(st.ID, st.NextRun, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	due, err := db.DueScheduledTransfers(now, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+
+	// reschedule
+	next := now.AddDate(0, 1, 0)
+	ok, err = db.UpdateScheduledTransfer(st.ID, driver.ScheduleActive, next, next.Add(time.Minute), 2)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	st, err = db.GetScheduledTransfer("rent")
+	assert.NoError(t, err)
+	assert.True(t, next.Equal(st.Occurrence))
+	assert.True(t, next.Add(time.Minute).Equal(st.NextRun))
+	assert.Equal(t, 2, st.Attempts)
+
+	// complete, then no further update is possible
+	ok, err = db.UpdateScheduledTransfer(st.ID, driver.ScheduleCompleted, next, next, 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.UpdateScheduledTransfer(st.ID, driver.ScheduleActive, next, next, 0)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = db.ClaimScheduledTransfer(st.ID, next, now.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	st, err = db.GetScheduledTransfer("rent")
+	assert.NoError(t, err)
+	assert.Equal(t, driver.ScheduleCompleted, st.Status)
+}
+
+func TestScheduledTransferRuns(t *testing.T, db driver.SchedulerDB) {
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, db.AddScheduledTransfer(newScheduledTransfer("rent", "alice", now)))
+
+	assert.NoError(t, db.AddScheduledTransferRun(&driver.ScheduledTransferRun{
+		ScheduleID: "rent",
+		Occurrence: now,
+		Attempt:    1,
+		Message:    "insufficient funds",
+		ExecutedAt: now.Add(time.Second),
+	}))
+	assert.NoError(t, db.AddScheduledTransferRun(&driver.ScheduledTransferRun{
+		ScheduleID: "rent",
+		Occurrence: now,
+		Attempt:    2,
+		TxID:       "tx1",
+		Succeeded:  true,
+		ExecutedAt: now.Add(time.Minute),
+	}))
+
+	runs, err := db.GetScheduledTransferRuns("rent")
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, 1, runs[0].Attempt)
+	assert.False(t, runs[0].Succeeded)
+	assert.Equal(t, "insufficient funds", runs[0].Message)
+	assert.Equal(t, 2, runs[1].Attempt)
+	assert.True(t, runs[1].Succeeded)
+	assert.Equal(t, "tx1", runs[1].TxID)
+	assert.True(t, now.Equal(runs[1].Occurrence))
+
+	runs, err = db.GetScheduledTransferRuns("unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, runs)
+}
@@ -37,6 +37,12 @@ var TokenTransactionDBCases = []struct {
 	{"TransactionQueries", TTransactionQueries},
 	{"ValidationRecordQueries", TValidationRecordQueries},
 	{"TEndorserAcks", TEndorserAcks},
+	{"TransactionSteps", TTransactionSteps},
+	{"ApplicationMetadataQueries", TApplicationMetadataQueries},
+	{"TransactionAttachments", TTransactionAttachments},
+	{"DistributionResults", TDistributionResults},
+	{"IdempotencyKeys", TIdempotencyKeys},
+	{"WithdrawalRequests", TWithdrawalRequests},
 }
 
 func TFailsIfRequestDoesNotExist(t *testing.T, db driver.TokenTransactionDB) {
@@ -197,6 +203,19 @@ func TMovements(t *testing.T, db driver.TokenTransactionDB) {
 	})
 	assert.NoError(t, err)
 	assert.Len(t, records, 3)
+	for _, r := range records {
+		assert.False(t, r.Timestamp.IsZero())
+	}
+
+	// From
+	from := records[2].Timestamp.Add(-time.Second)
+	records, err = db.QueryMovements(driver.QueryMovementsParams{MovementDirection: driver.All, From: &from})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	from = time.Now().Add(time.Hour)
+	records, err = db.QueryMovements(driver.QueryMovementsParams{MovementDirection: driver.All, From: &from})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
 
 	// Received
 	records, err = db.QueryMovements(driver.QueryMovementsParams{
@@ -374,7 +393,7 @@ func TTokenRequest(t *testing.T, db driver.TokenTransactionDB) {
 	err = w.AddTokenRequest("id1", tr1, map[string][]byte{}, []byte("tr"))
 	assert.NoError(t, err)
 	tr2 := []byte("arbitrary bytes 2")
-	err = w.AddTokenRequest("id2", tr2, map[string][]byte{}, []byte("tr"))
+	err = w.AddTokenRequest("id2", tr2, map[string][]byte{}, []byte("tr2"))
 	assert.NoError(t, err)
 	assert.NoError(t, w.Commit())
 	assert.NoError(t, db.SetStatus(context.TODO(), "id2", driver.Confirmed, ""))
@@ -445,6 +464,7 @@ func TTokenRequest(t *testing.T, db driver.TokenTransactionDB) {
 	assert.NoError(t, err)
 	assert.Equal(t, tr2, record.TokenRequest)
 	assert.Equal(t, driver.Confirmed, record.Status)
+	assert.Equal(t, []byte("tr2"), record.PPHash)
 	record, err = it.Next()
 	assert.NoError(t, err)
 	assert.Nil(t, record)
@@ -457,6 +477,7 @@ func TTokenRequest(t *testing.T, db driver.TokenTransactionDB) {
 	assert.NoError(t, err)
 	assert.Equal(t, tr1, record.TokenRequest)
 	assert.Equal(t, driver.Pending, record.Status)
+	assert.Equal(t, []byte("tr"), record.PPHash)
 	record, err = it.Next()
 	assert.NoError(t, err)
 	assert.Nil(t, record)
@@ -790,6 +811,43 @@ func getTransactions(t *testing.T, db driver.TokenTransactionDB, params driver.Q
 	}
 }
 
+func TApplicationMetadataQueries(t *testing.T, db driver.TokenTransactionDB) {
+	metadata := map[string]map[string][]byte{
+		"tx1": {"invoice": []byte("inv1"), "note": []byte("rent")},
+		"tx2": {"invoice": []byte("inv2")},
+		"tx3": {},
+	}
+	w, err := db.BeginAtomicWrite()
+	assert.NoError(t, err)
+	for _, txID := range []string{"tx1", "tx2", "tx3"} {
+		assert.NoError(t, w.AddTokenRequest(txID, []byte{}, metadata[txID], driver2.PPHash("tr")))
+		assert.NoError(t, w.AddTransaction(&driver.TransactionRecord{
+			TxID:         txID,
+			ActionType:   driver.Transfer,
+			SenderEID:    "alice",
+			RecipientEID: "bob",
+			TokenType:    "USD",
+			Amount:       big.NewInt(10),
+			Timestamp:    time.Now(),
+		}))
+	}
+	assert.NoError(t, w.Commit())
+
+	txs := getTransactions(t, db, driver.QueryTransactionsParams{ApplicationMetadata: map[string][]byte{"invoice": []byte("inv1")}})
+	assert.Len(t, txs, 1)
+	assert.Equal(t, "tx1", txs[0].TxID)
+	assert.Equal(t, []byte("rent"), txs[0].ApplicationMetadata["note"])
+	txs = getTransactions(t, db, driver.QueryTransactionsParams{ApplicationMetadata: map[string][]byte{"invoice": []byte("inv1"), "note": []byte("other")}})
+	assert.Empty(t, txs)
+	txs = getTransactions(t, db, driver.QueryTransactionsParams{ApplicationMetadata: map[string][]byte{"invoice": []byte("inv3")}})
+	assert.Empty(t, txs)
+	txs = getTransactions(t, db, driver.QueryTransactionsParams{IDs: []string{"tx2", "tx3"}, ApplicationMetadata: map[string][]byte{"invoice": []byte("inv2")}})
+	assert.Len(t, txs, 1)
+	assert.Equal(t, "tx2", txs[0].TxID)
+	txs = getTransactions(t, db, driver.QueryTransactionsParams{})
+	assert.Len(t, txs, 3)
+}
+
 func TValidationRecordQueries(t *testing.T, db driver.TokenTransactionDB) {
 	beforeTx := time.Now().UTC().Add(-1 * time.Second)
 	exp := []driver.ValidationRecord{
@@ -909,6 +967,164 @@ func TEndorserAcks(t *testing.T, db driver.TokenTransactionDB) {
 	}
 }
 
+func TDistributionResults(t *testing.T, db driver.TokenTransactionDB) {
+	records, err := db.GetTransactionDistributionResults("tx1")
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, db.AddTransactionDistributionResult(&driver.DistributionResultRecord{TxID: "tx1", Party: []byte("alice"), Acknowledged: true, Attempts: 1, Timestamp: now}))
+	assert.NoError(t, db.AddTransactionDistributionResult(&driver.DistributionResultRecord{TxID: "tx1", Party: []byte("bob"), Attempts: 3, Error: "timeout", Timestamp: now.Add(time.Second)}))
+	assert.NoError(t, db.AddTransactionDistributionResult(&driver.DistributionResultRecord{TxID: "tx2", Party: []byte("charlie"), Acknowledged: true, Attempts: 2, Timestamp: now}))
+
+	records, err = db.GetTransactionDistributionResults("tx1")
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "tx1", records[0].TxID)
+	assert.Equal(t, token.Identity("alice"), records[0].Party)
+	assert.True(t, records[0].Acknowledged)
+	assert.Equal(t, 1, records[0].Attempts)
+	assert.Empty(t, records[0].Error)
+	assert.True(t, now.Equal(records[0].Timestamp.UTC()), "expected [%s], got [%s]", now, records[0].Timestamp)
+	assert.Equal(t, token.Identity("bob"), records[1].Party)
+	assert.False(t, records[1].Acknowledged)
+	assert.Equal(t, 3, records[1].Attempts)
+	assert.Equal(t, "timeout", records[1].Error)
+
+	records, err = db.GetTransactionDistributionResults("tx2")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, token.Identity("charlie"), records[0].Party)
+}
+
+func TIdempotencyKeys(t *testing.T, db driver.TokenTransactionDB) {
+	record, err := db.GetIdempotencyKey("key1")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	record, err = db.AddIdempotencyKey(&driver.IdempotencyKeyRecord{Key: "key1", TxID: "tx1", Nonce: []byte("nonce1"), Creator: []byte("alice")})
+	assert.NoError(t, err)
+	assert.Equal(t, "tx1", record.TxID)
+
+	// the first binding wins
+	record, err = db.AddIdempotencyKey(&driver.IdempotencyKeyRecord{Key: "key1", TxID: "tx2", Nonce: []byte("nonce2"), Creator: []byte("alice")})
+	assert.NoError(t, err)
+	assert.Equal(t, "tx1", record.TxID)
+	assert.Equal(t, []byte("nonce1"), record.Nonce)
+
+	record, err = db.GetIdempotencyKey("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "key1", record.Key)
+	assert.Equal(t, "tx1", record.TxID)
+	assert.Equal(t, []byte("nonce1"), record.Nonce)
+	assert.Equal(t, []byte("alice"), record.Creator)
+	assert.False(t, record.Timestamp.IsZero())
+
+	record, err = db.AddIdempotencyKey(&driver.IdempotencyKeyRecord{Key: "key2", TxID: "tx2", Nonce: []byte("nonce2"), Creator: []byte("bob")})
+	assert.NoError(t, err)
+	assert.Equal(t, "tx2", record.TxID)
+	record, err = db.GetIdempotencyKey("key2")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("bob"), record.Creator)
+}
+
+func TTransactionAttachments(t *testing.T, db driver.TokenTransactionDB) {
+	attachments, err := db.GetTransactionAttachments("tx1")
+	assert.NoError(t, err)
+	assert.Empty(t, attachments)
+
+	assert.NoError(t, db.AddTransactionAttachment("tx1", "travel_rule", []byte("payload1")))
+	assert.NoError(t, db.AddTransactionAttachment("tx1", "invoice", []byte("payload2")))
+	assert.NoError(t, db.AddTransactionAttachment("tx2", "travel_rule", []byte("payload3")))
+
+	attachments, err = db.GetTransactionAttachments("tx1")
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 2)
+	assert.Equal(t, []byte("payload1"), attachments["travel_rule"])
+	assert.Equal(t, []byte("payload2"), attachments["invoice"])
+
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, db.AddTransactionAttachment("tx1", "travel_rule", []byte("payload4")))
+	attachments, err = db.GetTransactionAttachments("tx1")
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 2)
+	assert.Equal(t, []byte("payload4"), attachments["travel_rule"])
+
+	attachments, err = db.GetTransactionAttachments("tx2")
+	assert.NoError(t, err)
+	assert.Len(t, attachments, 1)
+	assert.Equal(t, []byte("payload3"), attachments["travel_rule"])
+}
+
+func TTransactionSteps(t *testing.T, db driver.TokenTransactionDB) {
+	records, err := db.QueryInFlightTransactions()
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	add := func(txID string, step driver.TransactionStep, payload []byte, offset time.Duration) {
+		assert.NoError(t, db.AddTransactionStep(&driver.TransactionStepRecord{TxID: txID, Step: step, Payload: payload, Timestamp: now.Add(offset)}))
+	}
+	// tx1 stops after the signatures have been collected
+	add("tx1", driver.Assembled, []byte("tx1"), 0)
+	add("tx1", driver.SignaturesCollected, nil, time.Second)
+	// tx2 has been broadcast, its request is still pending
+	add("tx2", driver.Assembled, []byte("tx2"), 0)
+	add("tx2", driver.Approved, []byte("tx2-approved"), time.Second)
+	add("tx2", driver.Broadcast, nil, 2*time.Second)
+	createTestTransaction(t, db, "tx2")
+	// tx3 has been broadcast and its request confirmed
+	add("tx3", driver.Assembled, []byte("tx3"), 0)
+	add("tx3", driver.Broadcast, nil, time.Second)
+	createTestTransaction(t, db, "tx3")
+	assert.NoError(t, db.SetStatus(context.TODO(), "tx3", driver.Confirmed, ""))
+	// tx4 is final, tx5 aborted
+	add("tx4", driver.Assembled, []byte("tx4"), 0)
+	add("tx4", driver.Final, nil, time.Second)
+	add("tx5", driver.Assembled, []byte("tx5"), 0)
+	assert.NoError(t, db.AddTransactionStep(&driver.TransactionStepRecord{TxID: "tx5", Step: driver.Aborted, Message: "no funds"}))
+	// tx6 has been received from another node
+	add("tx6", driver.Distributed, []byte("alice"), 0)
+	// tx7 has been received from another node and then canceled
+	add("tx7", driver.Distributed, []byte("alice"), 0)
+	add("tx7", driver.Aborted, nil, time.Second)
+
+	steps, err := db.GetTransactionSteps("tx2")
+	assert.NoError(t, err)
+	assert.Len(t, steps, 3)
+	assert.Equal(t, driver.Assembled, steps[0].Step)
+	assert.Equal(t, []byte("tx2"), steps[0].Payload)
+	assert.Equal(t, driver.Approved, steps[1].Step)
+	assert.Equal(t, []byte("tx2-approved"), steps[1].Payload)
+	assert.Equal(t, driver.Broadcast, steps[2].Step)
+	assert.Empty(t, steps[2].Payload)
+	assert.True(t, now.Add(2*time.Second).Equal(steps[2].Timestamp.UTC()), "expected [%s], got [%s]", now.Add(2*time.Second), steps[2].Timestamp)
+
+	steps, err = db.GetTransactionSteps("tx5")
+	assert.NoError(t, err)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, "no funds", steps[1].Message)
+
+	// the terminal steps follow the distributed one
+	steps, err = db.GetTransactionSteps("tx7")
+	assert.NoError(t, err)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, driver.Distributed, steps[0].Step)
+	assert.Equal(t, driver.Aborted, steps[1].Step)
+
+	steps, err = db.GetTransactionSteps("unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, steps)
+
+	records, err = db.QueryInFlightTransactions()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "tx1", records[0].TxID)
+	assert.Equal(t, driver.SignaturesCollected, records[0].Step)
+	assert.Equal(t, "tx2", records[1].TxID)
+	assert.Equal(t, driver.Broadcast, records[1].Step)
+}
+
 func createTestTransaction(t *testing.T, db driver.TokenTransactionDB, txID string) {
 	w, err := db.BeginAtomicWrite()
 	if err != nil {
@@ -934,3 +1150,195 @@ func createTestTransaction(t *testing.T, db driver.TokenTransactionDB, txID stri
 		t.Fatalf("error committing transaction while trying to test something else: %s", err)
 	}
 }
+
+// AuditTransactionDBCases collects test functions that audit db driver implementations can use for integration tests
+var AuditTransactionDBCases = []struct {
+	Name string
+	Fn   func(*testing.T, driver.AuditTransactionDB)
+}{
+	{"Discrepancies", TDiscrepancies},
+	{"ComplianceDecisions", TComplianceDecisions},
+	{"AuditorSignatures", TAuditorSignatures},
+}
+
+func TDiscrepancies(t *testing.T, db driver.AuditTransactionDB) {
+	records, err := db.QueryDiscrepancies(driver.QueryDiscrepanciesParams{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, db.AddDiscrepancy(&driver.DiscrepancyRecord{TxID: "tx1", Reason: "token request not submitted to the auditor", Timestamp: now.Add(-time.Hour)}))
+	assert.NoError(t, db.AddDiscrepancy(&driver.DiscrepancyRecord{TxID: "tx2", Reason: "token requests do not match", Timestamp: now}))
+	assert.NoError(t, db.AddDiscrepancy(&driver.DiscrepancyRecord{TxID: "tx2", Reason: "audit check failed", Timestamp: now.Add(time.Hour)}))
+
+	records, err = db.QueryDiscrepancies(driver.QueryDiscrepanciesParams{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "tx1", records[0].TxID)
+	assert.Equal(t, "token request not submitted to the auditor", records[0].Reason)
+	assert.True(t, now.Add(-time.Hour).Equal(records[0].Timestamp.UTC()), "expected [%s], got [%s]", now.Add(-time.Hour), records[0].Timestamp)
+
+	records, err = db.QueryDiscrepancies(driver.QueryDiscrepanciesParams{IDs: []string{"tx2"}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "token requests do not match", records[0].Reason)
+	assert.Equal(t, "audit check failed", records[1].Reason)
+
+	from := now.Add(-time.Minute)
+	to := now.Add(time.Minute)
+	records, err = db.QueryDiscrepancies(driver.QueryDiscrepanciesParams{From: &from, To: &to})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "tx2", records[0].TxID)
+}
+
+func TComplianceDecisions(t *testing.T, db driver.AuditTransactionDB) {
+	records, err := db.QueryComplianceDecisions(driver.QueryComplianceDecisionsParams{})
+	assert.NoError(t, err)
+	assert.Empty(t, records)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, db.AddComplianceDecision(&driver.ComplianceDecisionRecord{TxID: "tx1", Decision: driver.ComplianceApproved, Timestamp: now.Add(-time.Hour)}))
+	assert.NoError(t, db.AddComplianceDecision(&driver.ComplianceDecisionRecord{TxID: "tx2", Decision: driver.ComplianceFlagged, Reason: "large transfer", Timestamp: now}))
+	assert.NoError(t, db.AddComplianceDecision(&driver.ComplianceDecisionRecord{TxID: "tx3", Decision: driver.ComplianceDenied, Reason: "sanctioned party", Timestamp: now.Add(time.Hour)}))
+
+	records, err = db.QueryComplianceDecisions(driver.QueryComplianceDecisionsParams{})
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "tx1", records[0].TxID)
+	assert.Equal(t, driver.ComplianceApproved, records[0].Decision)
+	assert.Empty(t, records[0].Reason)
+	assert.True(t, now.Add(-time.Hour).Equal(records[0].Timestamp.UTC()), "expected [%s], got [%s]", now.Add(-time.Hour), records[0].Timestamp)
+
+	records, err = db.QueryComplianceDecisions(driver.QueryComplianceDecisionsParams{IDs: []string{"tx3"}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, driver.ComplianceDenied, records[0].Decision)
+	assert.Equal(t, "sanctioned party", records[0].Reason)
+
+	records, err = db.QueryComplianceDecisions(driver.QueryComplianceDecisionsParams{Decisions: []driver.ComplianceDecision{driver.ComplianceFlagged, driver.ComplianceDenied}})
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "tx2", records[0].TxID)
+	assert.Equal(t, "tx3", records[1].TxID)
+
+	from := now.Add(-time.Minute)
+	to := now.Add(time.Minute)
+	records, err = db.QueryComplianceDecisions(driver.QueryComplianceDecisionsParams{From: &from, To: &to})
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "tx2", records[0].TxID)
+}
+
+func TAuditorSignatures(t *testing.T, db driver.AuditTransactionDB) {
+	w, err := db.BeginAtomicWrite()
+	assert.NoError(t, err)
+	assert.NoError(t, w.AddTokenRequest("tx1", []byte("request"), map[string][]byte{}, []byte("pp hash")))
+	assert.NoError(t, w.Commit())
+
+	assert.NoError(t, db.AddTransactionEndorsementAck("tx1", token.Identity("auditor"), []byte("sigma")))
+	acks, err := db.GetTransactionEndorsementAcks("tx1")
+	assert.NoError(t, err)
+	assert.Len(t, acks, 1)
+	assert.Equal(t, []byte("sigma"), acks[token.Identity("auditor").String()])
+
+	it, err := db.QueryTokenRequests(driver.QueryTokenRequestsParams{})
+	assert.NoError(t, err)
+	defer it.Close()
+	record, err := it.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "tx1", record.TxID)
+	assert.Equal(t, []byte("pp hash"), record.PPHash)
+}
+
+func TWithdrawalRequests(t *testing.T, db driver.TokenTransactionDB) {
+	record, err := db.GetWithdrawalRequest("req1")
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	assert.NoError(t, db.AddWithdrawalRequest(&driver.WithdrawalRequestRecord{
+		ID:        "req1",
+		Requester: []byte("alice"),
+		TokenType: "USD",
+		Amount:    10,
+		Request:   []byte("request1"),
+		Status:    driver.WithdrawalPending,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Hour),
+	}))
+	assert.NoError(t, db.AddWithdrawalRequest(&driver.WithdrawalRequestRecord{
+		ID:        "req2",
+		Requester: []byte("bob"),
+		TokenType: "EUR",
+		Amount:    20,
+		Request:   []byte("request2"),
+		Status:    driver.WithdrawalPending,
+		CreatedAt: now.Add(time.Second),
+	}))
+	// ids are unique
+	assert.Error(t, db.AddWithdrawalRequest(&driver.WithdrawalRequestRecord{ID: "req1", Status: driver.WithdrawalPending}))
+
+	record, err = db.GetWithdrawalRequest("req1")
+	assert.NoError(t, err)
+	assert.Equal(t, "req1", record.ID)
+	assert.Equal(t, []byte("alice"), []byte(record.Requester))
+	assert.Equal(t, "USD", record.TokenType)
+	assert.Equal(t, uint64(10), record.Amount)
+	assert.Equal(t, []byte("request1"), record.Request)
+	assert.Equal(t, driver.WithdrawalPending, record.Status)
+	assert.True(t, now.Equal(record.CreatedAt))
+	assert.True(t, now.Add(time.Hour).Equal(record.ExpiresAt))
+	record, err = db.GetWithdrawalRequest("req2")
+	assert.NoError(t, err)
+	assert.True(t, record.ExpiresAt.IsZero())
+
+	// compare and set
+	ok, err := db.UpdateWithdrawalRequestStatus("req1", driver.WithdrawalApproved, driver.WithdrawalIssued, "", "tx1")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = db.UpdateWithdrawalRequestStatus("req1", driver.WithdrawalPending, driver.WithdrawalApproved, "", "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.UpdateWithdrawalRequestStatus("req1", driver.WithdrawalApproved, driver.WithdrawalIssued, "", "tx1")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.UpdateWithdrawalRequestStatus("req2", driver.WithdrawalPending, driver.WithdrawalRejected, "no deposit", "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = db.UpdateWithdrawalRequestStatus("req3", driver.WithdrawalPending, driver.WithdrawalRejected, "", "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	record, err = db.GetWithdrawalRequest("req1")
+	assert.NoError(t, err)
+	assert.Equal(t, driver.WithdrawalIssued, record.Status)
+	assert.Equal(t, "tx1", record.TxID)
+	record, err = db.GetWithdrawalRequest("req2")
+	assert.NoError(t, err)
+	assert.Equal(t, driver.WithdrawalRejected, record.Status)
+	assert.Equal(t, "no deposit", record.Message)
+
+	records, err := db.QueryWithdrawalRequests()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "req1", records[0].ID)
+	assert.Equal(t, "req2", records[1].ID)
+	records, err = db.QueryWithdrawalRequests(driver.WithdrawalRejected, driver.WithdrawalExpired)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "req2", records[0].ID)
+	records, err = db.QueryWithdrawalRequests(driver.WithdrawalPending)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+
+	approvers, err := db.GetWithdrawalApprovals("req1")
+	assert.NoError(t, err)
+	assert.Len(t, approvers, 0)
+	assert.NoError(t, db.AddWithdrawalApproval("req1", "alice"))
+	assert.NoError(t, db.AddWithdrawalApproval("req1", "bob"))
+	assert.NoError(t, db.AddWithdrawalApproval("req1", "alice"))
+	approvers, err = db.GetWithdrawalApprovals("req1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, approvers)
+}
@@ -8,6 +8,7 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 )
@@ -43,6 +44,92 @@ type AuditTransactionDB interface {
 	// GetTokenRequest returns the token request bound to the passed transaction id, if available.
 	// It returns nil without error if the key is not found.
 	GetTokenRequest(txID string) ([]byte, error)
+
+	// AddDiscrepancy records a discrepancy found while inspecting a committed transaction
+	AddDiscrepancy(record *DiscrepancyRecord) error
+
+	// QueryDiscrepancies returns the discrepancies matching the passed params
+	QueryDiscrepancies(params QueryDiscrepanciesParams) ([]*DiscrepancyRecord, error)
+
+	// AddComplianceDecision records the decision of the compliance rules on an audited transaction
+	AddComplianceDecision(record *ComplianceDecisionRecord) error
+
+	// QueryComplianceDecisions returns the compliance decisions matching the passed params
+	QueryComplianceDecisions(params QueryComplianceDecisionsParams) ([]*ComplianceDecisionRecord, error)
+
+	// TransactionEndorsementAckDB stores the signatures the auditor produced on the audited token requests
+	TransactionEndorsementAckDB
+
+	// TransactionAttachmentDB stores the off-ledger attachments of the audited transactions
+	TransactionAttachmentDB
+}
+
+// DiscrepancyRecord describes an inconsistency the auditor found on a transaction
+// that has been committed without being audited synchronously.
+type DiscrepancyRecord struct {
+	// TxID is the transaction the discrepancy refers to
+	TxID string
+	// Reason describes the discrepancy
+	Reason string
+	// Timestamp is the time the discrepancy has been recorded
+	Timestamp time.Time
+}
+
+// QueryDiscrepanciesParams defines the parameters for querying discrepancies
+type QueryDiscrepanciesParams struct {
+	// IDs is the list of transaction ids. If nil or empty, all discrepancies are returned
+	IDs []string
+	// From is the start time of the query
+	// If nil, the query starts from the first discrepancy
+	From *time.Time
+	// To is the end time of the query
+	// If nil, the query ends at the last discrepancy
+	To *time.Time
+}
+
+// ComplianceDecision is the outcome of the evaluation of the compliance rules on a transaction
+type ComplianceDecision int
+
+const (
+	// ComplianceApproved is the decision on a transaction that violates no rule
+	ComplianceApproved ComplianceDecision = iota
+	// ComplianceFlagged is the decision on a transaction the auditor approves but that needs a review
+	ComplianceFlagged
+	// ComplianceDenied is the decision on a transaction the auditor refuses to sign
+	ComplianceDenied
+)
+
+// ComplianceDecisionMessage maps ComplianceDecision to string
+var ComplianceDecisionMessage = map[ComplianceDecision]string{
+	ComplianceApproved: "Approved",
+	ComplianceFlagged:  "Flagged",
+	ComplianceDenied:   "Denied",
+}
+
+// ComplianceDecisionRecord records the decision of the compliance rules on an audited transaction
+type ComplianceDecisionRecord struct {
+	// TxID is the transaction the decision refers to
+	TxID string
+	// Decision is the outcome of the evaluation
+	Decision ComplianceDecision
+	// Reason describes the violated rules, empty if the transaction has been approved
+	Reason string
+	// Timestamp is the time the decision has been recorded
+	Timestamp time.Time
+}
+
+// QueryComplianceDecisionsParams defines the parameters for querying compliance decisions
+type QueryComplianceDecisionsParams struct {
+	// IDs is the list of transaction ids. If nil or empty, the decisions on all transactions are returned
+	IDs []string
+	// Decisions is the list of decisions to return. If nil or empty, all decisions are returned
+	Decisions []ComplianceDecision
+	// From is the start time of the query
+	// If nil, the query starts from the first decision
+	From *time.Time
+	// To is the end time of the query
+	// If nil, the query ends at the last decision
+	To *time.Time
 }
 
 // AuditDBDriver is the interface for an audit database driver
@@ -164,6 +164,8 @@ type TokenRequestRecord struct {
 	TokenRequest []byte
 	// Status is the status of the transaction
 	Status TxStatus
+	// PPHash is the hash of the public parameters the token request was assembled with
+	PPHash []byte
 }
 
 // TransactionIterator is an iterator for transactions
@@ -176,7 +178,7 @@ type ValidationRecordsIterator = collections.Iterator[*ValidationRecord]
 type TokenRequestIterator = collections.Iterator[*TokenRequestRecord]
 
 // QueryMovementsParams defines the parameters for querying movements.
-// Movement records will be filtered by EnrollmentID, TokenType, and Status.
+// Movement records will be filtered by EnrollmentID, TokenType, Status, and time.
 // SearchDirection tells if the search should start from the oldest to the newest records or vice versa.
 // MovementDirection which amounts to consider. Sent correspond to a negative amount,
 // Received to a positive amount, and All to both.
@@ -194,6 +196,8 @@ type QueryMovementsParams struct {
 	// NumRecords is the number of records to return
 	// If 0, all records are returned
 	NumRecords int
+	// From, if not nil, filters out the movements stored before this time
+	From *time.Time
 }
 
 // QueryTransactionsParams defines the parameters for querying transactions.
@@ -224,6 +228,9 @@ type QueryTransactionsParams struct {
 	// Statuses is the list of transaction status to accept
 	// If empty, any status is accepted
 	Statuses []TxStatus
+	// ApplicationMetadata is a set of key-value pairs the application metadata of a transaction must contain
+	// If empty, any application metadata is accepted
+	ApplicationMetadata map[string][]byte
 }
 
 // QueryValidationRecordsParams defines the parameters for querying validation records.
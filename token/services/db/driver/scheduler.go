@@ -0,0 +1,149 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package driver
+
+import (
+	"time"
+
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// ScheduledTransferStatus is the status of a scheduled transfer
+type ScheduledTransferStatus int
+
+const (
+	// ScheduleUnknown is the status of a scheduled transfer that does not exist
+	ScheduleUnknown ScheduledTransferStatus = iota
+	// ScheduleActive is the status of a scheduled transfer that will fire at its next run
+	ScheduleActive
+	// ScheduleCompleted is the status of a scheduled transfer whose occurrences have all fired
+	ScheduleCompleted
+	// ScheduleCancelled is the status of a scheduled transfer that has been cancelled
+	ScheduleCancelled
+	// ScheduleFailed is the status of a one-off scheduled transfer that exhausted its retries
+	ScheduleFailed
+)
+
+// ScheduledTransferStatusMessage maps ScheduledTransferStatus to string
+var ScheduledTransferStatusMessage = map[ScheduledTransferStatus]string{
+	ScheduleUnknown:   "Unknown",
+	ScheduleActive:    "Active",
+	ScheduleCompleted: "Completed",
+	ScheduleCancelled: "Cancelled",
+	ScheduleFailed:    "Failed",
+}
+
+// ScheduleUnit is the unit of the period of a recurring transfer
+type ScheduleUnit string
+
+const (
+	// Once is the unit of a transfer that fires only once
+	Once ScheduleUnit = ""
+	// Minute repeats a transfer every given number of minutes
+	Minute ScheduleUnit = "minute"
+	// Hour repeats a transfer every given number of hours
+	Hour ScheduleUnit = "hour"
+	// Day repeats a transfer every given number of days
+	Day ScheduleUnit = "day"
+	// Week repeats a transfer every given number of weeks
+	Week ScheduleUnit = "week"
+	// Month repeats a transfer every given number of calendar months
+	Month ScheduleUnit = "month"
+)
+
+// ScheduledTransfer is the intent of transferring tokens, once or periodically, to a recipient
+type ScheduledTransfer struct {
+	// ID is the identifier of the scheduled transfer
+	ID string
+	// Wallet is the identifier of the owner wallet the tokens are spent from
+	Wallet string
+	// TokenType is the type of the tokens to transfer
+	TokenType string
+	// Amount is the amount transferred at each occurrence
+	Amount uint64
+	// Recipient is the FSC node identity of the recipient, used to request the identity that will own the tokens
+	Recipient driver.Identity
+	// Start is the time of the first occurrence
+	Start time.Time
+	// Unit is the unit of the period, Once for a one-off transfer
+	Unit ScheduleUnit
+	// Every is the number of units between two occurrences
+	Every int
+	// End is the time after which no occurrence fires, the zero time if the transfer repeats forever
+	End time.Time
+	// Status is the status of the scheduled transfer
+	Status ScheduledTransferStatus
+	// Occurrence is the scheduled time of the pending occurrence
+	Occurrence time.Time
+	// NextRun is the time of the next attempt, later than Occurrence when the occurrence is being retried
+	NextRun time.Time
+	// Attempts is the number of failed attempts of the pending occurrence
+	Attempts int
+	// CreatedAt is the time the scheduled transfer has been stored
+	CreatedAt time.Time
+}
+
+// ScheduledTransferRun records an attempt of firing an occurrence of a scheduled transfer
+type ScheduledTransferRun struct {
+	// ScheduleID is the identifier of the scheduled transfer
+	ScheduleID string
+	// Occurrence is the scheduled time of the occurrence
+	Occurrence time.Time
+	// Attempt is the number of the attempt, starting from 1
+	Attempt int
+	// TxID is the identifier of the token transaction, if one has been assembled
+	TxID string
+	// Succeeded is true if the token transaction reached finality
+	Succeeded bool
+	// Message is the reason of the failure, if any
+	Message string
+	// ExecutedAt is the time the attempt completed
+	ExecutedAt time.Time
+}
+
+// QueryScheduledTransfersParams defines the parameters for querying scheduled transfers
+type QueryScheduledTransfersParams struct {
+	// Wallet, if not empty, selects the scheduled transfers spending from this wallet
+	Wallet string
+	// Statuses, if not empty, selects the scheduled transfers with one of these statuses
+	Statuses []ScheduledTransferStatus
+}
+
+// SchedulerDB stores scheduled transfers and the outcome of their runs
+type SchedulerDB interface {
+	// AddScheduledTransfer stores a new scheduled transfer
+	AddScheduledTransfer(st *ScheduledTransfer) error
+	// GetScheduledTransfer returns the scheduled transfer with the passed identifier, nil if it does not exist
+	GetScheduledTransfer(id string) (*ScheduledTransfer, error)
+	// QueryScheduledTransfers returns the scheduled transfers matching the passed params, ordered by creation time
+	QueryScheduledTransfers(params QueryScheduledTransfersParams) ([]*ScheduledTransfer, error)
+	// DueScheduledTransfers returns at most limit active scheduled transfers whose next run is not after the passed time,
+	// ordered by next run
+	DueScheduledTransfers(now time.Time, limit int) ([]*ScheduledTransfer, error)
+	// ClaimScheduledTransfer moves the next run of an active scheduled transfer from the passed one to the passed lease.
+	// It returns false if the scheduled transfer is not active anymore or has been claimed by someone else.
+	ClaimScheduledTransfer(id string, nextRun time.Time, lease time.Time) (bool, error)
+	// UpdateScheduledTransfer sets the status, the pending occurrence, the next run and the attempts of an active scheduled transfer.
+	// It returns false if the scheduled transfer is not active anymore, for instance because it has been cancelled.
+	UpdateScheduledTransfer(id string, status ScheduledTransferStatus, occurrence time.Time, nextRun time.Time, attempts int) (bool, error)
+	// CancelScheduledTransfer cancels an active scheduled transfer.
+	// It returns false if the scheduled transfer does not exist or is not active.
+	CancelScheduledTransfer(id string) (bool, error)
+	// AddScheduledTransferRun records a run of a scheduled transfer
+	AddScheduledTransferRun(run *ScheduledTransferRun) error
+	// GetScheduledTransferRuns returns the runs of the passed scheduled transfer, ordered by execution time
+	GetScheduledTransferRuns(id string) ([]*ScheduledTransferRun, error)
+	// Close closes the database
+	Close() error
+}
+
+// SchedulerDBDriver is the interface for a scheduler database driver
+type SchedulerDBDriver interface {
+	// Open opens a scheduler database
+	Open(cp ConfigProvider, tmsID token2.TMSID) (SchedulerDB, error)
+}
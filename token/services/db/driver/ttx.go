@@ -9,6 +9,7 @@ package driver
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
@@ -19,6 +20,10 @@ import (
 type TokenTransactionDB interface {
 	TransactionDB
 	TransactionEndorsementAckDB
+	TransactionStepDB
+	TransactionAttachmentDB
+	TransactionIdempotencyDB
+	WithdrawalRequestDB
 }
 
 type AtomicWrite interface {
@@ -85,6 +90,134 @@ type TransactionEndorsementAckDB interface {
 
 	// GetTransactionEndorsementAcks returns the endorsement signatures for the given transaction id
 	GetTransactionEndorsementAcks(txID string) (map[string][]byte, error)
+
+	// AddTransactionDistributionResult records the outcome of the distribution of a transaction to a party.
+	// An acknowledged outcome must be recorded after the acknowledgement of the party, via AddTransactionEndorsementAck,
+	// with the long term identity of the party as endorser.
+	AddTransactionDistributionResult(record *DistributionResultRecord) error
+
+	// GetTransactionDistributionResults returns the outcomes of the distribution of the given transaction,
+	// in the order they have been recorded
+	GetTransactionDistributionResults(txID string) ([]*DistributionResultRecord, error)
+}
+
+// DistributionResultRecord is the outcome of the distribution of a transaction to a party
+type DistributionResultRecord struct {
+	// TxID is the transaction id
+	TxID string
+	// Party is the long term identity of the party
+	Party token.Identity
+	// Acknowledged is true if the party acknowledged the transaction.
+	// The acknowledgement is then among the endorsement acks of the transaction, with Party as endorser.
+	Acknowledged bool
+	// Attempts is the number of times the transaction has been sent to the party
+	Attempts int
+	// Error is the error of the last attempt, if the party did not acknowledge the transaction
+	Error string
+	// Timestamp is the time the outcome has been recorded
+	Timestamp time.Time
+}
+
+// TransactionAttachmentDB stores the off-ledger attachments of a transaction, such as the travel-rule information
+type TransactionAttachmentDB interface {
+	// AddTransactionAttachment stores the passed payload as the attachment of the given transaction with the given name.
+	// An attachment added later under the same name replaces the previous one.
+	AddTransactionAttachment(txID string, name string, payload []byte) error
+
+	// GetTransactionAttachments returns the attachments of the given transaction, indexed by name
+	GetTransactionAttachments(txID string) (map[string][]byte, error)
+}
+
+// IdempotencyKeyRecord binds a client-provided idempotency key to the transaction created for it
+type IdempotencyKeyRecord struct {
+	// Key is the idempotency key
+	Key string
+	// TxID is the transaction id
+	TxID string
+	// Nonce is the nonce of the network transaction id
+	Nonce []byte
+	// Creator is the creator of the network transaction id
+	Creator []byte
+	// Timestamp is the time the key has been bound
+	Timestamp time.Time
+}
+
+// TransactionIdempotencyDB binds idempotency keys to transactions, so that repeated submissions
+// with the same key resolve to the same transaction
+type TransactionIdempotencyDB interface {
+	// AddIdempotencyKey binds the key of the passed record to its transaction, unless the key is already bound.
+	// It returns the record the key is bound to, that is the passed one or the one stored before.
+	AddIdempotencyKey(record *IdempotencyKeyRecord) (*IdempotencyKeyRecord, error)
+
+	// GetIdempotencyKey returns the record bound to the given key.
+	// It returns nil without error if the key is not found.
+	GetIdempotencyKey(key string) (*IdempotencyKeyRecord, error)
+}
+
+// TransactionStep is a step of the lifecycle of a token transaction as seen by this node
+type TransactionStep int
+
+const (
+	// UnknownStep is the step of a transaction whose lifecycle has not been recorded
+	UnknownStep TransactionStep = iota
+	// Assembled is the step of a transaction whose token request is complete and ready to be signed
+	Assembled
+	// SignaturesCollected is the step of a transaction whose token request carries the signatures of all the required parties
+	SignaturesCollected
+	// Audited is the step of a transaction that has been signed by the auditor
+	Audited
+	// Approved is the step of a transaction that has been approved by the backend and distributed to all the parties
+	Approved
+	// Broadcast is the step of a transaction that has been submitted for ordering
+	Broadcast
+	// Distributed is the step of a transaction assembled by another node and distributed by it to this node.
+	// The payload is the identity of the sender.
+	// It precedes the terminal steps, so that the last step of a received transaction becomes Final or Aborted.
+	Distributed
+	// Final is the step of a transaction whose finality has been observed
+	Final
+	// Aborted is the step of a transaction that has been abandoned before being broadcast
+	Aborted
+)
+
+// TransactionStepMessage maps TransactionStep to string
+var TransactionStepMessage = map[TransactionStep]string{
+	UnknownStep:         "Unknown",
+	Assembled:           "Assembled",
+	SignaturesCollected: "SignaturesCollected",
+	Audited:             "Audited",
+	Approved:            "Approved",
+	Broadcast:           "Broadcast",
+	Final:               "Final",
+	Aborted:             "Aborted",
+	Distributed:         "Distributed",
+}
+
+// TransactionStepRecord records that a transaction reached a given step of its lifecycle
+type TransactionStepRecord struct {
+	// TxID is the transaction id
+	TxID string
+	// Step is the step reached
+	Step TransactionStep
+	// Payload is the serialized transaction at this step, if stored
+	Payload []byte
+	// Message is an optional message, for instance the reason of an abort
+	Message string
+	// Timestamp is the time the step has been reached
+	Timestamp time.Time
+}
+
+type TransactionStepDB interface {
+	// AddTransactionStep records that a transaction reached a given step of its lifecycle
+	AddTransactionStep(record *TransactionStepRecord) error
+
+	// GetTransactionSteps returns the steps recorded for the given transaction id, in the order they have been reached
+	GetTransactionSteps(txID string) ([]*TransactionStepRecord, error)
+
+	// QueryInFlightTransactions returns, for each transaction that is neither final nor aborted,
+	// the record of the last step it reached. Transactions whose last step is Distributed are not in-flight.
+	// A transaction is final if its last step is Final or its token request status is Confirmed or Deleted.
+	QueryInFlightTransactions() ([]*TransactionStepRecord, error)
 }
 
 // TTXDBDriver is the interface for a token transaction db driver
@@ -0,0 +1,95 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package driver
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+)
+
+// WithdrawalRequestStatus is the status of a withdrawal request in the queue of an issuer
+type WithdrawalRequestStatus int
+
+const (
+	// WithdrawalUnknown is the status of a withdrawal request that does not exist
+	WithdrawalUnknown WithdrawalRequestStatus = iota
+	// WithdrawalPending is the status of a withdrawal request waiting for a decision
+	WithdrawalPending
+	// WithdrawalApproved is the status of a withdrawal request approved and waiting to be issued
+	WithdrawalApproved
+	// WithdrawalRejected is the status of a withdrawal request that has been rejected
+	WithdrawalRejected
+	// WithdrawalExpired is the status of a withdrawal request that has not been decided before its expiry
+	WithdrawalExpired
+	// WithdrawalIssued is the status of a withdrawal request whose tokens have been issued
+	WithdrawalIssued
+	// WithdrawalIssuing is the status of an approved withdrawal request claimed by an issuer that is issuing its tokens
+	WithdrawalIssuing
+)
+
+// WithdrawalRequestStatusMessage maps WithdrawalRequestStatus to string
+var WithdrawalRequestStatusMessage = map[WithdrawalRequestStatus]string{
+	WithdrawalUnknown:  "Unknown",
+	WithdrawalPending:  "Pending",
+	WithdrawalApproved: "Approved",
+	WithdrawalRejected: "Rejected",
+	WithdrawalExpired:  "Expired",
+	WithdrawalIssued:   "Issued",
+	WithdrawalIssuing:  "Issuing",
+}
+
+// WithdrawalRequestRecord is a withdrawal request stored in the queue of an issuer
+type WithdrawalRequestRecord struct {
+	// ID is the identifier of the request
+	ID string
+	// Requester is the FSC node identity of the node that sent the request
+	Requester driver.Identity
+	// TokenType is the type of the tokens to issue
+	TokenType string
+	// Amount is the amount to issue
+	Amount uint64
+	// Request is the serialized request
+	Request []byte
+	// Status is the status of the request
+	Status WithdrawalRequestStatus
+	// Message is the message attached to the status, for instance the reason of a rejection
+	Message string
+	// TxID is the id of the transaction issuing the tokens, once issued
+	TxID string
+	// CreatedAt is the time the request has been stored
+	CreatedAt time.Time
+	// ExpiresAt is the time after which a pending request expires, the zero time if it never expires
+	ExpiresAt time.Time
+	// UpdatedAt is the time of the last change of status
+	UpdatedAt time.Time
+}
+
+// WithdrawalRequestDB stores the withdrawal requests received by an issuer, and their approvals
+type WithdrawalRequestDB interface {
+	// AddWithdrawalRequest stores a new withdrawal request
+	AddWithdrawalRequest(record *WithdrawalRequestRecord) error
+
+	// GetWithdrawalRequest returns the withdrawal request with the given id.
+	// It returns nil without error if the request is not found.
+	GetWithdrawalRequest(id string) (*WithdrawalRequestRecord, error)
+
+	// QueryWithdrawalRequests returns the withdrawal requests with the given statuses, all of them if none is passed,
+	// in the order they have been stored
+	QueryWithdrawalRequests(statuses ...WithdrawalRequestStatus) ([]*WithdrawalRequestRecord, error)
+
+	// UpdateWithdrawalRequestStatus moves the withdrawal request with the given id from status `from` to status `to`,
+	// setting message and transaction id. It returns false if the request is not in status `from`.
+	UpdateWithdrawalRequestStatus(id string, from, to WithdrawalRequestStatus, message string, txID string) (bool, error)
+
+	// AddWithdrawalApproval records that the given approver approved the withdrawal request with the given id.
+	// Approving twice has no effect.
+	AddWithdrawalApproval(id string, approver string) error
+
+	// GetWithdrawalApprovals returns the approvers of the withdrawal request with the given id, in the order they approved
+	GetWithdrawalApprovals(id string) ([]string, error)
+}
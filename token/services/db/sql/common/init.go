@@ -19,6 +19,14 @@ type tableNames struct {
 	Requests               string
 	Validations            string
 	TransactionEndorseAck  string
+	Discrepancies          string
+	ComplianceDecisions    string
+	TransactionSteps       string
+	TransactionAttachments string
+	Distributions          string
+	IdempotencyKeys        string
+	WithdrawalRequests     string
+	WithdrawalApprovals    string
 	Certifications         string
 	Tokens                 string
 	Ownership              string
@@ -28,6 +36,8 @@ type tableNames struct {
 	IdentityInfo           string
 	Signers                string
 	TokenLocks             string
+	ScheduledTransfers     string
+	ScheduledTransferRuns  string
 }
 
 func GetTableNames(prefix string) (tableNames, error) {
@@ -41,6 +51,14 @@ func GetTableNames(prefix string) (tableNames, error) {
 		Transactions:           nc.MustGetTableName("transactions"),
 		TransactionEndorseAck:  nc.MustGetTableName("transaction_endorsements"),
 		Requests:               nc.MustGetTableName("requests"),
+		Discrepancies:          nc.MustGetTableName("discrepancies"),
+		ComplianceDecisions:    nc.MustGetTableName("compliance_decisions"),
+		TransactionSteps:       nc.MustGetTableName("transaction_steps"),
+		TransactionAttachments: nc.MustGetTableName("transaction_attachments"),
+		Distributions:          nc.MustGetTableName("transaction_distributions"),
+		IdempotencyKeys:        nc.MustGetTableName("idempotency_keys"),
+		WithdrawalRequests:     nc.MustGetTableName("withdrawal_requests"),
+		WithdrawalApprovals:    nc.MustGetTableName("withdrawal_approvals"),
 		Validations:            nc.MustGetTableName("request_validations"),
 		Tokens:                 nc.MustGetTableName("tokens"),
 		Ownership:              nc.MustGetTableName("token_ownership"),
@@ -51,5 +69,7 @@ func GetTableNames(prefix string) (tableNames, error) {
 		IdentityConfigurations: nc.MustGetTableName("identity_configurations"),
 		IdentityInfo:           nc.MustGetTableName("identity_information"),
 		Signers:                nc.MustGetTableName("identity_signers"),
+		ScheduledTransfers:     nc.MustGetTableName("scheduled_transfers"),
+		ScheduledTransferRuns:  nc.MustGetTableName("scheduled_transfer_runs"),
 	}, nil
 }
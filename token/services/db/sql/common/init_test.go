@@ -24,6 +24,14 @@ func TestGetTableNames(t *testing.T) {
 		Requests:               "requests",
 		Validations:            "request_validations",
 		TransactionEndorseAck:  "transaction_endorsements",
+		Discrepancies:          "discrepancies",
+		ComplianceDecisions:    "compliance_decisions",
+		TransactionSteps:       "transaction_steps",
+		TransactionAttachments: "transaction_attachments",
+		Distributions:          "transaction_distributions",
+		IdempotencyKeys:        "idempotency_keys",
+		WithdrawalRequests:     "withdrawal_requests",
+		WithdrawalApprovals:    "withdrawal_approvals",
 		Certifications:         "token_certifications",
 		Tokens:                 "tokens",
 		Ownership:              "token_ownership",
@@ -33,6 +41,8 @@ func TestGetTableNames(t *testing.T) {
 		IdentityInfo:           "identity_information",
 		Signers:                "identity_signers",
 		TokenLocks:             "token_locks",
+		ScheduledTransfers:     "scheduled_transfers",
+		ScheduledTransferRuns:  "scheduled_transfer_runs",
 	}, names)
 
 	names, err = GetTableNames("valid_prefix")
@@ -11,13 +11,31 @@ import (
 	"testing"
 	"time"
 
+	sql2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	"github.com/test-go/testify/assert"
 )
 
-var b = NewTokenInterpreter(common.NewInterpreter())
+var b = NewTokenInterpreter(common.NewInterpreter(), testJSONField)
+
+// testJSONField is the sqlite JSONFieldExtractor
+func testJSONField(column common.FieldName, key string) common.FieldName {
+	return fmt.Sprintf("(SELECT json_each.value FROM json_each(%s) WHERE json_each.key = %s)", column, QuoteLiteral(key))
+}
+
+// testPostgresJSONField is the postgres JSONFieldExtractor
+func testPostgresJSONField(column common.FieldName, key string) common.FieldName {
+	return fmt.Sprintf("(%s->>%s)", column, QuoteLiteral(key))
+}
+
+func testJSONFieldFor(driverName common.SQLDriverType) JSONFieldExtractor {
+	if driverName == sql2.Postgres {
+		return testPostgresJSONField
+	}
+	return testJSONField
+}
 
 func TestTransactionSql(t *testing.T) {
 	now := time.Now().Local().UTC()
@@ -107,6 +125,15 @@ func TestTransactionSql(t *testing.T) {
 			expectedSql:  "WHERE ((tbl.tx_id) IN (($1), ($2), ($3)) AND (sender_eid = $4 OR recipient_eid = $5))",
 			expectedArgs: []interface{}{"transactionID1", "transactionID2", "transactionID3", "alice", "bob"},
 		},
+		{
+			name: "Application metadata",
+			params: driver.QueryTransactionsParams{
+				Statuses:            []driver.TxStatus{driver.Confirmed},
+				ApplicationMetadata: map[string][]byte{"reference": []byte("inv1"), "payer's": []byte("alice")},
+			},
+			expectedSql:  "WHERE (status = $1 AND (SELECT json_each.value FROM json_each(application_metadata) WHERE json_each.key = 'payer''s') = $2 AND (SELECT json_each.value FROM json_each(application_metadata) WHERE json_each.key = 'reference') = $3)",
+			expectedArgs: []interface{}{driver.Confirmed, "YWxpY2U=", "aW52MQ=="},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,300 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/pkg/errors"
+)
+
+const scheduledTransferColumns = "id, wallet_id, token_type, amount, recipient, start_at, unit, unit_count, end_at, status, occurrence, next_run, attempts, created_at"
+
+type schedulerTables struct {
+	ScheduledTransfers    string
+	ScheduledTransferRuns string
+}
+
+type SchedulerDB struct {
+	db    *sql.DB
+	table schedulerTables
+}
+
+func newSchedulerDB(db *sql.DB, tables schedulerTables) *SchedulerDB {
+	return &SchedulerDB{
+		db:    db,
+		table: tables,
+	}
+}
+
+func NewSchedulerDB(db *sql.DB, opts NewDBOpts) (*SchedulerDB, error) {
+	tables, err := GetTableNames(opts.TablePrefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get table names")
+	}
+
+	schedulerDB := newSchedulerDB(db, schedulerTables{
+		ScheduledTransfers:    tables.ScheduledTransfers,
+		ScheduledTransferRuns: tables.ScheduledTransferRuns,
+	})
+	if opts.CreateSchema {
+		if err = common.InitSchema(db, schedulerDB.GetSchema()); err != nil {
+			return nil, err
+		}
+	}
+	return schedulerDB, nil
+}
+
+func (db *SchedulerDB) AddScheduledTransfer(st *driver.ScheduledTransfer) error {
+	logger.Debugf("adding scheduled transfer [%s]", st.ID)
+	createdAt := st.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.ScheduledTransfers).Rows(scheduledTransferColumns).Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, st.ID, st.Wallet, st.TokenType, st.Amount, st.Start, st.Unit, st.Every, st.End, st.Status)
+	_, err = db.db.Exec(query,
+		st.ID, st.Wallet, st.TokenType, st.Amount, st.Recipient,
+		st.Start.UTC(), string(st.Unit), st.Every, nullTime(st.End),
+		st.Status, st.Occurrence.UTC(), st.NextRun.UTC(), st.Attempts, createdAt.UTC(),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed storing scheduled transfer [%s]", st.ID)
+	}
+	return nil
+}
+
+func (db *SchedulerDB) GetScheduledTransfer(id string) (*driver.ScheduledTransfer, error) {
+	query, err := NewSelect(scheduledTransferColumns).From(db.table.ScheduledTransfers).Where("id = $1").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, id)
+	res, err := db.queryScheduledTransfers(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res[0], nil
+}
+
+func (db *SchedulerDB) QueryScheduledTransfers(params driver.QueryScheduledTransfersParams) ([]*driver.ScheduledTransfer, error) {
+	var conditions []string
+	var args []any
+	if len(params.Wallet) != 0 {
+		args = append(args, params.Wallet)
+		conditions = append(conditions, fmt.Sprintf("wallet_id = $%d", len(args)))
+	}
+	if len(params.Statuses) != 0 {
+		placeholders := make([]string, len(params.Statuses))
+		for i, status := range params.Statuses {
+			args = append(args, status)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", ")))
+	}
+	query, err := NewSelect(scheduledTransferColumns).From(db.table.ScheduledTransfers).Where(strings.Join(conditions, " AND ")).OrderBy("created_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, args)
+	return db.queryScheduledTransfers(query, args...)
+}
+
+func (db *SchedulerDB) DueScheduledTransfers(now time.Time, limit int) ([]*driver.ScheduledTransfer, error) {
+	query, err := NewSelect(scheduledTransferColumns).From(db.table.ScheduledTransfers).Where("status = $1 AND next_run <= $2").OrderBy("next_run ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	if limit > 0 {
+		query = fmt.Sprintf("%s LIMIT %d", query, limit)
+	}
+	logger.Debug(query, now)
+	return db.queryScheduledTransfers(query, driver.ScheduleActive, now.UTC())
+}
+
+func (db *SchedulerDB) ClaimScheduledTransfer(id string, nextRun time.Time, lease time.Time) (bool, error) {
+	query, err := NewUpdate(db.table.ScheduledTransfers).Set("next_run").Where("id = $2 AND status = $3 AND next_run = $4").Compile()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, lease, id, nextRun)
+	return db.exec(query, lease.UTC(), id, driver.ScheduleActive, nextRun.UTC())
+}
+
+func (db *SchedulerDB) UpdateScheduledTransfer(id string, status driver.ScheduledTransferStatus, occurrence time.Time, nextRun time.Time, attempts int) (bool, error) {
+	query, err := NewUpdate(db.table.ScheduledTransfers).Set("status, occurrence, next_run, attempts").Where("id = $5 AND status = $6").Compile()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, status, occurrence, nextRun, attempts, id)
+	return db.exec(query, status, occurrence.UTC(), nextRun.UTC(), attempts, id, driver.ScheduleActive)
+}
+
+func (db *SchedulerDB) CancelScheduledTransfer(id string) (bool, error) {
+	query, err := NewUpdate(db.table.ScheduledTransfers).Set("status").Where("id = $2 AND status = $3").Compile()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, driver.ScheduleCancelled, id)
+	return db.exec(query, driver.ScheduleCancelled, id, driver.ScheduleActive)
+}
+
+func (db *SchedulerDB) AddScheduledTransferRun(run *driver.ScheduledTransferRun) error {
+	executedAt := run.ExecutedAt
+	if executedAt.IsZero() {
+		executedAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.ScheduledTransferRuns).Rows("id, schedule_id, occurrence, attempt, tx_id, succeeded, message, executed_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, run.ScheduleID, run.Occurrence, run.Attempt, run.TxID, run.Succeeded, run.Message, executedAt)
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return errors.Wrapf(err, "error generating uuid")
+	}
+	if _, err = db.db.Exec(query, id, run.ScheduleID, run.Occurrence.UTC(), run.Attempt, run.TxID, run.Succeeded, run.Message, executedAt.UTC()); err != nil {
+		return errors.Wrapf(err, "failed storing run of scheduled transfer [%s]", run.ScheduleID)
+	}
+	return nil
+}
+
+func (db *SchedulerDB) GetScheduledTransferRuns(id string) ([]*driver.ScheduledTransferRun, error) {
+	query, err := NewSelect("schedule_id, occurrence, attempt, tx_id, succeeded, message, executed_at").From(db.table.ScheduledTransferRuns).Where("schedule_id = $1").OrderBy("executed_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, id)
+	rows, err := db.db.Query(query, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var res []*driver.ScheduledTransferRun
+	for rows.Next() {
+		var r driver.ScheduledTransferRun
+		if err := rows.Scan(&r.ScheduleID, &r.Occurrence, &r.Attempt, &r.TxID, &r.Succeeded, &r.Message, &r.ExecutedAt); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		res = append(res, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *SchedulerDB) queryScheduledTransfers(query string, args ...any) ([]*driver.ScheduledTransfer, error) {
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var res []*driver.ScheduledTransfer
+	for rows.Next() {
+		var r driver.ScheduledTransfer
+		var unit string
+		var end sql.NullTime
+		if err := rows.Scan(
+			&r.ID, &r.Wallet, &r.TokenType, &r.Amount, &r.Recipient,
+			&r.Start, &unit, &r.Every, &end,
+			&r.Status, &r.Occurrence, &r.NextRun, &r.Attempts, &r.CreatedAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		r.Unit = driver.ScheduleUnit(unit)
+		if end.Valid {
+			r.End = end.Time
+		}
+		res = append(res, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *SchedulerDB) exec(query string, args ...any) (bool, error) {
+	res, err := db.db.Exec(query, args...)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to execute")
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get affected rows")
+	}
+	return n == 1, nil
+}
+
+func (db *SchedulerDB) GetSchema() string {
+	return fmt.Sprintf(`
+		-- ScheduledTransfers
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT NOT NULL PRIMARY KEY,
+			wallet_id TEXT NOT NULL,
+			token_type TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			recipient BYTEA NOT NULL,
+			start_at TIMESTAMP NOT NULL,
+			unit TEXT NOT NULL,
+			unit_count INT NOT NULL,
+			end_at TIMESTAMP,
+			status INT NOT NULL,
+			occurrence TIMESTAMP NOT NULL,
+			next_run TIMESTAMP NOT NULL,
+			attempts INT NOT NULL,
+			created_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_status_next_run_%s ON %s ( status, next_run );
+
+		-- ScheduledTransferRuns
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT NOT NULL PRIMARY KEY,
+			schedule_id TEXT NOT NULL REFERENCES %s,
+			occurrence TIMESTAMP NOT NULL,
+			attempt INT NOT NULL,
+			tx_id TEXT NOT NULL,
+			succeeded BOOLEAN NOT NULL,
+			message TEXT NOT NULL,
+			executed_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_schedule_id_%s ON %s ( schedule_id );
+		`,
+		db.table.ScheduledTransfers,
+		db.table.ScheduledTransfers, db.table.ScheduledTransfers,
+		db.table.ScheduledTransferRuns, db.table.ScheduledTransfers,
+		db.table.ScheduledTransferRuns, db.table.ScheduledTransferRuns,
+	)
+}
+
+func (db *SchedulerDB) Close() error {
+	logger.Info("closing database")
+	err := db.db.Close()
+	if err != nil {
+		return errors.Wrap(err, "could not close DB")
+	}
+	return nil
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
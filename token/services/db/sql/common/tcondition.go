@@ -7,7 +7,10 @@ SPDX-License-Identifier: Apache-2.0
 package common
 
 import (
+	"encoding/base64"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
@@ -23,12 +26,22 @@ type TokenInterpreter interface {
 	HasTransactionParams(params driver.QueryTransactionsParams, table string) common.Condition
 }
 
-func NewTokenInterpreter(ci common.Interpreter) TokenInterpreter {
-	return &tokenInterpreter{Interpreter: ci}
+// JSONFieldExtractor returns the SQL expression that extracts, as text, the value of the passed key
+// from the JSON object stored in the passed column
+type JSONFieldExtractor func(column common.FieldName, key string) common.FieldName
+
+// QuoteLiteral returns the passed string as an SQL string literal
+func QuoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func NewTokenInterpreter(ci common.Interpreter, jsonField JSONFieldExtractor) TokenInterpreter {
+	return &tokenInterpreter{Interpreter: ci, jsonField: jsonField}
 }
 
 type tokenInterpreter struct {
 	common.Interpreter
+	jsonField JSONFieldExtractor
 }
 
 func (c *tokenInterpreter) HasTokens(colTxID, colIdx common.FieldName, ids ...*token.ID) common.Condition {
@@ -78,6 +91,9 @@ func (c *tokenInterpreter) HasMovementsParams(params driver.QueryMovementsParams
 	} else if params.MovementDirection == driver.Received {
 		conds = append(conds, common.ConstCondition("amount > 0"))
 	}
+	if params.From != nil && !params.From.IsZero() {
+		conds = append(conds, c.Cmp("stored_at", ">=", params.From.UTC()))
+	}
 	return c.And(conds...)
 }
 
@@ -126,5 +142,15 @@ func (c *tokenInterpreter) HasTransactionParams(params driver.QueryTransactionsP
 			c.Cmp("recipient_eid", "=", params.RecipientWallet),
 		))
 	}
+
+	// The application metadata is stored as a JSON object whose values are base64 encoded
+	keys := make([]string, 0, len(params.ApplicationMetadata))
+	for k := range params.ApplicationMetadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		conds = append(conds, c.Cmp(c.jsonField("application_metadata", k), "=", base64.StdEncoding.EncodeToString(params.ApplicationMetadata[k])))
+	}
 	return c.And(conds...)
 }
@@ -30,6 +30,14 @@ type transactionTables struct {
 	Requests              string
 	Validations           string
 	TransactionEndorseAck string
+	Discrepancies         string
+	ComplianceDecisions   string
+	TransactionSteps      string
+	Attachments           string
+	Distributions         string
+	IdempotencyKeys       string
+	WithdrawalRequests    string
+	WithdrawalApprovals   string
 }
 
 type TransactionDB struct {
@@ -47,7 +55,7 @@ func newTransactionDB(db *sql.DB, tables transactionTables, ci TokenInterpreter)
 }
 
 func NewAuditTransactionDB(sqlDB *sql.DB, opts NewDBOpts, ci TokenInterpreter) (driver.AuditTransactionDB, error) {
-	return NewTransactionDB(sqlDB, NewDBOpts{
+	return openTransactionDB(sqlDB, NewDBOpts{
 		DataSource:   opts.DataSource,
 		TablePrefix:  opts.TablePrefix + "_aud",
 		CreateSchema: opts.CreateSchema,
@@ -55,6 +63,10 @@ func NewAuditTransactionDB(sqlDB *sql.DB, opts NewDBOpts, ci TokenInterpreter) (
 }
 
 func NewTransactionDB(db *sql.DB, opts NewDBOpts, ci TokenInterpreter) (driver.TokenTransactionDB, error) {
+	return openTransactionDB(db, opts, ci)
+}
+
+func openTransactionDB(db *sql.DB, opts NewDBOpts, ci TokenInterpreter) (*TransactionDB, error) {
 	tables, err := GetTableNames(opts.TablePrefix)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get table names")
@@ -65,6 +77,14 @@ func NewTransactionDB(db *sql.DB, opts NewDBOpts, ci TokenInterpreter) (driver.T
 		Requests:              tables.Requests,
 		Validations:           tables.Validations,
 		TransactionEndorseAck: tables.TransactionEndorseAck,
+		Discrepancies:         tables.Discrepancies,
+		ComplianceDecisions:   tables.ComplianceDecisions,
+		TransactionSteps:      tables.TransactionSteps,
+		Attachments:           tables.TransactionAttachments,
+		Distributions:         tables.Distributions,
+		IdempotencyKeys:       tables.IdempotencyKeys,
+		WithdrawalRequests:    tables.WithdrawalRequests,
+		WithdrawalApprovals:   tables.WithdrawalApprovals,
 	}, ci)
 	if opts.CreateSchema {
 		if err = common.InitSchema(db, []string{transactionsDB.GetSchema()}...); err != nil {
@@ -97,7 +117,7 @@ func (db *TransactionDB) QueryMovements(params driver.QueryMovementsParams) (res
 	where, args := common.Where(db.ci.HasMovementsParams(params))
 	conditions := where + movementConditionsSql(params)
 	query, err := NewSelect(
-		fmt.Sprintf("%s.tx_id, enrollment_id, token_type, amount, %s.status", db.table.Movements, db.table.Requests),
+		fmt.Sprintf("%s.tx_id, enrollment_id, token_type, amount, %s.status, stored_at", db.table.Movements, db.table.Requests),
 	).From(db.table.Movements, joinOnTxID(db.table.Movements, db.table.Requests)).Where(conditions).Compile()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to compile query")
@@ -120,6 +140,7 @@ func (db *TransactionDB) QueryMovements(params driver.QueryMovementsParams) (res
 			&r.TokenType,
 			&amount,
 			&status,
+			&r.Timestamp,
 		)
 		if err != nil {
 			return res, err
@@ -198,7 +219,7 @@ func (db *TransactionDB) QueryValidations(params driver.QueryValidationRecordsPa
 func (db *TransactionDB) QueryTokenRequests(params driver.QueryTokenRequestsParams) (driver.TokenRequestIterator, error) {
 	where, args := common.Where(db.ci.InInts("status", params.Statuses))
 
-	query, err := NewSelect("tx_id, request, status").From(db.table.Requests).Where(where).Compile()
+	query, err := NewSelect("tx_id, request, status, pp_hash").From(db.table.Requests).Where(where).Compile()
 	if err != nil {
 		return nil, errors.Wrapf(err, "error compiling query")
 	}
@@ -261,6 +282,339 @@ func (db *TransactionDB) GetTransactionEndorsementAcks(txID string) (map[string]
 	return acks, nil
 }
 
+func (db *TransactionDB) AddTransactionDistributionResult(record *driver.DistributionResultRecord) error {
+	logger.Debugf("adding transaction distribution result [%s]", record.TxID)
+
+	storedAt := record.Timestamp
+	if storedAt.IsZero() {
+		storedAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.Distributions).Rows("id, tx_id, party, acknowledged, attempts, error, stored_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, record.TxID, record.Party, record.Acknowledged, record.Attempts, record.Error, storedAt)
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return errors.Wrapf(err, "error generating uuid")
+	}
+	if _, err = db.db.Exec(query, id, record.TxID, record.Party, record.Acknowledged, record.Attempts, record.Error, storedAt.UTC()); err != nil {
+		return ttxDBError(err)
+	}
+	return nil
+}
+
+func (db *TransactionDB) GetTransactionDistributionResults(txID string) ([]*driver.DistributionResultRecord, error) {
+	query, err := NewSelect("party, acknowledged, attempts, error, stored_at").From(db.table.Distributions).Where("tx_id=$1").OrderBy("stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, txID)
+
+	rows, err := db.db.Query(query, txID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var records []*driver.DistributionResultRecord
+	for rows.Next() {
+		r := &driver.DistributionResultRecord{TxID: txID}
+		var party []byte
+		if err := rows.Scan(&party, &r.Acknowledged, &r.Attempts, &r.Error, &r.Timestamp); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		r.Party = party
+		records = append(records, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (db *TransactionDB) AddTransactionAttachment(txID string, name string, payload []byte) error {
+	logger.Debugf("adding transaction attachment [%s:%s]", txID, name)
+
+	now := time.Now().UTC()
+	query, err := NewInsertInto(db.table.Attachments).Rows("id, tx_id, name, payload, stored_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, txID, name, fmt.Sprintf("(%d bytes)", len(payload)), now)
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return errors.Wrapf(err, "error generating uuid")
+	}
+	if _, err = db.db.Exec(query, id, txID, name, payload, now); err != nil {
+		return ttxDBError(err)
+	}
+	return nil
+}
+
+func (db *TransactionDB) GetTransactionAttachments(txID string) (map[string][]byte, error) {
+	query, err := NewSelect("name, payload").From(db.table.Attachments).Where("tx_id=$1").OrderBy("stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, txID)
+
+	rows, err := db.db.Query(query, txID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	attachments := make(map[string][]byte)
+	for rows.Next() {
+		var name string
+		var payload []byte
+		if err := rows.Scan(&name, &payload); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		// later attachments replace the earlier ones with the same name
+		attachments[name] = payload
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+func (db *TransactionDB) AddIdempotencyKey(record *driver.IdempotencyKeyRecord) (*driver.IdempotencyKeyRecord, error) {
+	logger.Debugf("adding idempotency key [%s] for [%s]", record.Key, record.TxID)
+
+	storedAt := record.Timestamp
+	if storedAt.IsZero() {
+		storedAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.IdempotencyKeys).Rows("idempotency_key, tx_id, nonce, creator, stored_at").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, record.Key, record.TxID, storedAt)
+	if _, err = db.db.Exec(query, record.Key, record.TxID, record.Nonce, record.Creator, storedAt.UTC()); err != nil {
+		// the key might have been bound concurrently, in this case the existing record wins
+		existing, err2 := db.GetIdempotencyKey(record.Key)
+		if err2 != nil || existing == nil {
+			return nil, ttxDBError(err)
+		}
+		return existing, nil
+	}
+	res := *record
+	res.Timestamp = storedAt
+	return &res, nil
+}
+
+func (db *TransactionDB) GetIdempotencyKey(key string) (*driver.IdempotencyKeyRecord, error) {
+	query, err := NewSelect("tx_id, nonce, creator, stored_at").From(db.table.IdempotencyKeys).Where("idempotency_key=$1").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, key)
+
+	r := &driver.IdempotencyKeyRecord{Key: key}
+	err = db.db.QueryRow(query, key).Scan(&r.TxID, &r.Nonce, &r.Creator, &r.Timestamp)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error querying db")
+	}
+	return r, nil
+}
+
+func (db *TransactionDB) AddDiscrepancy(record *driver.DiscrepancyRecord) error {
+	logger.Debugf("adding discrepancy record [%s]", record.TxID)
+
+	storedAt := record.Timestamp
+	if storedAt.IsZero() {
+		storedAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.Discrepancies).Rows("id, tx_id, reason, stored_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, record.TxID, record.Reason, storedAt)
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return errors.Wrapf(err, "error generating uuid")
+	}
+	if _, err = db.db.Exec(query, id, record.TxID, record.Reason, storedAt.UTC()); err != nil {
+		return ttxDBError(err)
+	}
+	return nil
+}
+
+func (db *TransactionDB) QueryDiscrepancies(params driver.QueryDiscrepanciesParams) ([]*driver.DiscrepancyRecord, error) {
+	conds := []common.Condition{db.ci.InStrings("tx_id", params.IDs)}
+	if params.From != nil && !params.From.IsZero() {
+		conds = append(conds, db.ci.Cmp("stored_at", ">=", params.From.UTC()))
+	}
+	if params.To != nil && !params.To.IsZero() {
+		conds = append(conds, db.ci.Cmp("stored_at", "<=", params.To.UTC()))
+	}
+	where, args := common.Where(db.ci.And(conds...))
+	query, err := NewSelect("tx_id, reason, stored_at").From(db.table.Discrepancies).Where(where).OrderBy("stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, args)
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var res []*driver.DiscrepancyRecord
+	for rows.Next() {
+		var r driver.DiscrepancyRecord
+		if err := rows.Scan(&r.TxID, &r.Reason, &r.Timestamp); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		res = append(res, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *TransactionDB) AddComplianceDecision(record *driver.ComplianceDecisionRecord) error {
+	logger.Debugf("adding compliance decision record [%s][%s]", record.TxID, driver.ComplianceDecisionMessage[record.Decision])
+
+	storedAt := record.Timestamp
+	if storedAt.IsZero() {
+		storedAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.ComplianceDecisions).Rows("id, tx_id, decision, reason, stored_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, record.TxID, record.Decision, record.Reason, storedAt)
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return errors.Wrapf(err, "error generating uuid")
+	}
+	if _, err = db.db.Exec(query, id, record.TxID, int(record.Decision), record.Reason, storedAt.UTC()); err != nil {
+		return ttxDBError(err)
+	}
+	return nil
+}
+
+func (db *TransactionDB) QueryComplianceDecisions(params driver.QueryComplianceDecisionsParams) ([]*driver.ComplianceDecisionRecord, error) {
+	conds := []common.Condition{db.ci.InStrings("tx_id", params.IDs)}
+	if len(params.Decisions) > 0 {
+		conds = append(conds, db.ci.InInts("decision", common.ToInts(params.Decisions)))
+	}
+	if params.From != nil && !params.From.IsZero() {
+		conds = append(conds, db.ci.Cmp("stored_at", ">=", params.From.UTC()))
+	}
+	if params.To != nil && !params.To.IsZero() {
+		conds = append(conds, db.ci.Cmp("stored_at", "<=", params.To.UTC()))
+	}
+	where, args := common.Where(db.ci.And(conds...))
+	query, err := NewSelect("tx_id, decision, reason, stored_at").From(db.table.ComplianceDecisions).Where(where).OrderBy("stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, args)
+
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var res []*driver.ComplianceDecisionRecord
+	for rows.Next() {
+		var r driver.ComplianceDecisionRecord
+		var decision int
+		if err := rows.Scan(&r.TxID, &decision, &r.Reason, &r.Timestamp); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		r.Decision = driver.ComplianceDecision(decision)
+		res = append(res, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (db *TransactionDB) AddTransactionStep(record *driver.TransactionStepRecord) error {
+	logger.Debugf("adding transaction step record [%s][%s]", record.TxID, driver.TransactionStepMessage[record.Step])
+
+	storedAt := record.Timestamp
+	if storedAt.IsZero() {
+		storedAt = time.Now()
+	}
+	query, err := NewInsertInto(db.table.TransactionSteps).Rows("id, tx_id, step, payload, message, stored_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, record.TxID, record.Step, fmt.Sprintf("(%d bytes)", len(record.Payload)), record.Message, storedAt)
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return errors.Wrapf(err, "error generating uuid")
+	}
+	if _, err = db.db.Exec(query, id, record.TxID, record.Step, record.Payload, record.Message, storedAt.UTC()); err != nil {
+		return ttxDBError(err)
+	}
+	return nil
+}
+
+func (db *TransactionDB) GetTransactionSteps(txID string) ([]*driver.TransactionStepRecord, error) {
+	query, err := NewSelect("tx_id, step, payload, message, stored_at").From(db.table.TransactionSteps).Where("tx_id=$1").OrderBy("step ASC, stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, txID)
+	return db.queryTransactionSteps(query, txID)
+}
+
+func (db *TransactionDB) QueryInFlightTransactions() ([]*driver.TransactionStepRecord, error) {
+	// select the last step of each transaction, unless the transaction reached a final step
+	// or its token request has been confirmed or deleted.
+	// Transactions received from other nodes, whose last step is Distributed, are not selected.
+	steps, requests := db.table.TransactionSteps, db.table.Requests
+	lastStep, err := NewSelect("MAX(last.step)").
+		From(steps + " AS last").
+		Where(fmt.Sprintf("last.tx_id = %s.tx_id", steps)).
+		Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	query, err := NewSelect(
+		fmt.Sprintf("%s.tx_id, %s.step, %s.payload, %s.message, %s.stored_at", steps, steps, steps, steps, steps),
+	).From(steps, joinOnTxID(steps, requests)).Where(fmt.Sprintf(
+		"%s.step = (%s) AND %s.step < $1 AND %s.step <> $2 AND (%s.status IS NULL OR %s.status NOT IN ($3, $4))",
+		steps, lastStep, steps, steps, requests, requests,
+	)).OrderBy(steps + ".stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query)
+	return db.queryTransactionSteps(query, driver.Final, driver.Distributed, driver.Confirmed, driver.Deleted)
+}
+
+func (db *TransactionDB) queryTransactionSteps(query string, args ...any) ([]*driver.TransactionStepRecord, error) {
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var res []*driver.TransactionStepRecord
+	for rows.Next() {
+		var r driver.TransactionStepRecord
+		if err := rows.Scan(&r.TxID, &r.Step, &r.Payload, &r.Message, &r.Timestamp); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		res = append(res, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 func (db *TransactionDB) Close() error {
 	logger.Info("closing database")
 	err := db.db.Close()
@@ -343,12 +697,105 @@ func (db *TransactionDB) GetSchema() string {
 			stored_at TIMESTAMP NOT NULL
 		);
 		CREATE INDEX IF NOT EXISTS idx_tx_id_%s ON %s ( tx_id );
+
+		-- discrepancies
+		CREATE TABLE IF NOT EXISTS %s (
+			id CHAR(36) NOT NULL PRIMARY KEY,
+			tx_id TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			stored_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_tx_id_%s ON %s ( tx_id );
+
+		-- compliance decisions
+		CREATE TABLE IF NOT EXISTS %s (
+			id CHAR(36) NOT NULL PRIMARY KEY,
+			tx_id TEXT NOT NULL,
+			decision INT NOT NULL,
+			reason TEXT NOT NULL,
+			stored_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_tx_id_%s ON %s ( tx_id );
+
+		-- transaction steps
+		CREATE TABLE IF NOT EXISTS %s (
+			id CHAR(36) NOT NULL PRIMARY KEY,
+			tx_id TEXT NOT NULL,
+			step INT NOT NULL,
+			payload BYTEA,
+			message TEXT NOT NULL,
+			stored_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_tx_id_%s ON %s ( tx_id );
+
+		-- transaction attachments
+		CREATE TABLE IF NOT EXISTS %s (
+			id CHAR(36) NOT NULL PRIMARY KEY,
+			tx_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			payload BYTEA NOT NULL,
+			stored_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_tx_id_%s ON %s ( tx_id );
+
+		-- transaction distributions
+		CREATE TABLE IF NOT EXISTS %s (
+			id CHAR(36) NOT NULL PRIMARY KEY,
+			tx_id TEXT NOT NULL,
+			party BYTEA NOT NULL,
+			acknowledged BOOL NOT NULL,
+			attempts INT NOT NULL,
+			error TEXT NOT NULL,
+			stored_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_tx_id_%s ON %s ( tx_id );
+
+		-- idempotency keys
+		CREATE TABLE IF NOT EXISTS %s (
+			idempotency_key TEXT NOT NULL PRIMARY KEY,
+			tx_id TEXT NOT NULL,
+			nonce BYTEA NOT NULL,
+			creator BYTEA NOT NULL,
+			stored_at TIMESTAMP NOT NULL
+		);
+
+		-- withdrawal requests
+		CREATE TABLE IF NOT EXISTS %s (
+			id TEXT NOT NULL PRIMARY KEY,
+			requester BYTEA NOT NULL,
+			token_type TEXT NOT NULL,
+			amount BIGINT NOT NULL,
+			request BYTEA NOT NULL,
+			status INT NOT NULL,
+			message TEXT NOT NULL,
+			tx_id TEXT NOT NULL,
+			created_at TIMESTAMP NOT NULL,
+			expires_at TIMESTAMP,
+			updated_at TIMESTAMP NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_status_%s ON %s ( status );
+
+		-- withdrawal approvals
+		CREATE TABLE IF NOT EXISTS %s (
+			request_id TEXT NOT NULL,
+			approver TEXT NOT NULL,
+			stored_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (request_id, approver)
+		);
 		`,
 		db.table.Requests,
 		db.table.Transactions, db.table.Requests, db.table.Transactions, db.table.Transactions,
 		db.table.Movements, db.table.Requests, db.table.Movements, db.table.Movements,
 		db.table.Validations, db.table.Requests,
 		db.table.TransactionEndorseAck, db.table.TransactionEndorseAck, db.table.TransactionEndorseAck,
+		db.table.Discrepancies, db.table.Discrepancies, db.table.Discrepancies,
+		db.table.ComplianceDecisions, db.table.ComplianceDecisions, db.table.ComplianceDecisions,
+		db.table.TransactionSteps, db.table.TransactionSteps, db.table.TransactionSteps,
+		db.table.Attachments, db.table.Attachments, db.table.Attachments,
+		db.table.Distributions, db.table.Distributions, db.table.Distributions,
+		db.table.IdempotencyKeys,
+		db.table.WithdrawalRequests, db.table.WithdrawalRequests, db.table.WithdrawalRequests,
+		db.table.WithdrawalApprovals,
 	)
 }
 
@@ -472,11 +919,12 @@ func (t *TokenRequestIterator) Next() (*driver.TokenRequestRecord, error) {
 	}
 
 	var status int
-	// tx_id, request, metadata, status, stored_at
+	// tx_id, request, status, pp_hash
 	if err := t.txs.Scan(
 		&r.TxID,
 		&r.TokenRequest,
 		&status,
+		&r.PPHash,
 	); err != nil {
 		return nil, err
 	}
@@ -26,7 +26,7 @@ func initTransactionsDB(driverName common.SQLDriverType, dataSourceName, tablePr
 		DataSource:   dataSourceName,
 		TablePrefix:  tablePrefix,
 		CreateSchema: true,
-	}, NewTokenInterpreter(common.NewInterpreter()))
+	}, NewTokenInterpreter(common.NewInterpreter(), testJSONFieldFor(driverName)))
 	if err != nil {
 		return nil, err
 	}
@@ -41,6 +41,17 @@ func TestTransactionsSqlite(t *testing.T) {
 			t.Fatal(err)
 		}
 
+		t.Run(c.Name, func(xt *testing.T) {
+			defer Close(db)
+			c.Fn(xt, db)
+		})
+	}
+	for _, c := range dbtest.AuditTransactionDBCases {
+		db, err := initTransactionsDB(sql2.SQLite, fmt.Sprintf("file:%s?_pragma=busy_timeout(20000)", path.Join(tempDir, "db.sqlite")), c.Name, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
 		t.Run(c.Name, func(xt *testing.T) {
 			defer Close(db)
 			c.Fn(xt, db)
@@ -75,4 +86,14 @@ func TestTransactionsPostgres(t *testing.T) {
 			c.Fn(xt, db)
 		})
 	}
+	for _, c := range dbtest.AuditTransactionDBCases {
+		db, err := initTransactionsDB(sql2.Postgres, pgConnStr, c.Name, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(c.Name, func(xt *testing.T) {
+			defer Close(db)
+			c.Fn(xt, db)
+		})
+	}
 }
@@ -0,0 +1,173 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package common
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/pkg/errors"
+)
+
+const withdrawalRequestColumns = "id, requester, token_type, amount, request, status, message, tx_id, created_at, expires_at, updated_at"
+
+func (db *TransactionDB) AddWithdrawalRequest(record *driver.WithdrawalRequestRecord) error {
+	logger.Debugf("adding withdrawal request [%s]", record.ID)
+
+	createdAt := record.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	updatedAt := record.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = createdAt
+	}
+	query, err := NewInsertInto(db.table.WithdrawalRequests).Rows(withdrawalRequestColumns).Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, record.ID, record.TokenType, record.Amount, record.Status, createdAt, record.ExpiresAt)
+	_, err = db.db.Exec(query,
+		record.ID, record.Requester, record.TokenType, record.Amount, record.Request,
+		record.Status, record.Message, record.TxID,
+		createdAt.UTC(), nullTime(record.ExpiresAt), updatedAt.UTC(),
+	)
+	if err != nil {
+		return errors.Wrapf(err, "failed storing withdrawal request [%s]", record.ID)
+	}
+	return nil
+}
+
+func (db *TransactionDB) GetWithdrawalRequest(id string) (*driver.WithdrawalRequestRecord, error) {
+	query, err := NewSelect(withdrawalRequestColumns).From(db.table.WithdrawalRequests).Where("id = $1").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, id)
+	res, err := db.queryWithdrawalRequests(query, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res[0], nil
+}
+
+func (db *TransactionDB) QueryWithdrawalRequests(statuses ...driver.WithdrawalRequestStatus) ([]*driver.WithdrawalRequestRecord, error) {
+	var where string
+	var args []any
+	if len(statuses) != 0 {
+		placeholders := make([]string, len(statuses))
+		for i, status := range statuses {
+			args = append(args, status)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		where = fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ", "))
+	}
+	query, err := NewSelect(withdrawalRequestColumns).From(db.table.WithdrawalRequests).Where(where).OrderBy("created_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, args)
+	return db.queryWithdrawalRequests(query, args...)
+}
+
+func (db *TransactionDB) UpdateWithdrawalRequestStatus(id string, from, to driver.WithdrawalRequestStatus, message string, txID string) (bool, error) {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, message = $2, tx_id = $3, updated_at = $4 WHERE id = $5 AND status = $6;", db.table.WithdrawalRequests)
+	logger.Debug(query, to, message, txID, id, from)
+	res, err := db.db.Exec(query, to, message, txID, time.Now().UTC(), id, from)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed updating withdrawal request [%s]", id)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get affected rows")
+	}
+	return n == 1, nil
+}
+
+func (db *TransactionDB) AddWithdrawalApproval(id string, approver string) error {
+	logger.Debugf("adding approval of [%s] to withdrawal request [%s]", approver, id)
+
+	query, err := NewInsertInto(db.table.WithdrawalApprovals).Rows("request_id, approver, stored_at").Compile()
+	if err != nil {
+		return errors.Wrapf(err, "error compiling query")
+	}
+	logger.Debug(query, id, approver)
+	if _, err = db.db.Exec(query, id, approver, time.Now().UTC()); err != nil {
+		// approving twice has no effect
+		approvers, err2 := db.GetWithdrawalApprovals(id)
+		if err2 == nil {
+			for _, a := range approvers {
+				if a == approver {
+					return nil
+				}
+			}
+		}
+		return errors.Wrapf(err, "failed storing approval of [%s] to withdrawal request [%s]", approver, id)
+	}
+	return nil
+}
+
+func (db *TransactionDB) GetWithdrawalApprovals(id string) ([]string, error) {
+	query, err := NewSelect("approver").From(db.table.WithdrawalApprovals).Where("request_id = $1").OrderBy("stored_at ASC").Compile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed compiling query")
+	}
+	logger.Debug(query, id)
+	rows, err := db.db.Query(query, id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var approvers []string
+	for rows.Next() {
+		var approver string
+		if err := rows.Scan(&approver); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		approvers = append(approvers, approver)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return approvers, nil
+}
+
+func (db *TransactionDB) queryWithdrawalRequests(query string, args ...any) ([]*driver.WithdrawalRequestRecord, error) {
+	rows, err := db.db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query")
+	}
+	defer Close(rows)
+	var res []*driver.WithdrawalRequestRecord
+	for rows.Next() {
+		var r driver.WithdrawalRequestRecord
+		var requester []byte
+		var expiresAt sql.NullTime
+		if err := rows.Scan(
+			&r.ID, &requester, &r.TokenType, &r.Amount, &r.Request,
+			&r.Status, &r.Message, &r.TxID,
+			&r.CreatedAt, &expiresAt, &r.UpdatedAt,
+		); err != nil {
+			return nil, errors.Wrapf(err, "error querying db")
+		}
+		r.Requester = requester
+		if expiresAt.Valid {
+			r.ExpiresAt = expiresAt.Time
+		}
+		res = append(res, &r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
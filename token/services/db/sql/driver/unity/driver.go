@@ -71,7 +71,7 @@ func (t *IdentityDBDriver) OpenIdentityDB(cp dbdriver.ConfigProvider, tmsID toke
 	return t.identityDriver.Open(cp, tmsID)
 }
 
-func NewDBDrivers() (db.NamedDriver[dbdriver.TTXDBDriver], db.NamedDriver[dbdriver.TokenDBDriver], db.NamedDriver[dbdriver.TokenNotifierDriver], db.NamedDriver[dbdriver.TokenLockDBDriver], db.NamedDriver[dbdriver.AuditDBDriver], db.NamedDriver[dbdriver.IdentityDBDriver]) {
+func NewDBDrivers() (db.NamedDriver[dbdriver.TTXDBDriver], db.NamedDriver[dbdriver.TokenDBDriver], db.NamedDriver[dbdriver.TokenNotifierDriver], db.NamedDriver[dbdriver.TokenLockDBDriver], db.NamedDriver[dbdriver.AuditDBDriver], db.NamedDriver[dbdriver.IdentityDBDriver], db.NamedDriver[dbdriver.SchedulerDBDriver]) {
 	root := common.NewSQLDBOpener(optsKey, envVarKey)
 
 	return newUnityDriver[dbdriver.TokenTransactionDB, dbdriver.TTXDBDriver](root, constructors[dbdriver.TokenTransactionDB]{
@@ -109,5 +109,9 @@ func NewDBDrivers() (db.NamedDriver[dbdriver.TTXDBDriver], db.NamedDriver[dbdriv
 					sql3.Postgres: postgres.NewWalletDB,
 				},
 			},
-		}}
+		}},
+		newUnityDriver[dbdriver.SchedulerDB, dbdriver.SchedulerDBDriver](root, constructors[dbdriver.SchedulerDB]{
+			sql3.SQLite:   sqlite.NewSchedulerDB,
+			sql3.Postgres: postgres.NewSchedulerDB,
+		})
 }
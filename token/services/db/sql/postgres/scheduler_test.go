@@ -0,0 +1,45 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package postgres
+
+import (
+	"testing"
+
+	sql2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/dbtest"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/common"
+)
+
+func initSchedulerDB(dataSourceName, tablePrefix string, maxOpenConns int) (driver.SchedulerDB, error) {
+	d := common.NewSQLDBOpener("", "")
+	sqlDB, err := d.OpenSQLDB(sql2.Postgres, dataSourceName, maxOpenConns, false)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchedulerDB(sqlDB, common.NewDBOpts{
+		DataSource:   dataSourceName,
+		TablePrefix:  tablePrefix,
+		CreateSchema: true,
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	terminate, pgConnStr := common.StartPostgresContainer(t)
+	defer terminate()
+
+	for _, c := range dbtest.SchedulerDBCases {
+		schedulerDB, err := initSchedulerDB(pgConnStr, c.Name, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(c.Name, func(xt *testing.T) {
+			defer schedulerDB.Close()
+			c.Fn(xt, schedulerDB)
+		})
+	}
+}
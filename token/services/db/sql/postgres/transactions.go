@@ -8,7 +8,9 @@ package postgres
 
 import (
 	"database/sql"
+	"fmt"
 
+	common2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/common"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/postgres"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/common"
@@ -23,7 +25,7 @@ func OpenAuditTransactionDB(k common.Opts) (driver.AuditTransactionDB, error) {
 }
 
 func NewAuditTransactionDB(db *sql.DB, opts common.NewDBOpts) (driver.AuditTransactionDB, error) {
-	return common.NewAuditTransactionDB(db, opts, common.NewTokenInterpreter(postgres.NewInterpreter()))
+	return common.NewAuditTransactionDB(db, opts, common.NewTokenInterpreter(postgres.NewInterpreter(), jsonField))
 }
 
 func OpenTransactionDB(k common.Opts) (driver.TokenTransactionDB, error) {
@@ -35,5 +37,10 @@ func OpenTransactionDB(k common.Opts) (driver.TokenTransactionDB, error) {
 }
 
 func NewTransactionDB(db *sql.DB, opts common.NewDBOpts) (driver.TokenTransactionDB, error) {
-	return common.NewTransactionDB(db, opts, common.NewTokenInterpreter(postgres.NewInterpreter()))
+	return common.NewTransactionDB(db, opts, common.NewTokenInterpreter(postgres.NewInterpreter(), jsonField))
+}
+
+// jsonField extracts the value of a key of a JSON object as text
+func jsonField(column common2.FieldName, key string) common2.FieldName {
+	return fmt.Sprintf("(%s->>%s)", column, common.QuoteLiteral(key))
 }
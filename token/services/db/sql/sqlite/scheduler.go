@@ -0,0 +1,27 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sqlite
+
+import (
+	"database/sql"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/sqlite"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/common"
+)
+
+func OpenSchedulerDB(k common.Opts) (driver.SchedulerDB, error) {
+	db, err := sqlite.OpenDB(k.DataSource, k.MaxOpenConns, k.MaxIdleConns, k.MaxIdleTime, k.SkipPragmas)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchedulerDB(db, common.NewDBOptsFromOpts(k))
+}
+
+func NewSchedulerDB(db *sql.DB, k common.NewDBOpts) (driver.SchedulerDB, error) {
+	return common.NewSchedulerDB(db, k)
+}
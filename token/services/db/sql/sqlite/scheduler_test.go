@@ -0,0 +1,46 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package sqlite
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"testing"
+
+	sql2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/dbtest"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/common"
+)
+
+func initSchedulerDB(dataSourceName, tablePrefix string, maxOpenConns int) (driver.SchedulerDB, error) {
+	d := common.NewSQLDBOpener("", "")
+	sqlDB, err := d.OpenSQLDB(sql2.SQLite, dataSourceName, maxOpenConns, false)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchedulerDB(sqlDB, common.NewDBOpts{
+		DataSource:   dataSourceName,
+		TablePrefix:  tablePrefix,
+		CreateSchema: true,
+	})
+}
+
+func TestScheduler(t *testing.T) {
+	for _, c := range dbtest.SchedulerDBCases {
+		tempDir := filepath.Join(t.TempDir(), c.Name)
+		schedulerDB, err := initSchedulerDB(fmt.Sprintf("file:%s?_pragma=busy_timeout(20000)", path.Join(tempDir, "db.sqlite")), c.Name, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Run(c.Name, func(xt *testing.T) {
+			defer schedulerDB.Close()
+			c.Fn(xt, schedulerDB)
+		})
+	}
+}
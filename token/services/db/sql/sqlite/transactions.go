@@ -8,7 +8,9 @@ package sqlite
 
 import (
 	"database/sql"
+	"fmt"
 
+	common2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/common"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql/sqlite"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/common"
@@ -23,7 +25,7 @@ func OpenAuditTransactionDB(k common.Opts) (driver.AuditTransactionDB, error) {
 }
 
 func NewAuditTransactionDB(db *sql.DB, opts common.NewDBOpts) (driver.AuditTransactionDB, error) {
-	return common.NewAuditTransactionDB(db, opts, common.NewTokenInterpreter(sqlite.NewInterpreter()))
+	return common.NewAuditTransactionDB(db, opts, common.NewTokenInterpreter(sqlite.NewInterpreter(), jsonField))
 }
 
 func OpenTransactionDB(k common.Opts) (driver.TokenTransactionDB, error) {
@@ -35,5 +37,10 @@ func OpenTransactionDB(k common.Opts) (driver.TokenTransactionDB, error) {
 }
 
 func NewTransactionDB(db *sql.DB, opts common.NewDBOpts) (driver.TokenTransactionDB, error) {
-	return common.NewTransactionDB(db, opts, common.NewTokenInterpreter(sqlite.NewInterpreter()))
+	return common.NewTransactionDB(db, opts, common.NewTokenInterpreter(sqlite.NewInterpreter(), jsonField))
+}
+
+// jsonField extracts the value of a key of a JSON object with json_each, that does not need to escape the key in a JSON path
+func jsonField(column common2.FieldName, key string) common2.FieldName {
+	return fmt.Sprintf("(SELECT json_each.value FROM json_each(%s) WHERE json_each.key = %s)", column, common.QuoteLiteral(key))
 }
@@ -0,0 +1,85 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/pkg/errors"
+)
+
+// Client is the light client's end of the protocol, implemented by each transport
+type Client interface {
+	// Next returns the oldest pending request for the wallet of the client, waiting at most the passed time.
+	// ErrNoRequest is returned if no request arrives in time.
+	Next(ctx context.Context, wait time.Duration) (*SignRequest, error)
+	// Respond sends the passed response to the node
+	Respond(ctx context.Context, response *SignResponse) error
+}
+
+// Approver decides whether the light client signs the passed request, typically by showing its summary to the user.
+// The approver is invoked only on requests whose message to sign commits to their summary.
+// A non-nil error refuses the request, the error is sent back to the node.
+type Approver func(request *SignRequest) error
+
+// Serve answers the sign requests delivered by the passed client until the context is done.
+// The signatures are generated by the passed signer provider, after the approver accepts the request.
+// If approver is nil, all the requests are accepted.
+func Serve(ctx context.Context, client Client, sp ttx.SignerProvider, approver Approver, wait time.Duration) error {
+	for {
+		request, err := client.Next(ctx, wait)
+		if errors.Is(err, ErrNoRequest) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return errors.WithMessagef(err, "failed getting next sign request")
+		}
+		err = client.Respond(ctx, answer(request, sp, approver))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// the request could have expired meanwhile
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return errors.WithMessagef(err, "failed responding to sign request [%s]", request.ID)
+		}
+	}
+}
+
+func answer(request *SignRequest, sp ttx.SignerProvider, approver Approver) *SignResponse {
+	if time.Now().After(request.Expiry) {
+		return &SignResponse{ID: request.ID, Error: "request expired"}
+	}
+	// the light client cannot check the message to sign, only the summary it commits to
+	if request.Summary == nil {
+		return &SignResponse{ID: request.ID, Error: "sign request carries no summary"}
+	}
+	if err := request.Summary.VerifyMessage(request.Message); err != nil {
+		logger.Errorf("invalid summary in request [%s]: [%s]", request.ID, err)
+		return &SignResponse{ID: request.ID, Error: "summary does not match the message to sign"}
+	}
+	if approver != nil {
+		if err := approver(request); err != nil {
+			return &SignResponse{ID: request.ID, Error: err.Error()}
+		}
+	}
+	signer, err := sp.GetSigner(request.Party)
+	if err != nil {
+		logger.Errorf("failed getting signer for party [%s]: [%s]", request.Party, err)
+		return &SignResponse{ID: request.ID, Error: "no signer for party"}
+	}
+	sigma, err := signer.Sign(request.Message)
+	if err != nil {
+		logger.Errorf("failed signing request [%s]: [%s]", request.ID, err)
+		return &SignResponse{ID: request.ID, Error: "failed signing"}
+	}
+	return &SignResponse{ID: request.ID, Sigma: sigma}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ConfigKey is the configuration key of the external signer service
+	ConfigKey = "token.extsigner"
+
+	defaultTimeout = 5 * time.Minute
+	defaultMaxWait = time.Minute
+)
+
+// ConfigProvider gives access to the configuration of the node
+type ConfigProvider interface {
+	IsSet(key string) bool
+	UnmarshalKey(key string, rawVal interface{}) error
+	TranslatePath(path string) string
+}
+
+// TLSConfig is the TLS configuration of a listener
+type TLSConfig struct {
+	// Enabled turns TLS on
+	Enabled bool `yaml:"enabled,omitempty"`
+	// CertFile is the path of the PEM encoded certificate of the server
+	CertFile string `yaml:"certFile,omitempty"`
+	// KeyFile is the path of the PEM encoded private key of the server
+	KeyFile string `yaml:"keyFile,omitempty"`
+}
+
+// ListenerConfig is the configuration of the listener of a front-end
+type ListenerConfig struct {
+	// Address is the address to listen on, the front-end is disabled if empty
+	Address string `yaml:"address,omitempty"`
+	// TLS is the TLS configuration of the listener. It is required, unless Insecure is set.
+	TLS TLSConfig `yaml:"tls,omitempty"`
+	// Insecure allows the listener to run without TLS, sending the bearer tokens of the light clients in clear.
+	// It is meant for tests, or for a listener reachable only through a TLS-terminating proxy.
+	Insecure bool `yaml:"insecure,omitempty"`
+}
+
+// ClientConfig binds a watch-only wallet to the credential of its light client
+type ClientConfig struct {
+	// Wallet is the id of the watch-only wallet
+	Wallet string `yaml:"wallet"`
+	// Token is the bearer token the light client authenticates with
+	Token string `yaml:"token"`
+}
+
+// Config is the configuration of the external signer service
+type Config struct {
+	// Enabled starts the configured front-ends when the token SDK starts
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Timeout is the time the node waits for a light client to answer a sign request
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// MaxWait caps the time a light client can wait for the next sign request in a single call
+	MaxWait time.Duration `yaml:"maxWait,omitempty"`
+	// HTTP is the listener of the HTTP/JSON front-end
+	HTTP ListenerConfig `yaml:"http,omitempty"`
+	// GRPC is the listener of the gRPC front-end
+	GRPC ListenerConfig `yaml:"grpc,omitempty"`
+	// Clients are the light clients allowed to connect
+	Clients []ClientConfig `yaml:"clients,omitempty"`
+}
+
+// NewConfig loads the configuration of the external signer service, using the defaults for the missing values
+func NewConfig(cp ConfigProvider) (*Config, error) {
+	c := &Config{}
+	if cp.IsSet(ConfigKey) {
+		if err := cp.UnmarshalKey(ConfigKey, c); err != nil {
+			return nil, errors.Wrapf(err, "invalid config for key [%s]", ConfigKey)
+		}
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.MaxWait <= 0 {
+		c.MaxWait = defaultMaxWait
+	}
+	for _, l := range []*ListenerConfig{&c.HTTP, &c.GRPC} {
+		if !l.TLS.Enabled {
+			if len(l.Address) != 0 && !l.Insecure {
+				return nil, errors.Errorf("tls disabled for [%s], set insecure to accept bearer tokens in clear", l.Address)
+			}
+			continue
+		}
+		if len(l.TLS.CertFile) == 0 || len(l.TLS.KeyFile) == 0 {
+			return nil, errors.Errorf("tls enabled for [%s] but certificate or key missing", l.Address)
+		}
+		l.TLS.CertFile = cp.TranslatePath(l.TLS.CertFile)
+		l.TLS.KeyFile = cp.TranslatePath(l.TLS.KeyFile)
+	}
+	for i, client := range c.Clients {
+		if len(client.Wallet) == 0 || len(client.Token) == 0 {
+			return nil, errors.Errorf("client [%d] is missing the wallet or the token", i)
+		}
+	}
+	return c, nil
+}
+
+// Tokens returns the map from wallet id to bearer token of the configured clients
+func (c *Config) Tokens() map[string]string {
+	tokens := make(map[string]string, len(c.Clients))
+	for _, client := range c.Clients {
+		tokens[client.Wallet] = client.Token
+	}
+	return tokens
+}
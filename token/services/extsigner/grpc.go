@@ -0,0 +1,209 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The gRPC front-end exchanges the same JSON messages of the HTTP one, by means of a JSON codec
+// registered under a private name. The client forces it on each call, as GRPCClient does,
+// and the server must be created with GRPCServerCodec.
+
+const (
+	grpcServiceName = "extsigner.ExternalSigner"
+	grpcCodecName   = "extsigner-json"
+	nextMethod      = "/" + grpcServiceName + "/Next"
+	respondMethod   = "/" + grpcServiceName + "/Respond"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+func (jsonCodec) Name() string { return grpcCodecName }
+
+// GRPCServerCodec returns the server option installing the codec of the gRPC front-end.
+// The codec applies to all the services of the server, therefore the server should be dedicated to the front-end.
+func GRPCServerCodec() grpc.ServerOption {
+	return grpc.ForceServerCodec(jsonCodec{})
+}
+
+// NextMessage is the gRPC request to get the next sign request of a wallet
+type NextMessage struct {
+	Wallet string        `json:"wallet"`
+	Wait   time.Duration `json:"wait"`
+}
+
+// NextReply is the gRPC response carrying the next sign request, nil if none arrived in time
+type NextReply struct {
+	Request *SignRequest `json:"request,omitempty"`
+}
+
+// RespondMessage is the gRPC request carrying the response to a sign request
+type RespondMessage struct {
+	Wallet   string        `json:"wallet"`
+	Response *SignResponse `json:"response"`
+}
+
+// RespondReply is the empty gRPC response to RespondMessage
+type RespondReply struct{}
+
+type grpcHandler interface {
+	Next(ctx context.Context, in *NextMessage) (*NextReply, error)
+	Respond(ctx context.Context, in *RespondMessage) (*RespondReply, error)
+}
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: grpcServiceName,
+	HandlerType: (*grpcHandler)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Next",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &NextMessage{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(grpcHandler).Next(ctx, in)
+				}
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: nextMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(grpcHandler).Next(ctx, req.(*NextMessage))
+				})
+			},
+		},
+		{
+			MethodName: "Respond",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := &RespondMessage{}
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				if interceptor == nil {
+					return srv.(grpcHandler).Respond(ctx, in)
+				}
+				return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: respondMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(grpcHandler).Respond(ctx, req.(*RespondMessage))
+				})
+			},
+		},
+	},
+}
+
+// RegisterGRPCServer registers the gRPC front-end of the passed service on the passed server,
+// which must have been created with GRPCServerCodec.
+// Each call must carry the metadata 'authorization: Bearer <token>' with a credential
+// the authenticator accepts for the wallet. The wait of Next is capped by maxWait.
+func RegisterGRPCServer(server *grpc.Server, service *Service, auth Authenticator, maxWait time.Duration) {
+	server.RegisterService(&grpcServiceDesc, &grpcServer{service: service, auth: auth, maxWait: maxWait})
+}
+
+type grpcServer struct {
+	service *Service
+	auth    Authenticator
+	maxWait time.Duration
+}
+
+func (s *grpcServer) Next(ctx context.Context, in *NextMessage) (*NextReply, error) {
+	if err := s.authenticate(ctx, in.Wallet); err != nil {
+		return nil, err
+	}
+	wait := in.Wait
+	if wait > s.maxWait {
+		wait = s.maxWait
+	}
+	request, err := s.service.Next(ctx, in.Wallet, wait)
+	if errors.Is(err, ErrNoRequest) {
+		return &NextReply{}, nil
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &NextReply{Request: request}, nil
+}
+
+func (s *grpcServer) Respond(ctx context.Context, in *RespondMessage) (*RespondReply, error) {
+	if err := s.authenticate(ctx, in.Wallet); err != nil {
+		return nil, err
+	}
+	if err := s.service.Respond(in.Wallet, in.Response); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &RespondReply{}, nil
+}
+
+func (s *grpcServer) authenticate(ctx context.Context, wallet string) error {
+	var credential string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("authorization"); len(values) != 0 {
+			credential, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+	}
+	if err := s.auth.Authenticate(wallet, credential); err != nil {
+		logger.Warnf("rejected grpc request for wallet [%s]: [%s]", wallet, err)
+		return status.Error(codes.Unauthenticated, ErrUnauthorized.Error())
+	}
+	return nil
+}
+
+// GRPCClient is the Client of a light client talking to the gRPC front-end of the node
+type GRPCClient struct {
+	conn   grpc.ClientConnInterface
+	wallet string
+	token  string
+}
+
+// NewGRPCClient returns a new GRPCClient for the passed wallet, that authenticates with the passed bearer token
+func NewGRPCClient(conn grpc.ClientConnInterface, wallet string, token string) *GRPCClient {
+	return &GRPCClient{conn: conn, wallet: wallet, token: token}
+}
+
+func (c *GRPCClient) Next(ctx context.Context, wait time.Duration) (*SignRequest, error) {
+	out := &NextReply{}
+	if err := c.invoke(ctx, nextMethod, &NextMessage{Wallet: c.wallet, Wait: wait}, out); err != nil {
+		return nil, err
+	}
+	if out.Request == nil {
+		return nil, ErrNoRequest
+	}
+	return out.Request, nil
+}
+
+func (c *GRPCClient) Respond(ctx context.Context, response *SignResponse) error {
+	return c.invoke(ctx, respondMethod, &RespondMessage{Wallet: c.wallet, Response: response}, &RespondReply{})
+}
+
+func (c *GRPCClient) invoke(ctx context.Context, method string, in interface{}, out interface{}) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.token)
+	err := c.conn.Invoke(ctx, method, in, out, grpc.ForceCodec(jsonCodec{}))
+	if err == nil {
+		return nil
+	}
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return ErrUnauthorized
+	case codes.NotFound:
+		return ErrNotFound
+	default:
+		return errors.Wrapf(err, "failed calling [%s]", method)
+	}
+}
@@ -0,0 +1,221 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxBodySize is the maximum size of the body of an HTTP request
+	maxBodySize = 1 << 20
+	// waitParam is the query parameter carrying the time a client is willing to wait for a request
+	waitParam = "wait"
+)
+
+type httpError struct {
+	Error string `json:"error"`
+}
+
+// NewHTTPHandler returns the HTTP/JSON front-end of the passed service.
+// It serves:
+//   - GET /v1/wallets/{wallet}/requests?wait=<duration>, that returns the oldest pending SignRequest
+//     of the wallet, waiting for it at most the passed duration, capped by maxWait; 204 if none arrives;
+//   - POST /v1/wallets/{wallet}/responses, whose body is the SignResponse to a pending request.
+//
+// Each request must carry the header 'Authorization: Bearer <token>' with a credential
+// the authenticator accepts for the wallet.
+func NewHTTPHandler(service *Service, auth Authenticator, maxWait time.Duration) http.Handler {
+	h := &httpHandler{service: service, auth: auth, maxWait: maxWait}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/wallets/{wallet}/requests", h.next)
+	mux.HandleFunc("POST /v1/wallets/{wallet}/responses", h.respond)
+	return mux
+}
+
+type httpHandler struct {
+	service *Service
+	auth    Authenticator
+	maxWait time.Duration
+}
+
+func (h *httpHandler) next(w http.ResponseWriter, r *http.Request) {
+	wallet, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	var wait time.Duration
+	if v := r.URL.Query().Get(waitParam); len(v) != 0 {
+		var err error
+		wait, err = time.ParseDuration(v)
+		if err != nil || wait < 0 {
+			writeHTTPError(w, http.StatusBadRequest, errors.Errorf("invalid wait [%s]", v))
+			return
+		}
+	}
+	if wait > h.maxWait {
+		wait = h.maxWait
+	}
+	request, err := h.service.Next(r.Context(), wallet, wait)
+	if errors.Is(err, ErrNoRequest) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err != nil {
+		writeHTTPError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeHTTPJSON(w, http.StatusOK, request)
+}
+
+func (h *httpHandler) respond(w http.ResponseWriter, r *http.Request) {
+	wallet, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+	response := &SignResponse{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(response); err != nil {
+		writeHTTPError(w, http.StatusBadRequest, errors.Wrapf(err, "invalid sign response"))
+		return
+	}
+	if err := h.service.Respond(wallet, response); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			writeHTTPError(w, http.StatusNotFound, err)
+			return
+		}
+		writeHTTPError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *httpHandler) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	wallet := r.PathValue("wallet")
+	credential, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		credential = ""
+	}
+	if err := h.auth.Authenticate(wallet, credential); err != nil {
+		logger.Warnf("rejected http request for wallet [%s] from [%s]: [%s]", wallet, r.RemoteAddr, err)
+		writeHTTPError(w, http.StatusUnauthorized, ErrUnauthorized)
+		return "", false
+	}
+	return wallet, true
+}
+
+func writeHTTPJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("failed writing http response: [%s]", err)
+	}
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	writeHTTPJSON(w, status, &httpError{Error: err.Error()})
+}
+
+// HTTPClient is the Client of a light client talking to the HTTP/JSON front-end of the node
+type HTTPClient struct {
+	client  *http.Client
+	baseURL string
+	wallet  string
+	token   string
+}
+
+// NewHTTPClient returns a new HTTPClient for the passed wallet, that authenticates with the passed bearer token.
+// If client is nil, http.DefaultClient is used. The client's timeout must exceed the wait passed to Next.
+func NewHTTPClient(client *http.Client, baseURL string, wallet string, token string) *HTTPClient {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPClient{
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		wallet:  wallet,
+		token:   token,
+	}
+}
+
+func (c *HTTPClient) Next(ctx context.Context, wait time.Duration) (*SignRequest, error) {
+	u := fmt.Sprintf("%s/v1/wallets/%s/requests?%s=%s", c.baseURL, url.PathEscape(c.wallet), waitParam, url.QueryEscape(wait.String()))
+	res, err := c.do(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		request := &SignRequest{}
+		if err := json.NewDecoder(res.Body).Decode(request); err != nil {
+			return nil, errors.Wrapf(err, "failed decoding sign request")
+		}
+		return request, nil
+	case http.StatusNoContent:
+		return nil, ErrNoRequest
+	default:
+		return nil, readHTTPError(res)
+	}
+}
+
+func (c *HTTPClient) Respond(ctx context.Context, response *SignResponse) error {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return errors.Wrapf(err, "failed encoding sign response")
+	}
+	u := fmt.Sprintf("%s/v1/wallets/%s/responses", c.baseURL, url.PathEscape(c.wallet))
+	res, err := c.do(ctx, http.MethodPost, u, raw)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return readHTTPError(res)
+	}
+	return nil
+}
+
+func (c *HTTPClient) do(ctx context.Context, method string, u string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating http request")
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed calling [%s]", u)
+	}
+	return res, nil
+}
+
+func readHTTPError(res *http.Response) error {
+	switch res.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	raw, _ := io.ReadAll(io.LimitReader(res.Body, maxBodySize))
+	e := &httpError{}
+	if err := json.Unmarshal(raw, e); err != nil || len(e.Error) == 0 {
+		e.Error = strings.TrimSpace(string(raw))
+	}
+	return errors.Errorf("unexpected status [%d]: %s", res.StatusCode, e.Error)
+}
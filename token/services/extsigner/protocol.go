@@ -0,0 +1,90 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrUnauthorized is returned when the credential presented by a client does not grant access to the wallet
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound is returned when a response refers to a request that is not pending
+	ErrNotFound = errors.New("sign request not found")
+	// ErrNoRequest is returned when no request is pending for the wallet before the wait time elapses
+	ErrNoRequest = errors.New("no pending sign request")
+)
+
+// SignRequest is a request of signature addressed to the external signer of a wallet.
+// It is the message the node delivers to the light client, whatever the transport.
+type SignRequest struct {
+	// ID identifies the request, the response must carry the same id
+	ID string `json:"id"`
+	// Wallet is the id of the watch-only wallet the party belongs to
+	Wallet string `json:"wallet"`
+	// TxID is the id of the transaction the signature is for
+	TxID string `json:"tx_id,omitempty"`
+	// Party is the identity that must sign
+	Party view.Identity `json:"party"`
+	// Message is the message to sign
+	Message []byte `json:"message"`
+	// Summary describes the token request the signature is for, nil if not available
+	Summary *token.RequestSummary `json:"summary,omitempty"`
+	// Expiry is the time after which the node stops waiting for the response
+	Expiry time.Time `json:"expiry"`
+}
+
+// SignResponse is the response of the light client to a SignRequest.
+// Either Sigma or Error is set.
+type SignResponse struct {
+	// ID is the id of the request this response is for
+	ID string `json:"id"`
+	// Sigma is the signature on the message of the request
+	Sigma []byte `json:"sigma,omitempty"`
+	// Error is the reason why the light client refused to sign
+	Error string `json:"error,omitempty"`
+}
+
+// Authenticator checks the credentials presented by the light clients
+type Authenticator interface {
+	// Authenticate returns nil if the passed credential grants access to the sign requests of the passed wallet
+	Authenticate(wallet string, credential string) error
+}
+
+// TokenAuthenticator authenticates light clients by means of a bearer token per wallet
+type TokenAuthenticator struct {
+	digests map[string][]byte
+}
+
+// NewTokenAuthenticator returns a new TokenAuthenticator for the passed map from wallet id to bearer token.
+// Only the digests of the tokens are kept in memory.
+func NewTokenAuthenticator(tokens map[string]string) *TokenAuthenticator {
+	digests := make(map[string][]byte, len(tokens))
+	for wallet, token := range tokens {
+		digest := sha256.Sum256([]byte(token))
+		digests[wallet] = digest[:]
+	}
+	return &TokenAuthenticator{digests: digests}
+}
+
+func (a *TokenAuthenticator) Authenticate(wallet string, credential string) error {
+	expected, ok := a.digests[wallet]
+	if !ok || len(credential) == 0 {
+		return ErrUnauthorized
+	}
+	digest := sha256.Sum256([]byte(credential))
+	if subtle.ConstantTimeCompare(expected, digest[:]) != 1 {
+		return ErrUnauthorized
+	}
+	return nil
+}
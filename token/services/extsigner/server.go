@@ -0,0 +1,127 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// Server runs the HTTP/JSON and gRPC front-ends of the external signer service, as configured
+type Server struct {
+	config  *Config
+	service *Service
+}
+
+// NewServer returns a new Server for the configuration of the node
+func NewServer(cp ConfigProvider) (*Server, error) {
+	c, err := NewConfig(cp)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{config: c, service: NewService(c.Timeout)}, nil
+}
+
+// Service returns the service the front-ends give access to
+func (s *Server) Service() *Service {
+	return s.service
+}
+
+// Start starts the configured front-ends, if enabled. They stop when the passed context is done.
+func (s *Server) Start(ctx context.Context) error {
+	if !s.config.Enabled {
+		return nil
+	}
+	auth := NewTokenAuthenticator(s.config.Tokens())
+	if len(s.config.HTTP.Address) != 0 {
+		if err := s.startHTTP(ctx, auth); err != nil {
+			return errors.WithMessagef(err, "failed starting http front-end")
+		}
+	}
+	if len(s.config.GRPC.Address) != 0 {
+		if err := s.startGRPC(ctx, auth); err != nil {
+			return errors.WithMessagef(err, "failed starting grpc front-end")
+		}
+	}
+	return nil
+}
+
+func (s *Server) startHTTP(ctx context.Context, auth Authenticator) error {
+	lis, err := s.listen(s.config.HTTP)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           NewHTTPHandler(s.service, auth, s.config.MaxWait),
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      s.config.MaxWait + 10*time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		if err := server.Close(); err != nil {
+			logger.Warnf("failed closing http front-end: [%s]", err)
+		}
+	}()
+	go func() {
+		if err := server.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("http front-end stopped: [%s]", err)
+		}
+	}()
+	logger.Infof("external signer http front-end listening on [%s]", lis.Addr())
+	return nil
+}
+
+func (s *Server) startGRPC(ctx context.Context, auth Authenticator) error {
+	opts := []grpc.ServerOption{GRPCServerCodec()}
+	if s.config.GRPC.TLS.Enabled {
+		cert, err := tls.LoadX509KeyPair(s.config.GRPC.TLS.CertFile, s.config.GRPC.TLS.KeyFile)
+		if err != nil {
+			return errors.Wrapf(err, "failed loading tls key pair")
+		}
+		opts = append(opts, grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+	}
+	lis, err := net.Listen("tcp", s.config.GRPC.Address)
+	if err != nil {
+		return errors.Wrapf(err, "failed listening on [%s]", s.config.GRPC.Address)
+	}
+	server := grpc.NewServer(opts...)
+	RegisterGRPCServer(server, s.service, auth, s.config.MaxWait)
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			logger.Errorf("grpc front-end stopped: [%s]", err)
+		}
+	}()
+	logger.Infof("external signer grpc front-end listening on [%s]", lis.Addr())
+	return nil
+}
+
+func (s *Server) listen(c ListenerConfig) (net.Listener, error) {
+	lis, err := net.Listen("tcp", c.Address)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed listening on [%s]", c.Address)
+	}
+	if !c.TLS.Enabled {
+		return lis, nil
+	}
+	cert, err := tls.LoadX509KeyPair(c.TLS.CertFile, c.TLS.KeyFile)
+	if err != nil {
+		_ = lis.Close()
+		return nil, errors.Wrapf(err, "failed loading tls key pair")
+	}
+	return tls.NewListener(lis, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}), nil
+}
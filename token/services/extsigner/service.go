@@ -0,0 +1,221 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"context"
+	"encoding/hex"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.MustGetLogger("token-sdk.services.extsigner")
+
+type pendingRequest struct {
+	request  *SignRequest
+	response chan *SignResponse
+}
+
+// Service queues the sign requests addressed to the external signers of the watch-only wallets
+// and hands them over to the light clients, independently of the transport used to reach them.
+// A request stays pending until the light client responds or the timeout expires.
+type Service struct {
+	timeout time.Duration
+
+	mutex   sync.Mutex
+	pending map[string]*pendingRequest
+	queues  map[string][]string
+	notify  map[string]chan struct{}
+}
+
+// NewService returns a new Service that waits for each signature at most the passed timeout
+func NewService(timeout time.Duration) *Service {
+	return &Service{
+		timeout: timeout,
+		pending: map[string]*pendingRequest{},
+		queues:  map[string][]string{},
+		notify:  map[string]chan struct{}{},
+	}
+}
+
+// Signer returns the external wallet signer for the passed wallet, to be passed to ttx.WithExternalWalletSigner
+func (s *Service) Signer(wallet string) *Signer {
+	return &Signer{service: s, wallet: wallet}
+}
+
+// Next returns the oldest pending request for the passed wallet, waiting at most the passed time for one to arrive.
+// The same request is returned until it is responded or it expires.
+// ErrNoRequest is returned if no request is pending when the wait time elapses.
+func (s *Service) Next(ctx context.Context, wallet string, wait time.Duration) (*SignRequest, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		s.mutex.Lock()
+		if queue := s.queues[wallet]; len(queue) != 0 {
+			request := s.pending[queue[0]].request
+			s.mutex.Unlock()
+			return request, nil
+		}
+		notify, ok := s.notify[wallet]
+		if !ok {
+			notify = make(chan struct{})
+			s.notify[wallet] = notify
+		}
+		s.mutex.Unlock()
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return nil, ErrNoRequest
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Pending returns the pending requests for the passed wallet, oldest first
+func (s *Service) Pending(wallet string) []*SignRequest {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var res []*SignRequest
+	for _, id := range s.queues[wallet] {
+		res = append(res, s.pending[id].request)
+	}
+	return res
+}
+
+// Respond delivers the passed response, coming from the light client of the passed wallet, to the waiting signer.
+// ErrNotFound is returned if the response does not match any pending request of the wallet.
+func (s *Service) Respond(wallet string, response *SignResponse) error {
+	if response == nil {
+		return errors.New("nil response")
+	}
+	s.mutex.Lock()
+	p, ok := s.pending[response.ID]
+	if !ok || p.request.Wallet != wallet {
+		s.mutex.Unlock()
+		return errors.Wrapf(ErrNotFound, "request [%s] of wallet [%s]", response.ID, wallet)
+	}
+	s.remove(p.request)
+	s.mutex.Unlock()
+
+	p.response <- response
+	return nil
+}
+
+func (s *Service) sign(request *SignRequest) ([]byte, error) {
+	id, err := ttx.GetRandomNonce()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed generating request id")
+	}
+	request.ID = hex.EncodeToString(id)
+	request.Expiry = time.Now().Add(s.timeout)
+	p := &pendingRequest{request: request, response: make(chan *SignResponse, 1)}
+
+	s.mutex.Lock()
+	s.pending[request.ID] = p
+	s.queues[request.Wallet] = append(s.queues[request.Wallet], request.ID)
+	if notify, ok := s.notify[request.Wallet]; ok {
+		close(notify)
+		delete(s.notify, request.Wallet)
+	}
+	s.mutex.Unlock()
+	logger.Debugf("sign request [%s] queued for wallet [%s], party [%s]", request.ID, request.Wallet, request.Party)
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case response := <-p.response:
+		if len(response.Error) != 0 {
+			return nil, errors.Errorf("sign request [%s] refused by the external signer of wallet [%s]: %s", request.ID, request.Wallet, response.Error)
+		}
+		if len(response.Sigma) == 0 {
+			return nil, errors.Errorf("sign request [%s] answered with an empty signature", request.ID)
+		}
+		return response.Sigma, nil
+	case <-timer.C:
+		s.mutex.Lock()
+		s.remove(request)
+		s.mutex.Unlock()
+		// the response could have been delivered meanwhile
+		select {
+		case response := <-p.response:
+			if len(response.Error) == 0 && len(response.Sigma) != 0 {
+				return response.Sigma, nil
+			}
+		default:
+		}
+		return nil, errors.Errorf("timeout waiting for the external signer of wallet [%s] to answer sign request [%s]", request.Wallet, request.ID)
+	}
+}
+
+// remove removes the passed request from the pending ones, the caller must hold the lock
+func (s *Service) remove(request *SignRequest) {
+	delete(s.pending, request.ID)
+	queue := s.queues[request.Wallet]
+	for i, id := range queue {
+		if id == request.ID {
+			queue = append(queue[:i:i], queue[i+1:]...)
+			break
+		}
+	}
+	if len(queue) == 0 {
+		delete(s.queues, request.Wallet)
+		return
+	}
+	s.queues[request.Wallet] = queue
+}
+
+// Signer is the ttx.ExternalWalletSigner of a watch-only wallet whose keys are held by a light client
+type Signer struct {
+	service *Service
+	wallet  string
+}
+
+// Sign asks the light client to sign the passed message.
+// The request carries no summary, therefore the light clients served by Serve refuse it.
+func (s *Signer) Sign(party view.Identity, message []byte) ([]byte, error) {
+	return s.service.sign(&SignRequest{
+		Wallet:  s.wallet,
+		Party:   party,
+		Message: message,
+	})
+}
+
+// SignRequest asks the light client to sign the message of the passed request, the summary is forwarded too
+func (s *Signer) SignRequest(request *ttx.ExternalWalletSignRequest) ([]byte, error) {
+	return s.service.sign(&SignRequest{
+		Wallet:  s.wallet,
+		TxID:    request.TxID,
+		Party:   request.Party,
+		Message: request.Message,
+		Summary: request.Summary,
+	})
+}
+
+// Done does nothing, the light client is not bound to a single transaction
+func (s *Signer) Done() error {
+	return nil
+}
+
+var serviceType = reflect.TypeOf((*Service)(nil))
+
+// GetService returns the external signer service from the passed service provider
+func GetService(sp token.ServiceProvider) (*Service, error) {
+	s, err := sp.GetService(serviceType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get external signer service")
+	}
+	return s.(*Service), nil
+}
@@ -0,0 +1,250 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package extsigner
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var alice = view.Identity("alice")
+
+type signer struct {
+	party view.Identity
+}
+
+func (s *signer) Sign(message []byte) ([]byte, error) {
+	return append(append([]byte{}, s.party...), message...), nil
+}
+
+type signerProvider struct{}
+
+func (signerProvider) GetSigner(party view.Identity) (token.Signer, error) {
+	if !party.Equal(alice) {
+		return nil, errors.Errorf("unknown party [%s]", party)
+	}
+	return &signer{party: party}, nil
+}
+
+func summary() *token.RequestSummary {
+	return summaryFor("tx1")
+}
+
+func summaryFor(anchor string) *token.RequestSummary {
+	return &token.RequestSummary{
+		Anchor:  anchor,
+		Inputs:  []*token.SummaryToken{{EnrollmentID: "alice", Type: "USD", Quantity: "10"}},
+		Outputs: []*token.SummaryToken{{EnrollmentID: "bob", Type: "USD", Quantity: "10"}},
+	}
+}
+
+func TestServiceSign(t *testing.T) {
+	s := NewService(time.Minute)
+	ctx := context.Background()
+
+	_, err := s.Next(ctx, "alice", 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrNoRequest)
+
+	res := make(chan []byte)
+	go func() {
+		sigma, err := s.Signer("alice").SignRequest(&ttx.ExternalWalletSignRequest{TxID: "tx1", Party: alice, Message: []byte("msg"), Summary: summary()})
+		assert.NoError(t, err)
+		res <- sigma
+	}()
+
+	request, err := s.Next(ctx, "alice", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", request.Wallet)
+	assert.Equal(t, "tx1", request.TxID)
+	assert.Equal(t, []byte("msg"), request.Message)
+	assert.Equal(t, summary(), request.Summary)
+	assert.True(t, request.Expiry.After(time.Now()))
+	assert.Len(t, s.Pending("alice"), 1)
+	assert.Empty(t, s.Pending("bob"))
+
+	// the request is delivered again until responded
+	again, err := s.Next(ctx, "alice", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, request.ID, again.ID)
+
+	// only the client of the wallet can respond
+	assert.ErrorIs(t, s.Respond("bob", &SignResponse{ID: request.ID, Sigma: []byte("sigma")}), ErrNotFound)
+	assert.NoError(t, s.Respond("alice", &SignResponse{ID: request.ID, Sigma: []byte("sigma")}))
+	assert.Equal(t, []byte("sigma"), <-res)
+	assert.Empty(t, s.Pending("alice"))
+	assert.ErrorIs(t, s.Respond("alice", &SignResponse{ID: request.ID, Sigma: []byte("sigma")}), ErrNotFound)
+}
+
+func TestServiceRefusedAndTimeout(t *testing.T) {
+	s := NewService(100 * time.Millisecond)
+	ctx := context.Background()
+
+	res := make(chan error)
+	go func() {
+		_, err := s.Signer("alice").Sign(alice, []byte("msg"))
+		res <- err
+	}()
+	request, err := s.Next(ctx, "alice", time.Second)
+	assert.NoError(t, err)
+	assert.Nil(t, request.Summary)
+	assert.NoError(t, s.Respond("alice", &SignResponse{ID: request.ID, Error: "not today"}))
+	assert.ErrorContains(t, <-res, "refused by the external signer of wallet [alice]: not today")
+
+	go func() {
+		_, err := s.Signer("alice").Sign(alice, []byte("msg"))
+		res <- err
+	}()
+	assert.ErrorContains(t, <-res, "timeout waiting for the external signer of wallet [alice]")
+	assert.Empty(t, s.Pending("alice"))
+}
+
+func TestTokenAuthenticator(t *testing.T) {
+	auth := NewTokenAuthenticator(map[string]string{"alice": "secret"})
+	assert.NoError(t, auth.Authenticate("alice", "secret"))
+	assert.ErrorIs(t, auth.Authenticate("alice", "wrong"), ErrUnauthorized)
+	assert.ErrorIs(t, auth.Authenticate("alice", ""), ErrUnauthorized)
+	assert.ErrorIs(t, auth.Authenticate("bob", "secret"), ErrUnauthorized)
+}
+
+func TestHTTP(t *testing.T) {
+	s := NewService(time.Minute)
+	server := httptest.NewServer(NewHTTPHandler(s, NewTokenAuthenticator(map[string]string{"alice": "secret"}), time.Second))
+	defer server.Close()
+
+	testClient(t, s, NewHTTPClient(server.Client(), server.URL, "alice", "secret"), NewHTTPClient(server.Client(), server.URL, "alice", "wrong"))
+
+	res, err := http.Get(server.URL + "/v1/wallets/alice/requests?wait=forever")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	assert.NoError(t, res.Body.Close())
+}
+
+func TestGRPC(t *testing.T) {
+	s := NewService(time.Minute)
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(GRPCServerCodec())
+	RegisterGRPCServer(server, s, NewTokenAuthenticator(map[string]string{"alice": "secret"}), time.Second)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	testClient(t, s, NewGRPCClient(conn, "alice", "secret"), NewGRPCClient(conn, "alice", "wrong"))
+}
+
+// boundMessage returns a message to sign that commits to the passed summary
+func boundMessage(t *testing.T, summary *token.RequestSummary) []byte {
+	h, err := summary.Hash()
+	assert.NoError(t, err)
+	raw, err := (&driver.TokenRequest{Transfers: [][]byte{[]byte("transfer")}, SummaryHash: h}).Bytes()
+	assert.NoError(t, err)
+	return append(raw, []byte(summary.Anchor)...)
+}
+
+// testClient checks that the passed client can serve the sign requests of the service
+// while the passed unauthorized client is rejected
+func testClient(t *testing.T, s *Service, client Client, unauthorized Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := unauthorized.Next(ctx, 0)
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = client.Next(ctx, 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrNoRequest)
+	assert.ErrorIs(t, client.Respond(ctx, &SignResponse{ID: "unknown", Sigma: []byte("sigma")}), ErrNotFound)
+
+	var approved []*SignRequest
+	served := make(chan error, 1)
+	go func() {
+		served <- Serve(ctx, client, signerProvider{}, func(request *SignRequest) error {
+			approved = append(approved, request)
+			if request.TxID == "tx2" {
+				return errors.New("not today")
+			}
+			return nil
+		}, 100*time.Millisecond)
+	}()
+
+	msg := boundMessage(t, summary())
+	sigma, err := s.Signer("alice").SignRequest(&ttx.ExternalWalletSignRequest{TxID: "tx1", Party: alice, Message: msg, Summary: summary()})
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte("alice"), msg...), sigma)
+
+	_, err = s.Signer("alice").SignRequest(&ttx.ExternalWalletSignRequest{TxID: "tx2", Party: alice, Message: boundMessage(t, summaryFor("tx2")), Summary: summaryFor("tx2")})
+	assert.ErrorContains(t, err, "not today")
+
+	_, err = s.Signer("alice").SignRequest(&ttx.ExternalWalletSignRequest{TxID: "bob", Party: view.Identity("bob"), Message: msg, Summary: summary()})
+	assert.ErrorContains(t, err, "no signer for party")
+
+	// the summary must be there and be the one the message commits to, the approver never sees the others
+	_, err = s.Signer("alice").Sign(alice, msg)
+	assert.ErrorContains(t, err, "sign request carries no summary")
+	forged := summary()
+	forged.Outputs[0].Quantity = "1"
+	_, err = s.Signer("alice").SignRequest(&ttx.ExternalWalletSignRequest{TxID: "tx1", Party: alice, Message: msg, Summary: forged})
+	assert.ErrorContains(t, err, "summary does not match the message to sign")
+
+	cancel()
+	assert.ErrorIs(t, <-served, context.Canceled)
+	assert.Len(t, approved, 3)
+	assert.Equal(t, summary(), approved[0].Summary)
+}
+
+type configProvider struct {
+	config *Config
+}
+
+func (c *configProvider) IsSet(string) bool { return true }
+
+func (c *configProvider) UnmarshalKey(_ string, rawVal interface{}) error {
+	*rawVal.(*Config) = *c.config
+	return nil
+}
+
+func (c *configProvider) TranslatePath(path string) string { return path }
+
+func TestConfigRequiresTLS(t *testing.T) {
+	// listeners without tls are refused, unless explicitly insecure
+	_, err := NewConfig(&configProvider{config: &Config{HTTP: ListenerConfig{Address: "0.0.0.0:9000"}}})
+	assert.EqualError(t, err, "tls disabled for [0.0.0.0:9000], set insecure to accept bearer tokens in clear")
+	_, err = NewConfig(&configProvider{config: &Config{GRPC: ListenerConfig{Address: "0.0.0.0:9001"}}})
+	assert.EqualError(t, err, "tls disabled for [0.0.0.0:9001], set insecure to accept bearer tokens in clear")
+
+	c, err := NewConfig(&configProvider{config: &Config{HTTP: ListenerConfig{Address: "0.0.0.0:9000", Insecure: true}}})
+	assert.NoError(t, err)
+	assert.Equal(t, defaultTimeout, c.Timeout)
+
+	c, err = NewConfig(&configProvider{config: &Config{GRPC: ListenerConfig{
+		Address: "0.0.0.0:9001",
+		TLS:     TLSConfig{Enabled: true, CertFile: "server.crt", KeyFile: "server.key"},
+	}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "server.crt", c.GRPC.TLS.CertFile)
+
+	// disabled listeners need nothing
+	_, err = NewConfig(&configProvider{config: &Config{}})
+	assert.NoError(t, err)
+}
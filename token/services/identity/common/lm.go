@@ -215,6 +215,7 @@ func (l *LocalMembership) registerIdentity(identity config.Identity) error {
 		URL:    identity.Path,
 		Config: optsRaw,
 		Raw:    nil,
+		Type:   identity.Type,
 	}, identity.Default)
 }
 
@@ -275,6 +276,7 @@ func (l *LocalMembership) registerLocalIdentities(configuration *driver.Identity
 			ID:     id,
 			URL:    filepath.Join(configuration.URL, id),
 			Config: configuration.Config,
+			Type:   configuration.Type,
 		}, false); err != nil {
 			l.logger.Errorf("failed registering local identity [%s]: [%s]", id, err)
 			continue
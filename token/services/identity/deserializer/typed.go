@@ -87,7 +87,7 @@ func (v *TypedVerifierDeserializerMultiplex) MatchOwnerIdentity(id driver.Identi
 	//	return errors.Errorf("expected serialized identity type, got [%s]", recipient.Type)
 	//}
 
-	matcher, err := v.GetOwnerMatcher(ai)
+	matcher, err := v.ownerMatcherDeserializer(recipient.Type).GetOwnerMatcher(ai)
 	if err != nil {
 		return errors.Wrapf(err, "failed getting audit info matcher for [%s]", id)
 	}
@@ -98,6 +98,24 @@ func (v *TypedVerifierDeserializerMultiplex) MatchOwnerIdentity(id driver.Identi
 	return nil
 }
 
+// ownerMatcherDeserializer returns the audit matcher deserializer of the deserializer registered for the passed type, if any,
+// the default one otherwise
+func (v *TypedVerifierDeserializerMultiplex) ownerMatcherDeserializer(typ string) AuditMatcherDeserializer {
+	d, ok := v.deserializers[typ]
+	if !ok {
+		return v.auditMatcherDeserializer
+	}
+	if t, ok := d.(*TypedIdentityVerifierDeserializer); ok {
+		if amd, ok := t.VerifierDeserializer.(AuditMatcherDeserializer); ok {
+			return amd
+		}
+	}
+	if amd, ok := d.(AuditMatcherDeserializer); ok {
+		return amd
+	}
+	return v.auditMatcherDeserializer
+}
+
 func (v *TypedVerifierDeserializerMultiplex) GetOwnerAuditInfo(id driver.Identity, p driver.AuditInfoProvider) ([][]byte, error) {
 	si, err := identity.UnmarshalTypedIdentity(id)
 	if err != nil {
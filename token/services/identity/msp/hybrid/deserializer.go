@@ -0,0 +1,126 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hybrid
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
+	"github.com/pkg/errors"
+)
+
+// AuditInfoDeserializer deserializes the audit info of hybrid identities, the same of X509 identities
+type AuditInfoDeserializer = x509.AuditInfoDeserializer
+
+// Deserializer deserializes typed hybrid identities.
+// It can be added to the deserializer manager of the signature service.
+type Deserializer struct{}
+
+func (d *Deserializer) DeserializeVerifier(raw []byte) (driver.Verifier, error) {
+	id, err := UnmarshalTypedIdentity(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(id)
+}
+
+func (d *Deserializer) DeserializeSigner(raw []byte) (driver.Signer, error) {
+	return nil, errors.New("not supported")
+}
+
+func (d *Deserializer) Info(raw []byte, auditInfo []byte) (string, error) {
+	id, err := UnmarshalTypedIdentity(raw)
+	if err != nil {
+		return "", err
+	}
+	pqID, err := pq.UnmarshalIdentity(id.PostQuantum)
+	if err != nil {
+		return "", err
+	}
+	ai := &x509.AuditInfo{}
+	if len(auditInfo) != 0 {
+		if err := ai.FromBytes(auditInfo); err != nil {
+			return "", errors.Wrapf(err, "failed to unmarshal audit info")
+		}
+	}
+	return fmt.Sprintf("Hybrid.x509+%s: [%s][%s]", pqID.Scheme, driver.Identity(raw).UniqueID(), ai.EID), nil
+}
+
+// IdentityDeserializer deserializes the content of a TypedIdentity of type IdentityType.
+// It can be plugged into a deserializer.TypedVerifierDeserializerMultiplex.
+type IdentityDeserializer struct{}
+
+func (d *IdentityDeserializer) DeserializeVerifier(raw driver.Identity) (driver.Verifier, error) {
+	id, err := UnmarshalIdentity(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(id)
+}
+
+// GetOwnerMatcher returns a matcher that checks that a hybrid identity has been issued to the enrollment ID in the passed audit info
+func (d *IdentityDeserializer) GetOwnerMatcher(raw []byte) (driver.Matcher, error) {
+	ai := &x509.AuditInfo{}
+	if err := ai.FromBytes(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal")
+	}
+	return &AuditInfoMatcher{EnrollmentID: ai.EID}, nil
+}
+
+// TypedIdentityDeserializer deserializes typed hybrid identities and delegates all other identities
+// to the passed deserializer. It is used where identities are not necessarily typed, like for issuers.
+type TypedIdentityDeserializer struct {
+	Deserializer
+	fallback common.VerifierDeserializer
+}
+
+// NewTypedIdentityDeserializer returns a new TypedIdentityDeserializer with the passed fallback
+func NewTypedIdentityDeserializer(fallback common.VerifierDeserializer) *TypedIdentityDeserializer {
+	return &TypedIdentityDeserializer{fallback: fallback}
+}
+
+func (d *TypedIdentityDeserializer) DeserializeVerifier(raw driver.Identity) (driver.Verifier, error) {
+	if IsIdentity(raw) {
+		return d.Deserializer.DeserializeVerifier(raw)
+	}
+	return d.fallback.DeserializeVerifier(raw)
+}
+
+// AuditInfoMatcher matches hybrid identities against an enrollment ID.
+// The classical certificate must be issued to the enrollment ID and
+// the post-quantum key must be bound to the same one by a certification of the classical key.
+type AuditInfoMatcher struct {
+	EnrollmentID string
+}
+
+func (a *AuditInfoMatcher) Match(raw []byte) error {
+	id, err := UnmarshalIdentity(raw)
+	if err != nil {
+		return err
+	}
+	if err := (&x509.AuditInfoMatcher{CommonName: a.EnrollmentID}).Match(id.Classical); err != nil {
+		return errors.WithMessage(err, "classical identity does not match")
+	}
+	pqID, err := pq.UnmarshalIdentity(id.PostQuantum)
+	if err != nil {
+		return err
+	}
+	if pqID.EnrollmentID != a.EnrollmentID {
+		return errors.Errorf("post-quantum identity does not match, expected [%s], got [%s]", a.EnrollmentID, pqID.EnrollmentID)
+	}
+	classical, err := (&x509.MSPIdentityDeserializer{}).DeserializeVerifier(id.Classical)
+	if err != nil {
+		return errors.WithMessage(err, "failed deserializing classical verifier")
+	}
+	if err := pqID.VerifyCertification(classical); err != nil {
+		return errors.WithMessage(err, "post-quantum identity not certified by the classical identity")
+	}
+	return nil
+}
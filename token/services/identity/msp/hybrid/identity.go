@@ -0,0 +1,147 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hybrid
+
+import (
+	"encoding/asn1"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
+	"github.com/pkg/errors"
+)
+
+// IdentityType identifies a hybrid identity, made of a classical X509 identity and a post-quantum identity
+const IdentityType identity.Type = "hybrid"
+
+// Identity is the serialized form of a hybrid identity
+type Identity struct {
+	// Classical is the serialized X509 MSP identity
+	Classical []byte
+	// PostQuantum is the serialized post-quantum identity
+	PostQuantum []byte
+}
+
+// Bytes returns the serialized identity
+func (i *Identity) Bytes() ([]byte, error) {
+	return asn1.Marshal(*i)
+}
+
+// Typed returns the identity wrapped in a TypedIdentity of type IdentityType
+func (i *Identity) Typed() (driver.Identity, error) {
+	raw, err := i.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal identity")
+	}
+	return identity.WrapWithType(IdentityType, raw)
+}
+
+// UnmarshalIdentity unmarshals the passed bytes into an Identity
+func UnmarshalIdentity(raw []byte) (*Identity, error) {
+	id := &Identity{}
+	rest, err := asn1.Unmarshal(raw, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal hybrid identity")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("failed to unmarshal hybrid identity: trailing bytes")
+	}
+	if len(id.Classical) == 0 || len(id.PostQuantum) == 0 {
+		return nil, errors.New("invalid hybrid identity: both the classical and the post-quantum identity are required")
+	}
+	return id, nil
+}
+
+// UnmarshalTypedIdentity unwraps the passed TypedIdentity and unmarshals it into an Identity.
+// It returns an error if the identity is not of type IdentityType.
+func UnmarshalTypedIdentity(id driver.Identity) (*Identity, error) {
+	ti, err := identity.UnmarshalTypedIdentity(id)
+	if err != nil {
+		return nil, err
+	}
+	if ti.Type != IdentityType {
+		return nil, errors.Errorf("expected identity type [%s], got [%s]", IdentityType, ti.Type)
+	}
+	return UnmarshalIdentity(ti.Identity)
+}
+
+// IsIdentity returns true if the passed identity is a typed hybrid identity
+func IsIdentity(id driver.Identity) bool {
+	_, err := UnmarshalTypedIdentity(id)
+	return err == nil
+}
+
+// Signature is a pair of signatures on the same message, one for each key of the hybrid identity
+type Signature struct {
+	Classical   []byte
+	PostQuantum []byte
+}
+
+// Verifier verifies hybrid signatures.
+// A signature is valid only if both the classical and the post-quantum signatures are valid.
+type Verifier struct {
+	classical   driver.Verifier
+	postQuantum driver.Verifier
+}
+
+// NewVerifier returns a verifier for the passed identity
+func NewVerifier(id *Identity) (*Verifier, error) {
+	classical, err := (&x509.MSPIdentityDeserializer{}).DeserializeVerifier(id.Classical)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed deserializing classical verifier")
+	}
+	pqID, err := pq.UnmarshalIdentity(id.PostQuantum)
+	if err != nil {
+		return nil, err
+	}
+	postQuantum, err := pq.NewVerifier(pqID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed deserializing post-quantum verifier")
+	}
+	return &Verifier{classical: classical, postQuantum: postQuantum}, nil
+}
+
+func (v *Verifier) Verify(message, sigma []byte) error {
+	sig := &Signature{}
+	rest, err := asn1.Unmarshal(sigma, sig)
+	if err != nil {
+		return errors.Wrap(err, "failed to unmarshal hybrid signature")
+	}
+	if len(rest) != 0 {
+		return errors.New("failed to unmarshal hybrid signature: trailing bytes")
+	}
+	if len(sig.Classical) == 0 || len(sig.PostQuantum) == 0 {
+		return errors.New("invalid hybrid signature: both signatures are required")
+	}
+	if err := v.classical.Verify(message, sig.Classical); err != nil {
+		return errors.WithMessage(err, "invalid classical signature")
+	}
+	if err := v.postQuantum.Verify(message, sig.PostQuantum); err != nil {
+		return errors.WithMessage(err, "invalid post-quantum signature")
+	}
+	return nil
+}
+
+// Signer produces hybrid signatures
+type Signer struct {
+	*Verifier
+	classical   driver.Signer
+	postQuantum driver.Signer
+}
+
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	classical, err := s.classical.Sign(message)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed generating classical signature")
+	}
+	postQuantum, err := s.postQuantum.Sign(message)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed generating post-quantum signature")
+	}
+	return asn1.Marshal(Signature{Classical: classical, PostQuantum: postQuantum})
+}
@@ -0,0 +1,129 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hybrid
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.MustGetLogger("token-sdk.services.identity.msp.hybrid")
+
+// KeyManager manages a long-term hybrid identity.
+// The audit info is the one of the classical X509 identity, therefore auditors see the enrollment ID
+// and the revocation handle of the X509 certificate.
+type KeyManager struct {
+	*Deserializer
+	classical *x509.KeyManager
+	signer    *Signer
+	typed     driver.Identity
+	auditInfo []byte
+}
+
+// NewKeyManager returns a new KeyManager combining the passed X509 key manager with the post-quantum key stored in the passed folder.
+// The key manager can generate signatures only if both the classical and the post-quantum private keys are available.
+func NewKeyManager(classical *x509.KeyManager, pqDir string, signerService driver2.SigService) (*KeyManager, error) {
+	classicalID, auditInfo, err := classical.Identity(nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting classical identity")
+	}
+	pqID, err := pq.LoadIdentity(pqDir)
+	if err != nil {
+		return nil, err
+	}
+	if pqID.EnrollmentID != classical.EnrollmentID() {
+		return nil, errors.Errorf("enrollment ID mismatch: classical [%s], post-quantum [%s]", classical.EnrollmentID(), pqID.EnrollmentID)
+	}
+	classicalVerifier, err := (&x509.MSPIdentityDeserializer{}).DeserializeVerifier(classicalID)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed deserializing classical verifier")
+	}
+	if err := pqID.VerifyCertification(classicalVerifier); err != nil {
+		return nil, errors.WithMessagef(err, "post-quantum key in [%s] not certified by the classical key", pqDir)
+	}
+	pqRaw, err := pqID.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal post-quantum identity")
+	}
+	id := &Identity{Classical: classicalID, PostQuantum: pqRaw}
+	typed, err := id.Typed()
+	if err != nil {
+		return nil, err
+	}
+	km := &KeyManager{
+		Deserializer: &Deserializer{},
+		classical:    classical,
+		typed:        typed,
+		auditInfo:    auditInfo,
+	}
+
+	if classical.IsRemote() {
+		logger.Debugf("no classical signer for [%s], load as verify only", classical.EnrollmentID())
+		return km, nil
+	}
+	pqSigner, err := pq.LoadSigner(pqDir, pqID)
+	if err != nil {
+		logger.Debugf("no post-quantum signer found in [%s], load as verify only: [%s]", pqDir, err)
+		return km, nil
+	}
+	classicalSigner, err := classical.SerializedIdentity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting classical signer")
+	}
+	verifier, err := NewVerifier(id)
+	if err != nil {
+		return nil, err
+	}
+	km.signer = &Signer{Verifier: verifier, classical: classicalSigner, postQuantum: pqSigner}
+	if signerService != nil {
+		logger.Debugf("register signer [%s][%s]", classical.EnrollmentID(), typed)
+		if err := signerService.RegisterSigner(typed, km.signer, km.signer.Verifier, nil); err != nil {
+			return nil, errors.Wrapf(err, "failed registering hybrid signer")
+		}
+	}
+	return km, nil
+}
+
+// GeneratePostQuantumKey generates a post-quantum key pair in the passed folder for the enrollment ID of the passed X509 key manager.
+// The classical key certifies the enrollment ID of the post-quantum key, therefore the classical signer must be available.
+func GeneratePostQuantumKey(classical *x509.KeyManager, dir string, scheme string) (*pq.Identity, error) {
+	if classical.IsRemote() {
+		return nil, errors.Errorf("no classical signer for [%s]", classical.EnrollmentID())
+	}
+	signer, err := classical.SerializedIdentity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting classical signer")
+	}
+	return pq.GenerateKeyPair(dir, scheme, classical.EnrollmentID(), signer)
+}
+
+func (k *KeyManager) IsRemote() bool {
+	return k.signer == nil
+}
+
+// Identity returns the typed identity and the audit info of the classical identity
+func (k *KeyManager) Identity([]byte) (driver.Identity, []byte, error) {
+	return k.typed, k.auditInfo, nil
+}
+
+func (k *KeyManager) EnrollmentID() string {
+	return k.classical.EnrollmentID()
+}
+
+func (k *KeyManager) Anonymous() bool {
+	return false
+}
+
+func (k *KeyManager) String() string {
+	return fmt.Sprintf("Hybrid KeyManager for EID [%s]", k.classical.EnrollmentID())
+}
@@ -0,0 +1,202 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hybrid
+
+import (
+	"encoding/asn1"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
+	"github.com/stretchr/testify/assert"
+)
+
+const eid = "auditor.org1.example.com"
+
+type config struct {
+	driver2.Config
+}
+
+func (c *config) TranslatePath(path string) string { return path }
+
+// newWallet copies the x509 test msp into a new folder and adds to it a post-quantum key bound,
+// by a certification of the classical key, to the passed enrollment ID
+func newWallet(t *testing.T, enrollmentID string) string {
+	dir := copyMSP(t)
+	_, err := pq.GenerateKeyPair(dir, "", enrollmentID, classicalSigner(t, dir))
+	assert.NoError(t, err)
+	return dir
+}
+
+func classicalSigner(t *testing.T, dir string) driver.Signer {
+	c := &config{}
+	km, err := x509.NewKeyManagerProvider(c, "apple", nil, false).Get(&driver.IdentityConfiguration{ID: "alice", URL: dir})
+	assert.NoError(t, err)
+	signer, err := km.(*x509.KeyManager).SerializedIdentity()
+	assert.NoError(t, err)
+	return signer
+}
+
+func copyMSP(t *testing.T) string {
+	dir := t.TempDir()
+	src := "../x509/testdata/msp"
+	assert.NoError(t, filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dir, rel), 0755)
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dir, rel), raw, 0600)
+	}))
+	return dir
+}
+
+func newKeyManager(t *testing.T, dir string) (*KeyManager, error) {
+	c := &config{}
+	kmp := NewKeyManagerProvider(c, nil, x509.NewKeyManagerProvider(c, "apple", nil, false), nil)
+	km, err := kmp.Get(&driver.IdentityConfiguration{ID: "alice", URL: dir})
+	if err != nil {
+		return nil, err
+	}
+	return km.(*KeyManager), nil
+}
+
+func TestKeyManager(t *testing.T) {
+	km, err := newKeyManager(t, newWallet(t, eid))
+	assert.NoError(t, err)
+	assert.False(t, km.IsRemote())
+	assert.False(t, km.Anonymous())
+	assert.Equal(t, eid, km.EnrollmentID())
+
+	id, auditInfo, err := km.Identity(nil)
+	assert.NoError(t, err)
+	assert.True(t, IsIdentity(id))
+	assert.False(t, pq.IsIdentity(id))
+	ti, err := identity.UnmarshalTypedIdentity(id)
+	assert.NoError(t, err)
+
+	// audit info
+	ai, err := (&AuditInfoDeserializer{}).DeserializeAuditInfo(auditInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, eid, ai.EnrollmentID())
+	assert.NotEmpty(t, ai.RevocationHandle())
+	matcher, err := (&IdentityDeserializer{}).GetOwnerMatcher(auditInfo)
+	assert.NoError(t, err)
+	assert.NoError(t, matcher.Match(ti.Identity))
+	assert.Error(t, (&AuditInfoMatcher{EnrollmentID: "bob"}).Match(ti.Identity))
+
+	// both signatures are required
+	msg := []byte("hello world")
+	sigma, err := km.signer.Sign(msg)
+	assert.NoError(t, err)
+	verifier, err := (&Deserializer{}).DeserializeVerifier(id)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(msg, sigma))
+	assert.Error(t, verifier.Verify([]byte("another message"), sigma))
+
+	sig := &Signature{}
+	_, err = asn1.Unmarshal(sigma, sig)
+	assert.NoError(t, err)
+	for _, s := range []Signature{
+		{Classical: sig.Classical},
+		{PostQuantum: sig.PostQuantum},
+		{Classical: sig.Classical, PostQuantum: sig.Classical},
+		{Classical: sig.PostQuantum, PostQuantum: sig.PostQuantum},
+	} {
+		raw, err := asn1.Marshal(s)
+		assert.NoError(t, err)
+		assert.Error(t, verifier.Verify(msg, raw))
+	}
+
+	// issuers
+	verifier, err = NewTypedIdentityDeserializer(&x509.MSPIdentityDeserializer{}).DeserializeVerifier(id)
+	assert.NoError(t, err)
+	assert.NoError(t, verifier.Verify(msg, sigma))
+}
+
+func TestEnrollmentIDMismatch(t *testing.T) {
+	_, err := newKeyManager(t, newWallet(t, "bob"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "enrollment ID mismatch")
+}
+
+func TestVerifyOnly(t *testing.T) {
+	dir := newWallet(t, eid)
+	assert.NoError(t, os.Remove(filepath.Join(dir, pq.PrivateKeyFile)))
+	km, err := newKeyManager(t, dir)
+	assert.NoError(t, err)
+	assert.True(t, km.IsRemote())
+}
+
+func TestPostQuantumCertification(t *testing.T) {
+	// a post-quantum key not certified by the classical key is rejected
+	dir := copyMSP(t)
+	_, err := pq.GenerateKeyPair(dir, "", eid, nil)
+	assert.NoError(t, err)
+	_, err = newKeyManager(t, dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not certified by the classical key")
+
+	// keys generated for the classical key manager are certified
+	dir = copyMSP(t)
+	c := &config{}
+	classical, err := x509.NewKeyManagerProvider(c, "apple", nil, false).Get(&driver.IdentityConfiguration{ID: "alice", URL: dir})
+	assert.NoError(t, err)
+	_, err = GeneratePostQuantumKey(classical.(*x509.KeyManager), dir, "")
+	assert.NoError(t, err)
+	km, err := newKeyManager(t, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, eid, km.EnrollmentID())
+}
+
+func TestAuditInfoMatcherEnrollmentIDMismatch(t *testing.T) {
+	km, err := newKeyManager(t, newWallet(t, eid))
+	assert.NoError(t, err)
+	id, auditInfo, err := km.Identity(nil)
+	assert.NoError(t, err)
+	hid, err := UnmarshalTypedIdentity(id)
+	assert.NoError(t, err)
+	matcher, err := (&IdentityDeserializer{}).GetOwnerMatcher(auditInfo)
+	assert.NoError(t, err)
+
+	match := func(pqID *pq.Identity) error {
+		pqRaw, err := pqID.Bytes()
+		assert.NoError(t, err)
+		raw, err := (&Identity{Classical: hid.Classical, PostQuantum: pqRaw}).Bytes()
+		assert.NoError(t, err)
+		return matcher.Match(raw)
+	}
+
+	// the post-quantum half carries another enrollment ID, even if certified by the classical key
+	bob, err := pq.GenerateKeyPair(t.TempDir(), "", "bob", classicalSigner(t, newWallet(t, eid)))
+	assert.NoError(t, err)
+	assert.EqualError(t, match(bob), "post-quantum identity does not match, expected ["+eid+"], got [bob]")
+
+	// the post-quantum half claims the enrollment ID without certification
+	claimed, err := pq.GenerateKeyPair(t.TempDir(), "", eid, nil)
+	assert.NoError(t, err)
+	assert.EqualError(t, match(claimed), "post-quantum identity not certified by the classical identity: enrollment ID ["+eid+"] not certified")
+
+	// or with the certification of a key other than the classical one
+	forged, err := pq.GenerateKeyPair(t.TempDir(), "", eid, km.signer.postQuantum)
+	assert.NoError(t, err)
+	assert.Error(t, match(forged))
+}
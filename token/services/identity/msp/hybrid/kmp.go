@@ -0,0 +1,78 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package hybrid
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/common"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
+	"github.com/pkg/errors"
+)
+
+const signcerts = "signcerts"
+
+// KeyManagerProvider returns hybrid key managers for the wallets whose type is IdentityType,
+// or whose folder is an X509 MSP folder that also contains a post-quantum key.
+// The classical part is loaded with the passed X509 provider.
+// All other wallets are delegated to the fallback provider.
+type KeyManagerProvider struct {
+	config        driver2.Config
+	signerService driver2.SigService
+	classical     common2.KeyManagerProvider
+	fallback      common2.KeyManagerProvider
+}
+
+func NewKeyManagerProvider(config driver2.Config, signerService driver2.SigService, classical common2.KeyManagerProvider, fallback common2.KeyManagerProvider) *KeyManagerProvider {
+	return &KeyManagerProvider{config: config, signerService: signerService, classical: classical, fallback: fallback}
+}
+
+func (k *KeyManagerProvider) Get(idConfig *driver.IdentityConfiguration) (common2.KeyManager, error) {
+	translatedPath := k.config.TranslatePath(idConfig.URL)
+	dir, found := k.keyDir(translatedPath)
+	switch {
+	case idConfig.Type == string(IdentityType):
+		if !found {
+			return nil, errors.Errorf("no hybrid keys found in [%s]", translatedPath)
+		}
+	case len(idConfig.Type) == 0 && found:
+		logger.Debugf("hybrid keys found in [%s]", dir)
+	default:
+		if k.fallback == nil {
+			return nil, errors.Errorf("identity type [%s] not supported", idConfig.Type)
+		}
+		return k.fallback.Get(idConfig)
+	}
+
+	km, err := k.classical.Get(idConfig)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed loading classical identity from [%s]", translatedPath)
+	}
+	classical, ok := km.(*x509.KeyManager)
+	if !ok {
+		return nil, errors.Errorf("expected an x509 key manager, got [%T]", km)
+	}
+	return NewKeyManager(classical, dir, k.signerService)
+}
+
+// keyDir looks for a folder, among the passed one and its msp sub-folder, containing both
+// an X509 signing certificate and a post-quantum key
+func (k *KeyManagerProvider) keyDir(path string) (string, bool) {
+	for _, dir := range []string{path, filepath.Join(path, "msp")} {
+		if !pq.IsKeyDir(dir) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, signcerts)); err == nil {
+			return dir, true
+		}
+	}
+	return "", false
+}
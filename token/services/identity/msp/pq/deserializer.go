@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pq
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/core/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/pkg/errors"
+)
+
+// Deserializer deserializes typed post-quantum identities.
+// It can be added to the deserializer manager of the signature service.
+type Deserializer struct{}
+
+func (d *Deserializer) DeserializeVerifier(raw []byte) (driver.Verifier, error) {
+	id, err := UnmarshalTypedIdentity(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(id)
+}
+
+func (d *Deserializer) DeserializeSigner(raw []byte) (driver.Signer, error) {
+	return nil, errors.New("not supported")
+}
+
+func (d *Deserializer) Info(raw []byte, auditInfo []byte) (string, error) {
+	id, err := UnmarshalTypedIdentity(raw)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("PQ.%s: [%s][%s]", id.Scheme, driver.Identity(raw).UniqueID(), id.EnrollmentID), nil
+}
+
+// IdentityDeserializer deserializes the content of a TypedIdentity of type IdentityType.
+// It can be plugged into a deserializer.TypedVerifierDeserializerMultiplex.
+type IdentityDeserializer struct {
+	// CAs are the verifiers of the post-quantum CAs that certify the enrollment IDs of the owners
+	CAs []driver.Verifier
+}
+
+// NewIdentityDeserializer returns an IdentityDeserializer that trusts the passed post-quantum CA identities,
+// either plain or typed, to certify enrollment IDs
+func NewIdentityDeserializer(cas [][]byte) (*IdentityDeserializer, error) {
+	d := &IdentityDeserializer{}
+	for i, raw := range cas {
+		id, err := UnmarshalTypedIdentity(raw)
+		if err != nil {
+			id, err = UnmarshalIdentity(raw)
+		}
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid post-quantum CA at index [%d]", i)
+		}
+		verifier, err := NewVerifier(id)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "invalid post-quantum CA at index [%d]", i)
+		}
+		d.CAs = append(d.CAs, verifier)
+	}
+	return d, nil
+}
+
+func (d *IdentityDeserializer) DeserializeVerifier(raw driver.Identity) (driver.Verifier, error) {
+	id, err := UnmarshalIdentity(raw)
+	if err != nil {
+		return nil, err
+	}
+	return NewVerifier(id)
+}
+
+// GetOwnerMatcher returns a matcher that checks that an identity has been issued to the enrollment ID in the passed audit info
+func (d *IdentityDeserializer) GetOwnerMatcher(raw []byte) (driver.Matcher, error) {
+	ai := &AuditInfo{}
+	if err := ai.FromBytes(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal")
+	}
+	return &AuditInfoMatcher{AuditInfo: ai, CAs: d.CAs}, nil
+}
+
+// TypedIdentityDeserializer deserializes typed post-quantum identities and delegates all other identities
+// to the passed deserializer. It is used where identities are not necessarily typed, like for issuers.
+type TypedIdentityDeserializer struct {
+	Deserializer
+	fallback common.VerifierDeserializer
+}
+
+// NewTypedIdentityDeserializer returns a new TypedIdentityDeserializer with the passed fallback
+func NewTypedIdentityDeserializer(fallback common.VerifierDeserializer) *TypedIdentityDeserializer {
+	return &TypedIdentityDeserializer{fallback: fallback}
+}
+
+func (d *TypedIdentityDeserializer) DeserializeVerifier(raw driver.Identity) (driver.Verifier, error) {
+	if IsIdentity(raw) {
+		return d.Deserializer.DeserializeVerifier(raw)
+	}
+	return d.fallback.DeserializeVerifier(raw)
+}
+
+// AuditInfoMatcher matches post-quantum identities against audit info.
+// The enrollment ID of the identity must be certified by one of the CAs.
+type AuditInfoMatcher struct {
+	AuditInfo *AuditInfo
+	CAs       []driver.Verifier
+}
+
+func (a *AuditInfoMatcher) Match(raw []byte) error {
+	id, err := UnmarshalIdentity(raw)
+	if err != nil {
+		return err
+	}
+	if id.EnrollmentID != a.AuditInfo.EID {
+		return errors.Errorf("expected [%s], got [%s]", a.AuditInfo.EID, id.EnrollmentID)
+	}
+	if string(id.RevocationHandle()) != string(a.AuditInfo.RH) {
+		return errors.New("revocation handle does not match")
+	}
+	if err := id.VerifyCertification(a.CAs...); err != nil {
+		return errors.WithMessage(err, "invalid post-quantum identity")
+	}
+	return nil
+}
+
+// AuditInfoDeserializer deserializes the audit info of post-quantum identities
+type AuditInfoDeserializer struct{}
+
+func (a *AuditInfoDeserializer) DeserializeAuditInfo(raw []byte) (driver2.AuditInfo, error) {
+	ai := &AuditInfo{}
+	if err := ai.FromBytes(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal")
+	}
+	return ai, nil
+}
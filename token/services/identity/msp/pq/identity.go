@@ -0,0 +1,211 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pq
+
+import (
+	"encoding/asn1"
+	"strings"
+
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/schemes"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
+	"github.com/pkg/errors"
+)
+
+const (
+	// IdentityType identifies a post-quantum identity
+	IdentityType identity.Type = "dilithium"
+	// DefaultScheme is the signature scheme used when none is specified
+	DefaultScheme = "ML-DSA-65"
+)
+
+// SupportedSchemes lists the post-quantum signature schemes that can back an identity
+var SupportedSchemes = []string{
+	"Dilithium2",
+	"Dilithium3",
+	"Dilithium5",
+	"ML-DSA-44",
+	"ML-DSA-65",
+	"ML-DSA-87",
+}
+
+// SchemeByName returns the supported signature scheme with the passed name, case-insensitive
+func SchemeByName(name string) (sign.Scheme, error) {
+	for _, s := range SupportedSchemes {
+		if strings.EqualFold(s, name) {
+			return schemes.ByName(s), nil
+		}
+	}
+	return nil, errors.Errorf("unsupported post-quantum signature scheme [%s]", name)
+}
+
+// Identity is the serialized form of a post-quantum identity
+type Identity struct {
+	// Scheme is the name of the signature scheme
+	Scheme string
+	// PublicKey is the marshalled public key
+	PublicKey []byte
+	// EnrollmentID is the enrollment ID the key has been issued to
+	EnrollmentID string
+	// Certification is the signature, by an enrollment certifier, that binds the enrollment ID to the public key.
+	// The certifier is either a post-quantum CA listed in the public parameters or,
+	// for the post-quantum half of a hybrid identity, the classical key.
+	Certification []byte `asn1:"optional"`
+}
+
+// enrollment is the message signed by an enrollment certifier
+type enrollment struct {
+	Scheme       string
+	PublicKey    []byte
+	EnrollmentID string
+}
+
+// Bytes returns the serialized identity
+func (i *Identity) Bytes() ([]byte, error) {
+	return asn1.Marshal(*i)
+}
+
+// Typed returns the identity wrapped in a TypedIdentity of type IdentityType
+func (i *Identity) Typed() (driver.Identity, error) {
+	raw, err := i.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal identity")
+	}
+	return identity.WrapWithType(IdentityType, raw)
+}
+
+// Certify binds the enrollment ID to the public key with a signature of the passed certifier
+func (i *Identity) Certify(certifier driver.Signer) error {
+	msg, err := i.enrollment()
+	if err != nil {
+		return err
+	}
+	sigma, err := certifier.Sign(msg)
+	if err != nil {
+		return errors.WithMessage(err, "failed certifying enrollment ID")
+	}
+	i.Certification = sigma
+	return nil
+}
+
+// VerifyCertification returns nil if the enrollment ID has been bound to the public key by one of the passed certifiers
+func (i *Identity) VerifyCertification(certifiers ...driver.Verifier) error {
+	if len(i.EnrollmentID) == 0 {
+		return errors.New("no enrollment ID")
+	}
+	if len(i.Certification) == 0 {
+		return errors.Errorf("enrollment ID [%s] not certified", i.EnrollmentID)
+	}
+	msg, err := i.enrollment()
+	if err != nil {
+		return err
+	}
+	for _, certifier := range certifiers {
+		if certifier.Verify(msg, i.Certification) == nil {
+			return nil
+		}
+	}
+	return errors.Errorf("enrollment ID [%s] not certified by a known certifier", i.EnrollmentID)
+}
+
+func (i *Identity) enrollment() ([]byte, error) {
+	msg, err := asn1.Marshal(enrollment{Scheme: i.Scheme, PublicKey: i.PublicKey, EnrollmentID: i.EnrollmentID})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal enrollment")
+	}
+	return msg, nil
+}
+
+// publicKey returns the signature scheme and the unmarshalled public key
+func (i *Identity) publicKey() (sign.Scheme, sign.PublicKey, error) {
+	scheme, err := SchemeByName(i.Scheme)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, err := scheme.UnmarshalBinaryPublicKey(i.PublicKey)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to unmarshal [%s] public key", i.Scheme)
+	}
+	return scheme, pk, nil
+}
+
+// RevocationHandle returns the revocation handle of the identity, the hash of its public key
+func (i *Identity) RevocationHandle() []byte {
+	return []byte(hash.Hashable(i.PublicKey).String())
+}
+
+// UnmarshalIdentity unmarshals the passed bytes into an Identity
+func UnmarshalIdentity(raw []byte) (*Identity, error) {
+	id := &Identity{}
+	rest, err := asn1.Unmarshal(raw, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal post-quantum identity")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("failed to unmarshal post-quantum identity: trailing bytes")
+	}
+	if len(id.Scheme) == 0 || len(id.PublicKey) == 0 {
+		return nil, errors.New("invalid post-quantum identity: empty scheme or public key")
+	}
+	return id, nil
+}
+
+// UnmarshalTypedIdentity unwraps the passed TypedIdentity and unmarshals it into an Identity.
+// It returns an error if the identity is not of type IdentityType.
+func UnmarshalTypedIdentity(id driver.Identity) (*Identity, error) {
+	ti, err := identity.UnmarshalTypedIdentity(id)
+	if err != nil {
+		return nil, err
+	}
+	if ti.Type != IdentityType {
+		return nil, errors.Errorf("expected identity type [%s], got [%s]", IdentityType, ti.Type)
+	}
+	return UnmarshalIdentity(ti.Identity)
+}
+
+// IsIdentity returns true if the passed identity is a typed post-quantum identity
+func IsIdentity(id driver.Identity) bool {
+	_, err := UnmarshalTypedIdentity(id)
+	return err == nil
+}
+
+// Verifier verifies signatures under a post-quantum public key
+type Verifier struct {
+	scheme sign.Scheme
+	pk     sign.PublicKey
+}
+
+// NewVerifier returns a verifier for the passed identity
+func NewVerifier(id *Identity) (*Verifier, error) {
+	scheme, pk, err := id.publicKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Verifier{scheme: scheme, pk: pk}, nil
+}
+
+func (v *Verifier) Verify(message, sigma []byte) error {
+	if len(sigma) != v.scheme.SignatureSize() {
+		return errors.Errorf("invalid [%s] signature length [%d]", v.scheme.Name(), len(sigma))
+	}
+	if !v.scheme.Verify(v.pk, message, sigma, nil) {
+		return errors.Errorf("invalid [%s] signature", v.scheme.Name())
+	}
+	return nil
+}
+
+// Signer produces signatures with a post-quantum private key
+type Signer struct {
+	*Verifier
+	sk sign.PrivateKey
+}
+
+func (s *Signer) Sign(message []byte) ([]byte, error) {
+	return s.scheme.Sign(s.sk, message, nil), nil
+}
@@ -0,0 +1,148 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pq
+
+import (
+	"encoding/pem"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PublicKeyFile is the name of the file, in the key folder, that contains the public key
+	PublicKeyFile = "pq_pk.pem"
+	// PrivateKeyFile is the name of the file, in the key folder, that contains the private key
+	PrivateKeyFile = "pq_sk.pem"
+
+	publicKeyBlockType     = "PQ PUBLIC KEY"
+	privateKeyBlockType    = "PQ PRIVATE KEY"
+	certificationBlockType = "PQ ENROLLMENT CERTIFICATION"
+	schemeHeader           = "Scheme"
+	enrollmentIDHeader     = "Enrollment-ID"
+)
+
+// IsKeyDir returns true if the passed folder contains a post-quantum public key
+func IsKeyDir(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, PublicKeyFile))
+	return err == nil
+}
+
+// GenerateKeyPair generates a new key pair for the passed scheme and enrollment ID, and stores it in the passed folder.
+// If the certifier is not nil, it binds the enrollment ID to the public key.
+// A key pair generated without enrollment ID can be used as the key of a post-quantum CA.
+func GenerateKeyPair(dir string, scheme string, enrollmentID string, certifier driver.Signer) (*Identity, error) {
+	if len(scheme) == 0 {
+		scheme = DefaultScheme
+	}
+	s, err := SchemeByName(scheme)
+	if err != nil {
+		return nil, err
+	}
+	pk, sk, err := s.GenerateKey()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate [%s] key pair", s.Name())
+	}
+	pkRaw, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal public key")
+	}
+	skRaw, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal private key")
+	}
+	id := &Identity{Scheme: s.Name(), PublicKey: pkRaw, EnrollmentID: enrollmentID}
+	if certifier != nil {
+		if err := id.Certify(certifier); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, errors.Wrapf(err, "failed to create folder [%s]", dir)
+	}
+	blocks := []*pem.Block{{Type: publicKeyBlockType, Headers: map[string]string{schemeHeader: s.Name(), enrollmentIDHeader: enrollmentID}, Bytes: pkRaw}}
+	if len(id.Certification) != 0 {
+		blocks = append(blocks, &pem.Block{Type: certificationBlockType, Bytes: id.Certification})
+	}
+	if err := writePEM(filepath.Join(dir, PublicKeyFile), 0644, blocks...); err != nil {
+		return nil, err
+	}
+	if err := writePEM(filepath.Join(dir, PrivateKeyFile), 0600, &pem.Block{Type: privateKeyBlockType, Headers: map[string]string{schemeHeader: s.Name()}, Bytes: skRaw}); err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// LoadIdentity loads the identity whose public key, and certification if any, is stored in the passed folder
+func LoadIdentity(dir string) (*Identity, error) {
+	block, rest, err := readPEM(filepath.Join(dir, PublicKeyFile), publicKeyBlockType)
+	if err != nil {
+		return nil, err
+	}
+	id := &Identity{
+		Scheme:       block.Headers[schemeHeader],
+		PublicKey:    block.Bytes,
+		EnrollmentID: block.Headers[enrollmentIDHeader],
+	}
+	if certification, _ := pem.Decode(rest); certification != nil && certification.Type == certificationBlockType {
+		id.Certification = certification.Bytes
+	}
+	if _, _, err := id.publicKey(); err != nil {
+		return nil, errors.WithMessagef(err, "invalid public key in [%s]", dir)
+	}
+	return id, nil
+}
+
+// LoadSigner loads the private key stored in the passed folder and returns a signer for the passed identity.
+// It returns an error if the private key does not match the identity.
+func LoadSigner(dir string, id *Identity) (*Signer, error) {
+	block, _, err := readPEM(filepath.Join(dir, PrivateKeyFile), privateKeyBlockType)
+	if err != nil {
+		return nil, err
+	}
+	if block.Headers[schemeHeader] != id.Scheme {
+		return nil, errors.Errorf("private key scheme [%s] does not match public key scheme [%s]", block.Headers[schemeHeader], id.Scheme)
+	}
+	verifier, err := NewVerifier(id)
+	if err != nil {
+		return nil, err
+	}
+	sk, err := verifier.scheme.UnmarshalBinaryPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal [%s] private key", id.Scheme)
+	}
+	if !verifier.pk.Equal(sk.Public()) {
+		return nil, errors.Errorf("private key in [%s] does not match the public key", dir)
+	}
+	return &Signer{Verifier: verifier, sk: sk}, nil
+}
+
+func writePEM(path string, perm os.FileMode, blocks ...*pem.Block) error {
+	var raw []byte
+	for _, block := range blocks {
+		raw = append(raw, pem.EncodeToMemory(block)...)
+	}
+	if err := os.WriteFile(path, raw, perm); err != nil {
+		return errors.Wrapf(err, "failed to write [%s]", path)
+	}
+	return nil
+}
+
+// readPEM returns the first block of the passed file, that must be of the passed type, and the rest of the file
+func readPEM(path, blockType string) (*pem.Block, []byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read [%s]", path)
+	}
+	block, rest := pem.Decode(raw)
+	if block == nil || block.Type != blockType {
+		return nil, nil, errors.Errorf("no [%s] pem block found in [%s]", blockType, path)
+	}
+	return block, rest, nil
+}
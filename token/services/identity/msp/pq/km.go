@@ -0,0 +1,90 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pq
+
+import (
+	"fmt"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.MustGetLogger("token-sdk.services.identity.msp.pq")
+
+// AuditInfo carries the enrollment ID and the revocation handle of a post-quantum identity
+type AuditInfo = x509.AuditInfo
+
+// KeyManager manages a long-term post-quantum identity
+type KeyManager struct {
+	*Deserializer
+	signer *Signer
+	id     *Identity
+	typed  driver.Identity
+}
+
+// NewKeyManager returns a new KeyManager for the key stored in the passed folder.
+// If the folder contains the private key, then the key manager can also generate signatures, otherwise it cannot.
+func NewKeyManager(dir string, signerService driver2.SigService) (*KeyManager, error) {
+	id, err := LoadIdentity(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(id.EnrollmentID) == 0 {
+		return nil, errors.Errorf("no enrollment ID found in [%s]", dir)
+	}
+	typed, err := id.Typed()
+	if err != nil {
+		return nil, err
+	}
+	km := &KeyManager{Deserializer: &Deserializer{}, id: id, typed: typed}
+
+	signer, err := LoadSigner(dir, id)
+	if err != nil {
+		logger.Debugf("no signer found in [%s], load as verify only: [%s]", dir, err)
+		return km, nil
+	}
+	km.signer = signer
+	if signerService != nil {
+		logger.Debugf("register signer [%s][%s]", id.EnrollmentID, typed)
+		if err := signerService.RegisterSigner(typed, signer, signer.Verifier, nil); err != nil {
+			return nil, errors.Wrapf(err, "failed registering post-quantum signer")
+		}
+	}
+	return km, nil
+}
+
+func (k *KeyManager) IsRemote() bool {
+	return k.signer == nil
+}
+
+// Identity returns the typed identity and its audit info
+func (k *KeyManager) Identity([]byte) (driver.Identity, []byte, error) {
+	ai := &AuditInfo{
+		EID: k.id.EnrollmentID,
+		RH:  k.id.RevocationHandle(),
+	}
+	infoRaw, err := ai.Bytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	return k.typed, infoRaw, nil
+}
+
+func (k *KeyManager) EnrollmentID() string {
+	return k.id.EnrollmentID
+}
+
+func (k *KeyManager) Anonymous() bool {
+	return false
+}
+
+func (k *KeyManager) String() string {
+	return fmt.Sprintf("PQ KeyManager [%s] for EID [%s]", k.id.Scheme, k.id.EnrollmentID)
+}
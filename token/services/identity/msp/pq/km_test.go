@@ -0,0 +1,203 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pq
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
+	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/common"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+type config struct {
+	driver2.Config
+}
+
+func (c *config) TranslatePath(path string) string { return path }
+
+type kmProvider struct{ called bool }
+
+func (k *kmProvider) Get(*driver.IdentityConfiguration) (common2.KeyManager, error) {
+	k.called = true
+	return nil, nil
+}
+
+// newCA generates the key of a post-quantum CA and returns its serialized identity and its signer
+func newCA(t *testing.T) ([]byte, *Signer) {
+	dir := t.TempDir()
+	id, err := GenerateKeyPair(dir, "", "", nil)
+	assert.NoError(t, err)
+	signer, err := LoadSigner(dir, id)
+	assert.NoError(t, err)
+	raw, err := id.Bytes()
+	assert.NoError(t, err)
+	return raw, signer
+}
+
+func TestKeyManager(t *testing.T) {
+	caRaw, ca := newCA(t)
+	d, err := NewIdentityDeserializer([][]byte{caRaw})
+	assert.NoError(t, err)
+	for _, scheme := range []string{"Dilithium2", "ml-dsa-65"} {
+		dir := t.TempDir()
+		_, err := GenerateKeyPair(dir, scheme, "alice", ca)
+		assert.NoError(t, err)
+		assert.True(t, IsKeyDir(dir))
+
+		km, err := NewKeyManager(dir, nil)
+		assert.NoError(t, err)
+		assert.False(t, km.Anonymous())
+		assert.False(t, km.IsRemote())
+		assert.Equal(t, "alice", km.EnrollmentID())
+
+		id, auditInfo, err := km.Identity(nil)
+		assert.NoError(t, err)
+		assert.True(t, IsIdentity(id))
+		ti, err := identity.UnmarshalTypedIdentity(id)
+		assert.NoError(t, err)
+		assert.Equal(t, IdentityType, ti.Type)
+
+		// sign and verify
+		msg := []byte("hello world")
+		sigma, err := km.signer.Sign(msg)
+		assert.NoError(t, err)
+		verifier, err := (&Deserializer{}).DeserializeVerifier(id)
+		assert.NoError(t, err)
+		assert.NoError(t, verifier.Verify(msg, sigma))
+		assert.Error(t, verifier.Verify([]byte("another message"), sigma))
+		assert.Error(t, verifier.Verify(msg, sigma[1:]))
+		verifier, err = (&IdentityDeserializer{}).DeserializeVerifier(ti.Identity)
+		assert.NoError(t, err)
+		assert.NoError(t, verifier.Verify(msg, sigma))
+
+		// audit info
+		ai, err := (&AuditInfoDeserializer{}).DeserializeAuditInfo(auditInfo)
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", ai.EnrollmentID())
+		matcher, err := d.GetOwnerMatcher(auditInfo)
+		assert.NoError(t, err)
+		assert.NoError(t, matcher.Match(ti.Identity))
+
+		// another identity does not match
+		other, err := GenerateKeyPair(t.TempDir(), scheme, "bob", ca)
+		assert.NoError(t, err)
+		otherRaw, err := other.Bytes()
+		assert.NoError(t, err)
+		assert.Error(t, matcher.Match(otherRaw))
+	}
+}
+
+func TestVerifyOnlyKeyManager(t *testing.T) {
+	dir := t.TempDir()
+	_, err := GenerateKeyPair(dir, "", "alice", nil)
+	assert.NoError(t, err)
+	// a private key for a different public key is rejected
+	other := t.TempDir()
+	_, err = GenerateKeyPair(other, "", "alice", nil)
+	assert.NoError(t, err)
+	sk, err := os.ReadFile(filepath.Join(other, PrivateKeyFile))
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, PrivateKeyFile), sk, 0600))
+
+	km, err := NewKeyManager(dir, nil)
+	assert.NoError(t, err)
+	assert.True(t, km.IsRemote())
+}
+
+func TestKeyManagerProvider(t *testing.T) {
+	dir := t.TempDir()
+	_, err := GenerateKeyPair(filepath.Join(dir, "msp"), "", "alice", nil)
+	assert.NoError(t, err)
+
+	fallback := &kmProvider{}
+	kmp := NewKeyManagerProvider(&config{}, nil, fallback)
+
+	// the type is inferred from the content of the folder
+	km, err := kmp.Get(&driver.IdentityConfiguration{ID: "alice", URL: dir})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", km.EnrollmentID())
+	assert.False(t, fallback.called)
+
+	// explicit type without keys
+	_, err = kmp.Get(&driver.IdentityConfiguration{ID: "bob", URL: t.TempDir(), Type: string(IdentityType)})
+	assert.Error(t, err)
+
+	// everything else goes to the fallback
+	_, err = kmp.Get(&driver.IdentityConfiguration{ID: "charlie", URL: t.TempDir()})
+	assert.NoError(t, err)
+	assert.True(t, fallback.called)
+}
+
+func TestTypedIdentityDeserializer(t *testing.T) {
+	id, err := GenerateKeyPair(t.TempDir(), "", "issuer", nil)
+	assert.NoError(t, err)
+	typed, err := id.Typed()
+	assert.NoError(t, err)
+
+	d := NewTypedIdentityDeserializer(&IdentityDeserializer{})
+	_, err = d.DeserializeVerifier(typed)
+	assert.NoError(t, err)
+	raw, err := id.Bytes()
+	assert.NoError(t, err)
+	_, err = d.DeserializeVerifier(raw)
+	assert.NoError(t, err)
+	_, err = d.DeserializeVerifier([]byte("garbage"))
+	assert.Error(t, err)
+}
+
+func TestEnrollmentCertification(t *testing.T) {
+	caRaw, ca := newCA(t)
+	d, err := NewIdentityDeserializer([][]byte{caRaw})
+	assert.NoError(t, err)
+	_, untrusted := newCA(t)
+
+	match := func(dir string) error {
+		km, err := NewKeyManager(dir, nil)
+		assert.NoError(t, err)
+		id, auditInfo, err := km.Identity(nil)
+		assert.NoError(t, err)
+		ti, err := identity.UnmarshalTypedIdentity(id)
+		assert.NoError(t, err)
+		matcher, err := d.GetOwnerMatcher(auditInfo)
+		assert.NoError(t, err)
+		return matcher.Match(ti.Identity)
+	}
+
+	// the certification is stored with the public key
+	dir := t.TempDir()
+	_, err = GenerateKeyPair(dir, "", "alice", ca)
+	assert.NoError(t, err)
+	assert.NoError(t, match(dir))
+
+	// a self-asserted enrollment ID is rejected
+	dir = t.TempDir()
+	_, err = GenerateKeyPair(dir, "", "alice", nil)
+	assert.NoError(t, err)
+	assert.EqualError(t, match(dir), "invalid post-quantum identity: enrollment ID [alice] not certified")
+
+	// so is an enrollment ID certified by an unknown CA
+	dir = t.TempDir()
+	_, err = GenerateKeyPair(dir, "", "alice", untrusted)
+	assert.NoError(t, err)
+	assert.EqualError(t, match(dir), "invalid post-quantum identity: enrollment ID [alice] not certified by a known certifier")
+
+	// and a certification moved to another enrollment ID
+	alice, err := GenerateKeyPair(t.TempDir(), "", "alice", ca)
+	assert.NoError(t, err)
+	alice.EnrollmentID = "bob"
+	assert.Error(t, alice.VerifyCertification(d.CAs...))
+
+	// without CAs, no enrollment ID can be trusted
+	alice.EnrollmentID = "alice"
+	assert.NoError(t, alice.VerifyCertification(d.CAs...))
+	assert.Error(t, alice.VerifyCertification())
+}
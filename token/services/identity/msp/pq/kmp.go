@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package pq
+
+import (
+	"path/filepath"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/common"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/pkg/errors"
+)
+
+// KeyManagerProvider returns post-quantum key managers for the wallets whose type is IdentityType,
+// or whose folder contains a post-quantum key.
+// All other wallets are delegated to the fallback provider.
+type KeyManagerProvider struct {
+	config        driver2.Config
+	signerService driver2.SigService
+	fallback      common2.KeyManagerProvider
+}
+
+func NewKeyManagerProvider(config driver2.Config, signerService driver2.SigService, fallback common2.KeyManagerProvider) *KeyManagerProvider {
+	return &KeyManagerProvider{config: config, signerService: signerService, fallback: fallback}
+}
+
+func (k *KeyManagerProvider) Get(idConfig *driver.IdentityConfiguration) (common2.KeyManager, error) {
+	translatedPath := k.config.TranslatePath(idConfig.URL)
+	dir, found := k.keyDir(translatedPath)
+	switch {
+	case idConfig.Type == string(IdentityType):
+		if !found {
+			return nil, errors.Errorf("no post-quantum key found in [%s]", translatedPath)
+		}
+	case len(idConfig.Type) == 0 && found:
+		logger.Debugf("post-quantum key found in [%s]", dir)
+	default:
+		if k.fallback == nil {
+			return nil, errors.Errorf("identity type [%s] not supported", idConfig.Type)
+		}
+		return k.fallback.Get(idConfig)
+	}
+	return NewKeyManager(dir, k.signerService)
+}
+
+// keyDir looks for the post-quantum key in the passed folder and its msp sub-folder
+func (k *KeyManagerProvider) keyDir(path string) (string, bool) {
+	for _, dir := range []string{path, filepath.Join(path, "msp")} {
+		if IsKeyDir(dir) {
+			return dir, true
+		}
+	}
+	return "", false
+}
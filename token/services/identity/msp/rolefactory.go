@@ -14,8 +14,10 @@ import (
 	common2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/common"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/config"
 	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
 	idemix2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/idemix"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/idemix/msp"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
 	x5092 "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/x509"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
 	"github.com/pkg/errors"
@@ -150,7 +152,14 @@ func (f *RoleFactory) NewWrappedX509(role driver.IdentityRole, ignoreRemote bool
 func (f *RoleFactory) newX509WithType(role driver.IdentityRole, identityType string, ignoreRemote bool) (identity.Role, error) {
 	f.Logger.Debugf("create x509 role for [%s]", driver.IdentityRoleStrings[role])
 
-	kmp := x5092.NewKeyManagerProvider(f.Config, RoleToMSPID[role], f.SignerService, ignoreRemote)
+	// wallets can be backed by x509 certificates, by post-quantum keys, or by both (hybrid)
+	x509kmp := x5092.NewKeyManagerProvider(f.Config, RoleToMSPID[role], f.SignerService, ignoreRemote)
+	kmp := hybrid.NewKeyManagerProvider(
+		f.Config,
+		f.SignerService,
+		x509kmp,
+		pq.NewKeyManagerProvider(f.Config, f.SignerService, x509kmp),
+	)
 
 	identityDB, err := f.StorageProvider.OpenIdentityDB(f.TMSID)
 	if err != nil {
@@ -258,8 +267,9 @@ func (i *WrappingBindingInfo) Get() (driver.Identity, []byte, error) {
 			return nil, nil, errors.Wrapf(err, "failed to bind identity [%s] to [%s]", id, i.RootIdentity)
 		}
 	}
-	// wrap the backend identity, and bind it
-	if len(i.IdentityType) != 0 {
+	// wrap the backend identity, and bind it.
+	// Post-quantum and hybrid identities are already typed.
+	if len(i.IdentityType) != 0 && !pq.IsIdentity(id) && !hybrid.IsIdentity(id) {
 		typedIdentity, err := identity.WrapWithType(i.IdentityType, id)
 		if err != nil {
 			return nil, nil, errors.Wrapf(err, "failed to wrap identity [%s]", i.IdentityType)
@@ -6,11 +6,19 @@ SPDX-License-Identifier: Apache-2.0
 
 package msp
 
-import "github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
+import (
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/hybrid"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/identity/msp/pq"
+)
 
 const (
 	// X509Identity identifies an X509-based identity
 	X509Identity identity.Type = "x509"
 	// IdemixIdentity identifies an idemix identity
 	IdemixIdentity identity.Type = "idemix"
+	// DilithiumIdentity identifies a post-quantum identity
+	DilithiumIdentity identity.Type = pq.IdentityType
+	// HybridIdentity identifies a hybrid identity, X509 plus post-quantum
+	HybridIdentity identity.Type = hybrid.IdentityType
 )
@@ -60,6 +60,11 @@ func (t *FinalityListener) OnStatus(ctx context.Context, txID string, status int
 	}
 }
 
+// Process applies the passed status change once, without retrying on failure
+func (t *FinalityListener) Process(ctx context.Context, txID string, status int, message string, tokenRequestHash []byte) error {
+	return t.runOnStatus(ctx, txID, status, message, tokenRequestHash)
+}
+
 func (t *FinalityListener) runOnStatus(ctx context.Context, txID string, status int, message string, tokenRequestHash []byte) error {
 	newCtx, span := t.tracer.Start(ctx, "on_status")
 	defer span.End()
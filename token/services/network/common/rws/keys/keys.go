@@ -11,6 +11,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"strconv"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common/rws/translator"
@@ -62,6 +63,27 @@ func (t *Translator) CreateOutputKey(id string, index uint64) (translator.Key, e
 	return createCompositeKey(id, []string{strconv.FormatUint(index, 10)})
 }
 
+// GetOutputID returns the transaction id and the index of the output stored under the passed key.
+// It returns an error if the passed key is not an output key.
+func (t *Translator) GetOutputID(k string) (string, uint64, error) {
+	if len(k) < 2 || k[:1] != compositeKeyNamespace || k[len(k)-1] != minUnicodeRuneValue {
+		return "", 0, errors.Errorf("key [%s] is not a composite key", k)
+	}
+	components := strings.Split(k[1:len(k)-1], string(rune(minUnicodeRuneValue)))
+	if len(components) != 2 {
+		return "", 0, errors.Errorf("key [%s] should contain 2 components, got [%d]", k, len(components))
+	}
+	switch components[0] {
+	case OutputSNKeyPrefix, TokenRequestKeyPrefix, InputSerialNumberPrefix, IssueActionMetadataPrefix, TransferActionMetadataPrefix:
+		return "", 0, errors.Errorf("key [%s] is not an output key", k)
+	}
+	index, err := strconv.ParseUint(components[1], 10, 64)
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "key [%s] is not an output key", k)
+	}
+	return components[0], index, nil
+}
+
 func (t *Translator) GetTransferMetadataSubKey(k string) (translator.Key, error) {
 	prefix, components, err := splitCompositeKey(k)
 	if err != nil {
@@ -42,6 +42,8 @@ type KeyTranslator interface {
 	CreateTransferActionMetadataKey(subkey string) (Key, error)
 	// GetTransferMetadataSubKey returns the subkey in the given transfer action metadata key
 	GetTransferMetadataSubKey(k string) (Key, error)
+	// GetOutputID returns the transaction id and the index of the output stored under the passed key
+	GetOutputID(k string) (string, uint64, error)
 }
 
 // RWSet interface, used to read from, and write to, a rwset.
@@ -175,6 +177,11 @@ func (h *HashedKeyTranslator) GetTransferMetadataSubKey(k string) (Key, error) {
 	return h.hash(5, key)
 }
 
+// GetOutputID always fails, because hashed keys cannot be parsed
+func (h *HashedKeyTranslator) GetOutputID(k string) (string, uint64, error) {
+	return "", 0, errors.Errorf("key [%s] is hashed, cannot extract the output id", k)
+}
+
 func (h *HashedKeyTranslator) CreateInputSNKey(id string) (Key, error) {
 	k, err := h.KT.CreateInputSNKey(id)
 	if err != nil {
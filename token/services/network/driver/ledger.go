@@ -6,8 +6,55 @@ SPDX-License-Identifier: Apache-2.0
 
 package driver
 
+import (
+	"context"
+	"errors"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+)
+
+// ErrScanNotSupported is returned by ScanLedger when the ledger cannot be walked block by block
+var ErrScanNotSupported = errors.New("ledger scan not supported")
+
 // Ledger models the ledger service
 type Ledger interface {
 	// Status returns the status of the transaction
 	Status(id string) (ValidationCode, error)
 }
+
+// LedgerTransaction is a transaction, found on the ledger, carrying a token request for a given namespace
+type LedgerTransaction struct {
+	// TxID is the transaction id
+	TxID string
+	// Status is the validation code of the transaction
+	Status ValidationCode
+	// Message is the validation message, if any
+	Message string
+	// RequestHash is the hash of the token request committed by the transaction
+	RequestHash []byte
+	// Outputs are the outputs the transaction wrote on the ledger, indexed by their position in the transaction.
+	// It is empty if the network does not expose them.
+	Outputs map[uint64][]byte
+	// Spent are the outputs of previous transactions the transaction deleted from the ledger.
+	// It is empty if the network does not expose them or the transaction graph is hidden.
+	Spent []*token.ID
+}
+
+// LedgerBlock is a block of the ledger with the token transactions it contains for a given namespace
+type LedgerBlock struct {
+	// Number is the block number
+	Number uint64
+	// Transactions are the token transactions in the block, in the order they appear in the block
+	Transactions []*LedgerTransaction
+}
+
+// ScanCallback is invoked for each block scanned, also for the blocks without token transactions.
+// If it returns true, the scan stops.
+type ScanCallback func(ctx context.Context, block *LedgerBlock) (bool, error)
+
+// TransactionLookup is implemented by the networks whose ledger cannot be walked block by block,
+// so that the token transactions known locally can be looked up by id instead
+type TransactionLookup interface {
+	// LookupTransaction returns the token transaction with the passed id in the passed namespace, nil if it is not on the ledger
+	LookupTransaction(ctx context.Context, namespace string, txID string) (*LedgerTransaction, error)
+}
@@ -92,6 +92,26 @@ type Network interface {
 
 	// ProcessNamespace indicates to the commit pipeline to process all transaction in the passed namespace
 	ProcessNamespace(namespace string) error
+
+	// ScanLedger walks the ledger starting from the passed block and invokes the callback for each block
+	// with the token transactions in the passed namespace.
+	// The scan continues with the new blocks as they get committed,
+	// and stops when the callback returns true or the context is done.
+	ScanLedger(ctx context.Context, namespace string, fromBlock uint64, callback ScanCallback) error
+}
+
+// ApprovalRequest is the token request of a TMS, approved together with the token requests of other TMSs
+// in a single network transaction
+type ApprovalRequest struct {
+	TMS        *token2.ManagementService
+	RequestRaw []byte
+}
+
+// BundleApprover is implemented by the networks that can approve, in a single network transaction,
+// the token requests of several TMSs on the same channel, so that they are committed atomically
+type BundleApprover interface {
+	// RequestApprovals requests approval for the passed requests, sharing the passed transaction id, and returns the returned envelope
+	RequestApprovals(context view.Context, requests []*ApprovalRequest, signer view.Identity, txID TxID) (Envelope, error)
 }
 
 type FinalityListenerManager interface {
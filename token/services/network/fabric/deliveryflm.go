@@ -18,6 +18,7 @@ import (
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common/rws/translator"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/trace"
@@ -80,6 +81,8 @@ type deliveryBasedFLM struct {
 	mu        sync.RWMutex
 	listeners CacheMap[translator.TxID, []listenerEntry]
 	txInfos   CacheMap[translator.TxID, txInfo]
+	// allListeners are invoked for any transaction and never removed automatically
+	allListeners []listenerEntry
 }
 
 func (m *deliveryBasedFLM) onBlock(ctx context.Context, block *common.Block) error {
@@ -108,7 +111,9 @@ func (m *deliveryBasedFLM) onBlock(ctx context.Context, block *common.Block) err
 				invokedTxIDs = append(invokedTxIDs, info.txID)
 			}
 			logger.Infof("Invoking %d listeners for [%s]", len(listeners), info.txID)
-			for _, entry := range listeners {
+			entries := make([]listenerEntry, 0, len(listeners)+len(m.allListeners))
+			entries = append(append(entries, listeners...), m.allListeners...)
+			for _, entry := range entries {
 				if len(entry.namespace) == 0 || len(ns) == 0 || entry.namespace == ns {
 					invokedListeners++
 					go entry.listener.OnStatus(ctx, info.txID, info.status, info.message, info.requestHash)
@@ -139,6 +144,13 @@ func (m *deliveryBasedFLM) onBlock(ctx context.Context, block *common.Block) err
 }
 
 func (m *deliveryBasedFLM) AddFinalityListener(namespace string, txID string, listener driver.FinalityListener) error {
+	if len(txID) == 0 {
+		logger.Infof("Adding listener for all transactions in [%s]", namespace)
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.allListeners = append(m.allListeners, listenerEntry{namespace, listener})
+		return nil
+	}
 	m.mu.RLock()
 	if txInfo, ok := m.txInfos.Get(txID); ok {
 		defer m.mu.RUnlock()
@@ -165,6 +177,15 @@ func (m *deliveryBasedFLM) RemoveFinalityListener(txID string, listener driver.F
 	logger.Infof("Manually invoked listener removal for [%s]", txID)
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if len(txID) == 0 {
+		for i, entry := range m.allListeners {
+			if entry.listener == listener {
+				m.allListeners = append(m.allListeners[:i], m.allListeners[i+1:]...)
+				return nil
+			}
+		}
+		return errors.Errorf("could not find listener [%v] for all transactions", listener)
+	}
 	ok := m.listeners.Update(txID, func(_ bool, listeners []listenerEntry) (bool, []listenerEntry) {
 		for i, entry := range listeners {
 			if entry.listener == listener {
@@ -184,6 +205,8 @@ type txInfo struct {
 	status      driver.TxStatus
 	message     string
 	requestHash []byte
+	outputs     map[uint64][]byte
+	spent       []*token.ID
 }
 
 type parallelBlockMapper struct {
@@ -245,12 +268,28 @@ func (m *parallelBlockMapper) mapTxInfo(ctx context.Context, tx []byte, block *c
 	for ns, write := range rwSet.WriteSet.Writes {
 		logger.Infof("TX [%s:%s] has %d writes", chdr.TxId, ns, len(write))
 		if requestHash, ok := write[key]; ok {
-			txInfos[ns] = txInfo{
+			info := txInfo{
 				txID:        chdr.TxId,
 				status:      finalityEvent.ValidationCode,
 				message:     finalityEvent.ValidationMessage,
 				requestHash: requestHash,
 			}
+			for k, v := range write {
+				outputTxID, index, err := m.keyTranslator.GetOutputID(k)
+				if err != nil {
+					continue
+				}
+				switch {
+				case outputTxID == chdr.TxId && len(v) != 0:
+					if info.outputs == nil {
+						info.outputs = map[uint64][]byte{}
+					}
+					info.outputs[index] = v
+				case outputTxID != chdr.TxId && len(v) == 0:
+					info.spent = append(info.spent, &token.ID{TxId: outputTxID, Index: index})
+				}
+			}
+			txInfos[ns] = info
 		} else {
 			logger.Warnf("TX [%s:%s] did not have key [%s]. Found: %v", chdr.TxId, ns, key, write.Keys())
 		}
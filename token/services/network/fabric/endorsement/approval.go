@@ -35,6 +35,15 @@ type RequestApprovalView struct {
 	Nonce []byte
 	// Endorsers are the identities of the FSC node that play the role of endorser
 	Endorsers []view.Identity
+	// Requests, if not empty, are the token requests of several TMSs to approve together, RequestRaw is then ignored.
+	// TMSID must be the TMS of one of them.
+	Requests []*BundledRequest
+}
+
+// BundledRequest is the token request of a TMS, approved together with the token requests of other TMSs
+type BundledRequest struct {
+	TMSID      token2.TMSID
+	RequestRaw []byte
 }
 
 func (r *RequestApprovalView) Call(context view.Context) (interface{}, error) {
@@ -55,11 +64,17 @@ func (r *RequestApprovalView) Call(context view.Context) (interface{}, error) {
 	if err := tx.EndorseProposal(); err != nil {
 		return nil, errors.WithMessagef(err, "failed to endorse proposal")
 	}
-	if err := tx.SetTransientState("tmsID", tms.ID()); err != nil {
-		return nil, errors.WithMessagef(err, "failed to set TMS ID transient")
-	}
-	if err := tx.SetTransient("token_request", r.RequestRaw); err != nil {
-		return nil, errors.WithMessagef(err, "failed to set token request transient")
+	if len(r.Requests) != 0 {
+		if err := tx.SetTransientState("bundle", r.Requests); err != nil {
+			return nil, errors.WithMessagef(err, "failed to set token requests transient")
+		}
+	} else {
+		if err := tx.SetTransientState("tmsID", tms.ID()); err != nil {
+			return nil, errors.WithMessagef(err, "failed to set TMS ID transient")
+		}
+		if err := tx.SetTransient("token_request", r.RequestRaw); err != nil {
+			return nil, errors.WithMessagef(err, "failed to set token request transient")
+		}
 	}
 	if len(r.RequestAnchor) != 0 {
 		if err := tx.SetTransient("RequestAnchor", []byte(r.RequestAnchor)); err != nil {
@@ -127,66 +142,84 @@ func (r *RequestApprovalResponderView) Call(context view.Context) (interface{},
 
 	logger.Debugf("Respond to request of approval for tx [%s][%s]", tx.ID(), hash.Hashable(raw))
 
-	var tmsID token2.TMSID
-	if err := tx.GetTransientState("tmsID", &tmsID); err != nil {
-		return nil, errors.WithMessagef(err, "failed to get TMS ID from transient [%s]", tx.ID())
-	}
-	requestRaw := tx.GetTransient("token_request")
-	if len(requestRaw) == 0 {
-		return nil, errors.Errorf("failed to get token request from transient [%s], it is empty", tx.ID())
+	var requests []*BundledRequest
+	if len(tx.GetTransient("bundle")) != 0 {
+		if err := tx.GetTransientState("bundle", &requests); err != nil {
+			return nil, errors.WithMessagef(err, "failed to get token requests from transient [%s]", tx.ID())
+		}
+		if len(requests) == 0 {
+			return nil, errors.Errorf("failed to get token requests from transient [%s], it is empty", tx.ID())
+		}
+	} else {
+		var tmsID token2.TMSID
+		if err := tx.GetTransientState("tmsID", &tmsID); err != nil {
+			return nil, errors.WithMessagef(err, "failed to get TMS ID from transient [%s]", tx.ID())
+		}
+		requestRaw := tx.GetTransient("token_request")
+		if len(requestRaw) == 0 {
+			return nil, errors.Errorf("failed to get token request from transient [%s], it is empty", tx.ID())
+		}
+		requests = []*BundledRequest{{TMSID: tmsID, RequestRaw: requestRaw}}
 	}
 	requestAnchor := string(tx.GetTransient("RequestAnchor"))
 	if len(requestAnchor) == 0 {
 		requestAnchor = tx.ID()
 	}
 
-	logger.Debugf("evaluate token request on TMS [%s]", tmsID)
-	tms := token2.GetManagementService(context, token2.WithTMSID(tmsID))
-	if tms == nil {
-		return nil, errors.Errorf("cannot find TMS for [%s]", tmsID)
-	}
-
 	rws, err := tx.RWSet()
 	if err != nil {
 		return nil, errors.WithMessagef(err, "failed to get rws for tx [%s]", tx.ID())
 	}
 	defer rws.Done()
 
-	fns, err := fabric2.GetFabricNetworkService(context, tms.Network())
-	if err != nil {
-		return nil, errors.WithMessagef(err, "cannot find fabric network for [%s]", tms.Network())
-	}
+	var endorserIDs []view.Identity
+	namespaces := map[string]bool{}
+	for _, request := range requests {
+		logger.Debugf("evaluate token request on TMS [%s]", request.TMSID)
+		tms := token2.GetManagementService(context, token2.WithTMSID(request.TMSID))
+		if tms == nil {
+			return nil, errors.Errorf("cannot find TMS for [%s]", request.TMSID)
+		}
+		if namespaces[tms.Namespace()] {
+			return nil, errors.Errorf("more than one token request for namespace [%s] in tx [%s]", tms.Namespace(), tx.ID())
+		}
+		namespaces[tms.Namespace()] = true
 
-	// validate token request
-	logger.Debugf("Validate TX [%s]", tx.ID())
-	actions, validationMetadata, err := r.validate(context, tms, tx, requestAnchor, requestRaw, func(id token.ID) ([]byte, error) {
-		key, err := r.keyTranslator.CreateOutputKey(id.TxId, id.Index)
+		fns, err := fabric2.GetFabricNetworkService(context, tms.Network())
 		if err != nil {
-			return nil, errors.WithMessagef(err, "failed to create token key for id [%s]", id)
+			return nil, errors.WithMessagef(err, "cannot find fabric network for [%s]", tms.Network())
 		}
-		return rws.GetDirectState(tms.Namespace(), key)
-	})
 
-	if err != nil {
-		return nil, err
-	}
+		// validate token request
+		logger.Debugf("Validate TX [%s] on [%s]", tx.ID(), tms.ID())
+		actions, validationMetadata, err := r.validate(context, tms, tx, requestAnchor, request.RequestRaw, func(id token.ID) ([]byte, error) {
+			key, err := r.keyTranslator.CreateOutputKey(id.TxId, id.Index)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed to create token key for id [%s]", id)
+			}
+			return rws.GetDirectState(tms.Namespace(), key)
+		})
+		if err != nil {
+			return nil, err
+		}
 
-	// endorse
-	logger.Debugf("Endorse TX [%s]", tx.ID())
-	endorserID, err := r.endorserID(tms, fns)
-	if err != nil {
-		return nil, err
-	}
+		// each namespace is endorsed with the endorser identity of its TMS
+		endorserID, err := r.endorserID(tms, fns)
+		if err != nil {
+			return nil, err
+		}
+		endorserIDs = appendEndorserID(endorserIDs, endorserID)
 
-	// write actions into the transaction
-	logger.Debugf("Translate TX [%s]", tx.ID())
-	err = r.translate(tms, tx, validationMetadata, rws, actions...)
-	if err != nil {
-		return nil, err
+		// write actions into the transaction
+		logger.Debugf("Translate TX [%s] on [%s]", tx.ID(), tms.ID())
+		err = r.translate(tms, tx, validationMetadata, rws, actions...)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	logger.Debugf("Endorse proposal for TX [%s]", tx.ID())
-	endorsementResult, err := context.RunView(endorser.NewEndorsementOnProposalResponderView(tx, endorserID))
+	endorsementResult, err := context.RunView(endorser.NewEndorsementOnProposalResponderView(tx, endorserIDs...))
 	if err != nil {
 		logger.Errorf("failed to respond to endorsement [%s]", err)
 	}
@@ -194,6 +227,16 @@ func (r *RequestApprovalResponderView) Call(context view.Context) (interface{},
 	return endorsementResult, err
 }
 
+// appendEndorserID appends the passed endorser identity, if not already in the list
+func appendEndorserID(ids []view.Identity, id view.Identity) []view.Identity {
+	for _, existing := range ids {
+		if existing.Equal(id) {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
 func (r *RequestApprovalResponderView) translate(
 	tms *token2.ManagementService,
 	tx *endorser.Transaction,
@@ -126,3 +126,31 @@ func (e *FSCService) Endorse(context view.Context, requestRaw []byte, signer vie
 	}
 	return env, nil
 }
+
+// EndorseBundle requests the approval of the passed token requests of several TMSs, the one of this service included,
+// in a single transaction. Each endorser must be able to validate all the requests.
+func (e *FSCService) EndorseBundle(context view.Context, requests []*BundledRequest, endorsers []view.Identity, txID driver.TxID) (driver.Envelope, error) {
+	logger.Debugf("request approval of [%d] token requests via fts endorsers: [%d]...", len(requests), len(endorsers))
+	envBoxed, err := e.ViewManager.InitiateView(&RequestApprovalView{
+		TMSID:     e.TmsID,
+		TxID:      txID,
+		Endorsers: endorsers,
+		Requests:  requests,
+	}, context.Context())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to request approval")
+	}
+	env, ok := envBoxed.(driver.Envelope)
+	if !ok {
+		return nil, errors.Errorf("expected driver.Envelope, got [%T]", envBoxed)
+	}
+	return env, nil
+}
+
+// BundleEndorsers returns the endorsers to contact, according to the policy, when this service takes part in a bundle
+func (e *FSCService) BundleEndorsers() []view.Identity {
+	if e.PolicyType == OneOutNPolicy {
+		return []view.Identity{e.Endorsers[rand.Intn(len(e.Endorsers))]}
+	}
+	return e.Endorsers
+}
@@ -67,6 +67,16 @@ type Service interface {
 	Endorse(context view.Context, requestRaw []byte, signer view.Identity, txID driver.TxID) (driver.Envelope, error)
 }
 
+// BundleService is implemented by the endorsement services that can approve, in a single transaction,
+// the token requests of several TMSs
+type BundleService interface {
+	Service
+	// BundleEndorsers returns the endorsers of this service to contact for a bundle
+	BundleEndorsers() []view.Identity
+	// EndorseBundle requests the approval of the passed token requests to the passed endorsers
+	EndorseBundle(context view.Context, requests []*BundledRequest, endorsers []view.Identity, txID driver.TxID) (driver.Envelope, error)
+}
+
 type loader struct {
 	fnsp             *fabric.NetworkServiceProvider
 	configService    common.Configuration
@@ -15,6 +15,7 @@ import (
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/common/utils/lazy"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/fabric/core/generic/fabricutils"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
@@ -28,6 +29,7 @@ import (
 	tokens2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/tokens"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/pkg/errors"
 	"go.opentelemetry.io/otel/trace"
@@ -40,6 +42,10 @@ const (
 	AreTokensSpent            = "areTokensSpent"
 	maxRetries                = 3
 	retryWaitDuration         = 1 * time.Second
+	// scanPollInterval is how often ScanLedger initially polls for new blocks once the end of the ledger has been reached
+	scanPollInterval = time.Second
+	// scanMaxPollInterval bounds the backoff of the polling for new blocks
+	scanMaxPollInterval = 30 * time.Second
 )
 
 var logger = logging.MustGetLogger("token-sdk.network.fabric")
@@ -274,6 +280,43 @@ func (n *Network) RequestApproval(context view.Context, tms *token2.ManagementSe
 	return endorsement.Endorse(context, requestRaw, signer, txID)
 }
 
+// RequestApprovals requests the approval of the token requests of several TMSs in a single transaction.
+// All the TMSs must use FSC endorsement, and each endorser involved must be able to validate all the requests.
+func (n *Network) RequestApprovals(context view.Context, requests []*driver.ApprovalRequest, signer view.Identity, txID driver.TxID) (driver.Envelope, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("no token requests to approve")
+	}
+	var first endorsement.BundleService
+	var endorsers []view.Identity
+	seen := map[string]bool{}
+	bundle := make([]*endorsement.BundledRequest, len(requests))
+	for i, request := range requests {
+		tmsID := request.TMS.ID()
+		if tmsID.Network != n.Name() || tmsID.Channel != n.Channel() {
+			return nil, errors.Errorf("tms [%s] is not on network [%s:%s]", tmsID, n.Name(), n.Channel())
+		}
+		service, err := n.endorsementServiceProvider.Get(tmsID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "network not connected [%s]", tmsID)
+		}
+		bs, ok := service.(endorsement.BundleService)
+		if !ok {
+			return nil, errors.Errorf("endorsement service of [%s] cannot approve token requests of several TMSs together", tmsID)
+		}
+		if first == nil {
+			first = bs
+		}
+		for _, endorser := range bs.BundleEndorsers() {
+			if !seen[endorser.UniqueID()] {
+				seen[endorser.UniqueID()] = true
+				endorsers = append(endorsers, endorser)
+			}
+		}
+		bundle[i] = &endorsement.BundledRequest{TMSID: tmsID, RequestRaw: request.RequestRaw}
+	}
+	return first.EndorseBundle(context, bundle, endorsers, txID)
+}
+
 func (n *Network) ComputeTxID(id *driver.TxID) string {
 	logger.Debugf("compute tx id for [%s]", id.String())
 	temp := &fabric.TxID{
@@ -397,6 +440,116 @@ func (n *Network) ProcessNamespace(namespace string) error {
 	return nil
 }
 
+// ScanLedger walks the ledger starting from the passed block.
+// The blocks are fetched by number from the peer, so that the scan starts from the passed block rather than from the first one.
+// When the end of the ledger is reached, the peer is polled for new blocks, starting every scanPollInterval
+// and backing off up to scanMaxPollInterval. Any other error stops the scan.
+func (n *Network) ScanLedger(ctx context.Context, namespace string, fromBlock uint64, callback driver.ScanCallback) error {
+	mapper := NewParallelResponseMapper(10, n.n.Name(), n.keyTranslator)
+	wait := scanPollInterval
+	for number := fromBlock; ; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		block, err := n.blockByNumber(number)
+		if err != nil {
+			if !isBlockNotAvailable(err) {
+				return errors.WithMessagef(err, "failed to get block [%d]", number)
+			}
+			logger.Debugf("block [%d] not available yet, wait [%s]", number, wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			wait = min(2*wait, scanMaxPollInterval)
+			continue
+		}
+		wait = scanPollInterval
+		txs, err := mapper.Map(ctx, block)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to map block [%d]", number)
+		}
+		lb := &driver.LedgerBlock{Number: number}
+		for _, txInfos := range txs {
+			info, ok := txInfos[namespace]
+			if !ok {
+				continue
+			}
+			lb.Transactions = append(lb.Transactions, &driver.LedgerTransaction{
+				TxID:        info.txID,
+				Status:      info.status,
+				Message:     info.message,
+				RequestHash: info.requestHash,
+				Outputs:     info.outputs,
+				Spent:       info.spent,
+			})
+		}
+		stop, err := callback(ctx, lb)
+		if err != nil || stop {
+			return err
+		}
+		number++
+	}
+}
+
+// isBlockNotAvailable returns true if the passed error reports that the requested block has not been committed yet
+func isBlockNotAvailable(err error) bool {
+	return strings.Contains(err.Error(), "no such block number")
+}
+
+// blockByNumber fetches the block with the passed number from the peer.
+// The ledger returns the block without its metadata, therefore the validation flags are rebuilt
+// from the validation code of each transaction.
+func (n *Network) blockByNumber(number uint64) (*common.Block, error) {
+	l := n.ch.Ledger()
+	b, err := l.GetBlockByNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	var data [][]byte
+	var flags []byte
+	for i := 0; ; i++ {
+		raw, ok := blockDataAt(b, i)
+		if !ok {
+			break
+		}
+		data = append(data, raw)
+		flag := byte(peer.TxValidationCode_VALID)
+		_, _, chdr, err := fabricutils.UnmarshalTx(raw)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed unmarshalling tx [%d:%d]", number, i)
+		}
+		if common.HeaderType(chdr.Type) == common.HeaderType_ENDORSER_TRANSACTION {
+			tx, err := l.GetTransactionByID(chdr.TxId)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "failed getting transaction [%s]", chdr.TxId)
+			}
+			flag = byte(tx.ValidationCode())
+		}
+		flags = append(flags, flag)
+	}
+	metadata := make([][]byte, common.BlockMetadataIndex_TRANSACTIONS_FILTER+1)
+	metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER] = flags
+	return &common.Block{
+		Header:   &common.BlockHeader{Number: number},
+		Data:     &common.BlockData{Data: data},
+		Metadata: &common.BlockMetadata{Metadata: metadata},
+	}, nil
+}
+
+// blockDataAt returns the transaction at the passed index of the passed block, false if the block has fewer transactions.
+// The block does not expose the number of its transactions, therefore the end of the block is detected by recovering
+// from the out-of-range access.
+func blockDataAt(b *fabric.Block, i int) (raw []byte, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			raw, ok = nil, false
+		}
+	}()
+	return b.DataAt(i), true
+}
+
 type FinalityListener struct {
 	flm           driver.FinalityListenerManager
 	root          driver.FinalityListener
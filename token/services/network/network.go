@@ -33,8 +33,17 @@ const (
 
 var logger = logging.MustGetLogger("token-sdk.network")
 
+// ErrScanNotSupported is returned by ScanLedger when the ledger cannot be walked block by block
+var ErrScanNotSupported = driver.ErrScanNotSupported
+
 type UnspentTokensIterator = driver.UnspentTokensIterator
 
+type (
+	LedgerBlock       = driver.LedgerBlock
+	LedgerTransaction = driver.LedgerTransaction
+	ScanCallback      = driver.ScanCallback
+)
+
 // FinalityListener is the interface that must be implemented to receive transaction status change notifications
 type FinalityListener interface {
 	// OnStatus is called when the status of a transaction changes
@@ -320,6 +329,27 @@ func (n *Network) RequestApproval(context view.Context, tms *token.ManagementSer
 	return &Envelope{e: env}, nil
 }
 
+// ApprovalRequest is the token request of a TMS, approved together with the token requests of other TMSs
+type ApprovalRequest = driver.ApprovalRequest
+
+// RequestApprovals requests approval, in a single network transaction, for the passed token requests of several TMSs,
+// and returns the returned envelope.
+// It returns an error if the network does not support this operation.
+func (n *Network) RequestApprovals(context view.Context, requests []*ApprovalRequest, signer view.Identity, txID TxID) (*Envelope, error) {
+	approver, ok := n.n.(driver.BundleApprover)
+	if !ok {
+		return nil, errors.Errorf("network [%s] does not support approving token requests of several TMSs together", n.n.Name())
+	}
+	env, err := approver.RequestApprovals(context, requests, signer, driver.TxID{
+		Nonce:   txID.Nonce,
+		Creator: txID.Creator,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Envelope{e: env}, nil
+}
+
 // ComputeTxID computes the transaction ID in the target network format for the given tx id
 func (n *Network) ComputeTxID(id *TxID) string {
 	temp := &driver.TxID{
@@ -384,6 +414,26 @@ func (n *Network) ProcessNamespace(namespace string) error {
 	return n.n.ProcessNamespace(namespace)
 }
 
+// ScanLedger walks the ledger starting from the passed block and invokes the callback for each block
+// with the token transactions in the passed namespace.
+// The scan stops when the callback returns true or the context is done.
+// It returns an error wrapping ErrScanNotSupported if the ledger cannot be walked block by block.
+func (n *Network) ScanLedger(ctx context.Context, namespace string, fromBlock uint64, callback ScanCallback) error {
+	return n.n.ScanLedger(ctx, namespace, fromBlock, callback)
+}
+
+// LookupTransaction returns, if the network supports it, the token transaction with the passed id as found on the ledger,
+// nil if it is not on the ledger.
+// It returns false if the network does not support this operation.
+func (n *Network) LookupTransaction(ctx context.Context, namespace string, txID string) (*LedgerTransaction, bool, error) {
+	lookup, ok := n.n.(driver.TransactionLookup)
+	if !ok {
+		return nil, false, nil
+	}
+	tx, err := lookup.LookupTransaction(ctx, namespace, txID)
+	return tx, true, err
+}
+
 func (n *Network) Normalize(opt *token.ServiceOptions) (*token.ServiceOptions, error) {
 	return n.n.Normalize(opt)
 }
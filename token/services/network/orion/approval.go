@@ -36,6 +36,23 @@ type ApprovalRequest struct {
 	Namespace string
 	TxID      string
 	Request   []byte
+	// Bundle, if not empty, lists the token requests of several namespaces to approve in a single transaction.
+	// In this case, Namespace and Request are ignored.
+	Bundle []*BundledApprovalRequest
+}
+
+// BundledApprovalRequest is the token request of a namespace approved together with the ones of other namespaces
+type BundledApprovalRequest struct {
+	Namespace string
+	Request   []byte
+}
+
+// requests returns the token requests to approve, one per namespace
+func (r *ApprovalRequest) requests() []*BundledApprovalRequest {
+	if len(r.Bundle) != 0 {
+		return r.Bundle
+	}
+	return []*BundledApprovalRequest{{Namespace: r.Namespace, Request: r.Request}}
 }
 
 type ApprovalResponse struct {
@@ -47,6 +64,7 @@ type RequestApprovalView struct {
 	Network    string
 	Namespace  string
 	RequestRaw []byte
+	Bundle     []*BundledApprovalRequest
 	Signer     view.Identity
 	TxID       string
 }
@@ -69,6 +87,24 @@ func NewRequestApprovalView(
 	}
 }
 
+// NewRequestBundleApprovalView returns a new RequestApprovalView asking the approval of the token requests
+// of several namespaces in a single transaction
+func NewRequestBundleApprovalView(
+	dbManager *DBManager,
+	network string,
+	bundle []*BundledApprovalRequest,
+	signer view.Identity,
+	txID string,
+) *RequestApprovalView {
+	return &RequestApprovalView{
+		DBManager: dbManager,
+		Network:   network,
+		Bundle:    bundle,
+		Signer:    signer,
+		TxID:      txID,
+	}
+}
+
 func (r *RequestApprovalView) Call(context view.Context) (interface{}, error) {
 	span := context.StartSpan("approval_request_view")
 	defer span.End()
@@ -86,6 +122,7 @@ func (r *RequestApprovalView) Call(context view.Context) (interface{}, error) {
 		Namespace: r.Namespace,
 		TxID:      r.TxID,
 		Request:   r.RequestRaw,
+		Bundle:    r.Bundle,
 	}
 	span.AddEvent("send_approval_request")
 	if err := session.SendWithContext(context.Context(), request); err != nil {
@@ -146,14 +183,23 @@ func (r *RequestApprovalResponderView) process(context view.Context, request *Ap
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get session manager for network [%s]", request.Network)
 	}
-	span.AddEvent("fetch_public_params")
-	pp, err := sm.PublicParameters(ds, request.Namespace)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get public parameters for network [%s]", request.Network)
-	}
-	validator, err := ds.NewValidator(token.TMSID{Network: request.Network, Namespace: request.Namespace}, pp)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create validator")
+	requests := request.requests()
+	validators := make([]driver.Validator, len(requests))
+	namespaces := map[string]bool{}
+	for i, req := range requests {
+		if namespaces[req.Namespace] {
+			return nil, errors.Errorf("more than one token request for namespace [%s] in tx [%s]", req.Namespace, request.TxID)
+		}
+		namespaces[req.Namespace] = true
+		span.AddEvent("fetch_public_params")
+		pp, err := sm.PublicParameters(ds, req.Namespace)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get public parameters for network [%s]", request.Network)
+		}
+		validators[i], err = ds.NewValidator(token.TMSID{Network: request.Network, Namespace: req.Namespace}, pp)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create validator")
+		}
 	}
 
 	// commit
@@ -169,7 +215,7 @@ func (r *RequestApprovalResponderView) process(context view.Context, request *Ap
 	validateErr := runner.RunWithErrors(func() (bool, error) {
 		span.AddEvent("try_validate")
 		var retry bool
-		envelopeRaw, retry, err = r.validate(context, request, validator)
+		envelopeRaw, retry, err = r.validate(context, request, requests, validators)
 		if err == nil {
 			return true, nil
 		}
@@ -183,7 +229,7 @@ func (r *RequestApprovalResponderView) process(context view.Context, request *Ap
 		span.AddEvent("fetch_tx_status")
 		status, err := txStatusFetcher.process(context, &TxStatusRequest{
 			Network:   request.Network,
-			Namespace: request.Namespace,
+			Namespace: requests[0].Namespace,
 			TxID:      request.TxID,
 		})
 		if err != nil {
@@ -207,7 +253,7 @@ func (r *RequestApprovalResponderView) process(context view.Context, request *Ap
 	return envelopeRaw, nil
 }
 
-func (r *RequestApprovalResponderView) validate(context view.Context, request *ApprovalRequest, validator driver.Validator) ([]byte, bool, error) {
+func (r *RequestApprovalResponderView) validate(context view.Context, request *ApprovalRequest, requests []*BundledApprovalRequest, validators []driver.Validator) ([]byte, bool, error) {
 	span := context.StartSpan("tx_request_validation")
 	defer span.End()
 	sm, err := r.dbManager.GetSessionManager(request.Network)
@@ -218,42 +264,47 @@ func (r *RequestApprovalResponderView) validate(context view.Context, request *A
 	if err != nil {
 		return nil, true, errors.Wrapf(err, "failed to create session to orion network [%s]", request.Network)
 	}
-	qe, err := oSession.QueryExecutor(request.Namespace)
-	if err != nil {
-		return nil, true, errors.Wrapf(err, "failed to get query executor for orion network [%s]", request.Network)
-	}
-	span.AddEvent("validate_request")
-	actions, attributes, err := token.NewValidator(validator).UnmarshallAndVerifyWithMetadata(
-		context.Context(),
-		&LedgerWrapper{qe: qe, keyTranslator: &translator.HashedKeyTranslator{KT: &keys.Translator{}}},
-		request.TxID,
-		request.Request,
-	)
-	if err != nil {
-		return nil, false, errors.Wrapf(err, "failed to unmarshall and verify request")
-	}
-
-	// Write
 	tx, err := sm.Orion.TransactionManager().NewTransactionFromSession(oSession, request.TxID)
 	if err != nil {
 		return nil, true, errors.Wrapf(err, "failed to create transaction [%s]", request.TxID)
 	}
-	rws := &TxRWSWrapper{
-		me: sm.CustodianID,
-		db: request.Namespace,
-		tx: tx,
-	}
-	t := translator.New(request.TxID, translator.NewRWSetWrapper(rws, "", request.TxID), &translator.HashedKeyTranslator{KT: &keys.Translator{}})
-	for _, action := range actions {
-		err = t.Write(action)
+
+	// all the token requests are written in the same transaction
+	var h []byte
+	for i, req := range requests {
+		qe, err := oSession.QueryExecutor(req.Namespace)
 		if err != nil {
-			return nil, false, errors.Wrapf(err, "failed to write action")
+			return nil, true, errors.Wrapf(err, "failed to get query executor for orion network [%s]", request.Network)
+		}
+		span.AddEvent("validate_request")
+		actions, attributes, err := token.NewValidator(validators[i]).UnmarshallAndVerifyWithMetadata(
+			context.Context(),
+			&LedgerWrapper{qe: qe, keyTranslator: &translator.HashedKeyTranslator{KT: &keys.Translator{}}},
+			request.TxID,
+			req.Request,
+		)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to unmarshall and verify request for namespace [%s]", req.Namespace)
+		}
+
+		// Write
+		rws := &TxRWSWrapper{
+			me: sm.CustodianID,
+			db: req.Namespace,
+			tx: tx,
+		}
+		t := translator.New(request.TxID, translator.NewRWSetWrapper(rws, "", request.TxID), &translator.HashedKeyTranslator{KT: &keys.Translator{}})
+		for _, action := range actions {
+			err = t.Write(action)
+			if err != nil {
+				return nil, false, errors.Wrapf(err, "failed to write action")
+			}
+		}
+		span.AddEvent("commit_token_request")
+		h, err = t.CommitTokenRequest(attributes[common.TokenRequestToSign], true)
+		if err != nil {
+			return nil, false, errors.Wrapf(err, "failed to commit token request")
 		}
-	}
-	span.AddEvent("commit_token_request")
-	h, err := t.CommitTokenRequest(attributes[common.TokenRequestToSign], true)
-	if err != nil {
-		return nil, false, errors.Wrapf(err, "failed to commit token request")
 	}
 
 	// close transaction
@@ -261,11 +312,14 @@ func (r *RequestApprovalResponderView) validate(context view.Context, request *A
 	if err != nil {
 		return nil, true, errors.Wrapf(err, "failed to sign and close transaction [%s]", request.TxID)
 	}
-	// update the cache
-	r.statusCache.Add(request.TxID, &TxStatusResponse{
-		Status:                driver2.Busy,
-		TokenRequestReference: h,
-	})
+	// update the cache, it holds a single token request reference per transaction,
+	// therefore the status of a bundle is always fetched from the ledger
+	if len(requests) == 1 {
+		r.statusCache.Add(request.TxID, &TxStatusResponse{
+			Status:                driver2.Busy,
+			TokenRequestReference: h,
+		})
+	}
 	return envelopeRaw, false, nil
 }
 
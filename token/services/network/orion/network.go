@@ -197,6 +197,30 @@ func (n *Network) RequestApproval(context view.Context, tms *token2.ManagementSe
 	return envBoxed.(driver.Envelope), nil
 }
 
+// RequestApprovals requests the custodian to approve the token requests of several TMSs, in different namespaces, in a single transaction
+func (n *Network) RequestApprovals(context view.Context, requests []*driver.ApprovalRequest, signer view.Identity, txID driver.TxID) (driver.Envelope, error) {
+	if len(requests) == 0 {
+		return nil, errors.New("no token requests to approve")
+	}
+	bundle := make([]*BundledApprovalRequest, len(requests))
+	for i, request := range requests {
+		tmsID := request.TMS.ID()
+		if tmsID.Network != n.Name() {
+			return nil, errors.Errorf("tms [%s] is not on network [%s]", tmsID, n.Name())
+		}
+		bundle[i] = &BundledApprovalRequest{Namespace: tmsID.Namespace, Request: request.RequestRaw}
+	}
+	envBoxed, err := view2.GetManager(context).InitiateView(NewRequestBundleApprovalView(
+		n.dbManager,
+		n.n.Name(), bundle,
+		signer, n.ComputeTxID(&txID),
+	), context.Context())
+	if err != nil {
+		return nil, err
+	}
+	return envBoxed.(driver.Envelope), nil
+}
+
 func (n *Network) ComputeTxID(id *driver.TxID) string {
 	logger.Debugf("compute tx id for [%s]", id.String())
 	temp := &orion.TxID{
@@ -269,6 +293,30 @@ func (n *Network) ProcessNamespace(namespace string) error {
 	return nil
 }
 
+// ScanLedger is not supported by orion, whose ledger cannot be walked block by block from the token-sdk.
+// The transactions can be looked up by id with LookupTransaction instead.
+func (n *Network) ScanLedger(ctx context.Context, namespace string, fromBlock uint64, callback driver.ScanCallback) error {
+	return errors.Wrapf(driver.ErrScanNotSupported, "orion network [%s]", n.Name())
+}
+
+// LookupTransaction asks the custodian for the status of the passed transaction and the hash of the token request it committed.
+// The outputs are not returned, because their keys are hashed.
+func (n *Network) LookupTransaction(ctx context.Context, namespace string, txID string) (*driver.LedgerTransaction, error) {
+	boxed, err := n.viewManager.InitiateView(NewRequestTxStatusView(n.Name(), namespace, txID, n.dbManager), ctx)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get status for [%s]", txID)
+	}
+	response := boxed.(*TxStatusResponse)
+	if response.Status == driver.Unknown {
+		return nil, nil
+	}
+	return &driver.LedgerTransaction{
+		TxID:        txID,
+		Status:      response.Status,
+		RequestHash: response.TokenRequestReference,
+	}, nil
+}
+
 type tokenVault struct {
 	tokenVault driver.TokenVault
 }
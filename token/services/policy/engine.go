@@ -0,0 +1,292 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package policy
+
+import (
+	"context"
+	"math/big"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.MustGetLogger("token-sdk.services.policy")
+
+// DefaultReservationTimeout is the time after which the amount reserved by a transfer is released,
+// if its transaction has not been recorded in the ttxdb by then
+const DefaultReservationTimeout = 10 * time.Minute
+
+// MovementStore gives access to the movements recorded by this node
+type MovementStore interface {
+	Movements(params ttxdb.QueryMovementsParams) ([]*ttxdb.MovementRecord, error)
+}
+
+// EnrollmentIDResolver returns the enrollment ID of the owner of an identity
+type EnrollmentIDResolver interface {
+	GetEnrollmentID(identity token.Identity) (string, error)
+}
+
+// Engine is a token.TransferPolicy enforcing spending rules.
+// The rules and the limits are per enrollment ID: the wallets sharing an enrollment ID share the limits.
+// The amounts already spent are taken from the movements recorded in the ttxdb, the deleted transactions excluded,
+// plus the amounts paid by the enrollment ID with the actions already in the request being assembled.
+// A transfer accepted against a limit reserves its amount until its transaction is recorded in the ttxdb,
+// so that the transfers assembled meanwhile count it too.
+type Engine struct {
+	source             RuleSource
+	store              MovementStore
+	resolver           EnrollmentIDResolver
+	now                func() time.Time
+	reservationTimeout time.Duration
+
+	// lock serializes the checks, so that each check sees the reservations of the previous ones
+	lock         sync.Mutex
+	reservations map[reservationKey]map[string]*reservation
+}
+
+type reservationKey struct {
+	enrollmentID string
+	tokenType    string
+}
+
+// reservation is the amount a request pays, for a token type and an enrollment ID, not recorded in the ttxdb yet
+type reservation struct {
+	amount *big.Int
+	at     time.Time
+}
+
+// NewEngine returns a new Engine enforcing the rules of the passed source.
+// A reservation is dropped after the default reservation timeout, if its transaction is not recorded in the ttxdb by then.
+func NewEngine(source RuleSource, store MovementStore, resolver EnrollmentIDResolver) *Engine {
+	return &Engine{
+		source:             source,
+		store:              store,
+		resolver:           resolver,
+		now:                time.Now,
+		reservationTimeout: DefaultReservationTimeout,
+		reservations:       map[reservationKey]map[string]*reservation{},
+	}
+}
+
+func (e *Engine) CheckTransfer(_ context.Context, transfer *token.PendingTransfer) error {
+	rules, err := e.source.Rules()
+	if err != nil {
+		return errors.WithMessagef(err, "failed loading spending rules")
+	}
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	check := &check{engine: e, transfer: transfer, enrollmentID: transfer.Wallet.EnrollmentID(), spent: map[time.Time]*big.Int{}}
+	for i, rule := range rules {
+		if !rule.appliesTo(check.enrollmentID, transfer.Type) {
+			continue
+		}
+		if err := check.rule(rule); err != nil {
+			return errors.WithMessagef(err, "rule [%s] violated", rule.id(i))
+		}
+	}
+	if check.pending != nil && !transfer.DryRun && transfer.Request != nil {
+		e.reserve(check.key(), transfer.Request.Anchor, new(big.Int).Add(check.pending, check.total()))
+	}
+	return nil
+}
+
+// reserve records the amount the passed request pays, replacing the previous reservation of the same request
+func (e *Engine) reserve(key reservationKey, txID string, amount *big.Int) {
+	reservations, ok := e.reservations[key]
+	if !ok {
+		reservations = map[string]*reservation{}
+		e.reservations[key] = reservations
+	}
+	reservations[txID] = &reservation{amount: amount, at: e.now()}
+}
+
+// reserved returns the amount reserved since the passed time by the requests other than the passed one,
+// whose transactions are not among the passed recorded ones.
+// The reservations of the recorded or deleted transactions, and the expired ones, are dropped.
+func (e *Engine) reserved(key reservationKey, txID string, from time.Time, recorded map[string]bool) *big.Int {
+	total := big.NewInt(0)
+	reservations := e.reservations[key]
+	now := e.now()
+	for id, r := range reservations {
+		if _, ok := recorded[id]; ok || now.Sub(r.at) > e.reservationTimeout {
+			delete(reservations, id)
+			continue
+		}
+		if id == txID || r.at.Before(from) {
+			continue
+		}
+		total.Add(total, r.amount)
+	}
+	if len(reservations) == 0 {
+		delete(e.reservations, key)
+	}
+	return total
+}
+
+// check evaluates the rules against a single transfer, caching what is shared among rules
+type check struct {
+	engine       *Engine
+	transfer     *token.PendingTransfer
+	enrollmentID string
+
+	amount  *big.Int
+	pending *big.Int
+	spent   map[time.Time]*big.Int
+}
+
+func (c *check) rule(rule *Rule) error {
+	if rule.MaxPerTransfer != 0 && c.total().Cmp(new(big.Int).SetUint64(rule.MaxPerTransfer)) > 0 {
+		return errors.Errorf("transfer of [%s] [%s] tokens exceeds the cap [%d]", c.total(), c.transfer.Type, rule.MaxPerTransfer)
+	}
+	if len(rule.Recipients) != 0 {
+		if err := c.recipients(rule.Recipients); err != nil {
+			return err
+		}
+	}
+	now := c.engine.now().UTC()
+	if rule.Daily != 0 {
+		if err := c.limit("daily", time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), rule.Daily); err != nil {
+			return err
+		}
+	}
+	if rule.Monthly != 0 {
+		if err := c.limit("monthly", time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), rule.Monthly); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *check) key() reservationKey {
+	return reservationKey{enrollmentID: c.enrollmentID, tokenType: c.transfer.Type}
+}
+
+func (c *check) recipients(allowed []string) error {
+	for _, owner := range c.transfer.Owners {
+		if c.transfer.Wallet.Contains(owner) {
+			continue
+		}
+		eID, err := c.engine.resolver.GetEnrollmentID(owner)
+		if err != nil || len(eID) == 0 {
+			return errors.Errorf("unknown recipient [%s]", owner)
+		}
+		if eID == c.enrollmentID {
+			continue
+		}
+		if !slices.Contains(allowed, eID) {
+			return errors.Errorf("recipient [%s] not allowed", eID)
+		}
+	}
+	return nil
+}
+
+func (c *check) limit(period string, from time.Time, limit uint64) error {
+	spent, ok := c.spent[from]
+	if !ok {
+		var err error
+		spent, err = c.spentSince(from)
+		if err != nil {
+			return err
+		}
+		c.spent[from] = spent
+	}
+	if new(big.Int).Add(spent, c.total()).Cmp(new(big.Int).SetUint64(limit)) > 0 {
+		return errors.Errorf("%s limit [%d] exceeded: [%s] [%s] tokens already spent, [%s] requested", period, limit, spent, c.transfer.Type, c.total())
+	}
+	return nil
+}
+
+// total returns the amount of the transfer
+func (c *check) total() *big.Int {
+	if c.amount == nil {
+		c.amount = big.NewInt(0)
+		for _, v := range c.transfer.Values {
+			c.amount.Add(c.amount, new(big.Int).SetUint64(v))
+		}
+	}
+	return c.amount
+}
+
+// spentSince returns the amount sent by the enrollment ID since the passed time:
+// the movements recorded in the ttxdb, the amounts reserved by the other requests, and the request being assembled
+func (c *check) spentSince(from time.Time) (*big.Int, error) {
+	if c.pending == nil {
+		pending, err := pendingInRequest(c.transfer, c.enrollmentID)
+		if err != nil {
+			return nil, err
+		}
+		c.pending = pending
+	}
+	// the deleted transactions are fetched too, to drop their reservations
+	records, err := c.engine.store.Movements(ttxdb.QueryMovementsParams{
+		EnrollmentIDs:     []string{c.enrollmentID},
+		TokenTypes:        []string{c.transfer.Type},
+		TxStatuses:        []driver.TxStatus{driver.Pending, driver.Confirmed, driver.Deleted},
+		MovementDirection: driver.Sent,
+		From:              &from,
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying movements")
+	}
+	spent := new(big.Int).Set(c.pending)
+	recorded := make(map[string]bool, len(records))
+	for _, record := range records {
+		recorded[record.TxID] = true
+		if record.Status == driver.Deleted {
+			continue
+		}
+		spent.Sub(spent, record.Amount)
+	}
+	txID := ""
+	if c.transfer.Request != nil {
+		txID = c.transfer.Request.Anchor
+	}
+	spent.Add(spent, c.engine.reserved(c.key(), txID, from, recorded))
+	return spent, nil
+}
+
+// pendingInRequest returns the amount of the transfer type paid to others with the inputs of the enrollment ID
+// by the transfer actions already in the request
+func pendingInRequest(transfer *token.PendingTransfer, enrollmentID string) (*big.Int, error) {
+	pending := big.NewInt(0)
+	request := transfer.Request
+	if request == nil || request.Actions == nil || len(request.Actions.Transfers) == 0 {
+		return pending, nil
+	}
+	inputs, outputs, err := request.InputsAndOutputs()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting inputs and outputs of [%s]", request.Anchor)
+	}
+	actions := map[int]bool{}
+	for _, input := range inputs.Inputs() {
+		if input.EnrollmentID == enrollmentID {
+			actions[input.ActionIndex] = true
+		}
+	}
+	// the issue outputs come first, and their action indexes overlap with those of the transfers
+	issued := 0
+	if request.Metadata != nil {
+		for _, issue := range request.Metadata.Issues {
+			issued += len(issue.Outputs)
+		}
+	}
+	for _, output := range outputs.Outputs() {
+		if output.Index < uint64(issued) || !actions[output.ActionIndex] || output.Type != transfer.Type ||
+			output.EnrollmentID == enrollmentID || output.Quantity == nil {
+			continue
+		}
+		pending.Add(pending, output.Quantity.ToBigInt())
+	}
+	return pending, nil
+}
@@ -0,0 +1,214 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package policy
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver/mock"
+	driver2 "github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type wallet struct {
+	driver.OwnerWallet
+	id  string
+	eID string
+}
+
+func (w *wallet) ID() string { return w.id }
+
+func (w *wallet) EnrollmentID() string { return w.eID }
+
+func (w *wallet) Contains(identity driver.Identity) bool { return string(identity) == w.eID }
+
+func ownerWallet(id string, eID string) *token.OwnerWallet {
+	ws := &mock.WalletService{}
+	ws.OwnerWalletReturns(&wallet{id: id, eID: eID}, nil)
+	return token.NewWalletManager(ws).OwnerWallet(id)
+}
+
+// store returns the movements stored since the requested time, the identities are the enrollment IDs
+type store struct {
+	records []*ttxdb.MovementRecord
+}
+
+func (s *store) Movements(params ttxdb.QueryMovementsParams) ([]*ttxdb.MovementRecord, error) {
+	var res []*ttxdb.MovementRecord
+	for _, r := range s.records {
+		if slices.Contains(params.EnrollmentIDs, r.EnrollmentID) && slices.Contains(params.TokenTypes, r.TokenType) &&
+			params.MovementDirection == driver2.Sent && r.Amount.Sign() < 0 && !r.Timestamp.Before(*params.From) {
+			res = append(res, r)
+		}
+	}
+	return res, nil
+}
+
+type resolver struct{}
+
+func (resolver) GetEnrollmentID(identity token.Identity) (string, error) {
+	if string(identity) == "unknown" {
+		return "", errors.New("no audit info")
+	}
+	return string(identity), nil
+}
+
+func sent(eID string, tokenType string, amount int64, at time.Time) *ttxdb.MovementRecord {
+	return &ttxdb.MovementRecord{EnrollmentID: eID, TokenType: tokenType, Amount: big.NewInt(-amount), Timestamp: at, Status: driver2.Pending}
+}
+
+func transfer(w *token.OwnerWallet, tokenType string, values []uint64, owners ...string) *token.PendingTransfer {
+	t := &token.PendingTransfer{Wallet: w, Type: tokenType, Values: values}
+	for _, owner := range owners {
+		t.Owners = append(t.Owners, token.Identity(owner))
+	}
+	return t
+}
+
+func TestEngine(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	s := &store{records: []*ttxdb.MovementRecord{
+		sent("alice", "USD", 50, now.Add(-time.Hour)),
+		sent("alice", "USD", 300, now.AddDate(0, 0, -3)),
+		sent("alice", "USD", 1000, now.AddDate(0, -1, 0)),
+		sent("alice", "EUR", 90, now.Add(-time.Hour)),
+		sent("bob", "USD", 90, now.Add(-time.Hour)),
+	}}
+	engine := NewEngine(StaticRules{
+		{Name: "usd", EnrollmentIDs: []string{"alice"}, Types: []string{"USD"}, MaxPerTransfer: 60, Daily: 100, Monthly: 400},
+		{EnrollmentIDs: []string{"alice"}, Recipients: []string{"bob", "charlie"}},
+	}, s, resolver{})
+	engine.now = func() time.Time { return now }
+	alice := ownerWallet("alice-wallet", "alice")
+	ctx := context.Background()
+
+	assert.NoError(t, engine.CheckTransfer(ctx, transfer(alice, "USD", []uint64{30, 20}, "bob", "charlie")))
+	// payments to the enrollment id itself are always allowed
+	assert.NoError(t, engine.CheckTransfer(ctx, transfer(alice, "USD", []uint64{50}, "alice")))
+	assert.NoError(t, engine.CheckTransfer(ctx, transfer(ownerWallet("alice-savings", "alice"), "EUR", []uint64{1}, "alice")))
+	// the rules of other enrollment ids and types do not apply
+	assert.NoError(t, engine.CheckTransfer(ctx, transfer(alice, "EUR", []uint64{1000}, "bob")))
+	assert.NoError(t, engine.CheckTransfer(ctx, transfer(ownerWallet("bob-wallet", "bob"), "USD", []uint64{1000}, "dave")))
+
+	assert.EqualError(t, engine.CheckTransfer(ctx, transfer(alice, "USD", []uint64{40, 21}, "bob", "bob")),
+		"rule [usd] violated: transfer of [61] [USD] tokens exceeds the cap [60]")
+	assert.EqualError(t, engine.CheckTransfer(ctx, transfer(alice, "USD", []uint64{51}, "bob")),
+		"rule [usd] violated: daily limit [100] exceeded: [50] [USD] tokens already spent, [51] requested")
+	assert.EqualError(t, engine.CheckTransfer(ctx, transfer(alice, "EUR", []uint64{1}, "dave")),
+		"rule [1] violated: recipient [dave] not allowed")
+	assert.ErrorContains(t, engine.CheckTransfer(ctx, transfer(alice, "EUR", []uint64{1}, "unknown")),
+		"rule [1] violated: unknown recipient")
+
+	// the monthly limit counts the movements since the beginning of the month
+	engine.now = func() time.Time { return now.AddDate(0, 0, 1) }
+	assert.NoError(t, engine.CheckTransfer(ctx, transfer(alice, "USD", []uint64{50}, "bob")))
+	assert.EqualError(t, engine.CheckTransfer(ctx, transfer(alice, "USD", []uint64{51}, "bob")),
+		"rule [usd] violated: monthly limit [400] exceeded: [350] [USD] tokens already spent, [51] requested")
+}
+
+func TestEngineReservations(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	s := &store{records: []*ttxdb.MovementRecord{sent("alice", "USD", 50, now.Add(-time.Hour))}}
+	engine := NewEngine(StaticRules{{Name: "usd", EnrollmentIDs: []string{"alice"}, Types: []string{"USD"}, Daily: 100}}, s, resolver{})
+	engine.now = func() time.Time { return now }
+	alice := ownerWallet("alice-wallet", "alice")
+	ctx := context.Background()
+	inRequest := func(txID string, w *token.OwnerWallet, tokenType string, values []uint64, owners ...string) *token.PendingTransfer {
+		t := transfer(w, tokenType, values, owners...)
+		t.Request = &token.Request{Anchor: txID}
+		return t
+	}
+
+	// the transfers assembled concurrently count the amounts reserved by the others
+	assert.NoError(t, engine.CheckTransfer(ctx, inRequest("tx1", alice, "USD", []uint64{30}, "bob")))
+	assert.EqualError(t, engine.CheckTransfer(ctx, inRequest("tx2", alice, "USD", []uint64{30}, "bob")),
+		"rule [usd] violated: daily limit [100] exceeded: [80] [USD] tokens already spent, [30] requested")
+	// the wallets of the same enrollment id share the limits
+	assert.EqualError(t, engine.CheckTransfer(ctx, inRequest("tx2", ownerWallet("alice-savings", "alice"), "USD", []uint64{30}, "bob")),
+		"rule [usd] violated: daily limit [100] exceeded: [80] [USD] tokens already spent, [30] requested")
+	// checking the same request again replaces its reservation
+	assert.NoError(t, engine.CheckTransfer(ctx, inRequest("tx1", alice, "USD", []uint64{40}, "bob")))
+	assert.NoError(t, engine.CheckTransfer(ctx, inRequest("tx2", alice, "USD", []uint64{10}, "bob")))
+	assert.Len(t, engine.reservations[reservationKey{enrollmentID: "alice", tokenType: "USD"}], 2)
+
+	// dry runs reserve nothing
+	dryRun := inRequest("tx3", alice, "USD", []uint64{1}, "bob")
+	dryRun.DryRun = true
+	assert.ErrorContains(t, engine.CheckTransfer(ctx, dryRun), "daily limit [100] exceeded: [100] [USD] tokens already spent, [1] requested")
+	assert.Len(t, engine.reservations[reservationKey{enrollmentID: "alice", tokenType: "USD"}], 2)
+
+	// once recorded in the ttxdb, the movement replaces the reservation
+	tx1 := sent("alice", "USD", 40, now)
+	tx1.TxID = "tx1"
+	s.records = append(s.records, tx1)
+	assert.EqualError(t, engine.CheckTransfer(ctx, inRequest("tx3", alice, "USD", []uint64{1}, "bob")),
+		"rule [usd] violated: daily limit [100] exceeded: [100] [USD] tokens already spent, [1] requested")
+	assert.Len(t, engine.reservations[reservationKey{enrollmentID: "alice", tokenType: "USD"}], 1)
+	// the deleted transactions count neither as movements nor as reservations
+	tx1.Status = driver2.Deleted
+	tx2 := sent("alice", "USD", 10, now)
+	tx2.TxID, tx2.Status = "tx2", driver2.Deleted
+	s.records = append(s.records, tx2)
+	assert.NoError(t, engine.CheckTransfer(ctx, inRequest("tx3", alice, "USD", []uint64{20}, "bob")))
+	assert.Len(t, engine.reservations[reservationKey{enrollmentID: "alice", tokenType: "USD"}], 1)
+
+	// the reservations of the transactions never recorded expire
+	assert.EqualError(t, engine.CheckTransfer(ctx, inRequest("tx4", alice, "USD", []uint64{31}, "bob")),
+		"rule [usd] violated: daily limit [100] exceeded: [70] [USD] tokens already spent, [31] requested")
+	engine.now = func() time.Time { return now.Add(DefaultReservationTimeout + time.Second) }
+	assert.NoError(t, engine.CheckTransfer(ctx, inRequest("tx4", alice, "USD", []uint64{31}, "bob")))
+	assert.Len(t, engine.reservations[reservationKey{enrollmentID: "alice", tokenType: "USD"}], 1)
+}
+
+func TestMemoryRules(t *testing.T) {
+	source := NewMemoryRules([]*Rule{{Name: "usd", Types: []string{"USD"}, MaxPerTransfer: 10}})
+	engine := NewEngine(source, &store{}, resolver{})
+	alice := ownerWallet("alice-wallet", "alice")
+	assert.ErrorContains(t, engine.CheckTransfer(context.Background(), transfer(alice, "USD", []uint64{20}, "bob")), "exceeds the cap [10]")
+
+	source.Set([]*Rule{{Name: "usd", Types: []string{"USD"}, MaxPerTransfer: 100}})
+	assert.NoError(t, engine.CheckTransfer(context.Background(), transfer(alice, "USD", []uint64{20}, "bob")))
+}
+
+func TestFileRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	_, err := NewFileRules(path)
+	assert.ErrorContains(t, err, "failed accessing rules file")
+
+	assert.NoError(t, os.WriteFile(path, []byte("rules:\n- name: usd\n  types: [USD]\n  daily: 100\n"), 0600))
+	source, err := NewFileRules(path)
+	assert.NoError(t, err)
+	rules, err := source.Rules()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Rule{{Name: "usd", Types: []string{"USD"}, Daily: 100}}, rules)
+
+	// the file is reloaded when modified
+	assert.NoError(t, os.WriteFile(path, []byte("rules:\n- name: usd\n  types: [USD]\n  daily: 200\n  recipients: [bob]\n"), 0600))
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	rules, err = source.Rules()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Rule{{Name: "usd", Types: []string{"USD"}, Daily: 200, Recipients: []string{"bob"}}}, rules)
+
+	// invalid rules are rejected until fixed
+	assert.NoError(t, os.WriteFile(path, []byte("rules:\n- name: usd\n  weekly: 100\n"), 0600))
+	assert.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	_, err = source.Rules()
+	assert.ErrorContains(t, err, "invalid rules file")
+	engine := NewEngine(source, &store{}, resolver{})
+	assert.ErrorContains(t, engine.CheckTransfer(context.Background(), transfer(ownerWallet("alice-wallet", "alice"), "USD", []uint64{1}, "bob")),
+		"failed loading spending rules")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package policy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+// ConfigKey is the configuration key, relative to the TMS, of the spending policy
+const ConfigKey = "services.policy"
+
+// Config is the configuration of the spending policy of a TMS.
+// If File is set, the rules are read from that file and reloaded when it changes, otherwise Rules are used,
+// and they can be replaced at runtime with Provider#SetRules.
+type Config struct {
+	// File is the path of the YAML file holding the rules, relative to the configuration path
+	File string `yaml:"file,omitempty"`
+	// Rules are the rules of the policy, if no file is set
+	Rules []*Rule `yaml:"rules,omitempty"`
+	// ReservationTimeout is the time after which the amount reserved by a transfer is released,
+	// if its transaction has not been recorded in the ttxdb by then. Default is DefaultReservationTimeout.
+	ReservationTimeout time.Duration `yaml:"reservationTimeout,omitempty"`
+}
+
+// DBProvider provides the ttxdb of a TMS
+type DBProvider interface {
+	DBByTMSId(id token.TMSID) (*ttxdb.DB, error)
+}
+
+// Provider is a token.TransferPolicyProvider returning, for each TMS, the Engine enforcing its configured spending policy
+type Provider struct {
+	dbProvider DBProvider
+
+	lock    sync.Mutex
+	engines map[token.TMSID]*Engine
+}
+
+// NewProvider returns a new Provider
+func NewProvider(dbProvider DBProvider) *Provider {
+	return &Provider{dbProvider: dbProvider, engines: map[token.TMSID]*Engine{}}
+}
+
+// TransferPolicy returns the policy of the passed TMS, nil if no spending policy is configured
+func (p *Provider) TransferPolicy(tms *token.ManagementService) (token.TransferPolicy, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if engine, ok := p.engines[tms.ID()]; ok {
+		if engine == nil {
+			return nil, nil
+		}
+		return engine, nil
+	}
+	engine, err := p.newEngine(tms)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed loading spending policy for [%s]", tms.ID())
+	}
+	p.engines[tms.ID()] = engine
+	if engine == nil {
+		return nil, nil
+	}
+	return engine, nil
+}
+
+// SetRules replaces the inlined rules of the spending policy of the passed TMS, until the node restarts.
+// It fails if no spending policy is configured for the TMS, or if its rules are read from a file: update the file instead.
+func (p *Provider) SetRules(tms *token.ManagementService, rules []*Rule) error {
+	policy, err := p.TransferPolicy(tms)
+	if err != nil {
+		return err
+	}
+	if policy == nil {
+		return errors.Errorf("no spending policy configured for [%s]", tms.ID())
+	}
+	memory, ok := policy.(*Engine).source.(*MemoryRules)
+	if !ok {
+		return errors.Errorf("the spending rules of [%s] are read from a file", tms.ID())
+	}
+	memory.Set(rules)
+	logger.Infof("set [%d] spending rules for [%s]", len(rules), tms.ID())
+	return nil
+}
+
+func (p *Provider) newEngine(tms *token.ManagementService) (*Engine, error) {
+	tmsConfig := tms.Configuration()
+	if !tmsConfig.IsSet(ConfigKey) {
+		return nil, nil
+	}
+	c := &Config{}
+	if err := tmsConfig.UnmarshalKey(ConfigKey, c); err != nil {
+		return nil, errors.Wrapf(err, "invalid config for key [%s]", ConfigKey)
+	}
+	var source RuleSource = NewMemoryRules(c.Rules)
+	if len(c.File) != 0 {
+		fileRules, err := NewFileRules(tmsConfig.TranslatePath(c.File))
+		if err != nil {
+			return nil, err
+		}
+		source = fileRules
+	}
+	db, err := p.dbProvider.DBByTMSId(tms.ID())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting ttxdb")
+	}
+	engine := NewEngine(source, db, tms.WalletManager())
+	if c.ReservationTimeout > 0 {
+		engine.reservationTimeout = c.ReservationTimeout
+	}
+	return engine, nil
+}
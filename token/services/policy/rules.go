@@ -0,0 +1,155 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package policy
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule constrains the transfers of some enrollment IDs and token types.
+// The limits are per enrollment ID and token type: a rule listing several types limits each of them separately,
+// and the wallets sharing an enrollment ID share the limits.
+type Rule struct {
+	// Name identifies the rule in the error messages
+	Name string `yaml:"name,omitempty"`
+	// EnrollmentIDs are the enrollment IDs of the wallets the rule applies to, all if empty
+	EnrollmentIDs []string `yaml:"enrollmentIDs,omitempty"`
+	// Types are the token types the rule applies to, all if empty
+	Types []string `yaml:"types,omitempty"`
+	// MaxPerTransfer, if not zero, is the maximum amount paid with a single transfer
+	MaxPerTransfer uint64 `yaml:"maxPerTransfer,omitempty"`
+	// Daily, if not zero, is the maximum amount sent since the beginning of the current day (UTC)
+	Daily uint64 `yaml:"daily,omitempty"`
+	// Monthly, if not zero, is the maximum amount sent since the beginning of the current month (UTC)
+	Monthly uint64 `yaml:"monthly,omitempty"`
+	// Recipients, if not empty, lists the only enrollment IDs the wallets can pay.
+	// Payments to the paying enrollment ID itself are always allowed.
+	Recipients []string `yaml:"recipients,omitempty"`
+}
+
+func (r *Rule) appliesTo(enrollmentID string, tokenType string) bool {
+	return (len(r.EnrollmentIDs) == 0 || slices.Contains(r.EnrollmentIDs, enrollmentID)) &&
+		(len(r.Types) == 0 || slices.Contains(r.Types, tokenType))
+}
+
+func (r *Rule) id(index int) string {
+	if len(r.Name) != 0 {
+		return r.Name
+	}
+	return fmt.Sprintf("%d", index)
+}
+
+// Rules is the document holding the rules of a spending policy.
+// A transfer must satisfy all the rules applying to it.
+type Rules struct {
+	Rules []*Rule `yaml:"rules,omitempty"`
+}
+
+// ParseRules parses the passed YAML document, unknown fields are rejected
+func ParseRules(raw []byte) ([]*Rule, error) {
+	rules := &Rules{}
+	if err := yaml.UnmarshalStrict(raw, rules); err != nil {
+		return nil, errors.Wrapf(err, "failed parsing rules")
+	}
+	for i, rule := range rules.Rules {
+		if rule == nil {
+			return nil, errors.Errorf("rule [%d] is empty", i)
+		}
+	}
+	return rules.Rules, nil
+}
+
+// RuleSource returns the rules currently in force
+type RuleSource interface {
+	Rules() ([]*Rule, error)
+}
+
+// StaticRules is a RuleSource whose rules never change
+type StaticRules []*Rule
+
+func (s StaticRules) Rules() ([]*Rule, error) {
+	return s, nil
+}
+
+// MemoryRules is a RuleSource whose rules can be replaced at runtime
+type MemoryRules struct {
+	lock  sync.RWMutex
+	rules []*Rule
+}
+
+// NewMemoryRules returns a new MemoryRules holding the passed rules
+func NewMemoryRules(rules []*Rule) *MemoryRules {
+	return &MemoryRules{rules: rules}
+}
+
+func (m *MemoryRules) Rules() ([]*Rule, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.rules, nil
+}
+
+// Set replaces the rules, the transfers checked from now on are checked against the passed rules
+func (m *MemoryRules) Set(rules []*Rule) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.rules = rules
+}
+
+// FileRules is a RuleSource reading the rules from a YAML file.
+// The file is parsed again whenever its modification time or size change, so that the rules can be updated
+// without restarting the node. If the file becomes unreadable or invalid, an error is returned until it is fixed,
+// therefore the transfers are rejected rather than checked against stale rules.
+type FileRules struct {
+	path string
+
+	lock    sync.Mutex
+	modTime int64
+	size    int64
+	rules   []*Rule
+}
+
+// NewFileRules returns a new FileRules for the passed path, the file is read immediately
+func NewFileRules(path string) (*FileRules, error) {
+	f := &FileRules{path: path}
+	if _, err := f.Rules(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *FileRules) Rules() ([]*Rule, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed accessing rules file [%s]", f.path)
+	}
+	if f.rules != nil && info.ModTime().UnixNano() == f.modTime && info.Size() == f.size {
+		return f.rules, nil
+	}
+	raw, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading rules file [%s]", f.path)
+	}
+	rules, err := ParseRules(raw)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid rules file [%s]", f.path)
+	}
+	if rules == nil {
+		rules = []*Rule{}
+	}
+	logger.Infof("loaded [%d] spending rules from [%s]", len(rules), f.path)
+	f.rules, f.modTime, f.size = rules, info.ModTime().UnixNano(), info.Size()
+	return f.rules, nil
+}
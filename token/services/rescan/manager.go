@@ -0,0 +1,218 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rescan
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokens"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var logger = logging.MustGetLogger("token-sdk.services.rescan")
+
+type NetworkProvider interface {
+	GetNetwork(network string, channel string) (*network.Network, error)
+}
+
+type TMSProvider interface {
+	GetManagementService(opts ...token.ServiceOption) (*token.ManagementService, error)
+}
+
+type TokensProvider interface {
+	Tokens(tmsID token.TMSID) (*tokens.Tokens, error)
+}
+
+type TTXDBProvider interface {
+	DBByTMSId(id token.TMSID) (*ttxdb.DB, error)
+}
+
+type AuditDBProvider interface {
+	DBByTMSId(id token.TMSID) (*auditdb.DB, error)
+}
+
+type KVS interface {
+	Exists(id string) bool
+	Put(id string, state interface{}) error
+	Get(id string, state interface{}) error
+}
+
+// Manager rebuilds the token databases of a TMS from the ledger history.
+// It walks the ledger of the TMS network and, for each token transaction in the TMS namespace whose token request
+// is known to the ttxdb or the auditdb, it sets the transaction status to the one on the ledger and, if the transaction
+// is valid, appends its tokens to the tokendb.
+// The token request and its metadata are not stored on the ledger. For a valid transaction unknown to the local databases,
+// the outputs written on the ledger are appended to the tokendb, and the inputs it spent are deleted.
+// This works only with drivers that do not hide the token data; otherwise, such transactions are skipped.
+// If the ledger cannot be walked, as for orion, the transactions known to the local databases are looked up by id.
+type Manager struct {
+	networkProvider NetworkProvider
+	tmsProvider     TMSProvider
+	tokensProvider  TokensProvider
+	ttxDBProvider   TTXDBProvider
+	auditDBProvider AuditDBProvider
+	kvs             KVS
+	tracerProvider  trace.TracerProvider
+
+	mutex   sync.Mutex
+	running map[string]bool
+}
+
+func NewManager(
+	networkProvider NetworkProvider,
+	tmsProvider TMSProvider,
+	tokensProvider TokensProvider,
+	ttxDBProvider TTXDBProvider,
+	auditDBProvider AuditDBProvider,
+	kvs KVS,
+	tracerProvider trace.TracerProvider,
+) *Manager {
+	return &Manager{
+		networkProvider: networkProvider,
+		tmsProvider:     tmsProvider,
+		tokensProvider:  tokensProvider,
+		ttxDBProvider:   ttxDBProvider,
+		auditDBProvider: auditDBProvider,
+		kvs:             kvs,
+		tracerProvider:  tracerProvider,
+		running:         map[string]bool{},
+	}
+}
+
+// Rescan rebuilds the token databases of the passed TMS from the ledger.
+// By default, it resumes from the last stored checkpoint and stops when no new block arrives for DefaultIdleTimeout.
+// Only one rescan per TMS can run at a given time.
+func (m *Manager) Rescan(ctx context.Context, tmsID token.TMSID, opts ...Option) (*Checkpoint, error) {
+	if err := m.acquire(tmsID); err != nil {
+		return nil, err
+	}
+	defer m.release(tmsID)
+
+	scanner, err := m.scanner(tmsID)
+	if err != nil {
+		return nil, err
+	}
+	return scanner.Scan(ctx, opts...)
+}
+
+// Checkpoint returns the last stored checkpoint for the passed TMS, nil if no rescan has ever run
+func (m *Manager) Checkpoint(tmsID token.TMSID) (*Checkpoint, error) {
+	return (&kvsCheckpointStore{kvs: m.kvs, tmsID: tmsID}).Load()
+}
+
+func (m *Manager) scanner(tmsID token.TMSID) (*Scanner, error) {
+	net, err := m.networkProvider.GetNetwork(tmsID.Network, tmsID.Channel)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get network instance for [%s:%s]", tmsID.Network, tmsID.Channel)
+	}
+	tokenDB, err := m.tokensProvider.Tokens(tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get tokendb for [%s]", tmsID)
+	}
+	ttxDB, err := m.ttxDBProvider.DBByTMSId(tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get ttxdb for [%s]", tmsID)
+	}
+	auditDB, err := m.auditDBProvider.DBByTMSId(tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get auditdb for [%s]", tmsID)
+	}
+	tracer := m.tracerProvider.Tracer("rescan")
+	return &Scanner{
+		Namespace: tmsID.Namespace,
+		Ledger:    net,
+		Targets: []Target{
+			{Name: "ttxdb", DB: ttxDB, Processor: common.NewFinalityListener(logger, m.tmsProvider, tmsID, ttxDB, tokenDB, tracer)},
+			{Name: "auditdb", DB: auditDB, Processor: common.NewFinalityListener(logger, m.tmsProvider, tmsID, auditDB, tokenDB, tracer)},
+		},
+		Checkpoints: &kvsCheckpointStore{kvs: m.kvs, tmsID: tmsID},
+		Recoverer:   &tokenRecoverer{tokens: tokenDB, tmsID: tmsID},
+	}, nil
+}
+
+// tokenRecoverer appends to the tokendb the outputs of a transaction as written on the ledger
+type tokenRecoverer struct {
+	tokens *tokens.Tokens
+	tmsID  token.TMSID
+}
+
+func (r *tokenRecoverer) Recover(ctx context.Context, tx *network.LedgerTransaction) (bool, error) {
+	if len(tx.Outputs) == 0 && len(tx.Spent) == 0 {
+		return false, nil
+	}
+	return r.tokens.AppendLedgerOutputs(ctx, r.tmsID, tx.TxID, tx.Outputs, tx.Spent)
+}
+
+func (m *Manager) acquire(tmsID token.TMSID) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.running[tmsID.String()] {
+		return errors.Errorf("rescan already running for [%s]", tmsID)
+	}
+	m.running[tmsID.String()] = true
+	return nil
+}
+
+func (m *Manager) release(tmsID token.TMSID) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.running, tmsID.String())
+}
+
+// kvsCheckpointStore stores the checkpoints of a TMS in the KVS
+type kvsCheckpointStore struct {
+	kvs   KVS
+	tmsID token.TMSID
+}
+
+func (s *kvsCheckpointStore) key() (string, error) {
+	return kvs.CreateCompositeKey("token-sdk.rescan.checkpoint", []string{s.tmsID.Network, s.tmsID.Channel, s.tmsID.Namespace})
+}
+
+func (s *kvsCheckpointStore) Load() (*Checkpoint, error) {
+	k, err := s.key()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating checkpoint key")
+	}
+	if !s.kvs.Exists(k) {
+		return nil, nil
+	}
+	cp := &Checkpoint{}
+	if err := s.kvs.Get(k, cp); err != nil {
+		return nil, errors.WithMessagef(err, "failed loading checkpoint for [%s]", s.tmsID)
+	}
+	return cp, nil
+}
+
+func (s *kvsCheckpointStore) Store(checkpoint *Checkpoint) error {
+	k, err := s.key()
+	if err != nil {
+		return errors.Wrapf(err, "failed creating checkpoint key")
+	}
+	return s.kvs.Put(k, checkpoint)
+}
+
+var managerType = reflect.TypeOf((*Manager)(nil))
+
+// GetManager returns the rescan manager from the passed service provider
+func GetManager(sp token.ServiceProvider) (*Manager, error) {
+	s, err := sp.GetService(managerType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get rescan manager")
+	}
+	return s.(*Manager), nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rescan
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultIdleTimeout is the time without new blocks after which the ledger is considered fully scanned
+	DefaultIdleTimeout = 30 * time.Second
+	// DefaultCheckpointInterval is the number of blocks after which a checkpoint is stored
+	DefaultCheckpointInterval = 100
+)
+
+// Progress is reported after each scanned block
+type Progress struct {
+	// Block is the number of the block just scanned
+	Block uint64
+	// Checkpoint is the current state of the rescan
+	Checkpoint Checkpoint
+}
+
+// Options models the options of a rescan
+type Options struct {
+	// FromBlock, if set, is the block the rescan starts from, instead of the stored checkpoint
+	FromBlock *uint64
+	// ToBlock, if not zero, is the last block to scan
+	ToBlock uint64
+	// IdleTimeout is the time without new blocks after which the rescan stops, if ToBlock is not set
+	IdleTimeout time.Duration
+	// CheckpointInterval is the number of blocks after which a checkpoint is stored
+	CheckpointInterval uint64
+	// OnProgress, if set, is invoked after each scanned block
+	OnProgress func(Progress)
+}
+
+// Option is a function that modifies Options
+type Option func(*Options) error
+
+func compile(opts ...Option) (*Options, error) {
+	o := &Options{
+		IdleTimeout:        DefaultIdleTimeout,
+		CheckpointInterval: DefaultCheckpointInterval,
+	}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	if o.FromBlock != nil && o.ToBlock != 0 && *o.FromBlock > o.ToBlock {
+		return nil, errors.Errorf("invalid block range [%d,%d]", *o.FromBlock, o.ToBlock)
+	}
+	return o, nil
+}
+
+// WithFromBlock starts the rescan from the passed block, ignoring the stored checkpoint
+func WithFromBlock(block uint64) Option {
+	return func(o *Options) error {
+		o.FromBlock = &block
+		return nil
+	}
+}
+
+// WithToBlock stops the rescan after the passed block has been processed
+func WithToBlock(block uint64) Option {
+	return func(o *Options) error {
+		o.ToBlock = block
+		return nil
+	}
+}
+
+// WithIdleTimeout sets the time without new blocks after which the rescan stops
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(o *Options) error {
+		if timeout <= 0 {
+			return errors.Errorf("invalid idle timeout [%s]", timeout)
+		}
+		o.IdleTimeout = timeout
+		return nil
+	}
+}
+
+// WithCheckpointInterval sets the number of blocks after which a checkpoint is stored
+func WithCheckpointInterval(blocks uint64) Option {
+	return func(o *Options) error {
+		if blocks == 0 {
+			return errors.New("invalid checkpoint interval, it must be positive")
+		}
+		o.CheckpointInterval = blocks
+		return nil
+	}
+}
+
+// WithProgress sets a function invoked after each scanned block
+func WithProgress(f func(Progress)) Option {
+	return func(o *Options) error {
+		o.OnProgress = f
+		return nil
+	}
+}
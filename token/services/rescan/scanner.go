@@ -0,0 +1,287 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rescan
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/pkg/errors"
+)
+
+// Ledger walks the ledger block by block.
+// If the ledger cannot be walked, ScanLedger returns network.ErrScanNotSupported and
+// the transactions are looked up by id with LookupTransaction.
+type Ledger interface {
+	ScanLedger(ctx context.Context, namespace string, fromBlock uint64, callback network.ScanCallback) error
+	// LookupTransaction returns the transaction with the passed id, nil if it is not on the ledger,
+	// and false if lookups are not supported
+	LookupTransaction(ctx context.Context, namespace string, txID string) (*network.LedgerTransaction, bool, error)
+}
+
+// TransactionDB is a database holding token requests, like the ttxdb and the auditdb
+type TransactionDB interface {
+	GetTokenRequest(txID string) ([]byte, error)
+	TokenRequests(params driver.QueryTokenRequestsParams) (driver.TokenRequestIterator, error)
+}
+
+// Recoverer restores from its ledger content a valid transaction not known to any target.
+// It returns false if the transaction could not be restored.
+type Recoverer interface {
+	Recover(ctx context.Context, tx *network.LedgerTransaction) (bool, error)
+}
+
+// Processor applies the ledger status of a transaction to a database and, if valid, appends its tokens to the token db
+type Processor interface {
+	Process(ctx context.Context, txID string, status int, message string, tokenRequestHash []byte) error
+}
+
+// Target is a database to rebuild together with the processor that updates it
+type Target struct {
+	Name      string
+	DB        TransactionDB
+	Processor Processor
+}
+
+// CheckpointStore stores the rescan checkpoints
+type CheckpointStore interface {
+	Load() (*Checkpoint, error)
+	Store(checkpoint *Checkpoint) error
+}
+
+// Checkpoint records how far a rescan went
+type Checkpoint struct {
+	// NextBlock is the block the next rescan starts from
+	NextBlock uint64
+	// LastTxID is the last token transaction processed
+	LastTxID string
+	// Blocks is the number of blocks scanned
+	Blocks uint64
+	// Transactions is the number of token transactions found
+	Transactions uint64
+	// Restored is the number of token transactions applied to at least one local database
+	Restored uint64
+	// Skipped is the number of token transactions not known to any local database
+	Skipped uint64
+	// UpdatedAt is when the checkpoint has been updated last
+	UpdatedAt time.Time
+}
+
+// Scanner walks the ledger and applies the token transactions it finds to the targets
+type Scanner struct {
+	Namespace   string
+	Ledger      Ledger
+	Targets     []Target
+	Checkpoints CheckpointStore
+	// Recoverer, if set, restores the valid transactions not known to any target
+	Recoverer Recoverer
+}
+
+// Scan runs the rescan and returns the final checkpoint.
+// The checkpoint is stored every CheckpointInterval blocks and when the scan ends, so that a new scan
+// can resume from there. A block is recorded as processed only when all its transactions have been applied.
+func (s *Scanner) Scan(ctx context.Context, opts ...Option) (*Checkpoint, error) {
+	o, err := compile(opts...)
+	if err != nil {
+		return nil, err
+	}
+	cp, err := s.Checkpoints.Load()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed loading checkpoint")
+	}
+	if cp == nil {
+		cp = &Checkpoint{}
+	}
+	if o.FromBlock != nil {
+		cp = &Checkpoint{NextBlock: *o.FromBlock}
+	}
+	if o.ToBlock != 0 && cp.NextBlock > o.ToBlock {
+		logger.Infof("nothing to rescan, next block [%d] after [%d]", cp.NextBlock, o.ToBlock)
+		return cp, nil
+	}
+	logger.Infof("rescan namespace [%s] from block [%d]", s.Namespace, cp.NextBlock)
+	restart := *cp
+
+	scanCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var idle atomic.Bool
+	var timer *time.Timer
+	if o.ToBlock == 0 {
+		timer = time.AfterFunc(o.IdleTimeout, func() {
+			idle.Store(true)
+			cancel()
+		})
+		defer timer.Stop()
+	}
+
+	sinceCheckpoint := uint64(0)
+	err = s.Ledger.ScanLedger(scanCtx, s.Namespace, cp.NextBlock, func(ctx context.Context, block *network.LedgerBlock) (bool, error) {
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := s.processBlock(ctx, block, cp); err != nil {
+			return true, err
+		}
+		sinceCheckpoint++
+		if sinceCheckpoint >= o.CheckpointInterval {
+			if err := s.store(cp); err != nil {
+				return true, err
+			}
+			sinceCheckpoint = 0
+		}
+		if o.OnProgress != nil {
+			o.OnProgress(Progress{Block: block.Number, Checkpoint: *cp})
+		}
+		if o.ToBlock != 0 && block.Number >= o.ToBlock {
+			return true, nil
+		}
+		if timer != nil {
+			timer.Reset(o.IdleTimeout)
+		}
+		return false, nil
+	})
+	if errors.Is(err, network.ErrScanNotSupported) {
+		logger.Infof("ledger scan not supported for namespace [%s], look up the local transactions by id", s.Namespace)
+		if timer != nil {
+			timer.Stop()
+		}
+		idle.Store(false)
+		cp = &restart
+		err = s.lookup(ctx, cp)
+	}
+	if err != nil && !(idle.Load() && ctx.Err() == nil) {
+		if err2 := s.store(cp); err2 != nil {
+			logger.Errorf("failed storing checkpoint at block [%d]: [%s]", cp.NextBlock, err2)
+		}
+		return cp, errors.WithMessagef(err, "rescan stopped at block [%d]", cp.NextBlock)
+	}
+	if err := s.store(cp); err != nil {
+		return cp, err
+	}
+	logger.Infof("rescan namespace [%s] done, next block [%d], transactions [%d], restored [%d], skipped [%d]",
+		s.Namespace, cp.NextBlock, cp.Transactions, cp.Restored, cp.Skipped)
+	return cp, nil
+}
+
+func (s *Scanner) processBlock(ctx context.Context, block *network.LedgerBlock, cp *Checkpoint) error {
+	for _, tx := range block.Transactions {
+		restored, err := s.processTransaction(ctx, tx)
+		if err != nil {
+			return errors.WithMessagef(err, "failed processing transaction [%s] in block [%d]", tx.TxID, block.Number)
+		}
+		cp.Transactions++
+		if restored {
+			cp.Restored++
+		} else {
+			cp.Skipped++
+		}
+		cp.LastTxID = tx.TxID
+	}
+	cp.Blocks++
+	cp.NextBlock = block.Number + 1
+	return nil
+}
+
+func (s *Scanner) processTransaction(ctx context.Context, tx *network.LedgerTransaction) (bool, error) {
+	restored := false
+	for _, target := range s.Targets {
+		request, err := target.DB.GetTokenRequest(tx.TxID)
+		if err != nil {
+			return false, errors.WithMessagef(err, "failed getting token request from [%s]", target.Name)
+		}
+		if len(request) == 0 {
+			continue
+		}
+		logger.Debugf("restore transaction [%s] in [%s] with status [%d]", tx.TxID, target.Name, tx.Status)
+		if err := target.Processor.Process(ctx, tx.TxID, tx.Status, tx.Message, tx.RequestHash); err != nil {
+			return false, errors.WithMessagef(err, "failed restoring in [%s]", target.Name)
+		}
+		restored = true
+	}
+	if restored {
+		return true, nil
+	}
+	if s.Recoverer != nil && tx.Status == network.Valid {
+		recovered, err := s.Recoverer.Recover(ctx, tx)
+		if err != nil {
+			return false, errors.WithMessagef(err, "failed recovering transaction from the ledger")
+		}
+		if recovered {
+			logger.Debugf("transaction [%s] not known to any local database, recovered from the ledger", tx.TxID)
+			return true, nil
+		}
+	}
+	logger.Debugf("transaction [%s] not known to any local database, skip it", tx.TxID)
+	return false, nil
+}
+
+// lookup applies the ledger status to the transactions known to the targets, looking them up by id.
+// It is used when the ledger cannot be walked, therefore the transactions not known locally cannot be found.
+func (s *Scanner) lookup(ctx context.Context, cp *Checkpoint) error {
+	var txIDs []string
+	seen := map[string]bool{}
+	for _, target := range s.Targets {
+		it, err := target.DB.TokenRequests(driver.QueryTokenRequestsParams{})
+		if err != nil {
+			return errors.WithMessagef(err, "failed listing token requests from [%s]", target.Name)
+		}
+		for {
+			record, err := it.Next()
+			if err != nil {
+				it.Close()
+				return errors.WithMessagef(err, "failed listing token requests from [%s]", target.Name)
+			}
+			if record == nil {
+				break
+			}
+			if !seen[record.TxID] {
+				seen[record.TxID] = true
+				txIDs = append(txIDs, record.TxID)
+			}
+		}
+		it.Close()
+	}
+	for _, txID := range txIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tx, supported, err := s.Ledger.LookupTransaction(ctx, s.Namespace, txID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed looking up transaction [%s]", txID)
+		}
+		if !supported {
+			return errors.Errorf("the ledger supports neither scans nor lookups")
+		}
+		if tx == nil {
+			logger.Debugf("transaction [%s] not on the ledger, skip it", txID)
+			continue
+		}
+		restored, err := s.processTransaction(ctx, tx)
+		if err != nil {
+			return errors.WithMessagef(err, "failed processing transaction [%s]", txID)
+		}
+		cp.Transactions++
+		if restored {
+			cp.Restored++
+		} else {
+			cp.Skipped++
+		}
+		cp.LastTxID = txID
+	}
+	return nil
+}
+
+func (s *Scanner) store(cp *Checkpoint) error {
+	cp.UpdatedAt = time.Now()
+	if err := s.Checkpoints.Store(cp); err != nil {
+		return errors.WithMessagef(err, "failed storing checkpoint at block [%d]", cp.NextBlock)
+	}
+	return nil
+}
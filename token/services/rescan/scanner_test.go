@@ -0,0 +1,261 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package rescan
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/common/utils/collections"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// ledger serves a fixed list of blocks and then waits for the context to be done, like a live ledger.
+// If noScan is set, it serves the transactions of the blocks by id only.
+type ledger struct {
+	blocks []*network.LedgerBlock
+	noScan bool
+}
+
+func (l *ledger) LookupTransaction(ctx context.Context, namespace string, txID string) (*network.LedgerTransaction, bool, error) {
+	for _, block := range l.blocks {
+		for _, tx := range block.Transactions {
+			if tx.TxID == txID {
+				return tx, true, nil
+			}
+		}
+	}
+	return nil, true, nil
+}
+
+func (l *ledger) ScanLedger(ctx context.Context, namespace string, fromBlock uint64, callback network.ScanCallback) error {
+	if l.noScan {
+		return errors.Wrapf(network.ErrScanNotSupported, "test ledger")
+	}
+	for _, block := range l.blocks {
+		if block.Number < fromBlock {
+			continue
+		}
+		stop, err := callback(ctx, block)
+		if err != nil {
+			return err
+		}
+		if stop {
+			return nil
+		}
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type db struct {
+	requests map[string][]byte
+}
+
+func (d *db) GetTokenRequest(txID string) ([]byte, error) {
+	return d.requests[txID], nil
+}
+
+func (d *db) TokenRequests(params driver.QueryTokenRequestsParams) (driver.TokenRequestIterator, error) {
+	var records []*driver.TokenRequestRecord
+	for _, txID := range []string{"tx0", "tx1", "tx2", "tx3", "tx4"} {
+		if request, ok := d.requests[txID]; ok {
+			records = append(records, &driver.TokenRequestRecord{TxID: txID, TokenRequest: request})
+		}
+	}
+	return collections.NewSliceIterator(records), nil
+}
+
+type recoverer struct {
+	recovered []string
+}
+
+func (r *recoverer) Recover(ctx context.Context, tx *network.LedgerTransaction) (bool, error) {
+	if len(tx.Outputs) == 0 {
+		return false, nil
+	}
+	r.recovered = append(r.recovered, tx.TxID)
+	return true, nil
+}
+
+type processor struct {
+	processed []string
+	failOn    string
+}
+
+func (p *processor) Process(ctx context.Context, txID string, status int, message string, tokenRequestHash []byte) error {
+	if txID == p.failOn {
+		return errors.New("boom")
+	}
+	p.processed = append(p.processed, txID)
+	return nil
+}
+
+type store struct {
+	cp     *Checkpoint
+	stores int
+}
+
+func (s *store) Load() (*Checkpoint, error) {
+	if s.cp == nil {
+		return nil, nil
+	}
+	cp := *s.cp
+	return &cp, nil
+}
+
+func (s *store) Store(checkpoint *Checkpoint) error {
+	cp := *checkpoint
+	s.cp = &cp
+	s.stores++
+	return nil
+}
+
+func newLedger() *ledger {
+	return &ledger{blocks: []*network.LedgerBlock{
+		{Number: 0},
+		{Number: 1, Transactions: []*network.LedgerTransaction{{TxID: "tx1", Status: network.Valid}}},
+		{Number: 2, Transactions: []*network.LedgerTransaction{{TxID: "tx2", Status: network.Valid}, {TxID: "tx3", Status: network.Invalid}}},
+		{Number: 3},
+		{Number: 4, Transactions: []*network.LedgerTransaction{{TxID: "tx4", Status: network.Valid}}},
+	}}
+}
+
+func TestScan(t *testing.T) {
+	ttxDB := &db{requests: map[string][]byte{"tx1": []byte("r1"), "tx3": []byte("r3"), "tx4": []byte("r4")}}
+	auditDB := &db{requests: map[string][]byte{"tx4": []byte("r4")}}
+	ttxProcessor, auditProcessor := &processor{}, &processor{}
+	checkpoints := &store{}
+	s := &Scanner{
+		Namespace: "ns",
+		Ledger:    newLedger(),
+		Targets: []Target{
+			{Name: "ttxdb", DB: ttxDB, Processor: ttxProcessor},
+			{Name: "auditdb", DB: auditDB, Processor: auditProcessor},
+		},
+		Checkpoints: checkpoints,
+	}
+
+	var progress []uint64
+	cp, err := s.Scan(context.Background(), WithIdleTimeout(100*time.Millisecond), WithCheckpointInterval(2), WithProgress(func(p Progress) {
+		progress = append(progress, p.Block)
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{0, 1, 2, 3, 4}, progress)
+	assert.Equal(t, []string{"tx1", "tx3", "tx4"}, ttxProcessor.processed)
+	assert.Equal(t, []string{"tx4"}, auditProcessor.processed)
+	assert.Equal(t, uint64(5), cp.NextBlock)
+	assert.Equal(t, uint64(4), cp.Transactions)
+	assert.Equal(t, uint64(3), cp.Restored)
+	assert.Equal(t, uint64(1), cp.Skipped)
+	assert.Equal(t, "tx4", cp.LastTxID)
+	assert.Equal(t, uint64(5), checkpoints.cp.NextBlock)
+	assert.Equal(t, 3, checkpoints.stores)
+
+	// resume from the checkpoint, nothing new
+	ttxProcessor.processed = nil
+	cp, err = s.Scan(context.Background(), WithIdleTimeout(100*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Empty(t, ttxProcessor.processed)
+	assert.Equal(t, uint64(5), cp.NextBlock)
+
+	// explicit range
+	cp, err = s.Scan(context.Background(), WithFromBlock(2), WithToBlock(3))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx3"}, ttxProcessor.processed)
+	assert.Equal(t, uint64(4), cp.NextBlock)
+	assert.Equal(t, uint64(2), cp.Transactions)
+}
+
+func TestScanResumeAfterFailure(t *testing.T) {
+	ttxDB := &db{requests: map[string][]byte{"tx1": []byte("r1"), "tx2": []byte("r2"), "tx4": []byte("r4")}}
+	p := &processor{failOn: "tx2"}
+	checkpoints := &store{}
+	s := &Scanner{
+		Namespace:   "ns",
+		Ledger:      newLedger(),
+		Targets:     []Target{{Name: "ttxdb", DB: ttxDB, Processor: p}},
+		Checkpoints: checkpoints,
+	}
+	_, err := s.Scan(context.Background(), WithIdleTimeout(100*time.Millisecond))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rescan stopped at block [2]")
+	assert.Equal(t, uint64(2), checkpoints.cp.NextBlock)
+
+	// the failed block is processed again
+	p.failOn = ""
+	cp, err := s.Scan(context.Background(), WithIdleTimeout(100*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx1", "tx2", "tx4"}, p.processed)
+	assert.Equal(t, uint64(5), cp.NextBlock)
+}
+
+func TestScanCanceled(t *testing.T) {
+	s := &Scanner{
+		Namespace:   "ns",
+		Ledger:      newLedger(),
+		Checkpoints: &store{},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := s.Scan(ctx, WithIdleTimeout(time.Hour))
+	assert.Error(t, err)
+}
+
+func TestScanRecover(t *testing.T) {
+	l := newLedger()
+	// tx2 is valid and not known locally, its outputs are on the ledger
+	l.blocks[2].Transactions[0].Outputs = map[uint64][]byte{0: []byte("output")}
+	// tx3 is invalid, it must not be recovered
+	l.blocks[2].Transactions[1].Outputs = map[uint64][]byte{0: []byte("output")}
+	p := &processor{}
+	r := &recoverer{}
+	s := &Scanner{
+		Namespace:   "ns",
+		Ledger:      l,
+		Targets:     []Target{{Name: "ttxdb", DB: &db{requests: map[string][]byte{"tx1": []byte("r1")}}, Processor: p}},
+		Checkpoints: &store{},
+		Recoverer:   r,
+	}
+	cp, err := s.Scan(context.Background(), WithIdleTimeout(100*time.Millisecond))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tx1"}, p.processed)
+	assert.Equal(t, []string{"tx2"}, r.recovered)
+	assert.Equal(t, uint64(2), cp.Restored)
+	assert.Equal(t, uint64(2), cp.Skipped)
+}
+
+func TestScanLookup(t *testing.T) {
+	l := newLedger()
+	l.noScan = true
+	ttxDB := &db{requests: map[string][]byte{"tx0": []byte("r0"), "tx1": []byte("r1"), "tx4": []byte("r4")}}
+	auditDB := &db{requests: map[string][]byte{"tx3": []byte("r3"), "tx4": []byte("r4")}}
+	ttxProcessor, auditProcessor := &processor{}, &processor{}
+	checkpoints := &store{cp: &Checkpoint{NextBlock: 3}}
+	s := &Scanner{
+		Namespace: "ns",
+		Ledger:    l,
+		Targets: []Target{
+			{Name: "ttxdb", DB: ttxDB, Processor: ttxProcessor},
+			{Name: "auditdb", DB: auditDB, Processor: auditProcessor},
+		},
+		Checkpoints: checkpoints,
+	}
+	cp, err := s.Scan(context.Background(), WithIdleTimeout(100*time.Millisecond))
+	assert.NoError(t, err)
+	// tx0 is not on the ledger, the checkpoint does not restrict the lookups
+	assert.Equal(t, []string{"tx1", "tx4"}, ttxProcessor.processed)
+	assert.Equal(t, []string{"tx4", "tx3"}, auditProcessor.processed)
+	assert.Equal(t, uint64(3), cp.Transactions)
+	assert.Equal(t, uint64(3), cp.Restored)
+	assert.Equal(t, uint64(3), cp.NextBlock)
+	assert.Equal(t, uint64(3), checkpoints.cp.Transactions)
+}
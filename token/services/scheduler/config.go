@@ -0,0 +1,103 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ConfigKey is the configuration key, relative to the TMS, of the scheduler
+	ConfigKey = "services.scheduler"
+
+	defaultPollInterval   = 10 * time.Second
+	defaultBatchSize      = 100
+	defaultWorkers        = 4
+	defaultMaxRetries     = 5
+	defaultInitialBackoff = 30 * time.Second
+	defaultMaxBackoff     = 1 * time.Hour
+	defaultLease          = 10 * time.Minute
+)
+
+// Config is the configuration of the scheduler of a TMS
+type Config struct {
+	// Enabled starts the scheduler of the TMS when the token SDK starts
+	Enabled bool `yaml:"enabled,omitempty"`
+	// PollInterval is the time between two checks for due transfers
+	PollInterval time.Duration `yaml:"pollInterval,omitempty"`
+	// BatchSize is the maximum number of due transfers fetched at each check
+	BatchSize int `yaml:"batchSize,omitempty"`
+	// Workers is the number of transfers fired concurrently
+	Workers int `yaml:"workers,omitempty"`
+	// MaxRetries is the number of times a failed occurrence is retried before moving to the next occurrence.
+	// If negative, failed occurrences are not retried.
+	MaxRetries int `yaml:"maxRetries,omitempty"`
+	// InitialBackoff is the time before the first retry, doubled at each subsequent retry
+	InitialBackoff time.Duration `yaml:"initialBackoff,omitempty"`
+	// MaxBackoff is the maximum time between two retries
+	MaxBackoff time.Duration `yaml:"maxBackoff,omitempty"`
+	// Lease is the time a fired transfer is hidden from other schedulers sharing the same database.
+	// It must be longer than the time needed to reach finality, otherwise the transfer could fire twice.
+	Lease time.Duration `yaml:"lease,omitempty"`
+}
+
+// NewConfig loads the scheduler configuration of the passed TMS.
+// The defaults for the missing values are applied by NewService.
+func NewConfig(tmsConfig driver.Configuration) (*Config, error) {
+	c := &Config{}
+	if tmsConfig.IsSet(ConfigKey) {
+		if err := tmsConfig.UnmarshalKey(ConfigKey, c); err != nil {
+			return nil, errors.Wrapf(err, "invalid config for key [%s]", ConfigKey)
+		}
+	}
+	return c, nil
+}
+
+// withDefaults returns a copy of the configuration having the defaults for the missing values.
+// It must be applied once: a negative MaxRetries becomes zero, which would then become the default.
+func (c *Config) withDefaults() *Config {
+	res := *c
+	if res.PollInterval <= 0 {
+		res.PollInterval = defaultPollInterval
+	}
+	if res.BatchSize <= 0 {
+		res.BatchSize = defaultBatchSize
+	}
+	if res.Workers <= 0 {
+		res.Workers = defaultWorkers
+	}
+	if res.MaxRetries < 0 {
+		res.MaxRetries = 0
+	} else if res.MaxRetries == 0 {
+		res.MaxRetries = defaultMaxRetries
+	}
+	if res.InitialBackoff <= 0 {
+		res.InitialBackoff = defaultInitialBackoff
+	}
+	if res.MaxBackoff <= 0 {
+		res.MaxBackoff = defaultMaxBackoff
+	}
+	if res.Lease <= 0 {
+		res.Lease = defaultLease
+	}
+	return &res
+}
+
+// backoff returns the time to wait before the retry following the passed number of failed attempts
+func (c *Config) backoff(attempts int) time.Duration {
+	backoff := c.InitialBackoff
+	for i := 1; i < attempts && backoff < c.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > c.MaxBackoff {
+		return c.MaxBackoff
+	}
+	return backoff
+}
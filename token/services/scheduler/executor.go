@@ -0,0 +1,177 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"context"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttx"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+// ScheduleIDMetadataKey is the application metadata key, set on the token transactions fired by the scheduler,
+// whose value is the identifier of the scheduled transfer
+const ScheduleIDMetadataKey = "scheduler.id"
+
+// ScheduleOccurrenceMetadataKey is the application metadata key, set on the token transactions fired by the scheduler,
+// whose value is the scheduled time of the occurrence, in RFC 3339 format
+const ScheduleOccurrenceMetadataKey = "scheduler.occurrence"
+
+// ErrFinalityUnknown is returned by an Executor when a token transaction has been submitted for ordering
+// but its finality could not be observed. Such occurrences are retried only once the transaction is known
+// to have been deleted, to avoid paying twice.
+var ErrFinalityUnknown = errors.New("finality unknown")
+
+// Submission is a token transaction fired for an occurrence of a scheduled transfer
+type Submission struct {
+	// TxID is the identifier of the token transaction
+	TxID string
+	// Status is the status of the token transaction
+	Status ttxdb.TxStatus
+}
+
+// Executor fires an occurrence of a scheduled transfer
+type Executor interface {
+	// Submission returns the token transaction already fired for the pending occurrence of the passed scheduled transfer,
+	// nil if there is none. A confirmed transaction is preferred to a pending one, and a pending one to a deleted one.
+	Submission(ctx context.Context, st *ScheduledTransfer) (*Submission, error)
+	// Execute transfers the tokens of the passed scheduled transfer and waits for finality.
+	// It returns the identifier of the token transaction, also on failure, if the transaction has been assembled.
+	Execute(ctx context.Context, st *ScheduledTransfer) (string, error)
+}
+
+// TransactionDB is the storage of the token transactions fired by the scheduler
+type TransactionDB interface {
+	Transactions(params ttxdb.QueryTransactionsParams) (driver.TransactionIterator, error)
+}
+
+type ViewManager interface {
+	InitiateView(view view2.View, ctx context.Context) (interface{}, error)
+}
+
+// ViewExecutor fires the scheduled transfers by initiating a TransferView
+type ViewExecutor struct {
+	ViewManager ViewManager
+	TMSID       token.TMSID
+	DB          TransactionDB
+}
+
+// submissionRank orders the statuses of the transactions fired for the same occurrence
+var submissionRank = map[ttxdb.TxStatus]int{
+	ttxdb.Deleted:   1,
+	ttxdb.Pending:   2,
+	ttxdb.Confirmed: 3,
+}
+
+func (e *ViewExecutor) Submission(_ context.Context, st *ScheduledTransfer) (*Submission, error) {
+	it, err := e.DB.Transactions(ttxdb.QueryTransactionsParams{ApplicationMetadata: occurrenceMetadata(st.ID, st.Occurrence)})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying transactions of scheduled transfer [%s]", st.ID)
+	}
+	defer it.Close()
+	var res *Submission
+	for {
+		record, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed iterating transactions of scheduled transfer [%s]", st.ID)
+		}
+		if record == nil {
+			return res, nil
+		}
+		if res == nil || submissionRank[record.Status] > submissionRank[res.Status] {
+			res = &Submission{TxID: record.TxID, Status: record.Status}
+		}
+	}
+}
+
+func (e *ViewExecutor) Execute(ctx context.Context, st *ScheduledTransfer) (string, error) {
+	v := NewTransferView(e.TMSID, st)
+	if _, err := e.ViewManager.InitiateView(v, ctx); err != nil {
+		if v.broadcast {
+			return v.txID, errors.Wrapf(ErrFinalityUnknown, "transaction [%s]: %s", v.txID, err)
+		}
+		return v.txID, err
+	}
+	return v.txID, nil
+}
+
+// TransferView fires an occurrence of a scheduled transfer through the ttx flow.
+// The recipient's node must respond to this view as it would to any transfer: by sending its recipient identity,
+// then receiving and accepting the transaction.
+type TransferView struct {
+	TMSID     token.TMSID
+	ID        string
+	Wallet    string
+	TokenType string
+	Amount    uint64
+	Recipient view.Identity
+	// Occurrence is the scheduled time of the occurrence being fired
+	Occurrence time.Time
+
+	txID      string
+	broadcast bool
+}
+
+func NewTransferView(tmsID token.TMSID, st *ScheduledTransfer) *TransferView {
+	return &TransferView{
+		TMSID:      tmsID,
+		ID:         st.ID,
+		Wallet:     st.Wallet,
+		TokenType:  st.TokenType,
+		Amount:     st.Amount,
+		Recipient:  view.Identity(st.Recipient),
+		Occurrence: st.Occurrence,
+	}
+}
+
+func (v *TransferView) Call(context view.Context) (interface{}, error) {
+	recipient, err := ttx.RequestRecipientIdentity(context, v.Recipient, token.WithTMSID(v.TMSID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity")
+	}
+	wallet := ttx.GetWallet(context, v.Wallet, token.WithTMSID(v.TMSID))
+	if wallet == nil {
+		return nil, errors.Errorf("wallet [%s] not found", v.Wallet)
+	}
+	tx, err := ttx.NewAnonymousTransaction(context, ttx.WithTMSID(v.TMSID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed creating transaction")
+	}
+	v.txID = tx.ID()
+	for k, value := range occurrenceMetadata(v.ID, v.Occurrence) {
+		tx.SetApplicationMetadata(k, value)
+	}
+	if err := tx.Transfer(wallet, v.TokenType, []uint64{v.Amount}, []view.Identity{recipient}); err != nil {
+		return nil, errors.WithMessagef(err, "failed appending transfer")
+	}
+	if _, err := context.RunView(ttx.NewCollectEndorsementsView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "failed collecting endorsements for [%s]", tx.ID())
+	}
+	// the transaction might reach the ordering service even if the ordering view fails
+	v.broadcast = true
+	if _, err := context.RunView(ttx.NewOrderingView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "failed ordering transaction [%s]", tx.ID())
+	}
+	if _, err := context.RunView(ttx.NewFinalityView(tx)); err != nil {
+		return nil, errors.WithMessagef(err, "failed waiting for finality of transaction [%s]", tx.ID())
+	}
+	return tx.ID(), nil
+}
+
+// occurrenceMetadata returns the application metadata identifying the transactions fired for the passed occurrence
+func occurrenceMetadata(id string, occurrence time.Time) map[string][]byte {
+	return map[string][]byte{
+		ScheduleIDMetadataKey:         []byte(id),
+		ScheduleOccurrenceMetadataKey: []byte(occurrence.UTC().Format(time.RFC3339Nano)),
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/config"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/schedulerdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+var logger = logging.MustGetLogger("token-sdk.services.scheduler")
+
+type DBProvider interface {
+	DBByTMSId(id token.TMSID) (*schedulerdb.DB, error)
+}
+
+type TTXDBProvider interface {
+	DBByTMSId(id token.TMSID) (*ttxdb.DB, error)
+}
+
+// Manager handles the schedulers of the TMSs
+type Manager struct {
+	configService *config.Service
+	dbProvider    DBProvider
+	ttxdbProvider TTXDBProvider
+	viewManager   ViewManager
+
+	mutex    sync.Mutex
+	services map[string]*Service
+}
+
+func NewManager(configService *config.Service, dbProvider DBProvider, ttxdbProvider TTXDBProvider, viewManager ViewManager) *Manager {
+	return &Manager{
+		configService: configService,
+		dbProvider:    dbProvider,
+		ttxdbProvider: ttxdbProvider,
+		viewManager:   viewManager,
+		services:      map[string]*Service{},
+	}
+}
+
+// ServiceByTMSId returns the scheduler of the passed TMS.
+// The scheduler fires the due transfers only if enabled in the configuration or started explicitly.
+func (m *Manager) ServiceByTMSId(tmsID token.TMSID) (*Service, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if s, ok := m.services[tmsID.String()]; ok {
+		return s, nil
+	}
+	tmsConfig, err := m.configService.ConfigurationFor(tmsID.Network, tmsID.Channel, tmsID.Namespace)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get configuration for [%s]", tmsID)
+	}
+	c, err := NewConfig(tmsConfig)
+	if err != nil {
+		return nil, err
+	}
+	db, err := m.dbProvider.DBByTMSId(tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get scheduler db for [%s]", tmsID)
+	}
+	ttxDB, err := m.ttxdbProvider.DBByTMSId(tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get ttx db for [%s]", tmsID)
+	}
+	s := NewService(tmsID, db, &ViewExecutor{ViewManager: m.viewManager, TMSID: tmsID, DB: ttxDB}, c)
+	m.services[tmsID.String()] = s
+	return s, nil
+}
+
+// Start starts the schedulers of the TMSs whose configuration enables them
+func (m *Manager) Start(ctx context.Context) error {
+	configurations, err := m.configService.Configurations()
+	if err != nil {
+		return errors.WithMessagef(err, "failed to get configurations")
+	}
+	for _, tmsConfig := range configurations {
+		c, err := NewConfig(tmsConfig)
+		if err != nil {
+			return err
+		}
+		if !c.Enabled {
+			continue
+		}
+		s, err := m.ServiceByTMSId(tmsConfig.ID())
+		if err != nil {
+			return err
+		}
+		if err := s.Start(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops all the started schedulers
+func (m *Manager) Stop() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, s := range m.services {
+		s.Stop()
+	}
+}
+
+var managerType = reflect.TypeOf((*Manager)(nil))
+
+// GetManager returns the scheduler manager from the passed service provider
+func GetManager(sp token.ServiceProvider) (*Manager, error) {
+	s, err := sp.GetService(managerType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get scheduler manager")
+	}
+	return s.(*Manager), nil
+}
+
+// GetService returns the scheduler of the TMS identified by the passed options
+func GetService(sp token.ServiceProvider, opts ...token.ServiceOption) (*Service, error) {
+	tms := token.GetManagementService(sp, opts...)
+	if tms == nil {
+		return nil, errors.Errorf("failed to get token management service for [%v]", opts)
+	}
+	m, err := GetManager(sp)
+	if err != nil {
+		return nil, err
+	}
+	return m.ServiceByTMSId(tms.ID())
+}
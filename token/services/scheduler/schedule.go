@@ -0,0 +1,149 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/pkg/errors"
+)
+
+type (
+	// ScheduledTransfer is the intent of transferring tokens, once or periodically, to a recipient
+	ScheduledTransfer = driver.ScheduledTransfer
+	// ScheduledTransferRun records an attempt of firing an occurrence of a scheduled transfer
+	ScheduledTransferRun = driver.ScheduledTransferRun
+	// Status is the status of a scheduled transfer
+	Status = driver.ScheduledTransferStatus
+	// Unit is the unit of the period of a recurring transfer
+	Unit = driver.ScheduleUnit
+)
+
+const (
+	Active    = driver.ScheduleActive
+	Completed = driver.ScheduleCompleted
+	Cancelled = driver.ScheduleCancelled
+	Failed    = driver.ScheduleFailed
+
+	Once   = driver.Once
+	Minute = driver.Minute
+	Hour   = driver.Hour
+	Day    = driver.Day
+	Week   = driver.Week
+	Month  = driver.Month
+)
+
+// Intent describes a transfer to schedule
+type Intent struct {
+	// Wallet is the identifier of the owner wallet the tokens are spent from
+	Wallet string
+	// TokenType is the type of the tokens to transfer
+	TokenType string
+	// Amount is the amount transferred at each occurrence
+	Amount uint64
+	// Recipient is the FSC node identity of the recipient
+	Recipient view.Identity
+	// Start is the time of the first occurrence. If zero, the first occurrence fires as soon as possible.
+	Start time.Time
+	// Unit is the unit of the period, Once for a one-off transfer
+	Unit Unit
+	// Every is the number of units between two occurrences. If zero, 1 is used for recurring transfers.
+	Every int
+	// End is the time after which no occurrence fires. If zero, a recurring transfer repeats until cancelled.
+	End time.Time
+}
+
+func (i *Intent) validate() error {
+	if len(i.Wallet) == 0 {
+		return errors.New("missing wallet")
+	}
+	if len(i.TokenType) == 0 {
+		return errors.New("missing token type")
+	}
+	if i.Amount == 0 {
+		return errors.New("amount is zero")
+	}
+	if i.Recipient.IsNone() {
+		return errors.New("missing recipient")
+	}
+	switch i.Unit {
+	case Once:
+		if i.Every != 0 {
+			return errors.New("a one-off transfer cannot have a period")
+		}
+	case Minute, Hour, Day, Week, Month:
+		if i.Every < 0 {
+			return errors.Errorf("invalid period [%d]", i.Every)
+		}
+	default:
+		return errors.Errorf("unknown unit [%s]", i.Unit)
+	}
+	if !i.End.IsZero() && i.End.Before(i.Start) {
+		return errors.Errorf("end [%s] is before start [%s]", i.End, i.Start)
+	}
+	return nil
+}
+
+// normalize returns the passed time in UTC, with the precision supported by all the database drivers
+func normalize(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return t.UTC().Truncate(time.Microsecond)
+}
+
+// nextOccurrence returns the first occurrence of the passed scheduled transfer strictly after the passed time,
+// or the zero time if there is none.
+// Occurrences are computed from the start, so that retries and delays do not make the schedule drift.
+func nextOccurrence(st *ScheduledTransfer, after time.Time) time.Time {
+	every := st.Every
+	if every <= 0 {
+		every = 1
+	}
+	var next time.Time
+	switch st.Unit {
+	case Once:
+		return time.Time{}
+	case Month:
+		// estimate the number of periods, then move forward, calendar months have different lengths
+		months := (after.Year()-st.Start.Year())*12 + int(after.Month()) - int(st.Start.Month())
+		k := months/every - 1
+		if k < 1 {
+			k = 1
+		}
+		for next = st.Start.AddDate(0, k*every, 0); !next.After(after); next = st.Start.AddDate(0, k*every, 0) {
+			k++
+		}
+	default:
+		period := unitDuration(st.Unit) * time.Duration(every)
+		if after.Before(st.Start) {
+			return st.Start
+		}
+		next = st.Start.Add((after.Sub(st.Start)/period + 1) * period)
+	}
+	if !st.End.IsZero() && next.After(st.End) {
+		return time.Time{}
+	}
+	return next
+}
+
+func unitDuration(unit Unit) time.Duration {
+	switch unit {
+	case Minute:
+		return time.Minute
+	case Hour:
+		return time.Hour
+	case Day:
+		return 24 * time.Hour
+	case Week:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
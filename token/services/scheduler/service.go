@@ -0,0 +1,292 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+// DB is the storage of the scheduled transfers
+type DB = driver.SchedulerDB
+
+// ListParams selects the scheduled transfers returned by List
+type ListParams = driver.QueryScheduledTransfersParams
+
+// Service persists the scheduled transfers of a TMS and fires them when due.
+// Each occurrence fires at most once per scheduler sharing the database: due transfers are claimed
+// before firing, and their outcome is recorded as a run.
+// A failed occurrence is retried with exponential backoff, up to the configured number of retries,
+// then the transfer moves to its next occurrence. Occurrences missed while no scheduler was running are skipped,
+// except the pending one, which fires once.
+// Before firing, the token transactions already fired for the occurrence are looked up:
+// the occurrence fires again only if they have all been deleted.
+type Service struct {
+	tmsID    token.TMSID
+	db       DB
+	executor Executor
+	config   *Config
+	now      func() time.Time
+
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService returns a new scheduler for the passed TMS, using the defaults for the missing configuration values
+func NewService(tmsID token.TMSID, db DB, executor Executor, config *Config) *Service {
+	return &Service{
+		tmsID:    tmsID,
+		db:       db,
+		executor: executor,
+		config:   config.withDefaults(),
+		now:      time.Now,
+	}
+}
+
+// Schedule stores a new scheduled transfer and returns its identifier
+func (s *Service) Schedule(intent *Intent) (string, error) {
+	if err := intent.validate(); err != nil {
+		return "", errors.WithMessage(err, "invalid intent")
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed generating id")
+	}
+	now := normalize(s.now())
+	start := normalize(intent.Start)
+	if start.IsZero() {
+		start = now
+	}
+	every := intent.Every
+	if intent.Unit != Once && every == 0 {
+		every = 1
+	}
+	st := &ScheduledTransfer{
+		ID:         id,
+		Wallet:     intent.Wallet,
+		TokenType:  intent.TokenType,
+		Amount:     intent.Amount,
+		Recipient:  intent.Recipient,
+		Start:      start,
+		Unit:       intent.Unit,
+		Every:      every,
+		End:        normalize(intent.End),
+		Status:     Active,
+		Occurrence: start,
+		NextRun:    start,
+		CreatedAt:  now,
+	}
+	if err := s.db.AddScheduledTransfer(st); err != nil {
+		return "", errors.WithMessagef(err, "failed storing scheduled transfer")
+	}
+	logger.Debugf("scheduled transfer [%s] of [%d:%s] from [%s], first occurrence at [%s]", id, st.Amount, st.TokenType, st.Wallet, start)
+	return id, nil
+}
+
+// Get returns the scheduled transfer with the passed identifier
+func (s *Service) Get(id string) (*ScheduledTransfer, error) {
+	st, err := s.db.GetScheduledTransfer(id)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting scheduled transfer [%s]", id)
+	}
+	if st == nil {
+		return nil, errors.Errorf("scheduled transfer [%s] not found", id)
+	}
+	return st, nil
+}
+
+// List returns the scheduled transfers matching the passed params, ordered by creation time
+func (s *Service) List(params ListParams) ([]*ScheduledTransfer, error) {
+	return s.db.QueryScheduledTransfers(params)
+}
+
+// Runs returns the recorded runs of the passed scheduled transfer, ordered by execution time
+func (s *Service) Runs(id string) ([]*ScheduledTransferRun, error) {
+	return s.db.GetScheduledTransferRuns(id)
+}
+
+// Cancel cancels an active scheduled transfer. An occurrence already firing is not interrupted.
+func (s *Service) Cancel(id string) error {
+	ok, err := s.db.CancelScheduledTransfer(id)
+	if err != nil {
+		return errors.WithMessagef(err, "failed cancelling scheduled transfer [%s]", id)
+	}
+	if !ok {
+		st, err := s.db.GetScheduledTransfer(id)
+		if err != nil {
+			return errors.WithMessagef(err, "failed getting scheduled transfer [%s]", id)
+		}
+		if st == nil {
+			return errors.Errorf("scheduled transfer [%s] not found", id)
+		}
+		return errors.Errorf("scheduled transfer [%s] is not active, status [%s]", id, driver.ScheduledTransferStatusMessage[st.Status])
+	}
+	logger.Debugf("scheduled transfer [%s] cancelled", id)
+	return nil
+}
+
+// Start checks for due transfers every poll interval, until Stop is called or the passed context is done
+func (s *Service) Start(ctx context.Context) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.cancel != nil {
+		return errors.Errorf("scheduler for [%s] already started", s.tmsID)
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.config.PollInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Tick(ctx); err != nil {
+				logger.Errorf("failed firing scheduled transfers for [%s]: %s", s.tmsID, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	logger.Infof("scheduler for [%s] started, poll interval [%s]", s.tmsID, s.config.PollInterval)
+	return nil
+}
+
+// Stop stops checking for due transfers, interrupts the transfers being fired, and waits for them to return
+func (s *Service) Stop() {
+	s.mutex.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mutex.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	s.wg.Wait()
+}
+
+// Tick fires the transfers due now, and returns how many occurrences have been fired
+func (s *Service) Tick(ctx context.Context) (int, error) {
+	now := normalize(s.now())
+	due, err := s.db.DueScheduledTransfers(now, s.config.BatchSize)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed getting due transfers")
+	}
+	var claimed []*ScheduledTransfer
+	for _, st := range due {
+		ok, err := s.db.ClaimScheduledTransfer(st.ID, st.NextRun, now.Add(s.config.Lease))
+		if err != nil {
+			return 0, errors.WithMessagef(err, "failed claiming scheduled transfer [%s]", st.ID)
+		}
+		if !ok {
+			logger.Debugf("scheduled transfer [%s] claimed by someone else", st.ID)
+			continue
+		}
+		claimed = append(claimed, st)
+	}
+
+	jobs := make(chan *ScheduledTransfer)
+	var wg sync.WaitGroup
+	for w := 0; w < s.config.Workers && w < len(claimed); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for st := range jobs {
+				s.fire(ctx, st)
+			}
+		}()
+	}
+	for _, st := range claimed {
+		jobs <- st
+	}
+	close(jobs)
+	wg.Wait()
+	return len(claimed), nil
+}
+
+// fire executes the pending occurrence of the passed scheduled transfer, records the outcome and reschedules it
+func (s *Service) fire(ctx context.Context, st *ScheduledTransfer) {
+	submission, err := s.executor.Submission(ctx, st)
+	if err != nil {
+		// the lease expires and the occurrence fires again
+		logger.Errorf("failed looking up the transactions of scheduled transfer [%s]: %s", st.ID, err)
+		return
+	}
+	var txID string
+	switch {
+	case submission != nil && submission.Status == ttxdb.Confirmed:
+		logger.Debugf("occurrence [%s] of scheduled transfer [%s] already paid by [%s]", st.Occurrence, st.ID, submission.TxID)
+		txID = submission.TxID
+	case submission != nil && submission.Status == ttxdb.Pending:
+		txID = submission.TxID
+		err = errors.Wrapf(ErrFinalityUnknown, "transaction [%s] still pending", submission.TxID)
+	default:
+		logger.Debugf("firing scheduled transfer [%s], occurrence [%s], attempt [%d]", st.ID, st.Occurrence, st.Attempts+1)
+		txID, err = s.executor.Execute(ctx, st)
+	}
+	now := normalize(s.now())
+
+	run := &ScheduledTransferRun{
+		ScheduleID: st.ID,
+		Occurrence: st.Occurrence,
+		Attempt:    st.Attempts + 1,
+		TxID:       txID,
+		Succeeded:  err == nil,
+		ExecutedAt: now,
+	}
+	if err != nil {
+		run.Message = err.Error()
+		logger.Warnf("scheduled transfer [%s] failed, attempt [%d]: %s", st.ID, run.Attempt, err)
+	}
+	if err := s.db.AddScheduledTransferRun(run); err != nil {
+		logger.Errorf("failed recording run of scheduled transfer [%s]: %s", st.ID, err)
+	}
+
+	status, occurrence, nextRun, attempts := s.reschedule(st, run.Attempt, err, now)
+	ok, err := s.db.UpdateScheduledTransfer(st.ID, status, occurrence, nextRun, attempts)
+	if err != nil {
+		// the lease expires and the occurrence fires again
+		logger.Errorf("failed rescheduling scheduled transfer [%s]: %s", st.ID, err)
+		return
+	}
+	if !ok {
+		logger.Debugf("scheduled transfer [%s] not active anymore, not rescheduled", st.ID)
+		return
+	}
+	logger.Debugf("scheduled transfer [%s] rescheduled, status [%s], next run [%s]", st.ID, driver.ScheduledTransferStatusMessage[status], nextRun)
+}
+
+// reschedule returns the state of the passed scheduled transfer after the passed attempt
+func (s *Service) reschedule(st *ScheduledTransfer, attempt int, err error, now time.Time) (Status, time.Time, time.Time, int) {
+	// an occurrence whose finality is unknown is retried too: the retry fires only if the transaction has been deleted
+	if err != nil && attempt <= s.config.MaxRetries {
+		return Active, st.Occurrence, now.Add(s.config.backoff(attempt)), attempt
+	}
+
+	// move to the first occurrence in the future
+	after := st.Occurrence
+	if now.After(after) {
+		after = now
+	}
+	next := nextOccurrence(st, after)
+	if next.IsZero() {
+		if err != nil && st.Unit == Once {
+			return Failed, st.Occurrence, st.NextRun, attempt
+		}
+		return Completed, st.Occurrence, st.NextRun, 0
+	}
+	return Active, next, next, 0
+}
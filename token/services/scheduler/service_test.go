@@ -0,0 +1,357 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/common/utils/collections"
+	sql2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/sql"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/sql/sqlite"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExecutor struct {
+	mutex       sync.Mutex
+	fired       []string
+	err         error
+	submissions map[string]*Submission
+}
+
+func (e *fakeExecutor) Submission(_ context.Context, st *ScheduledTransfer) (*Submission, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	return e.submissions[st.ID], nil
+}
+
+func (e *fakeExecutor) Execute(_ context.Context, st *ScheduledTransfer) (string, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.fired = append(e.fired, st.ID)
+	txID := fmt.Sprintf("tx%d", len(e.fired))
+	return txID, e.err
+}
+
+func newTestService(t *testing.T, now *time.Time) (*Service, *fakeExecutor) {
+	dataSource := fmt.Sprintf("file:%s?_pragma=busy_timeout(20000)", path.Join(t.TempDir(), "db.sqlite"))
+	sqlDB, err := common.NewSQLDBOpener("", "").OpenSQLDB(sql2.SQLite, dataSource, 10, false)
+	assert.NoError(t, err)
+	db, err := sqlite.NewSchedulerDB(sqlDB, common.NewDBOpts{DataSource: dataSource, CreateSchema: true})
+	assert.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, db.Close()) })
+
+	executor := &fakeExecutor{submissions: map[string]*Submission{}}
+	s := NewService(token.TMSID{Network: "n", Channel: "c", Namespace: "ns"}, db, executor, &Config{
+		MaxRetries:     2,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     time.Hour,
+	})
+	s.now = func() time.Time { return *now }
+	return s, executor
+}
+
+func TestNextOccurrence(t *testing.T) {
+	start := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+
+	monthly := &ScheduledTransfer{Start: start, Unit: Month, Every: 1}
+	assert.Equal(t, time.Date(2024, time.February, 15, 9, 0, 0, 0, time.UTC), nextOccurrence(monthly, start))
+	assert.Equal(t, time.Date(2024, time.July, 15, 9, 0, 0, 0, time.UTC), nextOccurrence(monthly, time.Date(2024, time.June, 20, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, time.January, 15, 9, 0, 0, 0, time.UTC), nextOccurrence(monthly, time.Date(2024, time.December, 15, 9, 0, 0, 0, time.UTC)))
+
+	quarterly := &ScheduledTransfer{Start: start, Unit: Month, Every: 3}
+	assert.Equal(t, time.Date(2024, time.October, 15, 9, 0, 0, 0, time.UTC), nextOccurrence(quarterly, time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC)))
+
+	daily := &ScheduledTransfer{Start: start, Unit: Day, Every: 2, End: start.AddDate(0, 0, 4)}
+	assert.Equal(t, start.AddDate(0, 0, 2), nextOccurrence(daily, start))
+	assert.Equal(t, start.AddDate(0, 0, 4), nextOccurrence(daily, start.AddDate(0, 0, 3)))
+	assert.True(t, nextOccurrence(daily, start.AddDate(0, 0, 4)).IsZero(), "no occurrence after the end")
+
+	assert.True(t, nextOccurrence(&ScheduledTransfer{Start: start, Unit: Once}, start).IsZero())
+}
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	s, _ := newTestService(t, &now)
+
+	_, err := s.Schedule(&Intent{TokenType: "USD", Amount: 10, Recipient: view.Identity("bob")})
+	assert.EqualError(t, err, "invalid intent: missing wallet")
+	_, err = s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Recipient: view.Identity("bob")})
+	assert.EqualError(t, err, "invalid intent: amount is zero")
+	_, err = s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10})
+	assert.EqualError(t, err, "invalid intent: missing recipient")
+	_, err = s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob"), Unit: "year"})
+	assert.EqualError(t, err, "invalid intent: unknown unit [year]")
+	_, err = s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob"), Unit: Once, Every: 2})
+	assert.EqualError(t, err, "invalid intent: a one-off transfer cannot have a period")
+
+	id, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob"), Unit: Month})
+	assert.NoError(t, err)
+	st, err := s.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, Active, st.Status)
+	assert.Equal(t, 1, st.Every)
+	assert.True(t, now.Equal(st.Start), "start defaults to now")
+	assert.True(t, now.Equal(st.NextRun))
+
+	_, err = s.Get("unknown")
+	assert.EqualError(t, err, "scheduled transfer [unknown] not found")
+}
+
+func TestTickRecurring(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	s, executor := newTestService(t, &now)
+
+	rent, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 1000, Recipient: view.Identity("landlord"), Unit: Month, End: now.AddDate(0, 2, 0)})
+	assert.NoError(t, err)
+	later, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 5, Recipient: view.Identity("bob"), Start: now.Add(time.Hour)})
+	assert.NoError(t, err)
+
+	// only rent is due
+	fired, err := s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	assert.Equal(t, []string{rent}, executor.fired)
+	st, err := s.Get(rent)
+	assert.NoError(t, err)
+	assert.Equal(t, Active, st.Status)
+	assert.True(t, now.AddDate(0, 1, 0).Equal(st.NextRun))
+	runs, err := s.Runs(rent)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.True(t, runs[0].Succeeded)
+	assert.Equal(t, "tx1", runs[0].TxID)
+
+	// nothing is due until next month, except the one-off transfer
+	fired, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, fired)
+	now = now.Add(2 * time.Hour)
+	fired, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	st, err = s.Get(later)
+	assert.NoError(t, err)
+	assert.Equal(t, Completed, st.Status)
+
+	// the last two occurrences of rent
+	now = time.Date(2024, time.February, 15, 9, 0, 0, 0, time.UTC)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	now = time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	st, err = s.Get(rent)
+	assert.NoError(t, err)
+	assert.Equal(t, Completed, st.Status)
+	runs, err = s.Runs(rent)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 3)
+	assert.Equal(t, []string{rent, later, rent, rent}, executor.fired)
+}
+
+func TestTickRetries(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	s, executor := newTestService(t, &now)
+	executor.err = errors.New("insufficient funds")
+
+	weekly, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob"), Unit: Week})
+	assert.NoError(t, err)
+	once, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob")})
+	assert.NoError(t, err)
+
+	// first attempt fails, retry after the initial backoff
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	st, err := s.Get(weekly)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, st.Attempts)
+	assert.True(t, now.Add(time.Minute).Equal(st.NextRun))
+	assert.True(t, now.Equal(st.Occurrence))
+
+	// second attempt fails, backoff doubles
+	now = now.Add(time.Minute)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	st, err = s.Get(weekly)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, st.Attempts)
+	assert.True(t, now.Add(2*time.Minute).Equal(st.NextRun))
+
+	// retries exhausted: the weekly transfer moves to the next occurrence, the one-off transfer fails
+	now = now.Add(2 * time.Minute)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	st, err = s.Get(weekly)
+	assert.NoError(t, err)
+	assert.Equal(t, Active, st.Status)
+	assert.Equal(t, 0, st.Attempts)
+	assert.True(t, st.Start.AddDate(0, 0, 7).Equal(st.NextRun))
+	st, err = s.Get(once)
+	assert.NoError(t, err)
+	assert.Equal(t, Failed, st.Status)
+	runs, err := s.Runs(once)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 3)
+	for i, run := range runs {
+		assert.Equal(t, i+1, run.Attempt)
+		assert.False(t, run.Succeeded)
+		assert.Equal(t, "insufficient funds", run.Message)
+	}
+}
+
+func TestTickFinalityUnknown(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	s, executor := newTestService(t, &now)
+	executor.err = errors.Wrapf(ErrFinalityUnknown, "transaction [tx1]: timeout")
+
+	id, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob"), Unit: Day})
+	assert.NoError(t, err)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	st, err := s.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, st.Attempts)
+	assert.True(t, now.Equal(st.Occurrence))
+
+	// the transaction is still pending, the retry does not fire a new one
+	executor.err = nil
+	executor.submissions[id] = &Submission{TxID: "tx1", Status: ttxdb.Pending}
+	now = now.Add(time.Minute)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{id}, executor.fired)
+	st, err = s.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, st.Attempts)
+	runs, err := s.Runs(id)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 2)
+	assert.Equal(t, "tx1", runs[1].TxID)
+	assert.Contains(t, runs[1].Message, "transaction [tx1] still pending")
+
+	// the transaction is confirmed, the occurrence is paid without firing again
+	executor.submissions[id] = &Submission{TxID: "tx1", Status: ttxdb.Confirmed}
+	now = now.Add(2 * time.Minute)
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{id}, executor.fired)
+	st, err = s.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, st.Attempts)
+	assert.True(t, st.Start.AddDate(0, 0, 1).Equal(st.Occurrence))
+	runs, err = s.Runs(id)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 3)
+	assert.True(t, runs[2].Succeeded)
+	assert.Equal(t, "tx1", runs[2].TxID)
+}
+
+func TestTickDeletedSubmission(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	s, executor := newTestService(t, &now)
+
+	id, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob")})
+	assert.NoError(t, err)
+	// a previous transaction for the occurrence has been deleted, a new one is fired
+	executor.submissions[id] = &Submission{TxID: "tx0", Status: ttxdb.Deleted}
+	_, err = s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{id}, executor.fired)
+	st, err := s.Get(id)
+	assert.NoError(t, err)
+	assert.Equal(t, Completed, st.Status)
+}
+
+func TestViewExecutorSubmission(t *testing.T) {
+	occurrence := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	db := &fakeTransactionDB{records: []*ttxdb.TransactionRecord{
+		{TxID: "tx1", Status: ttxdb.Deleted},
+		{TxID: "tx2", Status: ttxdb.Confirmed},
+		{TxID: "tx3", Status: ttxdb.Pending},
+	}}
+	e := &ViewExecutor{DB: db}
+	submission, err := e.Submission(context.Background(), &ScheduledTransfer{ID: "rent", Occurrence: occurrence})
+	assert.NoError(t, err)
+	assert.Equal(t, &Submission{TxID: "tx2", Status: ttxdb.Confirmed}, submission)
+	assert.Equal(t, map[string][]byte{
+		ScheduleIDMetadataKey:         []byte("rent"),
+		ScheduleOccurrenceMetadataKey: []byte("2024-01-15T09:00:00Z"),
+	}, db.params.ApplicationMetadata)
+
+	db.records = nil
+	submission, err = e.Submission(context.Background(), &ScheduledTransfer{ID: "rent", Occurrence: occurrence})
+	assert.NoError(t, err)
+	assert.Nil(t, submission)
+}
+
+type fakeTransactionDB struct {
+	records []*ttxdb.TransactionRecord
+	params  ttxdb.QueryTransactionsParams
+}
+
+func (f *fakeTransactionDB) Transactions(params ttxdb.QueryTransactionsParams) (driver.TransactionIterator, error) {
+	f.params = params
+	return collections.NewSliceIterator(f.records), nil
+}
+
+func TestCancelAndList(t *testing.T) {
+	now := time.Date(2024, time.January, 15, 9, 0, 0, 0, time.UTC)
+	s, executor := newTestService(t, &now)
+
+	rent, err := s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 1000, Recipient: view.Identity("landlord"), Unit: Month})
+	assert.NoError(t, err)
+	_, err = s.Schedule(&Intent{Wallet: "alice", TokenType: "USD", Amount: 10, Recipient: view.Identity("bob"), Unit: Day})
+	assert.NoError(t, err)
+	_, err = s.Schedule(&Intent{Wallet: "charlie", TokenType: "EUR", Amount: 10, Recipient: view.Identity("bob"), Unit: Day})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.Cancel(rent))
+	assert.EqualError(t, s.Cancel(rent), fmt.Sprintf("scheduled transfer [%s] is not active, status [Cancelled]", rent))
+	assert.EqualError(t, s.Cancel("unknown"), "scheduled transfer [unknown] not found")
+
+	all, err := s.List(ListParams{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 3)
+	alice, err := s.List(ListParams{Wallet: "alice", Statuses: []Status{Active}})
+	assert.NoError(t, err)
+	assert.Len(t, alice, 1)
+	assert.Equal(t, Day, alice[0].Unit)
+
+	// cancelled transfers do not fire
+	fired, err := s.Tick(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, fired)
+	assert.NotContains(t, executor.fired, rent)
+}
+
+func TestConfigDefaults(t *testing.T) {
+	s := NewService(token.TMSID{}, nil, nil, &Config{MaxRetries: -1})
+	assert.Equal(t, 0, s.config.MaxRetries, "negative retries disable the retries")
+	assert.Equal(t, defaultPollInterval, s.config.PollInterval)
+	s = NewService(token.TMSID{}, nil, nil, &Config{})
+	assert.Equal(t, defaultMaxRetries, s.config.MaxRetries)
+}
+
+func TestBackoff(t *testing.T) {
+	c := (&Config{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}).withDefaults()
+	assert.Equal(t, time.Second, c.backoff(1))
+	assert.Equal(t, 2*time.Second, c.backoff(2))
+	assert.Equal(t, 8*time.Second, c.backoff(4))
+	assert.Equal(t, 10*time.Second, c.backoff(5))
+	assert.Equal(t, 10*time.Second, c.backoff(100))
+}
@@ -0,0 +1,25 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package schedulerdb
+
+import (
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+)
+
+type (
+	Holder  = *db.DriverHolder[*DB, driver.SchedulerDB, driver.SchedulerDBDriver]
+	Manager = db.Manager[*DB, driver.SchedulerDB, driver.SchedulerDBDriver]
+)
+
+func NewHolder(drivers []db.NamedDriver[driver.SchedulerDBDriver]) Holder {
+	return db.NewDriverHolder[*DB, driver.SchedulerDB, driver.SchedulerDBDriver](newDB, drivers...)
+}
+
+type DB struct{ driver.SchedulerDB }
+
+func newDB(p driver.SchedulerDB) *DB { return &DB{SchedulerDB: p} }
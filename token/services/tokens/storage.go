@@ -60,6 +60,10 @@ func (d *DBStorage) StorePublicParams(raw []byte) error {
 	return d.tokenDB.StorePublicParams(raw)
 }
 
+func (d *DBStorage) PublicParamsByHash(hash token.PPHash) ([]byte, error) {
+	return d.tokenDB.PublicParamsByHash(hash)
+}
+
 type TokenToAppend struct {
 	txID                  string
 	index                 uint64
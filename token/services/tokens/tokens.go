@@ -124,6 +124,73 @@ func (t *Tokens) Append(ctx context.Context, tmsID token.TMSID, txID string, req
 	return nil
 }
 
+// AppendLedgerOutputs appends to the token db the outputs of the passed transaction as found on the ledger,
+// and deletes the tokens the transaction spent.
+// It is meant for the transactions whose token request is not available locally.
+// It returns false, and does nothing, if the driver hides the token data or the transaction is already in the token db.
+// The issuer of the recovered tokens is unknown, because the token metadata is not on the ledger.
+func (t *Tokens) AppendLedgerOutputs(ctx context.Context, tmsID token.TMSID, txID string, outputs map[uint64][]byte, spent []*token2.ID) (_ bool, err error) {
+	tms, err := t.TMSProvider.GetManagementService(token.WithTMSID(tmsID))
+	if err != nil {
+		return false, errors.WithMessagef(err, "failed getting token management service [%s]", tmsID)
+	}
+	pp := tms.PublicParametersManager().PublicParameters()
+	if pp.TokenDataHiding() {
+		logger.Debugf("transaction [%s], token data hidden, cannot recover the outputs from the ledger", txID)
+		return false, nil
+	}
+	exists, err := t.Storage.TransactionExists(ctx, txID)
+	if err != nil {
+		return false, errors.WithMessagef(err, "transaction [%s], failed to check existence in db", txID)
+	}
+	if exists {
+		logger.Debugf("transaction [%s], exists in db, skipping", txID)
+		return false, nil
+	}
+
+	auth := tms.Authorization()
+	auditorFlag := auth.AmIAnAuditor()
+	var toAppend []TokenToAppend
+	for index, raw := range outputs {
+		tok, issuer, err := tms.DeserializeToken(raw, nil)
+		if err != nil {
+			logger.Errorf("transaction [%s], failed deserializing output [%d], skipping it [%s]", txID, index, err)
+			continue
+		}
+		tta, ok := toAppendToken(auth, txID, index, tok, issuer, raw, []byte{}, auditorFlag, pp.Precision())
+		if !ok {
+			continue
+		}
+		toAppend = append(toAppend, tta)
+	}
+
+	ts, err := t.Storage.NewTransaction(ctx)
+	if err != nil {
+		return false, errors.WithMessagef(err, "transaction [%s], failed to start db transaction", txID)
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		if err1 := ts.Rollback(); err1 != nil {
+			logger.Errorf("error rolling back [%s][%s]", err1, debug.Stack())
+		}
+	}()
+	for _, tta := range toAppend {
+		if err = ts.AppendToken(ctx, tta); err != nil {
+			return false, errors.WithMessagef(err, "transaction [%s], failed to append token", txID)
+		}
+	}
+	if err = ts.DeleteTokens(ctx, txID, spent); err != nil {
+		return false, errors.WithMessagef(err, "transaction [%s], failed to delete tokens", txID)
+	}
+	if err = ts.Commit(); err != nil {
+		return false, errors.WithMessagef(err, "transaction [%s], failed to commit tokens to database", txID)
+	}
+	logger.Debugf("transaction [%s], recovered tokens [%d:%d] from the ledger", txID, len(toAppend), len(spent))
+	return true, nil
+}
+
 func (t *Tokens) AppendRaw(ctx context.Context, tmsID token.TMSID, txID string, requestRaw []byte) (err error) {
 	logger.Debugf("get tms for [%s]", txID)
 	tms, err := t.TMSProvider.GetManagementService(token.WithTMSID(tmsID))
@@ -178,6 +245,11 @@ func (t *Tokens) StorePublicParams(raw []byte) error {
 	return t.Storage.StorePublicParams(raw)
 }
 
+// PublicParamsByHash returns the public parameters, stored in the token db, whose hash matches the passed one
+func (t *Tokens) PublicParamsByHash(hash driver.PPHash) ([]byte, error) {
+	return t.Storage.PublicParamsByHash(hash)
+}
+
 // DeleteToken marks the entries corresponding to the passed token ids as deleted.
 // The deletion is attributed to the passed deletedBy argument.
 func (t *Tokens) DeleteToken(deletedBy string, ids ...*token2.ID) (err error) {
@@ -261,48 +333,10 @@ func (t *Tokens) parse(auth driver.Authorization, txID string, md MetaData, is *
 			continue
 		}
 
-		issuerFlag := !issuer.IsNone() && auth.Issued(issuer, tok)
-		ownerWalletID, ids, mine := auth.IsMine(tok)
-		if logger.IsEnabledFor(zapcore.DebugLevel) {
-			if mine {
-				logger.Debugf("transaction [%s], found a token and it is mine", txID)
-			} else {
-				logger.Debugf("transaction [%s], found a token and it is NOT mine", txID)
-			}
-			if issuerFlag {
-				logger.Debugf("transaction [%s], found a token and I have issued it", txID)
-			}
-			logger.Debugf("store token [%s:%d][%s]", txID, output.Index, hash.Hashable(output.LedgerOutput))
-		}
-		if !mine && !auditorFlag && !issuerFlag {
-			logger.Debugf("transaction [%s], discarding token, not mine, not an auditor, not an issuer", txID)
+		tta, ok := toAppendToken(auth, txID, output.Index, tok, issuer, output.LedgerOutput, tokenOnLedgerMetadata, auditorFlag, precision)
+		if !ok {
 			continue
 		}
-
-		ownerType, ownerIdentity, err := auth.OwnerType(tok.Owner)
-		if err != nil {
-			logger.Errorf("could not unmarshal identity when storing token: %s", err.Error())
-			continue
-		}
-
-		tta := TokenToAppend{
-			txID:                  txID,
-			index:                 output.Index,
-			tok:                   tok,
-			tokenOnLedger:         output.LedgerOutput,
-			tokenOnLedgerMetadata: tokenOnLedgerMetadata,
-			ownerType:             ownerType,
-			ownerIdentity:         ownerIdentity,
-			ownerWalletID:         ownerWalletID,
-			owners:                ids,
-			issuer:                issuer,
-			precision:             precision,
-			flags: Flags{
-				Mine:    mine,
-				Auditor: auditorFlag,
-				Issuer:  issuerFlag,
-			},
-		}
 		toAppend = append(toAppend, tta)
 
 		if logger.IsEnabledFor(zapcore.DebugLevel) {
@@ -311,3 +345,51 @@ func (t *Tokens) parse(auth driver.Authorization, txID string, md MetaData, is *
 	}
 	return
 }
+
+// toAppendToken returns the token to store for the passed output, false if this node is neither the owner,
+// nor the issuer, nor the auditor of the token
+func toAppendToken(auth driver.Authorization, txID string, index uint64, tok *token2.Token, issuer token.Identity, ledgerOutput []byte, ledgerOutputMetadata []byte, auditorFlag bool, precision uint64) (TokenToAppend, bool) {
+	issuerFlag := !issuer.IsNone() && auth.Issued(issuer, tok)
+	ownerWalletID, ids, mine := auth.IsMine(tok)
+	if logger.IsEnabledFor(zapcore.DebugLevel) {
+		if mine {
+			logger.Debugf("transaction [%s], found a token and it is mine", txID)
+		} else {
+			logger.Debugf("transaction [%s], found a token and it is NOT mine", txID)
+		}
+		if issuerFlag {
+			logger.Debugf("transaction [%s], found a token and I have issued it", txID)
+		}
+		logger.Debugf("store token [%s:%d][%s]", txID, index, hash.Hashable(ledgerOutput))
+	}
+	if !mine && !auditorFlag && !issuerFlag {
+		logger.Debugf("transaction [%s], discarding token, not mine, not an auditor, not an issuer", txID)
+		return TokenToAppend{}, false
+	}
+
+	ownerType, ownerIdentity, err := auth.OwnerType(tok.Owner)
+	if err != nil {
+		logger.Errorf("could not unmarshal identity when storing token: %s", err.Error())
+		return TokenToAppend{}, false
+	}
+
+	tta := TokenToAppend{
+		txID:                  txID,
+		index:                 index,
+		tok:                   tok,
+		tokenOnLedger:         ledgerOutput,
+		tokenOnLedgerMetadata: ledgerOutputMetadata,
+		ownerType:             ownerType,
+		ownerIdentity:         ownerIdentity,
+		ownerWalletID:         ownerWalletID,
+		owners:                ids,
+		issuer:                issuer,
+		precision:             precision,
+		flags: Flags{
+			Mine:    mine,
+			Auditor: auditorFlag,
+			Issuer:  issuerFlag,
+		},
+	}
+	return tta, true
+}
@@ -37,6 +37,10 @@ func (s *AcceptView) Call(context view.Context) (interface{}, error) {
 		return nil, err
 	}
 
+	if err := checkReceivePolicy(context, s.tx, s.options.ReceivePolicy); err != nil {
+		return nil, err
+	}
+
 	rawRequest, err := s.tx.Bytes()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to marshal token request")
@@ -129,6 +133,9 @@ func (s *AcceptView) respondToSignatureRequests(context view.Context) error {
 				return errors.Wrap(err, "failed unmarshalling signature request")
 			}
 		}
+		if err := signatureRequest.VerifySummary(context); err != nil {
+			return errors.WithMessagef(err, "refusing to sign")
+		}
 		tms := token.GetManagementService(context, token.WithTMS(s.tx.Network(), s.tx.Channel(), s.tx.Namespace()))
 		if tms == nil {
 			return errors.Errorf("failed getting TMS for [%s:%s:%s]", s.tx.Network(), s.tx.Channel(), s.tx.Namespace())
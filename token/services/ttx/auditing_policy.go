@@ -0,0 +1,122 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+)
+
+const (
+	// AuditingPolicyKey is the configuration key, relative to the TMS, of the auditing policy
+	AuditingPolicyKey = "services.auditor.policy"
+	// AsyncAuditingGracePeriodKey is the configuration key, relative to the TMS, of the time the auditor waits
+	// for a committed token request to be submitted before recording a discrepancy
+	AsyncAuditingGracePeriodKey = "services.auditor.async.gracePeriod"
+)
+
+// AuditingMode tells when a token request is audited
+type AuditingMode string
+
+const (
+	// SyncAuditing requires the auditor's signature before the transaction is submitted for ordering
+	SyncAuditing AuditingMode = "sync"
+	// AsyncAuditing lets the transaction commit without the auditor's signature.
+	// The token request is submitted to the auditor in the background and checked once committed.
+	AsyncAuditing AuditingMode = "async"
+)
+
+// AuditingPolicy chooses the auditing mode per token type
+type AuditingPolicy struct {
+	// Default is the auditing mode of the token types not listed in Types. If empty, SyncAuditing is used.
+	Default AuditingMode `yaml:"default,omitempty"`
+	// Types maps token types to auditing modes
+	Types map[string]AuditingMode `yaml:"types,omitempty"`
+}
+
+// Mode returns the auditing mode for the passed token type
+func (p *AuditingPolicy) Mode(tokenType string) AuditingMode {
+	if p == nil {
+		return SyncAuditing
+	}
+	if mode, ok := p.Types[tokenType]; ok && len(mode) != 0 {
+		return mode
+	}
+	if len(p.Default) == 0 {
+		return SyncAuditing
+	}
+	return p.Default
+}
+
+// IsAsync returns true if all the passed token types can be audited asynchronously.
+// It returns false if no token type is passed.
+func (p *AuditingPolicy) IsAsync(tokenTypes ...string) bool {
+	if len(tokenTypes) == 0 {
+		return false
+	}
+	for _, tokenType := range tokenTypes {
+		if p.Mode(tokenType) != AsyncAuditing {
+			return false
+		}
+	}
+	return true
+}
+
+// Validate returns an error if the policy contains unknown auditing modes
+func (p *AuditingPolicy) Validate() error {
+	check := func(mode AuditingMode) error {
+		switch mode {
+		case "", SyncAuditing, AsyncAuditing:
+			return nil
+		default:
+			return errors.Errorf("invalid auditing mode [%s]", mode)
+		}
+	}
+	if err := check(p.Default); err != nil {
+		return err
+	}
+	for tokenType, mode := range p.Types {
+		if err := check(mode); err != nil {
+			return errors.WithMessagef(err, "invalid policy for token type [%s]", tokenType)
+		}
+	}
+	return nil
+}
+
+// GetAuditingPolicy loads the auditing policy from the configuration of the passed TMS.
+// If no policy is configured, every token type is audited synchronously.
+func GetAuditingPolicy(tms *token.ManagementService) (*AuditingPolicy, error) {
+	policy := &AuditingPolicy{}
+	if !tms.Configuration().IsSet(AuditingPolicyKey) {
+		return policy, nil
+	}
+	if err := tms.Configuration().UnmarshalKey(AuditingPolicyKey, policy); err != nil {
+		return nil, errors.WithMessagef(err, "failed loading auditing policy for [%s]", tms.ID())
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, errors.WithMessagef(err, "invalid auditing policy for [%s]", tms.ID())
+	}
+	return policy, nil
+}
+
+// GetAsyncAuditingGracePeriod returns the grace period configured for the passed TMS, zero if not set
+func GetAsyncAuditingGracePeriod(tms *token.ManagementService) (time.Duration, error) {
+	if !tms.Configuration().IsSet(AsyncAuditingGracePeriodKey) {
+		return 0, nil
+	}
+	var v string
+	if err := tms.Configuration().UnmarshalKey(AsyncAuditingGracePeriodKey, &v); err != nil {
+		return 0, errors.WithMessagef(err, "failed loading grace period for [%s]", tms.ID())
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid grace period [%s] for [%s]", v, tms.ID())
+	}
+	return d, nil
+}
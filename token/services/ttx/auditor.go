@@ -9,6 +9,7 @@ package ttx
 import (
 	"context"
 	"encoding/base64"
+	"slices"
 	"time"
 
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
@@ -27,6 +28,7 @@ import (
 )
 
 type TxAuditor struct {
+	sp                      token.ServiceProvider
 	w                       *token.AuditorWallet
 	auditor                 *auditor.Auditor
 	auditDB                 *auditdb.DB
@@ -44,6 +46,7 @@ func NewAuditor(sp token.ServiceProvider, w *token.AuditorWallet) (*TxAuditor, e
 		return nil, err
 	}
 	return &TxAuditor{
+		sp:                      sp,
 		w:                       w,
 		auditor:                 backend,
 		auditDB:                 auditDB,
@@ -92,6 +95,18 @@ func (a *TxAuditor) Check(context context.Context) ([]string, error) {
 	return a.auditor.Check(context)
 }
 
+// ComplianceDecisions returns the compliance decisions recorded for the passed transaction ids, all if none is passed
+func (a *TxAuditor) ComplianceDecisions(txIDs ...string) ([]*auditor.ComplianceDecisionRecord, error) {
+	return a.auditor.ComplianceDecisions(txIDs...)
+}
+
+// Reverify verifies again the auditor signatures of the stored token requests whose transaction has one of the passed statuses, all if none.
+// The auditor keys are selected using the hash of the public parameters recorded with each token request,
+// therefore the token requests signed before an auditor rotation can still be verified.
+func (a *TxAuditor) Reverify(ctx context.Context, statuses ...driver.TxStatus) ([]*auditor.VerificationResult, error) {
+	return a.auditor.Reverify(ctx, statuses...)
+}
+
 type RegisterAuditorView struct {
 	TMSID     token.TMSID
 	AuditView view.View
@@ -125,6 +140,23 @@ func (r *RegisterAuditorView) Call(context view.Context) (interface{}, error) {
 	if err := net.ProcessNamespace(tms.Namespace()); err != nil {
 		return nil, errors.WithMessagef(err, "failed to register namespace for processing [%s]", tms.Network())
 	}
+	// enable asynchronous auditing, if allowed by the public parameters
+	if pp := tms.PublicParametersManager().PublicParameters(); pp != nil && pp.AsyncAuditing() {
+		if err := view2.GetRegistry(context).RegisterResponder(&AsyncAuditView{}, &AsyncAuditingViewInitiator{}); err != nil {
+			return nil, errors.Wrapf(err, "failed to register async auditor view")
+		}
+		gracePeriod, err := GetAsyncAuditingGracePeriod(tms)
+		if err != nil {
+			return nil, err
+		}
+		aud := auditor.GetByTMSID(context, tms.ID())
+		if aud == nil {
+			return nil, errors.Errorf("cannot find auditor for [%s]", tms.ID())
+		}
+		if err := aud.ListenToCommits(gracePeriod); err != nil {
+			return nil, errors.WithMessagef(err, "failed to listen to commits for [%s]", tms.ID())
+		}
+	}
 	return nil, nil
 }
 
@@ -278,10 +310,30 @@ func NewAuditApproveView(w *token.AuditorWallet, tx *Transaction) *AuditApproveV
 func (a *AuditApproveView) Call(context view.Context) (interface{}, error) {
 	span := context.StartSpan("audit_approve_view")
 	defer span.End()
+	aud := auditor.New(context, a.w)
+	// Evaluate the compliance rules, a denied transaction is not signed
+	if err := auditTravelRule(context, a.tx); err != nil {
+		aud.Release(a.tx)
+		return nil, errors.WithMessage(err, "invalid travel-rule information")
+	}
+	if err := a.checkCompliance(context, aud); err != nil {
+		aud.Release(a.tx)
+		if err2 := context.Session().SendError([]byte(err.Error())); err2 != nil {
+			logger.Warnf("failed notifying the denial of [%s]: [%s]", a.tx.ID(), err2)
+		}
+		return nil, err
+	}
 	// Append audit records
-	if err := auditor.New(context, a.w).Append(a.tx); err != nil {
+	if err := aud.Append(a.tx); err != nil {
 		return nil, errors.Wrapf(err, "failed appending audit records for transaction %s", a.tx.ID())
 	}
+	if err := appendTravelRule(aud, a.tx); err != nil {
+		return nil, err
+	}
+	// Record the sender of the transaction, it is the only node allowed to cancel it
+	if err := recordReceived(context, a.tx, context.Session().Info().Caller); err != nil {
+		return nil, errors.WithMessagef(err, "failed recording sender of %s", a.tx.ID())
+	}
 
 	if err := a.signAndSendBack(context); err != nil {
 		return nil, err
@@ -305,17 +357,27 @@ func (a *AuditApproveView) Call(context view.Context) (interface{}, error) {
 	return nil, nil
 }
 
+func (a *AuditApproveView) checkCompliance(context view.Context, aud *auditor.Auditor) error {
+	result, err := aud.CheckCompliance(context.Context(), a.tx)
+	if err != nil {
+		return errors.WithMessagef(err, "failed checking compliance of transaction %s", a.tx.ID())
+	}
+	switch result.Decision {
+	case auditor.Denied:
+		return errors.Errorf("transaction %s denied by the compliance rules: %s", a.tx.ID(), result.Reason())
+	case auditor.Flagged:
+		logger.Warnf("transaction [%s] flagged by the compliance rules: %s", a.tx.ID(), result.Reason())
+	}
+	return nil
+}
+
 func (a *AuditApproveView) signAndSendBack(context view.Context) error {
 	span := trace.SpanFromContext(context.Context())
 	logger.Debugf("Signing and sending back transaction... [%s]", a.tx.ID())
-	// Sign
-	aid, err := a.w.GetAuditorIdentity()
-	if err != nil {
-		return errors.WithMessagef(err, "failed getting auditor identity for node [%s]", context.Me())
-	}
-	signer, err := a.w.GetSigner(aid)
+	// Sign with the current auditor key
+	aid, signer, err := a.currentAuditorSigner()
 	if err != nil {
-		return errors.WithMessagef(err, "failed getting signing identity for auditor identity [%s]", aid)
+		return errors.WithMessagef(err, "failed getting current auditor signer for node [%s]", context.Me())
 	}
 
 	logger.Debug("signer at auditor", signer, aid)
@@ -332,6 +394,9 @@ func (a *AuditApproveView) signAndSendBack(context view.Context) error {
 	if err != nil {
 		return errors.Wrapf(err, "failed sign audit message for tx [%s]", a.tx.ID())
 	}
+	if err := auditor.New(context, a.w).AddSignature(a.tx.ID(), aid, sigma); err != nil {
+		return errors.WithMessagef(err, "failed storing auditor signature for tx [%s]", a.tx.ID())
+	}
 	logger.Debug("auditor sending sigma back", hash.Hashable(sigma))
 	session := context.Session()
 	span.AddEvent("send_back_tx")
@@ -348,6 +413,35 @@ func (a *AuditApproveView) signAndSendBack(context view.Context) error {
 	return nil
 }
 
+// currentAuditorSigner returns the signer of the auditor key that is current according to the public parameters.
+// If the auditor wallet of this view does not hold the current key, the other auditor wallets are searched.
+func (a *AuditApproveView) currentAuditorSigner() (token.Identity, token.Signer, error) {
+	aid, err := a.w.GetAuditorIdentity()
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed getting auditor identity")
+	}
+	current := a.tx.TokenService().PublicParametersManager().PublicParameters().Auditors()
+	if len(current) == 0 || slices.ContainsFunc(current, aid.Equal) {
+		signer, err := a.w.GetSigner(aid)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed getting signing identity for auditor identity [%s]", aid)
+		}
+		return aid, signer, nil
+	}
+	for _, id := range current {
+		w := a.tx.TokenService().WalletManager().AuditorWallet(id)
+		if w == nil {
+			continue
+		}
+		signer, err := w.GetSigner(id)
+		if err != nil {
+			return nil, nil, errors.WithMessagef(err, "failed getting signing identity for auditor identity [%s]", id)
+		}
+		return id, signer, nil
+	}
+	return nil, nil, errors.Errorf("auditor identity [%s] is not current and no wallet holds a current auditor key", aid)
+}
+
 func (a *AuditApproveView) waitEnvelope(context view.Context) error {
 	span := trace.SpanFromContext(context.Context())
 	logger.Debugf("Waiting for envelope... [%s]", a.tx.ID())
@@ -0,0 +1,160 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/pkg/utils"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/pkg/errors"
+)
+
+// AsyncAuditingViewInitiator submits a transaction to the auditor without waiting for the auditor's signature.
+// The auditor acknowledges the reception of the transaction, and the acknowledgement is stored
+// in the transaction endorsement ack database.
+type AsyncAuditingViewInitiator struct {
+	tx *Transaction
+}
+
+// NewAsyncAuditingViewInitiator returns a new AsyncAuditingViewInitiator for the passed transaction
+func NewAsyncAuditingViewInitiator(tx *Transaction) *AsyncAuditingViewInitiator {
+	return &AsyncAuditingViewInitiator{tx: tx}
+}
+
+func (a *AsyncAuditingViewInitiator) Call(context view.Context) (interface{}, error) {
+	if view2.GetSigService(context).IsMe(a.tx.Opts.Auditor) {
+		logger.Debugf("ingest locally transaction [%s]", a.tx.ID())
+		aud := auditor.GetByTMSID(context, a.tx.TMSID())
+		if aud == nil {
+			return nil, errors.Errorf("failed getting auditor for [%s]", a.tx.TMSID())
+		}
+		if err := aud.Ingest(context.Context(), a.tx); err != nil {
+			return nil, errors.WithMessagef(err, "failed ingesting transaction [%s]", a.tx.ID())
+		}
+		return nil, nil
+	}
+
+	txRaw, err := a.tx.Bytes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling transaction content")
+	}
+	retryRunner := utils.NewRetryRunner(3, 100*time.Millisecond, true)
+	if err := retryRunner.RunWithErrors(func() (bool, error) {
+		err := a.submit(context, txRaw)
+		return err == nil, err
+	}); err != nil {
+		return nil, errors.Wrapf(err, "failed submitting transaction [%s] to auditor [%s]", a.tx.ID(), a.tx.Opts.Auditor)
+	}
+	return nil, nil
+}
+
+func (a *AsyncAuditingViewInitiator) submit(context view.Context, txRaw []byte) error {
+	session, err := context.GetSession(a, a.tx.Opts.Auditor)
+	if err != nil {
+		return errors.Wrap(err, "failed getting session")
+	}
+	defer session.Close()
+	if err := session.SendWithContext(context.Context(), txRaw); err != nil {
+		return errors.Wrap(err, "failed sending transaction")
+	}
+	sigma, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed reading auditor's ack")
+	}
+
+	longTermIdentity, _, _, err := view2.GetEndpointService(context).Resolve(a.tx.Opts.Auditor)
+	if err != nil {
+		return errors.Wrapf(err, "cannot resolve long term auditor identity for [%s]", a.tx.Opts.Auditor)
+	}
+	verifier, err := view2.GetSigService(context).GetVerifier(longTermIdentity)
+	if err != nil {
+		return errors.Wrapf(err, "failed getting verifier for [%s]", a.tx.Opts.Auditor)
+	}
+	if err := verifier.Verify(txRaw, sigma); err != nil {
+		return errors.Wrapf(err, "failed verifying ack signature from [%s]", a.tx.Opts.Auditor)
+	}
+	logger.Debugf("auditor [%s] acknowledged transaction [%s]", a.tx.Opts.Auditor, a.tx.ID())
+	return NewOwner(context, a.tx.TokenService()).appendTransactionEndorseAck(a.tx, longTermIdentity, sigma)
+}
+
+// asyncAuditingListener submits a transaction to the auditor once the transaction is committed.
+// The submission runs on its own view context, independent of the view that assembled the transaction.
+// If the submission fails, the auditor records the committed transaction as a discrepancy.
+type asyncAuditingListener struct {
+	viewManager ViewManager
+	tx          *Transaction
+}
+
+func newAsyncAuditingListener(sp view2.ServiceProvider, tx *Transaction) *asyncAuditingListener {
+	return &asyncAuditingListener{viewManager: view2.GetManager(sp), tx: tx}
+}
+
+func (l *asyncAuditingListener) OnStatus(ctx context.Context, txID string, status int, message string, _ []byte) {
+	if status != network.Valid {
+		logger.Debugf("transaction [%s] not committed [%d][%s], nothing to submit to the auditor", txID, status, message)
+		return
+	}
+	if _, err := l.viewManager.InitiateView(NewAsyncAuditingViewInitiator(l.tx), ctx); err != nil {
+		logger.Errorf("failed submitting committed transaction [%s] to auditor [%s], the auditor will record it as a discrepancy: [%s]", txID, l.tx.Opts.Auditor, err)
+	}
+}
+
+// AsyncAuditView is the auditor's responder of AsyncAuditingViewInitiator.
+// It ingests the received transaction and sends back an acknowledgement.
+type AsyncAuditView struct{}
+
+func (a *AsyncAuditView) Call(context view.Context) (interface{}, error) {
+	tx, err := ReceiveTransaction(context, WithNoTransactionVerification())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed receiving transaction")
+	}
+	aud := auditor.GetByTMSID(context, tx.TMSID())
+	if aud == nil {
+		return nil, errors.Errorf("failed getting auditor for [%s]", tx.TMSID())
+	}
+	if err := aud.Ingest(context.Context(), tx); err != nil {
+		return nil, errors.WithMessagef(err, "failed ingesting transaction [%s]", tx.ID())
+	}
+	// the transaction has already been submitted, invalid travel-rule information is recorded as a discrepancy
+	// and the transaction is not acknowledged
+	if err := auditTravelRule(context, tx); err != nil {
+		if err2 := aud.AddDiscrepancy(tx.ID(), fmt.Sprintf("invalid travel-rule information: %s", err)); err2 != nil {
+			return nil, err2
+		}
+		return nil, errors.WithMessagef(err, "invalid travel-rule information in [%s]", tx.ID())
+	}
+	if err := appendTravelRule(aud, tx); err != nil {
+		return nil, err
+	}
+
+	// acknowledge
+	raw, err := tx.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling tx [%s]", tx.ID())
+	}
+	me := view2.GetIdentityProvider(context).DefaultIdentity()
+	signer, err := view2.GetSigService(context).GetSigner(me)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting signing identity for [%s]", me)
+	}
+	sigma, err := signer.Sign(raw)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to sign ack response")
+	}
+	logger.Debugf("ack ingestion of [%s]: [%s]", tx.ID(), hash.Hashable(sigma))
+	if err := context.Session().Send(sigma); err != nil {
+		return nil, errors.WithMessage(err, "failed sending ack")
+	}
+	return nil, nil
+}
@@ -0,0 +1,327 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/pkg/errors"
+)
+
+// Bundle is a set of token transactions, one per TMS, on the same network and channel.
+// The transactions share the same network transaction id, their token requests are approved together
+// and end up in a single network transaction, therefore they are committed atomically:
+// either all of them are valid, or none is.
+type Bundle struct {
+	Transactions []*Transaction
+}
+
+// NewAnonymousBundle returns a new bundle, for the passed TMSs, whose network transaction will be signed by an anonymous identity
+func NewAnonymousBundle(context view.Context, tmsIDs []token.TMSID, opts ...TxOption) (*Bundle, error) {
+	if len(tmsIDs) == 0 {
+		return nil, errors.New("no tms passed")
+	}
+	net := network.GetInstance(context, tmsIDs[0].Network, tmsIDs[0].Channel)
+	if net == nil {
+		return nil, errors.New("failed to get network")
+	}
+	id, err := net.AnonymousIdentity()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting anonymous identity for transaction")
+	}
+	return NewBundle(context, id, tmsIDs, opts...)
+}
+
+// NewBundle returns a new bundle containing a transaction for each of the passed TMSs, customized with the passed opts.
+// The TMSs must be on the same network and channel, and in different namespaces.
+// The network transaction will be signed by the passed signer, the default identity if nil.
+func NewBundle(context view.Context, signer view.Identity, tmsIDs []token.TMSID, opts ...TxOption) (*Bundle, error) {
+	if len(tmsIDs) == 0 {
+		return nil, errors.New("no tms passed")
+	}
+	if err := checkBundleTMSIDs(tmsIDs); err != nil {
+		return nil, err
+	}
+
+	first, err := NewTransaction(context, signer, append(opts, WithTMSID(tmsIDs[0]))...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed creating transaction for [%s]", tmsIDs[0])
+	}
+	b := &Bundle{Transactions: []*Transaction{first}}
+	for _, tmsID := range tmsIDs[1:] {
+		tx, err := NewTransaction(context, nil, append(opts, WithTMSID(tmsID), WithNetworkTxID(first.Payload.TxID))...)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed creating transaction for [%s]", tmsID)
+		}
+		if tx.ID() != first.ID() {
+			return nil, errors.Errorf("transaction ids do not match [%s][%s]", tx.ID(), first.ID())
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+	return b, nil
+}
+
+// checkBundleTMSIDs checks that the passed TMSs are on the same network and channel, and in different namespaces
+func checkBundleTMSIDs(tmsIDs []token.TMSID) error {
+	namespaces := map[string]bool{}
+	for _, tmsID := range tmsIDs {
+		if tmsID.Network != tmsIDs[0].Network || tmsID.Channel != tmsIDs[0].Channel {
+			return errors.Errorf("tms [%s] and [%s] are not on the same network and channel", tmsID, tmsIDs[0])
+		}
+		if namespaces[tmsID.Namespace] {
+			return errors.Errorf("namespace [%s] passed more than once", tmsID.Namespace)
+		}
+		namespaces[tmsID.Namespace] = true
+	}
+	return nil
+}
+
+// NewBundleFromBytes returns the bundle marshalled by Bundle#Bytes
+func NewBundleFromBytes(context view.Context, raw []byte) (*Bundle, error) {
+	var txs [][]byte
+	if err := Unmarshal(raw, &txs); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling bundle")
+	}
+	if len(txs) == 0 {
+		return nil, errors.New("empty bundle")
+	}
+	b := &Bundle{}
+	for _, txRaw := range txs {
+		tx, err := NewTransactionFromBytes(context, txRaw)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed unmarshalling transaction")
+		}
+		if len(b.Transactions) != 0 && tx.ID() != b.ID() {
+			return nil, errors.Errorf("transaction ids do not match [%s][%s]", tx.ID(), b.ID())
+		}
+		b.Transactions = append(b.Transactions, tx)
+	}
+	return b, nil
+}
+
+// ID returns the network transaction id shared by the transactions of the bundle
+func (b *Bundle) ID() string {
+	return b.Transactions[0].ID()
+}
+
+// Transaction returns the transaction of the bundle for the passed TMS, nil if not found
+func (b *Bundle) Transaction(tmsID token.TMSID) *Transaction {
+	for _, tx := range b.Transactions {
+		if tx.TMSID().Equal(tmsID) {
+			return tx
+		}
+	}
+	return nil
+}
+
+// Bytes marshals the bundle
+func (b *Bundle) Bytes() ([]byte, error) {
+	txs := make([][]byte, len(b.Transactions))
+	for i, tx := range b.Transactions {
+		raw, err := tx.Bytes()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed marshalling transaction for [%s]", tx.TMSID())
+		}
+		txs[i] = raw
+	}
+	return Marshal(txs)
+}
+
+// IsValid checks that all the transactions of the bundle are valid
+func (b *Bundle) IsValid() error {
+	for _, tx := range b.Transactions {
+		if err := tx.IsValid(); err != nil {
+			return errors.WithMessagef(err, "invalid transaction for [%s]", tx.TMSID())
+		}
+	}
+	return nil
+}
+
+// Release releases the tokens locked by the transactions of the bundle
+func (b *Bundle) Release() {
+	for _, tx := range b.Transactions {
+		tx.Release()
+	}
+}
+
+type CollectBundleEndorsementsView struct {
+	bundle *Bundle
+	opts   []EndorsementsOpt
+}
+
+// NewCollectBundleEndorsementsView returns a new instance of CollectBundleEndorsementsView.
+// The view does the following:
+// 1. For each transaction of the bundle, in order, it collects the signatures on the token request and the auditor's one.
+// 2. It asks the network to approve all the token requests in a single network transaction.
+// 3. For each transaction of the bundle, in order, it distributes the approved transaction to its parties.
+// The parties must respond with EndorseBundleView.
+// The network must support the approval of token requests of several TMSs together.
+func NewCollectBundleEndorsementsView(bundle *Bundle, opts ...EndorsementsOpt) *CollectBundleEndorsementsView {
+	return &CollectBundleEndorsementsView{bundle: bundle, opts: opts}
+}
+
+func (c *CollectBundleEndorsementsView) Call(context view.Context) (interface{}, error) {
+	res, err := c.call(context)
+	if err != nil {
+		for _, tx := range c.bundle.Transactions {
+			if err2 := recordStep(context, tx, Aborted, false, err.Error()); err2 != nil {
+				logger.Warnf("failed recording abort of [%s] on [%s]: [%s]", tx.ID(), tx.TMSID(), err2)
+			}
+		}
+	}
+	return res, err
+}
+
+// call collects the endorsements of the transactions of the bundle.
+// The token requests are approved together, once all the transactions have been signed and audited.
+func (c *CollectBundleEndorsementsView) call(context view.Context) (interface{}, error) {
+	views := make([]*CollectEndorsementsView, len(c.bundle.Transactions))
+	states := make([]*endorsementState, len(c.bundle.Transactions))
+	requests := make([]*network.ApprovalRequest, len(c.bundle.Transactions))
+	for i, tx := range c.bundle.Transactions {
+		views[i] = NewCollectEndorsementsView(tx, c.opts...)
+		state, err := views[i].collectSignaturesAndAudit(context)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed collecting endorsements for [%s]", tx.TMSID())
+		}
+		states[i] = state
+		requestRaw, err := tx.TokenRequest.RequestToBytes()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed marshalling request for [%s]", tx.TMSID())
+		}
+		requests[i] = &network.ApprovalRequest{TMS: tx.TokenService(), RequestRaw: requestRaw}
+	}
+	var env *network.Envelope
+	if !views[0].Opts.SkipApproval {
+		var err error
+		env, err = c.approve(context, requests)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed requesting approval")
+		}
+	}
+	for i, tx := range c.bundle.Transactions {
+		if err := views[i].distribute(context, env, states[i]); err != nil {
+			return nil, errors.WithMessagef(err, "failed distributing transaction for [%s]", tx.TMSID())
+		}
+	}
+	return c.bundle, nil
+}
+
+// approve asks the network to approve the passed token requests in a single network transaction
+func (c *CollectBundleEndorsementsView) approve(context view.Context, requests []*network.ApprovalRequest) (*network.Envelope, error) {
+	first := c.bundle.Transactions[0]
+	nw := network.GetInstance(context, first.Network(), first.Channel())
+	if nw == nil {
+		return nil, errors.Errorf("network [%s] not found", first.Network())
+	}
+	env, err := nw.RequestApprovals(context, requests, first.Signer, first.Payload.TxID)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range c.bundle.Transactions {
+		tx.Envelope = env
+	}
+	return env, nil
+}
+
+type EndorseBundleView struct {
+	bundle *Bundle
+	opts   []EndorsementsOpt
+}
+
+// NewEndorseBundleView returns a new instance of EndorseBundleView, the responder of CollectBundleEndorsementsView.
+// The view does the following:
+// 1. For each transaction of the bundle, in order, it answers the signature requests addressed to this node.
+// 2. For each transaction of the bundle this node is a party of, in order, it receives the approved transaction,
+// stores it, and sends back an acknowledgement, if the transaction satisfies the receive policy.
+func NewEndorseBundleView(bundle *Bundle, opts ...EndorsementsOpt) *EndorseBundleView {
+	return &EndorseBundleView{bundle: bundle, opts: opts}
+}
+
+// Call answers, in the order CollectBundleEndorsementsView sends them,
+// first all the signature requests and then the approved transactions
+func (e *EndorseBundleView) Call(context view.Context) (interface{}, error) {
+	views := make([]*EndorseView, len(e.bundle.Transactions))
+	for i, tx := range e.bundle.Transactions {
+		views[i] = NewEndorseView(tx, e.opts...)
+		if err := views[i].sign(context); err != nil {
+			return nil, errors.WithMessagef(err, "failed signing transaction for [%s]", tx.TMSID())
+		}
+	}
+	for i, tx := range e.bundle.Transactions {
+		if !isRecipientOf(tx) {
+			continue
+		}
+		if err := views[i].receive(context); err != nil {
+			return nil, errors.WithMessagef(err, "failed receiving transaction for [%s]", tx.TMSID())
+		}
+		e.bundle.Transactions[i] = views[i].tx
+	}
+	return e.bundle, nil
+}
+
+// isRecipientOf returns true if this node is in the distribution list of the passed transaction
+func isRecipientOf(tx *Transaction) bool {
+	wm := tx.TokenService().WalletManager()
+	sigService := tx.TokenService().SigService()
+	for _, id := range append(IssueDistributionList(tx.TokenRequest), TransferDistributionList(tx.TokenRequest)...) {
+		if id.IsNone() {
+			continue
+		}
+		if wm.OwnerWallet(id) != nil || sigService.IsMe(id) {
+			return true
+		}
+	}
+	return false
+}
+
+type bundleOrderingView struct {
+	bundle *Bundle
+	opts   []TxOption
+}
+
+// NewBundleOrderingView returns a new instance of the view that broadcasts the network transaction of the passed bundle
+func NewBundleOrderingView(bundle *Bundle, opts ...TxOption) *bundleOrderingView {
+	return &bundleOrderingView{bundle: bundle, opts: opts}
+}
+
+func (o *bundleOrderingView) Call(context view.Context) (interface{}, error) {
+	options, err := compile(o.opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to compile options")
+	}
+	// the transactions share the same envelope, broadcast it once
+	if err := (&orderingView{}).broadcast(context, o.bundle.Transactions[0]); err != nil {
+		return nil, err
+	}
+	for _, tx := range o.bundle.Transactions {
+		if err := broadcasted(context, tx, options.NoCachingRequest); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+type bundleFinalityView struct {
+	bundle *Bundle
+	opts   []TxOption
+}
+
+// NewBundleFinalityView returns a new instance of the view that waits for the finality of all the transactions of the passed bundle
+func NewBundleFinalityView(bundle *Bundle, opts ...TxOption) *bundleFinalityView {
+	return &bundleFinalityView{bundle: bundle, opts: opts}
+}
+
+func (f *bundleFinalityView) Call(context view.Context) (interface{}, error) {
+	for _, tx := range f.bundle.Transactions {
+		if _, err := context.RunView(NewFinalityView(tx, f.opts...)); err != nil {
+			return nil, errors.WithMessagef(err, "failed waiting for finality of [%s] on [%s]", tx.ID(), tx.TMSID())
+		}
+	}
+	return nil, nil
+}
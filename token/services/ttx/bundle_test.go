@@ -0,0 +1,29 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckBundleTMSIDs(t *testing.T) {
+	tms1 := token.TMSID{Network: "n", Channel: "c", Namespace: "ns1"}
+	tms2 := token.TMSID{Network: "n", Channel: "c", Namespace: "ns2"}
+	assert.NoError(t, checkBundleTMSIDs([]token.TMSID{tms1, tms2}))
+
+	otherChannel := token.TMSID{Network: "n", Channel: "c2", Namespace: "ns2"}
+	assert.EqualError(t, checkBundleTMSIDs([]token.TMSID{tms1, otherChannel}),
+		fmt.Sprintf("tms [%s] and [%s] are not on the same network and channel", otherChannel, tms1))
+	otherNetwork := token.TMSID{Network: "n2", Channel: "c", Namespace: "ns2"}
+	assert.EqualError(t, checkBundleTMSIDs([]token.TMSID{tms1, otherNetwork}),
+		fmt.Sprintf("tms [%s] and [%s] are not on the same network and channel", otherNetwork, tms1))
+	assert.EqualError(t, checkBundleTMSIDs([]token.TMSID{tms1, tms2, tms1}), "namespace [ns1] passed more than once")
+}
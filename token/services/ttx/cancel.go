@@ -0,0 +1,286 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+const defaultCancelReason = "canceled by the initiator"
+
+// CancelRequest is sent by the initiator of a transaction to the other parties to cancel it
+type CancelRequest struct {
+	TxID      string
+	Network   string
+	Channel   string
+	Namespace string
+	Reason    string
+}
+
+// CancelResponse is the answer of a party to a CancelRequest
+type CancelResponse struct {
+	// Error is empty if the party canceled the transaction
+	Error string
+}
+
+// CancelView cancels a transaction that has not been approved yet.
+// The view does the following:
+// 1. It checks that the transaction has not been approved yet. Once approved, the other parties hold a transaction
+// that can be committed, and no network supports preventing that, therefore the view fails.
+// 2. It marks the transaction as Deleted in the local databases with the given reason and releases the tokens it locked.
+// 3. It notifies all the parties in the distribution list, and the auditor, that mark the transaction as Deleted as well.
+// The parties must register CancelResponderView as responder of this view.
+type CancelView struct {
+	tx     *Transaction
+	reason string
+}
+
+// NewCancelView returns a new CancelView for the passed transaction.
+// If the reason is empty, a default reason is used.
+func NewCancelView(tx *Transaction, reason string) *CancelView {
+	if len(reason) == 0 {
+		reason = defaultCancelReason
+	}
+	return &CancelView{tx: tx, reason: reason}
+}
+
+func (c *CancelView) Call(context view.Context) (interface{}, error) {
+	tmsID := c.tx.TMSID()
+	ttxDB, err := ttxdb.GetByTMSId(context, tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting ttxdb for [%s]", tmsID)
+	}
+	steps, err := ttxDB.TransactionSteps(c.tx.ID())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting steps of [%s]", c.tx.ID())
+	}
+	status, _, err := ttxDB.GetStatus(c.tx.ID())
+	if err != nil {
+		status = Unknown
+	}
+	if err := checkCancelable(c.tx.ID(), steps, status); err != nil {
+		return nil, err
+	}
+	c.tx.Release()
+	if err := cancelLocally(context.Context(), context, tmsID, c.tx.ID(), c.reason); err != nil {
+		return nil, err
+	}
+
+	// notify the other parties
+	return nil, c.notify(context)
+}
+
+// checkCancelable checks that the passed transaction, assembled by this node, can be canceled,
+// that is, it has not been approved yet.
+// Once approved, the other parties hold a transaction that can be committed.
+func checkCancelable(txID string, steps []*ttxdb.TransactionStepRecord, status TxStatus) error {
+	if status == Confirmed {
+		return errors.Errorf("transaction [%s] is already committed", txID)
+	}
+	for _, step := range steps {
+		if step.Step == Approved || step.Step == Broadcast {
+			return errors.Errorf("transaction [%s] has been already approved and cannot be canceled anymore", txID)
+		}
+	}
+	return nil
+}
+
+func (c *CancelView) notify(context view.Context) error {
+	var auditors []view.Identity
+	if !c.tx.Opts.Auditor.IsNone() {
+		auditors = append(auditors, c.tx.Opts.Auditor)
+	}
+	distributionList := append(IssueDistributionList(c.tx.TokenRequest), TransferDistributionList(c.tx.TokenRequest)...)
+	entries, err := (&CollectEndorsementsView{tx: c.tx}).prepareDistributionList(context, auditors, distributionList)
+	if err != nil {
+		return errors.WithMessage(err, "failed preparing distribution list")
+	}
+	raw, err := Marshal(&CancelRequest{
+		TxID:      c.tx.ID(),
+		Network:   c.tx.Network(),
+		Channel:   c.tx.Channel(),
+		Namespace: c.tx.Namespace(),
+		Reason:    c.reason,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed marshalling cancel request")
+	}
+
+	var failures []string
+	for _, entry := range entries {
+		if entry.IsMe {
+			continue
+		}
+		if err := c.notifyParty(context, entry.ID, raw); err != nil {
+			logger.Errorf("failed notifying cancellation of [%s] to [%s]: [%s]", c.tx.ID(), entry.ID, err)
+			failures = append(failures, entry.ID.String()+": "+err.Error())
+		}
+	}
+	if len(failures) != 0 {
+		return errors.Errorf("transaction [%s] canceled, but not all parties acknowledged it: [%s]", c.tx.ID(), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (c *CancelView) notifyParty(context view.Context, party view.Identity, raw []byte) error {
+	session, err := context.GetSession(c, party)
+	if err != nil {
+		return errors.Wrap(err, "failed getting session")
+	}
+	defer session.Close()
+	if err := session.SendWithContext(context.Context(), raw); err != nil {
+		return errors.Wrap(err, "failed sending cancel request")
+	}
+	msg, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed reading response")
+	}
+	response := &CancelResponse{}
+	if err := Unmarshal(msg, response); err != nil {
+		return errors.Wrap(err, "failed unmarshalling response")
+	}
+	if len(response.Error) != 0 {
+		return errors.New(response.Error)
+	}
+	return nil
+}
+
+// CancelResponderView is the responder of CancelView.
+// It accepts the cancellation only from the node the transaction has been received from.
+type CancelResponderView struct{}
+
+func (c *CancelResponderView) Call(context view.Context) (interface{}, error) {
+	session := context.Session()
+	msg, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading cancel request")
+	}
+	request := &CancelRequest{}
+	if err := Unmarshal(msg, request); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling cancel request")
+	}
+
+	response := &CancelResponse{}
+	if err := c.cancel(context, request, session.Info().Caller); err != nil {
+		logger.Errorf("failed canceling [%s]: [%s]", request.TxID, err)
+		response.Error = err.Error()
+	}
+	raw, err := Marshal(response)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling cancel response")
+	}
+	if err := session.SendWithContext(context.Context(), raw); err != nil {
+		return nil, errors.Wrap(err, "failed sending cancel response")
+	}
+	return nil, nil
+}
+
+func (c *CancelResponderView) cancel(context view.Context, request *CancelRequest, caller view.Identity) error {
+	tmsID := token.TMSID{Network: request.Network, Channel: request.Channel, Namespace: request.Namespace}
+	ttxDB, err := ttxdb.GetByTMSId(context, tmsID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting ttxdb for [%s]", tmsID)
+	}
+
+	steps, err := ttxDB.TransactionSteps(request.TxID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting steps of [%s]", request.TxID)
+	}
+	status, _, err := ttxDB.GetStatus(request.TxID)
+	if err != nil {
+		status = Unknown
+	}
+	if err := checkCancelRequest(request.TxID, steps, status, caller); err != nil {
+		return err
+	}
+
+	tms := token.GetManagementService(context, token.WithTMSID(tmsID))
+	if tms == nil {
+		return errors.Errorf("failed getting TMS [%s]", tmsID)
+	}
+	sm, err := tms.SelectorManager()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting selector manager for [%s]", tmsID)
+	}
+	if err := sm.Unlock(request.TxID); err != nil {
+		logger.Warnf("failed releasing tokens locked by [%s]: [%s]", request.TxID, err)
+	}
+	return cancelLocally(context.Context(), context, tmsID, request.TxID, request.Reason)
+}
+
+// checkCancelRequest checks that the cancellation of the passed transaction comes from the node
+// the transaction has been received from, and that the transaction is not committed
+func checkCancelRequest(txID string, steps []*ttxdb.TransactionStepRecord, status TxStatus, caller view.Identity) error {
+	var sender view.Identity
+	for _, step := range steps {
+		if step.Step == Distributed {
+			sender = step.Payload
+		}
+	}
+	if sender.IsNone() {
+		return errors.Errorf("transaction [%s] not received", txID)
+	}
+	if !sender.Equal(caller) {
+		return errors.Errorf("transaction [%s] not received from [%s]", txID, caller)
+	}
+	if status == Confirmed {
+		return errors.Errorf("transaction [%s] is already committed", txID)
+	}
+	return nil
+}
+
+// recordReceived records in the ttxdb that the passed transaction has been received from the passed node.
+// Nothing is recorded if the sender is this node, for instance when the auditor runs locally.
+func recordReceived(sp token.ServiceProvider, tx *Transaction, sender view.Identity) error {
+	if sender.IsNone() || view2.GetSigService(sp).IsMe(sender) {
+		return nil
+	}
+	db, err := ttxdb.GetByTMSId(sp, tx.TMSID())
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting ttxdb for [%s]", tx.TMSID())
+	}
+	return db.AddTransactionStep(tx.ID(), Distributed, sender, "")
+}
+
+// cancelLocally marks the passed transaction as Deleted, with the passed reason, in the ttxdb and auditdb that know it,
+// and records that the transaction has been aborted
+func cancelLocally(ctx context.Context, sp token.ServiceProvider, tmsID token.TMSID, txID string, reason string) error {
+	ttxDB, err := ttxdb.GetByTMSId(sp, tmsID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting ttxdb for [%s]", tmsID)
+	}
+	auditDB, err := auditdb.GetByTMSId(sp, tmsID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting auditdb for [%s]", tmsID)
+	}
+	for _, db := range []interface {
+		GetTokenRequest(txID string) ([]byte, error)
+		SetStatus(ctx context.Context, txID string, status TxStatus, message string) error
+	}{ttxDB, auditDB} {
+		request, err := db.GetTokenRequest(txID)
+		if err != nil {
+			return errors.WithMessagef(err, "failed getting token request for [%s]", txID)
+		}
+		if len(request) == 0 {
+			continue
+		}
+		if err := db.SetStatus(ctx, txID, Deleted, reason); err != nil {
+			return errors.WithMessagef(err, "failed deleting [%s]", txID)
+		}
+	}
+	logger.Infof("transaction [%s] canceled: %s", txID, reason)
+	return ttxDB.AddTransactionStep(txID, Aborted, nil, reason)
+}
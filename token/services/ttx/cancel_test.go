@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func steps(txID string, steps ...TransactionStep) []*ttxdb.TransactionStepRecord {
+	var res []*ttxdb.TransactionStepRecord
+	for _, step := range steps {
+		res = append(res, &ttxdb.TransactionStepRecord{TxID: txID, Step: step})
+	}
+	return res
+}
+
+func TestCheckCancelable(t *testing.T) {
+	// not approved yet
+	assert.NoError(t, checkCancelable("tx1", steps("tx1", Assembled, SignaturesCollected, Audited), Pending))
+	assert.NoError(t, checkCancelable("tx1", nil, Unknown))
+
+	// approved, the parties hold a transaction that can be committed
+	for _, reached := range []TransactionStep{Approved, Broadcast} {
+		err := checkCancelable("tx1", steps("tx1", Assembled, reached), Pending)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "transaction [tx1] has been already approved and cannot be canceled anymore")
+	}
+
+	// committed transactions cannot be canceled
+	err := checkCancelable("tx1", steps("tx1", Approved, Broadcast, Final), Confirmed)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction [tx1] is already committed")
+}
+
+func TestCheckCancelRequest(t *testing.T) {
+	alice, bob := view.Identity("alice"), view.Identity("bob")
+	received := []*ttxdb.TransactionStepRecord{{TxID: "tx1", Step: Distributed, Payload: alice}}
+
+	assert.NoError(t, checkCancelRequest("tx1", received, Pending, alice))
+
+	err := checkCancelRequest("tx1", received, Pending, bob)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction [tx1] not received from ["+bob.String()+"]")
+
+	err = checkCancelRequest("tx1", nil, Pending, alice)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction [tx1] not received")
+
+	err = checkCancelRequest("tx1", received, Confirmed, alice)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "transaction [tx1] is already committed")
+}
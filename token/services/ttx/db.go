@@ -8,6 +8,7 @@ package ttx
 
 import (
 	"context"
+	"sync"
 
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
@@ -42,6 +43,10 @@ type DB struct {
 	tmsProvider     TMSProvider
 	finalityTracer  trace.Tracer
 	checkService    CheckService
+
+	notifierProvider TokenNotifierProvider
+	statusHubOnce    sync.Once
+	statusHub        *statusHub
 }
 
 // Append adds the passed transaction to the database
@@ -50,6 +55,12 @@ func (a *DB) Append(tx *Transaction) error {
 	if err := a.ttxDB.AppendTransactionRecord(tx.Request()); err != nil {
 		return errors.WithMessagef(err, "failed appending request %s", tx.ID())
 	}
+	// store the travel-rule information, if any
+	if raw := tx.Transient[TravelRuleAttachment]; len(raw) != 0 {
+		if err := a.ttxDB.AddTransactionAttachment(tx.ID(), TravelRuleAttachment, raw); err != nil {
+			return errors.WithMessagef(err, "failed appending travel-rule information of %s", tx.ID())
+		}
+	}
 
 	// listen to events
 	net, err := a.networkProvider.GetNetwork(tx.Network(), tx.Channel())
@@ -93,6 +104,10 @@ func (a *DB) GetTransactionEndorsementAcks(id string) (map[string][]byte, error)
 	return a.ttxDB.GetTransactionEndorsementAcks(id)
 }
 
+func (a *DB) AppendDistributionResult(record *ttxdb.DistributionResultRecord) error {
+	return a.ttxDB.AddTransactionDistributionResult(record)
+}
+
 func (a *DB) Check(context context.Context) ([]string, error) {
 	return a.checkService.Check(context)
 }
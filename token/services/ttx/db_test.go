@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"testing"
+
+	mem "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver/memory"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/drivers"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb/db/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+// openedTTXDBDriver returns an already opened database
+type openedTTXDBDriver struct {
+	db driver.TokenTransactionDB
+}
+
+func (d *openedTTXDBDriver) Open(driver.ConfigProvider, token.TMSID) (driver.TokenTransactionDB, error) {
+	return d.db, nil
+}
+
+type memoryDBConfig struct{}
+
+func (memoryDBConfig) DriverFor(token.TMSID) (drivers.DriverName, error) {
+	return string(mem.MemoryPersistence), nil
+}
+
+// newTestTTXDB returns a ttxdb, private to the calling test, backed by an in-memory sqlite database.
+// The database is returned too, to write the records the ttxdb does not expose.
+func newTestTTXDB(t *testing.T) (*ttxdb.DB, driver.TokenTransactionDB) {
+	tmsID := token.TMSID{Network: t.Name()}
+	raw, err := memory.NewDriver().Driver.Open(nil, tmsID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { assert.NoError(t, raw.Close()) })
+
+	holder := ttxdb.NewHolder([]db.NamedDriver[driver.TTXDBDriver]{
+		{Name: mem.MemoryPersistence, Driver: &openedTTXDBDriver{db: raw}},
+	})
+	ttxDB, err := holder.NewManager(nil, memoryDBConfig{}).DBByTMSId(tmsID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ttxDB, raw
+}
@@ -0,0 +1,192 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+// DistributionConfigKey is the configuration key, relative to the TMS, of the distribution of the transaction envelope
+const DistributionConfigKey = "services.ttx.distribution"
+
+const (
+	defaultDistributionWorkers    = 10
+	defaultDistributionTimeout    = 4 * time.Minute
+	defaultDistributionRetries    = 3
+	defaultDistributionRetryDelay = 100 * time.Millisecond
+)
+
+// DistributionResultRecord is the outcome of the distribution of a transaction to a party
+type DistributionResultRecord = ttxdb.DistributionResultRecord
+
+// DistributionConfig configures how CollectEndorsementsView distributes the approved transaction to the parties
+type DistributionConfig struct {
+	// Workers is the maximum number of parties the transaction is sent to concurrently
+	Workers int `yaml:"workers,omitempty"`
+	// Timeout is the time a party has to acknowledge the transaction, at each attempt
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+	// Retries is the number of attempts made for each party.
+	// If negative, each party gets a single attempt.
+	Retries int `yaml:"retries,omitempty"`
+	// RetryDelay is the time between two attempts
+	RetryDelay time.Duration `yaml:"retryDelay,omitempty"`
+	// MinAcks is the number of parties, auditors excluded, whose acknowledgement is enough to proceed.
+	// The auditors must always acknowledge. If zero, all the parties must acknowledge.
+	// The distribution to the remaining parties continues in the background.
+	MinAcks int `yaml:"minAcks,omitempty"`
+}
+
+// GetDistributionConfig loads the distribution configuration of the passed TMS, using the defaults for the missing values
+func GetDistributionConfig(tms *token.ManagementService) (*DistributionConfig, error) {
+	c := &DistributionConfig{}
+	if tms.Configuration().IsSet(DistributionConfigKey) {
+		if err := tms.Configuration().UnmarshalKey(DistributionConfigKey, c); err != nil {
+			return nil, errors.WithMessagef(err, "failed loading distribution config for [%s]", tms.ID())
+		}
+	}
+	return c.withDefaults(), nil
+}
+
+func (c *DistributionConfig) withDefaults() *DistributionConfig {
+	res := *c
+	if res.Workers <= 0 {
+		res.Workers = defaultDistributionWorkers
+	}
+	if res.Timeout <= 0 {
+		res.Timeout = defaultDistributionTimeout
+	}
+	if res.Retries < 0 {
+		res.Retries = 1
+	} else if res.Retries == 0 {
+		res.Retries = defaultDistributionRetries
+	}
+	if res.RetryDelay <= 0 {
+		res.RetryDelay = defaultDistributionRetryDelay
+	}
+	if res.MinAcks < 0 {
+		res.MinAcks = 0
+	}
+	return &res
+}
+
+// distributionJob is the delivery of the transaction to a party of the distribution list
+type distributionJob struct {
+	entry distributionListEntry
+	txRaw []byte
+}
+
+// distributionOutcome is the outcome of a distributionJob
+type distributionOutcome struct {
+	job      *distributionJob
+	attempts int
+	// sigma is the acknowledgement of the party, if it acknowledged the transaction
+	sigma []byte
+	err   error
+}
+
+// distributeConcurrently runs deliver for each job, with at most config.Workers jobs running at the same time.
+// Each job is attempted up to config.Retries times, record is invoked with the outcome of each job.
+// It returns as soon as all the auditors and config.MinAcks other parties (all of them, if zero) acknowledged,
+// or as soon as this is not possible anymore.
+// In the first case, the remaining jobs keep running until they complete or the passed context is cancelled.
+// In the second case, the remaining jobs are cancelled.
+// The outcome of a job completing after the cancellation is recorded as failed, its acknowledgement is dropped.
+func distributeConcurrently(ctx context.Context, jobs []*distributionJob, config *DistributionConfig, deliver func(ctx context.Context, job *distributionJob) ([]byte, error), record func(outcome *distributionOutcome)) error {
+	auditors, parties := 0, 0
+	for _, job := range jobs {
+		if job.entry.Auditor {
+			auditors++
+		} else {
+			parties++
+		}
+	}
+	requiredParties := parties
+	if config.MinAcks > 0 && config.MinAcks < parties {
+		requiredParties = config.MinAcks
+	}
+	if auditors == 0 && requiredParties == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	// the channel is buffered so that the jobs completing after the return do not block
+	outcomes := make(chan *distributionOutcome, len(jobs))
+	workers := make(chan struct{}, config.Workers)
+	var wg sync.WaitGroup
+	wg.Add(len(jobs))
+	for _, job := range jobs {
+		go func(job *distributionJob) {
+			defer wg.Done()
+			outcome := &distributionOutcome{job: job}
+			defer func() {
+				if err := ctx.Err(); err != nil {
+					outcome.sigma = nil
+					outcome.err = errors.Wrapf(err, "distribution cancelled")
+				}
+				record(outcome)
+				outcomes <- outcome
+			}()
+
+			select {
+			case workers <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-workers }()
+
+			for outcome.attempts < config.Retries && ctx.Err() == nil {
+				if outcome.attempts > 0 {
+					select {
+					case <-time.After(config.RetryDelay):
+					case <-ctx.Done():
+						return
+					}
+				}
+				outcome.attempts++
+				if outcome.sigma, outcome.err = deliver(ctx, job); outcome.err == nil {
+					break
+				}
+				logger.Warnf("failed distributing to [%s], attempt [%d]: [%s]", job.entry.ID, outcome.attempts, outcome.err)
+			}
+		}(job)
+	}
+	// release the context once all the jobs are done
+	go func() {
+		wg.Wait()
+		cancel()
+	}()
+
+	ackedAuditors, ackedParties, failedParties := 0, 0, 0
+	for range jobs {
+		outcome := <-outcomes
+		switch {
+		case outcome.job.entry.Auditor && outcome.err != nil:
+			cancel()
+			return errors.Wrapf(outcome.err, "failed distributing to auditor [%s]", outcome.job.entry.ID)
+		case outcome.job.entry.Auditor:
+			ackedAuditors++
+		case outcome.err != nil:
+			failedParties++
+			if parties-failedParties < requiredParties {
+				cancel()
+				return errors.Wrapf(outcome.err, "failed distributing to [%s], [%d] parties failed, [%d] out of [%d] required", outcome.job.entry.ID, failedParties, requiredParties, parties)
+			}
+		default:
+			ackedParties++
+		}
+		if ackedAuditors == auditors && ackedParties >= requiredParties {
+			return nil
+		}
+	}
+	return nil
+}
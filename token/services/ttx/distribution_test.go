@@ -0,0 +1,183 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func distributionJobs(parties []string, auditors ...string) []*distributionJob {
+	var jobs []*distributionJob
+	for _, party := range parties {
+		jobs = append(jobs, &distributionJob{entry: distributionListEntry{ID: view.Identity(party)}})
+	}
+	for _, auditor := range auditors {
+		jobs = append(jobs, &distributionJob{entry: distributionListEntry{ID: view.Identity(auditor), Auditor: true}})
+	}
+	return jobs
+}
+
+type distributionRecorder struct {
+	lock     sync.Mutex
+	outcomes map[string]*distributionOutcome
+}
+
+func (r *distributionRecorder) record(outcome *distributionOutcome) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.outcomes == nil {
+		r.outcomes = map[string]*distributionOutcome{}
+	}
+	r.outcomes[string(outcome.job.entry.ID)] = outcome
+}
+
+func (r *distributionRecorder) get(party string) *distributionOutcome {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.outcomes[party]
+}
+
+func TestDistributionConfigDefaults(t *testing.T) {
+	c := (&DistributionConfig{}).withDefaults()
+	assert.Equal(t, &DistributionConfig{Workers: 10, Timeout: 4 * time.Minute, Retries: 3, RetryDelay: 100 * time.Millisecond}, c)
+	c = (&DistributionConfig{Workers: 2, Retries: -1, MinAcks: -1}).withDefaults()
+	assert.Equal(t, 2, c.Workers)
+	assert.Equal(t, 1, c.Retries)
+	assert.Equal(t, 0, c.MinAcks)
+}
+
+func TestDistributeConcurrentlyBoundsWorkers(t *testing.T) {
+	config := (&DistributionConfig{Workers: 3}).withDefaults()
+	var running, maxRunning int32
+	recorder := &distributionRecorder{}
+	err := distributeConcurrently(context.Background(), distributionJobs([]string{"a", "b", "c", "d", "e", "f", "g"}, "auditor"), config, func(_ context.Context, job *distributionJob) ([]byte, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil, nil
+	}, recorder.record)
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, maxRunning, int32(3))
+	assert.Greater(t, maxRunning, int32(1))
+	for _, party := range []string{"a", "b", "c", "d", "e", "f", "g", "auditor"} {
+		assert.NoError(t, recorder.get(party).err)
+		assert.Equal(t, 1, recorder.get(party).attempts)
+	}
+}
+
+func TestDistributeConcurrentlyRetries(t *testing.T) {
+	config := (&DistributionConfig{Retries: 3, RetryDelay: time.Millisecond}).withDefaults()
+	var attempts int32
+	recorder := &distributionRecorder{}
+	err := distributeConcurrently(context.Background(), distributionJobs([]string{"a", "b"}), config, func(_ context.Context, job *distributionJob) ([]byte, error) {
+		if string(job.entry.ID) == "b" && atomic.AddInt32(&attempts, 1) < 3 {
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}, recorder.record)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, recorder.get("a").attempts)
+	assert.Equal(t, 3, recorder.get("b").attempts)
+	assert.NoError(t, recorder.get("b").err)
+
+	// all the parties must acknowledge by default
+	err = distributeConcurrently(context.Background(), distributionJobs([]string{"a", "b"}), config, func(_ context.Context, job *distributionJob) ([]byte, error) {
+		if string(job.entry.ID) == "b" {
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}, recorder.record)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "[1] parties failed, [2] out of [2] required: unavailable")
+	assert.Equal(t, 3, recorder.get("b").attempts)
+}
+
+func TestDistributeConcurrentlyMinAcks(t *testing.T) {
+	config := (&DistributionConfig{Retries: -1, MinAcks: 2}).withDefaults()
+	release := make(chan struct{})
+	recorder := &distributionRecorder{}
+	// c is slow and d fails, a and b are enough
+	err := distributeConcurrently(context.Background(), distributionJobs([]string{"a", "b", "c", "d"}, "auditor"), config, func(_ context.Context, job *distributionJob) ([]byte, error) {
+		switch string(job.entry.ID) {
+		case "c":
+			<-release
+		case "d":
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}, recorder.record)
+	assert.NoError(t, err)
+	assert.Nil(t, recorder.get("c"))
+	close(release)
+	assert.Eventually(t, func() bool { return recorder.get("c") != nil }, time.Second, time.Millisecond)
+
+	// too many failures
+	err = distributeConcurrently(context.Background(), distributionJobs([]string{"a", "b", "c"}), config, func(_ context.Context, job *distributionJob) ([]byte, error) {
+		if string(job.entry.ID) != "a" {
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}, recorder.record)
+	assert.Error(t, err)
+
+	// the auditor must always acknowledge
+	err = distributeConcurrently(context.Background(), distributionJobs([]string{"a", "b"}, "auditor"), config, func(_ context.Context, job *distributionJob) ([]byte, error) {
+		if string(job.entry.ID) == "auditor" {
+			return nil, errors.New("unavailable")
+		}
+		return nil, nil
+	}, recorder.record)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed distributing to auditor")
+}
+
+func TestDistributeConcurrentlyCancel(t *testing.T) {
+	config := (&DistributionConfig{Retries: -1}).withDefaults()
+	recorder := &distributionRecorder{}
+	// the auditor fails while b is waiting for its party, b is cancelled and its acknowledgement dropped
+	err := distributeConcurrently(context.Background(), distributionJobs([]string{"b"}, "auditor"), config, func(ctx context.Context, job *distributionJob) ([]byte, error) {
+		if string(job.entry.ID) == "auditor" {
+			return nil, errors.New("unavailable")
+		}
+		<-ctx.Done()
+		return []byte("sigma"), nil
+	}, recorder.record)
+	assert.Error(t, err)
+	assert.Eventually(t, func() bool { return recorder.get("b") != nil }, time.Second, time.Millisecond)
+	assert.Nil(t, recorder.get("b").sigma)
+	assert.EqualError(t, recorder.get("b").err, "distribution cancelled: context canceled")
+
+	// the jobs left running in the background stop when the passed context is cancelled
+	config = (&DistributionConfig{Retries: -1, MinAcks: 1}).withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	err = distributeConcurrently(ctx, distributionJobs([]string{"a", "b"}), config, func(ctx context.Context, job *distributionJob) ([]byte, error) {
+		if string(job.entry.ID) == "b" {
+			<-ctx.Done()
+		}
+		return []byte("sigma"), nil
+	}, recorder.record)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("sigma"), recorder.get("a").sigma)
+	cancel()
+	assert.Eventually(t, func() bool { return recorder.get("b") != nil }, time.Second, time.Millisecond)
+	assert.Error(t, recorder.get("b").err)
+	assert.Nil(t, recorder.get("b").sigma)
+}
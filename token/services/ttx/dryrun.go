@@ -0,0 +1,55 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"fmt"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+)
+
+// DryRun evaluates the passed transfers as if they were appended to the transaction, without modifying it.
+// In addition to the checks of token.Request#DryRun, it checks that the recipients can be reached,
+// meaning that they are either owned by this node or bound to an FSC node.
+func (t *Transaction) DryRun(context view.Context, templates ...*token.TransferTemplate) (*token.DryRunReport, error) {
+	report, err := t.TokenRequest.DryRun(context.Context(), templates...)
+	if err != nil {
+		return nil, err
+	}
+	resolver := view2.GetEndpointService(context)
+	for i, template := range templates {
+		if template == nil {
+			continue
+		}
+		checked := map[string]struct{}{}
+		for _, owner := range template.Owners {
+			if owner.IsNone() {
+				continue
+			}
+			if _, ok := checked[owner.UniqueID()]; ok {
+				continue
+			}
+			checked[owner.UniqueID()] = struct{}{}
+			if t.TMS.SigService().IsMe(owner) || t.TMS.WalletManager().OwnerWallet(owner) != nil {
+				continue
+			}
+			if _, _, _, err := resolver.Resolve(owner); err != nil {
+				report.Reasons = append(report.Reasons, token.InfeasibilityReason{
+					Code:      token.UnreachableRecipient,
+					Template:  i,
+					TokenType: template.TokenType,
+					Recipient: owner,
+					Message:   fmt.Sprintf("cannot resolve recipient [%s]: %s", owner, err),
+				})
+			}
+		}
+	}
+	report.Feasible = len(report.Reasons) == 0
+	return report, nil
+}
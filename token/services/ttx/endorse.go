@@ -7,16 +7,19 @@ SPDX-License-Identifier: Apache-2.0
 package ttx
 
 import (
+	"bytes"
+	context2 "context"
 	"encoding/base64"
 	"reflect"
+	"slices"
 	"time"
 
-	"github.com/hyperledger-labs/fabric-smart-client/pkg/utils"
 	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/hash"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokens"
 	"github.com/pkg/errors"
@@ -43,12 +46,62 @@ type SignatureRequest struct {
 	Request []byte
 	TxID    []byte
 	Signer  view.Identity
+	// Summary is the canonical summary of the token request, as returned by token.RequestSummary#Bytes
+	Summary []byte
 }
 
+// MessageToSign returns the message the signer signs: the token request to sign followed by the transaction id.
+// When the summary of the token request is bound, the token request carries its hash,
+// therefore the signature commits to the summary too (see token.Request#BindSummary).
 func (sr *SignatureRequest) MessageToSign() []byte {
 	return append(sr.Request, sr.TxID...)
 }
 
+// RequestSummary returns the summary of the token request to sign, nil if the signature request carries none.
+// The summary is not verified, use VerifySummary for that.
+func (sr *SignatureRequest) RequestSummary() (*token.RequestSummary, error) {
+	if len(sr.Summary) == 0 {
+		return nil, nil
+	}
+	return token.NewRequestSummaryFromBytes(sr.Summary)
+}
+
+// VerifySummary checks that the summary carried by the signature request, if any, is the summary of
+// the token request to sign, that is, of the token request whose bytes are part of the message to sign.
+// If the summary is bound to the token request, its hash must match too, even when the signature request carries no summary.
+// A signature request with no summary, for a token request not bound to a summary, has nothing to verify.
+func (sr *SignatureRequest) VerifySummary(context view.Context) error {
+	if len(sr.Summary) == 0 {
+		request := &driver.TokenRequest{}
+		if err := request.FromBytes(sr.Request); err != nil {
+			return errors.Wrapf(err, "failed unmarshalling the token request to sign")
+		}
+		if len(request.SummaryHash) == 0 {
+			return nil
+		}
+	}
+	tx, err := NewTransactionFromBytes(context, sr.TX)
+	if err != nil {
+		return errors.WithMessagef(err, "failed unmarshalling transaction of the signature request")
+	}
+	request, err := tx.TokenRequest.RequestToBytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed marshalling token request [%s]", tx.ID())
+	}
+	if !bytes.Equal(request, sr.Request) {
+		return errors.Errorf("the transaction of the signature request does not contain the token request to sign [%s]", tx.ID())
+	}
+	if len(sr.Summary) != 0 {
+		if err := tx.TokenRequest.VerifySummary(sr.Summary); err != nil {
+			return errors.WithMessagef(err, "invalid summary in signature request for [%s]", tx.ID())
+		}
+	}
+	if err := tx.TokenRequest.VerifySummaryHash(); err != nil {
+		return errors.WithMessagef(err, "invalid summary hash in signature request for [%s]", tx.ID())
+	}
+	return nil
+}
+
 type CollectEndorsementsView struct {
 	tx       *Transaction
 	Opts     *EndorsementsOpts
@@ -80,7 +133,65 @@ func NewCollectEndorsementsView(tx *Transaction, opts ...EndorsementsOpt) *Colle
 // Depending on the token driver implementation, the recipient's signature might or might not be needed to make
 // the token transaction valid.
 func (c *CollectEndorsementsView) Call(context view.Context) (interface{}, error) {
-	metrics := GetMetrics(context)
+	// a restored transaction is not aborted, its previous submission is still in charge of it
+	if err := c.tx.checkModifiable(); err != nil {
+		return nil, err
+	}
+	res, err := c.call(context)
+	if err != nil {
+		if err2 := recordStep(context, c.tx, Aborted, false, err.Error()); err2 != nil {
+			logger.Warnf("failed recording abort of [%s]: [%s]", c.tx.ID(), err2)
+		}
+	}
+	return res, err
+}
+
+func (c *CollectEndorsementsView) call(context view.Context) (interface{}, error) {
+	state, err := c.collectSignaturesAndAudit(context)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Endorse and return the transaction envelope
+	var env *network.Envelope
+	if !c.Opts.SkipApproval {
+		env, err = c.requestApproval(context)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed requesting approval")
+		}
+	}
+
+	if err := c.distribute(context, env, state); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// endorsementState is the outcome of the auditing step needed to complete the collection of the endorsements
+type endorsementState struct {
+	auditors      []view.Identity
+	asyncAuditing bool
+}
+
+// collectSignaturesAndAudit collects the signatures on the token request and, if needed, the auditor's one
+func (c *CollectEndorsementsView) collectSignaturesAndAudit(context view.Context) (*endorsementState, error) {
+	// The travel-rule information, if any, must be bound to the final token request
+	if err := checkTravelRule(c.tx); err != nil {
+		return nil, errors.WithMessage(err, "invalid travel-rule information")
+	}
+
+	// The signatures of the external wallets must commit to the summary shown to their users
+	if len(c.Opts.ExternalWalletSigners) != 0 {
+		if _, err := c.tx.TokenRequest.BindSummary(); err != nil {
+			return nil, errors.WithMessage(err, "failed binding summary to the token request")
+		}
+	}
+
+	// Record that the transaction has been assembled.
+	// The transaction is stored so that, if not approved yet on restart, its parties can be told it has been aborted.
+	if err := recordStep(context, c.tx, Assembled, true, ""); err != nil {
+		return nil, errors.WithMessage(err, "failed recording assembled transaction")
+	}
 
 	externalWallets := make(map[string]ExternalWalletSigner)
 	// 1. First collect signatures on the token request
@@ -104,33 +215,79 @@ func (c *CollectEndorsementsView) Call(context view.Context) (interface{}, error
 	// Add the signatures to the token request
 	c.tx.TokenRequest.SetSignatures(mergeSigmas(issueSigmas, transferSigmas))
 
-	// 2. Audit
-	var auditors []view.Identity
+	state := &endorsementState{}
 	if !c.Opts.SkipAuditing {
-		auditors, err = c.requestAudit(context)
+		state.asyncAuditing, err = c.isAsyncAuditing()
 		if err != nil {
-			return nil, errors.WithMessage(err, "failed requesting auditing")
+			return nil, errors.WithMessage(err, "failed checking auditing policy")
 		}
 	}
+	// Record the auditor the transaction is about to be submitted to, if any,
+	// so that it can be told if the transaction is aborted on restart.
+	if err := c.recordSignaturesCollected(context, !c.Opts.SkipAuditing && !state.asyncAuditing); err != nil {
+		return nil, errors.WithMessage(err, "failed recording signatures")
+	}
 
-	// 3. Endorse and return the transaction envelope
-	var env *network.Envelope
-	if !c.Opts.SkipApproval {
-		env, err = c.requestApproval(context)
-		if err != nil {
-			return nil, errors.WithMessage(err, "failed requesting approval")
+	// 2. Audit
+	if !c.Opts.SkipAuditing {
+		if !state.asyncAuditing {
+			state.auditors, err = c.requestAudit(context)
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed requesting auditing")
+			}
+			if len(state.auditors) != 0 {
+				if err := recordStep(context, c.tx, Audited, false, ""); err != nil {
+					return nil, errors.WithMessage(err, "failed recording auditing")
+				}
+			}
 		}
 	}
+	return state, nil
+}
+
+// recordSignaturesCollected records the SignaturesCollected step of the transaction.
+// If the transaction is going to be audited, the step carries the identity of the auditor.
+func (c *CollectEndorsementsView) recordSignaturesCollected(context view.Context, audit bool) error {
+	var auditor view.Identity
+	if audit && len(c.tx.TokenService().PublicParametersManager().PublicParameters().Auditors()) != 0 {
+		auditor = c.tx.Opts.Auditor
+	}
+	return addStep(context, c.tx.TMSID(), c.tx.ID(), SignaturesCollected, auditor, "")
+}
+
+// distribute sends the approved transaction to all the parties and completes the auditing
+func (c *CollectEndorsementsView) distribute(context view.Context, env *network.Envelope, state *endorsementState) error {
+	metrics := GetMetrics(context)
 
 	// Distribute Env to all parties
 	distributionList := append(IssueDistributionList(c.tx.TokenRequest), TransferDistributionList(c.tx.TokenRequest)...)
-	if err := c.distributeEnvToParties(context, env, distributionList, auditors); err != nil {
-		return nil, errors.WithMessage(err, "failed distributing envelope")
+	if err := c.distributeEnvToParties(context, env, distributionList, state.auditors); err != nil {
+		return errors.WithMessage(err, "failed distributing envelope")
+	}
+	// From now on, the transaction can be broadcast even if this node stops.
+	// If the approval has been skipped, the application is in charge of it and of the broadcast.
+	approvedMessage := ""
+	if c.Opts.SkipApproval {
+		approvedMessage = ApprovalSkippedMessage
+	}
+	if err := recordStep(context, c.tx, Approved, true, approvedMessage); err != nil {
+		return errors.WithMessage(err, "failed recording approved transaction")
 	}
 
-	// Cleanup audit
-	if err := c.cleanupAudit(context); err != nil {
-		return nil, errors.WithMessage(err, "failed cleaning up audit")
+	if state.asyncAuditing {
+		// Submit the transaction to the auditor once committed
+		net := network.GetInstance(context, c.tx.Network(), c.tx.Channel())
+		if net == nil {
+			return errors.Errorf("failed getting network [%s:%s]", c.tx.Network(), c.tx.Channel())
+		}
+		if err := net.AddFinalityListener(c.tx.Namespace(), c.tx.ID(), newAsyncAuditingListener(context, c.tx)); err != nil {
+			return errors.WithMessagef(err, "failed listening to the finality of [%s]", c.tx.ID())
+		}
+	} else {
+		// Cleanup audit
+		if err := c.cleanupAudit(context); err != nil {
+			return errors.WithMessage(err, "failed cleaning up audit")
+		}
 	}
 
 	if logger.IsEnabledFor(zapcore.DebugLevel) {
@@ -143,7 +300,7 @@ func (c *CollectEndorsementsView) Call(context view.Context) (interface{}, error
 		"namespace", c.tx.Namespace(),
 	}
 	metrics.EndorsedTransactions.With(labels...).Add(1)
-	return nil, nil
+	return nil
 }
 
 func (c *CollectEndorsementsView) requestSignaturesOnIssues(context view.Context, externalWallets map[string]ExternalWalletSigner) (map[string][]byte, error) {
@@ -171,6 +328,15 @@ func (c *CollectEndorsementsView) requestSignatures(signers []view.Identity, ver
 		return nil, err
 	}
 
+	summary, err := c.tx.TokenRequest.Summary()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed summarizing token request")
+	}
+	summaryRaw, err := summary.Bytes()
+	if err != nil {
+		return nil, err
+	}
+
 	sigmas := make(map[string][]byte)
 	for _, party := range signers {
 		signatureRequest := &SignatureRequest{
@@ -178,6 +344,7 @@ func (c *CollectEndorsementsView) requestSignatures(signers []view.Identity, ver
 			Request: requestRaw,
 			TxID:    []byte(c.tx.ID()),
 			Signer:  party,
+			Summary: summaryRaw,
 		}
 		if logger.IsEnabledFor(zapcore.DebugLevel) {
 			logger.Debugf("collecting signature on request from [%s]", party.UniqueID())
@@ -211,7 +378,7 @@ func (c *CollectEndorsementsView) requestSignatures(signers []view.Identity, ver
 				return nil, errors.Errorf("no external wallet signer found for [%s][%s]", w.ID(), party)
 			}
 			externalWallets[w.ID()] = ews
-			sigma, err := c.signExternal(party, ews, signatureRequest)
+			sigma, err := c.signExternal(party, ews, signatureRequest, summary)
 			if err != nil {
 				return nil, errors.WithMessagef(err, "failed signing external for party [%s]", party)
 			}
@@ -253,12 +420,27 @@ func (c *CollectEndorsementsView) signLocal(party view.Identity, signer token.Si
 	return sigma, nil
 }
 
-func (c *CollectEndorsementsView) signExternal(party view.Identity, signer ExternalWalletSigner, signatureRequest *SignatureRequest) ([]byte, error) {
+func (c *CollectEndorsementsView) signExternal(party view.Identity, signer ExternalWalletSigner, signatureRequest *SignatureRequest, summary *token.RequestSummary) ([]byte, error) {
 	if logger.IsEnabledFor(zapcore.DebugLevel) {
 		logger.Debugf("signing [%s][%s]", hash.Hashable(signatureRequest.Request).String(), c.tx.ID())
 		logger.Debugf("signing tx-id [%s,nonce=%s]", c.tx.ID(), base64.StdEncoding.EncodeToString(c.tx.TxID.Nonce))
 	}
-	sigma, err := signer.Sign(party, signatureRequest.MessageToSign())
+	// the external signer can only check the summary, which must then be bound to the message to sign
+	if err := summary.VerifyMessage(signatureRequest.MessageToSign()); err != nil {
+		return nil, errors.WithMessagef(err, "summary not bound to the message to sign")
+	}
+	var sigma []byte
+	var err error
+	if requestSigner, ok := signer.(ExternalWalletRequestSigner); ok {
+		sigma, err = requestSigner.SignRequest(&ExternalWalletSignRequest{
+			TxID:    c.tx.ID(),
+			Party:   party,
+			Message: signatureRequest.MessageToSign(),
+			Summary: summary,
+		})
+	} else {
+		sigma, err = signer.Sign(party, signatureRequest.MessageToSign())
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -361,6 +543,38 @@ func (c *CollectEndorsementsView) requestAudit(context view.Context) ([]view.Ide
 	return nil, nil
 }
 
+// isAsyncAuditing returns true if the public parameters allow to commit the transaction without the auditor's signature
+// and the auditing policy requires asynchronous auditing for all the token types in the transaction.
+func (c *CollectEndorsementsView) isAsyncAuditing() (bool, error) {
+	pp := c.tx.TokenService().PublicParametersManager().PublicParameters()
+	if len(pp.Auditors()) == 0 || !pp.AsyncAuditing() || c.tx.Opts.Auditor.IsNone() {
+		return false, nil
+	}
+	policy, err := GetAuditingPolicy(c.tx.TokenService())
+	if err != nil {
+		return false, err
+	}
+	inputs, outputs, err := c.tx.InputsAndOutputs()
+	if err != nil {
+		return false, errors.WithMessagef(err, "failed getting inputs and outputs of [%s]", c.tx.ID())
+	}
+	tokenTypes := append(inputs.TokenTypes(), outputs.TokenTypes()...)
+	async := policy.IsAsync(tokenTypes...)
+	if allowed := pp.AsyncAuditingTypes(); async && len(allowed) != 0 {
+		// the validators reject a request without the auditor's signature moving other token types
+		for _, tokenType := range tokenTypes {
+			if !slices.Contains(allowed, tokenType) {
+				async = false
+				break
+			}
+		}
+	}
+	if logger.IsEnabledFor(zapcore.DebugLevel) {
+		logger.Debugf("auditing of [%s] with token types [%v] is asynchronous [%v]", c.tx.ID(), tokenTypes, async)
+	}
+	return async, nil
+}
+
 func (c *CollectEndorsementsView) cleanupAudit(context view.Context) error {
 	if !c.tx.Opts.Auditor.IsNone() {
 		session, err := c.getSession(context, c.tx.Opts.Auditor)
@@ -407,6 +621,17 @@ func (c *CollectEndorsementsView) distributeEnvToParties(context view.Context, e
 		return errors.Wrapf(err, "failed adding transaction %s to the token transaction database", c.tx.ID())
 	}
 
+	config := c.Opts.Distribution
+	if config == nil {
+		config, err = GetDistributionConfig(c.tx.TokenService())
+		if err != nil {
+			return err
+		}
+	} else {
+		config = config.withDefaults()
+	}
+
+	var jobs []*distributionJob
 	for _, entry := range finalDistributionList {
 		if logger.IsEnabledFor(zapcore.DebugLevel) {
 			logger.Debugf("distribute transaction envelope to [%s]", entry.ID.UniqueID())
@@ -447,55 +672,82 @@ func (c *CollectEndorsementsView) distributeEnvToParties(context view.Context, e
 			}
 		}
 
-		retryRunner := utils.NewRetryRunner(3, 100*time.Millisecond, true)
-		if err := retryRunner.RunWithErrors(func() (bool, error) {
-			err := c.distributeEvnToParty(context, &entry, txRaw, owner)
-			return err == nil, err
-		}); err != nil {
-			return errors.Wrapf(err, "failed distribute evn to party [%s]", entry.ID)
+		jobs = append(jobs, &distributionJob{entry: entry, txRaw: txRaw})
+	}
+
+	// The jobs may outlive this view, when config.MinAcks is set.
+	// They do not use the view context: the sessions are opened and the signature service is fetched upfront.
+	// If the view fails, the jobs still running are cancelled and their acknowledgements dropped.
+	sessions := map[string]view.Session{}
+	sessionErrs := map[string]error{}
+	for _, job := range jobs {
+		session, err := c.getSession(context, job.entry.ID)
+		if err != nil {
+			sessionErrs[job.entry.ID.UniqueID()] = errors.Wrap(err, "failed getting session")
+			continue
 		}
+		sessions[job.entry.ID.UniqueID()] = session
 	}
+	sigService := view2.GetSigService(context)
+	ctx, cancel := context2.WithCancel(context.Context())
+	context.OnError(cancel)
 
-	return nil
+	// Distribute concurrently and record the outcome for each party.
+	// The acknowledgement is stored together with the outcome.
+	return distributeConcurrently(ctx, jobs, config, func(ctx context2.Context, job *distributionJob) ([]byte, error) {
+		if err := sessionErrs[job.entry.ID.UniqueID()]; err != nil {
+			return nil, err
+		}
+		return distributeEnvToParty(ctx, sessions[job.entry.ID.UniqueID()], sigService, &job.entry, job.txRaw, config.Timeout)
+	}, func(outcome *distributionOutcome) {
+		if outcome.err == nil {
+			if err := owner.appendTransactionEndorseAck(c.tx, outcome.job.entry.LongTerm, outcome.sigma); err != nil {
+				outcome.err = errors.Wrapf(err, "failed appending transaction endorsement ack to transaction %s", c.tx.ID())
+			}
+		}
+		record := &DistributionResultRecord{
+			TxID:         c.tx.ID(),
+			Party:        outcome.job.entry.LongTerm,
+			Acknowledged: outcome.err == nil,
+			Attempts:     outcome.attempts,
+			Timestamp:    time.Now(),
+		}
+		if outcome.err != nil {
+			record.Error = outcome.err.Error()
+		}
+		if err := owner.appendDistributionResult(record); err != nil {
+			logger.Warnf("failed recording distribution of [%s] to [%s]: [%s]", c.tx.ID(), outcome.job.entry.ID, err)
+		}
+	})
 }
 
-func (c *CollectEndorsementsView) distributeEvnToParty(context view.Context, entry *distributionListEntry, txRaw []byte, owner *TxOwner) error {
-	// Open a session to the party. and send the transaction.
-	session, err := c.getSession(context, entry.ID)
-	if err != nil {
-		return errors.Wrap(err, "failed getting session")
-	}
+// distributeEnvToParty sends the transaction to the party on the passed session and returns its verified acknowledgement
+func distributeEnvToParty(ctx context2.Context, session view.Session, sigService *view2.SigService, entry *distributionListEntry, txRaw []byte, timeout time.Duration) ([]byte, error) {
 	// Send the content
-	err = session.SendWithContext(context.Context(), txRaw)
-	if err != nil {
-		return errors.Wrap(err, "failed sending transaction content")
+	if err := session.SendWithContext(ctx, txRaw); err != nil {
+		return nil, errors.Wrap(err, "failed sending transaction content")
 	}
 
-	sigma, err := ReadMessage(session, time.Minute*4)
+	sigma, err := readMessage(ctx, session, timeout)
 	if err != nil {
-		return errors.Wrap(err, "failed reading message")
+		return nil, errors.Wrap(err, "failed reading message")
 	}
 	logger.Debugf("received ack from [%s] [%s], checking signature on [%s]",
 		entry.LongTerm, hash.Hashable(sigma).String(),
 		hash.Hashable(txRaw).String())
 
-	verifier, err := view2.GetSigService(context).GetVerifier(entry.LongTerm)
+	verifier, err := sigService.GetVerifier(entry.LongTerm)
 	if err != nil {
-		return errors.Wrapf(err, "failed getting verifier for [%s]", entry.ID)
+		return nil, errors.Wrapf(err, "failed getting verifier for [%s]", entry.ID)
 	}
 	if err := verifier.Verify(txRaw, sigma); err != nil {
-		return errors.Wrapf(err, "failed verifying ack signature from [%s]", entry.ID)
+		return nil, errors.Wrapf(err, "failed verifying ack signature from [%s]", entry.ID)
 	}
 
 	if logger.IsEnabledFor(zapcore.DebugLevel) {
 		logger.Debugf("CollectEndorsementsView: collected signature from %s", entry.ID)
 	}
-
-	if err := owner.appendTransactionEndorseAck(c.tx, entry.LongTerm, sigma); err != nil {
-		return errors.Wrapf(err, "failed appending transaction endorsement ack to transaction %s", c.tx.ID())
-	}
-
-	return nil
+	return sigma, nil
 }
 
 func (c *CollectEndorsementsView) prepareDistributionList(context view.Context, auditors []view.Identity, distributionList []view.Identity) ([]distributionListEntry, error) {
@@ -678,6 +930,10 @@ func (f *ReceiveTransactionView) unmarshalAsSignatureRequest(context view.Contex
 
 type EndorseView struct {
 	tx *Transaction
+	// expectedRequest, if not nil, is the only token request the view signs
+	expectedRequest []byte
+	// receivePolicy, if not nil, overrides the receive policy configured for the TMS
+	receivePolicy ReceivePolicy
 }
 
 // NewEndorseView returns an instance of the endorseView.
@@ -686,9 +942,13 @@ type EndorseView struct {
 // 2. Upon receiving a signature request, it validates the request and send back the requested signature.
 // 3. After, it waits to receive the Transaction. The Transaction is validated and stored locally
 // to be processed at time of committing.
-// 4. It sends back an ack.
-func NewEndorseView(tx *Transaction) *EndorseView {
-	return &EndorseView{tx: tx}
+// 4. It sends back an ack, if the transaction satisfies the receive policy.
+func NewEndorseView(tx *Transaction, opts ...EndorsementsOpt) *EndorseView {
+	options, err := CompileCollectEndorsementsOpts(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return &EndorseView{tx: tx, receivePolicy: options.ReceivePolicy}
 }
 
 // Call executes the view.
@@ -699,11 +959,22 @@ func NewEndorseView(tx *Transaction) *EndorseView {
 // to be processed at time of committing.
 // 4. It sends back an ack.
 func (s *EndorseView) Call(context view.Context) (interface{}, error) {
+	if err := s.sign(context); err != nil {
+		return nil, err
+	}
+	if err := s.receive(context); err != nil {
+		return nil, err
+	}
+	return s.tx, nil
+}
+
+// sign answers the signature requests on the token request
+func (s *EndorseView) sign(context view.Context) error {
 	// Process signature requests
 	logger.Debugf("chec expected numer of requests to sign for txid [%s]", s.tx.ID())
 	requestsToBeSigned, err := requestsToBeSigned(s.tx.Request())
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed collecting requests of signature")
+		return errors.Wrapf(err, "failed collecting requests of signature")
 	}
 
 	logger.Debugf("expect [%d] requests to sign for txid [%s]", len(requestsToBeSigned), s.tx.ID())
@@ -716,45 +987,71 @@ func (s *EndorseView) Call(context view.Context) (interface{}, error) {
 
 		msg, err := ReadMessage(session, time.Minute)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed receiving signature response")
+			return errors.Wrapf(err, "failed receiving signature response")
 		}
 
 		// TODO: check what is signed...
 		signatureRequest := &SignatureRequest{}
 		if err := Unmarshal(msg, signatureRequest); err != nil {
-			return nil, errors.Wrapf(err, "failed unmarshalling signature request, got [%s]", string(msg))
+			return errors.Wrapf(err, "failed unmarshalling signature request, got [%s]", string(msg))
+		}
+
+		if s.expectedRequest != nil && !bytes.Equal(s.expectedRequest, signatureRequest.Request) {
+			return errors.Errorf("signature requested on a token request different from the one of transaction [%s]", s.tx.ID())
+		}
+		if err := signatureRequest.VerifySummary(context); err != nil {
+			return errors.WithMessagef(err, "refusing to sign")
 		}
 
 		sigService := s.tx.TokenService().SigService()
 		if !sigService.IsMe(signatureRequest.Signer) {
-			return nil, errors.Errorf("identity [%s] is not me", signatureRequest.Signer.UniqueID())
+			return errors.Errorf("identity [%s] is not me", signatureRequest.Signer.UniqueID())
 		}
 		signer, err := sigService.GetSigner(signatureRequest.Signer)
 		if err != nil {
-			return nil, errors.Wrapf(err, "cannot find signer for [%s]", signatureRequest.Signer.UniqueID())
+			return errors.Wrapf(err, "cannot find signer for [%s]", signatureRequest.Signer.UniqueID())
 		}
 		sigma, err := signer.Sign(signatureRequest.MessageToSign())
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed signing request")
+			return errors.Wrapf(err, "failed signing request")
 		}
 		if logger.IsEnabledFor(zapcore.DebugLevel) {
 			logger.Debugf("Send back signature [%s][%s]", signatureRequest.Signer, hash.Hashable(sigma))
 		}
 		err = session.SendWithContext(context.Context(), sigma)
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed sending signature back")
+			return errors.Wrapf(err, "failed sending signature back")
 		}
 	}
 
+	return nil
+}
+
+// receive receives the approved transaction, stores it, and sends back an acknowledgement
+func (s *EndorseView) receive(context view.Context) error {
+	session := context.Session()
+
 	// Receive transaction with envelope
 	_, rawRequest, err := s.receiveTransaction(context)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed receiving transaction")
+		return errors.Wrapf(err, "failed receiving transaction")
+	}
+
+	if err := checkTravelRule(s.tx); err != nil {
+		return errors.WithMessage(err, "invalid travel-rule information")
+	}
+	if err := checkReceivePolicy(context, s.tx, s.receivePolicy); err != nil {
+		return err
+	}
+
+	// Record the sender of the transaction, it is the only node allowed to cancel it
+	if err := recordReceived(context, s.tx, session.Info().Caller); err != nil {
+		return errors.WithMessagef(err, "failed recording sender of %s", s.tx.ID())
 	}
 
 	// Store transaction in the token transaction database
 	if err := StoreTransactionRecords(context, s.tx); err != nil {
-		return nil, errors.Wrapf(err, "failed storing transaction records %s", s.tx.ID())
+		return errors.Wrapf(err, "failed storing transaction records %s", s.tx.ID())
 	}
 
 	// Send back an acknowledgement
@@ -763,29 +1060,28 @@ func (s *EndorseView) Call(context view.Context) (interface{}, error) {
 	}
 	signer, err := view2.GetSigService(context).GetSigner(view2.GetIdentityProvider(context).DefaultIdentity())
 	if err != nil {
-		return nil, errors.WithMessagef(err, "failed to get signer for default identity")
+		return errors.WithMessagef(err, "failed to get signer for default identity")
 	}
 	sigma, err := signer.Sign(rawRequest)
 	if err != nil {
-		return nil, errors.WithMessage(err, "failed to sign ack response")
+		return errors.WithMessage(err, "failed to sign ack response")
 	}
 	if logger.IsEnabledFor(zapcore.DebugLevel) {
 		logger.Debugf("ack response: [%s] from [%s]", hash.Hashable(sigma), view2.GetIdentityProvider(context).DefaultIdentity())
 	}
 	if err := session.SendWithContext(context.Context(), sigma); err != nil {
-		return nil, errors.WithMessage(err, "failed sending ack")
+		return errors.WithMessage(err, "failed sending ack")
 	}
 
 	// cache the token request into the tokens db
 	t, err := tokens.GetService(context, s.tx.TMSID())
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get tokens db for [%s]", s.tx.TMSID())
+		return errors.Wrapf(err, "failed to get tokens db for [%s]", s.tx.TMSID())
 	}
 	if err := t.CacheRequest(s.tx.TMSID(), s.tx.TokenRequest); err != nil {
 		logger.Warnf("failed to cache token request [%s], this might cause delay, investigate when possible: [%s]", s.tx.TokenRequest.Anchor, err)
 	}
-
-	return s.tx, nil
+	return nil
 }
 
 func (s *EndorseView) receiveTransaction(context view.Context) (*Transaction, []byte, error) {
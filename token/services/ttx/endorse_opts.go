@@ -16,6 +16,12 @@ type EndorsementsOpts struct {
 	SkipDistributeEnv bool
 	// External Signers
 	ExternalWalletSigners map[string]ExternalWalletSigner
+	// ReceivePolicy decides whether the transactions paying this node are accepted.
+	// If nil, the policy configured for the TMS is used.
+	ReceivePolicy ReceivePolicy
+	// Distribution configures the distribution of the approved transaction to the parties.
+	// If nil, the configuration of the TMS is used.
+	Distribution *DistributionConfig
 }
 
 func (o *EndorsementsOpts) ExternalWalletSigner(id string) ExternalWalletSigner {
@@ -72,3 +78,19 @@ func WithExternalWalletSigner(walletID string, ews ExternalWalletSigner) Endorse
 		return nil
 	}
 }
+
+// WithReceivePolicy sets the policy deciding whether a recipient accepts the transaction
+func WithReceivePolicy(policy ReceivePolicy) EndorsementsOpt {
+	return func(o *EndorsementsOpts) error {
+		o.ReceivePolicy = policy
+		return nil
+	}
+}
+
+// WithDistributionConfig sets how the approved transaction is distributed to the parties
+func WithDistributionConfig(config *DistributionConfig) EndorsementsOpt {
+	return func(o *EndorsementsOpts) error {
+		o.Distribution = config
+		return nil
+	}
+}
@@ -0,0 +1,28 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignatureRequestWithoutSummary(t *testing.T) {
+	// a token request not bound to a summary has nothing to verify when no summary is sent
+	request, err := (&driver.TokenRequest{Transfers: [][]byte{[]byte("transfer")}}).Bytes()
+	assert.NoError(t, err)
+	sr := &SignatureRequest{Request: request, TxID: []byte("tx1")}
+	assert.NoError(t, sr.VerifySummary(nil))
+	summary, err := sr.RequestSummary()
+	assert.NoError(t, err)
+	assert.Nil(t, summary)
+
+	sr = &SignatureRequest{Request: []byte("not a token request"), TxID: []byte("tx1")}
+	assert.ErrorContains(t, sr.VerifySummary(nil), "failed unmarshalling the token request to sign")
+}
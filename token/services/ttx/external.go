@@ -198,3 +198,42 @@ func (s *StreamExternalWalletSignerClient) sign(req *StreamExternalWalletSignReq
 		Sigma: sigma,
 	})
 }
+
+// ExternalWalletSignRequest is a request of signature addressed to an external wallet.
+// Besides the message to sign, it carries a summary of the token request the signature is for,
+// so that the wallet can show to its user what is being approved.
+type ExternalWalletSignRequest struct {
+	// TxID is the id of the transaction the signature is for
+	TxID string
+	// Party is the identity that must sign
+	Party view.Identity
+	// Message is the message to sign
+	Message []byte
+	// Summary describes the token request the signature is for
+	Summary *token.RequestSummary
+}
+
+// ExternalWalletRequestSigner is an ExternalWalletSigner that receives the whole signature request,
+// not just the message to sign.
+// When the external wallet signer implements this interface, SignRequest is used in place of Sign.
+type ExternalWalletRequestSigner interface {
+	ExternalWalletSigner
+	SignRequest(request *ExternalWalletSignRequest) ([]byte, error)
+}
+
+// RequestSummary is the summary of a token request carried by an ExternalWalletSignRequest.
+//
+// Deprecated: use token.RequestSummary.
+type RequestSummary = token.RequestSummary
+
+// TokenSummary is a token listed in a RequestSummary.
+//
+// Deprecated: use token.SummaryToken.
+type TokenSummary = token.SummaryToken
+
+// NewRequestSummary returns the summary of the passed token request.
+//
+// Deprecated: use token.Request#Summary.
+func NewRequestSummary(request *token.Request) (*RequestSummary, error) {
+	return request.Summary()
+}
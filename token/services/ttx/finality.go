@@ -112,6 +112,7 @@ func (f *finalityView) call(ctx view.Context, txID string, tmsID token.TMSID, ti
 	if statusTTXDB != ttxdb.Unknown {
 		span.AddEvent("request_ttxdb_finality")
 		index, err = f.dbFinality(c, txID, transactionDB, index, iterations)
+		recordFinalStep(transactionDB, txID)
 		if err != nil {
 			return nil, err
 		}
@@ -168,6 +169,10 @@ func (f *finalityView) dbFinality(c context.Context, txID string, finalityDB fin
 				logger.Debugf("Got an answer to finality of [%s]: [%s]", txID, event)
 			}
 			timeout.Stop()
+			if event.ValidationCode == ttxdb.Pending {
+				// not final yet
+				break
+			}
 			if event.ValidationCode == ttxdb.Confirmed {
 				return i, nil
 			}
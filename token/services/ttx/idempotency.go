@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+// Submission describes a transaction previously created with the same idempotency key
+type Submission struct {
+	// IdempotencyKey is the key the transaction is bound to
+	IdempotencyKey string
+	// TxID is the transaction id
+	TxID string
+	// Step is the last step of the lifecycle reached by the transaction, UnknownStep if none has been recorded
+	Step TransactionStep
+	// StepMessage is the message of the last step, for instance the reason of an abort
+	StepMessage string
+	// Status is the status of the token request of the transaction, Unknown if the transaction has not been submitted
+	Status TxStatus
+	// StatusMessage is the message attached to the status
+	StatusMessage string
+}
+
+// Submitted returns true if the transaction has been broadcast or its token request has a status,
+// meaning that the transaction must not be submitted again
+func (s *Submission) Submitted() bool {
+	return s.Status != Unknown || s.Step == Broadcast || s.Step == Final
+}
+
+// PreviousSubmission returns the description of the transaction previously created with the same idempotency key,
+// nil if the transaction has been created by this call.
+// When not nil, the transaction has been restored from the ttxdb and cannot be modified:
+// Issue, Transfer, Redeem, BatchPayout and CollectEndorsementsView fail.
+func (t *Transaction) PreviousSubmission() *Submission {
+	return t.previous
+}
+
+// checkModifiable returns an error if the transaction has been restored for its idempotency key
+func (t *Transaction) checkModifiable() error {
+	if t.previous != nil {
+		return errors.Errorf("transaction [%s] has been restored for idempotency key [%s], it cannot be modified", t.previous.TxID, t.previous.IdempotencyKey)
+	}
+	return nil
+}
+
+// idempotencyDB models the ttxdb operations the idempotent transactions rely on
+type idempotencyDB interface {
+	IdempotencyKey(key string) (*ttxdb.IdempotencyKeyRecord, error)
+	AddIdempotencyKey(key string, txID string, nonce []byte, creator []byte) (*ttxdb.IdempotencyKeyRecord, error)
+	TransactionSteps(txID string) ([]*ttxdb.TransactionStepRecord, error)
+	GetStatus(txID string) (TxStatus, string, error)
+}
+
+// newIdempotentTransaction returns the transaction bound to the idempotency key in the passed options.
+// If the key is unknown, a new transaction is created and bound to the key.
+func newIdempotentTransaction(context view.Context, tms *token.ManagementService, signer view.Identity, txOpts *TxOptions) (*Transaction, error) {
+	db, err := ttxdb.GetByTMSId(context, tms.ID())
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting ttxdb for [%s]", tms.ID())
+	}
+	return idempotentTransaction(db, txOpts.IdempotencyKey, func() (*Transaction, error) {
+		return newTransaction(context, tms, signer, txOpts)
+	}, func(record *ttxdb.IdempotencyKeyRecord, raw []byte) (*Transaction, error) {
+		return restoreTransaction(context, tms, record, raw, txOpts)
+	})
+}
+
+// idempotentTransaction returns the transaction bound to the passed key.
+// If the key is unknown, the transaction returned by create is bound to the key.
+// Otherwise, the bound transaction is rebuilt by restore, from its last stored payload if any,
+// and carries the description of its previous submission.
+func idempotentTransaction(db idempotencyDB, key string, create func() (*Transaction, error), restore func(record *ttxdb.IdempotencyKeyRecord, raw []byte) (*Transaction, error)) (*Transaction, error) {
+	record, err := db.IdempotencyKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		tx, err := create()
+		if err != nil {
+			return nil, err
+		}
+		record, err = db.AddIdempotencyKey(key, tx.ID(), tx.TxID.Nonce, tx.TxID.Creator)
+		if err != nil {
+			return nil, err
+		}
+		if record.TxID == tx.ID() {
+			return tx, nil
+		}
+		// the key has been bound concurrently to another transaction, this one has not been used yet
+		logger.Debugf("idempotency key [%s] bound concurrently to [%s], discarding [%s]", key, record.TxID, tx.ID())
+	}
+
+	steps, err := db.TransactionSteps(record.TxID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting steps of [%s]", record.TxID)
+	}
+	status, statusMessage, err := db.GetStatus(record.TxID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting status of [%s]", record.TxID)
+	}
+	previous, raw := previousSubmission(record, steps, status, statusMessage)
+	logger.Debugf("idempotency key [%s] bound to [%s], step [%s], status [%s]", record.Key, record.TxID, ttxdb.TransactionStepMessage[previous.Step], TxStatusMessage[previous.Status])
+	tx, err := restore(record, raw)
+	if err != nil {
+		return nil, err
+	}
+	tx.previous = previous
+	return tx, nil
+}
+
+// restoreTransaction returns the transaction bound to the passed idempotency key record.
+// The transaction is restored from the passed payload, the last one stored in its lifecycle, if any.
+// Otherwise, a transaction with the same id is created.
+func restoreTransaction(context view.Context, tms *token.ManagementService, record *ttxdb.IdempotencyKeyRecord, raw []byte, txOpts *TxOptions) (*Transaction, error) {
+	if len(raw) == 0 {
+		opts := *txOpts
+		opts.NetworkTxID = network.TxID{Nonce: record.Nonce, Creator: record.Creator}
+		tx, err := newTransaction(context, tms, nil, &opts)
+		if err != nil {
+			return nil, err
+		}
+		if tx.ID() != record.TxID {
+			return nil, errors.Errorf("failed recreating transaction [%s], got [%s]", record.TxID, tx.ID())
+		}
+		return tx, nil
+	}
+
+	networkProvider := network.GetProvider(context).GetNetwork
+	tx := &Transaction{
+		Payload: &Payload{
+			Transient:    map[string][]byte{},
+			TokenRequest: token.NewRequest(nil, ""),
+		},
+		TMS:             tms,
+		NetworkProvider: networkProvider,
+		Opts:            txOpts,
+		Context:         context.Context(),
+	}
+	if err := unmarshal(networkProvider, tx.Payload, raw); err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling transaction [%s]", record.TxID)
+	}
+	tx.TokenRequest.SetTokenService(tms)
+	return tx, nil
+}
+
+// previousSubmission describes the transaction bound to the passed record, given its steps and status.
+// It returns also the last transaction payload stored among the steps, if any.
+func previousSubmission(record *ttxdb.IdempotencyKeyRecord, steps []*ttxdb.TransactionStepRecord, status TxStatus, statusMessage string) (*Submission, []byte) {
+	s := &Submission{
+		IdempotencyKey: record.Key,
+		TxID:           record.TxID,
+		Status:         status,
+		StatusMessage:  statusMessage,
+	}
+	var raw []byte
+	for _, step := range steps {
+		s.Step = step.Step
+		s.StepMessage = step.Message
+		// the payload of a distributed step is the identity of the sender
+		if step.Step != Distributed && len(step.Payload) != 0 {
+			raw = step.Payload
+		}
+	}
+	return s, raw
+}
@@ -0,0 +1,181 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviousSubmission(t *testing.T) {
+	record := &ttxdb.IdempotencyKeyRecord{Key: "key1", TxID: "tx1"}
+
+	// no step recorded yet
+	s, raw := previousSubmission(record, nil, Unknown, "")
+	assert.Equal(t, &Submission{IdempotencyKey: "key1", TxID: "tx1"}, s)
+	assert.Nil(t, raw)
+	assert.False(t, s.Submitted())
+
+	// the last payload wins
+	steps := []*ttxdb.TransactionStepRecord{
+		{TxID: "tx1", Step: Assembled, Payload: []byte("assembled")},
+		{TxID: "tx1", Step: SignaturesCollected},
+		{TxID: "tx1", Step: Approved, Payload: []byte("approved")},
+	}
+	s, raw = previousSubmission(record, steps, Unknown, "")
+	assert.Equal(t, Approved, s.Step)
+	assert.Equal(t, []byte("approved"), raw)
+	assert.False(t, s.Submitted())
+
+	steps = append(steps, &ttxdb.TransactionStepRecord{TxID: "tx1", Step: Broadcast})
+	s, raw = previousSubmission(record, steps, Unknown, "")
+	assert.Equal(t, Broadcast, s.Step)
+	assert.Equal(t, []byte("approved"), raw)
+	assert.True(t, s.Submitted())
+
+	s, _ = previousSubmission(record, steps, Deleted, "invalid")
+	assert.Equal(t, Deleted, s.Status)
+	assert.Equal(t, "invalid", s.StatusMessage)
+	assert.True(t, s.Submitted())
+
+	// aborted transactions report the reason
+	s, raw = previousSubmission(record, []*ttxdb.TransactionStepRecord{
+		{TxID: "tx1", Step: Assembled, Payload: []byte("assembled")},
+		{TxID: "tx1", Step: Aborted, Message: "no funds"},
+	}, Unknown, "")
+	assert.Equal(t, Aborted, s.Step)
+	assert.Equal(t, "no funds", s.StepMessage)
+	assert.Equal(t, []byte("assembled"), raw)
+	assert.False(t, s.Submitted())
+}
+
+// idempotencyDBMock is an in-memory idempotencyDB
+type idempotencyDBMock struct {
+	lock   sync.Mutex
+	keys   map[string]*ttxdb.IdempotencyKeyRecord
+	steps  map[string][]*ttxdb.TransactionStepRecord
+	status map[string]TxStatus
+}
+
+func newIdempotencyDBMock() *idempotencyDBMock {
+	return &idempotencyDBMock{
+		keys:   map[string]*ttxdb.IdempotencyKeyRecord{},
+		steps:  map[string][]*ttxdb.TransactionStepRecord{},
+		status: map[string]TxStatus{},
+	}
+}
+
+func (d *idempotencyDBMock) IdempotencyKey(key string) (*ttxdb.IdempotencyKeyRecord, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.keys[key], nil
+}
+
+func (d *idempotencyDBMock) AddIdempotencyKey(key string, txID string, nonce []byte, creator []byte) (*ttxdb.IdempotencyKeyRecord, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if record, ok := d.keys[key]; ok {
+		return record, nil
+	}
+	d.keys[key] = &ttxdb.IdempotencyKeyRecord{Key: key, TxID: txID, Nonce: nonce, Creator: creator}
+	return d.keys[key], nil
+}
+
+func (d *idempotencyDBMock) TransactionSteps(txID string) ([]*ttxdb.TransactionStepRecord, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.steps[txID], nil
+}
+
+func (d *idempotencyDBMock) GetStatus(txID string) (TxStatus, string, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.status[txID], "", nil
+}
+
+func idempotencyTx(id string) *Transaction {
+	return &Transaction{Payload: &Payload{ID: id, TxID: network.TxID{Nonce: []byte(id), Creator: []byte("creator")}}}
+}
+
+func TestIdempotentTransactionRestore(t *testing.T) {
+	db := newIdempotencyDBMock()
+	create := func() (*Transaction, error) { return idempotencyTx("tx1"), nil }
+	var restored []byte
+	restore := func(record *ttxdb.IdempotencyKeyRecord, raw []byte) (*Transaction, error) {
+		assert.Equal(t, []byte("tx1"), record.Nonce)
+		restored = raw
+		return idempotencyTx(record.TxID), nil
+	}
+
+	tx, err := idempotentTransaction(db, "key1", create, restore)
+	assert.NoError(t, err)
+	assert.Equal(t, "tx1", tx.ID())
+	assert.Nil(t, tx.PreviousSubmission())
+	assert.NoError(t, tx.checkModifiable())
+
+	// the transaction is restored from the last stored payload
+	db.steps["tx1"] = []*ttxdb.TransactionStepRecord{
+		{TxID: "tx1", Step: Assembled, Payload: []byte("assembled")},
+		{TxID: "tx1", Step: Approved, Payload: []byte("approved")},
+		{TxID: "tx1", Step: Broadcast},
+	}
+	db.status["tx1"] = Pending
+	tx, err = idempotentTransaction(db, "key1", func() (*Transaction, error) {
+		return nil, fmt.Errorf("no transaction must be created")
+	}, restore)
+	assert.NoError(t, err)
+	assert.Equal(t, "tx1", tx.ID())
+	assert.Equal(t, []byte("approved"), restored)
+	assert.Equal(t, &Submission{IdempotencyKey: "key1", TxID: "tx1", Step: Broadcast, Status: Pending}, tx.PreviousSubmission())
+
+	// a restored transaction cannot be modified
+	assert.EqualError(t, tx.checkModifiable(), "transaction [tx1] has been restored for idempotency key [key1], it cannot be modified")
+	assert.EqualError(t, tx.Transfer(nil, "USD", []uint64{10}, nil), "transaction [tx1] has been restored for idempotency key [key1], it cannot be modified")
+	assert.EqualError(t, tx.Redeem(nil, "USD", 10), "transaction [tx1] has been restored for idempotency key [key1], it cannot be modified")
+	assert.EqualError(t, tx.Issue(nil, nil, "USD", 10), "transaction [tx1] has been restored for idempotency key [key1], it cannot be modified")
+	_, err = NewCollectEndorsementsView(tx).Call(nil)
+	assert.EqualError(t, err, "transaction [tx1] has been restored for idempotency key [key1], it cannot be modified")
+}
+
+func TestIdempotentTransactionConcurrentBinding(t *testing.T) {
+	db := newIdempotencyDBMock()
+	const callers = 10
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	txs := make([]*Transaction, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			txs[i], errs[i] = idempotentTransaction(db, "key1", func() (*Transaction, error) {
+				return idempotencyTx(fmt.Sprintf("tx%d", i)), nil
+			}, func(record *ttxdb.IdempotencyKeyRecord, raw []byte) (*Transaction, error) {
+				return idempotencyTx(record.TxID), nil
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	// all the callers get the transaction bound first, only one of them created it
+	created := 0
+	for i := 0; i < callers; i++ {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, db.keys["key1"].TxID, txs[i].ID())
+		if txs[i].PreviousSubmission() == nil {
+			created++
+		}
+	}
+	assert.Equal(t, 1, created)
+}
@@ -7,13 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package ttx
 
 import (
+	"context"
 	"reflect"
 	"sync"
+	"time"
 
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
 	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/tracing"
 	"github.com/hyperledger-labs/fabric-token-sdk/token"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/network/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokendb"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokens"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
 	"github.com/pkg/errors"
@@ -32,10 +36,20 @@ type TMSProvider interface {
 	GetManagementService(opts ...token.ServiceOption) (*token.ManagementService, error)
 }
 
+// TokenNotifierProvider returns the token notifier of a TMS
+type TokenNotifierProvider interface {
+	DBByTMSId(id token.TMSID) (*tokendb.Notifier, error)
+}
+
 type CheckServiceProvider interface {
 	CheckService(id token.TMSID, adb *ttxdb.DB, tdb *tokens.Tokens) (CheckService, error)
 }
 
+// ViewManager starts views on their own context
+type ViewManager interface {
+	InitiateView(view view2.View, ctx context.Context) (interface{}, error)
+}
+
 // Manager handles the databases
 type Manager struct {
 	networkProvider      NetworkProvider
@@ -44,9 +58,22 @@ type Manager struct {
 	tokensProvider       TokensProvider
 	tracerProvider       trace.TracerProvider
 	checkServiceProvider CheckServiceProvider
+	notifierProvider     TokenNotifierProvider
+	viewManager          ViewManager
+	// startedAt is the time this manager has been created.
+	// Transactions whose lifecycle has been recorded before this time belong to a previous run of the node.
+	startedAt time.Time
 
-	mutex sync.Mutex
-	dbs   map[string]*DB
+	mutex         sync.Mutex
+	dbs           map[string]*DB
+	resumeReports map[string]*ResumeReport
+	resumes       map[string]*resumeRun
+}
+
+// resumeRun is the background resume of the in-flight transactions of a TMS
+type resumeRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
 }
 
 // NewManager creates a new DB manager.
@@ -57,6 +84,8 @@ func NewManager(
 	tokensBProvider TokensProvider,
 	tracerProvider trace.TracerProvider,
 	CheckServiceProvider CheckServiceProvider,
+	notifierProvider TokenNotifierProvider,
+	viewManager ViewManager,
 ) *Manager {
 	return &Manager{
 		networkProvider:      np,
@@ -65,7 +94,12 @@ func NewManager(
 		tokensProvider:       tokensBProvider,
 		tracerProvider:       tracerProvider,
 		checkServiceProvider: CheckServiceProvider,
+		notifierProvider:     notifierProvider,
+		viewManager:          viewManager,
+		startedAt:            time.Now(),
 		dbs:                  map[string]*DB{},
+		resumeReports:        map[string]*ResumeReport{},
+		resumes:              map[string]*resumeRun{},
 	}
 }
 
@@ -111,7 +145,8 @@ func (m *Manager) newDB(tmsID token.TMSID) (*DB, error) {
 			Namespace:  "tokensdk",
 			LabelNames: []tracing.LabelName{txIdLabel},
 		})),
-		checkService: checkService,
+		checkService:     checkService,
+		notifierProvider: m.notifierProvider,
 	}
 	_, err = m.networkProvider.GetNetwork(tmsID.Network, tmsID.Channel)
 	if err != nil {
@@ -153,9 +188,83 @@ func (m *Manager) RestoreTMS(tmsID token.TMSID) error {
 		counter++
 	}
 	logger.Debugf("checked [%d] token requests", counter)
+
+	// complete or abort the transactions that were in-flight when the node stopped.
+	// RestoreTMS runs while the TMS is being created, and Resume needs the TMS,
+	// therefore Resume runs in the background.
+	m.startResume(tmsID)
 	return nil
 }
 
+// startResume resumes, in the background, the in-flight transactions of the passed TMS,
+// bounded by the resume timeout of the TMS.
+// RestoreTMS runs again when the TMS is reloaded: the resume of the previous instance of the TMS
+// is stopped, and awaited, before starting the new one.
+func (m *Manager) startResume(tmsID token.TMSID) {
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &resumeRun{cancel: cancel, done: make(chan struct{})}
+	m.mutex.Lock()
+	previous := m.resumes[tmsID.String()]
+	m.resumes[tmsID.String()] = run
+	m.mutex.Unlock()
+	if previous != nil {
+		previous.cancel()
+	}
+
+	go func() {
+		defer close(run.done)
+		defer cancel()
+		if previous != nil {
+			<-previous.done
+		}
+		report, err := m.resumeWithTimeout(ctx, tmsID)
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		if m.resumes[tmsID.String()] == run {
+			delete(m.resumes, tmsID.String())
+		}
+		if err != nil {
+			logger.Errorf("failed to resume in-flight transactions for [%s]: [%s]", tmsID, err)
+			return
+		}
+		m.resumeReports[tmsID.String()] = report
+	}()
+}
+
+func (m *Manager) resumeWithTimeout(ctx context.Context, tmsID token.TMSID) (*ResumeReport, error) {
+	tms, err := m.tmsProvider.GetManagementService(token.WithTMSID(tmsID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting TMS [%s]", tmsID)
+	}
+	timeout, err := GetResumeTimeout(tms)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return m.Resume(ctx, tmsID)
+}
+
+// Stop stops the background resumes of the in-flight transactions and waits for them to return
+func (m *Manager) Stop() {
+	m.mutex.Lock()
+	runs := m.resumes
+	m.resumes = map[string]*resumeRun{}
+	m.mutex.Unlock()
+	for _, run := range runs {
+		run.cancel()
+		<-run.done
+	}
+}
+
+// ResumeReport returns the outcome of resuming, at startup, the in-flight transactions of the passed TMS.
+// It returns nil if resuming has not completed yet.
+func (m *Manager) ResumeReport(tmsID token.TMSID) *ResumeReport {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.resumeReports[tmsID.String()]
+}
+
 var (
 	managerType = reflect.TypeOf((*Manager)(nil))
 )
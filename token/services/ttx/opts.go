@@ -23,6 +23,7 @@ type TxOptions struct {
 	Transaction               *Transaction
 	NetworkTxID               network.TxID
 	NoCachingRequest          bool
+	IdempotencyKey            string
 }
 
 func compile(opts ...TxOption) (*TxOptions, error) {
@@ -125,3 +126,13 @@ func WithNetworkTxID(id network.TxID) TxOption {
 		return nil
 	}
 }
+
+// WithIdempotencyKey binds the transaction to the passed client-provided key.
+// If a transaction has already been created with the same key, the existing transaction is returned
+// together with its status, see Transaction.PreviousSubmission.
+func WithIdempotencyKey(key string) TxOption {
+	return func(o *TxOptions) error {
+		o.IdempotencyKey = key
+		return nil
+	}
+}
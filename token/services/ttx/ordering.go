@@ -42,18 +42,30 @@ func (o *orderingView) Call(context view.Context) (interface{}, error) {
 	if err := o.broadcast(context, options.Transaction); err != nil {
 		return nil, err
 	}
+	if err := broadcasted(context, options.Transaction, options.NoCachingRequest); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// broadcasted records that the passed transaction has been broadcast and, unless noCaching is set,
+// caches its token request into the tokens db
+func broadcasted(context view.Context, tx *Transaction, noCaching bool) error {
+	if err := recordStep(context, tx, Broadcast, false, ""); err != nil {
+		logger.Warnf("failed recording broadcast of [%s]: [%s]", tx.ID(), err)
+	}
 
 	// cache the token request into the tokens db
-	t, err := tokens.GetService(context, options.Transaction.TMSID())
+	t, err := tokens.GetService(context, tx.TMSID())
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to get tokens db for [%s]", options.Transaction.TMSID())
+		return errors.Wrapf(err, "failed to get tokens db for [%s]", tx.TMSID())
 	}
-	if !options.NoCachingRequest {
-		if err := t.CacheRequest(options.Transaction.TMSID(), options.Transaction.TokenRequest); err != nil {
-			logger.Warnf("failed to cache token request [%s], this might cause delay, investigate when possible: [%s]", options.Transaction.TokenRequest.Anchor, err)
+	if !noCaching {
+		if err := t.CacheRequest(tx.TMSID(), tx.TokenRequest); err != nil {
+			logger.Warnf("failed to cache token request [%s], this might cause delay, investigate when possible: [%s]", tx.TokenRequest.Anchor, err)
 		}
 	}
-	return nil, nil
+	return nil
 }
 
 func (o *orderingView) broadcast(context view.Context, transaction *Transaction) error {
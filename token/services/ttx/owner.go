@@ -15,6 +15,7 @@ import (
 )
 
 type TxOwner struct {
+	sp                      token.ServiceProvider
 	tms                     *token.ManagementService
 	owner                   *DB
 	transactionInfoProvider *TransactionInfoProvider
@@ -24,6 +25,7 @@ type TxOwner struct {
 func NewOwner(sp token.ServiceProvider, tms *token.ManagementService) *TxOwner {
 	backend := New(sp, tms)
 	return &TxOwner{
+		sp:                      sp,
 		tms:                     tms,
 		owner:                   backend,
 		transactionInfoProvider: newTransactionInfoProvider(tms, backend),
@@ -64,6 +66,22 @@ func (a *TxOwner) Check(context context.Context) ([]string, error) {
 	return a.owner.Check(context)
 }
 
+// Subscribe returns a subscription to the status updates of the transactions selected by the passed options,
+// by default all the transactions of the TMS. The subscription must be closed when not needed anymore.
+func (a *TxOwner) Subscribe(opts ...SubscriptionOption) (*Subscription, error) {
+	return a.owner.Subscribe(opts...)
+}
+
+// DistributionResults returns the outcome of the distribution of the passed transaction to each party,
+// as recorded by the node that assembled it
+func (a *TxOwner) DistributionResults(txID string) ([]*DistributionResultRecord, error) {
+	return a.owner.ttxDB.TransactionDistributionResults(txID)
+}
+
 func (a *TxOwner) appendTransactionEndorseAck(tx *Transaction, id view.Identity, sigma []byte) error {
 	return a.owner.AppendTransactionEndorseAck(tx.ID(), id, sigma)
 }
+
+func (a *TxOwner) appendDistributionResult(record *DistributionResultRecord) error {
+	return a.owner.AppendDistributionResult(record)
+}
@@ -0,0 +1,487 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/pkg/errors"
+)
+
+// PaymentRequestMetadataKey is the application metadata key, set on the transactions paying a payment request,
+// whose value is the reference of the payment request
+const PaymentRequestMetadataKey = "ttx.payment_request"
+
+// PaymentRequest is an invoice signed by the FSC node of the payee.
+// It asks to pay an amount of a given token type before an expiry.
+type PaymentRequest struct {
+	// TMSID identifies the TMS the payment is expected on
+	TMSID token.TMSID
+	// Reference identifies the payment request at the payee
+	Reference string
+	// Payee is the FSC node identity of the payee, it signs the payment request
+	Payee view.Identity
+	// TokenType is the type of the tokens to pay
+	TokenType string
+	// Amount is the amount to pay
+	Amount uint64
+	// Expiry is the time after which the payment request cannot be paid anymore. If zero, it never expires.
+	Expiry time.Time
+	// Recipient, if not nil, is the recipient identity the payment must be sent to.
+	// Otherwise, the payer requests a recipient identity to the payee.
+	Recipient *RecipientData
+	// Signature is the signature of the payee over all the other fields
+	Signature []byte
+}
+
+func (p *PaymentRequest) Bytes() ([]byte, error) {
+	return Marshal(p)
+}
+
+func (p *PaymentRequest) FromBytes(raw []byte) error {
+	return Unmarshal(raw, p)
+}
+
+// MessageToSign returns the message signed by the payee
+func (p *PaymentRequest) MessageToSign() ([]byte, error) {
+	unsigned := *p
+	unsigned.Signature = nil
+	return unsigned.Bytes()
+}
+
+// Verify checks that the payment request is well-formed, signed by the payee, and not expired
+func (p *PaymentRequest) Verify(context view.Context) error {
+	if err := p.check(time.Now()); err != nil {
+		return err
+	}
+	verifier, err := view2.GetSigService(context).GetVerifier(p.Payee)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting verifier for payee [%s]", p.Payee)
+	}
+	return p.verifySignature(verifier)
+}
+
+// check checks that the payment request is well-formed and not expired at the passed time
+func (p *PaymentRequest) check(now time.Time) error {
+	if len(p.Reference) == 0 {
+		return errors.New("missing reference")
+	}
+	if p.Payee.IsNone() {
+		return errors.New("missing payee")
+	}
+	if len(p.TokenType) == 0 {
+		return errors.New("missing token type")
+	}
+	if p.Amount == 0 {
+		return errors.New("amount is zero")
+	}
+	if p.Expired(now) {
+		return errors.Errorf("payment request [%s] expired at [%s]", p.Reference, p.Expiry)
+	}
+	return nil
+}
+
+// verifySignature checks the signature of the payee with the passed verifier
+func (p *PaymentRequest) verifySignature(verifier token.Verifier) error {
+	msg, err := p.MessageToSign()
+	if err != nil {
+		return errors.WithMessagef(err, "failed marshalling payment request")
+	}
+	if err := verifier.Verify(msg, p.Signature); err != nil {
+		return errors.WithMessagef(err, "invalid signature on payment request [%s]", p.Reference)
+	}
+	return nil
+}
+
+// Expired returns true if the payment request cannot be paid anymore at the passed time
+func (p *PaymentRequest) Expired(now time.Time) bool {
+	return !p.Expiry.IsZero() && now.After(p.Expiry)
+}
+
+// PaymentRequestOptions configures a new payment request
+type PaymentRequestOptions struct {
+	// TMSID identifies the TMS the payment is expected on
+	TMSID token.TMSID
+	// Reference identifies the payment request. If empty, a random one is generated.
+	Reference string
+	// Expiry is the time after which the payment request cannot be paid anymore
+	Expiry time.Time
+	// Wallet, if not empty, is the owner wallet a fresh recipient identity is derived from and embedded in the request
+	Wallet string
+}
+
+// PaymentRequestOption is a function that modifies PaymentRequestOptions
+type PaymentRequestOption func(*PaymentRequestOptions) error
+
+// WithPaymentRequestTMSID sets the TMS the payment is expected on
+func WithPaymentRequestTMSID(tmsID token.TMSID) PaymentRequestOption {
+	return func(o *PaymentRequestOptions) error {
+		o.TMSID = tmsID
+		return nil
+	}
+}
+
+// WithPaymentRequestReference sets the reference of the payment request
+func WithPaymentRequestReference(reference string) PaymentRequestOption {
+	return func(o *PaymentRequestOptions) error {
+		o.Reference = reference
+		return nil
+	}
+}
+
+// WithPaymentRequestExpiry sets the time after which the payment request cannot be paid anymore
+func WithPaymentRequestExpiry(expiry time.Time) PaymentRequestOption {
+	return func(o *PaymentRequestOptions) error {
+		o.Expiry = expiry
+		return nil
+	}
+}
+
+// WithPaymentRequestWallet embeds in the payment request a recipient identity derived from the passed owner wallet
+func WithPaymentRequestWallet(wallet string) PaymentRequestOption {
+	return func(o *PaymentRequestOptions) error {
+		o.Wallet = wallet
+		return nil
+	}
+}
+
+// NewPaymentRequest returns a new payment request for the passed amount, signed by the default identity of this FSC node
+func NewPaymentRequest(context view.Context, tokenType string, amount uint64, opts ...PaymentRequestOption) (*PaymentRequest, error) {
+	options := &PaymentRequestOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, errors.WithMessagef(err, "failed to compile options")
+		}
+	}
+	reference := options.Reference
+	if len(reference) == 0 {
+		var err error
+		reference, err = uuid.GenerateUUID()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed generating reference")
+		}
+	}
+	tms := token.GetManagementService(context, token.WithTMSID(options.TMSID))
+	if tms == nil {
+		return nil, errors.Errorf("tms [%s] not found", options.TMSID)
+	}
+	payee := view2.GetIdentityProvider(context).DefaultIdentity()
+	pr := &PaymentRequest{
+		TMSID:     tms.ID(),
+		Reference: reference,
+		Payee:     payee,
+		TokenType: tokenType,
+		Amount:    amount,
+		Expiry:    options.Expiry,
+	}
+	if len(options.Wallet) != 0 {
+		w := tms.WalletManager().OwnerWallet(options.Wallet)
+		if w == nil {
+			return nil, errors.Errorf("wallet [%s:%s] not found", options.Wallet, tms.ID())
+		}
+		recipientIdentity, err := w.GetRecipientIdentity()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting recipient identity from wallet [%s]", options.Wallet)
+		}
+		auditInfo, err := w.GetAuditInfo(recipientIdentity)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting audit info")
+		}
+		tokenMetadata, err := w.GetTokenMetadata(recipientIdentity)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting token metadata")
+		}
+		pr.Recipient = &RecipientData{
+			Identity:      recipientIdentity,
+			AuditInfo:     auditInfo,
+			TokenMetadata: tokenMetadata,
+		}
+		if err := view2.GetEndpointService(context).Bind(payee, recipientIdentity); err != nil {
+			return nil, errors.WithMessagef(err, "failed binding [%s] to [%s]", recipientIdentity, payee)
+		}
+	}
+
+	signer, err := view2.GetSigService(context).GetSigner(payee)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting signer for [%s]", payee)
+	}
+	msg, err := pr.MessageToSign()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed marshalling payment request")
+	}
+	pr.Signature, err = signer.Sign(msg)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed signing payment request")
+	}
+	return pr, nil
+}
+
+// SendPaymentRequestView is executed by the payee to send a payment request to the FSC node of the payer
+type SendPaymentRequestView struct {
+	Payer          view.Identity
+	PaymentRequest *PaymentRequest
+}
+
+func NewSendPaymentRequestView(payer view.Identity, pr *PaymentRequest) *SendPaymentRequestView {
+	return &SendPaymentRequestView{Payer: payer, PaymentRequest: pr}
+}
+
+func (s *SendPaymentRequestView) Call(context view.Context) (interface{}, error) {
+	session, err := context.GetSession(context.Initiator(), s.Payer)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get session with [%s]", s.Payer)
+	}
+	raw, err := s.PaymentRequest.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling payment request")
+	}
+	if err := session.SendWithContext(context.Context(), raw); err != nil {
+		return nil, errors.Wrapf(err, "failed sending payment request [%s]", s.PaymentRequest.Reference)
+	}
+	return session, nil
+}
+
+// ReceivePaymentRequestView is executed by the payer to receive and verify a payment request sent with SendPaymentRequestView
+type ReceivePaymentRequestView struct{}
+
+// ReceivePaymentRequest runs ReceivePaymentRequestView and returns the verified payment request
+func ReceivePaymentRequest(context view.Context) (*PaymentRequest, error) {
+	pr, err := context.RunView(&ReceivePaymentRequestView{})
+	if err != nil {
+		return nil, err
+	}
+	return pr.(*PaymentRequest), nil
+}
+
+func (r *ReceivePaymentRequestView) Call(context view.Context) (interface{}, error) {
+	_, payload, err := session2.ReadFirstMessage(context)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read first message")
+	}
+	pr := &PaymentRequest{}
+	if err := pr.FromBytes(payload); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling payment request")
+	}
+	if err := pr.Verify(context); err != nil {
+		return nil, errors.WithMessagef(err, "invalid payment request")
+	}
+	return pr, nil
+}
+
+// PaymentRequestQuery asks the payee for the payment request with the given reference
+type PaymentRequestQuery struct {
+	TMSID     token.TMSID
+	Reference string
+}
+
+func (q *PaymentRequestQuery) Bytes() ([]byte, error) {
+	return Marshal(q)
+}
+
+func (q *PaymentRequestQuery) FromBytes(raw []byte) error {
+	return Unmarshal(raw, q)
+}
+
+// FetchPaymentRequestView is executed by the payer to fetch a payment request from the FSC node of the payee
+type FetchPaymentRequestView struct {
+	Payee     view.Identity
+	TMSID     token.TMSID
+	Reference string
+}
+
+// FetchPaymentRequest runs FetchPaymentRequestView and returns the verified payment request
+func FetchPaymentRequest(context view.Context, payee view.Identity, reference string, opts ...token.ServiceOption) (*PaymentRequest, error) {
+	options, err := CompileServiceOptions(opts...)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to compile options")
+	}
+	pr, err := context.RunView(&FetchPaymentRequestView{Payee: payee, TMSID: options.TMSID(), Reference: reference})
+	if err != nil {
+		return nil, err
+	}
+	return pr.(*PaymentRequest), nil
+}
+
+func (f *FetchPaymentRequestView) Call(context view.Context) (interface{}, error) {
+	session, err := context.GetSession(context.Initiator(), f.Payee)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get session with [%s]", f.Payee)
+	}
+	query := &PaymentRequestQuery{TMSID: f.TMSID, Reference: f.Reference}
+	raw, err := query.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling payment request query")
+	}
+	if err := session.SendWithContext(context.Context(), raw); err != nil {
+		return nil, errors.Wrapf(err, "failed sending payment request query")
+	}
+	msg, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading payment request [%s]", f.Reference)
+	}
+	pr := &PaymentRequest{}
+	if err := pr.FromBytes(msg); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling payment request")
+	}
+	if pr.Reference != f.Reference {
+		return nil, errors.Errorf("expected payment request [%s], got [%s]", f.Reference, pr.Reference)
+	}
+	if !pr.Payee.Equal(f.Payee) {
+		return nil, errors.Errorf("payment request [%s] not issued by [%s]", f.Reference, f.Payee)
+	}
+	if err := pr.Verify(context); err != nil {
+		return nil, errors.WithMessagef(err, "invalid payment request")
+	}
+	return pr, nil
+}
+
+// PaymentRequestLookup returns the payment request with the passed reference issued on the passed TMS
+type PaymentRequestLookup func(tmsID token.TMSID, reference string) (*PaymentRequest, error)
+
+// RespondPaymentRequestView is executed by the payee to answer a FetchPaymentRequestView
+type RespondPaymentRequestView struct {
+	Lookup PaymentRequestLookup
+}
+
+func NewRespondPaymentRequestView(lookup PaymentRequestLookup) *RespondPaymentRequestView {
+	return &RespondPaymentRequestView{Lookup: lookup}
+}
+
+func (r *RespondPaymentRequestView) Call(context view.Context) (interface{}, error) {
+	session, payload, err := session2.ReadFirstMessage(context)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read first message")
+	}
+	query := &PaymentRequestQuery{}
+	if err := query.FromBytes(payload); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling payment request query")
+	}
+	pr, err := r.Lookup(query.TMSID, query.Reference)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed looking up payment request [%s]", query.Reference)
+	}
+	if pr == nil {
+		return nil, errors.Errorf("payment request [%s] not found", query.Reference)
+	}
+	raw, err := pr.Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling payment request")
+	}
+	if err := session.SendWithContext(context.Context(), raw); err != nil {
+		return nil, errors.Wrapf(err, "failed sending payment request [%s]", pr.Reference)
+	}
+	return pr, nil
+}
+
+// PayPaymentRequest appends to the transaction a transfer, from the passed wallet, paying the passed payment request.
+// The payment request is verified first, and it must not be paid already by a transaction,
+// pending or confirmed, of the transaction db of this node. If it does not embed a recipient identity, one is requested to the payee.
+// The reference of the payment request is stored in the application metadata of the transaction,
+// under PaymentRequestMetadataKey, so that both the payer and the payee can find the payment in their transaction db.
+func (t *Transaction) PayPaymentRequest(context view.Context, wallet *token.OwnerWallet, pr *PaymentRequest, opts ...token.TransferOption) error {
+	if err := pr.Verify(context); err != nil {
+		return errors.WithMessagef(err, "invalid payment request")
+	}
+	if !t.TMSID().Equal(pr.TMSID) {
+		return errors.Errorf("payment request [%s] expects payment on [%s], transaction is on [%s]", pr.Reference, pr.TMSID, t.TMSID())
+	}
+	if reference := t.ApplicationMetadata(PaymentRequestMetadataKey); len(reference) != 0 {
+		return errors.Errorf("transaction [%s] already pays payment request [%s]", t.ID(), reference)
+	}
+	records, err := NewOwner(context, t.TokenService()).PaymentRequestTransactions(pr)
+	if err != nil {
+		return errors.WithMessagef(err, "failed looking up payments of payment request [%s]", pr.Reference)
+	}
+	if err := checkUnpaid(pr, records); err != nil {
+		return err
+	}
+
+	var recipient view.Identity
+	if pr.Recipient != nil {
+		if err := t.TokenService().WalletManager().RegisterRecipientIdentity(pr.Recipient); err != nil {
+			return errors.WithMessagef(err, "failed registering recipient identity of payment request [%s]", pr.Reference)
+		}
+		if err := view2.GetEndpointService(context).Bind(pr.Payee, pr.Recipient.Identity); err != nil {
+			return errors.WithMessagef(err, "failed binding [%s] to [%s]", pr.Recipient.Identity, pr.Payee)
+		}
+		recipient = pr.Recipient.Identity
+	} else {
+		recipient, err = RequestRecipientIdentity(context, pr.Payee, token.WithTMSID(pr.TMSID))
+		if err != nil {
+			return errors.WithMessagef(err, "failed getting recipient identity from [%s]", pr.Payee)
+		}
+	}
+
+	if err := t.Transfer(wallet, pr.TokenType, []uint64{pr.Amount}, []view.Identity{recipient}, opts...); err != nil {
+		return errors.WithMessagef(err, "failed paying payment request [%s]", pr.Reference)
+	}
+	t.SetApplicationMetadata(PaymentRequestMetadataKey, []byte(pr.Reference))
+	return nil
+}
+
+// PaymentRequestTransactions returns the records of the transactions, pending or confirmed, paying the passed payment request.
+// These are the transfers carrying the reference of the payment request in their application metadata
+// that move at least the requested amount of the requested token type to a party other than the sender.
+func (a *TxOwner) PaymentRequestTransactions(pr *PaymentRequest) ([]*driver.TransactionRecord, error) {
+	it, err := a.Transactions(paymentRequestQuery(pr))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying transactions of payment request [%s]", pr.Reference)
+	}
+	return paymentRequestRecords(it, pr)
+}
+
+// paymentRequestQuery returns the query selecting the candidate payments of the passed payment request
+func paymentRequestQuery(pr *PaymentRequest) QueryTransactionsParams {
+	return QueryTransactionsParams{
+		ExcludeToSelf:       true,
+		ActionTypes:         []driver.ActionType{driver.Transfer},
+		Statuses:            []driver.TxStatus{driver.Pending, driver.Confirmed},
+		ApplicationMetadata: map[string][]byte{PaymentRequestMetadataKey: []byte(pr.Reference)},
+	}
+}
+
+// paymentRequestRecords returns the records of the passed iterator paying the passed payment request,
+// the others only share its reference
+func paymentRequestRecords(it driver.TransactionIterator, pr *PaymentRequest) ([]*driver.TransactionRecord, error) {
+	defer it.Close()
+	amount := new(big.Int).SetUint64(pr.Amount)
+	var records []*driver.TransactionRecord
+	for {
+		record, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed iterating over transactions of payment request [%s]", pr.Reference)
+		}
+		if record == nil {
+			return records, nil
+		}
+		if record.TokenType != pr.TokenType || record.Amount == nil || record.Amount.Cmp(amount) < 0 {
+			logger.Warnf("transaction [%s] refers to payment request [%s] but does not pay it: [%s:%s], expected [%s:%d]",
+				record.TxID, pr.Reference, record.TokenType, record.Amount, pr.TokenType, pr.Amount)
+			continue
+		}
+		records = append(records, record)
+	}
+}
+
+// checkUnpaid returns an error if the passed records contain a payment of the passed payment request
+func checkUnpaid(pr *PaymentRequest, records []*driver.TransactionRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	txIDs := make([]string, len(records))
+	for i, record := range records {
+		txIDs[i] = record.TxID
+	}
+	return errors.Errorf("payment request [%s] already paid by transactions %v", pr.Reference, txIDs)
+}
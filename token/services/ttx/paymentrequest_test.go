@@ -0,0 +1,139 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/common/utils/collections"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver/mock"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func newSignedPaymentRequest(t *testing.T, expiry time.Time) *PaymentRequest {
+	pr := &PaymentRequest{
+		TMSID:     token.TMSID{Network: "n", Channel: "c", Namespace: "ns"},
+		Reference: "inv1",
+		Payee:     view.Identity("payee"),
+		TokenType: "USD",
+		Amount:    100,
+		Expiry:    expiry,
+	}
+	msg, err := pr.MessageToSign()
+	assert.NoError(t, err)
+	pr.Signature = append([]byte("signature of "), msg...)
+	return pr
+}
+
+func TestPaymentRequestVerify(t *testing.T) {
+	now := time.Now()
+	pr := newSignedPaymentRequest(t, now.Add(time.Hour))
+	assert.NoError(t, pr.check(now))
+	verifier := &mock.Verifier{}
+	assert.NoError(t, pr.verifySignature(verifier))
+	msg, sigma := verifier.VerifyArgsForCall(0)
+	expected, err := pr.MessageToSign()
+	assert.NoError(t, err)
+	assert.Equal(t, expected, msg)
+	assert.Equal(t, pr.Signature, sigma)
+
+	// the payment request survives the wire
+	raw, err := pr.Bytes()
+	assert.NoError(t, err)
+	pr2 := &PaymentRequest{}
+	assert.NoError(t, pr2.FromBytes(raw))
+	assert.NoError(t, pr2.check(now))
+	assert.NoError(t, pr2.verifySignature(verifier))
+	msg, sigma = verifier.VerifyArgsForCall(1)
+	assert.Equal(t, expected, msg)
+	assert.Equal(t, pr.Signature, sigma)
+
+	// the signature covers the terms, not itself
+	tampered := *pr
+	tampered.Amount = 1
+	assert.NoError(t, tampered.verifySignature(verifier))
+	msg, _ = verifier.VerifyArgsForCall(2)
+	assert.NotEqual(t, expected, msg)
+	tampered = *pr
+	tampered.Signature = []byte("another signature")
+	assert.NoError(t, tampered.verifySignature(verifier))
+	msg, _ = verifier.VerifyArgsForCall(3)
+	assert.Equal(t, expected, msg)
+
+	verifier.VerifyReturns(errors.New("signature mismatch"))
+	assert.EqualError(t, pr.verifySignature(verifier), "invalid signature on payment request [inv1]: signature mismatch")
+
+	// expiry
+	assert.False(t, pr.Expired(now))
+	assert.True(t, pr.Expired(now.Add(2*time.Hour)))
+	assert.Contains(t, pr.check(now.Add(2*time.Hour)).Error(), "payment request [inv1] expired at")
+	assert.False(t, newSignedPaymentRequest(t, time.Time{}).Expired(now.AddDate(10, 0, 0)))
+
+	// well-formedness
+	malformed := *pr
+	malformed.Reference = ""
+	assert.EqualError(t, malformed.check(now), "missing reference")
+	malformed = *pr
+	malformed.Payee = nil
+	assert.EqualError(t, malformed.check(now), "missing payee")
+	malformed = *pr
+	malformed.TokenType = ""
+	assert.EqualError(t, malformed.check(now), "missing token type")
+	malformed = *pr
+	malformed.Amount = 0
+	assert.EqualError(t, malformed.check(now), "amount is zero")
+}
+
+func TestPaymentRequestTransactions(t *testing.T) {
+	pr := newSignedPaymentRequest(t, time.Time{})
+
+	query := paymentRequestQuery(pr)
+	assert.True(t, query.ExcludeToSelf)
+	assert.Equal(t, []driver.ActionType{driver.Transfer}, query.ActionTypes)
+	assert.Equal(t, []driver.TxStatus{driver.Pending, driver.Confirmed}, query.Statuses)
+	assert.Equal(t, map[string][]byte{PaymentRequestMetadataKey: []byte("inv1")}, query.ApplicationMetadata)
+
+	records := []*driver.TransactionRecord{
+		{TxID: "paid", TokenType: "USD", Amount: big.NewInt(100)},
+		{TxID: "overpaid", TokenType: "USD", Amount: big.NewInt(150)},
+		{TxID: "underpaid", TokenType: "USD", Amount: big.NewInt(99)},
+		{TxID: "wrong type", TokenType: "EUR", Amount: big.NewInt(100)},
+		{TxID: "no amount", TokenType: "USD"},
+	}
+	matched, err := paymentRequestRecords(collections.NewSliceIterator(records), pr)
+	assert.NoError(t, err)
+	assert.Equal(t, records[:2], matched)
+
+	matched, err = paymentRequestRecords(collections.NewSliceIterator([]*driver.TransactionRecord{}), pr)
+	assert.NoError(t, err)
+	assert.Empty(t, matched)
+}
+
+func TestPaymentRequestDoublePay(t *testing.T) {
+	pr := newSignedPaymentRequest(t, time.Time{})
+	assert.NoError(t, checkUnpaid(pr, nil))
+
+	// a transaction sharing the reference without paying the request does not count
+	matched, err := paymentRequestRecords(collections.NewSliceIterator([]*driver.TransactionRecord{
+		{TxID: "tx1", TokenType: "USD", Amount: big.NewInt(1)},
+	}), pr)
+	assert.NoError(t, err)
+	assert.NoError(t, checkUnpaid(pr, matched))
+
+	matched, err = paymentRequestRecords(collections.NewSliceIterator([]*driver.TransactionRecord{
+		{TxID: "tx1", TokenType: "USD", Amount: big.NewInt(100)},
+		{TxID: "tx2", TokenType: "USD", Amount: big.NewInt(100)},
+	}), pr)
+	assert.NoError(t, err)
+	assert.EqualError(t, checkUnpaid(pr, matched), "payment request [inv1] already paid by transactions [tx1 tx2]")
+}
@@ -0,0 +1,220 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"sync"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+)
+
+// DefaultPayoutConcurrency is the default number of FSC nodes BatchPayout requests recipient identities to concurrently
+const DefaultPayoutConcurrency = 16
+
+// Payout is a payment of a batch payout
+type Payout struct {
+	// Recipient is the FSC node identity of the recipient, used to request the identity that will own the tokens
+	Recipient view.Identity
+	// Value is the amount to pay
+	Value uint64
+}
+
+// PayoutResult is the outcome of a payment of a batch payout
+type PayoutResult struct {
+	// Recipient is the FSC node identity of the recipient
+	Recipient view.Identity
+	// Value is the amount paid
+	Value uint64
+	// Owner is the identity owning the output paying the recipient, if the recipient identity has been received
+	Owner view.Identity
+	// Action is the index, among the actions added by the batch payout, of the transfer action paying the recipient
+	Action int
+	// Error is not nil if the recipient has not been paid
+	Error error
+}
+
+// PayoutOptions configures a batch payout
+type PayoutOptions struct {
+	// FanOut is the maximum number of outputs of each transfer action, the rest included
+	FanOut int
+	// Concurrency is the number of FSC nodes recipient identities are requested to concurrently
+	Concurrency int
+	// SkipFailedRecipients pays the recipients whose identity has been received, even if some other recipient failed
+	SkipFailedRecipients bool
+	// TransferOptions are the options passed to the token request
+	TransferOptions []token.TransferOption
+}
+
+// PayoutOption is a function that modifies PayoutOptions
+type PayoutOption func(*PayoutOptions) error
+
+// WithPayoutFanOut sets the maximum number of outputs of each transfer action, the rest included
+func WithPayoutFanOut(fanOut int) PayoutOption {
+	return func(o *PayoutOptions) error {
+		o.FanOut = fanOut
+		return nil
+	}
+}
+
+// WithPayoutConcurrency sets the number of FSC nodes recipient identities are requested to concurrently
+func WithPayoutConcurrency(concurrency int) PayoutOption {
+	return func(o *PayoutOptions) error {
+		o.Concurrency = concurrency
+		return nil
+	}
+}
+
+// WithSkipFailedRecipients pays the recipients whose identity has been received, even if some other recipient failed
+func WithSkipFailedRecipients() PayoutOption {
+	return func(o *PayoutOptions) error {
+		o.SkipFailedRecipients = true
+		return nil
+	}
+}
+
+// WithPayoutTransferOptions sets the options passed to the token request, for instance a custom token selector
+func WithPayoutTransferOptions(opts ...token.TransferOption) PayoutOption {
+	return func(o *PayoutOptions) error {
+		o.TransferOptions = append(o.TransferOptions, opts...)
+		return nil
+	}
+}
+
+func compilePayoutOptions(opts ...PayoutOption) (*PayoutOptions, error) {
+	options := &PayoutOptions{
+		FanOut:      token.DefaultBatchFanOut,
+		Concurrency: DefaultPayoutConcurrency,
+	}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, err
+		}
+	}
+	if options.FanOut <= 0 {
+		options.FanOut = token.DefaultBatchFanOut
+	}
+	if options.Concurrency <= 0 {
+		return nil, errors.Errorf("invalid concurrency [%d]", options.Concurrency)
+	}
+	return options, nil
+}
+
+// BatchPayout appends to the transaction the transfer actions paying tokens of the passed type, owned by the passed wallet,
+// to the passed recipients.
+// The identities of the recipients are requested concurrently, then the payments are split across transfer actions
+// whose proofs are generated in parallel (see token.Request.BatchTransfer).
+// The identities of recipients served by the same FSC node are requested one after the other,
+// because the requests share the session with that node.
+// BatchPayout returns the outcome of each payment, in the order of the passed payouts.
+// Unless WithSkipFailedRecipients is used, nothing is paid if the identity of a recipient cannot be obtained.
+func (t *Transaction) BatchPayout(context view.Context, wallet *token.OwnerWallet, typ string, payouts []*Payout, opts ...PayoutOption) ([]*PayoutResult, error) {
+	if err := t.checkModifiable(); err != nil {
+		return nil, err
+	}
+	options, err := compilePayoutOptions(opts...)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed compiling payout options")
+	}
+	if len(payouts) == 0 {
+		return nil, errors.New("no payouts")
+	}
+
+	// gather the recipient identities
+	results := requestPayoutRecipients(context, t.TMSID(), payouts, options.Concurrency)
+	var values []uint64
+	var owners []token.Identity
+	var paid []*PayoutResult
+	var failures int
+	for _, result := range results {
+		if result.Error != nil {
+			failures++
+			continue
+		}
+		values = append(values, result.Value)
+		owners = append(owners, result.Owner)
+		paid = append(paid, result)
+	}
+	if failures != 0 && (!options.SkipFailedRecipients || len(paid) == 0) {
+		return results, errors.Errorf("failed getting the identity of [%d] out of [%d] recipients", failures, len(payouts))
+	}
+
+	// pay
+	actions, err := t.TokenRequest.BatchTransfer(context.Context(), wallet, typ, values, owners, options.FanOut, options.TransferOptions...)
+	if err != nil {
+		err = errors.WithMessage(err, "failed appending batch transfer")
+		for _, result := range paid {
+			result.Error = err
+		}
+		return results, err
+	}
+	// each action pays FanOut-1 recipients and the rest
+	for i, result := range paid {
+		result.Action = i / (options.FanOut - 1)
+	}
+	logger.Debugf("batch payout of [%d] recipients in [%d] actions, [%d] failures", len(paid), len(actions), failures)
+	return results, nil
+}
+
+// requestPayoutRecipients requests the identities of the recipients of the passed payouts.
+// The recipients are grouped by FSC node: at most concurrency nodes are contacted at a time,
+// and the recipients of the same node are contacted sequentially.
+func requestPayoutRecipients(context view.Context, tmsID token.TMSID, payouts []*Payout, concurrency int) []*PayoutResult {
+	es := view2.GetEndpointService(context)
+	results := make([]*PayoutResult, len(payouts))
+	groups := map[string][]int{}
+	var endpoints []string
+	for i, payout := range payouts {
+		result := &PayoutResult{Recipient: payout.Recipient, Value: payout.Value, Action: -1}
+		results[i] = result
+		switch {
+		case payout.Value == 0:
+			result.Error = errors.New("value is zero")
+		case payout.Recipient.IsNone():
+			result.Error = errors.New("recipient not set")
+		default:
+			// recipients that cannot be resolved are contacted on their own
+			endpoint := payout.Recipient.UniqueID()
+			if e, _, _, err := es.Resolve(payout.Recipient); err == nil {
+				endpoint = e.UniqueID()
+			}
+			if _, ok := groups[endpoint]; !ok {
+				endpoints = append(endpoints, endpoint)
+			}
+			groups[endpoint] = append(groups[endpoint], i)
+		}
+	}
+
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	if concurrency > len(endpoints) {
+		concurrency = len(endpoints)
+	}
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for endpoint := range jobs {
+				for _, i := range groups[endpoint] {
+					result := results[i]
+					result.Owner, result.Error = RequestRecipientIdentity(context, result.Recipient, token.WithTMSID(tmsID))
+					if result.Error != nil {
+						result.Error = errors.WithMessagef(result.Error, "failed getting identity of recipient [%s]", result.Recipient)
+					}
+				}
+			}
+		}()
+	}
+	for _, endpoint := range endpoints {
+		jobs <- endpoint
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
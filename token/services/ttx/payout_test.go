@@ -0,0 +1,36 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompilePayoutOptions(t *testing.T) {
+	options, err := compilePayoutOptions()
+	assert.NoError(t, err)
+	assert.Equal(t, token.DefaultBatchFanOut, options.FanOut)
+	assert.Equal(t, DefaultPayoutConcurrency, options.Concurrency)
+	assert.False(t, options.SkipFailedRecipients)
+
+	options, err = compilePayoutOptions(WithPayoutFanOut(3), WithPayoutConcurrency(4), WithSkipFailedRecipients())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, options.FanOut)
+	assert.Equal(t, 4, options.Concurrency)
+	assert.True(t, options.SkipFailedRecipients)
+
+	// a non-positive fan out falls back to the default
+	options, err = compilePayoutOptions(WithPayoutFanOut(0))
+	assert.NoError(t, err)
+	assert.Equal(t, token.DefaultBatchFanOut, options.FanOut)
+
+	_, err = compilePayoutOptions(WithPayoutConcurrency(-1))
+	assert.EqualError(t, err, "invalid concurrency [-1]")
+}
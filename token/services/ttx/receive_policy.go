@@ -0,0 +1,225 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"math/big"
+	"slices"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+)
+
+// ReceivePolicyKey is the configuration key, relative to the TMS, of the receive policy
+const ReceivePolicyKey = "services.ttx.receivePolicy"
+
+// Receipt describes what a transaction pays to the wallets of this node
+type Receipt struct {
+	// Transaction is the transaction paying this node
+	Transaction *Transaction
+	// Outputs are the outputs of the transaction owned by the wallets of this node
+	Outputs []*token.Output
+	// Senders are the enrollment IDs of the owners of the tokens spent by the transfers paying this node, when known.
+	// Each enrollment ID is checked against the identity of its sender, the one that must sign the spending,
+	// and the validators check that the sender owns the spent tokens.
+	Senders []string
+	// ApplicationMetadata is the application metadata of the token request
+	ApplicationMetadata map[string][]byte
+}
+
+// Amounts returns the total amount received per token type
+func (r *Receipt) Amounts() map[string]*big.Int {
+	amounts := map[string]*big.Int{}
+	for _, output := range r.Outputs {
+		amount, ok := amounts[output.Type]
+		if !ok {
+			amount = big.NewInt(0)
+			amounts[output.Type] = amount
+		}
+		if output.Quantity != nil {
+			amount.Add(amount, output.Quantity.ToBigInt())
+		}
+	}
+	return amounts
+}
+
+// NewReceipt returns the receipt of the passed transaction for the wallets of this node.
+// The receipt has no outputs if the transaction does not pay this node.
+func NewReceipt(tx *Transaction) (*Receipt, error) {
+	inputs, outputs, err := tx.TokenRequest.InputsAndOutputs()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting inputs and outputs of [%s]", tx.ID())
+	}
+	receipt := &Receipt{Transaction: tx}
+	if tx.TokenRequest.Metadata != nil {
+		receipt.ApplicationMetadata = tx.TokenRequest.Metadata.Application
+	}
+	wm := tx.TokenService().WalletManager()
+	actions := map[int]bool{}
+	for _, output := range outputs.Outputs() {
+		if output.Owner.IsNone() || wm.OwnerWallet(output.Owner) == nil {
+			continue
+		}
+		receipt.Outputs = append(receipt.Outputs, output)
+		actions[output.ActionIndex] = true
+	}
+	receipt.Senders, err = receiptSenders(inputs.Inputs(), actions, tx.TokenService().SigService().MatchOwnerIdentity)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid senders in [%s]", tx.ID())
+	}
+	return receipt, nil
+}
+
+// receiptSenders returns the enrollment IDs of the senders of the passed inputs spent by the passed actions.
+// The enrollment IDs come from the audit information sent along with the transaction,
+// therefore the audit information must match the identity of the sender.
+func receiptSenders(inputs []*token.Input, actions map[int]bool, match func(id token.Identity, auditInfo []byte) error) ([]string, error) {
+	var senders []string
+	for _, input := range inputs {
+		if !actions[input.ActionIndex] || len(input.EnrollmentID) == 0 {
+			continue
+		}
+		if err := match(input.Owner, input.OwnerAuditInfo); err != nil {
+			return nil, errors.WithMessagef(err, "audit info of sender [%s] does not match its identity", input.EnrollmentID)
+		}
+		if !slices.Contains(senders, input.EnrollmentID) {
+			senders = append(senders, input.EnrollmentID)
+		}
+	}
+	return senders, nil
+}
+
+// ReceivePolicy decides whether this node accepts a transaction paying its wallets.
+// The policy is checked before this node acknowledges the transaction, a non-nil error rejects it.
+type ReceivePolicy interface {
+	Accept(context view.Context, receipt *Receipt) error
+}
+
+// AllowAllReceivePolicy accepts any transaction, it is the default receive policy
+type AllowAllReceivePolicy struct{}
+
+func (p *AllowAllReceivePolicy) Accept(view.Context, *Receipt) error {
+	return nil
+}
+
+// ReceiveRule constrains the outputs of some token types received with a transaction
+type ReceiveRule struct {
+	// Types are the token types the rule applies to, all if empty
+	Types []string `yaml:"types,omitempty"`
+	// AllowedSenders, if not empty, lists the only enrollment IDs allowed to send these types.
+	// Transactions whose senders are unknown are rejected.
+	AllowedSenders []string `yaml:"allowedSenders,omitempty"`
+	// DeniedSenders lists the enrollment IDs not allowed to send these types
+	DeniedSenders []string `yaml:"deniedSenders,omitempty"`
+	// MaxAmount, if not zero, is the maximum amount of each type received with a single transaction
+	MaxAmount uint64 `yaml:"maxAmount,omitempty"`
+	// RequiredMetadata lists the application metadata keys the transaction must carry, for instance travel-rule information
+	RequiredMetadata []string `yaml:"requiredMetadata,omitempty"`
+}
+
+func (r *ReceiveRule) appliesTo(tokenType string) bool {
+	return len(r.Types) == 0 || slices.Contains(r.Types, tokenType)
+}
+
+func (r *ReceiveRule) check(receipt *Receipt, tokenType string, amount *big.Int) error {
+	if len(r.AllowedSenders) != 0 {
+		if len(receipt.Senders) == 0 {
+			return errors.Errorf("unknown sender of [%s] tokens", tokenType)
+		}
+		for _, sender := range receipt.Senders {
+			if !slices.Contains(r.AllowedSenders, sender) {
+				return errors.Errorf("sender [%s] not allowed to send [%s] tokens", sender, tokenType)
+			}
+		}
+	}
+	for _, sender := range receipt.Senders {
+		if slices.Contains(r.DeniedSenders, sender) {
+			return errors.Errorf("sender [%s] denied for [%s] tokens", sender, tokenType)
+		}
+	}
+	if r.MaxAmount != 0 && amount.Cmp(new(big.Int).SetUint64(r.MaxAmount)) > 0 {
+		return errors.Errorf("received [%s] [%s] tokens, more than the limit [%d]", amount, tokenType, r.MaxAmount)
+	}
+	for _, key := range r.RequiredMetadata {
+		if _, ok := receipt.ApplicationMetadata[key]; !ok {
+			return errors.Errorf("missing metadata [%s] required for [%s] tokens", key, tokenType)
+		}
+	}
+	return nil
+}
+
+// RuleBasedReceivePolicy accepts a transaction if each received token type is allowed and satisfies all the rules applying to it
+type RuleBasedReceivePolicy struct {
+	// AllowedTypes, if not empty, lists the only token types this node accepts
+	AllowedTypes []string `yaml:"allowedTypes,omitempty"`
+	// Rules are the rules the received tokens must satisfy
+	Rules []*ReceiveRule `yaml:"rules,omitempty"`
+}
+
+func (p *RuleBasedReceivePolicy) Accept(_ view.Context, receipt *Receipt) error {
+	amounts := receipt.Amounts()
+	tokenTypes := make([]string, 0, len(amounts))
+	for tokenType := range amounts {
+		tokenTypes = append(tokenTypes, tokenType)
+	}
+	slices.Sort(tokenTypes)
+	for _, tokenType := range tokenTypes {
+		amount := amounts[tokenType]
+		if len(p.AllowedTypes) != 0 && !slices.Contains(p.AllowedTypes, tokenType) {
+			return errors.Errorf("token type [%s] not accepted", tokenType)
+		}
+		for i, rule := range p.Rules {
+			if !rule.appliesTo(tokenType) {
+				continue
+			}
+			if err := rule.check(receipt, tokenType, amount); err != nil {
+				return errors.WithMessagef(err, "rule [%d] violated", i)
+			}
+		}
+	}
+	return nil
+}
+
+// GetReceivePolicy loads the receive policy from the configuration of the passed TMS.
+// If no policy is configured, any transaction is accepted.
+func GetReceivePolicy(tms *token.ManagementService) (ReceivePolicy, error) {
+	if !tms.Configuration().IsSet(ReceivePolicyKey) {
+		return &AllowAllReceivePolicy{}, nil
+	}
+	policy := &RuleBasedReceivePolicy{}
+	if err := tms.Configuration().UnmarshalKey(ReceivePolicyKey, policy); err != nil {
+		return nil, errors.WithMessagef(err, "failed loading receive policy for [%s]", tms.ID())
+	}
+	return policy, nil
+}
+
+// checkReceivePolicy checks the passed transaction against the passed policy, or the one configured for its TMS if nil.
+// Transactions not paying this node are not checked.
+func checkReceivePolicy(context view.Context, tx *Transaction, policy ReceivePolicy) error {
+	if policy == nil {
+		var err error
+		policy, err = GetReceivePolicy(tx.TokenService())
+		if err != nil {
+			return err
+		}
+	}
+	if _, ok := policy.(*AllowAllReceivePolicy); ok {
+		return nil
+	}
+	receipt, err := NewReceipt(tx)
+	if err != nil {
+		return err
+	}
+	if len(receipt.Outputs) == 0 {
+		return nil
+	}
+	if err := policy.Accept(context, receipt); err != nil {
+		return errors.WithMessagef(err, "transaction [%s] rejected by the receive policy", tx.ID())
+	}
+	return nil
+}
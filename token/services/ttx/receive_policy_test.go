@@ -0,0 +1,93 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func receipt(senders []string, metadata map[string][]byte, outputs ...*token.Output) *Receipt {
+	return &Receipt{Outputs: outputs, Senders: senders, ApplicationMetadata: metadata}
+}
+
+func TestReceiptAmounts(t *testing.T) {
+	r := receipt(nil, nil, output(alice, "USD", 10), output(alice, "EUR", 5), output(alice, "USD", 7))
+	assert.Equal(t, map[string]*big.Int{"USD": big.NewInt(17), "EUR": big.NewInt(5)}, r.Amounts())
+}
+
+func TestReceiptSenders(t *testing.T) {
+	// the audit info of a genuine sender is its enrollment id
+	match := func(id token.Identity, auditInfo []byte) error {
+		if string(id) != string(auditInfo) {
+			return errors.New("no match")
+		}
+		return nil
+	}
+	input := func(actionIndex int, owner string, eid string) *token.Input {
+		return &token.Input{ActionIndex: actionIndex, Owner: token.Identity(owner), OwnerAuditInfo: []byte(eid), EnrollmentID: eid}
+	}
+	inputs := []*token.Input{
+		input(0, "bob", "bob"),
+		input(0, "bob", "bob"),
+		input(1, "charlie", "charlie"),
+		input(2, "dave", "dave"),
+		{ActionIndex: 0, Owner: token.Identity("unknown")},
+	}
+	senders, err := receiptSenders(inputs, map[int]bool{0: true, 1: true}, match)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "charlie"}, senders)
+
+	// a sender claiming the enrollment id of someone else is refused
+	inputs = append(inputs, input(1, "mallory", "bob"))
+	_, err = receiptSenders(inputs, map[int]bool{0: true, 1: true}, match)
+	assert.EqualError(t, err, "audit info of sender [bob] does not match its identity: no match")
+	// unless the action does not pay this node
+	_, err = receiptSenders(inputs, map[int]bool{0: true}, match)
+	assert.NoError(t, err)
+}
+
+func TestAllowAllReceivePolicy(t *testing.T) {
+	assert.NoError(t, (&AllowAllReceivePolicy{}).Accept(nil, receipt(nil, nil, output(alice, "USD", 10))))
+}
+
+func TestRuleBasedReceivePolicy(t *testing.T) {
+	policy := &RuleBasedReceivePolicy{
+		AllowedTypes: []string{"USD", "EUR"},
+		Rules: []*ReceiveRule{
+			{Types: []string{"USD"}, MaxAmount: 100, DeniedSenders: []string{"mallory"}},
+			{Types: []string{"EUR"}, AllowedSenders: []string{"bob", "charlie"}, RequiredMetadata: []string{"travel.rule"}},
+		},
+	}
+	travelRule := map[string][]byte{"travel.rule": []byte("info")}
+
+	assert.NoError(t, policy.Accept(nil, receipt([]string{"bob"}, nil, output(alice, "USD", 60), output(alice, "USD", 40))))
+	assert.NoError(t, policy.Accept(nil, receipt([]string{"bob", "charlie"}, travelRule, output(alice, "EUR", 1000))))
+
+	assert.EqualError(t, policy.Accept(nil, receipt([]string{"bob"}, nil, output(alice, "GBP", 1))),
+		"token type [GBP] not accepted")
+	assert.EqualError(t, policy.Accept(nil, receipt([]string{"bob"}, nil, output(alice, "USD", 60), output(alice, "USD", 41))),
+		"rule [0] violated: received [101] [USD] tokens, more than the limit [100]")
+	assert.EqualError(t, policy.Accept(nil, receipt([]string{"bob", "mallory"}, nil, output(alice, "USD", 1))),
+		"rule [0] violated: sender [mallory] denied for [USD] tokens")
+	assert.EqualError(t, policy.Accept(nil, receipt([]string{"mallory"}, travelRule, output(alice, "EUR", 1))),
+		"rule [1] violated: sender [mallory] not allowed to send [EUR] tokens")
+	assert.EqualError(t, policy.Accept(nil, receipt(nil, travelRule, output(alice, "EUR", 1))),
+		"rule [1] violated: unknown sender of [EUR] tokens")
+	assert.EqualError(t, policy.Accept(nil, receipt([]string{"bob"}, nil, output(alice, "EUR", 1))),
+		"rule [1] violated: missing metadata [travel.rule] required for [EUR] tokens")
+
+	// a rule without types applies to all types
+	policy = &RuleBasedReceivePolicy{Rules: []*ReceiveRule{{MaxAmount: 5}}}
+	assert.NoError(t, policy.Accept(nil, receipt(nil, nil, output(alice, "GBP", 5))))
+	assert.EqualError(t, policy.Accept(nil, receipt(nil, nil, output(alice, "GBP", 6))),
+		"rule [0] violated: received [6] [GBP] tokens, more than the limit [5]")
+}
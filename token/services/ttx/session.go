@@ -130,6 +130,11 @@ func (s *localSession) Close() {
 }
 
 func ReadMessage(session view.Session, timeout time.Duration) ([]byte, error) {
+	return readMessage(context.Background(), session, timeout)
+}
+
+// readMessage is ReadMessage that gives up when the passed context is done
+func readMessage(ctx context.Context, session view.Session, timeout time.Duration) ([]byte, error) {
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 
@@ -146,5 +151,7 @@ func ReadMessage(session view.Session, timeout time.Duration) ([]byte, error) {
 	case <-timer.C:
 		err := errors.New("timeout reached")
 		return nil, err
+	case <-ctx.Done():
+		return nil, errors.Wrapf(ctx.Err(), "stopped reading message")
 	}
 }
@@ -0,0 +1,478 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/cache/secondcache"
+	driver2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/common"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/tokendb"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+const (
+	// statusEventsBufferSize is the size of the buffer of the status events received by a statusHub
+	statusEventsBufferSize = 1000
+	// statusCacheSize is the number of transactions whose last dispatched status,
+	// and whose enrollment ids, are remembered by a statusHub
+	statusCacheSize = 10000
+)
+
+// ErrSubscriptionClosed is returned by Subscription.Next when the subscription is over
+var ErrSubscriptionClosed = errors.New("subscription closed")
+
+// StatusUpdate is a transition of the status of a transaction
+type StatusUpdate struct {
+	// TxID is the transaction id
+	TxID string
+	// Status is the new status: Pending, Confirmed, or Deleted
+	Status TxStatus
+	// Message is the message attached to the status, for instance the reason a transaction has been deleted
+	Message string
+}
+
+// IsFinal returns true if the transaction is either confirmed or deleted
+func (u *StatusUpdate) IsFinal() bool {
+	return u.Status == Confirmed || u.Status == Deleted
+}
+
+// SubscriptionOptions selects the transactions whose status updates are delivered to a subscription.
+// If no filter is set, the updates of all the transactions of the TMS are delivered.
+type SubscriptionOptions struct {
+	// WalletID selects the transactions that send tokens from or to the owner wallet with this identifier.
+	// The wallet is resolved to its enrollment id when subscribing: the transactions are matched by enrollment id,
+	// therefore wallets sharing the same enrollment id see the same transactions.
+	WalletID string
+	// TxID selects the transaction with this id.
+	// The subscription is over after the final status of the transaction has been delivered.
+	TxID string
+}
+
+// SubscriptionOption models an option for Subscribe
+type SubscriptionOption func(*SubscriptionOptions) error
+
+// WithSubscriptionWallet selects the transactions that send tokens from or to the passed wallet
+func WithSubscriptionWallet(walletID string) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.WalletID = walletID
+		return nil
+	}
+}
+
+// WithSubscriptionTxID selects the transaction with the passed id
+func WithSubscriptionTxID(txID string) SubscriptionOption {
+	return func(o *SubscriptionOptions) error {
+		o.TxID = txID
+		return nil
+	}
+}
+
+// Subscription delivers the status updates of the transactions it selects, in the order they happen.
+// The updates are queued, a slow subscriber does not block the others.
+type Subscription struct {
+	hub     *statusHub
+	options SubscriptionOptions
+	// enrollmentID is the enrollment id of the wallet selected by the options, if any
+	enrollmentID string
+
+	mutex     sync.Mutex
+	queue     []*StatusUpdate
+	last      TxStatus
+	ended     bool
+	signal    chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	updatesOnce sync.Once
+	updates     chan *StatusUpdate
+}
+
+func newSubscription(hub *statusHub, options SubscriptionOptions, enrollmentID string) *Subscription {
+	return &Subscription{
+		hub:          hub,
+		options:      options,
+		enrollmentID: enrollmentID,
+		signal:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
+	}
+}
+
+// Next returns the next status update, waiting for it if needed.
+// It returns ErrSubscriptionClosed when the subscription is over, and the context error if the context is done before.
+func (s *Subscription) Next(ctx context.Context) (*StatusUpdate, error) {
+	for {
+		s.mutex.Lock()
+		if len(s.queue) != 0 {
+			update := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mutex.Unlock()
+			return update, nil
+		}
+		ended := s.ended
+		s.mutex.Unlock()
+		if ended {
+			return nil, ErrSubscriptionClosed
+		}
+
+		select {
+		case <-s.signal:
+		case <-s.done:
+			return nil, ErrSubscriptionClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Updates returns a channel delivering the status updates.
+// The channel is closed when the subscription is over.
+func (s *Subscription) Updates() <-chan *StatusUpdate {
+	s.updatesOnce.Do(func() {
+		s.updates = make(chan *StatusUpdate)
+		go func() {
+			defer close(s.updates)
+			for {
+				update, err := s.Next(context.Background())
+				if err != nil {
+					return
+				}
+				select {
+				case s.updates <- update:
+				case <-s.done:
+					return
+				}
+			}
+		}()
+	})
+	return s.updates
+}
+
+// Close ends the subscription, the pending updates are discarded
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		s.hub.unsubscribe(s)
+		s.mutex.Lock()
+		s.ended = true
+		s.queue = nil
+		s.mutex.Unlock()
+		close(s.done)
+	})
+}
+
+// push queues the passed update. It returns true if the subscription is over after this update.
+func (s *Subscription) push(update *StatusUpdate) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.ended {
+		return true
+	}
+	if len(s.options.TxID) != 0 {
+		if s.last == update.Status {
+			return false
+		}
+		s.last = update.Status
+		s.ended = update.IsFinal()
+	}
+	s.queue = append(s.queue, update)
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+	return s.ended
+}
+
+func (s *Subscription) matches(txID string, enrollmentIDs map[string]struct{}) bool {
+	if len(s.options.TxID) != 0 && s.options.TxID != txID {
+		return false
+	}
+	if len(s.enrollmentID) != 0 {
+		if _, ok := enrollmentIDs[s.enrollmentID]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type statusCache interface {
+	Get(key string) (TxStatus, bool)
+	Add(key string, value TxStatus)
+}
+
+type enrollmentIDsCache interface {
+	Get(key string) (map[string]struct{}, bool)
+	Add(key string, value map[string]struct{})
+}
+
+// pendingStatus is the last status of a transaction received by a statusHub and not dispatched yet
+type pendingStatus struct {
+	status  TxStatus
+	message string
+	// lookup is true if the status must be read from the ttxdb
+	lookup bool
+}
+
+// statusHub dispatches the status updates of the transactions of a TMS to the subscriptions.
+// The updates come from the ttxdb, whose status is set by the finality listeners,
+// and from the token notifier, if available, that signals the tokens created by transactions committed elsewhere.
+// The received updates are queued without blocking their sources and dispatched in order by a single goroutine.
+// The updates of a transaction still queued are coalesced: only the last one is dispatched.
+type statusHub struct {
+	tmsID  token.TMSID
+	ttxDB  *ttxdb.DB
+	events chan common.StatusEvent
+
+	queueMutex sync.Mutex
+	queue      []string
+	pending    map[string]*pendingStatus
+	signal     chan struct{}
+
+	mutex         sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+	last          statusCache
+	enrollmentIDs enrollmentIDsCache
+}
+
+func newStatusHub(tmsID token.TMSID, ttxDB *ttxdb.DB, notifier *tokendb.Notifier) *statusHub {
+	h := &statusHub{
+		tmsID:         tmsID,
+		ttxDB:         ttxDB,
+		events:        make(chan common.StatusEvent, statusEventsBufferSize),
+		pending:       map[string]*pendingStatus{},
+		signal:        make(chan struct{}, 1),
+		subscriptions: map[*Subscription]struct{}{},
+		last:          secondcache.NewTyped[TxStatus](statusCacheSize),
+		enrollmentIDs: secondcache.NewTyped[map[string]struct{}](statusCacheSize),
+	}
+	ttxDB.AddAllStatusListener(h.events)
+	if notifier != nil {
+		if err := notifier.Subscribe(h.onTokenEvent); err != nil {
+			logger.Warnf("failed subscribing to the token notifier of [%s], relying on the ttxdb only: [%s]", tmsID, err)
+		}
+	}
+	go h.run()
+	go h.dispatchQueued()
+	return h
+}
+
+// subscribe registers a new subscription with the passed options.
+// The passed enrollment id is the one of the wallet selected by the options, if any.
+func (h *statusHub) subscribe(options SubscriptionOptions, enrollmentID string) (*Subscription, error) {
+	s := newSubscription(h, options, enrollmentID)
+	h.mutex.Lock()
+	h.subscriptions[s] = struct{}{}
+	h.mutex.Unlock()
+
+	// the transaction might have reached its current status before the subscription
+	if len(options.TxID) != 0 {
+		status, message, err := h.ttxDB.GetStatus(options.TxID)
+		if err != nil {
+			s.Close()
+			return nil, errors.WithMessagef(err, "failed getting status of [%s]", options.TxID)
+		}
+		if status != Unknown && s.push(&StatusUpdate{TxID: options.TxID, Status: status, Message: message}) {
+			h.unsubscribe(s)
+		}
+	}
+	return s, nil
+}
+
+func (h *statusHub) unsubscribe(s *Subscription) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	delete(h.subscriptions, s)
+}
+
+func (h *statusHub) run() {
+	for event := range h.events {
+		h.enqueue(event.TxID, &pendingStatus{status: event.ValidationCode, message: event.ValidationMessage})
+	}
+}
+
+// onTokenEvent handles the notifications of the token notifier.
+// A token inserted by a transaction means that the transaction has been committed, its status is read from the ttxdb
+// when dispatched.
+func (h *statusHub) onTokenEvent(operation driver2.Operation, values map[driver2.ColumnKey]string) {
+	if operation != driver2.Insert {
+		return
+	}
+	txID, ok := values["tx_id"]
+	if !ok || len(txID) == 0 {
+		return
+	}
+	h.enqueue(txID, &pendingStatus{lookup: true})
+}
+
+// enqueue queues the passed status of the passed transaction, replacing the one still queued for it, if any.
+// A status to look up does not replace a known one.
+func (h *statusHub) enqueue(txID string, status *pendingStatus) {
+	h.queueMutex.Lock()
+	if queued, ok := h.pending[txID]; ok {
+		if !status.lookup {
+			*queued = *status
+		}
+	} else {
+		h.pending[txID] = status
+		h.queue = append(h.queue, txID)
+	}
+	h.queueMutex.Unlock()
+	select {
+	case h.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (h *statusHub) dispatchQueued() {
+	for range h.signal {
+		h.drain()
+	}
+}
+
+// drain dispatches the queued statuses, in the order their transactions have been queued
+func (h *statusHub) drain() {
+	for {
+		h.queueMutex.Lock()
+		if len(h.queue) == 0 {
+			h.queueMutex.Unlock()
+			return
+		}
+		txID := h.queue[0]
+		h.queue[0] = ""
+		h.queue = h.queue[1:]
+		status := h.pending[txID]
+		delete(h.pending, txID)
+		h.queueMutex.Unlock()
+
+		if status.lookup {
+			var err error
+			status.status, status.message, err = h.ttxDB.GetStatus(txID)
+			if err != nil {
+				logger.Warnf("failed getting status of [%s]: [%s]", txID, err)
+				continue
+			}
+		}
+		h.dispatch(txID, status.status, status.message)
+	}
+}
+
+// dispatch delivers the passed status to the subscriptions selecting the passed transaction.
+// A status already dispatched for the same transaction is skipped.
+func (h *statusHub) dispatch(txID string, status TxStatus, message string) {
+	if status == Unknown {
+		return
+	}
+	h.mutex.RLock()
+	if last, ok := h.last.Get(txID); ok && last == status {
+		h.mutex.RUnlock()
+		return
+	}
+	subscriptions := make([]*Subscription, 0, len(h.subscriptions))
+	withWallets := false
+	for s := range h.subscriptions {
+		subscriptions = append(subscriptions, s)
+		withWallets = withWallets || len(s.enrollmentID) != 0
+	}
+	h.mutex.RUnlock()
+	h.last.Add(txID, status)
+	if len(subscriptions) == 0 {
+		return
+	}
+
+	var enrollmentIDs map[string]struct{}
+	if withWallets {
+		var err error
+		enrollmentIDs, err = h.enrollmentIDsOf(txID)
+		if err != nil {
+			logger.Warnf("failed getting the enrollment ids of [%s], wallet subscriptions will not be notified: [%s]", txID, err)
+		}
+	}
+	update := &StatusUpdate{TxID: txID, Status: status, Message: message}
+	for _, s := range subscriptions {
+		if s.matches(txID, enrollmentIDs) && s.push(update) {
+			h.unsubscribe(s)
+		}
+	}
+}
+
+// enrollmentIDsOf returns the enrollment ids sending or receiving tokens in the passed transaction.
+// The transaction records do not change once appended, the enrollment ids found are cached.
+func (h *statusHub) enrollmentIDsOf(txID string) (map[string]struct{}, error) {
+	if enrollmentIDs, ok := h.enrollmentIDs.Get(txID); ok {
+		return enrollmentIDs, nil
+	}
+	it, err := h.ttxDB.Transactions(QueryTransactionsParams{IDs: []string{txID}})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed querying transaction records of [%s]", txID)
+	}
+	defer it.Close()
+	enrollmentIDs := map[string]struct{}{}
+	for {
+		record, err := it.Next()
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed reading transaction records of [%s]", txID)
+		}
+		if record == nil {
+			break
+		}
+		if len(record.SenderEID) != 0 {
+			enrollmentIDs[record.SenderEID] = struct{}{}
+		}
+		if len(record.RecipientEID) != 0 {
+			enrollmentIDs[record.RecipientEID] = struct{}{}
+		}
+	}
+	if len(enrollmentIDs) != 0 {
+		h.enrollmentIDs.Add(txID, enrollmentIDs)
+	}
+	return enrollmentIDs, nil
+}
+
+// Subscribe returns a subscription to the status updates of the transactions selected by the passed options.
+// Without options, the updates of all the transactions of the TMS are delivered.
+// The subscription must be closed when not needed anymore.
+func (a *DB) Subscribe(opts ...SubscriptionOption) (*Subscription, error) {
+	options := &SubscriptionOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, errors.WithMessage(err, "failed compiling subscription options")
+		}
+	}
+	var enrollmentID string
+	if len(options.WalletID) != 0 {
+		tms, err := a.tmsProvider.GetManagementService(token.WithTMSID(a.tmsID))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed getting token management service [%s]", a.tmsID)
+		}
+		w := tms.WalletManager().OwnerWallet(options.WalletID)
+		if w == nil {
+			return nil, errors.Errorf("owner wallet [%s] not found", options.WalletID)
+		}
+		enrollmentID = w.EnrollmentID()
+	}
+	return a.getStatusHub().subscribe(*options, enrollmentID)
+}
+
+func (a *DB) getStatusHub() *statusHub {
+	a.statusHubOnce.Do(func() {
+		var notifier *tokendb.Notifier
+		if a.notifierProvider != nil {
+			var err error
+			notifier, err = a.notifierProvider.DBByTMSId(a.tmsID)
+			if err != nil {
+				logger.Warnf("no token notifier for [%s], relying on the ttxdb only: [%s]", a.tmsID, err)
+				notifier = nil
+			}
+		}
+		a.statusHub = newStatusHub(a.tmsID, a.ttxDB, notifier)
+	})
+	return a.statusHub
+}
@@ -0,0 +1,268 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/cache/secondcache"
+	driver2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/db/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// addRequest stores a pending transaction without notifying its status
+func addRequest(t *testing.T, raw driver.TokenTransactionDB, txID string) {
+	w, err := raw.BeginAtomicWrite()
+	assert.NoError(t, err)
+	assert.NoError(t, w.AddTokenRequest(txID, []byte(txID), nil, []byte("pp")))
+	assert.NoError(t, w.Commit())
+}
+
+// addTransfer stores a transaction record of the passed transaction, stored already, from the passed sender to the passed recipient
+func addTransfer(t *testing.T, raw driver.TokenTransactionDB, txID, sender, recipient string) {
+	w, err := raw.BeginAtomicWrite()
+	assert.NoError(t, err)
+	assert.NoError(t, w.AddTransaction(&driver.TransactionRecord{
+		TxID:         txID,
+		ActionType:   driver.Transfer,
+		SenderEID:    sender,
+		RecipientEID: recipient,
+		TokenType:    "USD",
+		Amount:       big.NewInt(1),
+		Timestamp:    time.Now(),
+	}))
+	assert.NoError(t, w.Commit())
+}
+
+// setStatus sets the status of the passed transaction, stored already, and notifies it
+func setStatus(t *testing.T, ttxDB *ttxdb.DB, txID string, status TxStatus, message string) {
+	assert.NoError(t, ttxDB.SetStatus(context.Background(), txID, status, message))
+}
+
+func nextUpdate(t *testing.T, s *Subscription) *StatusUpdate {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	update, err := s.Next(ctx)
+	assert.NoError(t, err)
+	return update
+}
+
+func assertNoUpdate(t *testing.T, s *Subscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := s.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSubscriptionAllTransactions(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	addRequest(t, raw, "tx1")
+	addRequest(t, raw, "tx2")
+	hub := newStatusHub(token.TMSID{Network: "n"}, ttxDB, nil)
+	s, err := hub.subscribe(SubscriptionOptions{}, "")
+	assert.NoError(t, err)
+	defer s.Close()
+
+	setStatus(t, ttxDB, "tx1", Pending, "")
+	setStatus(t, ttxDB, "tx2", Pending, "")
+	assert.Equal(t, &StatusUpdate{TxID: "tx1", Status: Pending}, nextUpdate(t, s))
+	assert.Equal(t, &StatusUpdate{TxID: "tx2", Status: Pending}, nextUpdate(t, s))
+
+	// the same status is dispatched once
+	setStatus(t, ttxDB, "tx1", Pending, "")
+	setStatus(t, ttxDB, "tx2", Deleted, "invalid signature")
+	assert.Equal(t, &StatusUpdate{TxID: "tx2", Status: Deleted, Message: "invalid signature"}, nextUpdate(t, s))
+	setStatus(t, ttxDB, "tx1", Confirmed, "")
+	assert.Equal(t, &StatusUpdate{TxID: "tx1", Status: Confirmed}, nextUpdate(t, s))
+	assertNoUpdate(t, s)
+
+	s.Close()
+	_, err = s.Next(context.Background())
+	assert.ErrorIs(t, err, ErrSubscriptionClosed)
+}
+
+func TestSubscriptionTxID(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	addRequest(t, raw, "tx1")
+	addRequest(t, raw, "tx2")
+	hub := newStatusHub(token.TMSID{Network: "n"}, ttxDB, nil)
+
+	// the current status is delivered first
+	s, err := hub.subscribe(SubscriptionOptions{TxID: "tx1"}, "")
+	assert.NoError(t, err)
+	setStatus(t, ttxDB, "tx2", Pending, "")
+	setStatus(t, ttxDB, "tx1", Confirmed, "")
+
+	var updates []*StatusUpdate
+	for update := range s.Updates() {
+		updates = append(updates, update)
+	}
+	assert.Equal(t, []*StatusUpdate{{TxID: "tx1", Status: Pending}, {TxID: "tx1", Status: Confirmed}}, updates)
+	assert.Eventually(t, func() bool {
+		hub.mutex.RLock()
+		defer hub.mutex.RUnlock()
+		return len(hub.subscriptions) == 0
+	}, time.Second, time.Millisecond)
+
+	// a final transaction ends the subscription immediately
+	s, err = hub.subscribe(SubscriptionOptions{TxID: "tx1"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, &StatusUpdate{TxID: "tx1", Status: Confirmed}, nextUpdate(t, s))
+	_, err = s.Next(context.Background())
+	assert.ErrorIs(t, err, ErrSubscriptionClosed)
+
+	// an unknown transaction has no current status
+	s, err = hub.subscribe(SubscriptionOptions{TxID: "unknown"}, "")
+	assert.NoError(t, err)
+	defer s.Close()
+	assertNoUpdate(t, s)
+}
+
+func TestSubscriptionWallet(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	hub := newStatusHub(token.TMSID{Network: "n"}, ttxDB, nil)
+	alice, err := hub.subscribe(SubscriptionOptions{WalletID: "alice"}, "alice-eid")
+	assert.NoError(t, err)
+	defer alice.Close()
+	bob, err := hub.subscribe(SubscriptionOptions{WalletID: "bob"}, "bob-eid")
+	assert.NoError(t, err)
+	defer bob.Close()
+
+	// the transactions are matched by the enrollment ids of the wallets
+	addRequest(t, raw, "tx1")
+	addTransfer(t, raw, "tx1", "alice-eid", "charlie-eid")
+	addRequest(t, raw, "tx2")
+	addTransfer(t, raw, "tx2", "charlie-eid", "bob-eid")
+	setStatus(t, ttxDB, "tx1", Pending, "")
+	setStatus(t, ttxDB, "tx2", Pending, "")
+
+	assert.Equal(t, "tx1", nextUpdate(t, alice).TxID)
+	assertNoUpdate(t, alice)
+	assert.Equal(t, "tx2", nextUpdate(t, bob).TxID)
+	assertNoUpdate(t, bob)
+}
+
+func TestSubscriptionTokenNotifier(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	hub := newStatusHub(token.TMSID{Network: "n"}, ttxDB, nil)
+	s, err := hub.subscribe(SubscriptionOptions{}, "")
+	assert.NoError(t, err)
+	defer s.Close()
+
+	// the transaction has been committed by another replica sharing the same databases
+	addRequest(t, raw, "tx1")
+	assert.NoError(t, raw.SetStatus(context.Background(), "tx1", Confirmed, ""))
+	hub.onTokenEvent(driver2.Insert, map[driver2.ColumnKey]string{"tx_id": "tx1", "idx": "0"})
+	hub.onTokenEvent(driver2.Insert, map[driver2.ColumnKey]string{"tx_id": "tx1", "idx": "1"})
+	hub.onTokenEvent(driver2.Delete, map[driver2.ColumnKey]string{"tx_id": "tx2", "idx": "0"})
+	hub.onTokenEvent(driver2.Insert, map[driver2.ColumnKey]string{"tx_id": "unknown", "idx": "0"})
+
+	assert.Equal(t, &StatusUpdate{TxID: "tx1", Status: Confirmed}, nextUpdate(t, s))
+	assertNoUpdate(t, s)
+}
+
+func TestSubscriptionConcurrentSubscribers(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	hub := newStatusHub(token.TMSID{Network: "n"}, ttxDB, nil)
+
+	// a subscriber that never reads does not block the others
+	slow, err := hub.subscribe(SubscriptionOptions{}, "")
+	assert.NoError(t, err)
+	defer slow.Close()
+
+	n := 50
+	txs := []string{"tx1", "tx2", "tx3"}
+	var wg sync.WaitGroup
+	subscriptions := make([]*Subscription, n)
+	for i := 0; i < n; i++ {
+		subscriptions[i], err = hub.subscribe(SubscriptionOptions{}, "")
+		assert.NoError(t, err)
+	}
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(s *Subscription) {
+			defer wg.Done()
+			defer s.Close()
+			for range txs {
+				assert.Equal(t, Confirmed, nextUpdate(t, s).Status)
+			}
+		}(subscriptions[i])
+	}
+	for _, txID := range txs {
+		addRequest(t, raw, txID)
+		setStatus(t, ttxDB, txID, Confirmed, "")
+	}
+	wg.Wait()
+}
+
+func TestStatusHubCoalescing(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	// the hub is not started, the queue is drained explicitly
+	hub := &statusHub{
+		ttxDB:         ttxDB,
+		pending:       map[string]*pendingStatus{},
+		signal:        make(chan struct{}, 1),
+		subscriptions: map[*Subscription]struct{}{},
+		last:          secondcache.NewTyped[TxStatus](10),
+		enrollmentIDs: secondcache.NewTyped[map[string]struct{}](10),
+	}
+	s, err := hub.subscribe(SubscriptionOptions{}, "")
+	assert.NoError(t, err)
+	defer s.Close()
+	for _, txID := range []string{"tx1", "tx2", "tx3"} {
+		addRequest(t, raw, txID)
+	}
+
+	// the statuses of a transaction still queued are coalesced, the position of the transaction is kept
+	hub.enqueue("tx1", &pendingStatus{status: Pending})
+	hub.enqueue("tx2", &pendingStatus{status: Pending})
+	hub.enqueue("tx1", &pendingStatus{status: Confirmed})
+	// a status to look up does not replace a known one
+	assert.NoError(t, raw.SetStatus(context.Background(), "tx2", Confirmed, ""))
+	hub.enqueue("tx2", &pendingStatus{lookup: true})
+	hub.enqueue("tx3", &pendingStatus{lookup: true})
+	hub.enqueue("unknown", &pendingStatus{lookup: true})
+	assert.NoError(t, raw.SetStatus(context.Background(), "tx3", Deleted, ""))
+	assert.Equal(t, []string{"tx1", "tx2", "tx3", "unknown"}, hub.queue)
+
+	hub.drain()
+	assert.Empty(t, hub.queue)
+	assert.Empty(t, hub.pending)
+	assert.Equal(t, &StatusUpdate{TxID: "tx1", Status: Confirmed}, nextUpdate(t, s))
+	assert.Equal(t, &StatusUpdate{TxID: "tx2", Status: Pending}, nextUpdate(t, s))
+	assert.Equal(t, &StatusUpdate{TxID: "tx3", Status: Deleted}, nextUpdate(t, s))
+	assertNoUpdate(t, s)
+}
+
+func TestStatusHubEnrollmentIDsCache(t *testing.T) {
+	ttxDB, raw := newTestTTXDB(t)
+	hub := newStatusHub(token.TMSID{Network: "n"}, ttxDB, nil)
+
+	// no record yet, nothing is cached
+	eids, err := hub.enrollmentIDsOf("tx1")
+	assert.NoError(t, err)
+	assert.Empty(t, eids)
+
+	addRequest(t, raw, "tx1")
+	addTransfer(t, raw, "tx1", "alice-eid", "bob-eid")
+	eids, err = hub.enrollmentIDsOf("tx1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"alice-eid": {}, "bob-eid": {}}, eids)
+
+	// the records are read once
+	addTransfer(t, raw, "tx1", "charlie-eid", "")
+	eids, err = hub.enrollmentIDsOf("tx1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{"alice-eid": {}, "bob-eid": {}}, eids)
+}
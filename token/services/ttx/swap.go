@@ -0,0 +1,633 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"sort"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/pkg/errors"
+)
+
+// SwapMetadataKey is the application metadata key, set on the transactions assembled by SwapView,
+// whose value is the identifier of the swap
+const SwapMetadataKey = "ttx.swap"
+
+// SwapLeg is a transfer agreed in a swap
+type SwapLeg struct {
+	// From is the FSC node identity of the party sending the tokens
+	From view.Identity
+	// To is the FSC node identity of the party receiving the tokens
+	To view.Identity
+	// Type is the type of the tokens to transfer
+	Type string
+	// Amount is the amount to transfer
+	Amount uint64
+}
+
+// SwapTerms are the terms of an atomic swap among two or more parties.
+// Either all the legs are settled by the same token transaction, or none is.
+type SwapTerms struct {
+	// ID identifies the swap
+	ID string
+	// TMSID identifies the TMS the swap is settled on
+	TMSID token.TMSID
+	// Legs are the transfers the parties agree on
+	Legs []*SwapLeg
+}
+
+// Validate checks that the terms are well-formed
+func (t *SwapTerms) Validate() error {
+	if len(t.ID) == 0 {
+		return errors.New("missing swap id")
+	}
+	if len(t.Legs) == 0 {
+		return errors.New("no legs")
+	}
+	for i, leg := range t.Legs {
+		if leg == nil {
+			return errors.Errorf("leg [%d] is nil", i)
+		}
+		if leg.From.IsNone() || leg.To.IsNone() {
+			return errors.Errorf("leg [%d] is missing a party", i)
+		}
+		if leg.From.Equal(leg.To) {
+			return errors.Errorf("leg [%d] transfers to its sender", i)
+		}
+		if len(leg.Type) == 0 {
+			return errors.Errorf("leg [%d] is missing the token type", i)
+		}
+		if leg.Amount == 0 {
+			return errors.Errorf("leg [%d] has zero amount", i)
+		}
+	}
+	return nil
+}
+
+// Parties returns the FSC node identities of the parties, in order of first appearance in the legs
+func (t *SwapTerms) Parties() []view.Identity {
+	var parties []view.Identity
+	seen := map[string]bool{}
+	for _, leg := range t.Legs {
+		for _, party := range []view.Identity{leg.From, leg.To} {
+			if !seen[party.UniqueID()] {
+				seen[party.UniqueID()] = true
+				parties = append(parties, party)
+			}
+		}
+	}
+	return parties
+}
+
+// IsParty returns true if the passed FSC node identity is a party of the swap
+func (t *SwapTerms) IsParty(id view.Identity) bool {
+	for _, leg := range t.Legs {
+		if leg.From.Equal(id) || leg.To.Equal(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expectations returns, for each token type the passed party sends or receives,
+// the net amount the party receives (positive) or sends (negative)
+func (t *SwapTerms) Expectations(party view.Identity) map[string]*big.Int {
+	expectations := map[string]*big.Int{}
+	for _, leg := range t.Legs {
+		amount := new(big.Int).SetUint64(leg.Amount)
+		switch {
+		case leg.From.Equal(party):
+			amount.Neg(amount)
+		case leg.To.Equal(party):
+		default:
+			continue
+		}
+		if e, ok := expectations[leg.Type]; ok {
+			e.Add(e, amount)
+		} else {
+			expectations[leg.Type] = amount
+		}
+	}
+	return expectations
+}
+
+// SwapPolicy is used by a party to accept, by returning nil, or reject the terms of a swap it is invited to
+type SwapPolicy func(terms *SwapTerms) error
+
+// SwapMessage is exchanged between the initiator of a swap and the other parties.
+// A message with a non-empty Abort aborts the swap.
+type SwapMessage struct {
+	Terms         *SwapTerms
+	Recipients    map[string]*RecipientData
+	RecipientData *RecipientData
+	TX            []byte
+	Abort         string
+}
+
+func (m *SwapMessage) Bytes() ([]byte, error) {
+	return Marshal(m)
+}
+
+func (m *SwapMessage) FromBytes(raw []byte) error {
+	return Unmarshal(raw, m)
+}
+
+// SwapView assembles, on behalf of the initiator, the token transaction settling a swap, and collects its endorsements.
+// The view does the following:
+// 1. It sends the terms to the other parties. Each party accepts them, sending back a recipient identity, or aborts.
+// 2. It appends the transfers of the initiator, then lets each party append its own transfers, one party at a time.
+// 3. It sends the assembled transaction to each party. Each party checks that the transaction settles its legs,
+// and nothing more, before accepting it, or aborts.
+// 4. It collects the endorsements. Each party signs only the transaction it has checked.
+// If a party aborts, the other parties are notified and the view fails.
+// On success, the transaction is ready to be ordered.
+type SwapView struct {
+	tx     *Transaction
+	wallet string
+	terms  *SwapTerms
+}
+
+// NewSwapView returns a new SwapView for the passed transaction, spending the tokens of the initiator from the passed wallet
+func NewSwapView(tx *Transaction, wallet string, terms *SwapTerms) *SwapView {
+	return &SwapView{tx: tx, wallet: wallet, terms: terms}
+}
+
+func (s *SwapView) Call(context view.Context) (interface{}, error) {
+	if err := s.terms.Validate(); err != nil {
+		return nil, errors.WithMessagef(err, "invalid swap terms")
+	}
+	if !s.terms.TMSID.Equal(s.tx.TMSID()) {
+		return nil, errors.Errorf("swap [%s] is settled on [%s], transaction is on [%s]", s.terms.ID, s.terms.TMSID, s.tx.TMSID())
+	}
+	w := GetWallet(context, s.wallet, token.WithTMSID(s.tx.TMSID()))
+	if w == nil {
+		return nil, errors.Errorf("wallet [%s:%s] not found", s.wallet, s.tx.TMSID())
+	}
+	s.tx.SetApplicationMetadata(SwapMetadataKey, []byte(s.terms.ID))
+	if err := s.swap(context, w); err != nil {
+		return nil, err
+	}
+	return s.tx, nil
+}
+
+// swap runs the protocol of SwapView. If it fails, the other parties are notified of the abort.
+func (s *SwapView) swap(context view.Context, w *token.OwnerWallet) error {
+	ctx := context.Context()
+	terms := s.terms
+	me := context.Me()
+	if !terms.IsParty(me) {
+		return errors.Errorf("[%s] is not a party of swap [%s]", me, terms.ID)
+	}
+	var others []view.Identity
+	for _, party := range terms.Parties() {
+		if !party.Equal(me) {
+			others = append(others, party)
+		}
+	}
+	sessions := make(map[string]view.Session, len(others))
+	fail := func(err error) error {
+		for _, session := range sessions {
+			if sendErr := sendSwapMessage(ctx, session, &SwapMessage{Abort: err.Error()}); sendErr != nil {
+				logger.Warnf("failed notifying abort of swap [%s]: %s", terms.ID, sendErr)
+			}
+		}
+		return err
+	}
+
+	// propose the terms and collect the recipient identities
+	recipients := map[string]*RecipientData{}
+	for _, party := range others {
+		session, err := context.GetSession(context.Initiator(), party)
+		if err != nil {
+			return fail(errors.Wrapf(err, "failed to get session with [%s]", party))
+		}
+		sessions[party.UniqueID()] = session
+		reply, err := exchangeSwapMessage(ctx, session, &SwapMessage{Terms: terms})
+		if err != nil {
+			return fail(errors.WithMessagef(err, "party [%s] did not accept swap [%s]", party, terms.ID))
+		}
+		if reply.RecipientData == nil {
+			return fail(errors.Errorf("party [%s] did not send a recipient identity", party))
+		}
+		recipients[party.UniqueID()] = reply.RecipientData
+	}
+	recipientData, err := swapRecipientData(context, w)
+	if err != nil {
+		return fail(err)
+	}
+	recipients[me.UniqueID()] = recipientData
+	if err := registerSwapRecipients(context, s.tx.TokenService(), terms, me, recipients); err != nil {
+		return fail(err)
+	}
+
+	// append the transfers of each party
+	if err := appendSwapTransfers(s.tx, w, terms, me, recipients); err != nil {
+		return fail(err)
+	}
+	for _, party := range others {
+		raw, err := s.tx.Bytes()
+		if err != nil {
+			return fail(errors.WithMessagef(err, "failed marshalling transaction"))
+		}
+		reply, err := exchangeSwapMessage(ctx, sessions[party.UniqueID()], &SwapMessage{TX: raw, Recipients: recipients})
+		if err != nil {
+			return fail(errors.WithMessagef(err, "party [%s] did not append its transfers", party))
+		}
+		if err := s.merge(context, party, reply.TX); err != nil {
+			return fail(err)
+		}
+	}
+
+	// let each party check the assembled transaction
+	if err := s.tx.IsValid(); err != nil {
+		return fail(errors.WithMessagef(err, "invalid transaction [%s]", s.tx.ID()))
+	}
+	if err := checkSwap(s.tx, terms, me); err != nil {
+		return fail(err)
+	}
+	raw, err := s.tx.Bytes()
+	if err != nil {
+		return fail(errors.WithMessagef(err, "failed marshalling transaction"))
+	}
+	for _, party := range others {
+		if _, err := exchangeSwapMessage(ctx, sessions[party.UniqueID()], &SwapMessage{TX: raw}); err != nil {
+			return fail(errors.WithMessagef(err, "party [%s] did not accept the transaction", party))
+		}
+	}
+
+	if _, err := context.RunView(NewCollectEndorsementsView(s.tx)); err != nil {
+		return errors.WithMessagef(err, "failed collecting endorsements for swap [%s]", terms.ID)
+	}
+	return nil
+}
+
+// merge replaces the transaction with the one sent back by the passed party, which must only append transfers to it
+func (s *SwapView) merge(context view.Context, party view.Identity, raw []byte) error {
+	previous := s.tx.TokenRequest.Actions.Transfers
+	payload := &Payload{
+		Transient:    map[string][]byte{},
+		TokenRequest: token.NewRequest(nil, ""),
+	}
+	if err := unmarshal(s.tx.NetworkProvider, payload, raw); err != nil {
+		return errors.Wrapf(err, "failed unmarshalling transaction from [%s]", party)
+	}
+	payload.TokenRequest.SetTokenService(s.tx.TokenService())
+	if payload.TokenRequest.ID() != s.tx.ID() {
+		return errors.Errorf("party [%s] sent back transaction [%s], expected [%s]", party, payload.TokenRequest.ID(), s.tx.ID())
+	}
+	if err := checkSwapPrefix(previous, payload.TokenRequest.Actions.Transfers); err != nil {
+		return errors.WithMessagef(err, "party [%s] modified the transaction", party)
+	}
+	if err := s.tx.appendPayload(payload); err != nil {
+		return errors.WithMessagef(err, "failed appending payload")
+	}
+	return bindSwapSenders(view2.GetEndpointService(context), s.tx.TokenRequest, len(previous), party)
+}
+
+// RespondSwapView is the responder of SwapView.
+// The view accepts the terms, if the policy does, appends the transfers of this party spending the tokens of the passed wallet,
+// checks that the assembled transaction settles the legs of this party, and nothing more, and endorses it.
+// Any failure aborts the swap.
+// The view returns the endorsed transaction, whose finality can then be awaited with FinalityView.
+type RespondSwapView struct {
+	wallet string
+	policy SwapPolicy
+}
+
+// NewRespondSwapView returns a new RespondSwapView. If the passed policy is nil, any terms are accepted.
+func NewRespondSwapView(wallet string, policy SwapPolicy) *RespondSwapView {
+	return &RespondSwapView{wallet: wallet, policy: policy}
+}
+
+// Call runs the protocol of RespondSwapView.
+// If it fails, the initiator is notified of the abort, unless the initiator aborted first.
+func (r *RespondSwapView) Call(context view.Context) (interface{}, error) {
+	session, proposal, err := session2.ReadFirstMessage(context)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read first message")
+	}
+	ctx := context.Context()
+	fail := func(err error) (interface{}, error) {
+		if sendErr := sendSwapMessage(ctx, session, &SwapMessage{Abort: err.Error()}); sendErr != nil {
+			logger.Warnf("failed notifying swap abort: %s", sendErr)
+		}
+		return nil, err
+	}
+	msg := &SwapMessage{}
+	if err := msg.FromBytes(proposal); err != nil {
+		return fail(errors.Wrapf(err, "failed unmarshalling swap proposal"))
+	}
+
+	// accept the terms
+	terms := msg.Terms
+	if terms == nil {
+		return fail(errors.New("missing swap terms"))
+	}
+	if err := terms.Validate(); err != nil {
+		return fail(errors.WithMessagef(err, "invalid swap terms"))
+	}
+	me := context.Me()
+	if !terms.IsParty(me) {
+		return fail(errors.Errorf("[%s] is not a party of swap [%s]", me, terms.ID))
+	}
+	if r.policy != nil {
+		if err := r.policy(terms); err != nil {
+			return fail(errors.WithMessagef(err, "swap [%s] rejected", terms.ID))
+		}
+	}
+	w := GetWallet(context, r.wallet, token.WithTMSID(terms.TMSID))
+	if w == nil {
+		return fail(errors.Errorf("wallet [%s:%s] not found", r.wallet, terms.TMSID))
+	}
+	recipientData, err := swapRecipientData(context, w)
+	if err != nil {
+		return fail(err)
+	}
+	if err := sendSwapMessage(ctx, session, &SwapMessage{RecipientData: recipientData}); err != nil {
+		return nil, err
+	}
+
+	// append my transfers
+	msg, err = receiveSwapMessage(session)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := r.appendTransfers(context, w, terms, msg.TX, msg.Recipients)
+	if err != nil {
+		return fail(err)
+	}
+	raw, err := tx.Bytes()
+	if err != nil {
+		return fail(errors.WithMessagef(err, "failed marshalling transaction"))
+	}
+	if err := sendSwapMessage(ctx, session, &SwapMessage{TX: raw}); err != nil {
+		return nil, err
+	}
+
+	// check the assembled transaction
+	msg, err = receiveSwapMessage(session)
+	if err != nil {
+		return nil, err
+	}
+	final, request, err := r.check(context, tx, terms, msg.TX)
+	if err != nil {
+		return fail(err)
+	}
+	if err := sendSwapMessage(ctx, session, &SwapMessage{}); err != nil {
+		return nil, err
+	}
+
+	// endorse only the checked token request
+	if _, err := context.RunView(&EndorseView{tx: final, expectedRequest: request}); err != nil {
+		return nil, errors.WithMessagef(err, "failed endorsing swap [%s]", terms.ID)
+	}
+	return final, nil
+}
+
+// appendTransfers appends the transfers of this party to the passed transaction
+func (r *RespondSwapView) appendTransfers(context view.Context, w *token.OwnerWallet, terms *SwapTerms, raw []byte, recipients map[string]*RecipientData) (*Transaction, error) {
+	tx, err := NewTransactionFromBytes(context, raw)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling transaction")
+	}
+	if id := string(tx.ApplicationMetadata(SwapMetadataKey)); id != terms.ID {
+		return nil, errors.Errorf("transaction [%s] settles swap [%s], expected [%s]", tx.ID(), id, terms.ID)
+	}
+	if !tx.TMSID().Equal(terms.TMSID) {
+		return nil, errors.Errorf("transaction [%s] is on [%s], expected [%s]", tx.ID(), tx.TMSID(), terms.TMSID)
+	}
+	me := context.Me()
+	if err := registerSwapRecipients(context, tx.TokenService(), terms, me, recipients); err != nil {
+		return nil, err
+	}
+	if err := appendSwapTransfers(tx, w, terms, me, recipients); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// check checks that the passed assembled transaction extends the one of this party and settles its legs.
+// It returns the assembled transaction and its token request to sign.
+func (r *RespondSwapView) check(context view.Context, tx *Transaction, terms *SwapTerms, raw []byte) (*Transaction, []byte, error) {
+	final, err := NewTransactionFromBytes(context, raw)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed unmarshalling transaction")
+	}
+	if final.ID() != tx.ID() {
+		return nil, nil, errors.Errorf("received transaction [%s], expected [%s]", final.ID(), tx.ID())
+	}
+	if err := final.IsValid(); err != nil {
+		return nil, nil, errors.WithMessagef(err, "invalid transaction [%s]", final.ID())
+	}
+	if err := checkSwap(final, terms, context.Me()); err != nil {
+		return nil, nil, err
+	}
+	request, err := final.TokenRequest.MarshalToSign()
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed marshalling token request")
+	}
+	return final, request, nil
+}
+
+func sendSwapMessage(ctx context.Context, session view.Session, msg *SwapMessage) error {
+	raw, err := msg.Bytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed marshalling swap message")
+	}
+	if err := session.SendWithContext(ctx, raw); err != nil {
+		return errors.Wrapf(err, "failed sending swap message")
+	}
+	return nil
+}
+
+func receiveSwapMessage(session view.Session) (*SwapMessage, error) {
+	raw, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading swap message")
+	}
+	msg := &SwapMessage{}
+	if err := msg.FromBytes(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling swap message")
+	}
+	if len(msg.Abort) != 0 {
+		return nil, errors.Errorf("swap aborted: %s", msg.Abort)
+	}
+	return msg, nil
+}
+
+func exchangeSwapMessage(ctx context.Context, session view.Session, msg *SwapMessage) (*SwapMessage, error) {
+	if err := sendSwapMessage(ctx, session, msg); err != nil {
+		return nil, err
+	}
+	return receiveSwapMessage(session)
+}
+
+// swapRecipientData derives a fresh recipient identity from the passed wallet, and binds it to this FSC node
+func swapRecipientData(context view.Context, w *token.OwnerWallet) (*RecipientData, error) {
+	id, err := w.GetRecipientIdentity()
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting recipient identity")
+	}
+	auditInfo, err := w.GetAuditInfo(id)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting audit info")
+	}
+	tokenMetadata, err := w.GetTokenMetadata(id)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting token metadata")
+	}
+	if err := view2.GetEndpointService(context).Bind(context.Me(), id); err != nil {
+		return nil, errors.WithMessagef(err, "failed binding [%s] to [%s]", id, context.Me())
+	}
+	return &RecipientData{Identity: id, AuditInfo: auditInfo, TokenMetadata: tokenMetadata}, nil
+}
+
+// registerSwapRecipients registers the recipient identities of the other parties, and binds them to their FSC nodes
+func registerSwapRecipients(context view.Context, tms *token.ManagementService, terms *SwapTerms, me view.Identity, recipients map[string]*RecipientData) error {
+	es := view2.GetEndpointService(context)
+	for _, party := range terms.Parties() {
+		if party.Equal(me) {
+			continue
+		}
+		data, ok := recipients[party.UniqueID()]
+		if !ok || data == nil {
+			return errors.Errorf("missing recipient identity of [%s]", party)
+		}
+		if err := tms.WalletManager().RegisterRecipientIdentity(data); err != nil {
+			return errors.WithMessagef(err, "failed registering recipient identity of [%s]", party)
+		}
+		if err := es.Bind(party, data.Identity); err != nil {
+			return errors.WithMessagef(err, "failed binding [%s] to [%s]", data.Identity, party)
+		}
+	}
+	return nil
+}
+
+// appendSwapTransfers appends a transfer, for each token type, settling the legs sent by the passed party
+func appendSwapTransfers(tx *Transaction, w *token.OwnerWallet, terms *SwapTerms, party view.Identity, recipients map[string]*RecipientData) error {
+	var types []string
+	values := map[string][]uint64{}
+	owners := map[string][]view.Identity{}
+	for _, leg := range terms.Legs {
+		if !leg.From.Equal(party) {
+			continue
+		}
+		recipient, ok := recipients[leg.To.UniqueID()]
+		if !ok || recipient == nil {
+			return errors.Errorf("missing recipient identity of [%s]", leg.To)
+		}
+		if _, ok := values[leg.Type]; !ok {
+			types = append(types, leg.Type)
+		}
+		values[leg.Type] = append(values[leg.Type], leg.Amount)
+		owners[leg.Type] = append(owners[leg.Type], recipient.Identity)
+	}
+	for _, typ := range types {
+		if err := tx.Transfer(w, typ, values[typ], owners[typ]); err != nil {
+			return errors.WithMessagef(err, "failed appending transfer of [%s]", typ)
+		}
+	}
+	return nil
+}
+
+// checkSwapPrefix checks that the passed transfer actions start with the previous ones
+func checkSwapPrefix(previous, current [][]byte) error {
+	if len(current) < len(previous) {
+		return errors.Errorf("expected at least [%d] transfers, got [%d]", len(previous), len(current))
+	}
+	for i, action := range previous {
+		if !bytes.Equal(action, current[i]) {
+			return errors.Errorf("transfer [%d] has been modified", i)
+		}
+	}
+	return nil
+}
+
+// bindSwapSenders binds the senders of the transfers, starting at the passed index, to the passed party
+func bindSwapSenders(binder token.Binder, request *token.Request, from int, party view.Identity) error {
+	wm := request.TokenService.WalletManager()
+	for i := from; i < len(request.Metadata.Transfers); i++ {
+		metadata := request.Metadata.Transfers[i]
+		signers := append(append([]token.Identity{}, metadata.Senders...), metadata.ExtraSigners...)
+		for _, eid := range signers {
+			if w := wm.Wallet(eid); w != nil {
+				continue
+			}
+			if err := binder.Bind(party, eid); err != nil {
+				return errors.Wrapf(err, "failed binding [%s] to [%s]", eid, party)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSwap checks that the passed transaction settles the legs of the passed party, whose wallets are local
+func checkSwap(tx *Transaction, terms *SwapTerms, me view.Identity) error {
+	inputs, outputs, err := tx.InputsAndOutputs()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting inputs and outputs of [%s]", tx.ID())
+	}
+	wm := tx.TokenService().WalletManager()
+	isMine := func(id token.Identity) bool {
+		return !id.IsNone() && wm.OwnerWallet(id) != nil
+	}
+	return checkSwapExpectations(terms, me, inputs, outputs, isMine)
+}
+
+// checkSwapExpectations checks that, for each token type, the tokens the passed party receives minus the ones it spends
+// are at least the ones expected by the terms. Token types not in the terms must not decrease.
+func checkSwapExpectations(terms *SwapTerms, party view.Identity, inputs *token.InputStream, outputs *token.OutputStream, isMine func(token.Identity) bool) error {
+	net := map[string]*big.Int{}
+	add := func(typ string, v *big.Int) {
+		if n, ok := net[typ]; ok {
+			n.Add(n, v)
+		} else {
+			net[typ] = new(big.Int).Set(v)
+		}
+	}
+	spent := inputs.Filter(func(i *token.Input) bool { return isMine(i.Owner) })
+	for _, typ := range spent.TokenTypes() {
+		add(typ, new(big.Int).Neg(spent.ByType(typ).Sum()))
+	}
+	received := outputs.Filter(func(o *token.Output) bool { return isMine(o.Owner) })
+	for _, typ := range received.TokenTypes() {
+		add(typ, received.ByType(typ).Sum())
+	}
+
+	expectations := terms.Expectations(party)
+	types := make([]string, 0, len(net)+len(expectations))
+	for typ := range net {
+		types = append(types, typ)
+	}
+	for typ := range expectations {
+		if _, ok := net[typ]; !ok {
+			types = append(types, typ)
+		}
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		got, ok := net[typ]
+		if !ok {
+			got = big.NewInt(0)
+		}
+		want, ok := expectations[typ]
+		if !ok {
+			want = big.NewInt(0)
+		}
+		if got.Cmp(want) < 0 {
+			return errors.Errorf("swap [%s]: expected a net amount of [%s] tokens of type [%s], got [%s]", terms.ID, want, typ, got)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/stretchr/testify/assert"
+)
+
+const precision = 64
+
+var (
+	alice   = view.Identity("alice")
+	bob     = view.Identity("bob")
+	charlie = view.Identity("charlie")
+)
+
+// circularSwap is a three-party circular trade: alice pays bob in USD, bob pays charlie in EUR, charlie pays alice in GBP
+func circularSwap() *SwapTerms {
+	return &SwapTerms{
+		ID: "swap",
+		Legs: []*SwapLeg{
+			{From: alice, To: bob, Type: "USD", Amount: 10},
+			{From: bob, To: charlie, Type: "EUR", Amount: 20},
+			{From: charlie, To: alice, Type: "GBP", Amount: 30},
+		},
+	}
+}
+
+// owner returns the identity owning the tokens of the passed party in the transaction
+func owner(party view.Identity) token.Identity {
+	return token.Identity("owner-" + party.String())
+}
+
+func input(party view.Identity, typ string, amount uint64) *token.Input {
+	return &token.Input{Owner: owner(party), Type: typ, Quantity: quantity(amount)}
+}
+
+func output(party view.Identity, typ string, amount uint64) *token.Output {
+	return &token.Output{Owner: owner(party), Type: typ, Quantity: quantity(amount)}
+}
+
+func quantity(amount uint64) token2.Quantity {
+	q, err := token2.UInt64ToQuantity(amount, precision)
+	if err != nil {
+		panic(err)
+	}
+	return q
+}
+
+func isMine(party view.Identity) func(token.Identity) bool {
+	return func(id token.Identity) bool {
+		return id.Equal(owner(party))
+	}
+}
+
+func checkCircular(party view.Identity, inputs []*token.Input, outputs []*token.Output) error {
+	return checkSwapExpectations(
+		circularSwap(),
+		party,
+		token.NewInputStream(nil, inputs, precision),
+		token.NewOutputStream(outputs, precision),
+		isMine(party),
+	)
+}
+
+func TestSwapTermsValidate(t *testing.T) {
+	assert.NoError(t, circularSwap().Validate())
+
+	terms := circularSwap()
+	terms.ID = ""
+	assert.EqualError(t, terms.Validate(), "missing swap id")
+
+	terms = circularSwap()
+	terms.Legs = nil
+	assert.EqualError(t, terms.Validate(), "no legs")
+
+	terms = circularSwap()
+	terms.Legs[1].To = bob
+	assert.EqualError(t, terms.Validate(), "leg [1] transfers to its sender")
+
+	terms = circularSwap()
+	terms.Legs[2].From = nil
+	assert.EqualError(t, terms.Validate(), "leg [2] is missing a party")
+
+	terms = circularSwap()
+	terms.Legs[0].Amount = 0
+	assert.EqualError(t, terms.Validate(), "leg [0] has zero amount")
+
+	terms = circularSwap()
+	terms.Legs[0].Type = ""
+	assert.EqualError(t, terms.Validate(), "leg [0] is missing the token type")
+}
+
+func TestSwapTermsParties(t *testing.T) {
+	terms := circularSwap()
+	assert.Equal(t, []view.Identity{alice, bob, charlie}, terms.Parties())
+	assert.True(t, terms.IsParty(charlie))
+	assert.False(t, terms.IsParty(view.Identity("dave")))
+
+	assert.Equal(t, map[string]*big.Int{"USD": big.NewInt(-10), "GBP": big.NewInt(30)}, terms.Expectations(alice))
+	assert.Equal(t, map[string]*big.Int{"USD": big.NewInt(10), "EUR": big.NewInt(-20)}, terms.Expectations(bob))
+	assert.Equal(t, map[string]*big.Int{"EUR": big.NewInt(20), "GBP": big.NewInt(-30)}, terms.Expectations(charlie))
+	assert.Empty(t, terms.Expectations(view.Identity("dave")))
+
+	// legs of the same type add up
+	terms.Legs = append(terms.Legs, &SwapLeg{From: bob, To: alice, Type: "USD", Amount: 4})
+	assert.Equal(t, map[string]*big.Int{"USD": big.NewInt(-6), "GBP": big.NewInt(30)}, terms.Expectations(alice))
+}
+
+func TestCircularSwapExpectations(t *testing.T) {
+	// the settling transaction: each party spends a token bigger than needed and gets the rest back
+	inputs := []*token.Input{
+		input(alice, "USD", 15),
+		input(bob, "EUR", 20),
+		input(charlie, "GBP", 50),
+	}
+	outputs := []*token.Output{
+		output(bob, "USD", 10),
+		output(alice, "USD", 5),
+		output(charlie, "EUR", 20),
+		output(alice, "GBP", 30),
+		output(charlie, "GBP", 20),
+	}
+	for _, party := range []view.Identity{alice, bob, charlie} {
+		assert.NoError(t, checkCircular(party, inputs, outputs), "party [%s]", party)
+	}
+
+	// a party not in the swap whose tokens are not touched accepts too
+	assert.NoError(t, checkSwapExpectations(circularSwap(), view.Identity("dave"),
+		token.NewInputStream(nil, inputs, precision), token.NewOutputStream(outputs, precision), isMine(view.Identity("dave"))))
+}
+
+func TestCircularSwapRejected(t *testing.T) {
+	// charlie pays alice less than agreed
+	inputs := []*token.Input{
+		input(alice, "USD", 10),
+		input(bob, "EUR", 20),
+		input(charlie, "GBP", 30),
+	}
+	outputs := []*token.Output{
+		output(bob, "USD", 10),
+		output(charlie, "EUR", 20),
+		output(alice, "GBP", 25),
+		output(charlie, "GBP", 5),
+	}
+	assert.EqualError(t, checkCircular(alice, inputs, outputs), "swap [swap]: expected a net amount of [30] tokens of type [GBP], got [25]")
+	assert.NoError(t, checkCircular(bob, inputs, outputs))
+	assert.NoError(t, checkCircular(charlie, inputs, outputs))
+
+	// alice's change is not returned
+	inputs = []*token.Input{
+		input(alice, "USD", 15),
+		input(bob, "EUR", 20),
+		input(charlie, "GBP", 30),
+	}
+	outputs = []*token.Output{
+		output(bob, "USD", 15),
+		output(charlie, "EUR", 20),
+		output(alice, "GBP", 30),
+	}
+	assert.EqualError(t, checkCircular(alice, inputs, outputs), "swap [swap]: expected a net amount of [-10] tokens of type [USD], got [-15]")
+
+	// alice's tokens of a type not in the terms are spent
+	inputs = []*token.Input{
+		input(alice, "USD", 10),
+		input(alice, "CHF", 1),
+		input(bob, "EUR", 20),
+		input(charlie, "GBP", 30),
+	}
+	outputs = []*token.Output{
+		output(bob, "USD", 10),
+		output(bob, "CHF", 1),
+		output(charlie, "EUR", 20),
+		output(alice, "GBP", 30),
+	}
+	assert.EqualError(t, checkCircular(alice, inputs, outputs), "swap [swap]: expected a net amount of [0] tokens of type [CHF], got [-1]")
+	assert.NoError(t, checkCircular(bob, inputs, outputs))
+
+	// bob's leg is missing
+	inputs = []*token.Input{
+		input(alice, "USD", 10),
+		input(charlie, "GBP", 30),
+	}
+	outputs = []*token.Output{
+		output(bob, "USD", 10),
+		output(alice, "GBP", 30),
+	}
+	assert.EqualError(t, checkCircular(charlie, inputs, outputs), "swap [swap]: expected a net amount of [20] tokens of type [EUR], got [0]")
+}
+
+func TestCheckSwapPrefix(t *testing.T) {
+	assert.NoError(t, checkSwapPrefix(nil, [][]byte{[]byte("a")}))
+	assert.NoError(t, checkSwapPrefix([][]byte{[]byte("a")}, [][]byte{[]byte("a"), []byte("b")}))
+	assert.EqualError(t, checkSwapPrefix([][]byte{[]byte("a"), []byte("b")}, [][]byte{[]byte("a")}), "expected at least [2] transfers, got [1]")
+	assert.EqualError(t, checkSwapPrefix([][]byte{[]byte("a")}, [][]byte{[]byte("c"), []byte("b")}), "transfer [0] has been modified")
+}
@@ -40,6 +40,8 @@ type Transaction struct {
 	NetworkProvider GetNetworkFunc
 	Opts            *TxOptions
 	Context         context.Context
+
+	previous *Submission
 }
 
 // NewAnonymousTransaction returns a new anonymous token transaction customized with the passed opts
@@ -73,6 +75,13 @@ func NewTransaction(context view.Context, signer view.Identity, opts ...TxOption
 		context,
 		token.WithTMSID(txOpts.TMSID),
 	)
+	if len(txOpts.IdempotencyKey) != 0 {
+		return newIdempotentTransaction(context, tms, signer, txOpts)
+	}
+	return newTransaction(context, tms, signer, txOpts)
+}
+
+func newTransaction(context view.Context, tms *token.ManagementService, signer view.Identity, txOpts *TxOptions) (*Transaction, error) {
 	networkService := network.GetInstance(context, tms.Network(), tms.Channel())
 	networkProvider := network.GetProvider(context).GetNetwork
 
@@ -210,17 +219,26 @@ func (t *Transaction) Bytes(eIDs ...string) ([]byte, error) {
 
 // Issue appends a new Issue operation to the TokenRequest inside this transaction
 func (t *Transaction) Issue(wallet *token.IssuerWallet, receiver view.Identity, typ string, q uint64, opts ...token.IssueOption) error {
+	if err := t.checkModifiable(); err != nil {
+		return err
+	}
 	_, err := t.TokenRequest.Issue(t.Context, wallet, receiver, typ, q, opts...)
 	return err
 }
 
 // Transfer appends a new Transfer operation to the TokenRequest inside this transaction
 func (t *Transaction) Transfer(wallet *token.OwnerWallet, typ string, values []uint64, owners []view.Identity, opts ...token.TransferOption) error {
+	if err := t.checkModifiable(); err != nil {
+		return err
+	}
 	_, err := t.TokenRequest.Transfer(t.Context, wallet, typ, values, owners, opts...)
 	return err
 }
 
 func (t *Transaction) Redeem(wallet *token.OwnerWallet, typ string, value uint64, opts ...token.TransferOption) error {
+	if err := t.checkModifiable(); err != nil {
+		return err
+	}
 	return t.TokenRequest.Redeem(t.Context, wallet, typ, value, opts...)
 }
 
@@ -0,0 +1,554 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	view2 "github.com/hyperledger-labs/fabric-smart-client/platform/view"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/services/kvs"
+	session2 "github.com/hyperledger-labs/fabric-smart-client/platform/view/services/session"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/auditor"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// TravelRuleAttachment is the name of the off-ledger attachment carrying the travel-rule information of a transaction.
+// The attachment travels with the transaction in its transient map, under the same key.
+const TravelRuleAttachment = "ttx.travel_rule"
+
+// travelRuleKeyID is the KVS key of the travel-rule encryption key of this node
+const travelRuleKeyID = "ttx.travel_rule.key"
+
+// TravelRuleKeyFileConfigKey is the configuration key of the file holding the secret that protects
+// the travel-rule encryption key of this node in the KVS
+const TravelRuleKeyFileConfigKey = "token.ttx.travelRule.keyFile"
+
+// minTravelRuleSecretLen is the minimum length of the secret protecting the travel-rule encryption key
+const minTravelRuleSecretLen = 32
+
+// travelRuleKeyLock serializes the generation of the travel-rule encryption key
+var travelRuleKeyLock sync.Mutex
+
+// TravelRuleParty describes the originator or the beneficiary of a payment
+type TravelRuleParty struct {
+	// Name is the name of the natural or legal person
+	Name string
+	// Account identifies the account of the person at its service provider
+	Account string
+	// Address is the geographic address of the person
+	Address string
+	// NationalID is a national identifier of the person, such as an identity document number or an LEI
+	NationalID string
+	// Institution is the service provider acting on behalf of the person
+	Institution string
+}
+
+// TravelRuleInfo is the originator and beneficiary information that regulated payments must carry.
+// It never goes on the ledger: it is encrypted to the readers chosen by the sender and delivered off-ledger.
+type TravelRuleInfo struct {
+	Originator  TravelRuleParty
+	Beneficiary TravelRuleParty
+	// Reference is an optional reference of the payment agreed between the parties
+	Reference string
+}
+
+// TravelRuleReader is an FSC node the travel-rule information is encrypted to
+type TravelRuleReader struct {
+	// Node is the identity of the FSC node
+	Node view.Identity
+	// PublicKey is the X25519 travel-rule encryption key of the node
+	PublicKey []byte
+}
+
+// TravelRuleWrappedKey is the content key of a TravelRuleEnvelope encrypted to one of its readers
+type TravelRuleWrappedKey struct {
+	// PublicKey is the travel-rule encryption key of the reader
+	PublicKey []byte
+	// Key is the content key, encrypted under the key agreed between the ephemeral key and PublicKey
+	Key []byte
+}
+
+// TravelRuleEnvelope is the encrypted travel-rule information of a transaction.
+// The information is encrypted with AES-GCM under a fresh content key, itself encrypted to each reader
+// using a key agreed via X25519 with the ephemeral key of the envelope.
+// Binding is the hash of the transaction id and of the token request actions, it is authenticated with the ciphertext.
+type TravelRuleEnvelope struct {
+	TxID         string
+	Binding      []byte
+	EphemeralKey []byte
+	Ciphertext   []byte
+	Readers      []*TravelRuleWrappedKey
+}
+
+func (e *TravelRuleEnvelope) Bytes() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (e *TravelRuleEnvelope) FromBytes(raw []byte) error {
+	return json.Unmarshal(raw, e)
+}
+
+// Open decrypts the envelope with the passed travel-rule encryption key.
+// It fails if the envelope has not been encrypted to that key.
+func (e *TravelRuleEnvelope) Open(sk *ecdh.PrivateKey) (*TravelRuleInfo, error) {
+	pk := sk.PublicKey().Bytes()
+	var wrapped *TravelRuleWrappedKey
+	for _, reader := range e.Readers {
+		if bytes.Equal(reader.PublicKey, pk) {
+			wrapped = reader
+			break
+		}
+	}
+	if wrapped == nil {
+		return nil, errors.Errorf("travel-rule information of [%s] not encrypted to this node", e.TxID)
+	}
+	ephemeral, err := ecdh.X25519().NewPublicKey(e.EphemeralKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid ephemeral key")
+	}
+	kek, err := travelRuleKEK(sk, ephemeral, e.EphemeralKey, pk)
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := aeadOpen(kek, wrapped.Key, e.additionalData())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed decrypting content key")
+	}
+	raw, err := aeadOpen(contentKey, e.Ciphertext, e.additionalData())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed decrypting travel-rule information")
+	}
+	info := &TravelRuleInfo{}
+	if err := json.Unmarshal(raw, info); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling travel-rule information")
+	}
+	return info, nil
+}
+
+func (e *TravelRuleEnvelope) additionalData() []byte {
+	return append([]byte(e.TxID), e.Binding...)
+}
+
+// sealTravelRuleInfo encrypts the passed information to the passed X25519 public keys, binding it to the passed transaction
+func sealTravelRuleInfo(info *TravelRuleInfo, txID string, binding []byte, readers ...[]byte) (*TravelRuleEnvelope, error) {
+	if len(readers) == 0 {
+		return nil, errors.New("no readers for the travel-rule information")
+	}
+	raw, err := json.Marshal(info)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling travel-rule information")
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed generating ephemeral key")
+	}
+	contentKey := make([]byte, 32)
+	if _, err := rand.Read(contentKey); err != nil {
+		return nil, errors.Wrapf(err, "failed generating content key")
+	}
+	e := &TravelRuleEnvelope{
+		TxID:         txID,
+		Binding:      binding,
+		EphemeralKey: ephemeral.PublicKey().Bytes(),
+	}
+	e.Ciphertext, err = aeadSeal(contentKey, raw, e.additionalData())
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed encrypting travel-rule information")
+	}
+	for _, reader := range readers {
+		if containsReader(e.Readers, reader) {
+			continue
+		}
+		pk, err := ecdh.X25519().NewPublicKey(reader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid travel-rule encryption key")
+		}
+		kek, err := travelRuleKEK(ephemeral, pk, e.EphemeralKey, reader)
+		if err != nil {
+			return nil, err
+		}
+		wrapped, err := aeadSeal(kek, contentKey, e.additionalData())
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed encrypting content key")
+		}
+		e.Readers = append(e.Readers, &TravelRuleWrappedKey{PublicKey: reader, Key: wrapped})
+	}
+	return e, nil
+}
+
+func containsReader(readers []*TravelRuleWrappedKey, pk []byte) bool {
+	for _, reader := range readers {
+		if bytes.Equal(reader.PublicKey, pk) {
+			return true
+		}
+	}
+	return false
+}
+
+// travelRuleKEK derives with HKDF-SHA256 the key encrypting the content key for the reader with the passed public key.
+// The salt binds the derived key to the ephemeral key of the envelope and to the key of the reader.
+func travelRuleKEK(sk *ecdh.PrivateKey, pk *ecdh.PublicKey, ephemeralPK []byte, readerPK []byte) ([]byte, error) {
+	shared, err := sk.ECDH(pk)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed agreeing key")
+	}
+	salt := append(append([]byte{}, ephemeralPK...), readerPK...)
+	return deriveKey(shared, salt, TravelRuleAttachment)
+}
+
+// deriveKey derives a 256-bit key from the passed secret with HKDF-SHA256
+func deriveKey(secret []byte, salt []byte, info string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, []byte(info)), key); err != nil {
+		return nil, errors.Wrapf(err, "failed deriving key")
+	}
+	return key, nil
+}
+
+func aeadSeal(key []byte, plaintext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, errors.Wrapf(err, "failed generating nonce")
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func aeadOpen(key []byte, ciphertext []byte, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed creating cipher")
+	}
+	return cipher.NewGCM(block)
+}
+
+// travelRuleBinding returns the hash of the transaction id and of the issue and transfer actions of its token request.
+// Signatures and metadata are left out, the former are collected after the binding, the latter are filtered per recipient.
+func travelRuleBinding(tx *Transaction) ([]byte, error) {
+	if tx.TokenRequest == nil || tx.TokenRequest.Actions == nil {
+		return nil, errors.Errorf("token request of [%s] is empty", tx.ID())
+	}
+	raw, err := (&driver.TokenRequest{
+		Issues:    tx.TokenRequest.Actions.Issues,
+		Transfers: tx.TokenRequest.Actions.Transfers,
+	}).Bytes()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling token request actions of [%s]", tx.ID())
+	}
+	h := sha256.New()
+	h.Write([]byte(tx.ID()))
+	h.Write(raw)
+	return h.Sum(nil), nil
+}
+
+// AttachTravelRuleInfo encrypts the passed travel-rule information to the passed readers and to this node,
+// and attaches it to the transaction, bound to its token request.
+// It must be invoked once the issues and transfers of the transaction are complete:
+// the transaction fails to collect endorsements if its token request changes afterward.
+// To make the information visible to the auditor, include the auditor's node among the readers.
+// The information is delivered to the parties with the transaction and stored in their ttxdb.
+func AttachTravelRuleInfo(context view.Context, tx *Transaction, info *TravelRuleInfo, readers ...*TravelRuleReader) error {
+	pk, err := TravelRulePublicKey(context)
+	if err != nil {
+		return errors.WithMessage(err, "failed getting travel-rule encryption key")
+	}
+	keys := [][]byte{pk}
+	for _, reader := range readers {
+		keys = append(keys, reader.PublicKey)
+	}
+	binding, err := travelRuleBinding(tx)
+	if err != nil {
+		return err
+	}
+	e, err := sealTravelRuleInfo(info, tx.ID(), binding, keys...)
+	if err != nil {
+		return errors.WithMessagef(err, "failed encrypting travel-rule information of [%s]", tx.ID())
+	}
+	raw, err := e.Bytes()
+	if err != nil {
+		return errors.Wrapf(err, "failed marshalling travel-rule envelope")
+	}
+	tx.Transient[TravelRuleAttachment] = raw
+	return nil
+}
+
+// TravelRuleEnvelopeOf returns the travel-rule envelope attached to the passed transaction, nil if none is attached.
+// The envelope is not checked against the transaction.
+func TravelRuleEnvelopeOf(tx *Transaction) (*TravelRuleEnvelope, error) {
+	raw, ok := tx.Transient[TravelRuleAttachment]
+	if !ok || len(raw) == 0 {
+		return nil, nil
+	}
+	e := &TravelRuleEnvelope{}
+	if err := e.FromBytes(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling travel-rule envelope of [%s]", tx.ID())
+	}
+	return e, nil
+}
+
+// checkTravelRule checks that the travel-rule envelope attached to the passed transaction, if any, is bound to it
+func checkTravelRule(tx *Transaction) error {
+	e, err := TravelRuleEnvelopeOf(tx)
+	if err != nil || e == nil {
+		return err
+	}
+	if e.TxID != tx.ID() {
+		return errors.Errorf("travel-rule information bound to [%s], expected [%s]", e.TxID, tx.ID())
+	}
+	binding, err := travelRuleBinding(tx)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(binding, e.Binding) {
+		return errors.Errorf("travel-rule information not bound to the token request of [%s]", tx.ID())
+	}
+	return nil
+}
+
+// auditTravelRule checks that the travel-rule envelope attached to the passed transaction, if any,
+// is bound to it and encrypted to this auditor's node.
+// An auditor that cannot read the information would store it without being able to produce it.
+func auditTravelRule(sp token.ServiceProvider, tx *Transaction) error {
+	e, err := TravelRuleEnvelopeOf(tx)
+	if err != nil || e == nil {
+		return err
+	}
+	sk, err := travelRuleKey(sp)
+	if err != nil {
+		return errors.WithMessage(err, "failed getting travel-rule encryption key")
+	}
+	return checkTravelRuleReader(tx, sk)
+}
+
+// checkTravelRuleReader checks that the travel-rule envelope attached to the passed transaction, if any,
+// is bound to it and can be opened with the passed key
+func checkTravelRuleReader(tx *Transaction, sk *ecdh.PrivateKey) error {
+	if err := checkTravelRule(tx); err != nil {
+		return err
+	}
+	e, err := TravelRuleEnvelopeOf(tx)
+	if err != nil || e == nil {
+		return err
+	}
+	if _, err := e.Open(sk); err != nil {
+		return errors.WithMessagef(err, "travel-rule information of [%s] not readable by the auditor", tx.ID())
+	}
+	return nil
+}
+
+// ReadTravelRuleInfo decrypts the travel-rule information attached to the passed transaction.
+// It returns nil if the transaction carries none, and an error if the information is not encrypted to this node.
+// Recipients can use it, for instance in a ReceivePolicy, before acknowledging the transaction.
+func ReadTravelRuleInfo(sp token.ServiceProvider, tx *Transaction) (*TravelRuleInfo, error) {
+	if err := checkTravelRule(tx); err != nil {
+		return nil, err
+	}
+	return openTravelRuleAttachment(sp, tx.Transient[TravelRuleAttachment])
+}
+
+func openTravelRuleAttachment(sp token.ServiceProvider, raw []byte) (*TravelRuleInfo, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	e := &TravelRuleEnvelope{}
+	if err := e.FromBytes(raw); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling travel-rule envelope")
+	}
+	sk, err := travelRuleKey(sp)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting travel-rule encryption key")
+	}
+	return e.Open(sk)
+}
+
+// TravelRulePublicKey returns the X25519 travel-rule encryption key of this node
+func TravelRulePublicKey(sp token.ServiceProvider) ([]byte, error) {
+	sk, err := travelRuleKey(sp)
+	if err != nil {
+		return nil, err
+	}
+	return sk.PublicKey().Bytes(), nil
+}
+
+// travelRuleKey returns the travel-rule decryption key of this node, generating it at first use.
+// The key is kept in the KVS, encrypted under a key derived from the secret in the file configured
+// under TravelRuleKeyFileConfigKey.
+func travelRuleKey(sp token.ServiceProvider) (*ecdh.PrivateKey, error) {
+	s, err := sp.GetService(&kvs.KVS{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting KVS")
+	}
+	wrappingKey, err := travelRuleWrappingKey(view2.GetConfigService(sp))
+	if err != nil {
+		return nil, err
+	}
+	return loadTravelRuleKey(s.(*kvs.KVS), wrappingKey)
+}
+
+// travelRuleKeyStore models the KVS the travel-rule encryption key is kept in
+type travelRuleKeyStore interface {
+	Exists(id string) bool
+	Get(id string, state interface{}) error
+	Put(id string, state interface{}) error
+}
+
+// loadTravelRuleKey loads from the passed store the travel-rule encryption key, encrypted under the passed wrapping key.
+// The key is generated and stored if missing.
+func loadTravelRuleKey(store travelRuleKeyStore, wrappingKey []byte) (*ecdh.PrivateKey, error) {
+	travelRuleKeyLock.Lock()
+	defer travelRuleKeyLock.Unlock()
+	if store.Exists(travelRuleKeyID) {
+		var wrapped []byte
+		if err := store.Get(travelRuleKeyID, &wrapped); err != nil {
+			return nil, errors.Wrapf(err, "failed loading travel-rule key")
+		}
+		raw, err := aeadOpen(wrappingKey, wrapped, []byte(travelRuleKeyID))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed decrypting travel-rule key, check [%s]", TravelRuleKeyFileConfigKey)
+		}
+		return ecdh.X25519().NewPrivateKey(raw)
+	}
+	sk, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed generating travel-rule key")
+	}
+	wrapped, err := aeadSeal(wrappingKey, sk.Bytes(), []byte(travelRuleKeyID))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed encrypting travel-rule key")
+	}
+	if err := store.Put(travelRuleKeyID, wrapped); err != nil {
+		return nil, errors.Wrapf(err, "failed storing travel-rule key")
+	}
+	return sk, nil
+}
+
+// travelRuleWrappingKey derives the key protecting the travel-rule encryption key from the configured secret
+func travelRuleWrappingKey(cs *view2.ConfigService) ([]byte, error) {
+	if !cs.IsSet(TravelRuleKeyFileConfigKey) {
+		return nil, errors.Errorf("no secret to protect the travel-rule key, set [%s]", TravelRuleKeyFileConfigKey)
+	}
+	secret, err := os.ReadFile(cs.GetPath(TravelRuleKeyFileConfigKey))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading travel-rule key secret")
+	}
+	if len(secret) < minTravelRuleSecretLen {
+		return nil, errors.Errorf("travel-rule key secret must be at least %d bytes long", minTravelRuleSecretLen)
+	}
+	return deriveKey(secret, nil, travelRuleKeyID)
+}
+
+// TravelRuleInfo returns the travel-rule information of the passed transaction as stored in the ttxdb of this node.
+// It returns nil if the transaction carries none.
+func (a *TxOwner) TravelRuleInfo(txID string) (*TravelRuleInfo, error) {
+	attachments, err := a.owner.ttxDB.TransactionAttachments(txID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed loading attachments of [%s]", txID)
+	}
+	return openTravelRuleAttachment(a.sp, attachments[TravelRuleAttachment])
+}
+
+// TravelRuleInfo returns the travel-rule information of the passed audited transaction.
+// It returns nil if the transaction carries none, and an error if the sender did not encrypt it to the auditor.
+func (a *TxAuditor) TravelRuleInfo(txID string) (*TravelRuleInfo, error) {
+	attachments, err := a.auditDB.TransactionAttachments(txID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed loading attachments of [%s]", txID)
+	}
+	return openTravelRuleAttachment(a.sp, attachments[TravelRuleAttachment])
+}
+
+// RequestTravelRuleKeyView is executed by the sender of a payment to get the travel-rule encryption key of another node
+type RequestTravelRuleKeyView struct {
+	Node view.Identity
+}
+
+// RequestTravelRuleKey runs RequestTravelRuleKeyView and returns the passed node as a travel-rule reader.
+// The node must answer with RespondTravelRuleKeyView.
+func RequestTravelRuleKey(context view.Context, node view.Identity) (*TravelRuleReader, error) {
+	reader, err := context.RunView(&RequestTravelRuleKeyView{Node: node})
+	if err != nil {
+		return nil, err
+	}
+	return reader.(*TravelRuleReader), nil
+}
+
+func (r *RequestTravelRuleKeyView) Call(context view.Context) (interface{}, error) {
+	session, err := context.GetSession(context.Initiator(), r.Node)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get session with [%s]", r.Node)
+	}
+	if err := session.SendWithContext(context.Context(), []byte(travelRuleKeyID)); err != nil {
+		return nil, errors.Wrapf(err, "failed sending travel-rule key request")
+	}
+	pk, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading travel-rule key of [%s]", r.Node)
+	}
+	if _, err := ecdh.X25519().NewPublicKey(pk); err != nil {
+		return nil, errors.Wrapf(err, "invalid travel-rule key from [%s]", r.Node)
+	}
+	return &TravelRuleReader{Node: r.Node, PublicKey: pk}, nil
+}
+
+// RespondTravelRuleKeyView is the responder of RequestTravelRuleKeyView.
+// It sends back the travel-rule encryption key of this node.
+type RespondTravelRuleKeyView struct{}
+
+func (r *RespondTravelRuleKeyView) Call(context view.Context) (interface{}, error) {
+	session, _, err := session2.ReadFirstMessage(context)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read first message")
+	}
+	pk, err := TravelRulePublicKey(context)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed getting travel-rule encryption key")
+	}
+	if err := session.SendWithContext(context.Context(), pk); err != nil {
+		return nil, errors.Wrapf(err, "failed sending travel-rule key")
+	}
+	return nil, nil
+}
+
+// appendTravelRule stores in the audit db the travel-rule information attached to the passed audited transaction, if any
+func appendTravelRule(aud *auditor.Auditor, tx *Transaction) error {
+	raw := tx.Transient[TravelRuleAttachment]
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := aud.AddTransactionAttachment(tx.ID(), TravelRuleAttachment, raw); err != nil {
+		return errors.WithMessagef(err, "failed appending travel-rule information of %s", tx.ID())
+	}
+	return nil
+}
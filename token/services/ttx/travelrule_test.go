@@ -0,0 +1,156 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func travelRuleTx(id string, transfers ...[]byte) *Transaction {
+	tr := token.NewRequest(nil, id)
+	tr.Actions.Transfers = transfers
+	return &Transaction{Payload: &Payload{ID: id, TokenRequest: tr, Transient: map[string][]byte{}}}
+}
+
+func TestTravelRuleEnvelope(t *testing.T) {
+	info := &TravelRuleInfo{
+		Originator:  TravelRuleParty{Name: "Alice", Account: "alice@bank1", Institution: "Bank1"},
+		Beneficiary: TravelRuleParty{Name: "Bob", Account: "bob@bank2", Institution: "Bank2"},
+		Reference:   "invoice-42",
+	}
+	sender, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	beneficiary, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	auditor, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	other, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	e, err := sealTravelRuleInfo(info, "tx1", []byte("binding"), sender.PublicKey().Bytes(), beneficiary.PublicKey().Bytes(), auditor.PublicKey().Bytes(), beneficiary.PublicKey().Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, e.Readers, 3)
+
+	raw, err := e.Bytes()
+	assert.NoError(t, err)
+	e = &TravelRuleEnvelope{}
+	assert.NoError(t, e.FromBytes(raw))
+	for _, sk := range []*ecdh.PrivateKey{sender, beneficiary, auditor} {
+		opened, err := e.Open(sk)
+		assert.NoError(t, err)
+		assert.Equal(t, info, opened)
+	}
+	_, err = e.Open(other)
+	assert.EqualError(t, err, "travel-rule information of [tx1] not encrypted to this node")
+
+	// the binding is authenticated
+	e.Binding = []byte("another binding")
+	_, err = e.Open(beneficiary)
+	assert.Error(t, err)
+
+	_, err = sealTravelRuleInfo(info, "tx1", []byte("binding"))
+	assert.EqualError(t, err, "no readers for the travel-rule information")
+}
+
+func TestCheckTravelRule(t *testing.T) {
+	reader, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	tx := travelRuleTx("tx1", []byte("transfer1"))
+	assert.NoError(t, checkTravelRule(tx))
+
+	binding, err := travelRuleBinding(tx)
+	assert.NoError(t, err)
+	e, err := sealTravelRuleInfo(&TravelRuleInfo{Reference: "ref"}, tx.ID(), binding, reader.PublicKey().Bytes())
+	assert.NoError(t, err)
+	raw, err := e.Bytes()
+	assert.NoError(t, err)
+	tx.Transient[TravelRuleAttachment] = raw
+	assert.NoError(t, checkTravelRule(tx))
+
+	// signatures do not change the binding
+	tx.TokenRequest.Actions.Signatures = [][]byte{[]byte("sigma")}
+	assert.NoError(t, checkTravelRule(tx))
+
+	// a different token request does
+	tx.TokenRequest.Actions.Transfers = append(tx.TokenRequest.Actions.Transfers, []byte("transfer2"))
+	assert.EqualError(t, checkTravelRule(tx), "travel-rule information not bound to the token request of [tx1]")
+
+	// as well as a different transaction
+	other := travelRuleTx("tx2", []byte("transfer1"))
+	other.Transient[TravelRuleAttachment] = raw
+	assert.EqualError(t, checkTravelRule(other), "travel-rule information bound to [tx1], expected [tx2]")
+}
+
+func TestCheckTravelRuleReader(t *testing.T) {
+	auditor, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	sender, err := ecdh.X25519().GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	tx := travelRuleTx("tx1", []byte("transfer1"))
+	assert.NoError(t, checkTravelRuleReader(tx, auditor))
+
+	binding, err := travelRuleBinding(tx)
+	assert.NoError(t, err)
+	e, err := sealTravelRuleInfo(&TravelRuleInfo{Reference: "ref"}, tx.ID(), binding, sender.PublicKey().Bytes())
+	assert.NoError(t, err)
+	raw, err := e.Bytes()
+	assert.NoError(t, err)
+	tx.Transient[TravelRuleAttachment] = raw
+	assert.EqualError(t, checkTravelRuleReader(tx, auditor), "travel-rule information of [tx1] not readable by the auditor: travel-rule information of [tx1] not encrypted to this node")
+
+	e, err = sealTravelRuleInfo(&TravelRuleInfo{Reference: "ref"}, tx.ID(), binding, sender.PublicKey().Bytes(), auditor.PublicKey().Bytes())
+	assert.NoError(t, err)
+	raw, err = e.Bytes()
+	assert.NoError(t, err)
+	tx.Transient[TravelRuleAttachment] = raw
+	assert.NoError(t, checkTravelRuleReader(tx, auditor))
+
+	tx.TokenRequest.Actions.Transfers = append(tx.TokenRequest.Actions.Transfers, []byte("transfer2"))
+	assert.EqualError(t, checkTravelRuleReader(tx, auditor), "travel-rule information not bound to the token request of [tx1]")
+}
+
+type travelRuleKeyStoreMock map[string][]byte
+
+func (s travelRuleKeyStoreMock) Exists(id string) bool {
+	_, ok := s[id]
+	return ok
+}
+
+func (s travelRuleKeyStoreMock) Get(id string, state interface{}) error {
+	*state.(*[]byte) = s[id]
+	return nil
+}
+
+func (s travelRuleKeyStoreMock) Put(id string, state interface{}) error {
+	s[id] = state.([]byte)
+	return nil
+}
+
+func TestLoadTravelRuleKey(t *testing.T) {
+	store := travelRuleKeyStoreMock{}
+	wrappingKey, err := deriveKey([]byte("a secret of at least thirty-two bytes"), nil, travelRuleKeyID)
+	assert.NoError(t, err)
+
+	sk, err := loadTravelRuleKey(store, wrappingKey)
+	assert.NoError(t, err)
+	// the key is not stored in the clear
+	assert.NotContains(t, string(store[travelRuleKeyID]), string(sk.Bytes()))
+
+	loaded, err := loadTravelRuleKey(store, wrappingKey)
+	assert.NoError(t, err)
+	assert.True(t, sk.Equal(loaded))
+
+	otherKey, err := deriveKey([]byte("another secret of at least thirty-two bytes"), nil, travelRuleKeyID)
+	assert.NoError(t, err)
+	_, err = loadTravelRuleKey(store, otherKey)
+	assert.Error(t, err)
+}
@@ -0,0 +1,711 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+	"github.com/hyperledger-labs/fabric-smart-client/platform/view/view"
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+const defaultExpiryReason = "expired"
+
+type (
+	// WithdrawalRequestStatus is the status of a withdrawal request in the queue of an issuer
+	WithdrawalRequestStatus = ttxdb.WithdrawalRequestStatus
+	// WithdrawalRequestRecord is a withdrawal request stored in the queue of an issuer
+	WithdrawalRequestRecord = ttxdb.WithdrawalRequestRecord
+)
+
+const (
+	WithdrawalUnknown  = ttxdb.WithdrawalUnknown
+	WithdrawalPending  = ttxdb.WithdrawalPending
+	WithdrawalApproved = ttxdb.WithdrawalApproved
+	WithdrawalRejected = ttxdb.WithdrawalRejected
+	WithdrawalExpired  = ttxdb.WithdrawalExpired
+	WithdrawalIssued   = ttxdb.WithdrawalIssued
+	WithdrawalIssuing  = ttxdb.WithdrawalIssuing
+)
+
+// WithdrawalRequestStatusMessage maps WithdrawalRequestStatus to string
+var WithdrawalRequestStatusMessage = ttxdb.WithdrawalRequestStatusMessage
+
+// WithdrawalDecision is the outcome of the evaluation of a withdrawal request by a WithdrawalApprovalPolicy
+type WithdrawalDecision int
+
+const (
+	// WithdrawalUndecided leaves the request pending, for instance until more approvals are collected
+	WithdrawalUndecided WithdrawalDecision = iota
+	// WithdrawalApprove approves the request
+	WithdrawalApprove
+	// WithdrawalReject rejects the request
+	WithdrawalReject
+)
+
+// WithdrawalApprovalPolicy decides on the pending withdrawal requests of a WithdrawalQueue.
+// It is evaluated when a request is enqueued and every time a request gets a new approval.
+type WithdrawalApprovalPolicy interface {
+	// Decide returns the decision on the passed request, given the approvers that have approved it so far.
+	// The returned reason is attached to the request, and sent to the requester in case of rejection.
+	Decide(request *WithdrawalRequest, record *WithdrawalRequestRecord, approvals []string) (WithdrawalDecision, string, error)
+}
+
+// WithdrawalApprovalPolicyFunc is a function implementing WithdrawalApprovalPolicy
+type WithdrawalApprovalPolicyFunc func(request *WithdrawalRequest, record *WithdrawalRequestRecord, approvals []string) (WithdrawalDecision, string, error)
+
+func (f WithdrawalApprovalPolicyFunc) Decide(request *WithdrawalRequest, record *WithdrawalRequestRecord, approvals []string) (WithdrawalDecision, string, error) {
+	return f(request, record, approvals)
+}
+
+// ManualApproval returns a policy that approves a request as soon as it has been approved via WithdrawalQueue.Approve
+func ManualApproval() WithdrawalApprovalPolicy {
+	return MultiApproval(1)
+}
+
+// MultiApproval returns a policy that approves a request once it has been approved by threshold distinct approvers.
+// If approvers are passed, only their approvals count.
+func MultiApproval(threshold int, approvers ...string) WithdrawalApprovalPolicy {
+	allowed := map[string]struct{}{}
+	for _, approver := range approvers {
+		allowed[approver] = struct{}{}
+	}
+	return WithdrawalApprovalPolicyFunc(func(_ *WithdrawalRequest, _ *WithdrawalRequestRecord, approvals []string) (WithdrawalDecision, string, error) {
+		var counted []string
+		for _, approver := range approvals {
+			if _, ok := allowed[approver]; ok || len(allowed) == 0 {
+				counted = append(counted, approver)
+			}
+		}
+		if len(counted) < threshold {
+			return WithdrawalUndecided, "", nil
+		}
+		return WithdrawalApprove, "approved by " + strings.Join(counted, ", "), nil
+	})
+}
+
+// WithdrawalRule is a rule of AutomaticApproval
+type WithdrawalRule struct {
+	// TokenType is the token type the rule applies to, any type if empty
+	TokenType string
+	// MaxAmount is the maximum amount the rule applies to, any amount if zero
+	MaxAmount uint64
+	// Decision is the decision taken on the requests the rule applies to
+	Decision WithdrawalDecision
+	// Reason is attached to the requests the rule applies to
+	Reason string
+}
+
+func (r *WithdrawalRule) applies(request *WithdrawalRequest) bool {
+	if len(r.TokenType) != 0 && r.TokenType != request.TokenType {
+		return false
+	}
+	if r.MaxAmount != 0 && request.Amount > r.MaxAmount {
+		return false
+	}
+	return true
+}
+
+// AutomaticApproval returns a policy that decides on a request with the first of the passed rules that applies to it.
+// If no rule applies, or the rule leaves the request undecided, the fallback policy decides.
+// If the fallback is nil, the request stays pending.
+func AutomaticApproval(fallback WithdrawalApprovalPolicy, rules ...WithdrawalRule) WithdrawalApprovalPolicy {
+	return WithdrawalApprovalPolicyFunc(func(request *WithdrawalRequest, record *WithdrawalRequestRecord, approvals []string) (WithdrawalDecision, string, error) {
+		for _, rule := range rules {
+			if rule.applies(request) && rule.Decision != WithdrawalUndecided {
+				return rule.Decision, rule.Reason, nil
+			}
+		}
+		if fallback == nil {
+			return WithdrawalUndecided, "", nil
+		}
+		return fallback.Decide(request, record, approvals)
+	})
+}
+
+// WithdrawalOutcome is sent by the issuer to the requester of a queued withdrawal request
+// when the request is enqueued, and when it is approved, rejected or expires.
+type WithdrawalOutcome struct {
+	// ID is the identifier of the request in the queue of the issuer
+	ID string
+	// Status is the status of the request
+	Status WithdrawalRequestStatus
+	// Message is the message attached to the status, for instance the reason of a rejection
+	Message string
+}
+
+// WithdrawalQueueOptions configures a WithdrawalQueue
+type WithdrawalQueueOptions struct {
+	// Policy decides on the pending requests. ManualApproval if nil.
+	Policy WithdrawalApprovalPolicy
+	// TTL is the time a request can stay pending before it expires. The requests never expire if zero.
+	TTL time.Duration
+}
+
+// WithdrawalQueueOption models an option for NewWithdrawalQueue
+type WithdrawalQueueOption func(*WithdrawalQueueOptions) error
+
+// WithWithdrawalApprovalPolicy sets the policy deciding on the pending requests
+func WithWithdrawalApprovalPolicy(policy WithdrawalApprovalPolicy) WithdrawalQueueOption {
+	return func(o *WithdrawalQueueOptions) error {
+		o.Policy = policy
+		return nil
+	}
+}
+
+// WithWithdrawalTTL sets the time a request can stay pending before it expires
+func WithWithdrawalTTL(ttl time.Duration) WithdrawalQueueOption {
+	return func(o *WithdrawalQueueOptions) error {
+		if ttl < 0 {
+			return errors.Errorf("invalid ttl [%s]", ttl)
+		}
+		o.TTL = ttl
+		return nil
+	}
+}
+
+// WithdrawalQueue is the persistent queue of the withdrawal requests received by an issuer.
+// A request is enqueued as pending, then it is either approved, according to the approval policy of the queue,
+// rejected, or it expires. An approved request is claimed by the issuer before issuing the tokens,
+// so that it is issued once, then it is marked as issued.
+// This allows the issuer to tie the issuance to an off-chain event, such as a deposit.
+type WithdrawalQueue struct {
+	db      *ttxdb.DB
+	options *WithdrawalQueueOptions
+	now     func() time.Time
+}
+
+// NewWithdrawalQueue returns the withdrawal queue of the passed TMS
+func NewWithdrawalQueue(sp token.ServiceProvider, tmsID token.TMSID, opts ...WithdrawalQueueOption) (*WithdrawalQueue, error) {
+	db, err := ttxdb.GetByTMSId(sp, tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting ttxdb for [%s]", tmsID)
+	}
+	return newWithdrawalQueue(db, opts...)
+}
+
+func newWithdrawalQueue(db *ttxdb.DB, opts ...WithdrawalQueueOption) (*WithdrawalQueue, error) {
+	options := &WithdrawalQueueOptions{}
+	for _, opt := range opts {
+		if err := opt(options); err != nil {
+			return nil, errors.WithMessage(err, "failed compiling withdrawal queue options")
+		}
+	}
+	if options.Policy == nil {
+		options.Policy = ManualApproval()
+	}
+	return &WithdrawalQueue{db: db, options: options, now: time.Now}, nil
+}
+
+// Receive receives a withdrawal request on the current session, as ReceiveWithdrawalRequest does, and enqueues it.
+// The outcome of the first evaluation of the request is sent back to the requester,
+// that is expected to run RequestQueuedWithdrawal.
+// If the request is approved, the issuer can issue the tokens on the same session.
+func (q *WithdrawalQueue) Receive(context view.Context) (*WithdrawalRequest, *WithdrawalRequestRecord, error) {
+	request, err := ReceiveWithdrawalRequest(context)
+	if err != nil {
+		return nil, nil, err
+	}
+	record, err := q.Enqueue(request, context.Session().Info().Caller)
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := Marshal(outcomeOf(record))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed marshalling withdrawal outcome")
+	}
+	if err := context.Session().SendWithContext(context.Context(), raw); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed sending outcome of withdrawal request [%s]", record.ID)
+	}
+	return request, record, nil
+}
+
+// Enqueue stores the passed request as pending and evaluates it.
+// The requester is the FSC node to notify about the outcome of the request.
+func (q *WithdrawalQueue) Enqueue(request *WithdrawalRequest, requester view.Identity) (*WithdrawalRequestRecord, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed generating withdrawal request id")
+	}
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling withdrawal request")
+	}
+	now := q.now()
+	record := &WithdrawalRequestRecord{
+		ID:        id,
+		Requester: requester,
+		TokenType: request.TokenType,
+		Amount:    request.Amount,
+		Request:   raw,
+		Status:    WithdrawalPending,
+		CreatedAt: now,
+	}
+	if q.options.TTL != 0 {
+		record.ExpiresAt = now.Add(q.options.TTL)
+	}
+	if err := q.db.AddWithdrawalRequest(record); err != nil {
+		return nil, err
+	}
+	logger.Debugf("enqueued withdrawal request [%s] of [%d:%s]", id, request.Amount, request.TokenType)
+	return q.evaluate(id)
+}
+
+// Approve records the approval of the passed approver and evaluates the request again.
+// It fails if the request is not pending.
+// If the request gets decided, the requester is notified of the outcome.
+// The decision is stored even if the requester cannot be notified.
+func (q *WithdrawalQueue) Approve(context view.Context, id string, approver string) (*WithdrawalRequestRecord, error) {
+	record, err := q.approve(id, approver)
+	if err != nil {
+		return nil, err
+	}
+	if record.Status == WithdrawalPending {
+		return record, nil
+	}
+	return record, q.notify(context, record)
+}
+
+// Reject rejects the passed request, if not claimed or issued yet, and sends the reason to the requester.
+// The rejection is stored even if the requester cannot be notified.
+func (q *WithdrawalQueue) Reject(context view.Context, id string, reason string) (*WithdrawalRequestRecord, error) {
+	record, err := q.reject(id, reason)
+	if err != nil {
+		return nil, err
+	}
+	return record, q.notify(context, record)
+}
+
+// Claim claims the passed approved request for issuance and returns it.
+// Only one claim succeeds: the request cannot be claimed again, nor rejected, until it is released with Unclaim.
+// The transaction issuing the tokens must be bound to the request with Bind before it is submitted,
+// and, once the tokens are issued, the request must be marked with Issued.
+func (q *WithdrawalQueue) Claim(id string) (*WithdrawalRequest, *WithdrawalRequestRecord, error) {
+	request, record, err := q.Request(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if record == nil {
+		return nil, nil, errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	ok, err := q.db.SetWithdrawalRequestStatus(id, WithdrawalApproved, WithdrawalIssuing, record.Message, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, errors.Errorf("withdrawal request [%s] cannot be claimed, it is not approved", id)
+	}
+	record.Status = WithdrawalIssuing
+	return request, record, nil
+}
+
+// Bind records that the passed claimed request is issued by the transaction with the passed id.
+// It must be invoked before the transaction is submitted, so that the request can be reconciled with the outcome
+// of the transaction if the issuance fails or is interrupted (see Reconcile).
+func (q *WithdrawalQueue) Bind(id string, txID string) error {
+	record, err := q.db.WithdrawalRequest(id)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	ok, err := q.db.SetWithdrawalRequestStatus(id, WithdrawalIssuing, WithdrawalIssuing, record.Message, txID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("withdrawal request [%s] is not claimed", id)
+	}
+	return nil
+}
+
+// Unclaim releases the claim on the passed request, that is approved again.
+// It must be invoked only if the issuance fails before the transaction issuing the tokens is submitted.
+func (q *WithdrawalQueue) Unclaim(id string) error {
+	record, err := q.db.WithdrawalRequest(id)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	ok, err := q.db.SetWithdrawalRequestStatus(id, WithdrawalIssuing, WithdrawalApproved, record.Message, "")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("withdrawal request [%s] is not claimed", id)
+	}
+	return nil
+}
+
+// Issued marks the passed claimed request as issued by the passed transaction
+func (q *WithdrawalQueue) Issued(id string, txID string) error {
+	ok, err := q.db.SetWithdrawalRequestStatus(id, WithdrawalIssuing, WithdrawalIssued, "", txID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("withdrawal request [%s] is not claimed", id)
+	}
+	return nil
+}
+
+// Issue claims the passed approved request, invokes assemble to get the transaction issuing the tokens,
+// binds the transaction to the request, collects its endorsements, and submits it.
+// The request is marked as issued once the transaction is committed.
+// The claim is released if the transaction cannot be assembled or endorsed, because it has not been submitted.
+// If the submission fails, the transaction might still be committed: the request is released only if
+// the transaction is known to be invalid, otherwise it is left issuing and must be settled later with Reconcile.
+func (q *WithdrawalQueue) Issue(context view.Context, id string, assemble func(request *WithdrawalRequest, record *WithdrawalRequestRecord) (*Transaction, error)) (*Transaction, error) {
+	request, record, err := q.Claim(id)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := assemble(request, record)
+	if err != nil {
+		q.unclaim(id)
+		return nil, errors.WithMessagef(err, "failed issuing withdrawal request [%s]", id)
+	}
+	if err := q.Bind(id, tx.ID()); err != nil {
+		q.unclaim(id)
+		return nil, errors.WithMessagef(err, "failed binding withdrawal request [%s] to [%s]", id, tx.ID())
+	}
+	if _, err := context.RunView(NewCollectEndorsementsView(tx)); err != nil {
+		q.unclaim(id)
+		return nil, errors.WithMessagef(err, "failed collecting endorsements of [%s] for withdrawal request [%s]", tx.ID(), id)
+	}
+	if _, err := context.RunView(NewOrderingAndFinalityView(tx)); err != nil {
+		if _, err2 := q.Reconcile(id); err2 != nil {
+			logger.Errorf("failed reconciling withdrawal request [%s] with [%s]: [%s]", id, tx.ID(), err2)
+		}
+		return tx, errors.WithMessagef(err, "failed submitting [%s] for withdrawal request [%s]", tx.ID(), id)
+	}
+	// the tokens have been issued, the claim is kept even if the request cannot be marked
+	return tx, q.Issued(id, tx.ID())
+}
+
+// Reconcile settles the passed request, left issuing by a failed or interrupted issuance,
+// with the outcome of the transaction bound to it, as known by the ttxdb:
+// - the request is marked as issued if the transaction is confirmed;
+// - the claim is released if the transaction is deleted, or if it has not been submitted,
+// that is, no transaction is bound to the request or the bound transaction has not been approved;
+// - otherwise, the transaction might still be committed and the request is left issuing.
+// Reconcile must not be invoked while the request is being issued. It returns the resulting record.
+func (q *WithdrawalQueue) Reconcile(id string) (*WithdrawalRequestRecord, error) {
+	record, err := q.db.WithdrawalRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	if record.Status != WithdrawalIssuing {
+		return record, nil
+	}
+	release, issued, err := q.outcome(record.TxID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed checking [%s] of withdrawal request [%s]", record.TxID, id)
+	}
+	switch {
+	case issued:
+		err = q.Issued(id, record.TxID)
+	case release:
+		err = q.Unclaim(id)
+	default:
+		logger.Infof("withdrawal request [%s] left issuing, [%s] is still pending", id, record.TxID)
+		return record, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return q.db.WithdrawalRequest(id)
+}
+
+// outcome returns whether the claim on a request bound to the passed transaction can be released,
+// or the request marked as issued
+func (q *WithdrawalQueue) outcome(txID string) (bool, bool, error) {
+	if len(txID) == 0 {
+		return true, false, nil
+	}
+	status, _, err := q.db.GetStatus(txID)
+	if err != nil {
+		return false, false, err
+	}
+	switch status {
+	case Confirmed:
+		return false, true, nil
+	case Deleted:
+		return true, false, nil
+	case Unknown:
+		steps, err := q.db.TransactionSteps(txID)
+		if err != nil {
+			return false, false, err
+		}
+		for _, step := range steps {
+			if step.Step == Approved || step.Step == Broadcast {
+				return false, false, nil
+			}
+		}
+		return true, false, nil
+	default:
+		return false, false, nil
+	}
+}
+
+// unclaim releases the claim on the passed request, logging failures
+func (q *WithdrawalQueue) unclaim(id string) {
+	if err := q.Unclaim(id); err != nil {
+		logger.Errorf("failed releasing the claim on withdrawal request [%s]: [%s]", id, err)
+	}
+}
+
+// Expire marks as expired the pending requests whose expiry has passed, and notifies their requesters.
+// It returns the expired requests.
+func (q *WithdrawalQueue) Expire(context view.Context) ([]*WithdrawalRequestRecord, error) {
+	expired, err := q.expire()
+	if err != nil {
+		return nil, err
+	}
+	var failures []string
+	for _, record := range expired {
+		if err := q.notify(context, record); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	if len(failures) != 0 {
+		return expired, errors.Errorf("failed notifying expired withdrawal requests: [%s]", strings.Join(failures, "; "))
+	}
+	return expired, nil
+}
+
+// Request returns the passed request and its record, nil if not found
+func (q *WithdrawalQueue) Request(id string) (*WithdrawalRequest, *WithdrawalRequestRecord, error) {
+	record, err := q.db.WithdrawalRequest(id)
+	if err != nil || record == nil {
+		return nil, nil, err
+	}
+	request := &WithdrawalRequest{}
+	if err := json.Unmarshal(record.Request, request); err != nil {
+		return nil, nil, errors.Wrapf(err, "failed unmarshalling withdrawal request [%s]", id)
+	}
+	return request, record, nil
+}
+
+// Requests returns the requests with the passed statuses, all of them if none is passed, in the order they have been received
+func (q *WithdrawalQueue) Requests(statuses ...WithdrawalRequestStatus) ([]*WithdrawalRequestRecord, error) {
+	return q.db.WithdrawalRequests(statuses...)
+}
+
+// Approvals returns the approvers of the passed request
+func (q *WithdrawalQueue) Approvals(id string) ([]string, error) {
+	return q.db.WithdrawalApprovals(id)
+}
+
+func (q *WithdrawalQueue) approve(id string, approver string) (*WithdrawalRequestRecord, error) {
+	record, err := q.pending(id)
+	if err != nil {
+		return nil, err
+	}
+	if q.expired(record) {
+		return nil, errors.Errorf("withdrawal request [%s] has expired", id)
+	}
+	if err := q.db.AddWithdrawalApproval(id, approver); err != nil {
+		return nil, err
+	}
+	return q.evaluate(id)
+}
+
+// evaluate runs the policy on the passed request, if pending, and stores the decision
+func (q *WithdrawalQueue) evaluate(id string) (*WithdrawalRequestRecord, error) {
+	request, record, err := q.Request(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	if record.Status != WithdrawalPending {
+		return record, nil
+	}
+	approvals, err := q.db.WithdrawalApprovals(id)
+	if err != nil {
+		return nil, err
+	}
+	decision, reason, err := q.options.Policy.Decide(request, record, approvals)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed evaluating withdrawal request [%s]", id)
+	}
+	var to WithdrawalRequestStatus
+	switch decision {
+	case WithdrawalUndecided:
+		return record, nil
+	case WithdrawalApprove:
+		to = WithdrawalApproved
+	case WithdrawalReject:
+		to = WithdrawalRejected
+	default:
+		return nil, errors.Errorf("invalid decision [%d] on withdrawal request [%s]", decision, id)
+	}
+	if _, err := q.db.SetWithdrawalRequestStatus(id, WithdrawalPending, to, reason, ""); err != nil {
+		return nil, err
+	}
+	// another replica might have decided in the meantime
+	return q.db.WithdrawalRequest(id)
+}
+
+func (q *WithdrawalQueue) reject(id string, reason string) (*WithdrawalRequestRecord, error) {
+	for _, from := range []WithdrawalRequestStatus{WithdrawalPending, WithdrawalApproved} {
+		ok, err := q.db.SetWithdrawalRequestStatus(id, from, WithdrawalRejected, reason, "")
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return q.db.WithdrawalRequest(id)
+		}
+	}
+	record, err := q.db.WithdrawalRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	return nil, errors.Errorf("withdrawal request [%s] cannot be rejected, it is [%s]", id, WithdrawalRequestStatusMessage[record.Status])
+}
+
+func (q *WithdrawalQueue) expire() ([]*WithdrawalRequestRecord, error) {
+	pending, err := q.db.WithdrawalRequests(WithdrawalPending)
+	if err != nil {
+		return nil, err
+	}
+	var expired []*WithdrawalRequestRecord
+	for _, record := range pending {
+		if !q.expired(record) {
+			continue
+		}
+		ok, err := q.db.SetWithdrawalRequestStatus(record.ID, WithdrawalPending, WithdrawalExpired, defaultExpiryReason, "")
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		record.Status = WithdrawalExpired
+		record.Message = defaultExpiryReason
+		expired = append(expired, record)
+	}
+	return expired, nil
+}
+
+func (q *WithdrawalQueue) pending(id string) (*WithdrawalRequestRecord, error) {
+	record, err := q.db.WithdrawalRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if record == nil {
+		return nil, errors.Errorf("withdrawal request [%s] not found", id)
+	}
+	if record.Status != WithdrawalPending {
+		return nil, errors.Errorf("withdrawal request [%s] is not pending, it is [%s]", id, WithdrawalRequestStatusMessage[record.Status])
+	}
+	return record, nil
+}
+
+func (q *WithdrawalQueue) expired(record *WithdrawalRequestRecord) bool {
+	return !record.ExpiresAt.IsZero() && !q.now().Before(record.ExpiresAt)
+}
+
+func (q *WithdrawalQueue) notify(context view.Context, record *WithdrawalRequestRecord) error {
+	if _, err := context.RunView(NewNotifyWithdrawalOutcomeView(record.Requester, outcomeOf(record))); err != nil {
+		return errors.WithMessagef(err, "failed notifying outcome of withdrawal request [%s] to [%s]", record.ID, record.Requester)
+	}
+	return nil
+}
+
+func outcomeOf(record *WithdrawalRequestRecord) *WithdrawalOutcome {
+	return &WithdrawalOutcome{ID: record.ID, Status: record.Status, Message: record.Message}
+}
+
+// NotifyWithdrawalOutcomeView sends the outcome of a withdrawal request to its requester.
+// The requester must register a responder of this view that calls ReceiveWithdrawalOutcome.
+type NotifyWithdrawalOutcomeView struct {
+	Requester view.Identity
+	Outcome   *WithdrawalOutcome
+}
+
+func NewNotifyWithdrawalOutcomeView(requester view.Identity, outcome *WithdrawalOutcome) *NotifyWithdrawalOutcomeView {
+	return &NotifyWithdrawalOutcomeView{Requester: requester, Outcome: outcome}
+}
+
+func (n *NotifyWithdrawalOutcomeView) Call(context view.Context) (interface{}, error) {
+	session, err := context.GetSession(n, n.Requester)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting session to [%s]", n.Requester)
+	}
+	defer session.Close()
+	raw, err := Marshal(n.Outcome)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed marshalling withdrawal outcome")
+	}
+	if err := session.SendWithContext(context.Context(), raw); err != nil {
+		return nil, errors.Wrap(err, "failed sending withdrawal outcome")
+	}
+	// wait for the acknowledgement
+	if _, err := ReadMessage(session, time.Minute); err != nil {
+		return nil, errors.Wrap(err, "failed reading acknowledgement")
+	}
+	return nil, nil
+}
+
+// ReceiveWithdrawalOutcome receives the outcome of a withdrawal request sent by NotifyWithdrawalOutcomeView,
+// and acknowledges it
+func ReceiveWithdrawalOutcome(context view.Context) (*WithdrawalOutcome, error) {
+	session := context.Session()
+	outcome, err := readWithdrawalOutcome(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.SendWithContext(context.Context(), []byte(outcome.ID)); err != nil {
+		return nil, errors.Wrap(err, "failed sending acknowledgement")
+	}
+	return outcome, nil
+}
+
+// RequestQueuedWithdrawal runs RequestWithdrawalView with the passed arguments,
+// for an issuer that enqueues the request with WithdrawalQueue.Receive.
+// It returns the recipient identity, the session with the issuer, and the outcome of the first evaluation of the request.
+// If the request is approved, the issuer can issue the tokens on the returned session.
+func RequestQueuedWithdrawal(context view.Context, issuer view.Identity, wallet string, tokenType string, amount uint64, notAnonymous bool, recipientData *RecipientData, opts ...token.ServiceOption) (view.Identity, view.Session, *WithdrawalOutcome, error) {
+	id, session, err := RequestWithdrawalForRecipient(context, issuer, wallet, tokenType, amount, notAnonymous, recipientData, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	outcome, err := readWithdrawalOutcome(session)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return id, session, outcome, nil
+}
+
+func readWithdrawalOutcome(session view.Session) (*WithdrawalOutcome, error) {
+	msg, err := ReadMessage(session, time.Minute)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed reading withdrawal outcome")
+	}
+	outcome := &WithdrawalOutcome{}
+	if err := Unmarshal(msg, outcome); err != nil {
+		return nil, errors.Wrap(err, "failed unmarshalling withdrawal outcome")
+	}
+	return outcome, nil
+}
@@ -0,0 +1,265 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithdrawalQueueManualApproval(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	q, err := newWithdrawalQueue(db)
+	assert.NoError(t, err)
+
+	record, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 10}, []byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalPending, record.Status)
+	assert.Equal(t, []byte("alice"), []byte(record.Requester))
+	assert.True(t, record.ExpiresAt.IsZero())
+
+	request, _, err := q.Request(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), request.Amount)
+
+	// only approved requests can be issued
+	assert.Error(t, q.Issued(record.ID, "tx1"))
+
+	record, err = q.approve(record.ID, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalApproved, record.Status)
+	assert.Equal(t, "approved by bob", record.Message)
+	_, err = q.approve(record.ID, "charlie")
+	assert.Error(t, err)
+
+	// the request must be claimed first
+	assert.Error(t, q.Issued(record.ID, "tx1"))
+	_, claimed, err := q.Claim(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalIssuing, claimed.Status)
+	assert.NoError(t, q.Issued(record.ID, "tx1"))
+	_, record, err = q.Request(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalIssued, record.Status)
+	assert.Equal(t, "tx1", record.TxID)
+
+	// issued requests cannot be rejected
+	_, err = q.reject(record.ID, "too late")
+	assert.Error(t, err)
+	_, err = q.approve("unknown", "bob")
+	assert.Error(t, err)
+}
+
+func TestWithdrawalQueueMultiApproval(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	q, err := newWithdrawalQueue(db, WithWithdrawalApprovalPolicy(MultiApproval(2, "bob", "charlie", "dave")))
+	assert.NoError(t, err)
+	record, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 10}, []byte("alice"))
+	assert.NoError(t, err)
+
+	record, err = q.approve(record.ID, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalPending, record.Status)
+	// approving twice, or by an unknown approver, does not count
+	record, err = q.approve(record.ID, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalPending, record.Status)
+	record, err = q.approve(record.ID, "eve")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalPending, record.Status)
+
+	record, err = q.approve(record.ID, "dave")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalApproved, record.Status)
+	assert.Equal(t, "approved by bob, dave", record.Message)
+	approvals, err := q.Approvals(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "eve", "dave"}, approvals)
+
+	// approved requests can still be rejected before issuance
+	record, err = q.reject(record.ID, "deposit reversed")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalRejected, record.Status)
+	assert.Equal(t, "deposit reversed", record.Message)
+}
+
+func TestWithdrawalQueueAutomaticApproval(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	policy := AutomaticApproval(
+		ManualApproval(),
+		WithdrawalRule{TokenType: "GOLD", Decision: WithdrawalReject, Reason: "gold is not issued"},
+		WithdrawalRule{TokenType: "USD", MaxAmount: 100, Decision: WithdrawalApprove, Reason: "small amount"},
+	)
+	q, err := newWithdrawalQueue(db, WithWithdrawalApprovalPolicy(policy))
+	assert.NoError(t, err)
+
+	record, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 100}, []byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalApproved, record.Status)
+	assert.Equal(t, "small amount", record.Message)
+
+	record, err = q.Enqueue(&WithdrawalRequest{TokenType: "GOLD", Amount: 1}, []byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalRejected, record.Status)
+	assert.Equal(t, "gold is not issued", record.Message)
+
+	// no rule applies, the fallback decides
+	record, err = q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 101}, []byte("alice"))
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalPending, record.Status)
+	record, err = q.approve(record.ID, "bob")
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalApproved, record.Status)
+}
+
+func TestWithdrawalQueueExpiry(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	_, err := newWithdrawalQueue(db, WithWithdrawalTTL(-time.Second))
+	assert.Error(t, err)
+
+	q, err := newWithdrawalQueue(db, WithWithdrawalTTL(time.Hour))
+	assert.NoError(t, err)
+	now := time.Now()
+	q.now = func() time.Time { return now }
+
+	first, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 10}, []byte("alice"))
+	assert.NoError(t, err)
+	assert.True(t, now.Add(time.Hour).Equal(first.ExpiresAt))
+	now = now.Add(30 * time.Minute)
+	second, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 20}, []byte("bob"))
+	assert.NoError(t, err)
+
+	expired, err := q.expire()
+	assert.NoError(t, err)
+	assert.Len(t, expired, 0)
+
+	now = now.Add(30 * time.Minute)
+	_, err = q.approve(first.ID, "charlie")
+	assert.Error(t, err)
+	expired, err = q.expire()
+	assert.NoError(t, err)
+	assert.Len(t, expired, 1)
+	assert.Equal(t, first.ID, expired[0].ID)
+	assert.Equal(t, WithdrawalExpired, expired[0].Status)
+
+	pending, err := q.Requests(WithdrawalPending)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, second.ID, pending[0].ID)
+}
+
+func TestWithdrawalQueueIssue(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	q, err := newWithdrawalQueue(db)
+	assert.NoError(t, err)
+	record, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 10}, []byte("alice"))
+	assert.NoError(t, err)
+
+	// only approved requests can be claimed
+	_, _, err = q.Claim(record.ID)
+	assert.EqualError(t, err, fmt.Sprintf("withdrawal request [%s] cannot be claimed, it is not approved", record.ID))
+	_, err = q.approve(record.ID, "bob")
+	assert.NoError(t, err)
+
+	// a claimed request cannot be claimed again, nor rejected
+	request, claimed, err := q.Claim(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), request.Amount)
+	assert.Equal(t, WithdrawalIssuing, claimed.Status)
+	_, _, err = q.Claim(record.ID)
+	assert.Error(t, err)
+	_, err = q.reject(record.ID, "deposit reversed")
+	assert.Error(t, err)
+	assert.NoError(t, q.Unclaim(record.ID))
+	assert.Error(t, q.Unclaim(record.ID))
+	assert.Error(t, q.Bind(record.ID, "tx0"))
+	_, record, err = q.Request(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalApproved, record.Status)
+	assert.Equal(t, "approved by bob", record.Message)
+
+	// concurrent issuers claim once
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	var claims int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := q.Claim(record.ID); err == nil {
+				lock.Lock()
+				defer lock.Unlock()
+				claims++
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, claims)
+	assert.NoError(t, q.Issued(record.ID, "tx1"))
+	_, record, err = q.Request(record.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, WithdrawalIssued, record.Status)
+	assert.Equal(t, "tx1", record.TxID)
+}
+
+func TestWithdrawalQueueReconcile(t *testing.T) {
+	db, raw := newTestTTXDB(t)
+	q, err := newWithdrawalQueue(db)
+	assert.NoError(t, err)
+	claim := func(txID string) string {
+		record, err := q.Enqueue(&WithdrawalRequest{TokenType: "USD", Amount: 10}, []byte("alice"))
+		assert.NoError(t, err)
+		_, err = q.approve(record.ID, "bob")
+		assert.NoError(t, err)
+		_, _, err = q.Claim(record.ID)
+		assert.NoError(t, err)
+		if len(txID) != 0 {
+			assert.NoError(t, q.Bind(record.ID, txID))
+		}
+		return record.ID
+	}
+	reconcile := func(id string, status WithdrawalRequestStatus, txID string) {
+		record, err := q.Reconcile(id)
+		assert.NoError(t, err)
+		assert.Equal(t, status, record.Status)
+		assert.Equal(t, txID, record.TxID)
+	}
+
+	// no transaction has been bound, nothing has been submitted
+	reconcile(claim(""), WithdrawalApproved, "")
+
+	// the bound transaction has not been approved, it has not been submitted
+	id := claim("assembled")
+	assert.NoError(t, db.AddTransactionStep("assembled", Assembled, nil, ""))
+	reconcile(id, WithdrawalApproved, "")
+
+	// the bound transaction might have been submitted
+	id = claim("approved")
+	assert.NoError(t, db.AddTransactionStep("approved", Approved, nil, ""))
+	reconcile(id, WithdrawalIssuing, "approved")
+	id = claim("broadcast")
+	addRequest(t, raw, "broadcast")
+	assert.NoError(t, db.AddTransactionStep("broadcast", Broadcast, nil, ""))
+	reconcile(id, WithdrawalIssuing, "broadcast")
+
+	// the bound transaction is known invalid
+	setStatus(t, db, "broadcast", Deleted, "invalid")
+	reconcile(id, WithdrawalApproved, "")
+
+	// the bound transaction has been committed
+	id = claim("committed")
+	addRequest(t, raw, "committed")
+	setStatus(t, db, "committed", Confirmed, "")
+	reconcile(id, WithdrawalIssued, "committed")
+
+	// settled requests are left untouched
+	reconcile(id, WithdrawalIssued, "committed")
+}
@@ -0,0 +1,347 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"context"
+	"time"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/services/ttxdb"
+	"github.com/pkg/errors"
+)
+
+// TransactionStep is a step of the lifecycle of a token transaction assembled by this node
+type TransactionStep = ttxdb.TransactionStep
+
+const (
+	// Assembled is the step of a transaction whose token request is complete and ready to be signed
+	Assembled = ttxdb.Assembled
+	// SignaturesCollected is the step of a transaction whose token request carries all the required signatures
+	SignaturesCollected = ttxdb.SignaturesCollected
+	// Audited is the step of a transaction that has been signed by the auditor
+	Audited = ttxdb.Audited
+	// Approved is the step of a transaction that has been approved by the backend and distributed to all the parties
+	Approved = ttxdb.Approved
+	// Broadcast is the step of a transaction that has been submitted for ordering
+	Broadcast = ttxdb.Broadcast
+	// Distributed is the step of a transaction assembled by another node and distributed by it to this node
+	Distributed = ttxdb.Distributed
+	// Final is the step of a transaction whose finality has been observed
+	Final = ttxdb.Final
+	// Aborted is the step of a transaction that has been abandoned before being broadcast
+	Aborted = ttxdb.Aborted
+)
+
+// ApprovalSkippedMessage is the message of the Approved step of a transaction whose approval has been skipped
+// with WithSkipApproval. The application is in charge of approving and broadcasting such a transaction.
+const ApprovalSkippedMessage = "approval skipped"
+
+// recordStep stores in the ttxdb that the passed transaction reached the passed step.
+// If withPayload is true, the serialized transaction is stored as well, so that the transaction can be resumed from that step.
+func recordStep(sp token.ServiceProvider, tx *Transaction, step TransactionStep, withPayload bool, message string) error {
+	var payload []byte
+	if withPayload {
+		var err error
+		payload, err = tx.Bytes()
+		if err != nil {
+			return errors.WithMessagef(err, "failed marshalling transaction [%s]", tx.ID())
+		}
+	}
+	return addStep(sp, tx.TMSID(), tx.ID(), step, payload, message)
+}
+
+// addStep stores in the ttxdb of the passed TMS that the passed transaction reached the passed step
+func addStep(sp token.ServiceProvider, tmsID token.TMSID, txID string, step TransactionStep, payload []byte, message string) error {
+	db, err := ttxdb.GetByTMSId(sp, tmsID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting ttxdb for [%s]", tmsID)
+	}
+	return db.AddTransactionStep(txID, step, payload, message)
+}
+
+// recordFinalStep records the Final step for the passed transaction, if its lifecycle has been recorded
+// and its status is either confirmed or deleted.
+func recordFinalStep(db *ttxdb.DB, txID string) {
+	status, _, err := db.GetStatus(txID)
+	if err != nil || (status != ttxdb.Confirmed && status != ttxdb.Deleted) {
+		return
+	}
+	steps, err := db.TransactionSteps(txID)
+	if err != nil {
+		logger.Warnf("failed getting steps of [%s]: [%s]", txID, err)
+		return
+	}
+	if len(steps) == 0 || steps[len(steps)-1].Step >= Final {
+		return
+	}
+	if err := db.AddTransactionStep(txID, Final, nil, TxStatusMessage[status]); err != nil {
+		logger.Warnf("failed recording final step of [%s]: [%s]", txID, err)
+	}
+}
+
+const (
+	// ResumeTimeoutKey is the configuration key, relative to the TMS, of the time spent resuming
+	// the in-flight transactions of the TMS at startup
+	ResumeTimeoutKey = "services.ttx.resume.timeout"
+	// DefaultResumeTimeout is the resume timeout of the TMSs that do not configure it
+	DefaultResumeTimeout = 5 * time.Minute
+)
+
+// GetResumeTimeout returns the resume timeout configured for the passed TMS, DefaultResumeTimeout if not set
+func GetResumeTimeout(tms *token.ManagementService) (time.Duration, error) {
+	if !tms.Configuration().IsSet(ResumeTimeoutKey) {
+		return DefaultResumeTimeout, nil
+	}
+	var v string
+	if err := tms.Configuration().UnmarshalKey(ResumeTimeoutKey, &v); err != nil {
+		return 0, errors.WithMessagef(err, "failed loading resume timeout for [%s]", tms.ID())
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid resume timeout [%s] for [%s]", v, tms.ID())
+	}
+	if d <= 0 {
+		return 0, errors.Errorf("invalid resume timeout [%s] for [%s], it must be positive", v, tms.ID())
+	}
+	return d, nil
+}
+
+// ResumeReport describes the outcome of resuming the in-flight transactions of a TMS
+type ResumeReport struct {
+	// Completed lists the approved transactions that have been broadcast
+	Completed []string
+	// Aborted lists the transactions that have been aborted, because they were not approved yet
+	Aborted []string
+	// Pending lists the transactions that have been already broadcast and whose finality is still awaited,
+	// and those whose approval has been skipped and is left to the application
+	Pending []string
+	// Failed maps the transactions that could not be resumed to the reason
+	Failed map[string]error
+}
+
+// Resume completes or aborts the transactions of the passed TMS that were in-flight when this node stopped.
+// Transactions whose lifecycle has been recorded by the current run of this node are ignored.
+// For each transaction, the last recorded step decides what happens:
+// - Assembled, SignaturesCollected, Audited: the transaction is aborted with CancelView. The tokens it locked are released,
+// its status is set to Deleted in the local databases that know it, and the parties and the auditor are told to do the same.
+// If the transaction has not been stored, because recorded by a previous version, it is aborted only locally;
+// - Approved: the approved transaction is broadcast, its records are stored in the ttxdb if missing.
+// If the approval has been skipped, the transaction is left to the application and reported as pending;
+// - Broadcast: nothing to do, the finality listener is registered by RestoreTMS.
+func (m *Manager) Resume(ctx context.Context, tmsID token.TMSID) (*ResumeReport, error) {
+	db, err := m.DB(tmsID)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to get db for [%s]", tmsID)
+	}
+	tms, err := m.tmsProvider.GetManagementService(token.WithTMSID(tmsID))
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting TMS [%s]", tmsID)
+	}
+	r := &resumer{m: m, db: db, tms: tms}
+	report, err := r.resume(ctx)
+	if err != nil {
+		return report, errors.WithMessagef(err, "failed to resume in-flight transactions for [%s]", tmsID)
+	}
+	logger.Infof("resumed transactions for [%s]: completed [%d], aborted [%d], pending [%d], failed [%d]",
+		tmsID, len(report.Completed), len(report.Aborted), len(report.Pending), len(report.Failed))
+	return report, nil
+}
+
+// resumeAction is what Resume does with an in-flight transaction
+type resumeAction int
+
+const (
+	// resumeSkip leaves the transaction alone, its lifecycle has been recorded by the current run
+	resumeSkip resumeAction = iota
+	// resumePending reports the transaction as pending
+	resumePending
+	// resumeComplete broadcasts the approved transaction
+	resumeComplete
+	// resumeAbort aborts the transaction
+	resumeAbort
+)
+
+// resumeActionOf returns what Resume does with the transaction whose last recorded step is the passed one
+func resumeActionOf(record *ttxdb.TransactionStepRecord, startedAt time.Time) resumeAction {
+	if !record.Timestamp.Before(startedAt) {
+		return resumeSkip
+	}
+	switch record.Step {
+	case Broadcast:
+		return resumePending
+	case Approved:
+		if record.Message == ApprovalSkippedMessage {
+			return resumePending
+		}
+		return resumeComplete
+	default:
+		return resumeAbort
+	}
+}
+
+// approvedPayload returns the approved transaction stored with the passed steps, if any
+func approvedPayload(steps []*ttxdb.TransactionStepRecord) []byte {
+	var raw []byte
+	for _, step := range steps {
+		if step.Step == Approved && len(step.Payload) != 0 {
+			raw = step.Payload
+		}
+	}
+	return raw
+}
+
+// assembledPayload returns the assembled transaction, and the identity of its auditor, stored with the passed steps, if any
+func assembledPayload(steps []*ttxdb.TransactionStepRecord) ([]byte, []byte) {
+	var raw []byte
+	var auditor []byte
+	for _, step := range steps {
+		switch step.Step {
+		case Assembled:
+			raw = step.Payload
+		case SignaturesCollected:
+			auditor = step.Payload
+		}
+	}
+	return raw, auditor
+}
+
+// resumer completes or aborts the in-flight transactions of a TMS recorded before the current run of the manager
+type resumer struct {
+	m   *Manager
+	db  *DB
+	tms *token.ManagementService
+}
+
+func (r *resumer) resume(ctx context.Context) (*ResumeReport, error) {
+	records, err := r.db.ttxDB.InFlightTransactions()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get in-flight transactions")
+	}
+	report := &ResumeReport{Failed: map[string]error{}}
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return report, errors.WithMessage(err, "resume interrupted")
+		}
+		action := resumeActionOf(record, r.m.startedAt)
+		if action == resumeSkip {
+			continue
+		}
+		logger.Infof("resume transaction [%s] at step [%s]", record.TxID, ttxdb.TransactionStepMessage[record.Step])
+		switch action {
+		case resumePending:
+			report.Pending = append(report.Pending, record.TxID)
+		case resumeComplete:
+			if err := r.complete(ctx, record.TxID); err != nil {
+				logger.Errorf("failed completing transaction [%s]: [%s]", record.TxID, err)
+				report.Failed[record.TxID] = err
+				continue
+			}
+			report.Completed = append(report.Completed, record.TxID)
+		case resumeAbort:
+			reason := "node stopped at step " + ttxdb.TransactionStepMessage[record.Step]
+			if err := r.abort(ctx, record.TxID, reason); err != nil {
+				logger.Errorf("failed aborting transaction [%s]: [%s]", record.TxID, err)
+				report.Failed[record.TxID] = err
+				continue
+			}
+			report.Aborted = append(report.Aborted, record.TxID)
+		}
+	}
+	return report, nil
+}
+
+// complete broadcasts the approved version of the passed transaction, storing its records in the ttxdb if missing
+func (r *resumer) complete(ctx context.Context, txID string) error {
+	steps, err := r.db.ttxDB.TransactionSteps(txID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting steps of [%s]", txID)
+	}
+	raw := approvedPayload(steps)
+	if len(raw) == 0 {
+		return errors.Errorf("no approved transaction stored for [%s]", txID)
+	}
+	tx, err := r.restore(txID, raw)
+	if err != nil {
+		return err
+	}
+	request, err := r.db.GetTokenRequest(txID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting token request for [%s]", txID)
+	}
+	if len(request) == 0 {
+		if err := r.db.Append(tx); err != nil {
+			return errors.WithMessagef(err, "failed storing records for [%s]", txID)
+		}
+	}
+	nw, err := r.m.networkProvider.GetNetwork(tx.Network(), tx.Channel())
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting network [%s:%s]", tx.Network(), tx.Channel())
+	}
+	if err := nw.Broadcast(ctx, tx.Payload.Envelope); err != nil {
+		return errors.WithMessagef(err, "failed to broadcast token transaction [%s]", txID)
+	}
+	return r.db.ttxDB.AddTransactionStep(txID, Broadcast, nil, "resumed")
+}
+
+// abort cancels the passed transaction with CancelView, if it has been stored.
+// Otherwise, it releases the tokens locked by the transaction and marks it as deleted, locally only.
+func (r *resumer) abort(ctx context.Context, txID string, reason string) error {
+	steps, err := r.db.ttxDB.TransactionSteps(txID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting steps of [%s]", txID)
+	}
+	raw, auditor := assembledPayload(steps)
+	if len(raw) != 0 {
+		tx, err := r.restore(txID, raw)
+		if err != nil {
+			return err
+		}
+		tx.Opts = &TxOptions{Auditor: auditor}
+		_, err = r.m.viewManager.InitiateView(NewCancelView(tx, reason), ctx)
+		return err
+	}
+
+	sm, err := r.tms.SelectorManager()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting selector manager for [%s]", r.tms.ID())
+	}
+	if err := sm.Unlock(txID); err != nil {
+		return errors.WithMessagef(err, "failed releasing tokens locked by [%s]", txID)
+	}
+	request, err := r.db.GetTokenRequest(txID)
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting token request for [%s]", txID)
+	}
+	if len(request) != 0 {
+		if err := r.db.SetStatus(ctx, txID, Deleted, reason); err != nil {
+			return errors.WithMessagef(err, "failed deleting [%s]", txID)
+		}
+	}
+	return r.db.ttxDB.AddTransactionStep(txID, Aborted, nil, reason)
+}
+
+// restore rebuilds the transaction with the passed id from its serialized form
+func (r *resumer) restore(txID string, raw []byte) (*Transaction, error) {
+	tx := &Transaction{
+		Payload: &Payload{
+			Transient:    map[string][]byte{},
+			TokenRequest: token.NewRequest(nil, ""),
+		},
+		TMS:             r.tms,
+		NetworkProvider: r.m.networkProvider.GetNetwork,
+		Context:         context.Background(),
+	}
+	if err := unmarshal(r.m.networkProvider.GetNetwork, tx.Payload, raw); err != nil {
+		return nil, errors.WithMessagef(err, "failed unmarshalling transaction [%s]", txID)
+	}
+	tx.TokenRequest.SetTokenService(r.tms)
+	if tx.ID() != txID || tx.ID() != tx.TokenRequest.ID() {
+		return nil, errors.Errorf("invalid stored transaction, transaction ids do not match [%s][%s][%s]", txID, tx.ID(), tx.TokenRequest.ID())
+	}
+	return tx, nil
+}
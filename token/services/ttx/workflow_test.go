@@ -0,0 +1,81 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package ttx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeActions(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	add := func(txID string, step TransactionStep, payload []byte, message string) {
+		assert.NoError(t, db.AddTransactionStep(txID, step, payload, message))
+	}
+	// not approved yet, aborted
+	add("assembled", Assembled, nil, "")
+	add("audited", Assembled, nil, "")
+	add("audited", SignaturesCollected, nil, "")
+	add("audited", Audited, nil, "")
+	// approved, broadcast with the stored payload
+	add("approved", Assembled, nil, "")
+	add("approved", Approved, []byte("approved tx"), "")
+	// approval skipped, left to the application
+	add("skipped", Approved, []byte("skipped tx"), ApprovalSkippedMessage)
+	// already broadcast, left to the finality listener
+	add("broadcast", Approved, []byte("broadcast tx"), "")
+	add("broadcast", Broadcast, nil, "")
+	// final, not in-flight
+	add("final", Broadcast, nil, "")
+	add("final", Final, nil, "")
+	time.Sleep(10 * time.Millisecond)
+	startedAt := time.Now()
+	// recorded by the current run, ignored
+	add("current", Assembled, nil, "")
+
+	records, err := db.InFlightTransactions()
+	assert.NoError(t, err)
+	actions := map[string]resumeAction{}
+	for _, record := range records {
+		actions[record.TxID] = resumeActionOf(record, startedAt)
+	}
+	assert.Equal(t, map[string]resumeAction{
+		"assembled": resumeAbort,
+		"audited":   resumeAbort,
+		"approved":  resumeComplete,
+		"skipped":   resumePending,
+		"broadcast": resumePending,
+		"current":   resumeSkip,
+	}, actions)
+}
+
+func TestResumePayloads(t *testing.T) {
+	db, _ := newTestTTXDB(t)
+	assert.NoError(t, db.AddTransactionStep("tx1", Assembled, []byte("assembled tx"), ""))
+	assert.NoError(t, db.AddTransactionStep("tx1", SignaturesCollected, []byte("auditor"), ""))
+	assert.NoError(t, db.AddTransactionStep("tx1", Audited, nil, ""))
+	assert.NoError(t, db.AddTransactionStep("tx1", Approved, []byte("approved tx"), ""))
+
+	steps, err := db.TransactionSteps("tx1")
+	assert.NoError(t, err)
+	raw, auditor := assembledPayload(steps)
+	assert.Equal(t, []byte("assembled tx"), raw)
+	assert.Equal(t, []byte("auditor"), auditor)
+	assert.Equal(t, []byte("approved tx"), approvedPayload(steps))
+
+	// a transaction recorded by a previous version has no payload, it can only be aborted locally
+	assert.NoError(t, db.AddTransactionStep("tx2", Assembled, nil, ""))
+	assert.NoError(t, db.AddTransactionStep("tx2", Approved, nil, ""))
+	steps, err = db.TransactionSteps("tx2")
+	assert.NoError(t, err)
+	raw, auditor = assembledPayload(steps)
+	assert.Empty(t, raw)
+	assert.Empty(t, auditor)
+	assert.Empty(t, approvedPayload(steps))
+}
@@ -65,6 +65,61 @@ const (
 // TxStatusMessage maps TxStatus to string
 var TxStatusMessage = driver.TxStatusMessage
 
+// TransactionStep is a step of the lifecycle of a token transaction as seen by this node
+type TransactionStep = driver.TransactionStep
+
+const (
+	// UnknownStep is the step of a transaction whose lifecycle has not been recorded
+	UnknownStep = driver.UnknownStep
+	// Assembled is the step of a transaction whose token request is complete and ready to be signed
+	Assembled = driver.Assembled
+	// SignaturesCollected is the step of a transaction whose token request carries all the required signatures
+	SignaturesCollected = driver.SignaturesCollected
+	// Audited is the step of a transaction that has been signed by the auditor
+	Audited = driver.Audited
+	// Approved is the step of a transaction that has been approved by the backend and distributed to all the parties
+	Approved = driver.Approved
+	// Broadcast is the step of a transaction that has been submitted for ordering
+	Broadcast = driver.Broadcast
+	// Final is the step of a transaction whose finality has been observed
+	Final = driver.Final
+	// Aborted is the step of a transaction that has been abandoned before being broadcast
+	Aborted = driver.Aborted
+	// Distributed is the step of a transaction assembled by another node and distributed by it to this node
+	Distributed = driver.Distributed
+)
+
+// TransactionStepMessage maps TransactionStep to string
+var TransactionStepMessage = driver.TransactionStepMessage
+
+// TransactionStepRecord records that a transaction reached a given step of its lifecycle
+type TransactionStepRecord = driver.TransactionStepRecord
+
+// DistributionResultRecord is the outcome of the distribution of a transaction to a party
+type DistributionResultRecord = driver.DistributionResultRecord
+
+// IdempotencyKeyRecord binds an idempotency key to a transaction
+type IdempotencyKeyRecord = driver.IdempotencyKeyRecord
+
+// WithdrawalRequestStatus is the status of a withdrawal request in the queue of an issuer
+type WithdrawalRequestStatus = driver.WithdrawalRequestStatus
+
+const (
+	WithdrawalUnknown  = driver.WithdrawalUnknown
+	WithdrawalPending  = driver.WithdrawalPending
+	WithdrawalApproved = driver.WithdrawalApproved
+	WithdrawalRejected = driver.WithdrawalRejected
+	WithdrawalExpired  = driver.WithdrawalExpired
+	WithdrawalIssued   = driver.WithdrawalIssued
+	WithdrawalIssuing  = driver.WithdrawalIssuing
+)
+
+// WithdrawalRequestStatusMessage maps WithdrawalRequestStatus to string
+var WithdrawalRequestStatusMessage = driver.WithdrawalRequestStatusMessage
+
+// WithdrawalRequestRecord is a withdrawal request stored in the queue of an issuer
+type WithdrawalRequestRecord = driver.WithdrawalRequestRecord
+
 // ActionType is the type of action performed by a transaction.
 type ActionType = driver.ActionType
 
@@ -182,6 +237,9 @@ type QueryTokenRequestsParams = driver.QueryTokenRequestsParams
 // QueryValidationRecordsParams defines the parameters for querying movements
 type QueryValidationRecordsParams = driver.QueryValidationRecordsParams
 
+// QueryMovementsParams defines the parameters for querying movements
+type QueryMovementsParams = driver.QueryMovementsParams
+
 // Transactions returns an iterators of transaction records filtered by the given params.
 func (d *DB) Transactions(params QueryTransactionsParams) (driver.TransactionIterator, error) {
 	return d.db.QueryTransactions(params)
@@ -201,6 +259,11 @@ func (d *DB) ValidationRecords(params QueryValidationRecordsParams) (*Validation
 	return &ValidationRecordsIterator{it: it}, nil
 }
 
+// Movements returns the movement records filtered by the given params.
+func (d *DB) Movements(params QueryMovementsParams) ([]*MovementRecord, error) {
+	return d.db.QueryMovements(params)
+}
+
 // AppendTransactionRecord appends the transaction records corresponding to the passed token request.
 func (d *DB) AppendTransactionRecord(req *token.Request) error {
 	logger.Debugf("appending new transaction record... [%s]", req.Anchor)
@@ -249,6 +312,12 @@ func (d *DB) AppendTransactionRecord(req *token.Request) error {
 		return errors.WithMessagef(err, "committing tx for txid [%s] failed", record.Anchor)
 	}
 
+	// notify the listeners that the transaction is pending
+	d.Notify(common.StatusEvent{
+		Ctx:            context.Background(),
+		TxID:           record.Anchor,
+		ValidationCode: driver.Pending,
+	})
 	logger.Debugf("appending transaction record new completed without errors")
 	return nil
 }
@@ -262,9 +331,10 @@ func (d *DB) SetStatus(ctx context.Context, txID string, status driver.TxStatus,
 
 	// notify the listeners
 	d.Notify(common.StatusEvent{
-		Ctx:            ctx,
-		TxID:           txID,
-		ValidationCode: status,
+		Ctx:               ctx,
+		TxID:              txID,
+		ValidationCode:    status,
+		ValidationMessage: message,
 	})
 	logger.Debugf("set status [%s][%s] done", txID, driver.TxStatusMessage[status])
 	return nil
@@ -301,6 +371,152 @@ func (d *DB) GetTransactionEndorsementAcks(txID string) (map[string][]byte, erro
 	return d.db.GetTransactionEndorsementAcks(txID)
 }
 
+// AddTransactionDistributionResult records the outcome of the distribution of a transaction to a party
+func (d *DB) AddTransactionDistributionResult(record *DistributionResultRecord) error {
+	if err := d.db.AddTransactionDistributionResult(record); err != nil {
+		return errors.Wrapf(err, "failed storing distribution result of [%s]", record.TxID)
+	}
+	return nil
+}
+
+// TransactionDistributionResults returns the outcomes of the distribution of the given transaction
+func (d *DB) TransactionDistributionResults(txID string) ([]*DistributionResultRecord, error) {
+	return d.db.GetTransactionDistributionResults(txID)
+}
+
+// AddTransactionAttachment stores the passed payload as the attachment of the given transaction with the given name
+func (d *DB) AddTransactionAttachment(txID string, name string, payload []byte) error {
+	if err := d.db.AddTransactionAttachment(txID, name, payload); err != nil {
+		return errors.Wrapf(err, "failed storing attachment [%s] of [%s]", name, txID)
+	}
+	return nil
+}
+
+// TransactionAttachments returns the attachments of the given transaction, indexed by name
+func (d *DB) TransactionAttachments(txID string) (map[string][]byte, error) {
+	return d.db.GetTransactionAttachments(txID)
+}
+
+// AddIdempotencyKey binds the passed idempotency key to the passed transaction, unless the key is already bound.
+// It returns the record the key is bound to.
+// The nonce and the creator are those of the network transaction id, they allow to recreate the same transaction id.
+func (d *DB) AddIdempotencyKey(key string, txID string, nonce []byte, creator []byte) (*IdempotencyKeyRecord, error) {
+	record, err := d.db.AddIdempotencyKey(&IdempotencyKeyRecord{
+		Key:       key,
+		TxID:      txID,
+		Nonce:     nonce,
+		Creator:   creator,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed binding idempotency key [%s] to [%s]", key, txID)
+	}
+	return record, nil
+}
+
+// IdempotencyKey returns the record bound to the passed idempotency key, nil if the key is unknown
+func (d *DB) IdempotencyKey(key string) (*IdempotencyKeyRecord, error) {
+	record, err := d.db.GetIdempotencyKey(key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting idempotency key [%s]", key)
+	}
+	return record, nil
+}
+
+// AddWithdrawalRequest stores the passed withdrawal request
+func (d *DB) AddWithdrawalRequest(record *WithdrawalRequestRecord) error {
+	if err := d.db.AddWithdrawalRequest(record); err != nil {
+		return errors.Wrapf(err, "failed storing withdrawal request [%s]", record.ID)
+	}
+	return nil
+}
+
+// WithdrawalRequest returns the withdrawal request with the passed id, nil if the request is unknown
+func (d *DB) WithdrawalRequest(id string) (*WithdrawalRequestRecord, error) {
+	record, err := d.db.GetWithdrawalRequest(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting withdrawal request [%s]", id)
+	}
+	return record, nil
+}
+
+// WithdrawalRequests returns the withdrawal requests with the passed statuses, all of them if none is passed
+func (d *DB) WithdrawalRequests(statuses ...WithdrawalRequestStatus) ([]*WithdrawalRequestRecord, error) {
+	records, err := d.db.QueryWithdrawalRequests(statuses...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed querying withdrawal requests")
+	}
+	return records, nil
+}
+
+// SetWithdrawalRequestStatus moves the withdrawal request with the passed id from status `from` to status `to`.
+// It returns false if the request is not in status `from`.
+func (d *DB) SetWithdrawalRequestStatus(id string, from, to WithdrawalRequestStatus, message string, txID string) (bool, error) {
+	logger.Debugf("set status of withdrawal request [%s] from [%s] to [%s]", id, WithdrawalRequestStatusMessage[from], WithdrawalRequestStatusMessage[to])
+	ok, err := d.db.UpdateWithdrawalRequestStatus(id, from, to, message, txID)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed setting status of withdrawal request [%s]", id)
+	}
+	return ok, nil
+}
+
+// AddWithdrawalApproval records that the passed approver approved the withdrawal request with the passed id
+func (d *DB) AddWithdrawalApproval(id string, approver string) error {
+	if err := d.db.AddWithdrawalApproval(id, approver); err != nil {
+		return errors.Wrapf(err, "failed storing approval of withdrawal request [%s]", id)
+	}
+	return nil
+}
+
+// WithdrawalApprovals returns the approvers of the withdrawal request with the passed id
+func (d *DB) WithdrawalApprovals(id string) ([]string, error) {
+	approvers, err := d.db.GetWithdrawalApprovals(id)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed getting approvals of withdrawal request [%s]", id)
+	}
+	return approvers, nil
+}
+
+// AddTransactionStep records that the passed transaction reached the passed step.
+// The payload, if not nil, is the serialized transaction at that step.
+func (d *DB) AddTransactionStep(txID string, step TransactionStep, payload []byte, message string) error {
+	logger.Debugf("add transaction step [%s][%s]", txID, TransactionStepMessage[step])
+	if err := d.db.AddTransactionStep(&TransactionStepRecord{
+		TxID:      txID,
+		Step:      step,
+		Payload:   payload,
+		Message:   message,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return errors.Wrapf(err, "failed adding step [%s] for [%s]", TransactionStepMessage[step], txID)
+	}
+	return nil
+}
+
+// TransactionSteps returns the steps reached by the passed transaction, in order
+func (d *DB) TransactionSteps(txID string) ([]*TransactionStepRecord, error) {
+	return d.db.GetTransactionSteps(txID)
+}
+
+// InFlightTransactions returns the last step reached by each transaction that is neither final nor aborted
+func (d *DB) InFlightTransactions() ([]*TransactionStepRecord, error) {
+	records, err := d.db.QueryInFlightTransactions()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed querying in-flight transactions")
+	}
+	// a step might have been recorded more than once, keep one record per transaction
+	res := make([]*TransactionStepRecord, 0, len(records))
+	seen := map[string]bool{}
+	for _, record := range records {
+		if seen[record.TxID] {
+			continue
+		}
+		seen[record.TxID] = true
+		res = append(res, record)
+	}
+	return res, nil
+}
+
 // AppendValidationRecord appends the given validation metadata related to the given transaction id
 func (d *DB) AppendValidationRecord(txID string, tokenRequest []byte, meta map[string][]byte, ppHash driver2.PPHash) error {
 	logger.Debugf("appending new validation record... [%s]", txID)
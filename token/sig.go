@@ -41,6 +41,12 @@ func (s *SignatureService) IssuerVerifier(id Identity) (Verifier, error) {
 	return s.deserializer.GetIssuerVerifier(id)
 }
 
+// MatchOwnerIdentity returns nil if the given owner identity matches the given audit information,
+// that is, if the enrollment ID carried by the audit information is the one of the identity
+func (s *SignatureService) MatchOwnerIdentity(id Identity, auditInfo []byte) error {
+	return s.deserializer.MatchOwnerIdentity(id, auditInfo)
+}
+
 // GetSigner returns a signer bound to the given identity
 func (s *SignatureService) GetSigner(id Identity) (Signer, error) {
 	return s.ip.GetSigner(id)
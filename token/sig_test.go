@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver/mock"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -125,3 +126,22 @@ func TestSignatureService_IsMe(t *testing.T) {
 
 	assert.True(t, isMe)
 }
+
+func TestSignatureService_MatchOwnerIdentity(t *testing.T) {
+	deserializer := &mock.Deserializer{}
+	ip := &mock.IdentityProvider{}
+
+	service := &SignatureService{
+		deserializer: deserializer,
+		ip:           ip,
+	}
+
+	deserializer.MatchOwnerIdentityReturns(nil)
+	assert.NoError(t, service.MatchOwnerIdentity([]byte("identity"), []byte("audit info")))
+	id, ai := deserializer.MatchOwnerIdentityArgsForCall(0)
+	assert.Equal(t, Identity("identity"), id)
+	assert.Equal(t, []byte("audit info"), ai)
+
+	deserializer.MatchOwnerIdentityReturns(errors.New("no match"))
+	assert.EqualError(t, service.MatchOwnerIdentity([]byte("identity"), []byte("audit info")), "no match")
+}
@@ -0,0 +1,237 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/pkg/errors"
+)
+
+// SummaryToken is a token spent or created by a token request, as shown in the request's summary
+type SummaryToken struct {
+	// ActionIndex is the index of the action spending or creating the token.
+	// Issue actions come first, then transfer actions.
+	ActionIndex int `json:"action_index"`
+	// EnrollmentID is the enrollment ID of the owner of the token, empty if unknown or if the token is redeemed
+	EnrollmentID string `json:"enrollment_id,omitempty"`
+	// Type is the type of the token
+	Type string `json:"type"`
+	// Quantity is the quantity of the token in decimal format
+	Quantity string `json:"quantity"`
+	// Redeemed is true if the token is an output that redeems the tokens
+	Redeemed bool `json:"redeemed,omitempty"`
+}
+
+// RequestSummary is a canonical, human-readable description of a token request.
+// Two requests with the same actions and metadata have the same summary, byte by byte.
+type RequestSummary struct {
+	// Anchor is the anchor of the request
+	Anchor string `json:"anchor"`
+	// Inputs are the tokens spent by the request, in the order of the actions
+	Inputs []*SummaryToken `json:"inputs,omitempty"`
+	// Outputs are the tokens created by the request, in the order of the actions, fees excluded
+	Outputs []*SummaryToken `json:"outputs,omitempty"`
+	// Fees are the outputs paying the fee collector
+	Fees []*SummaryToken `json:"fees,omitempty"`
+	// MetadataKeys are the keys of the application metadata, sorted
+	MetadataKeys []string `json:"metadata_keys,omitempty"`
+}
+
+// Summary returns the summary of this request.
+// The outputs are checked against the actions, therefore the summary describes what a signature on the request approves.
+// The metadata of the request must not be filtered.
+func (r *Request) Summary() (*RequestSummary, error) {
+	inputs, outputs, err := r.inputsAndOutputs(true, true)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed getting inputs and outputs of request [%s]", r.Anchor)
+	}
+	var keys []string
+	if r.Metadata != nil {
+		for k := range r.Metadata.Application {
+			keys = append(keys, k)
+		}
+	}
+	var feePolicy *driver.FeePolicy
+	if pp := r.TokenService.PublicParametersManager().PublicParameters(); pp != nil {
+		feePolicy = pp.FeePolicy()
+	}
+	return newRequestSummary(r.Anchor, inputs.Inputs(), outputs.Outputs(), keys, feePolicy), nil
+}
+
+// VerifySummary checks that the passed raw summary, as returned by RequestSummary#Bytes, is the summary of this request
+func (r *Request) VerifySummary(raw []byte) error {
+	summary, err := r.Summary()
+	if err != nil {
+		return err
+	}
+	expected, err := summary.Bytes()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(expected, raw) {
+		return errors.Errorf("summary does not match request [%s]", r.Anchor)
+	}
+	return nil
+}
+
+// BindSummary sets the summary hash of the request, so that the signatures on the request commit to its summary too.
+// It must be invoked once the actions and the metadata of the request are complete, before collecting any signature.
+func (r *Request) BindSummary() (*RequestSummary, error) {
+	if r.Actions == nil {
+		return nil, errors.Errorf("request [%s] has no actions", r.Anchor)
+	}
+	summary, err := r.Summary()
+	if err != nil {
+		return nil, err
+	}
+	h, err := summary.Hash()
+	if err != nil {
+		return nil, err
+	}
+	r.Actions.SummaryHash = h
+	return summary, nil
+}
+
+// VerifySummaryHash checks that the summary hash of the request, if set, is the hash of the summary of the request
+func (r *Request) VerifySummaryHash() error {
+	if r.Actions == nil || len(r.Actions.SummaryHash) == 0 {
+		return nil
+	}
+	summary, err := r.Summary()
+	if err != nil {
+		return err
+	}
+	h, err := summary.Hash()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, r.Actions.SummaryHash) {
+		return errors.Errorf("summary hash does not match request [%s]", r.Anchor)
+	}
+	return nil
+}
+
+func newRequestSummary(anchor string, inputs []*Input, outputs []*Output, metadataKeys []string, feePolicy *driver.FeePolicy) *RequestSummary {
+	summary := &RequestSummary{Anchor: anchor}
+	for _, input := range inputs {
+		summary.Inputs = append(summary.Inputs, &SummaryToken{
+			ActionIndex:  input.ActionIndex,
+			EnrollmentID: input.EnrollmentID,
+			Type:         input.Type,
+			Quantity:     quantityToDecimal(input.Quantity),
+		})
+	}
+	for _, output := range outputs {
+		t := &SummaryToken{
+			ActionIndex:  output.ActionIndex,
+			EnrollmentID: output.EnrollmentID,
+			Type:         output.Type,
+			Quantity:     quantityToDecimal(output.Quantity),
+			Redeemed:     output.Owner.IsNone(),
+		}
+		if feePolicy != nil && !t.Redeemed && feePolicy.IsFeeOutput(output.Owner, output.Type) {
+			summary.Fees = append(summary.Fees, t)
+			continue
+		}
+		summary.Outputs = append(summary.Outputs, t)
+	}
+	summary.MetadataKeys = append(summary.MetadataKeys, metadataKeys...)
+	sort.Strings(summary.MetadataKeys)
+	return summary
+}
+
+// Bytes returns the canonical encoding of the summary
+func (s *RequestSummary) Bytes() ([]byte, error) {
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed marshalling summary of request [%s]", s.Anchor)
+	}
+	return raw, nil
+}
+
+// Hash returns the hash of the canonical encoding of the summary, as bound to a request by Request#BindSummary
+func (s *RequestSummary) Hash() ([]byte, error) {
+	raw, err := s.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(raw)
+	return h[:], nil
+}
+
+// VerifyMessage checks that the passed message to sign, the marshalled token request followed by its anchor,
+// commits to this summary. It lets an external signer, that has no access to the request metadata,
+// check that the summary shown to its user is the one the signature approves.
+func (s *RequestSummary) VerifyMessage(message []byte) error {
+	request := &driver.TokenRequest{}
+	anchor, err := asn1.Unmarshal(message, request)
+	if err != nil {
+		return errors.Wrapf(err, "failed unmarshalling the token request of the message to sign")
+	}
+	if string(anchor) != s.Anchor {
+		return errors.Errorf("message to sign is not for request [%s]", s.Anchor)
+	}
+	if len(request.SummaryHash) == 0 {
+		return errors.Errorf("message to sign does not commit to the summary of request [%s]", s.Anchor)
+	}
+	h, err := s.Hash()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(h, request.SummaryHash) {
+		return errors.Errorf("summary does not match the message to sign of request [%s]", s.Anchor)
+	}
+	return nil
+}
+
+// NewRequestSummaryFromBytes unmarshals a summary encoded with RequestSummary#Bytes
+func NewRequestSummaryFromBytes(raw []byte) (*RequestSummary, error) {
+	summary := &RequestSummary{}
+	if err := json.Unmarshal(raw, summary); err != nil {
+		return nil, errors.Wrapf(err, "failed unmarshalling request summary")
+	}
+	return summary, nil
+}
+
+// String returns a description of the summary, one line per token
+func (s *RequestSummary) String() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("request [%s]\n", s.Anchor))
+	for _, input := range s.Inputs {
+		sb.WriteString(fmt.Sprintf("action [%d]: spend [%s] [%s] of [%s]\n", input.ActionIndex, input.Quantity, input.Type, input.EnrollmentID))
+	}
+	for _, output := range s.Outputs {
+		if output.Redeemed {
+			sb.WriteString(fmt.Sprintf("action [%d]: redeem [%s] [%s]\n", output.ActionIndex, output.Quantity, output.Type))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("action [%d]: pay [%s] [%s] to [%s]\n", output.ActionIndex, output.Quantity, output.Type, output.EnrollmentID))
+	}
+	for _, fee := range s.Fees {
+		sb.WriteString(fmt.Sprintf("action [%d]: pay fee [%s] [%s]\n", fee.ActionIndex, fee.Quantity, fee.Type))
+	}
+	if len(s.MetadataKeys) != 0 {
+		sb.WriteString(fmt.Sprintf("metadata %v\n", s.MetadataKeys))
+	}
+	return sb.String()
+}
+
+func quantityToDecimal(q token.Quantity) string {
+	if q == nil {
+		return ""
+	}
+	return q.Decimal()
+}
@@ -0,0 +1,115 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"encoding/asn1"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
+	"github.com/hyperledger-labs/fabric-token-sdk/token/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func summaryQuantity(t *testing.T, v uint64) token.Quantity {
+	q, err := token.UInt64ToQuantity(v, 64)
+	assert.NoError(t, err)
+	return q
+}
+
+func TestRequestSummary(t *testing.T) {
+	collector := Identity("collector")
+	feePolicy := &driver.FeePolicy{Kind: driver.FlatFee, Amount: 1, TokenType: "USD", Collector: collector}
+	inputs := []*Input{
+		{ActionIndex: 0, Owner: Identity("alice"), EnrollmentID: "alice", Type: "USD", Quantity: summaryQuantity(t, 20)},
+	}
+	outputs := []*Output{
+		{ActionIndex: 0, Owner: Identity("bob"), EnrollmentID: "bob", Type: "USD", Quantity: summaryQuantity(t, 10)},
+		{ActionIndex: 0, Owner: Identity("alice"), EnrollmentID: "alice", Type: "USD", Quantity: summaryQuantity(t, 4)},
+		{ActionIndex: 0, Owner: collector, EnrollmentID: "collector", Type: "USD", Quantity: summaryQuantity(t, 1)},
+		{ActionIndex: 0, Type: "USD", Quantity: summaryQuantity(t, 5)},
+	}
+
+	summary := newRequestSummary("tx1", inputs, outputs, []string{"z", "a", "m"}, feePolicy)
+	assert.Equal(t, &RequestSummary{
+		Anchor: "tx1",
+		Inputs: []*SummaryToken{
+			{ActionIndex: 0, EnrollmentID: "alice", Type: "USD", Quantity: "20"},
+		},
+		Outputs: []*SummaryToken{
+			{ActionIndex: 0, EnrollmentID: "bob", Type: "USD", Quantity: "10"},
+			{ActionIndex: 0, EnrollmentID: "alice", Type: "USD", Quantity: "4"},
+			{ActionIndex: 0, Type: "USD", Quantity: "5", Redeemed: true},
+		},
+		Fees: []*SummaryToken{
+			{ActionIndex: 0, EnrollmentID: "collector", Type: "USD", Quantity: "1"},
+		},
+		MetadataKeys: []string{"a", "m", "z"},
+	}, summary)
+	assert.Equal(t, "request [tx1]\n"+
+		"action [0]: spend [20] [USD] of [alice]\n"+
+		"action [0]: pay [10] [USD] to [bob]\n"+
+		"action [0]: pay [4] [USD] to [alice]\n"+
+		"action [0]: redeem [5] [USD]\n"+
+		"action [0]: pay fee [1] [USD]\n"+
+		"metadata [a m z]\n", summary.String())
+
+	// the encoding is canonical: the order of the metadata keys does not matter
+	raw, err := summary.Bytes()
+	assert.NoError(t, err)
+	raw2, err := newRequestSummary("tx1", inputs, outputs, []string{"m", "z", "a"}, feePolicy).Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, raw, raw2)
+
+	summary2, err := NewRequestSummaryFromBytes(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, summary, summary2)
+
+	// without a fee policy, the fee output is an ordinary output
+	summary = newRequestSummary("tx1", inputs, outputs, nil, nil)
+	assert.Len(t, summary.Outputs, 4)
+	assert.Empty(t, summary.Fees)
+	assert.Empty(t, summary.MetadataKeys)
+}
+
+func TestRequestSummaryVerifyMessage(t *testing.T) {
+	summary := &RequestSummary{
+		Anchor:  "tx1",
+		Inputs:  []*SummaryToken{{EnrollmentID: "alice", Type: "USD", Quantity: "10"}},
+		Outputs: []*SummaryToken{{EnrollmentID: "bob", Type: "USD", Quantity: "10"}},
+	}
+	h, err := summary.Hash()
+	assert.NoError(t, err)
+	message := func(summaryHash []byte, anchor string) []byte {
+		raw, err := (&driver.TokenRequest{Transfers: [][]byte{[]byte("transfer")}, SummaryHash: summaryHash}).Bytes()
+		assert.NoError(t, err)
+		return append(raw, []byte(anchor)...)
+	}
+	assert.NoError(t, summary.VerifyMessage(message(h, "tx1")))
+
+	assert.EqualError(t, summary.VerifyMessage(message(nil, "tx1")), "message to sign does not commit to the summary of request [tx1]")
+	assert.EqualError(t, summary.VerifyMessage(message(h, "tx2")), "message to sign is not for request [tx1]")
+	assert.EqualError(t, summary.VerifyMessage(message([]byte("other"), "tx1")), "summary does not match the message to sign of request [tx1]")
+	assert.ErrorContains(t, summary.VerifyMessage([]byte("garbage")), "failed unmarshalling the token request of the message to sign")
+
+	// a summary tampered with does not match
+	summary.Outputs[0].Quantity = "1"
+	assert.EqualError(t, summary.VerifyMessage(message(h, "tx1")), "summary does not match the message to sign of request [tx1]")
+
+	// requests without a summary hash encode as before
+	unbound, err := (&driver.TokenRequest{Transfers: [][]byte{[]byte("transfer")}}).Bytes()
+	assert.NoError(t, err)
+	type legacyTokenRequest struct {
+		Issues            [][]byte
+		Transfers         [][]byte
+		Signatures        [][]byte
+		AuditorSignatures [][]byte
+	}
+	legacy, err := asn1.Marshal(legacyTokenRequest{Transfers: [][]byte{[]byte("transfer")}})
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, unbound)
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/hyperledger-labs/fabric-token-sdk/token/driver"
 	"github.com/hyperledger-labs/fabric-token-sdk/token/services/logging"
+	token2 "github.com/hyperledger-labs/fabric-token-sdk/token/token"
 	"github.com/pkg/errors"
 )
 
@@ -155,6 +156,7 @@ type ManagementService struct {
 	vaultProvider               VaultProvider
 	certificationClientProvider CertificationClientProvider
 	selectorManagerProvider     SelectorManagerProvider
+	transferPolicyProvider      TransferPolicyProvider
 	signatureService            *SignatureService
 	vault                       *Vault
 	logger                      logging.Logger
@@ -259,6 +261,15 @@ func (t *ManagementService) SelectorManager() (SelectorManager, error) {
 	return t.selectorManagerProvider.SelectorManager(t)
 }
 
+// TransferPolicy returns the policy the transfers assembled with this TMS must satisfy.
+// It returns nil if no policy is in place.
+func (t *ManagementService) TransferPolicy() (TransferPolicy, error) {
+	if t.transferPolicyProvider == nil {
+		return nil, nil
+	}
+	return t.transferPolicyProvider.TransferPolicy(t)
+}
+
 // SigService returns the signature service for this TMS
 func (t *ManagementService) SigService() *SignatureService {
 	return t.signatureService
@@ -282,6 +293,12 @@ func (t *ManagementService) Authorization() *Authorization {
 	return &Authorization{Authorization: t.tms.Authorization()}
 }
 
+// DeserializeToken returns the token in the clear stored in the passed ledger output,
+// and its issuer if the output metadata is passed and carries it
+func (t *ManagementService) DeserializeToken(output []byte, outputMetadata []byte) (*token2.Token, Identity, error) {
+	return t.tms.TokensService().DeserializeToken(output, outputMetadata)
+}
+
 func (t *ManagementService) init() error {
 	v, err := t.vaultProvider.Vault(t.network, t.channel, t.namespace)
 	if err != nil {
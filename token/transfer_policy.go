@@ -0,0 +1,58 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// PendingTransfer describes a transfer about to be appended to a request
+type PendingTransfer struct {
+	// Request is the request the transfer will be appended to, it may already contain other actions
+	Request *Request
+	// Wallet is the wallet paying the transfer
+	Wallet *OwnerWallet
+	// Type is the type of the transferred tokens
+	Type string
+	// Values are the amounts paid to the owners, the fee excluded
+	Values []uint64
+	// Owners are the recipients of the payments
+	Owners []Identity
+	// DryRun is true if the transfer is only evaluated, see Request#DryRun.
+	// The policy must not keep track of it.
+	DryRun bool
+}
+
+// TransferPolicy decides whether a wallet is allowed to perform a transfer.
+// The policy is checked before the transfer is assembled, a non-nil error rejects it.
+type TransferPolicy interface {
+	CheckTransfer(ctx context.Context, transfer *PendingTransfer) error
+}
+
+// checkTransferPolicy checks the passed transfer against the transfer policy of the TMS, if any
+func (r *Request) checkTransferPolicy(ctx context.Context, wallet *OwnerWallet, typ string, values []uint64, owners []Identity) error {
+	policy, err := r.TokenService.TransferPolicy()
+	if err != nil {
+		return errors.WithMessagef(err, "failed getting transfer policy")
+	}
+	if policy == nil {
+		return nil
+	}
+	if err := policy.CheckTransfer(ctx, &PendingTransfer{
+		Request: r,
+		Wallet:  wallet,
+		Type:    typ,
+		Values:  values,
+		Owners:  owners,
+		DryRun:  r.dryRun,
+	}); err != nil {
+		return errors.WithMessagef(err, "transfer rejected by the transfer policy")
+	}
+	return nil
+}
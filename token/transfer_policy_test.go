@@ -0,0 +1,61 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package token
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger-labs/fabric-token-sdk/token/driver/mock"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// maxValuePolicy rejects the transfers paying more than max to a single owner
+type maxValuePolicy struct {
+	max       uint64
+	transfers []*PendingTransfer
+}
+
+func (p *maxValuePolicy) TransferPolicy(*ManagementService) (TransferPolicy, error) {
+	return p, nil
+}
+
+func (p *maxValuePolicy) CheckTransfer(_ context.Context, transfer *PendingTransfer) error {
+	p.transfers = append(p.transfers, transfer)
+	for _, v := range transfer.Values {
+		if v > p.max {
+			return errors.Errorf("value [%d] exceeds [%d]", v, p.max)
+		}
+	}
+	return nil
+}
+
+func TestRequest_TransferPolicy(t *testing.T) {
+	request, ts := newBatchRequest(&mock.PublicParameters{})
+	policy := &maxValuePolicy{max: 10}
+	request.TokenService.transferPolicyProvider = policy
+	wallet := &OwnerWallet{w: &batchWallet{}}
+	selector := WithTokenSelector(&batchSelector{})
+
+	_, err := request.Transfer(context.Background(), wallet, "USD", []uint64{10}, []Identity{Identity("bob")}, selector)
+	assert.NoError(t, err)
+	assert.Len(t, policy.transfers, 1)
+	assert.Equal(t, request, policy.transfers[0].Request)
+	assert.Equal(t, wallet, policy.transfers[0].Wallet)
+	assert.Equal(t, "USD", policy.transfers[0].Type)
+	assert.Equal(t, []uint64{10}, policy.transfers[0].Values)
+	assert.Equal(t, []Identity{Identity("bob")}, policy.transfers[0].Owners)
+
+	// rejected transfers are not assembled
+	_, err = request.Transfer(context.Background(), wallet, "USD", []uint64{11}, []Identity{Identity("bob")}, selector)
+	assert.EqualError(t, err, "transfer rejected by the transfer policy: value [11] exceeds [10]")
+	_, err = request.BatchTransfer(context.Background(), wallet, "USD", []uint64{5, 11}, []Identity{Identity("bob"), Identity("charlie")}, 0, selector)
+	assert.EqualError(t, err, "transfer rejected by the transfer policy: value [11] exceeds [10]")
+	assert.Equal(t, 1, ts.TransferCallCount())
+	assert.Len(t, request.Actions.Transfers, 1)
+}